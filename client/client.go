@@ -29,6 +29,7 @@ import (
 	"golang.org/x/net/proxy"
 
 	"github.com/openrport/openrport/client/monitoring"
+	"github.com/openrport/openrport/client/selfupdate"
 	"github.com/openrport/openrport/client/system"
 	"github.com/openrport/openrport/client/updates"
 	chshare "github.com/openrport/openrport/share"
@@ -61,6 +62,8 @@ type Client struct {
 	runningc           chan error
 	connStats          chshare.ConnStats
 	cmdExec            system.CmdExecutor
+	runningCmds        runningCmds
+	reverseTunnels     reverseTunnels
 	systemInfo         system.SysInfo
 	updates            *updates.Updates
 	monitor            *monitoring.Monitor
@@ -68,6 +71,7 @@ type Client struct {
 	serverCapabilities *models.Capabilities
 	filesAPI           files.FileAPI
 	watchdog           *Watchdog
+	selfUpdater        *selfupdate.Updater
 
 	mu sync.RWMutex
 }
@@ -95,6 +99,11 @@ func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, err
 		return nil, fmt.Errorf("failed to create watchdog: %s", err)
 	}
 
+	selfUpdater, err := selfupdate.New(logger.Fork("client-update"), config.ClientUpdateConfig, config.Client.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client updater: %s", err)
+	}
+
 	systemInfo := system.NewSystemInfo(cmdExec)
 	client := &Client{
 		SessionID:          sessionID,
@@ -109,6 +118,7 @@ func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, err
 		ipAddressesFetcher: ipAddresses.NewFetcher(logger, config.Client.IPAPIURL, config.Client.IPRefreshMin),
 		filesAPI:           filesAPI,
 		watchdog:           watchdog,
+		selfUpdater:        selfUpdater,
 	}
 
 	client.sshConfig = &ssh.ClientConfig{
@@ -161,6 +171,8 @@ func (c *Client) Start(ctx context.Context) error {
 
 	c.updates.Start(ctx)
 
+	c.selfUpdater.Start()
+
 	return nil
 }
 
@@ -286,6 +298,7 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 
 		// Connection request has succeeded
 		backoff.Reset()
+		c.selfUpdater.Confirm()
 
 		// Hand over the open SSH connection to the client
 		c.setConn(sshClientConn.Connection)
@@ -311,6 +324,7 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 		c.monitor.Stop()
 		c.updates.Stop()
 		c.ipAddressesFetcher.Stop()
+		c.reverseTunnels.closeAll(c.Logger)
 		cancelSwitchback()
 
 		// use of closed network connection happens when switchback closes the connection, ignore the error
@@ -636,6 +650,9 @@ func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClient
 		case comm.RequestTypeRunCmd:
 			resp, err = c.HandleRunCmdRequest(ctx, r.Payload)
 			// fall through for err and resp handling
+		case comm.RequestTypeCancelCmd:
+			err = c.HandleCancelCmdRequest(r.Payload)
+			// fall through to reply success with empty resp
 		case comm.RequestTypeRefreshUpdatesStatus:
 			c.updates.Refresh()
 			// fall through to reply success with empty resp
@@ -655,6 +672,25 @@ func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClient
 		case comm.RequestTypeCheckTunnelAllowed:
 			resp, err = c.checkTunnelAllowed(r.Payload)
 			// fall through for err and resp handling
+		case comm.RequestTypeStartReverseTunnel:
+			err = c.reverseTunnels.start(c.Logger, sshClientConn.Connection, r.Payload)
+			// fall through to reply success with empty resp
+		case comm.RequestTypeStopReverseTunnel:
+			err = c.reverseTunnels.stop(r.Payload)
+			// fall through to reply success with empty resp
+		case comm.RequestTypeClientUpdate:
+			// downloading and verifying the new binary takes a while, other requests must not wait for it
+			go func(r *ssh.Request) {
+				err := c.handleClientUpdateRequest(ctx, sshClientConn.Connection, r.Payload)
+				if err != nil {
+					c.Errorf("Failed to handle %q request: %v", r.Type, err)
+					comm.ReplyError(c.Logger, r, err)
+					return
+				}
+				comm.ReplySuccessJSON(c.Logger, r, nil)
+				c.selfUpdater.Restart()
+			}(r)
+			continue
 		case comm.RequestTypePing:
 			// use empty reply (and NOT empty resp with success reply)
 			_ = r.Reply(true, nil)
@@ -701,7 +737,7 @@ func (c *Client) checkTunnelAllowed(payload []byte) (*comm.CheckTunnelAllowedRes
 		return nil, err
 	}
 
-	allowed, err := TunnelIsAllowed(c.configHolder.Client.TunnelAllowed, req.Remote)
+	allowed, err := chshare.TunnelIsAllowed(c.configHolder.Client.TunnelAllowed, req.Remote)
 	if err != nil {
 		return nil, err
 	}
@@ -714,6 +750,19 @@ func (c *Client) checkTunnelAllowed(payload []byte) (*comm.CheckTunnelAllowedRes
 	}, nil
 }
 
+func (c *Client) handleClientUpdateRequest(ctx context.Context, sshConn ssh.Conn, payload []byte) error {
+	req := &comm.ClientUpdateRequest{}
+	err := json.Unmarshal(payload, req)
+	if err != nil {
+		return err
+	}
+
+	fileProvider := &SSHFileProvider{
+		sshConn: sshConn,
+	}
+	return c.selfUpdater.Update(ctx, req, fileProvider.Open)
+}
+
 // Wait blocks while the client is running.
 // Can only be called once.
 func (c *Client) Wait(ctx context.Context) (err error) {
@@ -756,6 +805,15 @@ func (c *Client) stopRunning() {
 func (c *Client) connectStreams(chans <-chan ssh.NewChannel) {
 	c.Logger.Debugf("connectStreams started")
 	for ch := range chans {
+		switch ch.ChannelType() {
+		case comm.ChannelTypeShell:
+			go c.handleShellChannel(ch)
+			continue
+		case comm.ChannelTypeSFTP:
+			go c.handleSFTPChannel(ch)
+			continue
+		}
+
 		remote := string(ch.ExtraData())
 		protocol := models.ProtocolTCP
 		c.Debugf("handling connect stream: remote=%s, protocol=%s", remote, protocol)
@@ -765,7 +823,7 @@ func (c *Client) connectStreams(chans <-chan ssh.NewChannel) {
 			protocol = parts[1]
 		}
 
-		allowed, err := TunnelIsAllowed(c.configHolder.Client.TunnelAllowed, remote)
+		allowed, err := chshare.TunnelIsAllowed(c.configHolder.Client.TunnelAllowed, remote)
 		if err != nil {
 			c.Errorf("Could not check if remote is allowed: %v", err)
 		}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
@@ -31,6 +32,60 @@ var (
 // now is used to stub time.Now in tests
 var now = time.Now
 
+// killProcessTree is used to stub killing processes in tests
+var killProcessTree = system.KillProcessTree
+
+// runningCmds tracks the commands observed by the client, so they can be cancelled.
+type runningCmds struct {
+	mu   sync.Mutex
+	cmds map[string]*runningCmd
+}
+
+type runningCmd struct {
+	cmd         *exec.Cmd
+	cancelledBy string
+}
+
+func (r *runningCmds) add(jid string, cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cmds == nil {
+		r.cmds = make(map[string]*runningCmd)
+	}
+	r.cmds[jid] = &runningCmd{cmd: cmd}
+}
+
+// remove stops tracking the command and returns the user who cancelled it, if any.
+func (r *runningCmds) remove(jid string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rc := r.cmds[jid]
+	delete(r.cmds, jid)
+	if rc == nil {
+		return ""
+	}
+	return rc.cancelledBy
+}
+
+func (r *runningCmds) cancel(jid, cancelledBy string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rc := r.cmds[jid]
+	if rc == nil {
+		return fmt.Errorf("job %s is not running", jid)
+	}
+	err := killProcessTree(rc.cmd.Process)
+	if err != nil {
+		return fmt.Errorf("failed to kill job %s: %v", jid, err)
+	}
+	rc.cancelledBy = cancelledBy
+
+	return nil
+}
+
 func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*comm.RunCmdResponse, error) {
 	if !c.configHolder.RemoteCommands.Enabled {
 		return nil, errors.New("remote commands execution is disabled")
@@ -89,7 +144,7 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		limitedStdOutCh = &LimitedWriter{
 			Writer:  stdOutCh,
 			Decoder: decoder,
-			Limit:   c.configHolder.RemoteCommands.SendBackLimit,
+			Limit:   c.configHolder.RemoteCommands.StreamLimit,
 		}
 
 		stdErrCh, reqs, err := c.getConn().OpenChannel(models.ChannelStderr, reqPayload)
@@ -100,7 +155,7 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		limitedStdErrCh = &LimitedWriter{
 			Writer:  stdErrCh,
 			Decoder: decoder,
-			Limit:   c.configHolder.RemoteCommands.SendBackLimit,
+			Limit:   c.configHolder.RemoteCommands.StreamLimit,
 		}
 
 		closeStreamChannels = func() {
@@ -132,10 +187,11 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		return nil, fmt.Errorf("failed to start a command: %s", err)
 	}
 
+	c.runningCmds.add(job.JID, cmd)
+
 	// observe the cmd execution in background
 	go func() {
 		defer c.rmScript(scriptPath)
-		defer closeStreamChannels()
 
 		c.Debugf("started to observe cmd [jid=%q,pid=%d]", job.JID, cmd.Process.Pid)
 
@@ -163,6 +219,13 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 			c.Debugf("timeout (%d seconds) reached, stop observing command[jid=%q,pid=%d]:\n%s", job.TimeoutSec, job.JID, cmd.Process.Pid, job.Command)
 		}
 
+		// after the timeout the cmd can no longer be cancelled
+		cancelledBy := c.runningCmds.remove(job.JID)
+		if cancelledBy != "" {
+			status = models.JobStatusCancelled
+			execErr = fmt.Errorf("cancelled by %s", cancelledBy)
+		}
+
 		// fill all unset fields
 		now := now()
 		job.FinishedAt = &now
@@ -176,6 +239,8 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		}
 
 		summary.Stop()
+		// the streamed output is complete before the result is sent
+		closeStreamChannels()
 
 		job.Result = &models.JobResult{
 			StdOut:  c.ToUTF8(stdOut.Bytes(), decoder),
@@ -204,6 +269,23 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 	}, nil
 }
 
+// HandleCancelCmdRequest kills the processes of a running job. The job result is sent when the processes exited.
+func (c *Client) HandleCancelCmdRequest(reqPayload []byte) error {
+	req := comm.CancelCmdRequest{}
+	err := json.Unmarshal(reqPayload, &req)
+	if err != nil {
+		return fmt.Errorf("failed to decode cancel request: %s", err)
+	}
+
+	err = c.runningCmds.cancel(req.JID, req.CancelledBy)
+	if err != nil {
+		return err
+	}
+
+	c.Infof("cancelled job %s on request of %s", req.JID, req.CancelledBy)
+	return nil
+}
+
 func (c *Client) buildErrText(execErr error, stdOut, stdErr *CapacityBuffer) string {
 	errs := make([]string, 0, 3)
 
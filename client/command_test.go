@@ -3,6 +3,7 @@ package chclient
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -34,6 +35,8 @@ type CmdExecutorMock struct {
 	ReturnWaitErr  error
 	ReturnStdOut   []string
 	ReturnStdErr   []string
+	// BlockWait blocks Wait until it's closed
+	BlockWait chan struct{}
 
 	wg sync.WaitGroup
 }
@@ -102,6 +105,9 @@ func (e *CmdExecutorMock) Wait(cmd *exec.Cmd) error {
 		return e.ReturnWaitErr
 	}
 	e.wg.Wait()
+	if e.BlockWait != nil {
+		<-e.BlockWait
+	}
 	// wait if needed
 	if e.DoneChannel != nil {
 		e.DoneChannel <- true
@@ -202,6 +208,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 	testCases := []struct {
 		name             string
 		sendBackLimit    int
+		streamLimit      int
 		denyRegexp       *regexp.Regexp
 		wantJSON         string
 		wantErrContains  string
@@ -211,6 +218,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		{
 			name:             "limit is larger than stdout and stderr",
 			sendBackLimit:    stdOutSize + 1,
+			streamLimit:      stdOutSize + 1,
 			wantJSON:         fmt.Sprintf(wantJSONPart1, "") + wantJSONPart2,
 			wantStdoutWrites: []string{"output1", "output2", "output3", "<summary>test</summary>"},
 			wantStderrWrites: []string{"error1", "error2"},
@@ -218,6 +226,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		{
 			name:             "limit is equal to the larger output",
 			sendBackLimit:    stdOutSize,
+			streamLimit:      stdOutSize,
 			wantJSON:         fmt.Sprintf(wantJSONPart1, "") + wantJSONPart2,
 			wantStdoutWrites: []string{"output1", "output2", "output3", "<summary>test</summary>"},
 			wantStderrWrites: []string{"error1", "error2"},
@@ -225,6 +234,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		{
 			name:          "limit is equal to the smaller output",
 			sendBackLimit: stdErrSize,
+			streamLimit:   stdErrSize,
 			wantJSON: fmt.Sprintf(wantJSONPart1, "overflow of stdOut buffer: maximum send_back_limit of 12 bytes exceeded") + `
        "result": {
        "stdout": "output1outpu",
@@ -238,6 +248,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		{
 			name:          "limit is less than smaller output",
 			sendBackLimit: stdErrSize - 1,
+			streamLimit:   stdErrSize - 1,
 			wantJSON: fmt.Sprintf(wantJSONPart1, "overflow of stdOut buffer: maximum send_back_limit of 11 bytes exceeded, overflow of stdErr buffer: maximum send_back_limit of 11 bytes exceeded") + `
 		"result": {
 		"stdout": "output1outp",
@@ -248,6 +259,20 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 			wantStdoutWrites: []string{"output1", "outp"},
 			wantStderrWrites: []string{"error1", "error"},
 		},
+		{
+			name:          "stream limit is larger than send back limit",
+			sendBackLimit: stdErrSize,
+			streamLimit:   stdOutSize,
+			wantJSON: fmt.Sprintf(wantJSONPart1, "overflow of stdOut buffer: maximum send_back_limit of 12 bytes exceeded") + `
+       "result": {
+       "stdout": "output1outpu",
+       "stderr": "error1error2",
+	   "summary": "test"
+   }
+}`,
+			wantStdoutWrites: []string{"output1", "output2", "output3", "<summary>test</summary>"},
+			wantStderrWrites: []string{"error1", "error2"},
+		},
 		{
 			name:          "limit is zero",
 			sendBackLimit: 0,
@@ -270,6 +295,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// given
 			c.configHolder.RemoteCommands.SendBackLimit = tc.sendBackLimit
+			c.configHolder.RemoteCommands.StreamLimit = tc.streamLimit
 			if tc.denyRegexp != nil {
 				c.configHolder.RemoteCommands.DenyRegexp = []*regexp.Regexp{tc.denyRegexp}
 			}
@@ -695,3 +721,58 @@ func TestLimitedWriter(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleCancelCmdRequest(t *testing.T) {
+	now = nowMockF
+
+	execMock := NewCmdExecutorMock()
+	execMock.ReturnPID = 123
+	execMock.BlockWait = make(chan struct{})
+	connMock := test.NewConnMock()
+	done := make(chan bool)
+	connMock.DoneChannel = done
+	configCopy := getDefaultValidMinConfig()
+	c := Client{
+		cmdExec:       execMock,
+		sshConnection: connMock,
+		Logger:        testLog,
+		configHolder:  &configCopy,
+	}
+
+	configCopy.Client.DataDir = filepath.Join(configCopy.Client.DataDir, "TestHandleCancelCmdRequest")
+	defer func() {
+		os.RemoveAll(configCopy.Client.DataDir)
+	}()
+	require.NoError(t, PrepareDirs(&configCopy))
+
+	var killedPID int
+	killProcessTree = func(p *os.Process) error {
+		killedPID = p.Pid
+		close(execMock.BlockWait)
+		return nil
+	}
+	defer func() {
+		killProcessTree = system.KillProcessTree
+	}()
+
+	err := c.HandleCancelCmdRequest([]byte(`{"jid":"unknown","cancelled_by":"admin"}`))
+	assert.EqualError(t, err, "job unknown is not running")
+
+	_, err = c.HandleRunCmdRequest(context.Background(), []byte(`{"jid": "5f02b216-3f8a-42be-b66c-f4c1d0ea3810", "command": "/bin/date", "timeout_sec": 60}`))
+	require.NoError(t, err)
+
+	err = c.HandleCancelCmdRequest([]byte(`{"jid":"5f02b216-3f8a-42be-b66c-f4c1d0ea3810","cancelled_by":"admin"}`))
+	require.NoError(t, err)
+	<-done
+
+	assert.Equal(t, 123, killedPID)
+	inputRequestName, _, inputPayload := connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeCmdResult, inputRequestName)
+	gotJob := models.Job{}
+	require.NoError(t, json.Unmarshal(inputPayload, &gotJob))
+	assert.Equal(t, models.JobStatusCancelled, gotJob.Status)
+	assert.Equal(t, "cancelled by admin", gotJob.Error)
+
+	err = c.HandleCancelCmdRequest([]byte(`{"jid":"5f02b216-3f8a-42be-b66c-f4c1d0ea3810","cancelled_by":"admin"}`))
+	assert.EqualError(t, err, "job 5f02b216-3f8a-42be-b66c-f4c1d0ea3810 is not running")
+}
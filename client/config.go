@@ -1,6 +1,9 @@
 package chclient
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"log"
@@ -78,6 +81,14 @@ func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) err
 		return err
 	}
 
+	if err := c.ParseAndValidateFileAccessConfig(); err != nil {
+		return fmt.Errorf("file access: %v", err)
+	}
+
+	if err := c.ParseAndValidateClientUpdateConfig(); err != nil {
+		return fmt.Errorf("client update: %v", err)
+	}
+
 	if err := c.ParseAndValidateConnection(); err != nil {
 		return err
 	}
@@ -136,6 +147,43 @@ func (c *ClientConfigHolder) ParseAndValidateFilePushConfig() error {
 	return nil
 }
 
+func (c *ClientConfigHolder) ParseAndValidateFileAccessConfig() error {
+	for _, globPattern := range append(c.FileAccessConfig.Allow, c.FileAccessConfig.Protected...) {
+		_, err := filepath.Match(globPattern, "/test")
+		if err != nil {
+			return fmt.Errorf("invalid glob pattern %s: %v", globPattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *ClientConfigHolder) ParseAndValidateClientUpdateConfig() error {
+	if !c.ClientUpdateConfig.Enabled {
+		return nil
+	}
+
+	if c.ClientUpdateConfig.PublicKey == "" {
+		return errors.New("'public_key' is required to verify the signature of updates")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(c.ClientUpdateConfig.PublicKey)
+	if err != nil {
+		return fmt.Errorf("invalid 'public_key', expected a base64 encoded ed25519 public key: %v", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return fmt.Errorf("invalid 'public_key', expected a base64 encoded ed25519 public key: %v", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("invalid 'public_key', expected an ed25519 public key, got %T", key)
+	}
+	c.ClientUpdateConfig.ParsedPublicKey = edKey
+
+	return nil
+}
+
 func (c *ClientConfigHolder) parseHeaders() error {
 	c.Connection.HTTPHeaders = http.Header{}
 	for _, h := range c.Connection.HeadersRaw {
@@ -222,7 +270,7 @@ func (c *ClientConfigHolder) parseRemotes() error {
 	}
 
 	for _, ta := range c.Client.TunnelAllowed {
-		_, _, err := ParseTunnelAllowed(ta)
+		_, _, err := chshare.ParseTunnelAllowed(ta)
 		if err != nil {
 			return fmt.Errorf(`invalid "tunnel_allowed" config: %v`, err)
 		}
@@ -234,12 +282,15 @@ func (c *ClientConfigHolder) parseRemotes() error {
 			return fmt.Errorf("failed to decode remote %q: %v", s, err)
 		}
 
-		allowed, err := TunnelIsAllowed(c.Client.TunnelAllowed, r.Remote())
-		if err != nil {
-			return fmt.Errorf("failed to check if remote %q is allowed: %v", s, err)
-		}
-		if !allowed {
-			return fmt.Errorf(`remote %q is not allowed by "tunnel_allowed" config`, s)
+		// the destinations of socks tunnels are checked per connection
+		if r.Protocol != models.ProtocolSOCKS {
+			allowed, err := chshare.TunnelIsAllowed(c.Client.TunnelAllowed, r.Remote())
+			if err != nil {
+				return fmt.Errorf("failed to check if remote %q is allowed: %v", s, err)
+			}
+			if !allowed {
+				return fmt.Errorf(`remote %q is not allowed by "tunnel_allowed" config`, s)
+			}
 		}
 
 		r = c.applyTunnelsConfig(r)
@@ -262,8 +313,11 @@ func (c *ClientConfigHolder) applyTunnelsConfig(r *models.Remote) *models.Remote
 		r.Scheme = &c.Tunnels.Scheme
 	}
 
-	r.HTTPProxy = c.Tunnels.ReverseProxy
-	r.HostHeader = c.Tunnels.HostHeader
+	// socks tunnels are never served by the tunnel proxy
+	if r.Protocol != models.ProtocolSOCKS {
+		r.HTTPProxy = c.Tunnels.ReverseProxy
+		r.HostHeader = c.Tunnels.HostHeader
+	}
 
 	return r
 }
@@ -280,6 +334,9 @@ func (c *ClientConfigHolder) parseRemoteCommands() error {
 	if c.RemoteCommands.SendBackLimit < 0 {
 		return fmt.Errorf("send back limit can not be negative: %d", c.RemoteCommands.SendBackLimit)
 	}
+	if c.RemoteCommands.StreamLimit < 0 {
+		return fmt.Errorf("stream limit can not be negative: %d", c.RemoteCommands.StreamLimit)
+	}
 
 	allow, err := parseRegexpList(c.RemoteCommands.Allow)
 	if err != nil {
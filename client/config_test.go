@@ -313,6 +313,23 @@ func TestConfigParseAndValidateRemotes(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:    "socks remote is not served by reverse proxy",
+			Remotes: []string{"1080/socks"},
+			TunnelsConfig: clientconfig.TunnelsConfig{
+				Scheme:       schemeHTTP,
+				ReverseProxy: true,
+				HostHeader:   "my-host.dev",
+			},
+			ExpectedRemotes: []*models.Remote{
+				{
+					Protocol:  models.ProtocolSOCKS,
+					LocalHost: "0.0.0.0",
+					LocalPort: "1080",
+					Scheme:    &schemeHTTP,
+				},
+			},
+		},
 		{
 			Name:    "invalid tunnels config: host-header requires reverse-proxy",
 			Remotes: []string{"8000"},
@@ -717,6 +734,115 @@ func TestConfigParseAndValidateFilePushConfig(t *testing.T) {
 	}
 }
 
+func TestConfigParseAndValidateFileAccessConfig(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Allow         []string
+		Protected     []string
+		ExpectedError string
+	}{
+		{
+			Name:      "default globs",
+			Allow:     FileAccessAllowGlobs,
+			Protected: FileAccessProtectedGlobs,
+		},
+		{
+			Name: "no globs",
+		},
+		{
+			Name:          "invalid allow pattern",
+			Allow:         []string{"/var/log", "["},
+			ExpectedError: "file access: invalid glob pattern [: syntax error in pattern",
+		},
+		{
+			Name:          "invalid protected pattern",
+			Protected:     []string{"[a"},
+			ExpectedError: "file access: invalid glob pattern [a: syntax error in pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			config := getDefaultValidMinConfig()
+			config.FileAccessConfig = clientconfig.FileAccessConfig{
+				Enabled:   true,
+				Allow:     tc.Allow,
+				Protected: tc.Protected,
+			}
+
+			err := config.ParseAndValidate(true)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestConfigParseAndValidateClientUpdateConfig(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Enabled       bool
+		PublicKey     string
+		ExpectedError string
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name:      "valid public key",
+			Enabled:   true,
+			PublicKey: "MCowBQYDK2VwAyEAu5pYE2S7pYkdB2DcWKAdzNfQhsZWZ03kZPjsKQ2n3yk=",
+		},
+		{
+			Name:          "missing public key",
+			Enabled:       true,
+			ExpectedError: "client update: 'public_key' is required to verify the signature of updates",
+		},
+		{
+			Name:          "invalid public key",
+			Enabled:       true,
+			PublicKey:     "not base64",
+			ExpectedError: "client update: invalid 'public_key', expected a base64 encoded ed25519 public key: illegal base64 data at input byte 3",
+		},
+		{
+			Name:          "rsa public key",
+			Enabled:       true,
+			PublicKey:     "MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQDXvd5Vr5aOYFW3x4wHVyrOQxi/UI8JgRXlOBeFf7O9O1Fo3o6Skfc9EvVEG2cmkc/LI4yYMhMJjCipV44MbdMOqPw4SSabZEW3px4knN9MCoeyfL6qxkroAUwruTj4yLLxIdLj9mhuFx7/ayjzolNGxjbXVYxGAcLxhJHWfNp8+QIDAQAB",
+			ExpectedError: "client update: invalid 'public_key', expected an ed25519 public key, got *rsa.PublicKey",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			config := getDefaultValidMinConfig()
+			config.ClientUpdateConfig = clientconfig.ClientUpdateConfig{
+				Enabled:   tc.Enabled,
+				PublicKey: tc.PublicKey,
+			}
+
+			err := config.ParseAndValidate(true)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+				if tc.Enabled {
+					assert.Len(t, config.ClientUpdateConfig.ParsedPublicKey, 32)
+				}
+			} else {
+				require.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
 func TestConfigParseInterpreterAliases(t *testing.T) {
 	alias := "test-alias"
 	testCases := []struct {
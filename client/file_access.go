@@ -0,0 +1,185 @@
+package chclient
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/logger"
+)
+
+var errFileAccessDisabled = errors.New("file access is disabled on this client, check [file-access] enabled option")
+
+// handleSFTPChannel serves a read-only SFTP subsystem on the given channel, so the server can browse and download
+// files. Only paths matching the [file-access] allow globs and not matching the protected globs are accessible.
+func (c *Client) handleSFTPChannel(ch ssh.NewChannel) {
+	cfg := c.configHolder.FileAccessConfig
+	if !cfg.Enabled {
+		c.Debugf(errFileAccessDisabled.Error())
+		if err := ch.Reject(ssh.Prohibited, errFileAccessDisabled.Error()); err != nil {
+			c.Errorf("Failed to reject sftp channel: %v", err)
+		}
+		return
+	}
+
+	channel, reqs, err := ch.Accept()
+	if err != nil {
+		c.Errorf("Failed to accept sftp channel: %v", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	fa := newFileAccess(c.Logger.Fork("file-access"), cfg)
+	server := sftp.NewRequestServer(channel, sftp.Handlers{
+		FileGet:  fa,
+		FilePut:  fa,
+		FileCmd:  fa,
+		FileList: fa,
+	})
+	defer server.Close()
+
+	if err := server.Serve(); err != nil && !errors.Is(err, io.EOF) {
+		c.Errorf("Failed to serve sftp channel: %v", err)
+	}
+}
+
+// fileAccess implements the sftp request handlers, all write operations are denied.
+type fileAccess struct {
+	logger    *logger.Logger
+	allow     []string
+	protected []string
+}
+
+func newFileAccess(l *logger.Logger, cfg clientconfig.FileAccessConfig) *fileAccess {
+	return &fileAccess{
+		logger:    l,
+		allow:     cfg.Allow,
+		protected: cfg.Protected,
+	}
+}
+
+// resolve returns the real local path of the requested path, if it's allowed to be accessed.
+// Symlinks are resolved before the check, so they cannot be used to escape the allowed folders.
+func (fa *fileAccess) resolve(requestPath string) (string, error) {
+	p := localPath(requestPath)
+	if !filepath.IsAbs(p) {
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+
+	realPath, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+
+	if !matchesPathOrParent(fa.allow, realPath) {
+		fa.logger.Debugf("access to %s denied, it's not allowed", requestPath)
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+	if matchesPathOrParent(fa.protected, p) || matchesPathOrParent(fa.protected, realPath) {
+		fa.logger.Debugf("access to %s denied, it's protected", requestPath)
+		return "", sftp.ErrSSHFxPermissionDenied
+	}
+
+	return realPath, nil
+}
+
+func (fa *fileAccess) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	p, err := fa.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	fa.logger.Infof("reading %s", p)
+	return os.Open(p)
+}
+
+func (fa *fileAccess) Filewrite(*sftp.Request) (io.WriterAt, error) {
+	return nil, sftp.ErrSSHFxPermissionDenied
+}
+
+func (fa *fileAccess) Filecmd(*sftp.Request) error {
+	return sftp.ErrSSHFxPermissionDenied
+}
+
+func (fa *fileAccess) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	p, err := fa.resolve(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		infos := make(listerAt, 0, len(entries))
+		for _, e := range entries {
+			if matchesPathOrParent(fa.protected, filepath.Join(p, e.Name())) {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				// the file was removed in the meantime
+				continue
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	case "Stat", "Lstat":
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// localPath converts the slash separated path of a sftp request to a local path,
+// on windows requests paths like /C:/Windows are received.
+func localPath(p string) string {
+	p = filepath.FromSlash(p)
+	if trimmed := strings.TrimLeft(p, `\/`); filepath.VolumeName(trimmed) != "" {
+		return trimmed
+	}
+	return p
+}
+
+// matchesPathOrParent returns true if the path or one of its parent folders matches one of the glob patterns.
+func matchesPathOrParent(globPatterns []string, p string) bool {
+	for {
+		for _, pattern := range globPatterns {
+			if matched, _ := filepath.Match(filepath.Clean(pattern), p); matched {
+				return true
+			}
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return false
+		}
+		p = parent
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+var FileAccessAllowGlobs = []string{
+	"/var/log", "/tmp",
+}
+
+var FileAccessProtectedGlobs = []string{
+	"/etc/shadow*", "/etc/gshadow*", "/etc/sudoers*", "/etc/ssh/ssh_host_*", "/root/.ssh", "/home/*/.ssh",
+}
@@ -0,0 +1,174 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/clientconfig"
+)
+
+func TestMatchesPathOrParent(t *testing.T) {
+	testCases := []struct {
+		name     string
+		globs    []string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			globs:    []string{"/var/log"},
+			path:     "/var/log",
+			expected: true,
+		},
+		{
+			name:     "sub folder",
+			globs:    []string{"/var/log"},
+			path:     "/var/log/nginx/access.log",
+			expected: true,
+		},
+		{
+			name:     "parent folder",
+			globs:    []string{"/var/log"},
+			path:     "/var",
+			expected: false,
+		},
+		{
+			name:     "similar prefix",
+			globs:    []string{"/var/log"},
+			path:     "/var/logs/access.log",
+			expected: false,
+		},
+		{
+			name:     "wildcard",
+			globs:    []string{"/home/*/.ssh"},
+			path:     "/home/user/.ssh/id_rsa",
+			expected: true,
+		},
+		{
+			name:     "trailing separator",
+			globs:    []string{"/tmp/"},
+			path:     "/tmp/file",
+			expected: true,
+		},
+		{
+			name:     "no globs",
+			globs:    nil,
+			path:     "/tmp/file",
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchesPathOrParent(tc.globs, tc.path))
+		})
+	}
+}
+
+func TestFileAccess(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	allowed := filepath.Join(dir, "allowed")
+	require.NoError(t, os.MkdirAll(filepath.Join(allowed, "secret"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "app.log"), []byte("line 1\nline 2\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "secret", "key"), []byte("key"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "outside"), []byte("outside"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "outside"), filepath.Join(allowed, "link")))
+
+	fa := newFileAccess(testLog, clientconfig.FileAccessConfig{
+		Enabled:   true,
+		Allow:     []string{allowed},
+		Protected: []string{filepath.Join(allowed, "secret")},
+	})
+	client := newTestSFTPClient(t, fa)
+
+	t.Run("list", func(t *testing.T) {
+		infos, err := client.ReadDir(allowed)
+		require.NoError(t, err)
+
+		var names []string
+		for _, info := range infos {
+			names = append(names, info.Name())
+		}
+		sort.Strings(names)
+		assert.Equal(t, []string{"app.log", "link"}, names)
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		info, err := client.Stat(filepath.Join(allowed, "app.log"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(14), info.Size())
+		assert.False(t, info.IsDir())
+	})
+
+	t.Run("read", func(t *testing.T) {
+		f, err := client.Open(filepath.Join(allowed, "app.log"))
+		require.NoError(t, err)
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "line 1\nline 2\n", string(data))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.Stat(filepath.Join(allowed, "missing"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	denied := map[string]string{
+		"outside allowed":   filepath.Join(dir, "outside"),
+		"protected":         filepath.Join(allowed, "secret", "key"),
+		"symlink escape":    filepath.Join(allowed, "link"),
+		"parent of allowed": dir,
+	}
+	for name, p := range denied {
+		t.Run(name, func(t *testing.T) {
+			_, err := client.Open(p)
+			assert.ErrorIs(t, err, os.ErrPermission)
+
+			_, err = client.Stat(p)
+			assert.ErrorIs(t, err, os.ErrPermission)
+		})
+	}
+
+	t.Run("write denied", func(t *testing.T) {
+		_, err := client.Create(filepath.Join(allowed, "new"))
+		assert.ErrorIs(t, err, os.ErrPermission)
+
+		err = client.Remove(filepath.Join(allowed, "app.log"))
+		assert.ErrorIs(t, err, os.ErrPermission)
+		assert.FileExists(t, filepath.Join(allowed, "app.log"))
+	})
+}
+
+func newTestSFTPClient(t *testing.T, fa *fileAccess) *sftp.Client {
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.Handlers{
+		FileGet:  fa,
+		FilePut:  fa,
+		FileCmd:  fa,
+		FileList: fa,
+	})
+	go func() {
+		_ = server.Serve()
+	}()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client
+}
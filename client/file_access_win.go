@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+var FileAccessAllowGlobs = []string{
+	`C:\Windows\Logs`, `C:\Windows\Temp`,
+}
+
+var FileAccessProtectedGlobs = []string{
+	`C:\Windows\System32\config`, `C:\Users\*\.ssh`,
+}
@@ -0,0 +1,114 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+// reverseTunnels tracks the listeners of reverse tunnels, all connections accepted by them are forwarded to the server.
+type reverseTunnels struct {
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+}
+
+func (r *reverseTunnels) start(l *logger.Logger, conn ssh.Conn, payload []byte) error {
+	req := &comm.StartReverseTunnelRequest{}
+	err := json.Unmarshal(payload, req)
+	if err != nil {
+		return err
+	}
+	if req.ID == "" {
+		return errors.New("reverse tunnel id is missing")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.listeners[req.ID]; ok {
+		return fmt.Errorf("reverse tunnel %s already exists", req.ID)
+	}
+
+	listener, err := net.Listen("tcp", req.Local)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", req.Local, err)
+	}
+	if r.listeners == nil {
+		r.listeners = make(map[string]net.Listener)
+	}
+	r.listeners[req.ID] = listener
+
+	l = l.Fork("reverse tunnel#%s", req.ID)
+	l.Infof("Listening on %s", listener.Addr())
+	go r.accept(l, conn, req.ID, listener)
+
+	return nil
+}
+
+func (r *reverseTunnels) accept(l *logger.Logger, conn ssh.Conn, id string, listener net.Listener) {
+	var connStats chshare.ConnStats
+	for {
+		src, err := listener.Accept()
+		if err != nil {
+			l.Debugf("Stopped accepting connections: %v", err)
+			return
+		}
+
+		go func() {
+			connLog := l.Fork("conn#%d", connStats.New())
+			stream, reqs, err := conn.OpenChannel(comm.ChannelTypeReverseTunnel, []byte(id))
+			if err != nil {
+				connLog.Errorf("Failed to open channel: %v", err)
+				src.Close()
+				return
+			}
+			go ssh.DiscardRequests(reqs)
+
+			connStats.Open()
+			connLog.Debugf("%s: Open", connStats.String())
+			chshare.Pipe(src, stream)
+			connStats.Close()
+			connLog.Debugf("%s: Close", connStats.String())
+		}()
+	}
+}
+
+func (r *reverseTunnels) stop(payload []byte) error {
+	req := &comm.StopReverseTunnelRequest{}
+	err := json.Unmarshal(payload, req)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	listener, ok := r.listeners[req.ID]
+	if !ok {
+		return fmt.Errorf("reverse tunnel %s not found", req.ID)
+	}
+	delete(r.listeners, req.ID)
+
+	return listener.Close()
+}
+
+// closeAll stops all reverse tunnels, they have to be started again by the server after reconnecting.
+func (r *reverseTunnels) closeAll(l *logger.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, listener := range r.listeners {
+		if err := listener.Close(); err != nil {
+			l.Errorf("Failed to close reverse tunnel %s: %v", id, err)
+		}
+	}
+	r.listeners = nil
+}
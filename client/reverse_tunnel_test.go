@@ -0,0 +1,79 @@
+package chclient
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+type reverseTunnelChannelMock struct {
+	ssh.Channel
+	conn net.Conn
+}
+
+func (c *reverseTunnelChannelMock) Read(p []byte) (int, error)  { return c.conn.Read(p) }
+func (c *reverseTunnelChannelMock) Write(p []byte) (int, error) { return c.conn.Write(p) }
+func (c *reverseTunnelChannelMock) Close() error                { return c.conn.Close() }
+
+type reverseTunnelConnMock struct {
+	ssh.Conn
+	opened chan string
+	server chan net.Conn
+}
+
+func (c *reverseTunnelConnMock) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	c.opened <- name + ":" + string(data)
+	server, client := net.Pipe()
+	c.server <- server
+	return &reverseTunnelChannelMock{conn: client}, nil, nil
+}
+
+func TestReverseTunnels(t *testing.T) {
+	l := logger.NewLogger("reverse-tunnel-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	conn := &reverseTunnelConnMock{
+		opened: make(chan string, 1),
+		server: make(chan net.Conn, 1),
+	}
+	r := &reverseTunnels{}
+
+	start, err := json.Marshal(comm.StartReverseTunnelRequest{ID: "1", Local: "127.0.0.1:0"})
+	require.NoError(t, err)
+	require.NoError(t, r.start(l, conn, start))
+	assert.Error(t, r.start(l, conn, start))
+
+	local, err := net.Dial("tcp", r.listeners["1"].Addr().String())
+	require.NoError(t, err)
+	defer local.Close()
+
+	assert.Equal(t, comm.ChannelTypeReverseTunnel+":1", <-conn.opened)
+	server := <-conn.server
+	defer server.Close()
+
+	_, err = local.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(server, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+
+	_, err = server.Write([]byte("pong"))
+	require.NoError(t, err)
+	_, err = io.ReadFull(local, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "pong", string(buf))
+
+	stop, err := json.Marshal(comm.StopReverseTunnelRequest{ID: "1"})
+	require.NoError(t, err)
+	require.NoError(t, r.stop(stop))
+	assert.Error(t, r.stop(stop))
+	assert.Empty(t, r.listeners)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package selfupdate
+
+import (
+	"os"
+	"syscall"
+)
+
+// restart replaces the running process by the given binary, the process id doesn't change.
+func restart(executable string) error {
+	return syscall.Exec(executable, os.Args, os.Environ())
+}
@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package selfupdate
+
+import (
+	"os"
+)
+
+// restart exits with an error, so the service manager starts the given binary again.
+// The client service is installed to restart on failure.
+func restart(string) error {
+	os.Exit(1)
+	return nil
+}
@@ -0,0 +1,355 @@
+// Package selfupdate replaces the running client binary by a new version sent by the server. The new version has
+// to connect to the server within the rollback timeout, otherwise the previous version is restored.
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	pendingFile = "client-update.json"
+	// maxStarts is how often the new version is started without connecting to the server, before the previous
+	// version is restored. It stops a crash loop of a broken version.
+	maxStarts     = 3
+	verifyTimeout = 30 * time.Second
+	// restartDelay gives the client time to reply to the update request before it restarts
+	restartDelay = time.Second
+)
+
+var ErrDisabled = errors.New("client update is disabled on this client, check [client-update] enabled option")
+
+// Pending is an installed update waiting for the new version to connect to the server.
+type Pending struct {
+	RolloutID       string        `json:"rollout_id"`
+	Version         string        `json:"version"`
+	PreviousVersion string        `json:"previous_version"`
+	RollbackTimeout time.Duration `json:"rollback_timeout"`
+	Starts          int           `json:"starts"`
+}
+
+type Updater struct {
+	logger     *logger.Logger
+	cfg        clientconfig.ClientUpdateConfig
+	dataDir    string
+	executable string
+	version    string
+
+	// restart and verifyBinary are replaced in tests
+	restart      func(executable string) error
+	verifyBinary func(ctx context.Context, path, version string) error
+
+	mu      sync.Mutex
+	pending *Pending
+	timer   *time.Timer
+}
+
+func New(l *logger.Logger, cfg clientconfig.ClientUpdateConfig, dataDir string) (*Updater, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get path of the client binary: %w", err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get path of the client binary: %w", err)
+	}
+
+	return &Updater{
+		logger:       l,
+		cfg:          cfg,
+		dataDir:      dataDir,
+		executable:   executable,
+		version:      chshare.BuildVersion,
+		restart:      restart,
+		verifyBinary: verifyBinary,
+	}, nil
+}
+
+// Update downloads the new version, verifies its checksum and signature and replaces the client binary by it.
+// The previous binary is kept to roll back. The client has to be restarted with Restart to run the new version.
+func (u *Updater) Update(ctx context.Context, req *comm.ClientUpdateRequest, open func(path string) (io.ReadCloser, error)) error {
+	if !u.cfg.Enabled {
+		return ErrDisabled
+	}
+	if req.Version == u.version {
+		return fmt.Errorf("version %s is already running", req.Version)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.pending != nil {
+		return fmt.Errorf("update to version %s is not completed yet", u.pending.Version)
+	}
+
+	newPath := u.siblingPath("new")
+	err := u.download(req, open, newPath)
+	if err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	err = u.verifyBinary(ctx, newPath, req.Version)
+	if err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	pending := &Pending{
+		RolloutID:       req.RolloutID,
+		Version:         req.Version,
+		PreviousVersion: u.version,
+		RollbackTimeout: req.RollbackTimeout,
+	}
+	err = u.savePending(pending)
+	if err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	err = u.swap(newPath)
+	if err != nil {
+		_ = os.Remove(newPath)
+		u.removePending()
+		return err
+	}
+
+	u.pending = pending
+	u.logger.Infof("Installed version %s, restarting", req.Version)
+
+	return nil
+}
+
+// Restart replaces the running process by the installed binary.
+func (u *Updater) Restart() {
+	time.Sleep(restartDelay)
+	if err := u.restart(u.executable); err != nil {
+		u.logger.Errorf("Failed to restart the client: %v", err)
+	}
+}
+
+// Start checks for an update waiting for the new version to connect. If it doesn't connect within the rollback
+// timeout, the previous version is restored.
+func (u *Updater) Start() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	pending, err := u.loadPending()
+	if err != nil {
+		u.logger.Errorf("Failed to read pending client update: %v", err)
+		return
+	}
+	if pending == nil {
+		return
+	}
+
+	if pending.Version != u.version {
+		// the update failed before the new version was started or it was rolled back
+		u.logger.Infof("Update to version %s was not completed, running version %s", pending.Version, u.version)
+		u.removePending()
+		_ = os.Remove(u.siblingPath("failed"))
+		return
+	}
+
+	pending.Starts++
+	if pending.Starts > maxStarts {
+		u.logger.Errorf("Version %s did not connect to the server after %d starts, rolling back to version %s", pending.Version, maxStarts, pending.PreviousVersion)
+		u.rollback(pending)
+		return
+	}
+	err = u.savePending(pending)
+	if err != nil {
+		u.logger.Errorf("Failed to save pending client update: %v", err)
+	}
+
+	u.pending = pending
+	u.timer = time.AfterFunc(pending.RollbackTimeout, func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		if u.pending == nil {
+			return
+		}
+		u.logger.Errorf("Version %s did not connect to the server within %s, rolling back to version %s", pending.Version, pending.RollbackTimeout, pending.PreviousVersion)
+		u.rollback(pending)
+	})
+	u.logger.Infof("Running version %s, waiting for the connection to the server to complete the update", pending.Version)
+}
+
+// Confirm completes a pending update after the new version connected to the server successfully.
+func (u *Updater) Confirm() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.pending == nil || u.pending.Version != u.version {
+		return
+	}
+
+	if u.timer != nil {
+		u.timer.Stop()
+	}
+	u.removePending()
+	if err := os.Remove(u.siblingPath("rollback")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		u.logger.Errorf("Failed to remove previous client binary: %v", err)
+	}
+	u.logger.Infof("Update from version %s to %s completed", u.pending.PreviousVersion, u.pending.Version)
+	u.pending = nil
+}
+
+func (u *Updater) download(req *comm.ClientUpdateRequest, open func(path string) (io.ReadCloser, error), dest string) error {
+	src, err := open(req.SourceFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to download version %s: %w", req.Version, err)
+	}
+	defer src.Close()
+
+	// the signature is verified on the whole binary, so it's read into memory
+	data, err := io.ReadAll(io.LimitReader(src, req.Size+1))
+	if err != nil {
+		return fmt.Errorf("failed to download version %s: %w", req.Version, err)
+	}
+	if int64(len(data)) != req.Size {
+		return fmt.Errorf("downloaded binary has %d bytes, expected %d", len(data), req.Size)
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != req.SHA256 {
+		return fmt.Errorf("sha256 checksum of the downloaded binary doesn't match %s", req.SHA256)
+	}
+	if !ed25519.Verify(u.cfg.ParsedPublicKey, data, req.Signature) {
+		return errors.New("signature of the downloaded binary doesn't match the configured public key")
+	}
+
+	return os.WriteFile(dest, data, 0755)
+}
+
+// swap moves the running binary aside to roll back and the new binary into its place.
+func (u *Updater) swap(newPath string) error {
+	rollbackPath := u.siblingPath("rollback")
+	if err := os.Remove(rollbackPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Rename(u.executable, rollbackPath); err != nil {
+		return fmt.Errorf("failed to move client binary: %w", err)
+	}
+	if err := os.Rename(newPath, u.executable); err != nil {
+		if restoreErr := os.Rename(rollbackPath, u.executable); restoreErr != nil {
+			u.logger.Errorf("Failed to restore client binary: %v", restoreErr)
+		}
+		return fmt.Errorf("failed to install new client binary: %w", err)
+	}
+
+	return nil
+}
+
+// rollback restores the previous binary and restarts it.
+func (u *Updater) rollback(pending *Pending) {
+	failedPath := u.siblingPath("failed")
+	_ = os.Remove(failedPath)
+
+	if err := os.Rename(u.executable, failedPath); err != nil {
+		u.logger.Errorf("Failed to roll back to version %s: %v", pending.PreviousVersion, err)
+		return
+	}
+	if err := os.Rename(u.siblingPath("rollback"), u.executable); err != nil {
+		u.logger.Errorf("Failed to roll back to version %s: %v", pending.PreviousVersion, err)
+		if restoreErr := os.Rename(failedPath, u.executable); restoreErr != nil {
+			u.logger.Errorf("Failed to restore client binary: %v", restoreErr)
+		}
+		return
+	}
+	// removing the running binary fails on windows, then it's removed on the next start
+	_ = os.Remove(failedPath)
+
+	u.pending = nil
+	u.logger.Infof("Rolled back to version %s, restarting", pending.PreviousVersion)
+
+	if err := u.restart(u.executable); err != nil {
+		u.logger.Errorf("Failed to restart the client: %v", err)
+	}
+}
+
+// siblingPath returns the path of a file next to the client binary, e.g. rport.rollback or rport.rollback.exe.
+// It has to be in the same directory, so it can be renamed to the binary.
+func (u *Updater) siblingPath(suffix string) string {
+	base, ext := u.executable, ""
+	if strings.HasSuffix(strings.ToLower(base), ".exe") {
+		base, ext = base[:len(base)-4], base[len(base)-4:]
+	}
+	return base + "." + suffix + ext
+}
+
+func (u *Updater) pendingPath() string {
+	return filepath.Join(u.dataDir, pendingFile)
+}
+
+func (u *Updater) loadPending() (*Pending, error) {
+	b, err := os.ReadFile(u.pendingPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	pending := &Pending{}
+	err = json.Unmarshal(b, pending)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", pendingFile, err)
+	}
+
+	return pending, nil
+}
+
+func (u *Updater) savePending(pending *Pending) error {
+	b, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(u.pendingPath(), b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save pending client update: %w", err)
+	}
+
+	return nil
+}
+
+func (u *Updater) removePending() {
+	if err := os.Remove(u.pendingPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		u.logger.Errorf("Failed to remove pending client update: %v", err)
+	}
+}
+
+// verifyBinary checks the new binary can be started on this system and reports the expected version.
+func verifyBinary(ctx context.Context, path, version string) error {
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run new client binary: %v: %s", err, out)
+	}
+	if !strings.Contains(string(out), version) {
+		return fmt.Errorf("new client binary reports version %q, expected %s", strings.TrimSpace(string(out)), version)
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/clientconfig"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	oldBinary = "old binary"
+	newBinary = "new binary"
+)
+
+type testUpdater struct {
+	*Updater
+	restarts chan string
+}
+
+func newTestUpdater(t *testing.T, dir string, version string, publicKey ed25519.PublicKey) *testUpdater {
+	restarts := make(chan string, 1)
+	return &testUpdater{
+		Updater: &Updater{
+			logger: logger.NewLogger("client-update", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+			cfg: clientconfig.ClientUpdateConfig{
+				Enabled:         true,
+				ParsedPublicKey: publicKey,
+			},
+			dataDir:    dir,
+			executable: filepath.Join(dir, "rport"),
+			version:    version,
+			restart: func(executable string) error {
+				restarts <- executable
+				return nil
+			},
+			verifyBinary: func(ctx context.Context, path, version string) error {
+				return nil
+			},
+		},
+		restarts: restarts,
+	}
+}
+
+func newUpdateRequest(t *testing.T, privateKey ed25519.PrivateKey, rollbackTimeout time.Duration) *comm.ClientUpdateRequest {
+	sum := sha256.Sum256([]byte(newBinary))
+	return &comm.ClientUpdateRequest{
+		RolloutID:       "rollout-1",
+		Version:         "0.9.2",
+		SourceFilePath:  "/var/lib/rport/client-updates/0.9.2/linux_amd64/rport",
+		Size:            int64(len(newBinary)),
+		SHA256:          hex.EncodeToString(sum[:]),
+		Signature:       ed25519.Sign(privateKey, []byte(newBinary)),
+		RollbackTimeout: rollbackTimeout,
+	}
+}
+
+func openNewBinary(string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(newBinary)), nil
+}
+
+func assertFileContent(t *testing.T, path, expected string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(b))
+}
+
+func TestUpdateAndConfirm(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	u := newTestUpdater(t, dir, "0.9.1", publicKey)
+	require.NoError(t, os.WriteFile(u.executable, []byte(oldBinary), 0755))
+
+	err = u.Update(context.Background(), newUpdateRequest(t, privateKey, time.Minute), openNewBinary)
+	require.NoError(t, err)
+
+	assertFileContent(t, u.executable, newBinary)
+	assertFileContent(t, filepath.Join(dir, "rport.rollback"), oldBinary)
+	assert.FileExists(t, filepath.Join(dir, pendingFile))
+
+	err = u.Update(context.Background(), newUpdateRequest(t, privateKey, time.Minute), openNewBinary)
+	assert.EqualError(t, err, "update to version 0.9.2 is not completed yet")
+
+	// the new version is started and connects to the server
+	restarted := newTestUpdater(t, dir, "0.9.2", publicKey)
+	restarted.Start()
+	require.NotNil(t, restarted.pending)
+	assert.Equal(t, 1, restarted.pending.Starts)
+	assert.Equal(t, "0.9.1", restarted.pending.PreviousVersion)
+
+	restarted.Confirm()
+	assert.Nil(t, restarted.pending)
+	assert.NoFileExists(t, filepath.Join(dir, pendingFile))
+	assert.NoFileExists(t, filepath.Join(dir, "rport.rollback"))
+	assertFileContent(t, u.executable, newBinary)
+}
+
+func TestUpdateRollbackWhenNotConnected(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	u := newTestUpdater(t, dir, "0.9.1", publicKey)
+	require.NoError(t, os.WriteFile(u.executable, []byte(oldBinary), 0755))
+
+	err = u.Update(context.Background(), newUpdateRequest(t, privateKey, 10*time.Millisecond), openNewBinary)
+	require.NoError(t, err)
+
+	restarted := newTestUpdater(t, dir, "0.9.2", publicKey)
+	restarted.Start()
+
+	select {
+	case executable := <-restarted.restarts:
+		assert.Equal(t, u.executable, executable)
+	case <-time.After(time.Second):
+		t.Fatal("client was not restarted")
+	}
+	assertFileContent(t, u.executable, oldBinary)
+	assert.NoFileExists(t, filepath.Join(dir, "rport.rollback"))
+
+	// the previous version is started again
+	rolledBack := newTestUpdater(t, dir, "0.9.1", publicKey)
+	rolledBack.Start()
+	assert.Nil(t, rolledBack.pending)
+	assert.NoFileExists(t, filepath.Join(dir, pendingFile))
+}
+
+func TestUpdateRollbackAfterMaxStarts(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	u := newTestUpdater(t, dir, "0.9.1", publicKey)
+	require.NoError(t, os.WriteFile(u.executable, []byte(oldBinary), 0755))
+
+	err = u.Update(context.Background(), newUpdateRequest(t, privateKey, time.Minute), openNewBinary)
+	require.NoError(t, err)
+
+	for i := 0; i < maxStarts; i++ {
+		restarted := newTestUpdater(t, dir, "0.9.2", publicKey)
+		restarted.Start()
+		restarted.timer.Stop()
+		assertFileContent(t, u.executable, newBinary)
+	}
+
+	restarted := newTestUpdater(t, dir, "0.9.2", publicKey)
+	restarted.Start()
+	assert.Len(t, restarted.restarts, 1)
+	assertFileContent(t, u.executable, oldBinary)
+}
+
+func TestUpdateRejected(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		disabled      bool
+		modify        func(req *comm.ClientUpdateRequest)
+		expectedError string
+	}{
+		{
+			name:          "disabled",
+			disabled:      true,
+			expectedError: ErrDisabled.Error(),
+		},
+		{
+			name: "same version",
+			modify: func(req *comm.ClientUpdateRequest) {
+				req.Version = "0.9.1"
+			},
+			expectedError: "version 0.9.1 is already running",
+		},
+		{
+			name: "invalid signature",
+			modify: func(req *comm.ClientUpdateRequest) {
+				req.Signature = ed25519.Sign(otherKey, []byte(newBinary))
+			},
+			expectedError: "signature of the downloaded binary doesn't match the configured public key",
+		},
+		{
+			name: "invalid checksum",
+			modify: func(req *comm.ClientUpdateRequest) {
+				req.SHA256 = strings.Repeat("0", 64)
+			},
+			expectedError: "sha256 checksum of the downloaded binary doesn't match " + strings.Repeat("0", 64),
+		},
+		{
+			name: "invalid size",
+			modify: func(req *comm.ClientUpdateRequest) {
+				req.Size = 3
+			},
+			expectedError: "downloaded binary has 4 bytes, expected 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			u := newTestUpdater(t, dir, "0.9.1", publicKey)
+			u.cfg.Enabled = !tc.disabled
+			require.NoError(t, os.WriteFile(u.executable, []byte(oldBinary), 0755))
+			req := newUpdateRequest(t, privateKey, time.Minute)
+			if tc.modify != nil {
+				tc.modify(req)
+			}
+
+			err := u.Update(context.Background(), req, openNewBinary)
+			assert.EqualError(t, err, tc.expectedError)
+
+			assertFileContent(t, u.executable, oldBinary)
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+// allowedShell returns the shell to run for the given request. Interactive shells are remote commands, so they are
+// only allowed if remote commands are enabled and the shell executable passes the allow and deny filters.
+func (c *Client) allowedShell(shell string) (string, error) {
+	if !c.configHolder.RemoteCommands.Enabled {
+		return "", errors.New("remote commands execution is disabled")
+	}
+
+	if shell == "" {
+		shell = defaultShell
+	}
+	if shell == "" {
+		return "", errors.New("no default shell on this platform")
+	}
+
+	if !c.isAllowed(shell) {
+		return "", fmt.Errorf("shell is not allowed: %v", shell)
+	}
+
+	return shell, nil
+}
+
+// handleShellChannel runs an interactive shell in a pseudo terminal connected to the given channel.
+// The exit code of the shell is sent back as exit-status request before the channel is closed.
+func (c *Client) handleShellChannel(ch ssh.NewChannel) {
+	req := &comm.ShellRequest{}
+	err := json.Unmarshal(ch.ExtraData(), req)
+	if err != nil {
+		c.rejectShellChannel(ch, ssh.ConnectionFailed, fmt.Errorf("invalid shell request: %v", err))
+		return
+	}
+
+	shell, err := c.allowedShell(req.Shell)
+	if err != nil {
+		c.rejectShellChannel(ch, ssh.Prohibited, err)
+		return
+	}
+
+	cmd := exec.Command(shell)
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	ptmx, err := startPTY(cmd, req.Cols, req.Rows)
+	if err != nil {
+		c.rejectShellChannel(ch, ssh.ConnectionFailed, fmt.Errorf("failed to start shell: %v", err))
+		return
+	}
+
+	channel, reqs, err := ch.Accept()
+	if err != nil {
+		c.Errorf("Failed to accept shell channel: %v", err)
+		ptmx.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return
+	}
+
+	l := c.Logger.Fork("shell#%d", cmd.Process.Pid)
+	l.Infof("Started shell %s", shell)
+
+	go handleShellRequests(l, reqs, ptmx)
+	go func() {
+		// input ends when the server closes the channel, the shell receives a hangup once the terminal is closed
+		_, _ = io.Copy(ptmx, channel)
+		ptmx.Close()
+	}()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// reading fails once the shell exited and all output is read
+		_, _ = io.Copy(channel, ptmx)
+	}()
+
+	exitCode := 0
+	err = cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			l.Errorf("Failed to wait for shell: %v", err)
+		}
+		exitCode = shellExitCode(cmd.ProcessState)
+	}
+	wg.Wait()
+	ptmx.Close()
+
+	l.Infof("Shell finished with exit code %d", exitCode)
+
+	_, err = channel.SendRequest(comm.RequestTypeExitStatus, false, ssh.Marshal(&comm.ExitStatusRequest{Status: uint32(exitCode)}))
+	if err != nil {
+		l.Debugf("Failed to send exit status: %v", err)
+	}
+	channel.Close()
+}
+
+func handleShellRequests(l *logger.Logger, reqs <-chan *ssh.Request, ptmx *os.File) {
+	for r := range reqs {
+		switch r.Type {
+		case comm.RequestTypeWindowChange:
+			size := &comm.WindowChangeRequest{}
+			err := ssh.Unmarshal(r.Payload, size)
+			if err == nil {
+				err = setPTYSize(ptmx, size.Cols, size.Rows)
+			}
+			if err != nil {
+				l.Errorf("Failed to resize shell: %v", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		default:
+			if r.WantReply {
+				_ = r.Reply(false, nil)
+			}
+		}
+	}
+}
+
+func (c *Client) rejectShellChannel(ch ssh.NewChannel, reason ssh.RejectionReason, err error) {
+	c.Errorf("Rejecting shell: %v", err)
+	rejectErr := ch.Reject(reason, err.Error())
+	if rejectErr != nil {
+		c.Errorf("Failed to reject shell: %v", rejectErr)
+	}
+}
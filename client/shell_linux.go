@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+package chclient
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const defaultShell = "/bin/sh"
+
+// startPTY starts cmd with a newly allocated pseudo terminal as its controlling terminal and stdin, stdout and stderr.
+// It returns the master side of the terminal, reading from it returns an error once cmd and its children exited.
+func startPTY(cmd *exec.Cmd, cols, rows uint32) (*os.File, error) {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty master: %w", err)
+	}
+
+	tty, err := openPTS(ptmx)
+	if err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+	// the child keeps its own copy of the terminal
+	defer tty.Close()
+
+	err = setPTYSize(ptmx, cols, rows)
+	if err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setsid:  true,
+		Setctty: true,
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		ptmx.Close()
+		return nil, err
+	}
+
+	return ptmx, nil
+}
+
+// openPTS unlocks and opens the slave side of the given pty master.
+func openPTS(ptmx *os.File) (*os.File, error) {
+	fd := int(ptmx.Fd())
+
+	err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock pty: %w", err)
+	}
+
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pty number: %w", err)
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pty slave: %w", err)
+	}
+
+	return tty, nil
+}
+
+func setPTYSize(ptmx *os.File, cols, rows uint32) error {
+	if cols == 0 || rows == 0 {
+		return nil
+	}
+
+	err := unix.IoctlSetWinsize(int(ptmx.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Col: uint16(cols),
+		Row: uint16(rows),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set pty size: %w", err)
+	}
+
+	return nil
+}
+
+// shellExitCode returns the exit code of the shell, for a shell killed by a signal it returns 128+signal
+// as shells do, ExitCode would return -1.
+func shellExitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
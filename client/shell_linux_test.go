@@ -0,0 +1,150 @@
+//go:build linux
+// +build linux
+
+package chclient
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+)
+
+// newSSHConnPair returns the server side of an in-memory ssh connection, new channels opened by the server are
+// handled by the given client.
+func newSSHConnPair(t *testing.T, c *Client) ssh.Conn {
+	key, err := chshare.GenerateKey("test")
+	require.NoError(t, err)
+	private, err := ssh.ParsePrivateKey(key)
+	require.NoError(t, err)
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	serverConfig.AddHostKey(private)
+
+	// net.Pipe is unbuffered and deadlocks the ssh version exchange, so use a loopback connection
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	clientNetConn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	serverNetConn, err := l.Accept()
+	require.NoError(t, err)
+
+	clientConnCh := make(chan error, 1)
+	go func() {
+		clientConn, chans, reqs, err := ssh.NewClientConn(clientNetConn, "", &ssh.ClientConfig{
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(), //nolint:gosec
+		})
+		clientConnCh <- err
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() { clientConn.Close() })
+		go ssh.DiscardRequests(reqs)
+		c.connectStreams(chans)
+	}()
+
+	serverConn, _, reqs, err := ssh.NewServerConn(serverNetConn, serverConfig)
+	require.NoError(t, err)
+	require.NoError(t, <-clientConnCh)
+	t.Cleanup(func() { serverConn.Close() })
+	go ssh.DiscardRequests(reqs)
+
+	return serverConn
+}
+
+func newShellTestClient(allow string) *Client {
+	config := getDefaultValidMinConfig()
+	config.RemoteCommands.AllowRegexp = []*regexp.Regexp{regexp.MustCompile(allow)}
+	return &Client{
+		Logger:       testLog,
+		configHolder: &config,
+	}
+}
+
+func openShell(t *testing.T, conn ssh.Conn, req comm.ShellRequest) (ssh.Channel, <-chan *ssh.Request, error) {
+	payload, err := json.Marshal(req)
+	require.NoError(t, err)
+	return conn.OpenChannel(comm.ChannelTypeShell, payload)
+}
+
+func TestShellChannel(t *testing.T) {
+	conn := newSSHConnPair(t, newShellTestClient("^/bin/sh$"))
+
+	channel, reqs, err := openShell(t, conn, comm.ShellRequest{Shell: "/bin/sh", Cols: 100, Rows: 30})
+	require.NoError(t, err)
+
+	exitStatus := make(chan uint32, 1)
+	go func() {
+		for r := range reqs {
+			if r.Type == comm.RequestTypeExitStatus {
+				status := &comm.ExitStatusRequest{}
+				assert.NoError(t, ssh.Unmarshal(r.Payload, status))
+				exitStatus <- status.Status
+			}
+		}
+		close(exitStatus)
+	}()
+
+	_, err = channel.SendRequest(comm.RequestTypeWindowChange, false, ssh.Marshal(&comm.WindowChangeRequest{Cols: 120, Rows: 40}))
+	require.NoError(t, err)
+	// give the resize a moment, requests and data are delivered independently
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = channel.Write([]byte("stty size; tty; exit 3\n"))
+	require.NoError(t, err)
+
+	output, err := io.ReadAll(channel)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(output), "40 120")
+	assert.Contains(t, string(output), "/dev/pts/")
+	assert.Equal(t, uint32(3), <-exitStatus)
+}
+
+func TestShellChannelKilled(t *testing.T) {
+	conn := newSSHConnPair(t, newShellTestClient("^/bin/sh$"))
+
+	channel, reqs, err := openShell(t, conn, comm.ShellRequest{Shell: "/bin/sh"})
+	require.NoError(t, err)
+
+	exitStatus := make(chan uint32, 1)
+	go func() {
+		for r := range reqs {
+			if r.Type == comm.RequestTypeExitStatus {
+				status := &comm.ExitStatusRequest{}
+				assert.NoError(t, ssh.Unmarshal(r.Payload, status))
+				exitStatus <- status.Status
+			}
+		}
+		close(exitStatus)
+	}()
+
+	_, err = channel.Write([]byte("kill -9 $$\n"))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(channel)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint32(128+9), <-exitStatus)
+}
+
+func TestShellChannelNotAllowed(t *testing.T) {
+	conn := newSSHConnPair(t, newShellTestClient("^/usr/bin/.*"))
+
+	_, _, err := openShell(t, conn, comm.ShellRequest{Shell: "/bin/sh"})
+
+	var openErr *ssh.OpenChannelError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, ssh.Prohibited, openErr.Reason)
+	assert.True(t, strings.HasPrefix(openErr.Message, "shell is not allowed"))
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package chclient
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+const defaultShell = ""
+
+var errShellNotSupported = errors.New("interactive shell sessions are only supported on linux")
+
+func startPTY(cmd *exec.Cmd, cols, rows uint32) (*os.File, error) {
+	return nil, errShellNotSupported
+}
+
+func setPTYSize(ptmx *os.File, cols, rows uint32) error {
+	return errShellNotSupported
+}
+
+func shellExitCode(state *os.ProcessState) int {
+	return state.ExitCode()
+}
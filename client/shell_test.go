@@ -0,0 +1,55 @@
+package chclient
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedShell(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Enabled       bool
+		Shell         string
+		ExpectedShell string
+		ExpectedError string
+	}{
+		{
+			Name:          "remote commands disabled",
+			Enabled:       false,
+			Shell:         "/bin/bash",
+			ExpectedError: "remote commands execution is disabled",
+		}, {
+			Name:          "allowed",
+			Enabled:       true,
+			Shell:         "/bin/bash",
+			ExpectedShell: "/bin/bash",
+		}, {
+			Name:          "denied",
+			Enabled:       true,
+			Shell:         "/usr/bin/zsh",
+			ExpectedError: "shell is not allowed: /usr/bin/zsh",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			config := getDefaultValidMinConfig()
+			config.RemoteCommands.Enabled = tc.Enabled
+			config.RemoteCommands.DenyRegexp = []*regexp.Regexp{regexp.MustCompile("zsh")}
+			c := Client{
+				Logger:       testLog,
+				configHolder: &config,
+			}
+
+			shell, err := c.allowedShell(tc.Shell)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.ExpectedShell, shell)
+		})
+	}
+}
@@ -5,12 +5,18 @@ package system
 
 import (
 	"context"
+	"os"
 	"os/exec"
 	"strings"
+	"syscall"
+	"time"
 
 	chshare "github.com/openrport/openrport/share"
 )
 
+// killGracePeriod is the time processes have to terminate before they are killed
+const killGracePeriod = 5 * time.Second
+
 func (e *CmdExecutorImpl) New(ctx context.Context, execCtx *CmdExecutorContext) *exec.Cmd {
 	var args []string
 	if execCtx.IsSudo {
@@ -37,6 +43,25 @@ func (e *CmdExecutorImpl) New(ctx context.Context, execCtx *CmdExecutorContext)
 
 	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec
 	cmd.Dir = execCtx.WorkingDir
+	// run in an own process group to be able to kill all processes started by the command
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	return cmd
 }
+
+// KillProcessTree terminates the process group of the given process and kills it after killGracePeriod.
+// sudo forwards the termination signal to the command it runs.
+func KillProcessTree(p *os.Process) error {
+	err := syscall.Kill(-p.Pid, syscall.SIGTERM)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		time.Sleep(killGracePeriod)
+		// fails with ESRCH if all processes are already terminated
+		_ = syscall.Kill(-p.Pid, syscall.SIGKILL)
+	}()
+
+	return nil
+}
@@ -4,7 +4,18 @@
 package system
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/logger"
 )
 
 func getCmdBuildTestcases() []cmdBuildTestCase {
@@ -56,3 +67,31 @@ func getCmdBuildTestcases() []cmdBuildTestCase {
 		},
 	}
 }
+
+func TestKillProcessTree(t *testing.T) {
+	testLog := logger.NewLogger("client-system", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	script := filepath.Join(t.TempDir(), "script.sh")
+	// the child process keeps running, if only the shell is killed
+	require.NoError(t, os.WriteFile(script, []byte("sleep 30 &\nsleep 30\n"), 0700))
+
+	cmd := NewCmdExecutor(testLog).New(context.Background(), &CmdExecutorContext{
+		Interpreter: Interpreter{InterpreterNameFromInput: chshare.UnixShell},
+		Command:     script,
+	})
+	require.NoError(t, cmd.Start())
+
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+
+	require.NoError(t, KillProcessTree(cmd.Process))
+	select {
+	case err := <-done:
+		assert.EqualError(t, err, "signal: terminated")
+	case <-time.After(5 * time.Second):
+		t.Fatal("process was not terminated")
+	}
+	// the process group is gone, once the child process is terminated as well
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(-cmd.Process.Pid, 0) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -6,7 +6,9 @@ package system
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -80,3 +82,12 @@ func buildDefaultCmd(ctx context.Context, execCtx *CmdExecutorContext, interpret
 
 	return cmd
 }
+
+// KillProcessTree kills the given process and all its child processes.
+func KillProcessTree(p *os.Process) error {
+	out, err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(p.Pid)).CombinedOutput() //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("taskkill failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
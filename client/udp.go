@@ -1,38 +1,41 @@
 package chclient
 
 import (
+	"errors"
 	"io"
 	"net"
-	"sync"
 	"time"
 
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/logger"
 )
 
-const activeConnectionTimeout = 15 * time.Second
+var udpReadTimeout = time.Second
 
+// udpHandler relays the datagrams of a UDP tunnel. Each peer of the tunnel gets its own session with a separate socket
+// towards the target, so replies are sent back to the peer that sent the request.
 type udpHandler struct {
 	*logger.Logger
-	addr    string
-	channel *comm.UDPChannel
-
-	mtx        sync.Mutex
-	conns      map[string]net.Conn
-	lastActive map[string]time.Time
+	addr     string
+	channel  *comm.UDPChannel
+	sessions *comm.UDPSessions
 }
 
 func newUDPHandler(logger *logger.Logger, addr string) *udpHandler {
 	return &udpHandler{
-		Logger:     logger,
-		addr:       addr,
-		conns:      make(map[string]net.Conn),
-		lastActive: make(map[string]time.Time),
+		Logger:   logger,
+		addr:     addr,
+		sessions: comm.NewUDPSessions(comm.UDPSessionIdleTimeout, comm.UDPMaxSessions),
 	}
 }
 
 func (h *udpHandler) Handle(stream io.ReadWriteCloser) error {
 	defer stream.Close()
+	defer func() {
+		if n := h.sessions.CloseAll(); n > 0 {
+			h.Debugf("Closed %d UDP sessions", n)
+		}
+	}()
 
 	h.channel = comm.NewUDPChannel(stream)
 	for {
@@ -44,104 +47,66 @@ func (h *udpHandler) Handle(stream io.ReadWriteCloser) error {
 			return err
 		}
 
-		conn, err := h.getConn(id)
-		if err != nil {
-			return err
+		session, created, err := h.sessions.Open(id, func() (net.Conn, error) {
+			return net.Dial("udp", h.addr)
+		})
+		if errors.Is(err, comm.ErrUDPSessionLimit) {
+			h.Debugf("Datagram from %s dropped: %v", id, err)
+			continue
 		}
-
-		h.setActive(id)
-		_, err = conn.Write(data)
 		if err != nil {
 			return err
 		}
-	}
-}
-
-func (h *udpHandler) getConn(id *net.UDPAddr) (net.Conn, error) {
-	h.mtx.Lock()
-	defer h.mtx.Unlock()
-
-	idStr := id.String()
-	conn, ok := h.conns[idStr]
-	if ok {
-		return conn, nil
-	}
+		if created {
+			h.Debugf("New UDP session of %s, %d active", id, h.sessions.Active())
+			go func() {
+				err := h.receive(session)
+				if err != nil {
+					h.Errorf("Error in receive: %v", err)
+				}
+			}()
+		}
 
-	conn, err := net.Dial("udp", h.addr)
-	if err != nil {
-		return nil, err
-	}
-	go func() {
-		err := h.receive(id, conn)
+		_, err = session.Conn.Write(data)
 		if err != nil {
-			h.Errorf("Error in receive: %v", err)
+			// only the session of the peer fails, e.g. if the target refused a previous datagram
+			h.Debugf("Closing UDP session of %s: %v", id, err)
+			h.sessions.Remove(session)
 		}
-	}()
-
-	h.conns[idStr] = conn
-
-	return conn, nil
-}
-
-func (h *udpHandler) close(id string) {
-	h.mtx.Lock()
-	defer h.mtx.Unlock()
-
-	conn, ok := h.conns[id]
-	if !ok {
-		return
 	}
-
-	h.Debugf("Closing connection for client: %v", id)
-	conn.Close()
-	delete(h.conns, id)
 }
 
-func (h *udpHandler) receive(id *net.UDPAddr, conn net.Conn) error {
-	defer h.close(id.String())
+func (h *udpHandler) receive(session *comm.UDPSession) error {
+	defer h.sessions.Remove(session)
 
 	const maxMTU = 9012
 	buff := make([]byte, maxMTU)
-	for h.isActive(id) {
-		err := conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		err := session.Conn.SetReadDeadline(time.Now().Add(udpReadTimeout))
 		if err != nil {
 			return err
 		}
 
-		n, err := conn.Read(buff)
+		n, err := session.Conn.Read(buff)
 		if e, ok := err.(net.Error); ok && (e.Timeout() || e.Temporary()) {
+			if h.sessions.ExpireIdle(session, time.Now()) {
+				h.Debugf("UDP session of %s expired, %d active", session.Addr, h.sessions.Active())
+				return nil
+			}
 			continue
 		}
-		if err == io.EOF {
+		if err == io.EOF || errors.Is(err, net.ErrClosed) {
 			return nil
 		}
 		if err != nil {
-			return err
+			h.Debugf("Closing UDP session of %s: %v", session.Addr, err)
+			return nil
 		}
 
-		h.setActive(id)
-		err = h.channel.Encode(id, buff[:n])
+		h.sessions.Touch(session.Addr)
+		err = h.channel.Encode(session.Addr, buff[:n])
 		if err != nil {
 			return err
 		}
 	}
-	return nil
-}
-
-func (h *udpHandler) isActive(id *net.UDPAddr) bool {
-	h.mtx.Lock()
-	defer h.mtx.Unlock()
-
-	la, ok := h.lastActive[id.String()]
-	if !ok {
-		return true
-	}
-	return time.Since(la) < activeConnectionTimeout
-}
-
-func (h *udpHandler) setActive(id *net.UDPAddr) {
-	h.mtx.Lock()
-	defer h.mtx.Unlock()
-
-	h.lastActive[id.String()] = time.Now()
 }
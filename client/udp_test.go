@@ -68,6 +68,52 @@ func TestUDPHandler(t *testing.T) {
 	wg.Wait()
 }
 
+func TestUDPHandlerSessionExpiry(t *testing.T) {
+	udpReadTimeout = 5 * time.Millisecond
+	mockServer := newMockUDPServer(t)
+	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	serverChannel, clientChannel := test.NewMockChannel()
+	channel := comm.NewUDPChannel(clientChannel)
+	handler := newUDPHandler(logger, mockServer.LocalAddr().String())
+	handler.sessions = comm.NewUDPSessions(100*time.Millisecond, 0)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		err := handler.Handle(serverChannel)
+		assert.NoError(t, err)
+		wg.Done()
+	}()
+	addr1, err := net.ResolveUDPAddr("udp", "127.0.0.1:12345")
+	require.NoError(t, err)
+
+	err = channel.Encode(addr1, []byte("123"))
+	require.NoError(t, err)
+	addr, _, err := channel.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, addr1, addr)
+	assert.Equal(t, 1, handler.sessions.Active())
+
+	// the delayed second answer of the mock server keeps the session active until it's idle
+	_, _, err = channel.Decode()
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return handler.sessions.Active() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	err = channel.Encode(addr1, []byte("456"))
+	require.NoError(t, err)
+	addr, data, err := channel.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, addr1, addr)
+	assert.Equal(t, []byte("456"), data)
+	assert.Equal(t, int64(2), handler.sessions.Total())
+
+	clientChannel.Close()
+	serverChannel.Close()
+
+	wg.Wait()
+}
+
 type mockUDPServer struct {
 	*net.UDPConn
 }
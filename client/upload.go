@@ -1,12 +1,15 @@
 package chclient
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	errors2 "github.com/openrport/openrport/share/errors"
 
@@ -18,13 +21,31 @@ import (
 
 	"golang.org/x/crypto/ssh"
 
+	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/files"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
 )
 
+const (
+	partialUploadExt = ".part"
+	// partialUploadMaxAge is the time after which partial copies that weren't resumed are deleted,
+	// the server deletes unfinished uploads after the same time by default.
+	partialUploadMaxAge    = 24 * time.Hour
+	uploadCopyAttempts     = 3
+	uploadProgressInterval = time.Second
+)
+
+var uploadRetryDelay = time.Second
+
 type SourceFileProvider interface {
 	Open(path string) (io.ReadCloser, error)
+	// OpenAt opens the file to read from the given offset
+	OpenAt(path string, offset int64) (io.ReadCloser, error)
+}
+
+type UploadProgressReporter interface {
+	ReportUploadProgress(progress *models.UploadProgress)
 }
 
 type OptionsProvider interface {
@@ -39,6 +60,7 @@ type UploadManager struct {
 	OptionsProvider    OptionsProvider
 	SourceFileProvider SourceFileProvider
 	SysUserLookup      system.SysUserLookup
+	ProgressReporter   UploadProgressReporter
 }
 
 type SSHFileProvider struct {
@@ -75,6 +97,10 @@ func (ss *SftpSession) Close() error {
 }
 
 func (sfp SSHFileProvider) Open(path string) (io.ReadCloser, error) {
+	return sfp.OpenAt(path, 0)
+}
+
+func (sfp SSHFileProvider) OpenAt(path string, offset int64) (io.ReadCloser, error) {
 	conn := ssh.NewClient(sfp.sshConn, nil, nil)
 	sftpCl, err := sftp.NewClient(conn)
 	if err != nil {
@@ -82,11 +108,20 @@ func (sfp SSHFileProvider) Open(path string) (io.ReadCloser, error) {
 	}
 
 	sftpFile, err := sftpCl.Open(path)
-
 	if err != nil {
+		sftpCl.Close()
 		return nil, err
 	}
 
+	if offset > 0 {
+		_, err = sftpFile.Seek(offset, io.SeekStart)
+		if err != nil {
+			sftpFile.Close()
+			sftpCl.Close()
+			return nil, err
+		}
+	}
+
 	return &SftpSession{
 		RemoteFile: sftpFile,
 		SftpCl:     sftpCl,
@@ -109,6 +144,28 @@ func NewSSHUploadManager(
 		OptionsProvider:    optionsProvider,
 		SourceFileProvider: sshFileProvider,
 		SysUserLookup:      sysUserLookup,
+		ProgressReporter: &sshUploadProgressReporter{
+			logger:  l,
+			sshConn: sshConn,
+		},
+	}
+}
+
+type sshUploadProgressReporter struct {
+	logger  *logger.Logger
+	sshConn ssh.Conn
+}
+
+func (r *sshUploadProgressReporter) ReportUploadProgress(progress *models.UploadProgress) {
+	payload, err := json.Marshal(progress)
+	if err != nil {
+		r.logger.Errorf("failed to encode upload progress: %v", err)
+		return
+	}
+
+	_, _, err = r.sshConn.SendRequest(comm.RequestTypeUploadProgress, false, payload)
+	if err != nil {
+		r.logger.Debugf("failed to send upload progress: %v", err)
 	}
 }
 
@@ -219,11 +276,17 @@ func (um *UploadManager) fileShouldBeSynched(uploadedFile *models.UploadedFile)
 }
 
 func (um *UploadManager) handleWritingFile(uploadedFile *models.UploadedFile) (resp *models.UploadResponse, err error) {
-	copiedBytes, tempFilePath, err := um.copyFileToTempLocation(
-		uploadedFile.SourceFilePath,
-		uploadedFile.DestinationFileMode,
-		uploadedFile.Md5Checksum,
-	)
+	var copiedBytes int64
+	var tempFilePath string
+	if uploadedFile.Size > 0 {
+		copiedBytes, tempFilePath, err = um.resumableCopyFileToTempLocation(uploadedFile)
+	} else {
+		copiedBytes, tempFilePath, err = um.copyFileToTempLocation(
+			uploadedFile.SourceFilePath,
+			uploadedFile.DestinationFileMode,
+			uploadedFile.Md5Checksum,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -439,6 +502,184 @@ func (um *UploadManager) copyFileToTempLocation(remoteFilePath string, targetFil
 	return copiedBytes, tempFilePath, nil
 }
 
+// resumableCopyFileToTempLocation copies the source file to the temp dir like copyFileToTempLocation, but keeps
+// the partial copy if the copy fails. The source file is named by its content hash, so the copy is resumed by
+// further uploads of the same file. Interrupted copies are retried a few times.
+func (um *UploadManager) resumableCopyFileToTempLocation(uploadedFile *models.UploadedFile) (
+	bytesCopied int64,
+	tempFilePath string,
+	err error,
+) {
+	targetFileMode := uploadedFile.DestinationFileMode
+	if targetFileMode == 0 {
+		targetFileMode = files.DefaultMode
+	}
+
+	tempDirWasCreated, err := um.FilesAPI.CreateDirIfNotExists(um.OptionsProvider.GetUploadDir(), targetFileMode)
+	if err != nil {
+		return 0, "", err
+	}
+	if tempDirWasCreated {
+		um.Logger.Debugf("created temp dir %s for uploaded files", um.OptionsProvider.GetUploadDir())
+	}
+
+	um.removeStalePartialCopies()
+
+	tempFilePath = filepath.Join(um.OptionsProvider.GetUploadDir(), filepath.Base(uploadedFile.SourceFilePath)+partialUploadExt)
+
+	// concurrent uploads of the same file must not write to the same temp file
+	unlock := lockTempFile(tempFilePath)
+	defer unlock()
+
+	offset, err := um.partialCopySize(tempFilePath, uploadedFile.Size)
+	if err != nil {
+		return 0, tempFilePath, err
+	}
+	if offset > 0 {
+		um.Logger.Infof("resuming copy of %s to %s at %d of %d bytes", uploadedFile.SourceFilePath, tempFilePath, offset, uploadedFile.Size)
+	}
+
+	for attempt := 1; offset < uploadedFile.Size; attempt++ {
+		var n int64
+		n, err = um.copyRemoteFileFrom(uploadedFile, tempFilePath, offset)
+		offset += n
+		if err == nil {
+			break
+		}
+		if attempt == uploadCopyAttempts {
+			return 0, tempFilePath, errors.Wrapf(err, "copy failed at %d of %d bytes after %d attempts", offset, uploadedFile.Size, attempt)
+		}
+
+		um.Logger.Infof("copy of %s interrupted at %d of %d bytes, will retry: %v", uploadedFile.SourceFilePath, offset, uploadedFile.Size, err)
+		time.Sleep(uploadRetryDelay * time.Duration(attempt))
+	}
+	um.Logger.Debugf("copied %d bytes from server path %s to temp path %s", offset, uploadedFile.SourceFilePath, tempFilePath)
+
+	hashSumMatch, err := files.Md5HashMatch(uploadedFile.Md5Checksum, tempFilePath, um.FilesAPI)
+	if err != nil {
+		return 0, tempFilePath, err
+	}
+
+	if !hashSumMatch {
+		err := um.FilesAPI.Remove(tempFilePath)
+		if err != nil {
+			um.Logger.Errorf("failed to remove %s: %v", tempFilePath, err)
+		}
+
+		return 0,
+			tempFilePath,
+			fmt.Errorf(
+				"md5 check failed: checksum from server %x doesn't equal the calculated checksum",
+				uploadedFile.Md5Checksum,
+			)
+	}
+
+	return offset, tempFilePath, nil
+}
+
+// removeStalePartialCopies deletes the partial copies of uploads that weren't resumed within partialUploadMaxAge.
+func (um *UploadManager) removeStalePartialCopies() {
+	uploadDir := um.OptionsProvider.GetUploadDir()
+	infos, err := um.FilesAPI.ReadDir(uploadDir)
+	if err != nil {
+		um.Logger.Errorf("failed to list partial copies: %v", err)
+		return
+	}
+
+	for _, fi := range infos {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), partialUploadExt) || time.Since(fi.ModTime()) < partialUploadMaxAge {
+			continue
+		}
+
+		p := filepath.Join(uploadDir, fi.Name())
+		unlock := lockTempFile(p)
+		err := um.FilesAPI.Remove(p)
+		unlock()
+		if err != nil {
+			um.Logger.Errorf("failed to remove partial copy %s: %v", p, err)
+			continue
+		}
+		um.Logger.Debugf("removed partial copy %s, it wasn't resumed for %v", p, partialUploadMaxAge)
+	}
+}
+
+// partialCopySize returns the size of the partial copy of a previous upload, a partial copy larger than the
+// source file can't be resumed and is deleted.
+func (um *UploadManager) partialCopySize(tempFilePath string, size int64) (int64, error) {
+	tempFileExists, err := um.FilesAPI.Exist(tempFilePath)
+	if err != nil || !tempFileExists {
+		return 0, err
+	}
+
+	tempFileSize, err := um.FilesAPI.GetFileSize(tempFilePath)
+	if err != nil {
+		return 0, err
+	}
+
+	if tempFileSize > size {
+		um.Logger.Debugf("temp file %s is larger than the uploaded file, will delete it", tempFilePath)
+		return 0, um.FilesAPI.Remove(tempFilePath)
+	}
+
+	return tempFileSize, nil
+}
+
+// copyRemoteFileFrom appends the remote file from the given offset to the temp file and reports the progress.
+func (um *UploadManager) copyRemoteFileFrom(uploadedFile *models.UploadedFile, tempFilePath string, offset int64) (int64, error) {
+	remoteFile, err := um.SourceFileProvider.OpenAt(uploadedFile.SourceFilePath, offset)
+	if err != nil {
+		return 0, err
+	}
+	defer remoteFile.Close()
+
+	return um.FilesAPI.AppendFile(tempFilePath, &progressReader{
+		reader:  remoteFile,
+		copied:  offset,
+		started: time.Now(),
+		report: func(copied int64) {
+			if um.ProgressReporter == nil {
+				return
+			}
+			um.ProgressReporter.ReportUploadProgress(&models.UploadProgress{
+				ID:          uploadedFile.ID,
+				Filepath:    uploadedFile.DestinationPath,
+				SizeBytes:   uploadedFile.Size,
+				CopiedBytes: copied,
+			})
+		},
+	})
+}
+
+// progressReader calls report with the number of bytes copied at most once per uploadProgressInterval
+type progressReader struct {
+	reader       io.Reader
+	copied       int64
+	started      time.Time
+	lastReported time.Time
+	report       func(copied int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.copied += int64(n)
+
+	now := time.Now()
+	if now.Sub(r.started) >= uploadProgressInterval && now.Sub(r.lastReported) >= uploadProgressInterval {
+		r.lastReported = now
+		r.report(r.copied)
+	}
+
+	return n, err
+}
+
+var tempFileLocks sync.Map
+
+func lockTempFile(path string) (unlock func()) {
+	mu, _ := tempFileLocks.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
 func (um *UploadManager) getUploadedFile(reqPayload []byte) (*models.UploadedFile, error) {
 	uploadedFile := new(models.UploadedFile)
 	err := uploadedFile.FromBytes(reqPayload)
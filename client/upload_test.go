@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/openrport/openrport/share/errors"
 	"github.com/openrport/openrport/share/files"
@@ -40,6 +41,18 @@ func (sfpm *SourceFileProviderMock) Open(path string) (io.ReadCloser, error) {
 	return f.(io.ReadCloser), args.Error(1)
 }
 
+func (sfpm *SourceFileProviderMock) OpenAt(path string, offset int64) (io.ReadCloser, error) {
+	args := sfpm.Called(path, offset)
+
+	f := args.Get(0)
+
+	if f == nil {
+		return nil, args.Error(1)
+	}
+
+	return f.(io.ReadCloser), args.Error(1)
+}
+
 type UploadOptionsProviderMock struct {
 	mock.Mock
 }
@@ -456,3 +469,136 @@ func defaultOptionsCallback(opts *UploadOptionsProviderMock) {
 	opts.On("GetProtectedUploadDirs").Return([]string{})
 	opts.On("IsFileReceptionEnabled").Return(true)
 }
+
+type interruptedReader struct {
+	data string
+}
+
+func (r *interruptedReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func (r *interruptedReader) Close() error {
+	return nil
+}
+
+func TestHandleUploadRequestResume(t *testing.T) {
+	uploadRetryDelay = 0
+	uploadDir := t.TempDir()
+	destination := filepath.Join(t.TempDir(), "file.txt")
+	sourceFilePath := "/data/filepush/blobs/290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56"
+	partialFilePath := filepath.Join(uploadDir, filepath.Base(sourceFilePath)+partialUploadExt)
+
+	uploadedFile := &models.UploadedFile{
+		ID:              "97e97cdd-135a-4620-ab50-d44025b8fe31",
+		SourceFilePath:  sourceFilePath,
+		DestinationPath: destination,
+		Md5Checksum:     test.Md5Hash("some content"),
+		Size:            12,
+	}
+	uploadedFileBytes, err := uploadedFile.ToBytes()
+	require.NoError(t, err)
+
+	optionsProvMock := &UploadOptionsProviderMock{}
+	optionsProvMock.On("GetUploadDir").Return(uploadDir)
+	optionsProvMock.On("GetProtectedUploadDirs").Return([]string{})
+	optionsProvMock.On("IsFileReceptionEnabled").Return(true)
+
+	// the copy of a previous upload was interrupted after 2 bytes, the first retry after 3 more bytes
+	require.NoError(t, os.WriteFile(partialFilePath, []byte("so"), 0600))
+	sourceFileProvider := &SourceFileProviderMock{}
+	sourceFileProvider.On("OpenAt", sourceFilePath, int64(2)).Return(&interruptedReader{data: "me "}, nil)
+	sourceFileProvider.On("OpenAt", sourceFilePath, int64(5)).Return(io.NopCloser(strings.NewReader("content")), nil)
+
+	um := &UploadManager{
+		FilesAPI:           files.NewFileSystem(),
+		OptionsProvider:    optionsProvMock,
+		Logger:             logger.NewLogger("client-upload-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		SourceFileProvider: sourceFileProvider,
+		SysUserLookup:      &test.SysUserProviderMock{},
+	}
+
+	resp, err := um.HandleUploadRequest(uploadedFileBytes)
+	require.NoError(t, err)
+	sourceFileProvider.AssertExpectations(t)
+
+	assert.Equal(t, "success", resp.Status)
+	assert.Equal(t, int64(12), resp.SizeBytes)
+	content, err := os.ReadFile(destination)
+	require.NoError(t, err)
+	assert.Equal(t, "some content", string(content))
+	assert.NoFileExists(t, partialFilePath)
+}
+
+func TestHandleUploadRequestKeepsPartialCopy(t *testing.T) {
+	uploadRetryDelay = 0
+	uploadDir := t.TempDir()
+	sourceFilePath := "/data/filepush/blobs/290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56"
+	partialFilePath := filepath.Join(uploadDir, filepath.Base(sourceFilePath)+partialUploadExt)
+
+	uploadedFile := &models.UploadedFile{
+		ID:              "97e97cdd-135a-4620-ab50-d44025b8fe31",
+		SourceFilePath:  sourceFilePath,
+		DestinationPath: filepath.Join(t.TempDir(), "file.txt"),
+		Md5Checksum:     test.Md5Hash("some content"),
+		Size:            12,
+	}
+	uploadedFileBytes, err := uploadedFile.ToBytes()
+	require.NoError(t, err)
+
+	optionsProvMock := &UploadOptionsProviderMock{}
+	optionsProvMock.On("GetUploadDir").Return(uploadDir)
+	optionsProvMock.On("GetProtectedUploadDirs").Return([]string{})
+	optionsProvMock.On("IsFileReceptionEnabled").Return(true)
+
+	sourceFileProvider := &SourceFileProviderMock{}
+	sourceFileProvider.On("OpenAt", sourceFilePath, int64(0)).Return(&interruptedReader{data: "some "}, nil)
+	sourceFileProvider.On("OpenAt", sourceFilePath, int64(5)).Return(nil, io.ErrUnexpectedEOF)
+
+	um := &UploadManager{
+		FilesAPI:           files.NewFileSystem(),
+		OptionsProvider:    optionsProvMock,
+		Logger:             logger.NewLogger("client-upload-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		SourceFileProvider: sourceFileProvider,
+		SysUserLookup:      &test.SysUserProviderMock{},
+	}
+
+	_, err = um.HandleUploadRequest(uploadedFileBytes)
+	require.EqualError(t, err, "copy failed at 5 of 12 bytes after 3 attempts: unexpected EOF")
+
+	content, err := os.ReadFile(partialFilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "some ", string(content))
+}
+
+func TestRemoveStalePartialCopies(t *testing.T) {
+	uploadDir := t.TempDir()
+	stale := filepath.Join(uploadDir, "stale"+partialUploadExt)
+	recent := filepath.Join(uploadDir, "recent"+partialUploadExt)
+	other := filepath.Join(uploadDir, "other.txt")
+	for _, p := range []string{stale, recent, other} {
+		require.NoError(t, os.WriteFile(p, []byte("data"), 0600))
+	}
+	old := time.Now().Add(-partialUploadMaxAge - time.Minute)
+	require.NoError(t, os.Chtimes(stale, old, old))
+	require.NoError(t, os.Chtimes(other, old, old))
+
+	optionsProvMock := &UploadOptionsProviderMock{}
+	optionsProvMock.On("GetUploadDir").Return(uploadDir)
+	um := &UploadManager{
+		FilesAPI:        files.NewFileSystem(),
+		OptionsProvider: optionsProvMock,
+		Logger:          logger.NewLogger("client-upload-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+	}
+
+	um.removeStalePartialCopies()
+
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, recent)
+	assert.FileExists(t, other)
+}
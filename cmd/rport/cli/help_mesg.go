@@ -129,6 +129,10 @@ var ClientHelp = `
     Applies to the stdout and stderr separately. If exceeded the specified number of bytes are sent.
     Defaults: 2048
 
+    --remote-commands-stream-limit, Limit the maximum length of the command or script output that is streamed to
+    the server while it runs. Applies to the stdout and stderr separately.
+    Defaults: 104857600
+
     --updates-interval, How often after the rport client has started pending updates are summarized.
     Defaults: 4h
 
@@ -40,6 +40,7 @@ func BindPFlagsToViperConfig(pFlags *pflag.FlagSet, viperCfg *viper.Viper) {
 	_ = viperCfg.BindPFlag("remote-commands.enabled", pFlags.Lookup("remote-commands-enabled"))
 	_ = viperCfg.BindPFlag("remote-scripts.enabled", pFlags.Lookup("remote-scripts-enabled"))
 	_ = viperCfg.BindPFlag("remote-commands.send_back_limit", pFlags.Lookup("remote-commands-send-back-limit"))
+	_ = viperCfg.BindPFlag("remote-commands.stream_limit", pFlags.Lookup("remote-commands-stream-limit"))
 
 	_ = viperCfg.BindPFlag("monitoring.enabled", pFlags.Lookup("monitoring-enabled"))
 	_ = viperCfg.BindPFlag("monitoring.interval", pFlags.Lookup("monitoring-interval"))
@@ -55,6 +56,13 @@ func BindPFlagsToViperConfig(pFlags *pflag.FlagSet, viperCfg *viper.Viper) {
 
 	_ = viperCfg.BindPFlag("file-reception.protected", pFlags.Lookup("file-reception-protected"))
 	_ = viperCfg.BindPFlag("file-reception.enabled", pFlags.Lookup("file-reception-enabled"))
+
+	_ = viperCfg.BindPFlag("file-access.enabled", pFlags.Lookup("file-access-enabled"))
+	_ = viperCfg.BindPFlag("file-access.allow", pFlags.Lookup("file-access-allow"))
+	_ = viperCfg.BindPFlag("file-access.protected", pFlags.Lookup("file-access-protected"))
+
+	_ = viperCfg.BindPFlag("client-update.enabled", pFlags.Lookup("client-update-enabled"))
+	_ = viperCfg.BindPFlag("client-update.public_key", pFlags.Lookup("client-update-public-key"))
 }
 
 func SetPFlags(pFlags *pflag.FlagSet) {
@@ -91,6 +99,7 @@ func SetPFlags(pFlags *pflag.FlagSet) {
 	pFlags.Bool("remote-scripts-enabled", false, "")
 	pFlags.String("data-dir", chclient.DefaultDataDir, "")
 	pFlags.Int("remote-commands-send-back-limit", 0, "")
+	pFlags.Int("remote-commands-stream-limit", 0, "")
 	pFlags.Duration("updates-interval", 0, "")
 	pFlags.StringArray("fallback-server", []string{}, "")
 	pFlags.Duration("server-switchback-interval", 0, "")
@@ -107,6 +116,11 @@ func SetPFlags(pFlags *pflag.FlagSet) {
 	pFlags.StringArray("monitoring-net-wan", []string{}, "")
 	pFlags.StringArray("file-reception-protected", []string{}, "")
 	pFlags.Bool("file-reception-enabled", true, "")
+	pFlags.Bool("file-access-enabled", false, "")
+	pFlags.StringArray("file-access-allow", []string{}, "")
+	pFlags.StringArray("file-access-protected", []string{}, "")
+	pFlags.Bool("client-update-enabled", false, "")
+	pFlags.String("client-update-public-key", "", "")
 	pFlags.String("bind-interface", "", "")
 }
 
@@ -122,6 +136,7 @@ func SetViperConfigDefaults(viperCfg *viper.Viper) {
 	viperCfg.SetDefault("remote-commands.deny", []string{`(\||<|>|;|,|\n|&)`})
 	viperCfg.SetDefault("remote-commands.order", []string{"allow", "deny"})
 	viperCfg.SetDefault("remote-commands.send_back_limit", 4194304)
+	viperCfg.SetDefault("remote-commands.stream_limit", 104857600)
 	viperCfg.SetDefault("remote-commands.enabled", true)
 	viperCfg.SetDefault("remote-scripts.enabled", false)
 
@@ -141,4 +156,10 @@ func SetViperConfigDefaults(viperCfg *viper.Viper) {
 
 	viperCfg.SetDefault("file-reception.protected", chclient.FileReceptionGlobs)
 	viperCfg.SetDefault("file-reception.enabled", true)
+
+	viperCfg.SetDefault("file-access.enabled", false)
+	viperCfg.SetDefault("file-access.allow", chclient.FileAccessAllowGlobs)
+	viperCfg.SetDefault("file-access.protected", chclient.FileAccessProtectedGlobs)
+
+	viperCfg.SetDefault("client-update.enabled", false)
 }
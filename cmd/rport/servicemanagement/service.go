@@ -15,6 +15,10 @@ var svcConfig = &service.Config{
 	Name:        "rport",
 	DisplayName: "Rport Client",
 	Description: "Create reverse tunnels with ease.",
+	// on windows the client exits after an update, so the service manager starts the new version
+	Option: service.KeyValue{
+		"OnFailure": "restart",
+	},
 }
 
 func HandleSvcCommand(svcCommand string, configPath string, user string) error {
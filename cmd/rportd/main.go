@@ -47,6 +47,7 @@ const (
 	DefaultRunRemoteCmdTimeoutSec           = 60
 	DefaultMonitoringDataStorageDuration    = "7d"
 	DefaultPairingURL                       = "https://pairing.openrport.io"
+	DefaultLDAPTimeout                      = 10 * time.Second
 )
 
 var (
@@ -239,6 +240,9 @@ var serverHelp = `
     --novnc-root, Specifies local directory path. If specified, rportd will serve
     novnc javascript app from this directory.
 
+    --xtermjs-root, Specifies local directory path. If specified, rportd will serve
+    xterm.js from this directory for the browser terminal of ssh tunnels.
+
     --guacd-address, Specifies network address (host:port) of guacd daemon. If specified, rportd will serve
     remote desktop connections in browser using Guacamole protocol.
 
@@ -315,6 +319,7 @@ func init() {
 	lFlags.String("tunnel-proxy-cert-file", "", "")
 	lFlags.String("tunnel-proxy-key-file", "", "")
 	lFlags.String("novnc-root", "", "")
+	lFlags.String("xtermjs-root", "", "")
 	lFlags.String("guacd-address", "", "")
 
 	cfgPath = pFlags.StringP("config", "c", "", "location of the config file")
@@ -353,6 +358,7 @@ func init() {
 	viperCfg.SetDefault("server.pairing_url", DefaultPairingURL)
 	viperCfg.SetDefault("server.ban_time", 3600)
 	viperCfg.SetDefault("server.jobs_max_results", 10000)
+	viperCfg.SetDefault("server.jobs_max_output_bytes", 10485760)
 	viperCfg.SetDefault("server.tls_min", "1.3")
 	viperCfg.SetDefault("api.user_header", "Authentication-User")
 	viperCfg.SetDefault("api.default_user_group", "Administrators")
@@ -365,15 +371,34 @@ func init() {
 	viperCfg.SetDefault("api.enable_audit_log", true)
 	viperCfg.SetDefault("api.totp_enabled", false)
 	viperCfg.SetDefault("api.audit_log_rotation", auditlog.RotationMonthly)
+	viperCfg.SetDefault("api.record_sessions", false)
+	viperCfg.SetDefault("api.record_tunnels", false)
+	viperCfg.SetDefault("api.recordings_storage_duration", 30*24*time.Hour)
+	viperCfg.SetDefault("api.uploads_retention", 24*time.Hour)
+	viperCfg.SetDefault("api.uploads_max_size", int64(10<<30))
+	viperCfg.SetDefault("ldap.timeout", DefaultLDAPTimeout)
+	viperCfg.SetDefault("ldap.user_filter", "(&(objectClass=person)(uid={username}))")
+	viperCfg.SetDefault("ldap.username_attribute", "uid")
+	viperCfg.SetDefault("ldap.group_filter", "(member={dn})")
+	viperCfg.SetDefault("ldap.cache_ttl", time.Minute)
 	viperCfg.SetDefault("monitoring.data_storage_duration", DefaultMonitoringDataStorageDuration)
 	viperCfg.SetDefault("monitoring.enabled", true)
+	viperCfg.SetDefault("high-availability.enabled", false)
+	viperCfg.SetDefault("high-availability.lease_duration", 15*time.Second)
 	viperCfg.SetDefault("api.max_request_bytes", DefaultMaxRequestBytes)
 	viperCfg.SetDefault("api.max_filepush_size", DefaultMaxFilePushBytes)
 	viperCfg.SetDefault("api.enable_ws_test_endpoints", false)
 	viperCfg.SetDefault("api.totp_login_session_ttl", time.Minute*10)
 	viperCfg.SetDefault("api.totp_account_name", "RPort")
+	viperCfg.SetDefault("api.webauthn_enabled", false)
+	viperCfg.SetDefault("api.webauthn_rp_name", "RPort")
+	viperCfg.SetDefault("api.webauthn_timeout", 2*time.Minute)
 	viperCfg.SetDefault("api.password_min_length", 14)
 	viperCfg.SetDefault("api.password_zxcvbn_minscore", 0)
+	viperCfg.SetDefault("api.password_max_age_days", 0)
+	viperCfg.SetDefault("api.password_history", 0)
+	viperCfg.SetDefault("api.user_lockout_threshold", 0)
+	viperCfg.SetDefault("api.user_lockout_duration", 0)
 	viperCfg.SetDefault("api.tls_min", "1.3")
 	viperCfg.SetDefault("notifications.notification_script_dir", "/usr/local/lib/rport/notification_scripts")
 }
@@ -402,6 +427,7 @@ func bindPFlags() {
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_cert_file", pFlags.Lookup("tunnel-proxy-cert-file"))
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_key_file", pFlags.Lookup("tunnel-proxy-key-file"))
 	_ = viperCfg.BindPFlag("server.novnc_root", pFlags.Lookup("novnc-root"))
+	_ = viperCfg.BindPFlag("server.xtermjs_root", pFlags.Lookup("xtermjs-root"))
 	_ = viperCfg.BindPFlag("server.guacd_address", pFlags.Lookup("guacd-address"))
 
 	_ = viperCfg.BindPFlag("logging.log_file", pFlags.Lookup("log-file"))
@@ -15,13 +15,14 @@ const (
 	UserAddCommand    = "add"
 	UserDeleteCommand = "delete"
 	UserChangeCommand = "change"
+	UserUnlockCommand = "unlock"
 )
 
 var (
 	userCmd = &cobra.Command{
 		Use:   "user",
 		Short: "modify api users",
-		Long:  "Add, change or delete api users",
+		Long:  "Add, change, delete or unlock api users",
 	}
 	userAddCmd = &cobra.Command{
 		Use:     UserAddCommand,
@@ -104,6 +105,29 @@ var (
 			}
 		},
 	}
+	userUnlockCmd = &cobra.Command{
+		Use:     UserUnlockCommand,
+		Short:   "unlock a user",
+		Long:    "Unlock a user who was locked due to too many failed login attempts",
+		Example: "rportd user unlock -u admin",
+		Run: func(*cobra.Command, []string) {
+			mLog := logger.NewMemLogger()
+			err := decodeAndValidateConfig(&mLog)
+			if err != nil {
+				log.Fatalf("Invalid config: %v. See rportd --help", err)
+			}
+
+			userService, err := usercli.NewUserService(cfg)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			err = usercli.UnlockUser(userService, *usernameFlag)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
 
 	usernameFlag    *string
 	twoFASendToFlag *string
@@ -117,6 +141,7 @@ func init() {
 	userCmd.AddCommand(userAddCmd)
 	userCmd.AddCommand(userDeleteCmd)
 	userCmd.AddCommand(userChangeCmd)
+	userCmd.AddCommand(userUnlockCmd)
 
 	usernameFlag = userCmd.PersistentFlags().StringP("username", "u", "", "username [required]")
 	err := userCmd.MarkPersistentFlagRequired("username")
@@ -1,10 +1,13 @@
 package usercli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
+	"github.com/openrport/openrport/db/migration/password_policy"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/chconfig"
 	"github.com/openrport/openrport/share/enums"
@@ -15,12 +18,15 @@ type UserInput struct {
 	Password    string
 	Groups      []string
 	TwoFASendTo string
+	// PasswordExpired forces the user to change the password on the next login
+	PasswordExpired bool
 }
 
 type UserService interface {
 	Create(input UserInput) error
 	Change(input UserInput) error
 	Delete(username string) error
+	Unlock(username string) error
 	ProviderType() enums.ProviderSource
 }
 
@@ -28,7 +34,7 @@ func NewUserService(cfg *chconfig.Config) (UserService, error) {
 	var authDB *sqlx.DB
 	var err error
 	if cfg.Database.Driver != "" {
-		authDB, err = sqlx.Connect(cfg.Database.Driver, cfg.Database.Dsn)
+		authDB, err = backend.Connect(cfg.Database.Driver, cfg.Database.Dsn)
 		if err != nil {
 			return nil, fmt.Errorf("Could not connect to user database: %w", err)
 		}
@@ -39,6 +45,18 @@ func NewUserService(cfg *chconfig.Config) (UserService, error) {
 		return nil, fmt.Errorf("Could not initialize user service: %w", err)
 	}
 
+	// the lockouts and the password history are kept in the same store as used by the server
+	passwordPolicyDB, err := cfg.Backend().Open(backend.Store{
+		Name:           "password_policy",
+		SQLiteFilename: "password_policy.db",
+		AssetNames:     password_policy.AssetNames(),
+		Asset:          password_policy.Asset,
+	}, cfg.Server.GetSQLiteDataSourceOptions())
+	if err != nil {
+		return nil, fmt.Errorf("Could not open password policy database: %w", err)
+	}
+	apiUserService.PasswordPolicyProvider = users.NewPasswordPolicySqliteProvider(passwordPolicyDB)
+
 	return &userServiceImpl{
 		apiUserService: apiUserService,
 	}, nil
@@ -50,10 +68,11 @@ type userServiceImpl struct {
 
 func (u *userServiceImpl) Create(input UserInput) error {
 	return u.apiUserService.Change(&users.User{
-		Username:    input.Username,
-		Password:    input.Password,
-		Groups:      input.Groups,
-		TwoFASendTo: input.TwoFASendTo,
+		Username:        input.Username,
+		Password:        input.Password,
+		PasswordExpired: users.PasswordExpired(input.PasswordExpired),
+		Groups:          input.Groups,
+		TwoFASendTo:     input.TwoFASendTo,
 	}, "")
 }
 
@@ -69,6 +88,10 @@ func (u *userServiceImpl) Delete(username string) error {
 	return u.apiUserService.Delete(username)
 }
 
+func (u *userServiceImpl) Unlock(username string) error {
+	return u.apiUserService.Unlock(context.Background(), username)
+}
+
 func (u *userServiceImpl) ProviderType() enums.ProviderSource {
 	return u.apiUserService.GetProviderType()
 }
@@ -12,11 +12,13 @@ func CreateUser(userService UserService, pr PasswordReader, username string, gro
 		Password:    password,
 		Groups:      groups,
 		TwoFASendTo: twoFASendTo,
+		// the password was chosen by an administrator, so the user must replace it
+		PasswordExpired: true,
 	})
 	if err != nil {
 		return fmt.Errorf("Could not add user: %w", err)
 	}
-	fmt.Println("User added successfully.")
+	fmt.Println("User added successfully. The user must change the password on the first login.")
 
 	return nil
 }
@@ -53,3 +55,13 @@ func DeleteUser(userService UserService, username string) error {
 
 	return nil
 }
+
+func UnlockUser(userService UserService, username string) error {
+	err := userService.Unlock(username)
+	if err != nil {
+		return fmt.Errorf("Could not unlock user: %w", err)
+	}
+	fmt.Println("User unlocked successfully.")
+
+	return nil
+}
@@ -19,10 +19,11 @@ func TestCreateUser(t *testing.T) {
 	require.NoError(t, err)
 
 	expected := UserInput{
-		Username:    "testuser",
-		Password:    "testpassword",
-		Groups:      []string{"group1", "group2"},
-		TwoFASendTo: "test-2fa",
+		Username:        "testuser",
+		Password:        "testpassword",
+		Groups:          []string{"group1", "group2"},
+		TwoFASendTo:     "test-2fa",
+		PasswordExpired: true,
 	}
 	assert.True(t, mockUserService.CreateCalled)
 	assert.Equal(t, expected, mockUserService.UserInput)
@@ -97,11 +98,21 @@ func TestDeleteUser(t *testing.T) {
 	assert.Equal(t, "testuser", mockUserService.DeletedUser)
 }
 
+func TestUnlockUser(t *testing.T) {
+	mockUserService := &userServiceMock{}
+
+	err := UnlockUser(mockUserService, "testuser")
+	require.NoError(t, err)
+
+	assert.Equal(t, "testuser", mockUserService.UnlockedUser)
+}
+
 type userServiceMock struct {
 	CreateCalled bool
 	ChangeCalled bool
 	UserInput    UserInput
 	DeletedUser  string
+	UnlockedUser string
 }
 
 func (m *userServiceMock) Create(input UserInput) error {
@@ -121,6 +132,11 @@ func (m *userServiceMock) Delete(username string) error {
 	return nil
 }
 
+func (m *userServiceMock) Unlock(username string) error {
+	m.UnlockedUser = username
+	return nil
+}
+
 func (m *userServiceMock) ProviderType() enums.ProviderSource {
 	return enums.ProviderSourceMock
 }
@@ -0,0 +1,143 @@
+// Package backend opens the server databases either as separate sqlite files in the data dir
+// or as tables of a shared SQL server, depending on the configuration.
+package backend
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/db/migration/mysql"
+	"github.com/openrport/openrport/db/migration/postgres"
+	mysqldb "github.com/openrport/openrport/db/mysql"
+	postgresdb "github.com/openrport/openrport/db/postgres"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverMySQL    = mysqldb.DriverName
+	DriverPostgres = postgresdb.DriverName
+)
+
+// Store describes one of the server databases.
+type Store struct {
+	// Name identifies the store, it's used to lookup the migrations of other dialects.
+	Name string
+	// SQLiteFilename is the file name of the sqlite DB inside the data dir.
+	SQLiteFilename string
+	// AssetNames and Asset provide the sqlite migrations.
+	AssetNames []string
+	Asset      func(name string) ([]byte, error)
+}
+
+type Config struct {
+	// Driver is the database driver used for all stores, empty means sqlite.
+	Driver string
+	// DSN is the data source name of the shared SQL server, unused for sqlite.
+	DSN string
+	// DataDir is where sqlite files are created.
+	DataDir string
+}
+
+func (c Config) IsSQLite() bool {
+	return c.Driver == "" || c.Driver == DriverSQLite
+}
+
+// SQLitePath returns the path of the sqlite file of the given store.
+func (c Config) SQLitePath(s Store) string {
+	return path.Join(c.DataDir, s.SQLiteFilename)
+}
+
+// Open returns a DB instance of the given store with DB scheme migrated to the latest version.
+// sqliteOptions are ignored for other drivers.
+func (c Config) Open(s Store, sqliteOptions sqlite.DataSourceOptions) (*sqlx.DB, error) {
+	switch c.Driver {
+	case "", DriverSQLite:
+		return sqlite.New(c.SQLitePath(s), s.AssetNames, s.Asset, sqliteOptions)
+	case DriverMySQL:
+		assetNames, asset, err := mysql.Assets(s.Name)
+		if err != nil {
+			return nil, err
+		}
+		return mysqldb.New(c.DSN, s.Name, assetNames, asset)
+	case DriverPostgres:
+		assetNames, asset, err := postgres.Assets(s.Name)
+		if err != nil {
+			return nil, err
+		}
+		return postgresdb.New(c.DSN, s.Name, assetNames, asset)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", c.Driver)
+	}
+}
+
+// Connect returns a DB instance without migrations, it's used for the user tables which are created manually.
+func Connect(driverName, dsn string) (*sqlx.DB, error) {
+	if driverName == DriverPostgres {
+		return postgresdb.Connect(dsn)
+	}
+	return sqlx.Connect(driverName, dsn)
+}
+
+// DateTime wraps the given column or placeholder so stored times are compared chronologically.
+// sqlite stores times as text including the zone offset, so they are normalized with DATETIME().
+func DateTime(driverName, expr string) string {
+	if driverName == DriverMySQL || driverName == DriverPostgres {
+		return expr
+	}
+	return "DATETIME(" + expr + ")"
+}
+
+// ScanText returns the content of a text column read by a sql.Scanner, mysql returns text columns as bytes while
+// sqlite and postgres return strings.
+func ScanText(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("expected to have string, got %T", value)
+	}
+}
+
+// ReplaceInto returns an insert statement with named parameters for the given columns, which replaces an existing
+// row with the same keys. sqlite and mysql support REPLACE INTO, postgres requires an upsert on the keys.
+func ReplaceInto(driverName, table string, keys []string, columns ...string) string {
+	params := make([]string, 0, len(columns))
+	for _, c := range columns {
+		params = append(params, ":"+c)
+	}
+	values := "(" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")"
+	if driverName != DriverPostgres {
+		return "REPLACE INTO " + table + " " + values
+	}
+
+	updates := make([]string, 0, len(columns))
+	for _, c := range columns {
+		updates = append(updates, c+" = EXCLUDED."+c)
+	}
+	return "INSERT INTO " + table + " " + values +
+		" ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(updates, ", ")
+}
+
+// InsertIgnore returns an insert statement with named parameters for the given columns, which does nothing if a row
+// with the same unique keys exists.
+func InsertIgnore(driverName, table string, columns ...string) string {
+	params := make([]string, 0, len(columns))
+	for _, c := range columns {
+		params = append(params, ":"+c)
+	}
+	values := "(" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(params, ", ") + ")"
+	switch driverName {
+	case DriverMySQL:
+		return "INSERT IGNORE INTO " + table + " " + values
+	case DriverPostgres:
+		return "INSERT INTO " + table + " " + values + " ON CONFLICT DO NOTHING"
+	default:
+		return "INSERT OR IGNORE INTO " + table + " " + values
+	}
+}
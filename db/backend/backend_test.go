@@ -0,0 +1,240 @@
+package backend
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/dummy"
+	"github.com/openrport/openrport/db/migration/mysql"
+	"github.com/openrport/openrport/db/migration/password_policy"
+	"github.com/openrport/openrport/db/migration/postgres"
+	"github.com/openrport/openrport/db/migration/roles"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+var dummyStore = Store{
+	Name:           "dummy",
+	SQLiteFilename: "dummy.db",
+	AssetNames:     dummy.AssetNames(),
+	Asset:          dummy.Asset,
+}
+
+// stores lists all stores having mysql and postgres migrations
+var stores = []string{
+	"api_sessions",
+	"api_token",
+	"auditlog",
+	"client_groups",
+	"clients",
+	"ha",
+	"jobs",
+	"library",
+	"monitoring",
+	"notifications",
+	"password_policy",
+	"roles",
+	"vaults",
+	"webauthn",
+}
+
+func TestOpenSQLite(t *testing.T) {
+	c := Config{DataDir: t.TempDir()}
+
+	db, err := c.Open(dummyStore, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, DriverSQLite, db.DriverName())
+	_, err = os.Stat(c.SQLitePath(dummyStore))
+	assert.NoError(t, err)
+}
+
+func TestOpenUnsupportedDriver(t *testing.T) {
+	c := Config{Driver: "oracle"}
+
+	_, err := c.Open(dummyStore, sqlite.DataSourceOptions{})
+	assert.EqualError(t, err, `unsupported database driver "oracle"`)
+}
+
+func TestDateTime(t *testing.T) {
+	assert.Equal(t, "DATETIME(expires_at)", DateTime(DriverSQLite, "expires_at"))
+	assert.Equal(t, "expires_at", DateTime(DriverMySQL, "expires_at"))
+	assert.Equal(t, "expires_at", DateTime(DriverPostgres, "expires_at"))
+}
+
+func TestScanText(t *testing.T) {
+	data, err := ScanText("sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sqlite"), data)
+
+	data, err = ScanText([]byte("mysql"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("mysql"), data)
+
+	_, err = ScanText(1)
+	assert.EqualError(t, err, "expected to have string, got int")
+}
+
+func TestReplaceInto(t *testing.T) {
+	assert.Equal(t,
+		"REPLACE INTO roles (name, description) VALUES (:name, :description)",
+		ReplaceInto(DriverSQLite, "roles", []string{"name"}, "name", "description"),
+	)
+	assert.Equal(t,
+		"REPLACE INTO roles (name, description) VALUES (:name, :description)",
+		ReplaceInto(DriverMySQL, "roles", []string{"name"}, "name", "description"),
+	)
+	assert.Equal(t,
+		"INSERT INTO roles (name, description) VALUES (:name, :description) ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description",
+		ReplaceInto(DriverPostgres, "roles", []string{"name"}, "name", "description"),
+	)
+}
+
+func TestInsertIgnore(t *testing.T) {
+	assert.Equal(t,
+		"INSERT OR IGNORE INTO user_lockouts (username) VALUES (:username)",
+		InsertIgnore(DriverSQLite, "user_lockouts", "username"),
+	)
+	assert.Equal(t,
+		"INSERT IGNORE INTO user_lockouts (username) VALUES (:username)",
+		InsertIgnore(DriverMySQL, "user_lockouts", "username"),
+	)
+	assert.Equal(t,
+		"INSERT INTO user_lockouts (username) VALUES (:username) ON CONFLICT DO NOTHING",
+		InsertIgnore(DriverPostgres, "user_lockouts", "username"),
+	)
+}
+
+func TestMySQLMigrationsExist(t *testing.T) {
+	for _, store := range stores {
+		names, asset, err := mysql.Assets(store)
+		require.NoError(t, err, store)
+		require.NotEmpty(t, names, store)
+		for _, name := range names {
+			_, err := asset(name)
+			assert.NoError(t, err, name)
+		}
+	}
+
+	_, _, err := mysql.Assets("unknown")
+	assert.Error(t, err)
+}
+
+func TestPostgresMigrationsExist(t *testing.T) {
+	for _, store := range stores {
+		names, asset, err := postgres.Assets(store)
+		require.NoError(t, err, store)
+		require.NotEmpty(t, names, store)
+		for _, name := range names {
+			_, err := asset(name)
+			assert.NoError(t, err, name)
+		}
+
+		// both servers must run the same migration versions
+		mysqlNames, _, err := mysql.Assets(store)
+		require.NoError(t, err, store)
+		assert.ElementsMatch(t, mysqlNames, names, store)
+	}
+
+	_, _, err := postgres.Assets("unknown")
+	assert.Error(t, err)
+}
+
+// TestOpenMySQL migrates all stores on a MySQL server, it's skipped unless RPORT_TEST_MYSQL_DSN is set,
+// e.g. RPORT_TEST_MYSQL_DSN="rport:password@tcp(127.0.0.1:3306)/rport_test"
+func TestOpenMySQL(t *testing.T) {
+	dsn := os.Getenv("RPORT_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("RPORT_TEST_MYSQL_DSN not set")
+	}
+	testOpenAllStores(t, Config{Driver: DriverMySQL, DSN: dsn})
+}
+
+// TestOpenPostgres migrates all stores on a PostgreSQL server, it's skipped unless RPORT_TEST_POSTGRES_DSN is set,
+// e.g. RPORT_TEST_POSTGRES_DSN="host=127.0.0.1 user=rport password=password dbname=rport_test sslmode=disable"
+func TestOpenPostgres(t *testing.T) {
+	dsn := os.Getenv("RPORT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("RPORT_TEST_POSTGRES_DSN not set")
+	}
+	testOpenAllStores(t, Config{Driver: DriverPostgres, DSN: dsn})
+}
+
+func testOpenAllStores(t *testing.T, c Config) {
+	for _, store := range stores {
+		db, err := c.Open(Store{Name: store}, sqlite.DataSourceOptions{})
+		require.NoError(t, err, store)
+		assert.Equal(t, c.Driver, db.DriverName())
+		require.NoError(t, db.Close())
+
+		// migrations are applied only once
+		db, err = c.Open(Store{Name: store}, sqlite.DataSourceOptions{})
+		require.NoError(t, err, store)
+		require.NoError(t, db.Close())
+	}
+
+	testDialectQueries(t, c, Store{Name: "roles"}, Store{Name: "password_policy"})
+}
+
+// TestDialectQueriesSQLite runs the statements of the dialect helpers on sqlite, the same checks run on the MySQL and
+// PostgreSQL servers by TestOpenMySQL and TestOpenPostgres.
+func TestDialectQueriesSQLite(t *testing.T) {
+	c := Config{DataDir: t.TempDir()}
+	testDialectQueries(t, c,
+		Store{
+			Name:           "roles",
+			SQLiteFilename: "roles.db",
+			AssetNames:     roles.AssetNames(),
+			Asset:          roles.Asset,
+		},
+		Store{
+			Name:           "password_policy",
+			SQLiteFilename: "password_policy.db",
+			AssetNames:     password_policy.AssetNames(),
+			Asset:          password_policy.Asset,
+		},
+	)
+}
+
+func testDialectQueries(t *testing.T, c Config, rolesStore, passwordPolicyStore Store) {
+	db, err := c.Open(rolesStore, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("DELETE FROM roles WHERE name = ?", "backend-test")
+	require.NoError(t, err)
+	defer db.Exec("DELETE FROM roles WHERE name = ?", "backend-test")
+
+	q := ReplaceInto(db.DriverName(), "roles", []string{"name"}, "name", "description", "permissions", "resources", "user_groups")
+	for _, description := range []string{"first", "second"} {
+		_, err = db.NamedExec(q, map[string]interface{}{
+			"name":        "backend-test",
+			"description": description,
+			"permissions": "[]",
+			"resources":   "{}",
+			"user_groups": "[]",
+		})
+		require.NoError(t, err)
+	}
+
+	var descriptions []string
+	require.NoError(t, db.Select(&descriptions, "SELECT description FROM roles WHERE name = ?", "backend-test"))
+	assert.Equal(t, []string{"second"}, descriptions)
+
+	lockouts, err := c.Open(passwordPolicyStore, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer lockouts.Close()
+	defer lockouts.Exec("DELETE FROM user_lockouts WHERE username = ?", "backend-test")
+
+	q = InsertIgnore(lockouts.DriverName(), "user_lockouts", "username")
+	for i := 0; i < 2; i++ {
+		_, err = lockouts.NamedExec(q, map[string]interface{}{"username": "backend-test"})
+		require.NoError(t, err)
+	}
+
+	var count int
+	require.NoError(t, lockouts.Get(&count, "SELECT COUNT(*) FROM user_lockouts WHERE username = ?", "backend-test"))
+	assert.Equal(t, 1, count)
+}
@@ -6,6 +6,8 @@
 // 002_plural_and_name.up.sql (169B)
 // 003_init.down.sql (57B)
 // 003_init.up.sql (513B)
+// 004_restrictions.down.sql (53B)
+// 004_restrictions.up.sql (57B)
 
 package api_token
 
@@ -193,6 +195,46 @@ func _003_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_restrictionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x48\x2c\xc8\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x4e\x50\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x28\x4a\x2d\x2e\x29\xca\x4c\x2e\xc9\xcc\x07\x4a\x59\x73\x01\x00\x9a\x8c\x8d\x00\x35\x00\x00\x00")
+
+func _004_restrictionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_restrictionsDownSql,
+		"004_restrictions.down.sql",
+	)
+}
+
+func _004_restrictionsDownSql() (*asset, error) {
+	bytes, err := _004_restrictionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_restrictions.down.sql", size: 53, mode: os.FileMode(0644), modTime: time.Unix(1792139600, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x83, 0xfc, 0xf0, 0x38, 0xc2, 0x6c, 0xdd, 0xcc, 0x3, 0x16, 0xfd, 0xd2, 0x3e, 0x72, 0x75, 0x45, 0x1b, 0x9c, 0xba, 0xfb, 0x85, 0x74, 0x35, 0xde, 0x1c, 0xc8, 0x34, 0x58, 0xb1, 0x94, 0xc6, 0x77}}
+	return a, nil
+}
+
+var __004_restrictionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x48\x2c\xc8\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x4e\x50\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x28\x4a\x2d\x2e\x29\xca\x4c\x2e\xc9\xcc\x07\xc9\x84\xb8\x46\x84\x58\x73\x01\x00\xbe\x66\xf0\xbd\x39\x00\x00\x00")
+
+func _004_restrictionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_restrictionsUpSql,
+		"004_restrictions.up.sql",
+	)
+}
+
+func _004_restrictionsUpSql() (*asset, error) {
+	bytes, err := _004_restrictionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_restrictions.up.sql", size: 57, mode: os.FileMode(0644), modTime: time.Unix(1792139600, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2a, 0x2d, 0x3d, 0x3b, 0x99, 0xe5, 0xe, 0x6e, 0xb5, 0x3e, 0x54, 0x91, 0x33, 0x86, 0x97, 0xc1, 0x82, 0x93, 0x7d, 0x80, 0x7a, 0xa1, 0x88, 0x70, 0x41, 0xe6, 0x47, 0xc5, 0x7c, 0x1c, 0xe5, 0x14}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +332,8 @@ var _bindata = map[string]func() (*asset, error){
 	"002_plural_and_name.up.sql":   _002_plural_and_nameUpSql,
 	"003_init.down.sql":            _003_initDownSql,
 	"003_init.up.sql":              _003_initUpSql,
+	"004_restrictions.down.sql": _004_restrictionsDownSql,
+	"004_restrictions.up.sql": _004_restrictionsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +388,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_plural_and_name.up.sql":   {_002_plural_and_nameUpSql, map[string]*bintree{}},
 	"003_init.down.sql":            {_003_initDownSql, map[string]*bintree{}},
 	"003_init.up.sql":              {_003_initUpSql, map[string]*bintree{}},
+	"004_restrictions.down.sql": {_004_restrictionsDownSql, map[string]*bintree{}},
+	"004_restrictions.up.sql": {_004_restrictionsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
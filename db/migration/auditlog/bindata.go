@@ -2,6 +2,8 @@
 // sources:
 // 001_init.down.sql (23B)
 // 001_init.up.sql (928B)
+// 002_recording_id.down.sql (51B)
+// 002_recording_id.up.sql (75B)
 
 package auditlog
 
@@ -109,6 +111,46 @@ func _001_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __002_recording_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x48\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x4f\x50\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x28\x4a\x4d\xce\x2f\x4a\xc9\xcc\x4b\x8f\xcf\x4c\x49\xb0\xe6\x02\x00\xf7\x2a\xac\xce\x33\x00\x00\x00")
+
+func _002_recording_idDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_recording_idDownSql,
+		"002_recording_id.down.sql",
+	)
+}
+
+func _002_recording_idDownSql() (*asset, error) {
+	bytes, err := _002_recording_idDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_recording_id.down.sql", size: 51, mode: os.FileMode(0644), modTime: time.Unix(1792130996, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x25, 0x79, 0xd7, 0x8b, 0xfb, 0x65, 0xc0, 0x70, 0xf0, 0x52, 0x15, 0xef, 0x42, 0x86, 0x1, 0x3e, 0x7d, 0xa8, 0xf6, 0x4c, 0x3a, 0xed, 0x11, 0x24, 0x72, 0xe5, 0xdb, 0xf8, 0x49, 0xd, 0xb7, 0x35}}
+	return a, nil
+}
+
+var __002_recording_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x48\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x4f\x50\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\x28\x4a\x4d\xce\x2f\x4a\xc9\xcc\x4b\x8f\xcf\x4c\x49\x50\x08\x71\x8d\x08\x51\xf0\xf3\x07\xe2\x50\x1f\x1f\x05\x17\x57\x37\xc7\x50\x9f\x10\x05\x75\x75\x6b\x2e\x00\xfe\xb7\xfa\x40\x4b\x00\x00\x00")
+
+func _002_recording_idUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_recording_idUpSql,
+		"002_recording_id.up.sql",
+	)
+}
+
+func _002_recording_idUpSql() (*asset, error) {
+	bytes, err := _002_recording_idUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_recording_id.up.sql", size: 75, mode: os.FileMode(0644), modTime: time.Unix(1792130996, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x93, 0xa1, 0x1b, 0x61, 0xe7, 0x92, 0xf0, 0xe5, 0x64, 0x54, 0xbf, 0x47, 0xb5, 0xa7, 0xe8, 0xf8, 0x40, 0xac, 0x6c, 0xa9, 0x5, 0xac, 0xf2, 0xc3, 0x7e, 0x2f, 0x5a, 0xf6, 0x8d, 0x5, 0x66, 0xe2}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -200,8 +242,10 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql": _001_initDownSql,
-	"001_init.up.sql":   _001_initUpSql,
+	"001_init.down.sql":         _001_initDownSql,
+	"001_init.up.sql":           _001_initUpSql,
+	"002_recording_id.down.sql": _002_recording_idDownSql,
+	"002_recording_id.up.sql":   _002_recording_idUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -250,8 +294,10 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql": {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":   {_001_initUpSql, map[string]*bintree{}},
+	"001_init.down.sql":         {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":           {_001_initUpSql, map[string]*bintree{}},
+	"002_recording_id.down.sql": {_002_recording_idDownSql, map[string]*bintree{}},
+	"002_recording_id.up.sql":   {_002_recording_idUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1 @@
+ALTER TABLE `auditlog` ADD COLUMN `recording_id` TEXT NOT NULL DEFAULT '';
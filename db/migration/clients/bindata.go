@@ -4,8 +4,10 @@
 // 001_init.up.sql (356B)
 // 002_stored_tunnels.down.sql (27B)
 // 002_stored_tunnels.up.sql (251B)
-// 003_add_tunnel_fields.down.sql (0)
+// 003_add_tunnel_fields.down.sql (0B)
 // 003_add_tunnel_fields.up.sql (104B)
+// 004_autostart_tunnels.down.sql (194B)
+// 004_autostart_tunnels.up.sql (642B)
 
 package clients
 
@@ -193,6 +195,46 @@ func _003_add_tunnel_fieldsUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_autostart_tunnelsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\x2f\xca\x2f\x2d\x88\x2f\x2e\xc9\x2f\x4a\x4d\x89\x2f\x29\xcd\xcb\x4b\xcd\x29\xb6\xe6\x72\xf4\x09\x71\x0d\x82\xaa\x40\x95\x53\x70\x01\xe9\x75\xf6\xf7\x09\xf5\xf5\x53\x48\x2c\x2d\xc9\x2f\x2e\x49\x2c\x2a\x21\x5d\x47\x7c\x5a\x51\x7e\x2e\x19\xda\x4a\xf3\x4a\x32\x73\xac\xb9\x00\x24\xf5\xb2\xb1\xc2\x00\x00\x00")
+
+func _004_autostart_tunnelsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_autostart_tunnelsDownSql,
+		"004_autostart_tunnels.down.sql",
+	)
+}
+
+func _004_autostart_tunnelsDownSql() (*asset, error) {
+	bytes, err := _004_autostart_tunnelsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_autostart_tunnels.down.sql", size: 194, mode: os.FileMode(0644), modTime: time.Unix(1792138006, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa4, 0xf8, 0x1b, 0xf, 0xa7, 0x2e, 0x73, 0xc3, 0x3a, 0x7c, 0xda, 0x8f, 0xbf, 0xa9, 0xd2, 0x7e, 0xe, 0xe6, 0x8b, 0xc2, 0xd6, 0x87, 0xfd, 0x83, 0x70, 0x93, 0x7b, 0x2, 0xf, 0xaf, 0xc4, 0xac}}
+	return a, nil
+}
+
+var __004_autostart_tunnelsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x95\x91\x51\x6b\xc3\x20\x14\x85\xdf\xf3\x2b\xee\xe3\x06\x7b\xd8\xfb\x9e\x4c\xe3\x20\xcc\x98\x11\x0c\xb4\x4f\xe2\x52\xdb\x0a\x89\x06\x73\x85\xfe\xfc\x49\xd2\x2d\x9d\xdd\xa0\xf3\xcd\x73\xcf\x77\x94\x73\x09\x13\xb4\x01\x41\x72\x46\x61\x42\xe7\xf5\x5e\x62\xb0\x56\xf7\x13\x90\xa2\x00\x15\xd0\x4d\xa8\x3c\x42\x5e\xd7\x8c\x12\x0e\xbc\x16\xc0\x5b\xc6\xa0\xa0\xaf\xa4\x65\x02\x9e\x5f\x32\x72\x67\x88\x3c\x78\x37\x80\xa0\x5b\xf1\x0f\x26\x58\x34\xfd\x05\xca\x36\x0d\x25\x82\x5e\xb0\xa3\x77\x61\x94\x09\xfc\x90\x41\x3c\x66\x3f\x13\xf0\xde\x94\x15\x69\x76\xf0\x46\x77\xdf\x3f\x7f\x9a\x1d\x5d\x6f\xb4\x45\xb9\x64\x7c\xd9\x13\x8b\xd7\x0a\x63\xb4\x42\x28\xe2\xab\xa2\xac\xe8\x32\xb0\x6a\xd0\x33\xb0\x5c\xa7\xee\xa4\x7f\x08\x5e\x0f\x0e\xb5\x34\xe3\xad\x36\xba\xd8\x25\x6f\xab\x9c\x36\x8b\x3e\x86\x8f\xde\x74\xb7\xba\xea\xfa\x2b\xfa\x10\x3c\x9e\xb4\x97\x6e\x44\xe3\xec\x74\x35\xb9\x67\x43\x89\x73\x5d\x43\x3a\x58\xbb\xce\x1e\xd7\xb6\x4b\x5e\xd0\x6d\xac\xf4\x2c\x7f\x6b\x5c\x26\x4d\xce\x91\x35\xff\x63\x3b\x89\x39\xbe\xf2\x09\x3a\x12\x3a\x4b\x82\x02\x00\x00")
+
+func _004_autostart_tunnelsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_autostart_tunnelsUpSql,
+		"004_autostart_tunnels.up.sql",
+	)
+}
+
+func _004_autostart_tunnelsUpSql() (*asset, error) {
+	bytes, err := _004_autostart_tunnelsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_autostart_tunnels.up.sql", size: 642, mode: os.FileMode(0644), modTime: time.Unix(1792138006, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x18, 0xf6, 0x53, 0x3f, 0xbb, 0x19, 0x6, 0xd4, 0x13, 0xfd, 0x30, 0xa5, 0x9, 0x93, 0x4d, 0xec, 0xd3, 0x6e, 0x89, 0x9b, 0xa9, 0x5e, 0x5f, 0xea, 0x3, 0xf3, 0xd1, 0x7, 0xf2, 0x3c, 0xfc, 0x55}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +332,8 @@ var _bindata = map[string]func() (*asset, error){
 	"002_stored_tunnels.up.sql":      _002_stored_tunnelsUpSql,
 	"003_add_tunnel_fields.down.sql": _003_add_tunnel_fieldsDownSql,
 	"003_add_tunnel_fields.up.sql":   _003_add_tunnel_fieldsUpSql,
+	"004_autostart_tunnels.down.sql": _004_autostart_tunnelsDownSql,
+	"004_autostart_tunnels.up.sql":   _004_autostart_tunnelsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +388,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_stored_tunnels.up.sql":      {_002_stored_tunnelsUpSql, map[string]*bintree{}},
 	"003_add_tunnel_fields.down.sql": {_003_add_tunnel_fieldsDownSql, map[string]*bintree{}},
 	"003_add_tunnel_fields.up.sql":   {_003_add_tunnel_fieldsUpSql, map[string]*bintree{}},
+	"004_autostart_tunnels.down.sql": {_004_autostart_tunnelsDownSql, map[string]*bintree{}},
+	"004_autostart_tunnels.up.sql":   {_004_autostart_tunnelsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
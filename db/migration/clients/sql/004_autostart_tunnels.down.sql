@@ -0,0 +1,4 @@
+DROP TABLE group_stored_tunnels;
+ALTER TABLE stored_tunnels DROP COLUMN autostart;
+ALTER TABLE stored_tunnels DROP COLUMN autostart_from;
+ALTER TABLE stored_tunnels DROP COLUMN autostart_until;
@@ -0,0 +1,22 @@
+ALTER TABLE stored_tunnels ADD autostart BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE stored_tunnels ADD autostart_from TEXT;
+ALTER TABLE stored_tunnels ADD autostart_until TEXT;
+
+CREATE TABLE group_stored_tunnels (
+    id TEXT PRIMARY KEY NOT NULL,
+    client_group_id TEXT NOT NULL,
+    created_at DATETIME,
+    name TEXT,
+    scheme TEXT,
+    remote_ip TEXT,
+    remote_port NUMBER,
+    public_port NUMBER,
+    acl TEXT,
+    further_options TEXT,
+    autostart BOOLEAN NOT NULL DEFAULT 0,
+    autostart_from TEXT,
+    autostart_until TEXT
+);
+
+CREATE INDEX idx_group_stored_tunnels_client_group_id
+    ON group_stored_tunnels (client_group_id);
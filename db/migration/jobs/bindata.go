@@ -6,6 +6,8 @@
 // 002_schedules.up.sql (228B)
 // 003_multi_job_schedule_id.down.sql (0)
 // 003_multi_job_schedule_id.up.sql (50B)
+// 004_job_outputs.down.sql (24B)
+// 004_job_outputs.up.sql (220B)
 
 package jobs
 
@@ -193,6 +195,46 @@ func _003_multi_job_schedule_idUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_job_outputsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xc8\xca\x4f\x8a\xcf\x2f\x2d\x29\x28\x2d\x29\xb6\xe6\x02\x00\xbe\xba\xc6\x65\x18\x00\x00\x00")
+
+func _004_job_outputsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_job_outputsDownSql,
+		"004_job_outputs.down.sql",
+	)
+}
+
+func _004_job_outputsDownSql() (*asset, error) {
+	bytes, err := _004_job_outputsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_job_outputs.down.sql", size: 24, mode: os.FileMode(0644), modTime: time.Unix(1792134819, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x59, 0x5e, 0x33, 0xba, 0xf4, 0xaa, 0x98, 0xbb, 0xfe, 0xbd, 0x97, 0x70, 0xa3, 0x9a, 0x82, 0xc8, 0x50, 0x7f, 0x37, 0xec, 0x16, 0x76, 0xf0, 0x25, 0x9c, 0x2a, 0x6e, 0xb9, 0xd5, 0x4f, 0xc7, 0x50}}
+	return a, nil
+}
+
+var __004_job_outputsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6d\x8e\xc1\x0a\x82\x40\x14\x45\xf7\x7e\xc5\x5d\x2a\xf8\x07\xad\x34\x1f\x35\x34\x3a\x31\x3c\x31\x57\x32\xe6\x08\x0a\x61\xe4\x73\xd1\xdf\x37\xb4\x0a\xe9\xc2\xdd\x5c\xce\x85\x73\xb4\x94\x31\x81\xb3\x5c\x13\xe6\xa5\xef\x96\x4d\x9e\x9b\xac\x88\x23\x84\xcc\xd3\x00\xa6\x1b\xa3\x32\xa1\xb5\xd6\xe9\x77\xee\xdf\xe2\xbb\x65\x1c\x57\x2f\x50\x15\xd3\x89\xec\x8e\x58\xe5\xe5\xdd\xe3\xdf\x77\x70\xe2\x90\x6b\x93\xef\xf6\x7b\x38\x88\x1f\x3a\x27\x28\x82\x12\xab\x92\x76\xc4\xd5\xaa\x32\xb3\x2d\x2e\xd4\x22\x0e\x66\xe9\xaf\x47\x12\x25\x68\x14\x9f\x4d\xcd\xb0\xa6\x51\xc5\x21\xfa\x00\x80\x98\x0e\x88\xdc\x00\x00\x00")
+
+func _004_job_outputsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_job_outputsUpSql,
+		"004_job_outputs.up.sql",
+	)
+}
+
+func _004_job_outputsUpSql() (*asset, error) {
+	bytes, err := _004_job_outputsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_job_outputs.up.sql", size: 220, mode: os.FileMode(0644), modTime: time.Unix(1792134819, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7b, 0xdf, 0xe1, 0xa5, 0xc7, 0xb2, 0xa2, 0xca, 0xbe, 0x5f, 0x22, 0x57, 0xf5, 0xe8, 0x7e, 0x6a, 0x4e, 0xba, 0xff, 0x5f, 0xd5, 0x37, 0x44, 0x68, 0xf, 0x42, 0x82, 0x9f, 0x90, 0x63, 0x45, 0x8}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +332,8 @@ var _bindata = map[string]func() (*asset, error){
 	"002_schedules.up.sql":               _002_schedulesUpSql,
 	"003_multi_job_schedule_id.down.sql": _003_multi_job_schedule_idDownSql,
 	"003_multi_job_schedule_id.up.sql":   _003_multi_job_schedule_idUpSql,
+	"004_job_outputs.down.sql":           _004_job_outputsDownSql,
+	"004_job_outputs.up.sql":             _004_job_outputsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +388,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_schedules.up.sql":               {_002_schedulesUpSql, map[string]*bintree{}},
 	"003_multi_job_schedule_id.down.sql": {_003_multi_job_schedule_idDownSql, map[string]*bintree{}},
 	"003_multi_job_schedule_id.up.sql":   {_003_multi_job_schedule_idUpSql, map[string]*bintree{}},
+	"004_job_outputs.down.sql":           {_004_job_outputsDownSql, map[string]*bintree{}},
+	"004_job_outputs.up.sql":             {_004_job_outputsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,8 @@
+CREATE TABLE job_outputs (
+    jid TEXT NOT NULL,
+    byte_offset INTEGER NOT NULL,
+    stream TEXT NOT NULL,
+    data BLOB NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (jid, byte_offset)
+) WITHOUT ROWID;
@@ -6,6 +6,8 @@
 // 002_indexes.up.sql (261B)
 // 003_add_net.down.sql (298B)
 // 003_add_net.up.sql (325B)
+// 004_tunnel_usage.up.sql (598B)
+// 004_tunnel_usage.down.sql (25B)
 
 package monitoring
 
@@ -193,6 +195,46 @@ func _003_add_netUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_tunnel_usageUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9d\x91\xcf\x6a\xc3\x30\x0c\xc6\xef\x79\x0a\xe1\x53\x0a\xf5\x13\xec\x94\xb5\xda\x30\x4b\xdd\x91\x78\x90\x9e\xd2\x34\xd5\x86\x21\x75\x46\x6c\xc3\xf6\xf6\xb3\xdb\xad\x64\xff\x52\xa8\x4e\xb6\xf4\x93\xf8\xa4\x8f\x73\xe0\x13\x91\x70\x0e\xaa\xd9\x75\x04\xd6\x0d\xbe\x75\x7e\x20\x78\xee\x07\x70\xde\x18\xea\x6a\x6f\x9b\x17\x8a\xcc\xe4\x8c\x45\x81\x99\x42\x50\xd9\x6d\x8e\x20\xee\x40\xae\x15\x60\x25\x4a\x55\x02\x1b\x0f\x62\x49\x9a\x40\x08\xd6\x76\x9a\x8c\xab\xf5\x9e\x85\x9f\xc2\x4a\xc5\xec\xb1\x4d\x3e\xe5\xf9\xfc\x04\x7d\x76\x5e\x80\xf4\x81\xac\x6b\x0e\xaf\x11\x5a\x06\x15\x4a\xac\xf0\x27\xb4\x7b\x77\x64\x6b\x6d\x22\x03\x42\x2a\xbc\xc7\x02\xfe\x86\x7a\xef\xd8\x04\xd4\xf6\x41\x53\xeb\x74\x6f\x2c\xfb\x0f\x7a\x2c\xc4\x2a\x2b\x36\xf0\x80\x1b\x48\xcf\x9b\xce\xe1\xbc\x4f\x78\x7e\xa9\x9e\x25\xb3\x9b\x8b\xe7\x0d\x75\x61\xf6\xf4\x46\xf6\x64\xcd\xd1\xae\x6b\x0c\x12\x72\x89\xd5\x77\x4b\xea\xd1\x01\xd7\x12\xb6\xe3\xda\x16\xd2\x5f\x47\xce\xca\x45\x94\xfc\x01\x0e\xf0\x8f\xf5\x56\x02\x00\x00")
+
+func _004_tunnel_usageUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_tunnel_usageUpSql,
+		"004_tunnel_usage.up.sql",
+	)
+}
+
+func _004_tunnel_usageUpSql() (*asset, error) {
+	bytes, err := _004_tunnel_usageUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_tunnel_usage.up.sql", size: 598, mode: os.FileMode(0644), modTime: time.Unix(1792135547, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4e, 0x3a, 0xea, 0x7d, 0x56, 0xa5, 0x95, 0x2c, 0xf8, 0xf5, 0x9d, 0x60, 0x38, 0x23, 0x85, 0xb2, 0x17, 0x7a, 0x32, 0x9d, 0xd3, 0x17, 0xaa, 0xc3, 0x4f, 0x91, 0x6, 0x8e, 0xcb, 0x1f, 0xdd, 0x25}}
+	return a, nil
+}
+
+var __004_tunnel_usageDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x28\x29\xcd\xcb\x4b\xcd\x89\x2f\x2d\x4e\x4c\x4f\xb5\xe6\x02\x00\x84\x24\xcc\x31\x19\x00\x00\x00")
+
+func _004_tunnel_usageDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_tunnel_usageDownSql,
+		"004_tunnel_usage.down.sql",
+	)
+}
+
+func _004_tunnel_usageDownSql() (*asset, error) {
+	bytes, err := _004_tunnel_usageDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_tunnel_usage.down.sql", size: 25, mode: os.FileMode(0644), modTime: time.Unix(1792135547, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x5d, 0x3a, 0x16, 0x60, 0x90, 0x58, 0x8e, 0x67, 0x47, 0xf5, 0x4f, 0x5f, 0xd6, 0x69, 0x2c, 0xad, 0x30, 0x94, 0x99, 0xa5, 0xe6, 0x2f, 0xec, 0xaa, 0xef, 0x13, 0x9c, 0x2d, 0xcd, 0x8c, 0x87, 0xbb}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -284,12 +326,14 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql":    _001_initDownSql,
-	"001_init.up.sql":      _001_initUpSql,
-	"002_indexes.down.sql": _002_indexesDownSql,
-	"002_indexes.up.sql":   _002_indexesUpSql,
-	"003_add_net.down.sql": _003_add_netDownSql,
-	"003_add_net.up.sql":   _003_add_netUpSql,
+	"001_init.down.sql":         _001_initDownSql,
+	"001_init.up.sql":           _001_initUpSql,
+	"002_indexes.down.sql":      _002_indexesDownSql,
+	"002_indexes.up.sql":        _002_indexesUpSql,
+	"003_add_net.down.sql":      _003_add_netDownSql,
+	"003_add_net.up.sql":        _003_add_netUpSql,
+	"004_tunnel_usage.up.sql":   _004_tunnel_usageUpSql,
+	"004_tunnel_usage.down.sql": _004_tunnel_usageDownSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -338,12 +382,14 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql":    {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":      {_001_initUpSql, map[string]*bintree{}},
-	"002_indexes.down.sql": {_002_indexesDownSql, map[string]*bintree{}},
-	"002_indexes.up.sql":   {_002_indexesUpSql, map[string]*bintree{}},
-	"003_add_net.down.sql": {_003_add_netDownSql, map[string]*bintree{}},
-	"003_add_net.up.sql":   {_003_add_netUpSql, map[string]*bintree{}},
+	"001_init.down.sql":         {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":           {_001_initUpSql, map[string]*bintree{}},
+	"002_indexes.down.sql":      {_002_indexesDownSql, map[string]*bintree{}},
+	"002_indexes.up.sql":        {_002_indexesUpSql, map[string]*bintree{}},
+	"003_add_net.down.sql":      {_003_add_netDownSql, map[string]*bintree{}},
+	"003_add_net.up.sql":        {_003_add_netUpSql, map[string]*bintree{}},
+	"004_tunnel_usage.up.sql":   {_004_tunnel_usageUpSql, map[string]*bintree{}},
+	"004_tunnel_usage.down.sql": {_004_tunnel_usageDownSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,19 @@
+-- ----------------------------
+-- Table structure for tunnel_usage
+-- ----------------------------
+CREATE TABLE IF NOT EXISTS "tunnel_usage"
+(
+    "client_id"   TEXT     NOT NULL,
+    "tunnel_id"   TEXT     NOT NULL,
+    "timestamp"   DATETIME NOT NULL,
+    "bytes_in"    INTEGER  NOT NULL,
+    "bytes_out"   INTEGER  NOT NULL,
+    "connections" INTEGER  NOT NULL,
+    PRIMARY KEY (client_id, tunnel_id, timestamp)
+);
+-- ----------------------------
+-- Indexes for table tunnel_usage
+-- ----------------------------
+CREATE INDEX "tunnel_usage_timestamp" ON `tunnel_usage` (
+    "timestamp" ASC
+);
@@ -0,0 +1,15 @@
+CREATE TABLE api_sessions (
+    session_id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    expires_at DATETIME(6) NOT NULL,
+    username VARCHAR(255) NOT NULL,
+    last_access_at DATETIME(6) NOT NULL,
+    user_agent TEXT,
+    ip_address VARCHAR(255)
+);
+
+CREATE INDEX idx_expires_at_time
+    ON api_sessions (expires_at DESC);
+
+-- username may not be unique as the user may create new tokens before old ones are deleted/expired
+CREATE INDEX idx_username
+    ON api_sessions (username);
@@ -0,0 +1 @@
+ALTER TABLE `api_tokens` ADD COLUMN `restrictions` TEXT;
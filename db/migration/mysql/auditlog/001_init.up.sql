@@ -0,0 +1,39 @@
+-- ----------------------------
+-- Table structure for auditlog
+-- ----------------------------
+CREATE TABLE `auditlog`
+(
+    `timestamp`       DATETIME(6)  NOT NULL,
+    `application`     VARCHAR(255) NOT NULL,
+    `action`          VARCHAR(255) NOT NULL,
+    `username`        VARCHAR(255) NULL,
+    `remote_ip`       VARCHAR(255) NULL,
+    `affected_id`     VARCHAR(255) NULL,
+    `client_id`       VARCHAR(255) NULL,
+    `client_hostname` VARCHAR(255) NULL,
+    `request`         LONGTEXT     NULL,
+    `response`        LONGTEXT     NULL
+);
+
+CREATE INDEX `auditlog_timestamp_application_action_affedted_id` ON `auditlog` (
+    `timestamp` ASC,
+    `application` ASC,
+    `action` ASC,
+    `affected_id` ASC
+);
+
+CREATE INDEX `auditlog_client_id` ON `auditlog` (
+    `client_id` ASC
+);
+
+CREATE INDEX `auditlog_client_hostname` ON `auditlog` (
+    `client_hostname` ASC
+);
+
+CREATE INDEX `auditlog_username` ON `auditlog` (
+    `username` ASC
+);
+
+CREATE INDEX `auditlog_remote_ip` ON `auditlog` (
+    `remote_ip` ASC
+);
@@ -0,0 +1,6 @@
+-- leases elect the active server when running several rportd in high availability mode
+CREATE TABLE ha_leases (
+    name VARCHAR(255) NOT NULL PRIMARY KEY,
+    node_id VARCHAR(255) NOT NULL,
+    expires_at DATETIME(6) NOT NULL
+);
@@ -0,0 +1,38 @@
+CREATE TABLE multi_jobs (
+    jid VARCHAR(255) PRIMARY KEY NOT NULL,
+    started_at DATETIME(6) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    schedule_id VARCHAR(255) NULL,
+    details LONGTEXT NOT NULL
+);
+
+CREATE INDEX idx_multi_jobs_schedule_id
+    ON multi_jobs (schedule_id);
+
+-- no foreign key to multi_jobs, multi-client jobs are replaced on every update
+CREATE TABLE jobs (
+    jid VARCHAR(255) PRIMARY KEY NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    started_at DATETIME(6) NOT NULL,
+    finished_at DATETIME(6),
+    created_by VARCHAR(255) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    multi_job_id VARCHAR(255),
+    details LONGTEXT NOT NULL
+);
+
+CREATE INDEX idx_jobs_client_id_time
+    ON jobs (client_id, finished_at DESC);
+
+CREATE INDEX idx_jobs_multi_id
+    ON jobs (multi_job_id);
+
+CREATE TABLE schedules (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    name TEXT NOT NULL,
+    schedule TEXT NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    details LONGTEXT NOT NULL
+);
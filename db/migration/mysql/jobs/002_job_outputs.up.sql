@@ -0,0 +1,8 @@
+CREATE TABLE job_outputs (
+    jid VARCHAR(255) NOT NULL,
+    byte_offset BIGINT NOT NULL,
+    stream VARCHAR(255) NOT NULL,
+    data LONGBLOB NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (jid, byte_offset)
+);
@@ -0,0 +1,40 @@
+-- ----------------------------
+-- Table structure for scripts
+-- ----------------------------
+CREATE TABLE `scripts`
+(
+    `id` VARCHAR(255) PRIMARY KEY NOT NULL,
+    `name` VARCHAR(255) NOT NULL,
+    `created_at` DATETIME(6) NOT NULL,
+    `created_by` VARCHAR(255) NOT NULL,
+    `updated_at` DATETIME(6) NOT NULL,
+    `updated_by` VARCHAR(255) NOT NULL DEFAULT '',
+    `interpreter` TEXT,
+    `is_sudo` TINYINT(1) NOT NULL DEFAULT 0,
+    `cwd` TEXT,
+    `script` LONGTEXT NOT NULL,
+    `tags` TEXT NOT NULL,
+    `timeout_sec` INT NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX `unique_name`
+    ON `scripts` (`name` ASC);
+
+-- ----------------------------
+-- Table structure for commands
+-- ----------------------------
+CREATE TABLE `commands`
+(
+    `id` VARCHAR(255) PRIMARY KEY NOT NULL,
+    `name` VARCHAR(255) NOT NULL,
+    `created_at` DATETIME(6) NOT NULL,
+    `created_by` VARCHAR(255) NOT NULL,
+    `updated_at` DATETIME(6) NOT NULL,
+    `updated_by` VARCHAR(255) NOT NULL,
+    `cmd` LONGTEXT NOT NULL,
+    `tags` TEXT NOT NULL,
+    `timeout_sec` INT NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX `commands__unique_name`
+    ON `commands` (`name` ASC);
@@ -0,0 +1,22 @@
+-- ----------------------------
+-- Table structure for measurements
+-- ----------------------------
+CREATE TABLE `measurements`
+(
+    `client_id`             VARCHAR(255) NOT NULL,
+    `timestamp`             DATETIME(6)  NOT NULL,
+    `cpu_usage_percent`     DOUBLE       NOT NULL,
+    `memory_usage_percent`  DOUBLE       NOT NULL,
+    `io_usage_percent`      DOUBLE       NOT NULL,
+    `processes`             LONGTEXT,
+    `mountpoints`           LONGTEXT,
+    `net_lan_in`            BIGINT,
+    `net_lan_out`           BIGINT,
+    `net_wan_in`            BIGINT,
+    `net_wan_out`           BIGINT,
+    PRIMARY KEY (`client_id`, `timestamp`)
+);
+
+CREATE INDEX `measurements_timestamp` ON `measurements` (
+    `timestamp` ASC
+);
@@ -0,0 +1,17 @@
+-- ----------------------------
+-- Table structure for tunnel_usage
+-- ----------------------------
+CREATE TABLE `tunnel_usage`
+(
+    `client_id`   VARCHAR(255) NOT NULL,
+    `tunnel_id`   VARCHAR(255) NOT NULL,
+    `timestamp`   DATETIME(6)  NOT NULL,
+    `bytes_in`    BIGINT       NOT NULL,
+    `bytes_out`   BIGINT       NOT NULL,
+    `connections` BIGINT       NOT NULL,
+    PRIMARY KEY (`client_id`, `tunnel_id`, `timestamp`)
+);
+
+CREATE INDEX `tunnel_usage_timestamp` ON `tunnel_usage` (
+    `timestamp` ASC
+);
@@ -0,0 +1,7 @@
+CREATE TABLE roles (
+    name VARCHAR(255) PRIMARY KEY NOT NULL,
+    description TEXT NOT NULL,
+    permissions TEXT NOT NULL,
+    resources TEXT NOT NULL,
+    user_groups TEXT NOT NULL
+);
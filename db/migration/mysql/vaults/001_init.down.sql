@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `status`;
+DROP TABLE IF EXISTS `values`;
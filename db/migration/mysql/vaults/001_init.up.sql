@@ -0,0 +1,30 @@
+-- ----------------------------
+-- Table structure for vault
+-- ----------------------------
+CREATE TABLE `values`
+(
+    `id`             BIGINT       NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    `client_id`      VARCHAR(255) NOT NULL DEFAULT '0',
+    `required_group` TEXT,
+    `created_at`     DATETIME(6)  NOT NULL,
+    `created_by`     VARCHAR(255) NOT NULL,
+    `updated_at`     DATETIME(6)  NOT NULL,
+    `updated_by`     VARCHAR(255),
+    `key`            VARCHAR(255) NOT NULL,
+    `value`          LONGTEXT     NOT NULL,
+    `type`           VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX `key`
+    ON `values` (`key` ASC);
+
+CREATE UNIQUE INDEX `unique_client_id_key`
+    ON `values` (`client_id` ASC, `key` ASC);
+
+CREATE TABLE `status`
+(
+    `id`        BIGINT       NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    `db_status` VARCHAR(255) NOT NULL,
+    `enc_check` TEXT,
+    `dec_check` TEXT
+);
@@ -0,0 +1,13 @@
+CREATE TABLE webauthn_credentials (
+    id VARCHAR(1400) CHARACTER SET ascii NOT NULL,
+    username VARCHAR(255) NOT NULL CHECK (username != ''),
+    name VARCHAR(255) NOT NULL,
+    public_key BLOB NOT NULL,
+    sign_count BIGINT UNSIGNED NOT NULL DEFAULT 0,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    last_used_at DATETIME(6),
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX webauthn_credentials_username
+    ON webauthn_credentials (username);
@@ -0,0 +1,14 @@
+CREATE TABLE password_history (
+    username TEXT NOT NULL CHECK (username != ''),
+    password TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX password_history_username
+    ON password_history (username, created_at);
+
+CREATE TABLE user_lockouts (
+    username TEXT PRIMARY KEY NOT NULL CHECK (username != ''),
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_at DATETIME
+) WITHOUT ROWID;
@@ -0,0 +1 @@
+ALTER TABLE auditlog ADD COLUMN recording_id VARCHAR(255) NOT NULL DEFAULT '';
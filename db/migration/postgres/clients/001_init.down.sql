@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stored_tunnels;
+DROP TABLE IF EXISTS clients;
@@ -0,0 +1,26 @@
+CREATE TABLE clients (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    client_auth_id VARCHAR(255) NOT NULL,
+    disconnected_at TIMESTAMPTZ,
+    details TEXT NOT NULL
+);
+
+CREATE INDEX idx_disconnected_time_client
+    ON clients (disconnected_at DESC, client_auth_id);
+
+-- no foreign key to clients, client rows are replaced on every update
+CREATE TABLE stored_tunnels (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ,
+    name TEXT,
+    scheme TEXT,
+    remote_ip TEXT,
+    remote_port INTEGER,
+    acl TEXT,
+    public_port INTEGER,
+    further_options TEXT
+);
+
+CREATE INDEX idx_stored_tunnels_client_id
+    ON stored_tunnels (client_id);
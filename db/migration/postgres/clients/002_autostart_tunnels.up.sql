@@ -0,0 +1,22 @@
+ALTER TABLE stored_tunnels ADD autostart BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE stored_tunnels ADD autostart_from VARCHAR(5);
+ALTER TABLE stored_tunnels ADD autostart_until VARCHAR(5);
+
+CREATE TABLE group_stored_tunnels (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    client_group_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ,
+    name TEXT,
+    scheme TEXT,
+    remote_ip TEXT,
+    remote_port INTEGER,
+    public_port INTEGER,
+    acl TEXT,
+    further_options TEXT,
+    autostart BOOLEAN NOT NULL DEFAULT FALSE,
+    autostart_from VARCHAR(5),
+    autostart_until VARCHAR(5)
+);
+
+CREATE INDEX idx_group_stored_tunnels_client_group_id
+    ON group_stored_tunnels (client_group_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS commands;
+DROP TABLE IF EXISTS scripts;
@@ -0,0 +1,40 @@
+-- ----------------------------
+-- Table structure for scripts
+-- ----------------------------
+CREATE TABLE scripts
+(
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by VARCHAR(255) NOT NULL DEFAULT '',
+    interpreter TEXT,
+    is_sudo BOOLEAN NOT NULL DEFAULT FALSE,
+    cwd TEXT,
+    script TEXT NOT NULL,
+    tags TEXT NOT NULL,
+    timeout_sec INTEGER NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX unique_name
+    ON scripts (name ASC);
+
+-- ----------------------------
+-- Table structure for commands
+-- ----------------------------
+CREATE TABLE commands
+(
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    updated_by VARCHAR(255) NOT NULL,
+    cmd TEXT NOT NULL,
+    tags TEXT NOT NULL,
+    timeout_sec INTEGER NOT NULL DEFAULT 60
+);
+
+CREATE UNIQUE INDEX commands__unique_name
+    ON commands (name ASC);
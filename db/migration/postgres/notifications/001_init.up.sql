@@ -0,0 +1,23 @@
+CREATE TABLE notifications_log (
+    oid BIGSERIAL PRIMARY KEY, -- insertion order, sqlite provides it as implicit rowid
+    notification_id CHAR(26) NOT NULL CHECK (notification_id != ''),
+    timestamp TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "contentType" VARCHAR(50) NOT NULL DEFAULT '',
+    reference_id VARCHAR(26) NOT NULL DEFAULT '',
+    transport TEXT NOT NULL,
+    recipients TEXT NOT NULL,
+
+    state VARCHAR(20) NOT NULL CHECK (state != ''),
+
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+
+    out TEXT NOT NULL,
+    err TEXT NOT NULL
+);
+
+CREATE INDEX idx_notifications_id
+    ON notifications_log (notification_id);
+
+CREATE INDEX idx_notifications_timestamp
+    ON notifications_log (timestamp);
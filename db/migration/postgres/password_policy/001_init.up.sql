@@ -0,0 +1,15 @@
+CREATE TABLE password_history (
+    username VARCHAR(255) NOT NULL CHECK (username != ''),
+    password VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX password_history_username
+    ON password_history (username, created_at);
+
+CREATE TABLE user_lockouts (
+    username VARCHAR(255) NOT NULL CHECK (username != ''),
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_at TIMESTAMPTZ,
+    PRIMARY KEY (username)
+);
@@ -0,0 +1,33 @@
+// Package postgres contains the PostgreSQL migrations of all server databases. Each store has its own directory
+// named like its sqlite counterpart in db/migration.
+package postgres
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+//go:embed */*.sql
+var migrations embed.FS
+
+// Assets returns the migration file names of the given store and a func to read them,
+// in the form expected by the go-bindata migration source.
+func Assets(store string) ([]string, func(name string) ([]byte, error), error) {
+	entries, err := fs.ReadDir(migrations, store)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no postgres migrations for %q: %v", store, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	asset := func(name string) ([]byte, error) {
+		return migrations.ReadFile(path.Join(store, name))
+	}
+
+	return names, asset, nil
+}
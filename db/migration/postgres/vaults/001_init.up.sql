@@ -0,0 +1,30 @@
+-- ----------------------------
+-- Table structure for vault
+-- ----------------------------
+CREATE TABLE "values"
+(
+    id             BIGSERIAL    NOT NULL PRIMARY KEY,
+    client_id      VARCHAR(255) NOT NULL DEFAULT '0',
+    required_group TEXT,
+    created_at     TIMESTAMPTZ  NOT NULL,
+    created_by     VARCHAR(255) NOT NULL,
+    updated_at     TIMESTAMPTZ  NOT NULL,
+    updated_by     VARCHAR(255),
+    key            VARCHAR(255) NOT NULL,
+    value          TEXT         NOT NULL,
+    type           VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX key
+    ON "values" (key ASC);
+
+CREATE UNIQUE INDEX unique_client_id_key
+    ON "values" (client_id ASC, key ASC);
+
+CREATE TABLE status
+(
+    id        BIGSERIAL    NOT NULL PRIMARY KEY,
+    db_status VARCHAR(255) NOT NULL,
+    enc_check TEXT,
+    dec_check TEXT
+);
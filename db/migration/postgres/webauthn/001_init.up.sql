@@ -0,0 +1,13 @@
+CREATE TABLE webauthn_credentials (
+    id VARCHAR(1400) NOT NULL,
+    username VARCHAR(255) NOT NULL CHECK (username != ''),
+    name VARCHAR(255) NOT NULL,
+    public_key BYTEA NOT NULL,
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMPTZ,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX webauthn_credentials_username
+    ON webauthn_credentials (username);
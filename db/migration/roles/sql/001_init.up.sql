@@ -0,0 +1,7 @@
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT NOT NULL,
+    resources TEXT NOT NULL,
+    user_groups TEXT NOT NULL
+) WITHOUT ROWID;
@@ -0,0 +1,12 @@
+CREATE TABLE webauthn_credentials (
+    id TEXT PRIMARY KEY NOT NULL,
+    username TEXT NOT NULL CHECK (username != ''),
+    name TEXT NOT NULL DEFAULT '',
+    public_key BLOB NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME
+) WITHOUT ROWID;
+
+CREATE INDEX webauthn_credentials_username
+    ON webauthn_credentials (username);
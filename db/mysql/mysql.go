@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DriverName                = "mysql"
+	DefaultMaxOpenConnections = 10
+	// errDuplicateEntry is the MySQL server error number of a unique constraint violation.
+	errDuplicateEntry = 1062
+)
+
+// New returns a new MySQL DB instance with migrated DB scheme to the latest version.
+// All server databases share the same MySQL schema, so every store keeps its own migrations table named
+// after the store. assetNames and asset are used to migrate DB scheme.
+func New(dsn string, store string, assetNames []string, asset func(name string) ([]byte, error)) (*sqlx.DB, error) {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql DSN: %v", err)
+	}
+	// times are stored as DATETIME columns and scanned into time.Time
+	cfg.ParseTime = true
+	// migrations consist of multiple statements per file
+	cfg.MultiStatements = true
+	// report matched instead of changed rows like sqlite does, providers rely on it to detect missing rows
+	cfg.ClientFoundRows = true
+
+	db, err := sqlx.Connect(DriverName, cfg.FormatDSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to DB: %v", err)
+	}
+
+	db.SetMaxOpenConns(DefaultMaxOpenConnections)
+
+	s := bindata.Resource(assetNames,
+		func(name string) ([]byte, error) {
+			return asset(name)
+		})
+	sourceDriver, err := bindata.WithInstance(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB source driver: %v", err)
+	}
+
+	dbDriver, err := mysql.WithInstance(db.DB, &mysql.Config{
+		MigrationsTable: store + "_schema_migrations",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB migration driver: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("go-bindata", sourceDriver, DriverName, dbDriver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB migration instance: %v", err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return nil, fmt.Errorf("failed to migrate DB to the latest version: %v", err)
+	}
+
+	return db, nil
+}
+
+// IsDuplicateEntry returns true if err is caused by inserting a row that violates a unique constraint.
+func IsDuplicateEntry(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == errDuplicateEntry
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	DriverName                = "postgres"
+	DefaultMaxOpenConnections = 10
+	// errUniqueViolation is the SQLSTATE of a unique constraint violation.
+	errUniqueViolation = "23505"
+)
+
+// Connect returns a DB instance which accepts the queries written for sqlite and mysql, see rewriteQuery.
+func Connect(dsn string) (*sqlx.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres DSN: %v", err)
+	}
+
+	db := sqlx.NewDb(sql.OpenDB(&rewritingConnector{connector: connector}), DriverName)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to DB: %v", err)
+	}
+
+	return db, nil
+}
+
+// New returns a new PostgreSQL DB instance with migrated DB scheme to the latest version.
+// All server databases share the same schema, so every store keeps its own migrations table named
+// after the store. assetNames and asset are used to migrate DB scheme.
+func New(dsn string, store string, assetNames []string, asset func(name string) ([]byte, error)) (*sqlx.DB, error) {
+	db, err := Connect(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(DefaultMaxOpenConnections)
+
+	s := bindata.Resource(assetNames,
+		func(name string) ([]byte, error) {
+			return asset(name)
+		})
+	sourceDriver, err := bindata.WithInstance(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB source driver: %v", err)
+	}
+
+	dbDriver, err := postgres.WithInstance(db.DB, &postgres.Config{
+		MigrationsTable: store + "_schema_migrations",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB migration driver: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("go-bindata", sourceDriver, DriverName, dbDriver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init DB migration instance: %v", err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return nil, fmt.Errorf("failed to migrate DB to the latest version: %v", err)
+	}
+
+	return db, nil
+}
+
+// IsDuplicateEntry returns true if err is caused by inserting a row that violates a unique constraint.
+func IsDuplicateEntry(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == errUniqueViolation
+}
+
+// rewriteQuery converts the '?' placeholders used by all providers to the numbered placeholders of postgres
+// and quotes identifiers with double quotes instead of backticks. String literals are kept as they are.
+func rewriteQuery(q string) string {
+	if !strings.ContainsAny(q, "?`") {
+		return q
+	}
+
+	var b strings.Builder
+	b.Grow(len(q) + 8)
+	n := 0
+	inString := false
+	for _, r := range q {
+		switch {
+		case r == '\'':
+			inString = !inString
+			b.WriteRune(r)
+		case inString:
+			b.WriteRune(r)
+		case r == '?':
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+		case r == '`':
+			b.WriteRune('"')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type rewritingConnector struct {
+	connector *pq.Connector
+}
+
+func (c *rewritingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &rewritingConn{conn: conn}, nil
+}
+
+func (c *rewritingConnector) Driver() driver.Driver {
+	return c.connector.Driver()
+}
+
+// rewritingConn passes all queries through rewriteQuery before handing them to lib/pq.
+type rewritingConn struct {
+	conn driver.Conn
+}
+
+func (c *rewritingConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(rewriteQuery(query))
+}
+
+func (c *rewritingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, rewriteQuery(query))
+}
+
+func (c *rewritingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.conn.(driver.QueryerContext).QueryContext(ctx, rewriteQuery(query), args)
+}
+
+func (c *rewritingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.conn.(driver.ExecerContext).ExecContext(ctx, rewriteQuery(query), args)
+}
+
+func (c *rewritingConn) Begin() (driver.Tx, error) {
+	//nolint:staticcheck // required by driver.Conn, database/sql uses BeginTx
+	return c.conn.Begin()
+}
+
+func (c *rewritingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *rewritingConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *rewritingConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *rewritingConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+func (c *rewritingConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteQuery(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "no placeholders",
+			query:    "SELECT * FROM clients",
+			expected: "SELECT * FROM clients",
+		},
+		{
+			name:     "placeholders",
+			query:    "SELECT * FROM clients WHERE id = ? AND client_auth_id IN (?, ?) LIMIT ? OFFSET ?",
+			expected: "SELECT * FROM clients WHERE id = $1 AND client_auth_id IN ($2, $3) LIMIT $4 OFFSET $5",
+		},
+		{
+			name:     "backticks",
+			query:    "UPDATE `values` SET `key` = ? WHERE id = ?",
+			expected: `UPDATE "values" SET "key" = $1 WHERE id = $2`,
+		},
+		{
+			name:     "string literals",
+			query:    "SELECT * FROM t WHERE a = '?' AND b LIKE ? ESCAPE '\\' AND c = 'it''s `x`?' AND d = ?",
+			expected: "SELECT * FROM t WHERE a = '?' AND b LIKE $1 ESCAPE '\\' AND c = 'it''s `x`?' AND d = $2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, rewriteQuery(tc.query))
+		})
+	}
+}
+
+// recordingConn is a driver connection which records the queries instead of sending them to a server.
+type recordingConn struct {
+	queries []string
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	c.queries = append(c.queries, query)
+	return nil, nil
+}
+
+func (c *recordingConn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	return c.Prepare(query)
+}
+
+func (c *recordingConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.queries = append(c.queries, query)
+	return nil, nil
+}
+
+func (c *recordingConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.queries = append(c.queries, query)
+	return driver.RowsAffected(0), nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, nil
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func TestRewritingConn(t *testing.T) {
+	ctx := context.Background()
+	recorder := &recordingConn{}
+	conn := &rewritingConn{conn: recorder}
+
+	_, err := conn.Prepare("SELECT * FROM clients WHERE id = ?")
+	require.NoError(t, err)
+	_, err = conn.PrepareContext(ctx, "SELECT * FROM `values` WHERE id = ?")
+	require.NoError(t, err)
+	_, err = conn.QueryContext(ctx, "SELECT * FROM jobs WHERE jid = ? LIMIT ?", nil)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "UPDATE user_lockouts SET failed_attempts = failed_attempts + 1 WHERE username = ?", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"SELECT * FROM clients WHERE id = $1",
+		`SELECT * FROM "values" WHERE id = $1`,
+		"SELECT * FROM jobs WHERE jid = $1 LIMIT $2",
+		"UPDATE user_lockouts SET failed_attempts = failed_attempts + 1 WHERE username = $1",
+	}, recorder.queries)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/rules"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/rundata"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/templates"
+	"github.com/openrport/openrport/plus/capabilities/alerting/entities/tunnelhealth"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/validations"
 	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/share/logger"
@@ -45,6 +46,7 @@ type Service interface {
 
 	PutClientUpdate(cl *clientupdates.Client) (err error)
 	PutMeasurement(m *measures.Measure) (err error)
+	PutTunnelHealth(th *tunnelhealth.TunnelHealth) (err error)
 
 	GetAllTemplates() (templateList templates.TemplateList, err error)
 	GetTemplate(templateID templates.TemplateID) (template *templates.Template, err error)
@@ -14,6 +14,7 @@ import (
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/rules"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/rundata"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/templates"
+	"github.com/openrport/openrport/plus/capabilities/alerting/entities/tunnelhealth"
 	"github.com/openrport/openrport/plus/capabilities/alerting/entities/validations"
 	"github.com/openrport/openrport/plus/capabilities/status"
 	"github.com/openrport/openrport/plus/validator"
@@ -257,6 +258,10 @@ func (mp *MockServiceProvider) PutMeasurement(_ *measures.Measure) (err error) {
 	return nil
 }
 
+func (mp *MockServiceProvider) PutTunnelHealth(_ *tunnelhealth.TunnelHealth) (err error) {
+	return nil
+}
+
 func (mp *MockServiceProvider) LoadDefaultRuleSet() (err error) {
 	return nil
 }
@@ -0,0 +1,27 @@
+package tunnelhealth
+
+import (
+	"time"
+)
+
+// TunnelHealth is sent to the alerting service when the health status of a tunnel changes
+type TunnelHealth struct {
+	UID       string    `json:"uid"` // unique id for idempotency
+	Timestamp time.Time `json:"timestamp"`
+
+	ClientID   string `json:"client_id"`
+	ClientName string `json:"client_name"`
+	TunnelID   string `json:"tunnel_id"`
+	TunnelName string `json:"tunnel_name"`
+	Remote     string `json:"remote"`
+
+	Status              string `json:"status"`
+	Error               string `json:"error"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Restarted           bool   `json:"restarted"`
+}
+
+func (th *TunnelHealth) Clone() (clonedTunnelHealth TunnelHealth) {
+	clonedTunnelHealth = *th
+	return clonedTunnelHealth
+}
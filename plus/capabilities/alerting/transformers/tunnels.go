@@ -0,0 +1,32 @@
+package transformers
+
+import (
+	"fmt"
+
+	"github.com/openrport/openrport/plus/capabilities/alerting/entities/tunnelhealth"
+	rportclients "github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+)
+
+func TransformRportTunnelToTunnelHealth(rc *rportclients.Client, t *clienttunnel.Tunnel, restarted bool) (th *tunnelhealth.TunnelHealth) {
+	health := t.Health.Get()
+
+	th = &tunnelhealth.TunnelHealth{
+		ClientID:            rc.GetID(),
+		ClientName:          rc.GetName(),
+		TunnelID:            t.ID,
+		TunnelName:          t.Name,
+		Remote:              t.Remote.Remote(),
+		Status:              health.Status,
+		Error:               health.Error,
+		ConsecutiveFailures: health.ConsecutiveFailures,
+		Restarted:           restarted,
+	}
+	if health.CheckedAt != nil {
+		th.Timestamp = *health.CheckedAt
+	}
+	// a status change is identified by the tunnel and the probe, so a resent update keeps its uid
+	th.UID = fmt.Sprintf("%s-%s-%d", th.ClientID, th.TunnelID, th.Timestamp.UnixNano())
+
+	return th
+}
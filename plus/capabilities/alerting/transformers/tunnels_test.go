@@ -0,0 +1,35 @@
+package transformers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	rportclients "github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+)
+
+func TestShouldTransformTunnelToTunnelHealth(t *testing.T) {
+	client := &rportclients.Client{ID: "client-1", Name: "Client 1"}
+	tunnel := &clienttunnel.Tunnel{ID: "1", Health: clienttunnel.NewTunnelHealth()}
+	tunnel.Remote.RemoteHost = "127.0.0.1"
+	tunnel.Remote.RemotePort = "22"
+	checkedAt := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	tunnel.Health.Record(errors.New("connection refused"), checkedAt)
+
+	th := TransformRportTunnelToTunnelHealth(client, tunnel, true)
+
+	assert.Equal(t, "client-1", th.ClientID)
+	assert.Equal(t, "127.0.0.1:22", th.Remote)
+	assert.Equal(t, clienttunnel.HealthStatusUnhealthy, th.Status)
+	assert.Equal(t, "connection refused", th.Error)
+	assert.Equal(t, checkedAt, th.Timestamp)
+	assert.True(t, th.Restarted)
+	assert.NotEmpty(t, th.UID)
+	assert.Equal(t, th.UID, TransformRportTunnelToTunnelHealth(client, tunnel, true).UID, "uid of the same probe must not change")
+
+	tunnel.Health.Record(nil, checkedAt.Add(time.Minute))
+	assert.NotEqual(t, th.UID, TransformRportTunnelToTunnelHealth(client, tunnel, false).UID)
+}
@@ -8,10 +8,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-webauthn/webauthn/protocol"
+
 	"github.com/openrport/openrport/server/api/users"
 
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/message"
+	"github.com/openrport/openrport/server/webauthn"
 	"github.com/openrport/openrport/share/security"
 )
 
@@ -20,6 +23,8 @@ type TwoFAService struct {
 	MsgSrv      message.Service
 	UserSrv     UserService
 	SendTimeout time.Duration
+	// WebAuthnSrv verifies security keys, nil if webauthn is disabled
+	WebAuthnSrv *webauthn.Service
 
 	tokensByUser map[string]*expirableToken
 	mu           sync.RWMutex
@@ -156,6 +161,37 @@ func (srv *TwoFAService) ValidateTotPCode(user *users.User, code string) error {
 	return nil
 }
 
+// ValidateWebAuthnAssertion verifies the assertion of a security key of a user with a pending login.
+func (srv *TwoFAService) ValidateWebAuthnAssertion(ctx context.Context, username string, resp *protocol.CredentialAssertionResponse) error {
+	srv.mu.RLock()
+	t := srv.tokensByUser[username]
+	srv.mu.RUnlock()
+
+	if t == nil {
+		return errors2.APIError{
+			Message:    "login request not found for provided username",
+			HTTPStatus: http.StatusUnauthorized,
+		}
+	}
+
+	if time.Now().After(t.expiry) {
+		return errors2.APIError{
+			Message:    "login request expired",
+			HTTPStatus: http.StatusUnauthorized,
+		}
+	}
+
+	if _, err := srv.WebAuthnSrv.FinishAssertion(ctx, username, resp); err != nil {
+		return err
+	}
+
+	srv.mu.Lock()
+	delete(srv.tokensByUser, username)
+	srv.mu.Unlock()
+
+	return nil
+}
+
 func (srv *TwoFAService) ValidateToken(username, token string) error {
 	srv.mu.RLock()
 	t := srv.tokensByUser[username]
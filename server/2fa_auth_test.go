@@ -1,9 +1,11 @@
 package chserver
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/pquerna/otp/totp"
@@ -109,3 +111,14 @@ func TestValidateTotPCodeWithUnknownUser(t *testing.T) {
 	err := tfaService.ValidateTotPCode(usr, "123")
 	require.EqualError(t, err, "login request not found for provided username")
 }
+
+func TestValidateWebAuthnAssertionWithoutLogin(t *testing.T) {
+	tfaService := NewTwoFAService(100, time.Second, &MockUsersService{}, &message.ServiceMock{})
+
+	err := tfaService.ValidateWebAuthnAssertion(context.Background(), "no@mail.me", &protocol.CredentialAssertionResponse{})
+	require.EqualError(t, err, "login request not found for provided username")
+
+	tfaService.SetTotPLoginSession("no@mail.me", -time.Second)
+	err = tfaService.ValidateWebAuthnAssertion(context.Background(), "no@mail.me", &protocol.CredentialAssertionResponse{})
+	require.EqualError(t, err, "login request expired")
+}
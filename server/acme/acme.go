@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/acme/autocert"
@@ -20,6 +21,7 @@ const httpChallengeServerReadHeaderTimeout = 3 * time.Second
 type Acme struct {
 	*logger.Logger
 	manager  *autocert.Manager
+	hostsMu  sync.RWMutex
 	hosts    map[string]bool
 	httpPort int
 }
@@ -60,6 +62,9 @@ func (a *Acme) listenHTTP() {
 }
 
 func (a *Acme) hostPolicy(ctx context.Context, host string) error {
+	a.hostsMu.RLock()
+	defer a.hostsMu.RUnlock()
+
 	if !a.hosts[host] {
 		return fmt.Errorf("host %q not configured for acme", host)
 	}
@@ -68,20 +73,40 @@ func (a *Acme) hostPolicy(ctx context.Context, host string) error {
 
 // AddHost adds host(s) to allowed acme hosts, if host is url, then host part is extracted
 func (a *Acme) AddHost(hosts ...string) {
+	a.hostsMu.Lock()
+	defer a.hostsMu.Unlock()
+
 	for _, host := range hosts {
-		u, err := url.Parse(host)
-		if err == nil && u.Host != "" {
-			host = u.Host
-		}
-		h, _, err := net.SplitHostPort(host)
-		if err == nil {
-			host = h
-		}
+		host = hostname(host)
 		a.Infof("enabled for %q", host)
 		a.hosts[host] = true
 	}
 }
 
+// RemoveHost removes host(s) from allowed acme hosts, e.g. when a tunnel subdomain is gone. Issued certificates stay cached.
+func (a *Acme) RemoveHost(hosts ...string) {
+	a.hostsMu.Lock()
+	defer a.hostsMu.Unlock()
+
+	for _, host := range hosts {
+		host = hostname(host)
+		a.Debugf("disabled for %q", host)
+		delete(a.hosts, host)
+	}
+}
+
+func hostname(host string) string {
+	u, err := url.Parse(host)
+	if err == nil && u.Host != "" {
+		host = u.Host
+	}
+	h, _, err := net.SplitHostPort(host)
+	if err == nil {
+		host = h
+	}
+	return host
+}
+
 func (a *Acme) ApplyTLSConfig(cfg *tls.Config) *tls.Config {
 	acmeConfig := a.manager.TLSConfig()
 	cfg.GetCertificate = acmeConfig.GetCertificate
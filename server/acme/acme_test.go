@@ -25,4 +25,8 @@ func TestHostPolicy(t *testing.T) {
 	assert.NoError(t, acme.hostPolicy(ctx, "test4.example.com"))
 	assert.Error(t, acme.hostPolicy(ctx, "not-allowed.example.com"))
 	assert.Error(t, acme.hostPolicy(ctx, "example.com"))
+
+	acme.RemoveHost("https://test3.example.com:443")
+	assert.Error(t, acme.hostPolicy(ctx, "test3.example.com"))
+	assert.NoError(t, acme.hostPolicy(ctx, "test4.example.com"))
 }
@@ -15,6 +15,8 @@ type APIToken struct {
 	ExpiresAt *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
 	Scope     APITokenScope `json:"scope,omitempty" db:"scope"`
 	Token     string        `json:"token,omitempty" db:"token"`
+	// Restrictions narrow down the scope, nil means no restrictions
+	Restrictions *APITokenRestrictions `json:"restrictions,omitempty" db:"restrictions"`
 }
 
 const APITokenPrefixLength = 8
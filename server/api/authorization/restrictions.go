@@ -0,0 +1,189 @@
+package authorization
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/openrport/openrport/db/backend"
+	"github.com/openrport/openrport/server/api/users"
+)
+
+var ErrTokenRouteNotAllowed = errors.New("the provided token is not allowed to access this route")
+var ErrTokenSourceIPNotAllowed = errors.New("the provided token is not allowed to be used from this IP address")
+
+// APITokenRestrictions limit what a token can do in addition to its scope. Empty fields don't restrict.
+type APITokenRestrictions struct {
+	// Permissions is a subset of users.AllPermissions, routes requiring other permissions are denied
+	Permissions []string `json:"permissions,omitempty"`
+	// ClientIDs and ClientGroupIDs limit the clients the token can access, a client must match either of them
+	ClientIDs      []string `json:"client_ids,omitempty"`
+	ClientGroupIDs []string `json:"client_group_ids,omitempty"`
+	// Routes are "[METHOD ]PATH" patterns, e.g. "POST /api/v1/library/scripts/*". A trailing * matches any suffix
+	// and a missing method matches any method.
+	Routes []string `json:"routes,omitempty"`
+	// SourceCIDRs limit the addresses the token can be used from
+	SourceCIDRs []string `json:"source_cidrs,omitempty"`
+}
+
+func (r *APITokenRestrictions) Validate() error {
+	for _, p := range r.Permissions {
+		if !isKnownPermission(p) {
+			return fmt.Errorf("unknown permission %q, allowed: %s", p, strings.Join(users.AllPermissions, ", "))
+		}
+	}
+	for _, route := range r.Routes {
+		method, path := splitRoute(route)
+		if method != "*" && !isHTTPMethod(method) {
+			return fmt.Errorf("invalid method in route %q", route)
+		}
+		if !strings.HasPrefix(path, "/") || strings.Contains(strings.TrimSuffix(path, "*"), "*") {
+			return fmt.Errorf("invalid route %q, expected a path starting with / and an optional trailing *", route)
+		}
+	}
+	for _, cidr := range r.SourceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid source CIDR %q: %v", cidr, err)
+		}
+	}
+	return nil
+}
+
+// AllowsRequest checks the route and the source address of a request authorized with the token. remoteIP is the
+// address of the client, with or without a port.
+func (r *APITokenRestrictions) AllowsRequest(method, path, remoteIP string) error {
+	if r == nil {
+		return nil
+	}
+
+	if len(r.Routes) > 0 && !r.allowsRoute(method, path) {
+		return ErrTokenRouteNotAllowed
+	}
+
+	if len(r.SourceCIDRs) > 0 {
+		host, _, err := net.SplitHostPort(remoteIP)
+		if err != nil {
+			host = remoteIP
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || !r.allowsIP(ip) {
+			return ErrTokenSourceIPNotAllowed
+		}
+	}
+	return nil
+}
+
+// HasPermission returns true if the token may use routes requiring the given permission.
+func (r *APITokenRestrictions) HasPermission(permission string) bool {
+	if r == nil || len(r.Permissions) == 0 {
+		return true
+	}
+	return contains(r.Permissions, permission)
+}
+
+// AllowsClient returns true if the token may access the client, clientGroupIDs are the groups the client belongs to.
+func (r *APITokenRestrictions) AllowsClient(clientID string, clientGroupIDs []string) bool {
+	if r == nil || (len(r.ClientIDs) == 0 && len(r.ClientGroupIDs) == 0) {
+		return true
+	}
+	if contains(r.ClientIDs, clientID) {
+		return true
+	}
+	for _, groupID := range clientGroupIDs {
+		if contains(r.ClientGroupIDs, groupID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *APITokenRestrictions) allowsRoute(method, path string) bool {
+	for _, route := range r.Routes {
+		routeMethod, routePath := splitRoute(route)
+		if routeMethod != "*" && !strings.EqualFold(routeMethod, method) {
+			continue
+		}
+		if strings.HasSuffix(routePath, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(routePath, "*")) {
+				return true
+			}
+			continue
+		}
+		if path == routePath {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *APITokenRestrictions) allowsIP(ip net.IP) bool {
+	for _, cidr := range r.SourceCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *APITokenRestrictions) Scan(value interface{}) error {
+	if r == nil {
+		return errors.New("'restrictions' cannot be nil")
+	}
+
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(data, r)
+	if err != nil {
+		return fmt.Errorf("failed to decode 'restrictions' field: %v", err)
+	}
+	return nil
+}
+
+func (r APITokenRestrictions) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'restrictions' field: %v", err)
+	}
+	return string(b), nil
+}
+
+// splitRoute returns the method and the path of a route pattern, the method is "*" if missing.
+func splitRoute(route string) (string, string) {
+	route = strings.TrimSpace(route)
+	if method, path, ok := strings.Cut(route, " "); ok {
+		return strings.ToUpper(method), strings.TrimSpace(path)
+	}
+	return "*", route
+}
+
+func isHTTPMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isKnownPermission(permission string) bool {
+	return contains(users.AllPermissions, permission)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package authorization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPITokenRestrictionsValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		restrictions APITokenRestrictions
+		expectedErr  string
+	}{
+		{
+			name: "valid",
+			restrictions: APITokenRestrictions{
+				Permissions: []string{"scripts", "commands"},
+				Routes:      []string{"POST /api/v1/library/scripts/*", "/api/v1/clients"},
+				SourceCIDRs: []string{"192.168.0.0/16", "::1/128"},
+			},
+		},
+		{
+			name:         "unknown permission",
+			restrictions: APITokenRestrictions{Permissions: []string{"unknown"}},
+			expectedErr:  `unknown permission "unknown"`,
+		},
+		{
+			name:         "invalid method",
+			restrictions: APITokenRestrictions{Routes: []string{"FETCH /api/v1/clients"}},
+			expectedErr:  `invalid method in route "FETCH /api/v1/clients"`,
+		},
+		{
+			name:         "relative path",
+			restrictions: APITokenRestrictions{Routes: []string{"GET api/v1/clients"}},
+			expectedErr:  `invalid route "GET api/v1/clients"`,
+		},
+		{
+			name:         "wildcard in the middle",
+			restrictions: APITokenRestrictions{Routes: []string{"GET /api/v1/clients/*/tunnels"}},
+			expectedErr:  `invalid route "GET /api/v1/clients/*/tunnels"`,
+		},
+		{
+			name:         "invalid cidr",
+			restrictions: APITokenRestrictions{SourceCIDRs: []string{"10.0.0.1"}},
+			expectedErr:  `invalid source CIDR "10.0.0.1"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.restrictions.Validate()
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestAPITokenRestrictionsAllowsRequest(t *testing.T) {
+	restrictions := &APITokenRestrictions{
+		Routes:      []string{"POST /api/v1/library/scripts/*", "/api/v1/clients"},
+		SourceCIDRs: []string{"10.0.0.0/8"},
+	}
+
+	testCases := []struct {
+		name        string
+		method      string
+		path        string
+		remoteAddr  string
+		expectedErr error
+	}{
+		{
+			name:       "prefix route",
+			method:     "POST",
+			path:       "/api/v1/library/scripts/123",
+			remoteAddr: "10.1.2.3:4567",
+		},
+		{
+			name:       "route without method",
+			method:     "DELETE",
+			path:       "/api/v1/clients",
+			remoteAddr: "10.1.2.3:4567",
+		},
+		{
+			name:        "wrong method",
+			method:      "GET",
+			path:        "/api/v1/library/scripts/123",
+			remoteAddr:  "10.1.2.3:4567",
+			expectedErr: ErrTokenRouteNotAllowed,
+		},
+		{
+			name:        "exact route with suffix",
+			method:      "GET",
+			path:        "/api/v1/clients/123",
+			remoteAddr:  "10.1.2.3:4567",
+			expectedErr: ErrTokenRouteNotAllowed,
+		},
+		{
+			name:        "source ip not allowed",
+			method:      "GET",
+			path:        "/api/v1/clients",
+			remoteAddr:  "192.168.1.1:4567",
+			expectedErr: ErrTokenSourceIPNotAllowed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedErr, restrictions.AllowsRequest(tc.method, tc.path, tc.remoteAddr))
+		})
+	}
+
+	var noRestrictions *APITokenRestrictions
+	assert.NoError(t, noRestrictions.AllowsRequest("GET", "/api/v1/anything", "192.168.1.1:4567"))
+}
+
+func TestAPITokenRestrictionsClientsAndPermissions(t *testing.T) {
+	restrictions := &APITokenRestrictions{
+		Permissions:    []string{"scripts"},
+		ClientIDs:      []string{"client-1"},
+		ClientGroupIDs: []string{"group-1"},
+	}
+
+	assert.True(t, restrictions.HasPermission("scripts"))
+	assert.False(t, restrictions.HasPermission("commands"))
+	assert.True(t, restrictions.AllowsClient("client-1", nil))
+	assert.True(t, restrictions.AllowsClient("client-2", []string{"group-2", "group-1"}))
+	assert.False(t, restrictions.AllowsClient("client-2", []string{"group-2"}))
+
+	var noRestrictions *APITokenRestrictions
+	assert.True(t, noRestrictions.HasPermission("commands"))
+	assert.True(t, noRestrictions.AllowsClient("client-2", nil))
+	assert.True(t, (&APITokenRestrictions{Routes: []string{"/api/v1/me"}}).AllowsClient("client-2", nil))
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/db/backend"
 )
 
 type SqliteProvider struct {
@@ -70,19 +72,35 @@ func (p *SqliteProvider) GetByName(ctx context.Context, username, name string) (
 }
 
 func (p *SqliteProvider) Save(ctx context.Context, tokenLine *APIToken) (err error) {
-	res, err := p.db.NamedExecContext(
-		ctx,
-		`INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token)
+	q := `INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token, restrictions)
 			      VALUES (:username, :prefix, :name, 
 					CASE WHEN :created_at IS NOT NULL THEN :created_at ELSE CURRENT_TIMESTAMP END,
-					:expires_at, :scope, :token)
+					:expires_at, :scope, :token, :restrictions)
 			 	ON CONFLICT(username, prefix) DO UPDATE SET
 				 expires_at=CASE WHEN :expires_at IS NOT NULL THEN EXCLUDED.expires_at ELSE api_tokens.expires_at END,
 				 name=CASE WHEN :name != "" THEN EXCLUDED.name ELSE api_tokens.name END
 				WHERE EXCLUDED.username = api_tokens.username AND
-				       EXCLUDED.prefix = api_tokens.prefix`,
-		tokenLine,
-	)
+				       EXCLUDED.prefix = api_tokens.prefix`
+	switch p.db.DriverName() {
+	case backend.DriverMySQL:
+		q = `INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token, restrictions)
+			      VALUES (:username, :prefix, :name,
+					CASE WHEN :created_at IS NOT NULL THEN :created_at ELSE CURRENT_TIMESTAMP END,
+					:expires_at, :scope, :token, :restrictions)
+				ON DUPLICATE KEY UPDATE
+				 expires_at=CASE WHEN :expires_at IS NOT NULL THEN VALUES(expires_at) ELSE expires_at END,
+				 name=CASE WHEN :name != '' THEN VALUES(name) ELSE name END`
+	case backend.DriverPostgres:
+		// postgres can't infer the type of parameters which are only checked for NULL
+		q = `INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token, restrictions)
+			      VALUES (:username, :prefix, :name,
+					COALESCE(CAST(:created_at AS TIMESTAMPTZ), CURRENT_TIMESTAMP),
+					:expires_at, :scope, :token, :restrictions)
+				ON CONFLICT(username, prefix) DO UPDATE SET
+				 expires_at=COALESCE(CAST(:expires_at AS TIMESTAMPTZ), api_tokens.expires_at),
+				 name=CASE WHEN CAST(:name AS TEXT) != '' THEN EXCLUDED.name ELSE api_tokens.name END`
+	}
+	res, err := p.db.NamedExecContext(ctx, q, tokenLine)
 
 	if err != nil {
 		return err
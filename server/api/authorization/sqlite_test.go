@@ -137,6 +137,36 @@ func TestCreate(t *testing.T) {
 	test.AssertRowsEqual(t, dbProv.db, expectedRows, q, []interface{}{})
 }
 
+func TestCreateWithRestrictions(t *testing.T) {
+	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	dbProv := NewSqliteProvider(db)
+	defer dbProv.Close()
+
+	ctx := context.Background()
+	itemToSave := demoData[0]
+	itemToSave.Restrictions = &APITokenRestrictions{
+		Permissions: []string{"scripts"},
+		ClientIDs:   []string{"client-1"},
+		Routes:      []string{"POST /api/v1/library/scripts/*"},
+		SourceCIDRs: []string{"10.0.0.0/8"},
+	}
+	err = dbProv.Save(ctx, &itemToSave)
+	require.NoError(t, err)
+
+	actual, err := dbProv.Get(ctx, itemToSave.Username, itemToSave.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, itemToSave.Restrictions, actual.Restrictions)
+
+	itemWithout := demoData[1]
+	err = dbProv.Save(ctx, &itemWithout)
+	require.NoError(t, err)
+
+	actual, err = dbProv.Get(ctx, itemWithout.Username, itemWithout.Prefix)
+	require.NoError(t, err)
+	assert.Nil(t, actual.Restrictions)
+}
+
 func TestUpdate(t *testing.T) {
 
 	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
@@ -268,13 +298,14 @@ func TestDelete(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"username":   demoData[0].Username,
-			"prefix":     demoData[0].Prefix,
-			"name":       demoData[0].Name,
-			"created_at": *demoData[0].CreatedAt,
-			"expires_at": *demoData[0].ExpiresAt,
-			"scope":      "read", // needed to avoid test fail using itemToSave.Scope which is of type enum
-			"token":      demoData[0].Token,
+			"username":     demoData[0].Username,
+			"prefix":       demoData[0].Prefix,
+			"name":         demoData[0].Name,
+			"created_at":   *demoData[0].CreatedAt,
+			"expires_at":   *demoData[0].ExpiresAt,
+			"scope":        "read", // needed to avoid test fail using itemToSave.Scope which is of type enum
+			"token":        demoData[0].Token,
+			"restrictions": nil,
 		},
 	}
 	q := "SELECT * FROM `api_tokens`"
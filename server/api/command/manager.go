@@ -60,6 +60,11 @@ func NewManager(db DbProvider) *Manager {
 }
 
 func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, error) {
+	return m.ListAllowed(ctx, re, nil)
+}
+
+// ListAllowed is like List but returns only the entries for which allowed returns true, nil allows all entries.
+func (m *Manager) ListAllowed(ctx context.Context, re *http.Request, allowed func(id string) bool) ([]Command, int, error) {
 	listOptions := query.GetListOptions(re)
 
 	err := query.ValidateListOptions(listOptions, supportedSortAndFilters, supportedSortAndFilters, supportedFields, &query.PaginationConfig{
@@ -83,6 +88,9 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, e
 
 	filtered := make([]Command, 0, len(entries))
 	for _, entry := range entries {
+		if allowed != nil && !allowed(entry.ID) {
+			continue
+		}
 		matches, err := query.MatchesFilters(entry, manualFilters)
 		if err != nil {
 			return nil, 0, err
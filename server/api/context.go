@@ -3,6 +3,8 @@ package api
 import (
 	"context"
 
+	"github.com/openrport/openrport/server/api/authorization"
+	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/share/logger"
 )
 
@@ -10,6 +12,10 @@ type userCtxKeyType string
 
 const userCtxKey userCtxKeyType = "user"
 
+const tokenRestrictionsCtxKey userCtxKeyType = "token_restrictions"
+
+const resourceScopeCtxKey userCtxKeyType = "resource_scope"
+
 // WithUser returns a copy of a given context that contains a given username.
 func WithUser(ctx context.Context, username string) context.Context {
 	return context.WithValue(ctx, userCtxKey, username)
@@ -25,3 +31,27 @@ func GetUser(ctx context.Context, log *logger.Logger) string {
 	}
 	return user
 }
+
+// WithTokenRestrictions returns a copy of a given context that contains the restrictions of the API token used
+// to authorize the request.
+func WithTokenRestrictions(ctx context.Context, restrictions *authorization.APITokenRestrictions) context.Context {
+	return context.WithValue(ctx, tokenRestrictionsCtxKey, restrictions)
+}
+
+// GetTokenRestrictions returns the restrictions of the API token from a given context, nil if there are none.
+func GetTokenRestrictions(ctx context.Context) *authorization.APITokenRestrictions {
+	restrictions, _ := ctx.Value(tokenRestrictionsCtxKey).(*authorization.APITokenRestrictions)
+	return restrictions
+}
+
+// WithResourceScope returns a copy of a given context that contains the resources the permission of the route is
+// limited to by the roles of the user.
+func WithResourceScope(ctx context.Context, scope *users.RoleResources) context.Context {
+	return context.WithValue(ctx, resourceScopeCtxKey, scope)
+}
+
+// GetResourceScope returns the resources the permission of the route is limited to, nil if it's not limited.
+func GetResourceScope(ctx context.Context) *users.RoleResources {
+	scope, _ := ctx.Value(resourceScopeCtxKey).(*users.RoleResources)
+	return scope
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+
+	"github.com/openrport/openrport/db/backend"
 )
 
 type CleanupProvider interface {
@@ -27,21 +29,43 @@ func (t *CleanupTask) Run(ctx context.Context) error {
 }
 
 func (p *SqliteProvider) CleanupJobsMultiJobs(ctx context.Context, maxJobs int) error {
+	driverName := p.db.DriverName()
 	// Delete all multi jobs that have jobs after max jobs
-	_, err := p.db.ExecContext(ctx, "DELETE FROM multi_jobs WHERE jid IN (SELECT multi_job_id FROM jobs ORDER BY started_at DESC LIMIT -1 OFFSET ?)", maxJobs)
+	_, err := p.db.ExecContext(ctx, "DELETE FROM multi_jobs WHERE jid IN ("+oldJobs(driverName, "multi_job_id")+")", maxJobs)
 	if err != nil {
 		return errors.Wrap(err, "deleting multi jobs")
 	}
 	// Delete all jobs associated with multi jobs
-	_, err = p.db.ExecContext(ctx, "DELETE FROM jobs WHERE multi_job_id IN (SELECT multi_job_id FROM jobs ORDER BY started_at DESC LIMIT -1 OFFSET ?) AND multi_job_id IS NOT NULL", maxJobs)
+	_, err = p.db.ExecContext(ctx, "DELETE FROM jobs WHERE multi_job_id IN ("+oldJobs(driverName, "multi_job_id")+") AND multi_job_id IS NOT NULL", maxJobs)
 	if err != nil {
 		return errors.Wrap(err, "deleting multi jobs' jobs")
 	}
 	// Delete any jobs left not from multi jobs
-	_, err = p.db.ExecContext(ctx, "DELETE FROM jobs WHERE jid IN (SELECT jid FROM jobs ORDER BY started_at DESC LIMIT -1 OFFSET ?) AND multi_job_id IS NULL", maxJobs)
+	_, err = p.db.ExecContext(ctx, "DELETE FROM jobs WHERE jid IN ("+oldJobs(driverName, "jid")+") AND multi_job_id IS NULL", maxJobs)
 	if err != nil {
 		return errors.Wrap(err, "deleting jobs")
 	}
+	// Delete the output of deleted jobs
+	_, err = p.db.ExecContext(ctx, "DELETE FROM job_outputs WHERE jid NOT IN (SELECT jid FROM jobs)")
+	if err != nil {
+		return errors.Wrap(err, "deleting job outputs")
+	}
 
 	return nil
 }
+
+// oldJobs returns a subquery selecting the column of all jobs except the newest ones, the number of newest jobs to
+// keep is the only parameter. The databases differ in how to select all rows after an offset.
+func oldJobs(driverName, column string) string {
+	switch driverName {
+	case backend.DriverMySQL:
+		// mysql doesn't support a limit in IN subqueries and requires a limit with an offset, so the rows are
+		// selected by a derived table with the maximum limit. The derived table is materialized, which also allows
+		// to select from the table rows are deleted from.
+		return "SELECT " + column + " FROM (SELECT " + column + " FROM jobs ORDER BY started_at DESC LIMIT 18446744073709551615 OFFSET ?) AS old_jobs"
+	case backend.DriverPostgres:
+		return "SELECT " + column + " FROM jobs ORDER BY started_at DESC LIMIT ALL OFFSET ?"
+	default:
+		return "SELECT " + column + " FROM jobs ORDER BY started_at DESC LIMIT -1 OFFSET ?"
+	}
+}
@@ -2,21 +2,74 @@ package jobs
 
 import (
 	"context"
+	"os"
 	"testing"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/jobs"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/server/test/jb"
+	"github.com/openrport/openrport/share/models"
 )
 
 func TestCleanupJobsMultiJobs(t *testing.T) {
-	ctx := context.Background()
 	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
 	require.NoError(t, err)
+	testCleanupJobsMultiJobs(t, jobsDB)
+}
+
+// TestCleanupJobsMultiJobsMySQL runs the cleanup on a MySQL server, it's skipped unless RPORT_TEST_MYSQL_DSN is set.
+// All jobs of the database are deleted.
+func TestCleanupJobsMultiJobsMySQL(t *testing.T) {
+	dsn := os.Getenv("RPORT_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("RPORT_TEST_MYSQL_DSN not set")
+	}
+	testCleanupJobsMultiJobs(t, openTestJobsDB(t, backend.Config{Driver: backend.DriverMySQL, DSN: dsn}))
+}
+
+// TestCleanupJobsMultiJobsPostgres runs the cleanup on a PostgreSQL server, it's skipped unless
+// RPORT_TEST_POSTGRES_DSN is set. All jobs of the database are deleted.
+func TestCleanupJobsMultiJobsPostgres(t *testing.T) {
+	dsn := os.Getenv("RPORT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("RPORT_TEST_POSTGRES_DSN not set")
+	}
+	testCleanupJobsMultiJobs(t, openTestJobsDB(t, backend.Config{Driver: backend.DriverPostgres, DSN: dsn}))
+}
+
+func openTestJobsDB(t *testing.T, c backend.Config) *sqlx.DB {
+	db, err := c.Open(backend.Store{Name: "jobs"}, DataSourceOptions)
+	require.NoError(t, err)
+	for _, table := range []string{"job_outputs", "jobs", "multi_jobs"} {
+		_, err = db.Exec("DELETE FROM " + table)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestOldJobs(t *testing.T) {
+	assert.Equal(t,
+		"SELECT jid FROM jobs ORDER BY started_at DESC LIMIT -1 OFFSET ?",
+		oldJobs(backend.DriverSQLite, "jid"),
+	)
+	assert.Equal(t,
+		"SELECT jid FROM (SELECT jid FROM jobs ORDER BY started_at DESC LIMIT 18446744073709551615 OFFSET ?) AS old_jobs",
+		oldJobs(backend.DriverMySQL, "jid"),
+	)
+	assert.Equal(t,
+		"SELECT jid FROM jobs ORDER BY started_at DESC LIMIT ALL OFFSET ?",
+		oldJobs(backend.DriverPostgres, "jid"),
+	)
+}
+
+func testCleanupJobsMultiJobs(t *testing.T, jobsDB *sqlx.DB) {
+	ctx := context.Background()
 	p := NewSqliteProvider(jobsDB, testLog)
 	defer p.Close()
 
@@ -27,14 +80,15 @@ func TestCleanupJobsMultiJobs(t *testing.T) {
 	require.NoError(t, p.SaveMultiJob(mj2))
 	require.NoError(t, p.SaveMultiJob(mj3))
 
-	j1 := jb.New(t).MultiJobID(mj1.JID).StartedAt(time.Now()).Build()
-	j2 := jb.New(t).MultiJobID(mj1.JID).StartedAt(time.Now()).Build()
-	j3 := jb.New(t).StartedAt(time.Now()).Build()
-	j4 := jb.New(t).MultiJobID(mj2.JID).StartedAt(time.Now()).Build()
-	j5 := jb.New(t).StartedAt(time.Now()).Build()
-	j6 := jb.New(t).MultiJobID(mj2.JID).StartedAt(time.Now()).Build()
-	j7 := jb.New(t).MultiJobID(mj3.JID).StartedAt(time.Now()).Build()
-	j8 := jb.New(t).MultiJobID(mj3.JID).StartedAt(time.Now()).Build()
+	startedAt := time.Now().Add(-time.Hour)
+	j1 := jb.New(t).MultiJobID(mj1.JID).StartedAt(startedAt.Add(1 * time.Second)).Build()
+	j2 := jb.New(t).MultiJobID(mj1.JID).StartedAt(startedAt.Add(2 * time.Second)).Build()
+	j3 := jb.New(t).StartedAt(startedAt.Add(3 * time.Second)).Build()
+	j4 := jb.New(t).MultiJobID(mj2.JID).StartedAt(startedAt.Add(4 * time.Second)).Build()
+	j5 := jb.New(t).StartedAt(startedAt.Add(5 * time.Second)).Build()
+	j6 := jb.New(t).MultiJobID(mj2.JID).StartedAt(startedAt.Add(6 * time.Second)).Build()
+	j7 := jb.New(t).MultiJobID(mj3.JID).StartedAt(startedAt.Add(7 * time.Second)).Build()
+	j8 := jb.New(t).MultiJobID(mj3.JID).StartedAt(startedAt.Add(8 * time.Second)).Build()
 	require.NoError(t, p.SaveJob(j1))
 	require.NoError(t, p.SaveJob(j2))
 	require.NoError(t, p.SaveJob(j3))
@@ -43,6 +97,10 @@ func TestCleanupJobsMultiJobs(t *testing.T) {
 	require.NoError(t, p.SaveJob(j6))
 	require.NoError(t, p.SaveJob(j7))
 	require.NoError(t, p.SaveJob(j8))
+	_, err := p.AppendOutput(ctx, j1.JID, models.ChannelStdout, []byte("j1 output"), 1024)
+	require.NoError(t, err)
+	_, err = p.AppendOutput(ctx, j5.JID, models.ChannelStdout, []byte("j5 output"), 1024)
+	require.NoError(t, err)
 
 	err = p.CleanupJobsMultiJobs(ctx, 3)
 	require.NoError(t, err)
@@ -83,4 +141,12 @@ func TestCleanupJobsMultiJobs(t *testing.T) {
 	j, err = p.GetByJID(j8.ClientID, j8.JID)
 	require.NoError(t, err)
 	assert.NotNil(t, j)
+
+	// output of deleted jobs is deleted
+	chunks, err := p.ListOutput(ctx, j1.JID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+	chunks, err = p.ListOutput(ctx, j5.JID, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, chunks, 1)
 }
@@ -6,12 +6,16 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 
+	"github.com/openrport/openrport/db/backend"
+	"github.com/openrport/openrport/db/mysql"
+	"github.com/openrport/openrport/db/postgres"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
@@ -92,13 +96,17 @@ type SqliteProvider struct {
 	log       *logger.Logger
 	db        *sqlx.DB
 	converter *query.SQLConverter
+
+	outputMu     sync.Mutex
+	outputStates map[string]*jobOutputState
 }
 
 func NewSqliteProvider(db *sqlx.DB, log *logger.Logger) *SqliteProvider {
 	return &SqliteProvider{
-		db:        db,
-		log:       log,
-		converter: query.NewSQLConverter(db.DriverName()),
+		db:           db,
+		log:          log,
+		converter:    query.NewSQLConverter(db.DriverName()),
+		outputStates: make(map[string]*jobOutputState),
 	}
 }
 
@@ -148,8 +156,9 @@ func (p *SqliteProvider) List(ctx context.Context, options *query.ListOptions) (
 func (p *SqliteProvider) Count(ctx context.Context, options *query.ListOptions) (int, error) {
 	countOptions := *options
 	countOptions.Pagination = nil
+	countOptions.Sorts = nil
 
-	q := "SELECT count(*) FROM (SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid)"
+	q := "SELECT count(*) FROM (SELECT jobs.*, schedule_id FROM jobs LEFT JOIN multi_jobs ON jobs.multi_job_id = multi_jobs.jid) AS jobs_with_schedule"
 	q, params := p.converter.AppendOptionsToQuery(&countOptions, q, nil)
 
 	var result int
@@ -163,14 +172,18 @@ func (p *SqliteProvider) Count(ctx context.Context, options *query.ListOptions)
 // SaveJob creates a new or updates an existing job.
 func (p *SqliteProvider) SaveJob(job *models.Job) error {
 	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
-		result, err = p.db.NamedExec(`INSERT OR REPLACE INTO jobs (jid, status, started_at, finished_at, created_by, client_id, multi_job_id, details)
-		VALUES (:jid, :status, :started_at, :finished_at, :created_by, :client_id, :multi_job_id, :details)`,
+		result, err = p.db.NamedExec(
+			backend.ReplaceInto(p.db.DriverName(), "jobs", []string{"jid"},
+				"jid", "status", "started_at", "finished_at", "created_by", "client_id", "multi_job_id", "details"),
 			convertToSqlite(job))
 		return result, err
 	}, "savejob", p.log)
 
 	if err == nil {
 		p.log.Debugf("Job saved successfully: %v", *job)
+		if job.Status != models.JobStatusRunning {
+			p.forgetOutputState(job.JID)
+		}
 	}
 	return err
 }
@@ -186,7 +199,7 @@ func (p *SqliteProvider) CreateJob(job *models.Job) error {
 	if err != nil {
 		// check if it's "already exist" err
 		typeErr, ok := err.(sqlite3.Error)
-		if ok && typeErr.Code == sqlite3.ErrConstraint {
+		if (ok && typeErr.Code == sqlite3.ErrConstraint) || mysql.IsDuplicateEntry(err) || postgres.IsDuplicateEntry(err) {
 			p.log.Debugf("Job already exist with ID: %s", job.JID)
 			return nil
 		}
@@ -231,11 +244,11 @@ func (d *JobDetails) Scan(value interface{}) error {
 	if d == nil {
 		return errors.New("'details' cannot be nil")
 	}
-	valueStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("expected to have string, got %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
 	}
-	err := json.Unmarshal([]byte(valueStr), d)
+	err = json.Unmarshal(data, d)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'details' field: %v", err)
 	}
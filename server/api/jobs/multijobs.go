@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/query"
 )
@@ -67,7 +68,7 @@ func (p *SqliteProvider) GetMultiJobSummaries(ctx context.Context, options *quer
 	if len(options.Sorts) == 0 {
 		options.Sorts = []query.SortOption{
 			{
-				Column: "DATETIME(started_at)",
+				Column: backend.DateTime(p.db.DriverName(), "started_at"),
 				IsASC:  false,
 			},
 			{
@@ -92,6 +93,7 @@ func (p *SqliteProvider) CountMultiJobs(ctx context.Context, options *query.List
 
 	countOptions := *options
 	countOptions.Pagination = nil
+	countOptions.Sorts = nil
 	q := "SELECT count(*) FROM multi_jobs"
 	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, q)
 
@@ -102,14 +104,42 @@ func (p *SqliteProvider) CountMultiJobs(ctx context.Context, options *query.List
 	return result, nil
 }
 
+// GetMultiJobClientIDs returns the IDs of the clients of all multi-client jobs by the multi-client job ID. These are
+// the clients of the child jobs and the client IDs of the request.
+func (p *SqliteProvider) GetMultiJobClientIDs(ctx context.Context) (map[string][]string, error) {
+	var details []struct {
+		JID     string                `db:"jid"`
+		Details *multiJobDetailSqlite `db:"details"`
+	}
+	err := p.db.SelectContext(ctx, &details, "SELECT jid, details FROM multi_jobs")
+	if err != nil {
+		return nil, err
+	}
+
+	var childJobs []struct {
+		MultiJobID string `db:"multi_job_id"`
+		ClientID   string `db:"client_id"`
+	}
+	err = p.db.SelectContext(ctx, &childJobs, "SELECT DISTINCT multi_job_id, client_id FROM jobs WHERE multi_job_id IS NOT NULL")
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string][]string, len(details))
+	for _, d := range details {
+		res[d.JID] = append(res[d.JID], d.Details.ClientIDs...)
+	}
+	for _, j := range childJobs {
+		res[j.MultiJobID] = append(res[j.MultiJobID], j.ClientID)
+	}
+	return res, nil
+}
+
 // SaveMultiJob creates a new or updates an existing multi-client job (without child jobs).
 func (p *SqliteProvider) SaveMultiJob(job *models.MultiJob) error {
-	_, err := p.db.NamedExec(`
-INSERT OR REPLACE INTO multi_jobs (
-	jid, started_at, created_by, schedule_id, details
-) VALUES (
-	:jid, :started_at, :created_by, :schedule_id, :details
-)`,
+	_, err := p.db.NamedExec(
+		backend.ReplaceInto(p.db.DriverName(), "multi_jobs", []string{"jid"},
+			"jid", "started_at", "created_by", "schedule_id", "details"),
 		convertMultiJobToSqlite(job))
 	if err == nil {
 		p.log.Debugf("Multi-client Job saved successfully: %v", *job)
@@ -140,17 +170,28 @@ type multiJobDetailSqlite struct {
 	TimeoutSec  int                   `json:"timeout_sec"`
 	Concurrent  bool                  `json:"concurrent"`
 	AbortOnErr  bool                  `json:"abort_on_err"`
+	IsScript    bool                  `json:"is_script"`
+
+	Rollout         *models.JobRollout     `json:"rollout,omitempty"`
+	Status          string                 `json:"status,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+	RequireApproval bool                   `json:"require_approval,omitempty"`
+	ApprovedBy      string                 `json:"approved_by,omitempty"`
+	RejectedBy      string                 `json:"rejected_by,omitempty"`
+	CancelledBy     string                 `json:"cancelled_by,omitempty"`
+	DecidedAt       *time.Time             `json:"decided_at,omitempty"`
+	Waves           []*models.MultiJobWave `json:"waves,omitempty"`
 }
 
 func (d *multiJobDetailSqlite) Scan(value interface{}) error {
 	if d == nil {
 		return errors.New("'details' cannot be nil")
 	}
-	valueStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("expected to have string, got %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
 	}
-	err := json.Unmarshal([]byte(valueStr), d)
+	err = json.Unmarshal(data, d)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'details' field: %v", err)
 	}
@@ -200,6 +241,16 @@ func (j *multiJobSqlite) convert() *models.MultiJob {
 		TimeoutSec:      d.TimeoutSec,
 		Concurrent:      d.Concurrent,
 		AbortOnErr:      d.AbortOnErr,
+		IsScript:        d.IsScript,
+		Rollout:         d.Rollout,
+		Status:          d.Status,
+		Error:           d.Error,
+		RequireApproval: d.RequireApproval,
+		ApprovedBy:      d.ApprovedBy,
+		RejectedBy:      d.RejectedBy,
+		CancelledBy:     d.CancelledBy,
+		DecidedAt:       d.DecidedAt,
+		Waves:           d.Waves,
 	}
 }
 
@@ -222,6 +273,17 @@ func convertMultiJobToSqlite(job *models.MultiJob) *multiJobSqlite {
 			TimeoutSec:  job.TimeoutSec,
 			Concurrent:  job.Concurrent,
 			AbortOnErr:  job.AbortOnErr,
+			IsScript:    job.IsScript,
+
+			Rollout:         job.Rollout,
+			Status:          job.Status,
+			Error:           job.Error,
+			RequireApproval: job.RequireApproval,
+			ApprovedBy:      job.ApprovedBy,
+			RejectedBy:      job.RejectedBy,
+			CancelledBy:     job.CancelledBy,
+			DecidedAt:       job.DecidedAt,
+			Waves:           job.Waves,
 		},
 	}
 }
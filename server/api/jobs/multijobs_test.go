@@ -75,6 +75,18 @@ func TestMultiJobsSqliteProvider(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 3, count)
 
+	// verify clients of jobs
+	clientIDs, err := p.GetMultiJobClientIDs(ctx)
+	require.NoError(t, err)
+	require.Len(t, clientIDs, 3)
+	var job1ClientIDs []string
+	job1ClientIDs = append(job1ClientIDs, job1.ClientIDs...)
+	for _, j := range job1.Jobs {
+		job1ClientIDs = append(job1ClientIDs, j.ClientID)
+	}
+	assert.ElementsMatch(t, job1ClientIDs, clientIDs[job1.JID])
+	assert.ElementsMatch(t, job2.ClientIDs, clientIDs[job2.JID])
+
 	// verify job update
 	job1.Interpreter = "cmd"
 	job1.Concurrent = true
@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/share/models"
+)
+
+// ErrOutputLimitExceeded is returned when the persisted output of a job reached its maximum size.
+var ErrOutputLimitExceeded = errors.New("maximum job output size exceeded")
+
+type jobOutputSqlite struct {
+	JID       string    `db:"jid"`
+	Offset    int64     `db:"byte_offset"`
+	Stream    string    `db:"stream"`
+	Data      []byte    `db:"data"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// jobOutputState is the end of the persisted output of a job, it's kept in memory while the job is running.
+type jobOutputState struct {
+	mu     sync.Mutex
+	loaded bool
+	end    int64
+	// users is the number of appends holding the state, finished is set once the job finished. The state is
+	// forgotten once both apply, a later append loads the end from the DB again.
+	users    int
+	finished bool
+}
+
+// AppendOutput persists a chunk streamed by a job at the end of its output. If the output would exceed maxSize bytes,
+// the chunk is truncated. ErrOutputLimitExceeded is returned, if nothing was persisted because of the limit.
+// The data must not end with an incomplete UTF-8 character, chunks are returned as strings.
+func (p *SqliteProvider) AppendOutput(ctx context.Context, jid, stream string, data []byte, maxSize int64) (*models.JobOutputChunk, error) {
+	state := p.acquireOutputState(jid)
+	defer p.releaseOutputState(jid, state)
+
+	// the offset depends on the previous chunks of both streams
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if !state.loaded {
+		err := p.db.GetContext(ctx, &state.end, "SELECT COALESCE(MAX(byte_offset + LENGTH(data)), 0) FROM job_outputs WHERE jid = ?", jid)
+		if err != nil {
+			return nil, err
+		}
+		state.loaded = true
+	}
+	end := state.end
+	if end+int64(len(data)) > maxSize {
+		data = truncateUTF8(data, maxSize-end)
+	}
+	if len(data) == 0 {
+		return nil, ErrOutputLimitExceeded
+	}
+
+	chunk := &jobOutputSqlite{
+		JID:       jid,
+		Offset:    end,
+		Stream:    stream,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
+		return p.db.NamedExecContext(ctx, `INSERT INTO job_outputs (jid, byte_offset, stream, data, created_at)
+		VALUES (:jid, :byte_offset, :stream, :data, :created_at)`, chunk)
+	}, "appendoutput", p.log)
+	if err != nil {
+		return nil, err
+	}
+	state.end = chunk.Offset + int64(len(chunk.Data))
+
+	return chunk.convert(), nil
+}
+
+func (p *SqliteProvider) acquireOutputState(jid string) *jobOutputState {
+	p.outputMu.Lock()
+	defer p.outputMu.Unlock()
+
+	state, ok := p.outputStates[jid]
+	if !ok {
+		state = &jobOutputState{}
+		p.outputStates[jid] = state
+	}
+	state.users++
+	return state
+}
+
+func (p *SqliteProvider) releaseOutputState(jid string, state *jobOutputState) {
+	p.outputMu.Lock()
+	defer p.outputMu.Unlock()
+
+	state.users--
+	if state.users == 0 && state.finished {
+		delete(p.outputStates, jid)
+	}
+}
+
+// forgetOutputState removes the in-memory state of the output of a finished job.
+func (p *SqliteProvider) forgetOutputState(jid string) {
+	p.outputMu.Lock()
+	defer p.outputMu.Unlock()
+
+	state, ok := p.outputStates[jid]
+	if !ok {
+		return
+	}
+	state.finished = true
+	if state.users == 0 {
+		delete(p.outputStates, jid)
+	}
+}
+
+// truncateUTF8 returns at most n bytes of data without splitting a UTF-8 character.
+func truncateUTF8(data []byte, n int64) []byte {
+	if n <= 0 {
+		return nil
+	}
+	if int64(len(data)) <= n {
+		return data
+	}
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return data[:n]
+}
+
+// ListOutput returns at most limit chunks of the output of a job starting at the given offset. A chunk containing the
+// offset is cut at it.
+func (p *SqliteProvider) ListOutput(ctx context.Context, jid string, since int64, limit int) ([]*models.JobOutputChunk, error) {
+	var res []*jobOutputSqlite
+	err := p.db.SelectContext(ctx, &res, `SELECT * FROM job_outputs WHERE jid = ? AND byte_offset + LENGTH(data) > ?
+		ORDER BY byte_offset LIMIT ?`, jid, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]*models.JobOutputChunk, 0, len(res))
+	for _, r := range res {
+		if r.Offset < since {
+			// an offset within a character continues with the next character
+			cut := since - r.Offset
+			for cut < int64(len(r.Data)) && !utf8.RuneStart(r.Data[cut]) {
+				cut++
+			}
+			r.Data = r.Data[cut:]
+			r.Offset += cut
+		}
+		chunks = append(chunks, r.convert())
+	}
+	return chunks, nil
+}
+
+func (o *jobOutputSqlite) convert() *models.JobOutputChunk {
+	return &models.JobOutputChunk{
+		JID:       o.JID,
+		Offset:    o.Offset,
+		Stream:    o.Stream,
+		Data:      string(o.Data),
+		CreatedAt: o.CreatedAt,
+	}
+}
@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/jobs"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/share/models"
+)
+
+func TestJobOutput(t *testing.T) {
+	ctx := context.Background()
+	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewSqliteProvider(jobsDB, testLog)
+	defer p.Close()
+
+	c, err := p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("hello "), 16)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), c.Offset)
+	c, err = p.AppendOutput(ctx, "job-1", models.ChannelStderr, []byte("oops"), 16)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), c.Offset)
+	_, err = p.AppendOutput(ctx, "job-2", models.ChannelStdout, []byte("other job"), 16)
+	require.NoError(t, err)
+	c, err = p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("world\n"), 16)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), c.Offset)
+	assert.Equal(t, "world\n", c.Data)
+	c, err = p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("truncated"), 18)
+	require.NoError(t, err)
+	assert.Equal(t, "tr", c.Data)
+	_, err = p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("dropped"), 18)
+	assert.ErrorIs(t, err, ErrOutputLimitExceeded)
+
+	chunks, err := p.ListOutput(ctx, "job-1", 0, 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+	assert.Equal(t, "hello ", chunks[0].Data)
+	assert.Equal(t, models.ChannelStderr, chunks[1].Stream)
+	assert.Equal(t, int64(18), chunks[3].End())
+
+	chunks, err = p.ListOutput(ctx, "job-1", 8, 2)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, int64(8), chunks[0].Offset)
+	assert.Equal(t, "ps", chunks[0].Data)
+	assert.Equal(t, "world\n", chunks[1].Data)
+
+	chunks, err = p.ListOutput(ctx, "job-1", 18, 100)
+	require.NoError(t, err)
+	assert.Empty(t, chunks)
+}
+
+func TestJobOutputUTF8(t *testing.T) {
+	ctx := context.Background()
+	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewSqliteProvider(jobsDB, testLog)
+	defer p.Close()
+
+	// "€" is 3 bytes, the limit is within the second one
+	c, err := p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("a€"), 6)
+	require.NoError(t, err)
+	assert.Equal(t, "a€", c.Data)
+	c, err = p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("b€"), 6)
+	require.NoError(t, err)
+	assert.Equal(t, "b", c.Data)
+	_, err = p.AppendOutput(ctx, "job-1", models.ChannelStdout, []byte("€"), 6)
+	assert.ErrorIs(t, err, ErrOutputLimitExceeded)
+
+	chunks, err := p.ListOutput(ctx, "job-1", 2, 100)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, int64(4), chunks[0].Offset)
+	assert.Equal(t, "", chunks[0].Data)
+	assert.Equal(t, "b", chunks[1].Data)
+
+	// the end of the output is loaded from the DB, if the state of the job isn't in memory
+	p2 := NewSqliteProvider(jobsDB, testLog)
+	c, err = p2.AppendOutput(ctx, "job-1", models.ChannelStderr, []byte("x"), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), c.Offset)
+}
@@ -55,3 +55,13 @@ func (c *CronImplementation) Remove(id string) {
 	c.cron.Remove(entryID)
 	delete(c.mapping, id)
 }
+
+func (c *CronImplementation) RemoveAll() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for id, entryID := range c.mapping {
+		c.cron.Remove(entryID)
+		delete(c.mapping, id)
+	}
+}
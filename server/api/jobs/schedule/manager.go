@@ -55,6 +55,12 @@ type Cron interface {
 	Validate(string) error
 	Add(string, string, func(context.Context, string)) error
 	Remove(string)
+	RemoveAll()
+}
+
+// ActiveChecker tells whether this server is the active one when running in high availability mode.
+type ActiveChecker interface {
+	IsActive() bool
 }
 
 type JobRunner interface {
@@ -66,6 +72,7 @@ type Manager struct {
 	jobRunner JobRunner
 	provider  Provider
 	cron      Cron
+	active    ActiveChecker
 
 	runRemoteCmdTimeoutSec int
 }
@@ -73,18 +80,11 @@ type Manager struct {
 func New(ctx context.Context, logger *logger.Logger, db *sqlx.DB, jobRunner JobRunner, runRemoteCmdTimeoutSec int) (*Manager, error) {
 	m := NewManager(jobRunner, db, logger, runRemoteCmdTimeoutSec)
 
-	existing, err := m.provider.List(ctx, nil)
+	err := m.Reload(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, cron := range existing {
-		err := m.addCron(cron)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return m, nil
 }
 
@@ -100,6 +100,29 @@ func NewManager(jobRunner JobRunner, db *sqlx.DB, logger *logger.Logger, runRemo
 	return m
 }
 
+// SetActiveChecker makes schedules run only while the given checker reports this server as active.
+func (m *Manager) SetActiveChecker(active ActiveChecker) {
+	m.active = active
+}
+
+// Reload replaces all crons by the schedules stored in the DB, e.g. to pick up the changes made by another server.
+func (m *Manager) Reload(ctx context.Context) error {
+	existing, err := m.provider.List(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	m.cron.RemoveAll()
+	for _, cron := range existing {
+		err := m.addCron(cron)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *Manager) List(ctx context.Context, r *http.Request) (*api.SuccessPayload, error) {
 	listOptions := query.GetListOptions(r)
 
@@ -273,6 +296,11 @@ func (m *Manager) addCron(s *Schedule) error {
 }
 
 func (m *Manager) run(ctx context.Context, id string) {
+	if m.active != nil && !m.active.IsActive() {
+		m.Debugf("Skipping schedule %s, because the server is passive.", id)
+		return
+	}
+
 	schedule, err := m.provider.Get(ctx, id)
 	if err != nil {
 		m.Errorf("Could not get schedule %s: %v", id, err)
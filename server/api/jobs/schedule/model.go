@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/server/api/jobs"
 	"github.com/openrport/openrport/share/models"
 )
@@ -84,11 +85,11 @@ func (d *Details) Scan(value interface{}) error {
 	if d == nil {
 		return errors.New("'details' cannot be nil")
 	}
-	valueStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("expected to have string, got %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
 	}
-	err := json.Unmarshal([]byte(valueStr), d)
+	err = json.Unmarshal(data, d)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'details' field: %v", err)
 	}
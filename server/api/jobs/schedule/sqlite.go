@@ -7,6 +7,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/query"
 )
@@ -25,7 +26,7 @@ LEFT JOIN (
 		mj.schedule_id,
 		mj.started_at AS last_started_at,
 		COUNT(j.jid) AS last_client_count,
-		COUNT(j.jid) FILTER (WHERE j.status = '` + models.JobStatusSuccessful + `') AS last_success_count,
+		SUM(CASE WHEN j.status = '` + models.JobStatusSuccessful + `' THEN 1 ELSE 0 END) AS last_success_count,
 		MIN(j.status) AS last_status,
 		MIN(j.details) AS last_details,
 		ROW_NUMBER() OVER (PARTITION BY mj.schedule_id ORDER BY mj.started_at DESC) AS rn
@@ -159,6 +160,14 @@ func (p *SQLiteProvider) Delete(ctx context.Context, id string) error {
 func (p *SQLiteProvider) CountJobsInProgress(ctx context.Context, scheduleID string, timeoutSec int) (int, error) {
 	var result int
 
+	runningSec := "strftime('%s', 'now') - strftime('%s', jobs.started_at)"
+	switch p.db.DriverName() {
+	case backend.DriverMySQL:
+		runningSec = "UNIX_TIMESTAMP() - UNIX_TIMESTAMP(jobs.started_at)"
+	case backend.DriverPostgres:
+		runningSec = "EXTRACT(EPOCH FROM CURRENT_TIMESTAMP - jobs.started_at)"
+	}
+
 	err := p.db.GetContext(ctx, &result, `
 SELECT count(*)
 FROM jobs
@@ -168,7 +177,7 @@ WHERE
 AND
 	finished_at IS NULL
 AND
-	strftime('%s', 'now') - strftime('%s', jobs.started_at) <= ?
+	`+runningSec+` <= ?
 `, scheduleID, timeoutSec)
 	if err != nil {
 		return 0, err
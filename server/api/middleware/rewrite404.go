@@ -42,6 +42,13 @@ func (w *NotFoundRewriteResponseWriter) Write(p []byte) (int, error) {
 	return len(p), nil // lie that it was successfully written
 }
 
+// Flush implements http.Flusher to support streamed responses.
+func (w *NotFoundRewriteResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func Rewrite404(h http.Handler, rewritePath string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		newW := &NotFoundRewriteResponseWriter{ResponseWriter: w}
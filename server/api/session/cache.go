@@ -25,20 +25,40 @@ func NewCache(
 	if c == nil {
 		c = cache.New(defaultExpiration, cleanupInterval)
 	}
+	p := &Cache{
+		cache:   c,
+		storage: storage,
+	}
+
+	err := p.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Reload replaces the cached sessions by the valid sessions from storage, e.g. to pick up the sessions created
+// by another server sharing the storage.
+func (p *Cache) Reload(ctx context.Context) error {
+	for id := range p.cache.Items() {
+		p.cache.Delete(id)
+	}
+	return p.load(ctx)
+}
+
+func (p *Cache) load(ctx context.Context) error {
 	now := time.Now()
-	validSessions, err := storage.GetAll(ctx)
+	validSessions, err := p.storage.GetAll(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get api sessions from storage: %w", err)
+		return fmt.Errorf("unable to get api sessions from storage: %w", err)
 	}
 
 	for _, cur := range validSessions {
-		c.Set(formatID(cur.SessionID), cur, cur.ExpiresAt.Sub(now))
+		p.cache.Set(formatID(cur.SessionID), cur, cur.ExpiresAt.Sub(now))
 	}
 
-	return &Cache{
-		cache:   c,
-		storage: storage,
-	}, nil
+	return nil
 }
 
 func (p *Cache) Get(ctx context.Context, sessionID int64) (found bool, sessionInfo APISession, err error) {
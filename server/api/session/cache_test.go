@@ -310,3 +310,33 @@ func TestShouldNotChangeExistingSessionsWhenDeleteAllForUnknownUser(t *testing.T
 	require.True(t, found)
 	assert.Equal(t, s2, storedS2)
 }
+
+func TestShouldReloadSessionsFromStorage(t *testing.T) {
+	ctx, c := SetupTestAPISessionCache(t)
+
+	longTTL := time.Hour
+	timeNow := time.Now().UTC()
+
+	s1 := generateAPISession(t, "user1", timeNow.Add(longTTL), timeNow)
+	sid, err := c.Save(ctx, s1)
+	require.NoError(t, err)
+	s1.SessionID = sid
+
+	// saved and deleted by another server sharing the storage
+	s2 := generateAPISession(t, "user2", timeNow.Add(longTTL), timeNow)
+	sid, err = c.storage.Save(ctx, s2)
+	require.NoError(t, err)
+	s2.SessionID = sid
+	require.NoError(t, c.storage.Delete(ctx, s1.SessionID))
+
+	require.NoError(t, c.Reload(ctx))
+
+	found, _, err := c.Get(ctx, s1.SessionID)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, cachedS2, err := c.Get(ctx, s2.SessionID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, s2, cachedS2)
+}
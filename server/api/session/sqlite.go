@@ -8,10 +8,18 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/api_sessions"
 	"github.com/openrport/openrport/db/sqlite"
 )
 
+var Store = backend.Store{
+	Name:           "api_sessions",
+	SQLiteFilename: "api_sessions.db",
+	AssetNames:     api_sessions.AssetNames(),
+	Asset:          api_sessions.Asset,
+}
+
 type SqliteProvider struct {
 	db *sqlx.DB
 }
@@ -22,14 +30,19 @@ func NewSqliteProvider(dbPath string, dataSourceOptions sqlite.DataSourceOptions
 		return nil, fmt.Errorf("unable to create api session DB instance: %w", err)
 	}
 
-	return &SqliteProvider{db: db}, nil
+	return NewProvider(db), nil
+}
+
+// NewProvider returns a provider using an already migrated DB.
+func NewProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{db: db}
 }
 
 func (p *SqliteProvider) GetAll(ctx context.Context) ([]APISession, error) {
 	var result []APISession
 	err := p.db.SelectContext(
 		ctx, &result,
-		"SELECT * FROM api_sessions WHERE DATETIME(expires_at) >= DATETIME(?)",
+		"SELECT * FROM api_sessions WHERE "+backend.DateTime(p.db.DriverName(), "expires_at")+" >= "+backend.DateTime(p.db.DriverName(), "?"),
 		time.Now(),
 	)
 	if err != nil {
@@ -71,13 +84,24 @@ func (p *SqliteProvider) Save(ctx context.Context, session APISession) (sessionI
 }
 
 func (p *SqliteProvider) add(ctx context.Context, session APISession) (sessionID int64, err error) {
-	result, err := p.db.NamedExecContext(
-		ctx,
-		"INSERT INTO"+
-			" api_sessions (expires_at, username, last_access_at, user_agent, ip_address)"+
-			" VALUES (:expires_at, :username, :last_access_at, :user_agent, :ip_address)",
-		session,
-	)
+	q := "INSERT INTO" +
+		" api_sessions (expires_at, username, last_access_at, user_agent, ip_address)" +
+		" VALUES (:expires_at, :username, :last_access_at, :user_agent, :ip_address)"
+
+	if p.db.DriverName() == backend.DriverPostgres {
+		// postgres doesn't support LastInsertId
+		bound, args, err := p.db.BindNamed(q+" RETURNING session_id", session)
+		if err != nil {
+			return 0, fmt.Errorf("unable to create api session: %w", err)
+		}
+		err = p.db.GetContext(ctx, &sessionID, bound, args...)
+		if err != nil {
+			return 0, fmt.Errorf("unable to create api session: %w", err)
+		}
+		return sessionID, nil
+	}
+
+	result, err := p.db.NamedExecContext(ctx, q, session)
 	if err != nil {
 		return 0, fmt.Errorf("unable to create api session: %w", err)
 	}
@@ -123,7 +147,7 @@ func (p *SqliteProvider) Delete(ctx context.Context, sessionID int64) error {
 func (p *SqliteProvider) DeleteExpired(ctx context.Context) error {
 	_, err := p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_sessions WHERE DATETIME(expires_at) <= DATETIME(?)",
+		"DELETE FROM api_sessions WHERE "+backend.DateTime(p.db.DriverName(), "expires_at")+" <= "+backend.DateTime(p.db.DriverName(), "?"),
 		time.Now(),
 	)
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/openrport/openrport/db/backend"
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/share/enums"
 	"github.com/openrport/openrport/share/logger"
@@ -199,22 +200,16 @@ func (d *UserDatabase) UpdateGroup(name string, group Group) error {
 	var err error
 	group.Name = name
 
-	qt1 := ""
-	qt2 := ""
+	columns := []string{"name", "permissions"}
 	if group.TunnelsRestricted != nil {
-		qt1 = ", tunnels_restricted"
-		qt2 = ", :tunnels_restricted"
+		columns = append(columns, "tunnels_restricted")
 	}
-
-	qc1 := ""
-	qc2 := ""
 	if group.CommandsRestricted != nil {
-		qc1 = ", commands_restricted"
-		qc2 = ", :commands_restricted"
+		columns = append(columns, "commands_restricted")
 	}
 	// compose the query (assume the extended fields are present)
 	// We rely on a unique index. Let the database decide, if INSERT or UPDATE is needed.
-	qb := fmt.Sprintf("REPLACE INTO `%s` (name, permissions%s%s) VALUES (:name, :permissions%s%s)", d.groupDetailsTableName, qt1, qc1, qt2, qc2)
+	qb := backend.ReplaceInto(d.db.DriverName(), "`"+d.groupDetailsTableName+"`", []string{"name"}, columns...)
 
 	_, err = d.db.NamedExec(qb, group)
 
@@ -281,6 +276,11 @@ func (d *UserDatabase) Add(usr *User) error {
 		usr.Password,
 	}
 
+	if usr.PasswordExpired != nil {
+		columns = append(columns, "`password_expired`")
+		params = append(params, usr.PasswordExpired)
+	}
+
 	if d.twoFAOn {
 		columns = append(columns, "`two_fa_send_to`")
 		params = append(params, usr.TwoFASendTo)
@@ -0,0 +1,386 @@
+package users
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/patrickmn/go-cache"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/share/enums"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	// maxNestedGroupDepth limits the resolution of nested groups in case of circular memberships
+	maxNestedGroupDepth = 10
+	// ldapPageSize is the number of entries requested per page, it stays below the default size limit of
+	// Active Directory (MaxPageSize of 1000) that fails unpaged searches with sizeLimitExceeded
+	ldapPageSize = 500
+)
+
+// LDAPDirectory is a connection to an LDAP server that is bound as the search user, it's implemented by *ldap.Conn.
+type LDAPDirectory interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	SearchWithPaging(req *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// LDAPDialer opens a new connection to the directory.
+type LDAPDialer func() (LDAPDirectory, error)
+
+// LDAPProvider authenticates users with a bind to an LDAP server or Active Directory. Users and their groups are
+// read from the directory, LDAP groups are mapped to rport user groups. Users without a mapped group get the
+// default user group if missing users should be created, otherwise they are unknown.
+type LDAPProvider struct {
+	*logger.Logger
+
+	config             chconfig.LDAPConfig
+	dial               LDAPDialer
+	createMissingUsers bool
+	defaultUserGroup   string
+	// cache holds users by username to avoid a directory lookup on every request, nil if caching is disabled
+	cache *cache.Cache
+}
+
+func NewLDAPProvider(logger *logger.Logger, config *chconfig.Config, dial LDAPDialer) *LDAPProvider {
+	p := &LDAPProvider{
+		Logger:             logger,
+		config:             config.LDAP,
+		dial:               dial,
+		createMissingUsers: config.API.CreateMissingUsers,
+		defaultUserGroup:   config.API.DefaultUserGroup,
+	}
+	if config.LDAP.CacheTTL > 0 {
+		p.cache = cache.New(config.LDAP.CacheTTL, 2*config.LDAP.CacheTTL)
+	}
+	return p
+}
+
+// NewLDAPDialer returns a dialer that connects to the configured server, upgrades the connection with StartTLS
+// if enabled and binds as the search user.
+func NewLDAPDialer(config chconfig.LDAPConfig) (LDAPDialer, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         u.Hostname(),
+		InsecureSkipVerify: config.InsecureSkipVerify, // #nosec G402 -- explicitly enabled by the user
+		MinVersion:         tls.VersionTLS12,
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ldap.ca_file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ldap.ca_file doesn't contain any PEM encoded certificates")
+		}
+	}
+
+	return func() (LDAPDirectory, error) {
+		conn, err := ldap.DialURL(config.URL,
+			ldap.DialWithDialer(&net.Dialer{Timeout: config.Timeout}),
+			ldap.DialWithTLSConfig(tlsConfig),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to ldap server: %v", err)
+		}
+		conn.SetTimeout(config.Timeout)
+		if config.StartTLS {
+			if err := conn.StartTLS(tlsConfig); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to start tls with ldap server: %v", err)
+			}
+		}
+		if config.BindDN != "" {
+			if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("failed to bind to ldap server as %q: %v", config.BindDN, err)
+			}
+		}
+		return conn, nil
+	}, nil
+}
+
+func (p *LDAPProvider) Type() enums.ProviderSource {
+	return enums.ProviderSourceLDAP
+}
+
+func (p *LDAPProvider) SupportsGroupPermissions() bool {
+	return false
+}
+
+func (p *LDAPProvider) GetByUsername(username string) (*User, error) {
+	if p.cache != nil {
+		if cached, found := p.cache.Get(username); found {
+			return cached.(*User), nil
+		}
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := p.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+	var user *User
+	if entry != nil {
+		user, err = p.toUser(conn, entry, username)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.cache != nil {
+		p.cache.SetDefault(username, user)
+	}
+	return user, nil
+}
+
+func (p *LDAPProvider) GetAll() ([]*User, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	result, err := conn.SearchWithPaging(&ldap.SearchRequest{
+		BaseDN:     p.config.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     strings.ReplaceAll(p.config.UserFilter, "{username}", "*"),
+		Attributes: p.userAttributes(),
+	}, ldapPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search ldap users: %v", err)
+	}
+
+	var users []*User
+	for _, entry := range result.Entries {
+		username := entry.GetEqualFoldAttributeValue(p.config.UsernameAttribute)
+		if username == "" {
+			continue
+		}
+		user, err := p.toUser(conn, entry, username)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// VerifyPassword binds as the user to check the password.
+func (p *LDAPProvider) VerifyPassword(username, password string) (bool, error) {
+	// a bind with an empty password is an unauthenticated bind that succeeds on most servers
+	if password == "" {
+		return false, nil
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	entry, err := p.findUser(conn, username)
+	if err != nil || entry == nil {
+		return false, err
+	}
+
+	err = conn.Bind(entry.DN, password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to bind to ldap server as %q: %v", entry.DN, err)
+	}
+	return true, nil
+}
+
+// findUser returns the entry of the user or nil if it doesn't exist.
+func (p *LDAPProvider) findUser(conn LDAPDirectory, username string) (*ldap.Entry, error) {
+	result, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:     p.config.BaseDN,
+		Scope:      ldap.ScopeWholeSubtree,
+		Filter:     strings.ReplaceAll(p.config.UserFilter, "{username}", ldap.EscapeFilter(username)),
+		Attributes: p.userAttributes(),
+		SizeLimit:  2,
+	})
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("failed to search ldap user %q: %v", username, err)
+	}
+	if err != nil || len(result.Entries) > 1 {
+		return nil, fmt.Errorf("ldap user filter matches more than one entry for user %q", username)
+	}
+	if len(result.Entries) == 0 {
+		return nil, nil
+	}
+	return result.Entries[0], nil
+}
+
+// toUser returns the rport user of an entry, nil if the user has no mapped group and missing users are not created.
+func (p *LDAPProvider) toUser(conn LDAPDirectory, entry *ldap.Entry, username string) (*User, error) {
+	groups, err := p.findGroups(conn, entry.DN, username)
+	if err != nil {
+		return nil, err
+	}
+
+	userGroups := p.mapGroups(groups)
+	if len(userGroups) == 0 {
+		if !p.createMissingUsers {
+			p.Debugf("LDAP user %q has no group mapped to an rport user group.", username)
+			return nil, nil
+		}
+		userGroups = []string{p.defaultUserGroup}
+	}
+
+	if canonical := entry.GetEqualFoldAttributeValue(p.config.UsernameAttribute); strings.EqualFold(canonical, username) {
+		username = canonical
+	}
+	user := &User{
+		Username: username,
+		Groups:   userGroups,
+	}
+	if p.config.TwoFASendToAttribute != "" {
+		user.TwoFASendTo = entry.GetEqualFoldAttributeValue(p.config.TwoFASendToAttribute)
+	}
+	return user, nil
+}
+
+// findGroups returns the groups the entry with the given DN is a member of. Nested groups are resolved by searching
+// the groups of each found group again, Active Directory can resolve them in a single search with the
+// ldap.MatchingRuleInChain matching rule instead.
+func (p *LDAPProvider) findGroups(conn LDAPDirectory, dn, username string) ([]*ldap.Entry, error) {
+	if p.config.GroupFilter == "" {
+		return nil, nil
+	}
+
+	var groups []*ldap.Entry
+	found := make(map[string]bool)
+	members := []string{dn}
+	for depth := 0; len(members) > 0 && depth < maxNestedGroupDepth; depth++ {
+		var nextMembers []string
+		for _, member := range members {
+			filter := strings.NewReplacer(
+				"{dn}", ldap.EscapeFilter(member),
+				"{username}", ldap.EscapeFilter(username),
+			).Replace(p.config.GroupFilter)
+			result, err := conn.SearchWithPaging(&ldap.SearchRequest{
+				BaseDN:     p.config.GroupBaseDN,
+				Scope:      ldap.ScopeWholeSubtree,
+				Filter:     filter,
+				Attributes: []string{"cn"},
+			}, ldapPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search ldap groups of %q: %v", member, err)
+			}
+			for _, entry := range result.Entries {
+				key := strings.ToLower(entry.DN)
+				if found[key] {
+					continue
+				}
+				found[key] = true
+				groups = append(groups, entry)
+				nextMembers = append(nextMembers, entry.DN)
+			}
+		}
+		if !p.config.NestedGroups {
+			break
+		}
+		members = nextMembers
+	}
+	return groups, nil
+}
+
+// mapGroups returns the rport user groups mapped to the DNs or common names of the given LDAP groups.
+func (p *LDAPProvider) mapGroups(groups []*ldap.Entry) []string {
+	var userGroups []string
+	for _, group := range groups {
+		for _, name := range append([]string{group.DN}, group.GetEqualFoldAttributeValues("cn")...) {
+			for _, userGroup := range p.config.GroupMappingParsed[strings.ToLower(name)] {
+				if !containsString(userGroups, userGroup) {
+					userGroups = append(userGroups, userGroup)
+				}
+			}
+		}
+	}
+	return userGroups
+}
+
+func (p *LDAPProvider) userAttributes() []string {
+	var attributes []string
+	for _, attr := range []string{p.config.UsernameAttribute, p.config.TwoFASendToAttribute} {
+		if attr != "" {
+			attributes = append(attributes, attr)
+		}
+	}
+	return attributes
+}
+
+func (p *LDAPProvider) ListGroups() ([]Group, error) {
+	return nil, errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this feature.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) GetGroup(string) (Group, error) {
+	return Group{}, errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this feature.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) UpdateGroup(string, Group) error {
+	return errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this feature.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) DeleteGroup(string) error {
+	return errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this feature.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) Add(*User) error {
+	return errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this operation, users are managed in the directory.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) Update(*User, string) error {
+	return errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this operation, users are managed in the directory.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (p *LDAPProvider) Delete(string) error {
+	return errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this operation, users are managed in the directory.",
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
@@ -0,0 +1,277 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/chconfig"
+)
+
+const (
+	ldapJDoeDN   = "uid=jdoe,ou=people,dc=example,dc=com"
+	ldapGuestDN  = "uid=guest,ou=people,dc=example,dc=com"
+	ldapDevsDN   = "cn=devs,ou=groups,dc=example,dc=com"
+	ldapStaffDN  = "cn=staff,ou=groups,dc=example,dc=com"
+	ldapGuestsDN = "cn=guests,ou=groups,dc=example,dc=com"
+)
+
+// LDAPDirectoryMock answers searches by their filter and binds by DN. Unpaged searches fail like Active Directory
+// if they match more than SizeLimit entries.
+type LDAPDirectoryMock struct {
+	Passwords     map[string]string
+	Entries       map[string][]*ldap.Entry
+	SizeLimit     int
+	Searches      int
+	PagedSearches int
+	Closed        bool
+}
+
+func (d *LDAPDirectoryMock) Bind(dn, password string) error {
+	if d.Passwords[dn] != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+	}
+	return nil
+}
+
+func (d *LDAPDirectoryMock) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.Searches++
+	entries := d.Entries[req.Filter]
+	if d.SizeLimit > 0 && len(entries) > d.SizeLimit {
+		return &ldap.SearchResult{Entries: entries[:d.SizeLimit]}, ldap.NewError(ldap.LDAPResultSizeLimitExceeded, errors.New("size limit exceeded"))
+	}
+	return &ldap.SearchResult{Entries: entries}, nil
+}
+
+func (d *LDAPDirectoryMock) SearchWithPaging(req *ldap.SearchRequest, pagingSize uint32) (*ldap.SearchResult, error) {
+	d.Searches++
+	d.PagedSearches++
+	return &ldap.SearchResult{Entries: d.Entries[req.Filter]}, nil
+}
+
+func (d *LDAPDirectoryMock) Close() error {
+	d.Closed = true
+	return nil
+}
+
+func newLDAPDirectoryMock() *LDAPDirectoryMock {
+	return &LDAPDirectoryMock{
+		Passwords: map[string]string{
+			ldapJDoeDN:  "jdoe-secret",
+			ldapGuestDN: "guest-secret",
+		},
+		Entries: map[string][]*ldap.Entry{
+			"(&(objectClass=person)(uid=jdoe))": {
+				ldap.NewEntry(ldapJDoeDN, map[string][]string{"uid": {"jdoe"}, "mail": {"jdoe@example.com"}}),
+			},
+			"(&(objectClass=person)(uid=guest))": {
+				ldap.NewEntry(ldapGuestDN, map[string][]string{"uid": {"guest"}}),
+			},
+			"(&(objectClass=person)(uid=*))": {
+				ldap.NewEntry(ldapJDoeDN, map[string][]string{"uid": {"jdoe"}, "mail": {"jdoe@example.com"}}),
+				ldap.NewEntry(ldapGuestDN, map[string][]string{"uid": {"guest"}}),
+			},
+			"(member=" + ldapJDoeDN + ")": {
+				ldap.NewEntry(ldapDevsDN, map[string][]string{"cn": {"devs"}}),
+			},
+			"(member=" + ldapDevsDN + ")": {
+				ldap.NewEntry(ldapStaffDN, map[string][]string{"cn": {"staff"}}),
+			},
+			"(member=" + ldapStaffDN + ")": {
+				// circular membership must not loop
+				ldap.NewEntry(ldapDevsDN, map[string][]string{"cn": {"devs"}}),
+			},
+			"(member=" + ldapGuestDN + ")": {
+				ldap.NewEntry(ldapGuestsDN, map[string][]string{"cn": {"guests"}}),
+			},
+		},
+	}
+}
+
+func newTestLDAPProvider(t *testing.T, directory *LDAPDirectoryMock, configure func(*chconfig.Config)) *LDAPProvider {
+	config := &chconfig.Config{
+		API: chconfig.APIConfig{
+			DefaultUserGroup: "Administrators",
+		},
+		LDAP: chconfig.LDAPConfig{
+			URL:                  "ldap://ldap.example.com",
+			BaseDN:               "dc=example,dc=com",
+			UserFilter:           "(&(objectClass=person)(uid={username}))",
+			UsernameAttribute:    "uid",
+			TwoFASendToAttribute: "mail",
+			GroupFilter:          "(member={dn})",
+			NestedGroups:         true,
+			GroupMapping: []string{
+				"CN=Devs,OU=Groups,DC=example,DC=com:developers",
+				"staff:operators",
+			},
+		},
+	}
+	if configure != nil {
+		configure(config)
+	}
+	require.NoError(t, config.LDAP.ParseAndValidate())
+
+	return NewLDAPProvider(testLog, config, func() (LDAPDirectory, error) {
+		directory.Closed = false
+		return directory, nil
+	})
+}
+
+func TestLDAPProviderGetByUsername(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Username      string
+		Configure     func(*chconfig.Config)
+		ExpectedUser  *User
+		ExpectedError string
+	}{
+		{
+			Name:     "nested groups",
+			Username: "jdoe",
+			ExpectedUser: &User{
+				Username:    "jdoe",
+				Groups:      []string{"developers", "operators"},
+				TwoFASendTo: "jdoe@example.com",
+			},
+		},
+		{
+			Name:     "nested groups disabled",
+			Username: "jdoe",
+			Configure: func(config *chconfig.Config) {
+				config.LDAP.NestedGroups = false
+			},
+			ExpectedUser: &User{
+				Username:    "jdoe",
+				Groups:      []string{"developers"},
+				TwoFASendTo: "jdoe@example.com",
+			},
+		},
+		{
+			Name:         "no mapped group",
+			Username:     "guest",
+			ExpectedUser: nil,
+		},
+		{
+			Name:     "no mapped group, create missing users",
+			Username: "guest",
+			Configure: func(config *chconfig.Config) {
+				config.API.CreateMissingUsers = true
+			},
+			ExpectedUser: &User{
+				Username: "guest",
+				Groups:   []string{"Administrators"},
+			},
+		},
+		{
+			Name:     "unknown user, create missing users",
+			Username: "unknown",
+			Configure: func(config *chconfig.Config) {
+				config.API.CreateMissingUsers = true
+			},
+			ExpectedUser: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			directory := newLDAPDirectoryMock()
+			p := newTestLDAPProvider(t, directory, tc.Configure)
+
+			user, err := p.GetByUsername(tc.Username)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedUser, user)
+			assert.True(t, directory.Closed)
+		})
+	}
+}
+
+func TestLDAPProviderCache(t *testing.T) {
+	directory := newLDAPDirectoryMock()
+	p := newTestLDAPProvider(t, directory, func(config *chconfig.Config) {
+		config.LDAP.CacheTTL = time.Minute
+	})
+
+	user, err := p.GetByUsername("jdoe")
+	require.NoError(t, err)
+	searches := directory.Searches
+
+	cached, err := p.GetByUsername("jdoe")
+	require.NoError(t, err)
+	assert.Equal(t, user, cached)
+	assert.Equal(t, searches, directory.Searches)
+
+	unknown, err := p.GetByUsername("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, unknown)
+	searches = directory.Searches
+	_, err = p.GetByUsername("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, searches, directory.Searches)
+}
+
+func TestLDAPProviderGetAll(t *testing.T) {
+	directory := newLDAPDirectoryMock()
+	directory.SizeLimit = 1
+	p := newTestLDAPProvider(t, directory, nil)
+
+	all, err := p.GetAll()
+
+	require.NoError(t, err)
+	assert.Equal(t, directory.Searches, directory.PagedSearches, "searches for all users and groups must be paged")
+	assert.Equal(t, []*User{
+		{
+			Username:    "jdoe",
+			Groups:      []string{"developers", "operators"},
+			TwoFASendTo: "jdoe@example.com",
+		},
+	}, all)
+}
+
+func TestLDAPProviderVerifyPassword(t *testing.T) {
+	p := newTestLDAPProvider(t, newLDAPDirectoryMock(), nil)
+
+	testCases := []struct {
+		Username string
+		Password string
+		Expected bool
+	}{
+		{"jdoe", "jdoe-secret", true},
+		{"jdoe", "guest-secret", false},
+		{"jdoe", "", false},
+		{"unknown", "jdoe-secret", false},
+	}
+	for _, tc := range testCases {
+		ok, err := p.VerifyPassword(tc.Username, tc.Password)
+		require.NoError(t, err)
+		assert.Equal(t, tc.Expected, ok, "%s:%s", tc.Username, tc.Password)
+	}
+}
+
+func TestLDAPProviderIsReadOnly(t *testing.T) {
+	p := newTestLDAPProvider(t, newLDAPDirectoryMock(), nil)
+
+	err := p.Add(&User{Username: "new"})
+	assert.Equal(t, errors2.APIError{
+		Message:    "The LDAP authentication backend doesn't support this operation, users are managed in the directory.",
+		HTTPStatus: http.StatusBadRequest,
+	}, err)
+	assert.Error(t, p.Update(&User{}, "jdoe"))
+	assert.Error(t, p.Delete("jdoe"))
+	_, err = p.ListGroups()
+	assert.Error(t, err)
+
+	service := NewAPIService(p, false, 0, -1)
+	assert.NotNil(t, service.PasswordVerifier())
+	assert.Nil(t, NewAPIService(NewStaticProvider(nil), false, 0, -1).PasswordVerifier())
+}
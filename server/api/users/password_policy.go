@@ -0,0 +1,220 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/share/enums"
+)
+
+// PasswordHistoryEntry is a password hash a user had, the latest entry is the current password.
+type PasswordHistoryEntry struct {
+	Username  string    `db:"username"`
+	Password  string    `db:"password"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Lockout counts the consecutive failed logins of a user, the user is locked if LockedAt is set.
+type Lockout struct {
+	Username       string     `json:"username" db:"username"`
+	FailedAttempts int        `json:"failed_attempts" db:"failed_attempts"`
+	LockedAt       *time.Time `json:"locked_at" db:"locked_at"`
+}
+
+type PasswordPolicyProvider interface {
+	// GetPasswordHistory returns the latest entries of the user, newest first.
+	GetPasswordHistory(ctx context.Context, username string, limit int) ([]*PasswordHistoryEntry, error)
+	// AddPasswordHistory adds the entry and removes all but the latest keep entries of the user.
+	AddPasswordHistory(ctx context.Context, entry *PasswordHistoryEntry, keep int) error
+	GetLockout(ctx context.Context, username string) (*Lockout, error)
+	GetLockouts(ctx context.Context) ([]*Lockout, error)
+	// AddFailedLogin counts a failed login of the user, it returns true if the user got locked.
+	AddFailedLogin(ctx context.Context, username string, threshold int, now time.Time) (bool, error)
+	DeleteLockout(ctx context.Context, username string) error
+	RenameUser(ctx context.Context, oldUsername, newUsername string) error
+	DeleteUser(ctx context.Context, username string) error
+	Close() error
+}
+
+// PasswordPolicy configures how long passwords are valid, how many previous passwords can't be reused and after how
+// many failed logins a user is locked.
+type PasswordPolicy struct {
+	MaxAge  time.Duration
+	History int
+	// LockoutThreshold is the number of consecutive failed logins after which the user is locked, 0 disables it
+	LockoutThreshold int
+	// LockoutDuration unlocks the user automatically, if 0 the user stays locked until unlocked by an administrator
+	LockoutDuration time.Duration
+}
+
+var ErrUserLocked = errors2.APIError{
+	Message:    "account is locked due to too many failed login attempts, please contact an administrator",
+	HTTPStatus: http.StatusUnauthorized,
+}
+
+// hasPasswordHistory returns true if password hashes are stored with the users, so they can be kept in the history.
+func (as *APIService) hasPasswordHistory() bool {
+	if as.PasswordPolicyProvider == nil {
+		return false
+	}
+	providerType := as.Provider.Type()
+	return providerType == enums.ProviderSourceDB || providerType == enums.ProviderSourceFile
+}
+
+// checkPasswordReuse returns an error if the password matches the current or one of the previous passwords of the user.
+func (as *APIService) checkPasswordReuse(ctx context.Context, username, password string) error {
+	if as.PasswordPolicy.History <= 0 || !as.hasPasswordHistory() {
+		return nil
+	}
+
+	history, err := as.PasswordPolicyProvider.GetPasswordHistory(ctx, username, as.PasswordPolicy.History)
+	if err != nil {
+		return err
+	}
+	hashes := make([]string, 0, len(history)+1)
+	for _, entry := range history {
+		hashes = append(hashes, entry.Password)
+	}
+	existing, err := as.Provider.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		hashes = append(hashes, existing.Password)
+	}
+
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return errors2.APIError{
+				Message:    "Your password was used before",                                                               // title
+				Err:        fmt.Errorf("password must not match any of the last %d passwords", as.PasswordPolicy.History), // detail
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+func (as *APIService) addPasswordHistory(ctx context.Context, username, passwordHash string, createdAt time.Time) error {
+	if !as.hasPasswordHistory() {
+		return nil
+	}
+	keep := as.PasswordPolicy.History
+	if keep < 1 {
+		// the current password is kept to know its age
+		keep = 1
+	}
+	return as.PasswordPolicyProvider.AddPasswordHistory(ctx, &PasswordHistoryEntry{
+		Username:  username,
+		Password:  passwordHash,
+		CreatedAt: createdAt,
+	}, keep)
+}
+
+// IsPasswordExpired returns true if the password of the user was expired by an administrator or it's older than the
+// max age. Passwords which were set before the max age was enabled start to age on the first check.
+func (as *APIService) IsPasswordExpired(ctx context.Context, user *User) (bool, error) {
+	if user.PasswordExpired != nil && *user.PasswordExpired {
+		return true, nil
+	}
+	if as.PasswordPolicy.MaxAge <= 0 || !as.hasPasswordHistory() {
+		return false, nil
+	}
+
+	history, err := as.PasswordPolicyProvider.GetPasswordHistory(ctx, user.Username, 1)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if len(history) == 0 || history[0].Password != user.Password {
+		// the password was changed outside of rport, e.g. directly in the database
+		return false, as.addPasswordHistory(ctx, user.Username, user.Password, now)
+	}
+	return now.Sub(history[0].CreatedAt) > as.PasswordPolicy.MaxAge, nil
+}
+
+// CheckLockout returns ErrUserLocked if the user is locked.
+func (as *APIService) CheckLockout(ctx context.Context, username string) error {
+	if as.PasswordPolicy.LockoutThreshold <= 0 || as.PasswordPolicyProvider == nil {
+		return nil
+	}
+
+	lockout, err := as.PasswordPolicyProvider.GetLockout(ctx, username)
+	if err != nil {
+		return err
+	}
+	if lockout == nil || lockout.LockedAt == nil {
+		return nil
+	}
+	if as.PasswordPolicy.LockoutDuration > 0 && time.Since(*lockout.LockedAt) > as.PasswordPolicy.LockoutDuration {
+		return as.PasswordPolicyProvider.DeleteLockout(ctx, username)
+	}
+	return ErrUserLocked
+}
+
+// AddFailedLogin counts a failed login of an existing user and locks the user if the threshold is reached.
+// It returns true if the user got locked.
+func (as *APIService) AddFailedLogin(ctx context.Context, username string) (bool, error) {
+	if as.PasswordPolicy.LockoutThreshold <= 0 || as.PasswordPolicyProvider == nil || username == "" {
+		return false, nil
+	}
+
+	// unknown users are not counted, otherwise anyone could fill the database with random usernames
+	user, err := as.Provider.GetByUsername(username)
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	return as.PasswordPolicyProvider.AddFailedLogin(ctx, username, as.PasswordPolicy.LockoutThreshold, time.Now())
+}
+
+// ResetFailedLogins is called after a successful login.
+func (as *APIService) ResetFailedLogins(ctx context.Context, username string) error {
+	if as.PasswordPolicy.LockoutThreshold <= 0 || as.PasswordPolicyProvider == nil {
+		return nil
+	}
+	// it's called on every request with basic auth, so the lockout is only deleted if there is one
+	lockout, err := as.PasswordPolicyProvider.GetLockout(ctx, username)
+	if err != nil || lockout == nil {
+		return err
+	}
+	return as.PasswordPolicyProvider.DeleteLockout(ctx, username)
+}
+
+// GetLockouts returns the lockouts of all users by username.
+func (as *APIService) GetLockouts(ctx context.Context) (map[string]*Lockout, error) {
+	result := make(map[string]*Lockout)
+	if as.PasswordPolicyProvider == nil {
+		return result, nil
+	}
+	lockouts, err := as.PasswordPolicyProvider.GetLockouts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, lockout := range lockouts {
+		result[lockout.Username] = lockout
+	}
+	return result, nil
+}
+
+func (as *APIService) Unlock(ctx context.Context, username string) error {
+	user, err := as.Provider.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("cannot find user by username '%s'", username),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	if as.PasswordPolicyProvider == nil {
+		return nil
+	}
+	return as.PasswordPolicyProvider.DeleteLockout(ctx, username)
+}
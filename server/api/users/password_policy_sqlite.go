@@ -0,0 +1,157 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/db/backend"
+)
+
+type PasswordPolicySqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewPasswordPolicySqliteProvider(db *sqlx.DB) *PasswordPolicySqliteProvider {
+	return &PasswordPolicySqliteProvider{
+		db: db,
+	}
+}
+
+func (p *PasswordPolicySqliteProvider) GetPasswordHistory(ctx context.Context, username string, limit int) ([]*PasswordHistoryEntry, error) {
+	var res []*PasswordHistoryEntry
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM password_history WHERE username = ? ORDER BY created_at DESC LIMIT ?", username, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get password history from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *PasswordPolicySqliteProvider) AddPasswordHistory(ctx context.Context, entry *PasswordHistoryEntry, keep int) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT INTO password_history (username, password, created_at) VALUES (:username, :password, :created_at)",
+		entry,
+	)
+	if err != nil {
+		return err
+	}
+
+	var history []*PasswordHistoryEntry
+	err = p.db.SelectContext(ctx, &history, "SELECT * FROM password_history WHERE username = ? ORDER BY created_at DESC", entry.Username)
+	if err != nil {
+		return fmt.Errorf("unable to get password history from DB: %w", err)
+	}
+	for i := keep; i < len(history); i++ {
+		_, err = p.db.ExecContext(
+			ctx,
+			"DELETE FROM password_history WHERE username = ? AND password = ? AND created_at = ?",
+			history[i].Username, history[i].Password, history[i].CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PasswordPolicySqliteProvider) GetLockout(ctx context.Context, username string) (*Lockout, error) {
+	res := &Lockout{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM user_lockouts WHERE username = ?", username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get user lockout from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *PasswordPolicySqliteProvider) GetLockouts(ctx context.Context) ([]*Lockout, error) {
+	var res []*Lockout
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM user_lockouts ORDER BY username")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get user lockouts from DB: %w", err)
+	}
+	return res, nil
+}
+
+// AddFailedLogin increments the failed attempts of a user who isn't locked and locks the user at the threshold with
+// the given time. The counter is incremented by the database in a transaction, so concurrent failed logins are all
+// counted and only one of them returns true.
+func (p *PasswordPolicySqliteProvider) AddFailedLogin(ctx context.Context, username string, threshold int, now time.Time) (bool, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	locked, err := p.addFailedLogin(ctx, tx, username, threshold, now)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	return locked, tx.Commit()
+}
+
+func (p *PasswordPolicySqliteProvider) addFailedLogin(ctx context.Context, tx *sqlx.Tx, username string, threshold int, now time.Time) (bool, error) {
+	_, err := tx.NamedExecContext(ctx, backend.InsertIgnore(p.db.DriverName(), "user_lockouts", "username"), &Lockout{Username: username})
+	if err != nil {
+		return false, err
+	}
+
+	// locked_at is set first, because mysql evaluates the assignments from left to right. It's kept as the ELSE
+	// branch, so postgres infers the type of the parameter from the column.
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE user_lockouts SET locked_at = CASE WHEN failed_attempts + 1 >= ? THEN ? ELSE locked_at END, failed_attempts = failed_attempts + 1 WHERE username = ? AND locked_at IS NULL",
+		threshold, now, username,
+	)
+	if err != nil {
+		return false, err
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affectedRows == 0 {
+		// already locked
+		return false, nil
+	}
+
+	var failedAttempts int
+	err = tx.GetContext(ctx, &failedAttempts, "SELECT failed_attempts FROM user_lockouts WHERE username = ?", username)
+	if err != nil {
+		return false, err
+	}
+	return failedAttempts >= threshold, nil
+}
+
+func (p *PasswordPolicySqliteProvider) DeleteLockout(ctx context.Context, username string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM user_lockouts WHERE username = ?", username)
+	return err
+}
+
+func (p *PasswordPolicySqliteProvider) RenameUser(ctx context.Context, oldUsername, newUsername string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE password_history SET username = ? WHERE username = ?", newUsername, oldUsername)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "UPDATE user_lockouts SET username = ? WHERE username = ?", newUsername, oldUsername)
+	return err
+}
+
+func (p *PasswordPolicySqliteProvider) DeleteUser(ctx context.Context, username string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM password_history WHERE username = ?", username)
+	if err != nil {
+		return err
+	}
+	_, err = p.db.ExecContext(ctx, "DELETE FROM user_lockouts WHERE username = ?", username)
+	return err
+}
+
+func (p *PasswordPolicySqliteProvider) Close() error {
+	return p.db.Close()
+}
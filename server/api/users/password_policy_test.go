@@ -0,0 +1,198 @@
+package users
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/password_policy"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+func newTestPasswordPolicyProvider(t *testing.T) *PasswordPolicySqliteProvider {
+	db, err := sqlite.New(":memory:", password_policy.AssetNames(), password_policy.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	provider := NewPasswordPolicySqliteProvider(db)
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func TestPasswordHistory(t *testing.T) {
+	ctx := context.Background()
+	currentHash, err := GenerateTokenHash("current-password")
+	require.NoError(t, err)
+	provider := newTestPasswordPolicyProvider(t)
+	service := APIService{
+		Provider: &ProviderMock{
+			UsersToGive: []*User{{Username: "user1", Password: currentHash}},
+		},
+		PasswordPolicyProvider: provider,
+		PasswordPolicy:         PasswordPolicy{History: 2},
+	}
+
+	err = service.Change(&User{Password: "current-password"}, "user1")
+	assert.EqualError(t, err, "password must not match any of the last 2 passwords")
+
+	require.NoError(t, service.Change(&User{Password: "password-1"}, "user1"))
+	require.NoError(t, service.Change(&User{Password: "password-2"}, "user1"))
+	require.NoError(t, service.Change(&User{Password: "password-3"}, "user1"))
+
+	history, err := provider.GetPasswordHistory(ctx, "user1", 10)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	err = service.Change(&User{Password: "password-2"}, "user1")
+	assert.EqualError(t, err, "password must not match any of the last 2 passwords")
+	// only the last 2 passwords are kept
+	assert.NoError(t, service.Change(&User{Password: "password-1"}, "user1"))
+
+	require.NoError(t, service.Delete("user1"))
+	history, err = provider.GetPasswordHistory(ctx, "user1", 10)
+	require.NoError(t, err)
+	assert.Len(t, history, 0)
+}
+
+func TestIsPasswordExpired(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestPasswordPolicyProvider(t)
+	service := APIService{
+		Provider:               &ProviderMock{},
+		PasswordPolicyProvider: provider,
+		PasswordPolicy:         PasswordPolicy{MaxAge: 24 * time.Hour},
+	}
+	user := &User{Username: "user1", Password: "hash-1"}
+
+	// the password starts to age on the first check
+	expired, err := service.IsPasswordExpired(ctx, user)
+	require.NoError(t, err)
+	assert.False(t, expired)
+	history, err := provider.GetPasswordHistory(ctx, "user1", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "hash-1", history[0].Password)
+
+	require.NoError(t, provider.AddPasswordHistory(ctx, &PasswordHistoryEntry{
+		Username:  "user2",
+		Password:  "hash-2",
+		CreatedAt: time.Now().Add(-25 * time.Hour),
+	}, 1))
+	user = &User{Username: "user2", Password: "hash-2"}
+	expired, err = service.IsPasswordExpired(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, expired)
+
+	user.PasswordExpired = PasswordExpired(true)
+	service.PasswordPolicy.MaxAge = 0
+	expired, err = service.IsPasswordExpired(ctx, user)
+	require.NoError(t, err)
+	assert.True(t, expired)
+}
+
+func TestUserLockout(t *testing.T) {
+	ctx := context.Background()
+	service := APIService{
+		Provider: &ProviderMock{
+			UsersToGive: []*User{{Username: "user1"}},
+		},
+		PasswordPolicyProvider: newTestPasswordPolicyProvider(t),
+		PasswordPolicy:         PasswordPolicy{LockoutThreshold: 3},
+	}
+
+	for i := 0; i < 2; i++ {
+		locked, err := service.AddFailedLogin(ctx, "user1")
+		require.NoError(t, err)
+		assert.False(t, locked)
+	}
+	require.NoError(t, service.ResetFailedLogins(ctx, "user1"))
+
+	for i := 0; i < 2; i++ {
+		locked, err := service.AddFailedLogin(ctx, "user1")
+		require.NoError(t, err)
+		assert.False(t, locked)
+	}
+	assert.NoError(t, service.CheckLockout(ctx, "user1"))
+	locked, err := service.AddFailedLogin(ctx, "user1")
+	require.NoError(t, err)
+	assert.True(t, locked)
+	assert.Equal(t, ErrUserLocked, service.CheckLockout(ctx, "user1"))
+
+	lockouts, err := service.GetLockouts(ctx)
+	require.NoError(t, err)
+	require.Contains(t, lockouts, "user1")
+	assert.NotNil(t, lockouts["user1"].LockedAt)
+
+	// unknown users are not counted
+	for i := 0; i < 3; i++ {
+		locked, err := service.AddFailedLogin(ctx, "unknown")
+		require.NoError(t, err)
+		assert.False(t, locked)
+	}
+	assert.NoError(t, service.CheckLockout(ctx, "unknown"))
+
+	require.NoError(t, service.Unlock(ctx, "user1"))
+	assert.NoError(t, service.CheckLockout(ctx, "user1"))
+	assert.EqualError(t, service.Unlock(ctx, "unknown"), "cannot find user by username 'unknown'")
+}
+
+func TestUserLockoutDuration(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestPasswordPolicyProvider(t)
+	service := APIService{
+		Provider:               &ProviderMock{},
+		PasswordPolicyProvider: provider,
+		PasswordPolicy:         PasswordPolicy{LockoutThreshold: 3, LockoutDuration: time.Hour},
+	}
+
+	locked, err := provider.AddFailedLogin(ctx, "user1", 1, time.Now().Add(-30*time.Minute))
+	require.NoError(t, err)
+	require.True(t, locked)
+	assert.Equal(t, ErrUserLocked, service.CheckLockout(ctx, "user1"))
+
+	require.NoError(t, provider.DeleteLockout(ctx, "user1"))
+	locked, err = provider.AddFailedLogin(ctx, "user1", 1, time.Now().Add(-2*time.Hour))
+	require.NoError(t, err)
+	require.True(t, locked)
+	assert.NoError(t, service.CheckLockout(ctx, "user1"))
+	lockout, err := provider.GetLockout(ctx, "user1")
+	require.NoError(t, err)
+	assert.Nil(t, lockout)
+}
+
+func TestAddFailedLoginConcurrently(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestPasswordPolicyProvider(t)
+	const attempts = 20
+	const threshold = 5
+
+	var wg sync.WaitGroup
+	results := make(chan bool, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			locked, err := provider.AddFailedLogin(ctx, "user1", threshold, time.Now())
+			assert.NoError(t, err)
+			results <- locked
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	lockedCount := 0
+	for locked := range results {
+		if locked {
+			lockedCount++
+		}
+	}
+	assert.Equal(t, 1, lockedCount, "only one failed login must lock the user")
+
+	lockout, err := provider.GetLockout(ctx, "user1")
+	require.NoError(t, err)
+	require.NotNil(t, lockout)
+	assert.Equal(t, threshold, lockout.FailedAttempts)
+	assert.NotNil(t, lockout.LockedAt)
+}
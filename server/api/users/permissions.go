@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	"github.com/openrport/openrport/db/backend"
 )
 
 const (
@@ -16,6 +18,7 @@ const (
 	PermissionMonitoring = "monitoring"
 	PermissionUploads    = "uploads"
 	PermissionsAuditLog  = "auditlog"
+	PermissionFiles      = "files"
 )
 
 var AllPermissions = []string{
@@ -27,6 +30,7 @@ var AllPermissions = []string{
 	PermissionMonitoring,
 	PermissionUploads,
 	PermissionsAuditLog,
+	PermissionFiles,
 }
 
 type Permissions struct {
@@ -63,24 +67,15 @@ func (permissions *Permissions) Scan(value interface{}) error {
 		return errors.New("'permissions' cannot be nil")
 	}
 
-	var err error
-	switch d := value.(type) {
-	case string:
-		// Handle sqlite json decoding
-		if d == "" {
-			return nil
-		}
-		err = json.Unmarshal([]byte(d), &permissions.data)
-	case []uint8:
-		// Handle MySQL json decoding
-		if len(d) == 0 {
-			return nil
-		}
-		err = json.Unmarshal(d, &permissions.data)
-	default:
-		return fmt.Errorf("failed to decode json column: unknown comlumn type %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
 	}
 
+	err = json.Unmarshal(data, &permissions.data)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'permissions' field: %v", err)
 	}
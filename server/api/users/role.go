@@ -0,0 +1,228 @@
+package users
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openrport/openrport/db/backend"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/share/types"
+)
+
+// Role combines permissions with the resources they are limited to. A role is assigned to user groups, its members
+// get the permissions of the role for the selected resources only.
+type Role struct {
+	Name        string            `json:"name" db:"name"`
+	Description string            `json:"description" db:"description"`
+	Permissions Permissions       `json:"permissions" db:"permissions"`
+	Resources   RoleResources     `json:"resources" db:"resources"`
+	UserGroups  types.StringSlice `json:"user_groups" db:"user_groups"`
+}
+
+// RoleResources select the resources of a role. An empty selector selects all resources of its kind.
+type RoleResources struct {
+	// ClientGroupIDs and ClientTags select clients, a client must match either of them
+	ClientGroupIDs []string `json:"client_group_ids,omitempty"`
+	ClientTags     []string `json:"client_tags,omitempty"`
+	// StoredTunnelIDs select stored tunnels of clients and client groups
+	StoredTunnelIDs []string `json:"stored_tunnel_ids,omitempty"`
+	// ScriptIDs and CommandIDs select items of the library
+	ScriptIDs  []string `json:"script_ids,omitempty"`
+	CommandIDs []string `json:"command_ids,omitempty"`
+	// VaultKeys select vault values by key, a trailing * matches any suffix
+	VaultKeys []string `json:"vault_keys,omitempty"`
+}
+
+type RoleProvider interface {
+	GetAll(ctx context.Context) ([]*Role, error)
+	Get(ctx context.Context, name string) (*Role, error)
+	Save(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, name string) error
+	Close() error
+}
+
+// EffectivePermission explains how a permission is granted to a user.
+type EffectivePermission struct {
+	Granted bool `json:"granted"`
+	// UserGroups grant the permission for all resources
+	UserGroups []string `json:"user_groups"`
+	// Roles grant the permission for their resources only
+	Roles []string `json:"roles"`
+	// Resources the permission is limited to, nil if it's granted for all resources
+	Resources *RoleResources `json:"resources"`
+}
+
+func (r *Role) Validate() error {
+	if r.Name == "" {
+		return errors2.APIError{
+			Message:    "role name is required",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if strings.TrimSpace(r.Name) != r.Name {
+		return errors2.APIError{
+			Message:    "role name must not start or end with whitespace",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	for _, key := range r.Resources.VaultKeys {
+		if key == "" || strings.Contains(strings.TrimSuffix(key, "*"), "*") {
+			return errors2.APIError{
+				Message:    fmt.Sprintf("invalid vault key %q, only a trailing * is allowed", key),
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+	return nil
+}
+
+// IsAssignedTo returns true if the role is assigned to one of the given user groups.
+func (r *Role) IsAssignedTo(userGroups []string) bool {
+	for _, group := range userGroups {
+		if containsString(r.UserGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge adds the resources of other to r, a selector that is empty in either of them selects all resources.
+func (r *RoleResources) merge(other RoleResources) {
+	// client groups and tags are a single selector, a client matching either of them is selected
+	if r.selectsAllClients() || other.selectsAllClients() {
+		r.ClientGroupIDs = nil
+		r.ClientTags = nil
+	} else {
+		r.ClientGroupIDs = unionSelector(r.ClientGroupIDs, other.ClientGroupIDs)
+		r.ClientTags = unionSelector(r.ClientTags, other.ClientTags)
+	}
+	r.StoredTunnelIDs = mergeSelector(r.StoredTunnelIDs, other.StoredTunnelIDs)
+	r.ScriptIDs = mergeSelector(r.ScriptIDs, other.ScriptIDs)
+	r.CommandIDs = mergeSelector(r.CommandIDs, other.CommandIDs)
+	r.VaultKeys = mergeSelector(r.VaultKeys, other.VaultKeys)
+}
+
+// AllowsClient returns true if the client with the given tags and client groups is selected.
+func (r *RoleResources) AllowsClient(tags []string, clientGroupIDs []string) bool {
+	if r == nil || r.selectsAllClients() {
+		return true
+	}
+	for _, tag := range tags {
+		if containsString(r.ClientTags, tag) {
+			return true
+		}
+	}
+	for _, groupID := range clientGroupIDs {
+		if containsString(r.ClientGroupIDs, groupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsStoredTunnel returns true if the stored tunnel is selected, an empty id is a new stored tunnel.
+func (r *RoleResources) AllowsStoredTunnel(id string) bool {
+	return r == nil || allowsID(r.StoredTunnelIDs, id)
+}
+
+// AllowsScript returns true if the library script is selected, an empty id is a new script.
+func (r *RoleResources) AllowsScript(id string) bool {
+	return r == nil || allowsID(r.ScriptIDs, id)
+}
+
+// AllowsCommand returns true if the library command is selected, an empty id is a new command.
+func (r *RoleResources) AllowsCommand(id string) bool {
+	return r == nil || allowsID(r.CommandIDs, id)
+}
+
+// AllowsVaultKey returns true if the vault value with the given key is selected.
+func (r *RoleResources) AllowsVaultKey(key string) bool {
+	if r == nil || len(r.VaultKeys) == 0 {
+		return true
+	}
+	for _, pattern := range r.VaultKeys {
+		if strings.HasSuffix(pattern, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+				return true
+			}
+			continue
+		}
+		if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RoleResources) Scan(value interface{}) error {
+	if r == nil {
+		return errors.New("'resources' cannot be nil")
+	}
+
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	err = json.Unmarshal(data, r)
+	if err != nil {
+		return fmt.Errorf("failed to decode 'resources' field: %v", err)
+	}
+	return nil
+}
+
+func (r RoleResources) Value() (driver.Value, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'resources' field: %v", err)
+	}
+	return string(b), nil
+}
+
+func (r *RoleResources) selectsAllClients() bool {
+	return len(r.ClientGroupIDs) == 0 && len(r.ClientTags) == 0
+}
+
+func mergeSelector(a, b []string) []string {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	return unionSelector(a, b)
+}
+
+func unionSelector(a, b []string) []string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	result := append([]string{}, a...)
+	for _, v := range b {
+		if !containsString(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func allowsID(selector []string, id string) bool {
+	if len(selector) == 0 {
+		return true
+	}
+	return id != "" && containsString(selector, id)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
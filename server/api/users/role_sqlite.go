@@ -0,0 +1,72 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/db/backend"
+)
+
+type RoleSqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewRoleSqliteProvider(db *sqlx.DB) *RoleSqliteProvider {
+	return &RoleSqliteProvider{
+		db: db,
+	}
+}
+
+func (p *RoleSqliteProvider) GetAll(ctx context.Context) ([]*Role, error) {
+	var res []*Role
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM roles ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get roles from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *RoleSqliteProvider) Get(ctx context.Context, name string) (*Role, error) {
+	res := &Role{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM roles WHERE name = ?", name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get role from DB: %w", err)
+	}
+	return res, nil
+}
+
+// Save creates the role or replaces an existing role with the same name.
+func (p *RoleSqliteProvider) Save(ctx context.Context, role *Role) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		backend.ReplaceInto(p.db.DriverName(), "roles", []string{"name"}, "name", "description", "permissions", "resources", "user_groups"),
+		role,
+	)
+	return err
+}
+
+func (p *RoleSqliteProvider) Delete(ctx context.Context, name string) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM roles WHERE name = ?", name)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affectedRows == 0 {
+		return fmt.Errorf("cannot find role by name %s", name)
+	}
+	return nil
+}
+
+func (p *RoleSqliteProvider) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,152 @@
+package users
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/roles"
+	"github.com/openrport/openrport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestRoleValidate(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Role          Role
+		ExpectedError string
+	}{
+		{
+			Name: "valid",
+			Role: Role{Name: "operators", Resources: RoleResources{VaultKeys: []string{"db-*", "api-key"}}},
+		},
+		{
+			Name:          "missing name",
+			Role:          Role{},
+			ExpectedError: "role name is required",
+		},
+		{
+			Name:          "whitespace in name",
+			Role:          Role{Name: " operators"},
+			ExpectedError: "role name must not start or end with whitespace",
+		},
+		{
+			Name:          "invalid vault key",
+			Role:          Role{Name: "operators", Resources: RoleResources{VaultKeys: []string{"db-*-password"}}},
+			ExpectedError: `invalid vault key "db-*-password", only a trailing * is allowed`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.Role.Validate()
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRoleResourcesAllows(t *testing.T) {
+	var unscoped *RoleResources
+	assert.True(t, unscoped.AllowsClient(nil, nil))
+	assert.True(t, unscoped.AllowsScript("script-1"))
+	assert.True(t, unscoped.AllowsVaultKey("any"))
+
+	scope := &RoleResources{
+		ClientGroupIDs:  []string{"group-1"},
+		ClientTags:      []string{"linux"},
+		StoredTunnelIDs: []string{"tunnel-1"},
+		ScriptIDs:       []string{"script-1"},
+		VaultKeys:       []string{"db-*", "api-key"},
+	}
+
+	assert.True(t, scope.AllowsClient([]string{"linux", "prod"}, nil))
+	assert.True(t, scope.AllowsClient(nil, []string{"group-2", "group-1"}))
+	assert.False(t, scope.AllowsClient([]string{"windows"}, []string{"group-2"}))
+
+	assert.True(t, scope.AllowsStoredTunnel("tunnel-1"))
+	assert.False(t, scope.AllowsStoredTunnel("tunnel-2"))
+	assert.False(t, scope.AllowsStoredTunnel(""))
+
+	assert.True(t, scope.AllowsScript("script-1"))
+	assert.False(t, scope.AllowsScript(""))
+	assert.True(t, scope.AllowsCommand("command-1"))
+	assert.True(t, scope.AllowsCommand(""))
+
+	assert.True(t, scope.AllowsVaultKey("db-password"))
+	assert.True(t, scope.AllowsVaultKey("api-key"))
+	assert.False(t, scope.AllowsVaultKey("api-key-2"))
+}
+
+func TestRoleResourcesMerge(t *testing.T) {
+	resources := RoleResources{
+		ClientTags: []string{"linux"},
+		ScriptIDs:  []string{"script-1"},
+		VaultKeys:  []string{"db-*"},
+	}
+	resources.merge(RoleResources{
+		ClientTags: []string{"windows", "linux"},
+		ScriptIDs:  []string{"script-2"},
+	})
+
+	assert.Equal(t, RoleResources{
+		ClientTags: []string{"linux", "windows"},
+		ScriptIDs:  []string{"script-1", "script-2"},
+	}, resources)
+}
+
+func TestRoleResourcesMergeClients(t *testing.T) {
+	resources := RoleResources{ClientGroupIDs: []string{"group-1"}}
+	resources.merge(RoleResources{ClientTags: []string{"linux"}})
+	assert.Equal(t, RoleResources{ClientGroupIDs: []string{"group-1"}, ClientTags: []string{"linux"}}, resources)
+	assert.True(t, resources.AllowsClient(nil, []string{"group-1"}))
+	assert.True(t, resources.AllowsClient([]string{"linux"}, nil))
+	assert.False(t, resources.AllowsClient([]string{"windows"}, []string{"group-2"}))
+
+	resources.merge(RoleResources{ScriptIDs: []string{"script-1"}})
+	assert.True(t, resources.AllowsClient([]string{"windows"}, []string{"group-2"}), "a role without client selectors selects all clients")
+}
+
+func TestRoleSqliteProvider(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", roles.AssetNames(), roles.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewRoleSqliteProvider(db)
+	defer p.Close()
+
+	role := &Role{
+		Name:        "operators",
+		Description: "Run scripts on linux servers",
+		Permissions: NewPermissions(PermissionScripts),
+		Resources:   RoleResources{ClientTags: []string{"linux"}},
+		UserGroups:  []string{"ops"},
+	}
+	require.NoError(t, p.Save(ctx, role))
+
+	actual, err := p.Get(ctx, "operators")
+	require.NoError(t, err)
+	assert.Equal(t, role, actual)
+
+	role.UserGroups = []string{"ops", "dev"}
+	require.NoError(t, p.Save(ctx, role))
+
+	all, err := p.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Role{role}, all)
+
+	require.NoError(t, p.Delete(ctx, "operators"))
+	actual, err = p.Get(ctx, "operators")
+	require.NoError(t, err)
+	assert.Nil(t, actual)
+
+	assert.EqualError(t, p.Delete(ctx, "operators"), "cannot find role by name operators")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	zxcvbn "github.com/trustelem/zxcvbn"
@@ -34,6 +35,11 @@ type Provider interface {
 	Delete(usernameToDelete string) error
 }
 
+// PasswordVerifier is implemented by providers that verify passwords themselves instead of storing password hashes.
+type PasswordVerifier interface {
+	VerifyPassword(username, password string) (bool, error)
+}
+
 type APIService struct {
 	DeliverySrv            message.Service
 	Provider               Provider
@@ -41,6 +47,11 @@ type APIService struct {
 	TotPOn                 bool
 	PasswordMinLength      int
 	PasswordZxcvbnMinscore int
+	// RoleProvider stores the roles assigned to user groups, roles are not used if it's nil
+	RoleProvider RoleProvider
+	// PasswordPolicyProvider stores the password history and lockouts, the password policy is not applied if it's nil
+	PasswordPolicyProvider PasswordPolicyProvider
+	PasswordPolicy         PasswordPolicy
 }
 
 func NewAPIService(provider Provider, twoFAOn bool, passwordMinLength int, PasswordZxcvbnMinscore int) *APIService {
@@ -56,7 +67,12 @@ func NewAPIServiceFromConfig(authDB *sqlx.DB, config *chconfig.Config) (*APIServ
 	var usersProvider Provider
 	var err error
 	if rportplus.IsOAuthPermittedUserList(config.PlusConfig) {
-		if config.API.AuthFile != "" {
+		if config.LDAP.Enabled() {
+			usersProvider, err = newLDAPProvider(config)
+			if err != nil {
+				return nil, err
+			}
+		} else if config.API.AuthFile != "" {
 			logger := logger.NewLogger("auth-file", config.Logging.LogOutput, config.Logging.LogLevel)
 			usersProvider, err = NewFileAdapter(logger, NewFileManager(logger, config.API.AuthFile))
 			if err != nil {
@@ -89,10 +105,31 @@ func NewAPIServiceFromConfig(authDB *sqlx.DB, config *chconfig.Config) (*APIServ
 		if err != nil {
 			return nil, err
 		}
+	} else if config.LDAP.Enabled() {
+		usersProvider, err = newLDAPProvider(config)
+		if err != nil {
+			return nil, err
+		}
 	}
-	return NewAPIService(
+	apiService := NewAPIService(
 		usersProvider, config.API.IsTwoFAOn(), config.API.PasswordMinLength, config.API.PasswordZxcvbnMinscore,
-	), nil
+	)
+	apiService.PasswordPolicy = PasswordPolicy{
+		MaxAge:           time.Duration(config.API.PasswordMaxAgeDays) * 24 * time.Hour,
+		History:          config.API.PasswordHistory,
+		LockoutThreshold: config.API.UserLockoutThreshold,
+		LockoutDuration:  config.API.UserLockoutDuration,
+	}
+	return apiService, nil
+}
+
+func newLDAPProvider(config *chconfig.Config) (*LDAPProvider, error) {
+	dial, err := NewLDAPDialer(config.LDAP)
+	if err != nil {
+		return nil, err
+	}
+	logger := logger.NewLogger("ldap", config.Logging.LogOutput, config.Logging.LogLevel)
+	return NewLDAPProvider(logger, config, dial), nil
 }
 
 func (as APIService) SupportsGroupPermissions() bool {
@@ -103,6 +140,14 @@ func (as APIService) GetProviderType() enums.ProviderSource {
 	return as.Provider.Type()
 }
 
+// PasswordVerifier returns the provider if it verifies passwords itself, nil if passwords are stored with the users.
+func (as APIService) PasswordVerifier() PasswordVerifier {
+	if verifier, ok := as.Provider.(PasswordVerifier); ok {
+		return verifier
+	}
+	return nil
+}
+
 func (as *APIService) GetAll() ([]*User, error) {
 	return as.Provider.GetAll()
 }
@@ -128,7 +173,40 @@ func (as *APIService) UpdateGroup(name string, g Group) (Group, error) {
 }
 
 func (as *APIService) DeleteGroup(name string) error {
-	return as.Provider.DeleteGroup(name)
+	err := as.Provider.DeleteGroup(name)
+	if err != nil {
+		return err
+	}
+	return as.unassignRoles(context.Background(), name)
+}
+
+// unassignRoles removes a deleted user group from the roles assigned to it.
+func (as *APIService) unassignRoles(ctx context.Context, userGroup string) error {
+	if as.RoleProvider == nil {
+		return nil
+	}
+
+	roles, err := as.RoleProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if !role.IsAssignedTo([]string{userGroup}) {
+			continue
+		}
+		userGroups := make([]string, 0, len(role.UserGroups))
+		for _, g := range role.UserGroups {
+			if g != userGroup {
+				userGroups = append(userGroups, g)
+			}
+		}
+		role.UserGroups = userGroups
+		err = as.RoleProvider.Save(ctx, role)
+		if err != nil {
+			return fmt.Errorf("failed to remove user group %q from role %q: %v", userGroup, role.Name, err)
+		}
+	}
+	return nil
 }
 
 func (as *APIService) ExtendedPermissionsParsingValidation(g Group) error {
@@ -147,6 +225,17 @@ func (as *APIService) CheckPermission(user *User, permission string) error {
 			return nil
 		}
 	}
+
+	roles, err := as.getUserRoles(user)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.Permissions.Has(permission) {
+			return nil
+		}
+	}
+
 	return errors2.APIError{
 		Message:    fmt.Sprintf("user does not have %q permission", permission),
 		HTTPStatus: http.StatusForbidden,
@@ -188,9 +277,221 @@ func (as *APIService) GetEffectiveUserPermissions(user *User) (map[string]bool,
 			}
 		}
 	}
+
+	roles, err := as.getUserRoles(user)
+	if err != nil {
+		return permissions, err
+	}
+	for _, role := range roles {
+		for _, permission := range AllPermissions {
+			if role.Permissions.Has(permission) {
+				permissions[permission] = true
+			}
+		}
+	}
 	return permissions, nil
 }
 
+// ExplainPermissions returns for each permission whether it's granted to the user, by which user groups and roles,
+// and the resources it's limited to.
+func (as *APIService) ExplainPermissions(user *User) (map[string]*EffectivePermission, error) {
+	result := make(map[string]*EffectivePermission, len(AllPermissions))
+	if !as.SupportsGroupPermissions() {
+		for _, permission := range AllPermissions {
+			result[permission] = &EffectivePermission{
+				Granted:    true,
+				UserGroups: []string{},
+				Roles:      []string{},
+			}
+		}
+		return result, nil
+	}
+
+	groups, roles, err := as.getUserGroupsAndRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range AllPermissions {
+		result[permission] = explainPermission(permission, groups, roles)
+	}
+	return result, nil
+}
+
+// GetPermissionScope checks the permission of the user like CheckPermission and returns the resources it's limited to
+// in the same pass. The scope is nil if the permission is granted for all resources.
+func (as *APIService) GetPermissionScope(user *User, permission string) (*RoleResources, error) {
+	groups, roles, err := as.getUserGroupsAndRoles(user)
+	if err != nil {
+		return nil, err
+	}
+
+	explained := explainPermission(permission, groups, roles)
+	if !explained.Granted {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("user does not have %q permission", permission),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return explained.Resources, nil
+}
+
+// GetClientScope returns the clients the user can access, the union of the clients selected by the roles of the user.
+// It returns nil if the user has no roles or a user group grants a permission, as it's granted for all clients.
+func (as *APIService) GetClientScope(user *User) (*RoleResources, error) {
+	if !as.SupportsGroupPermissions() {
+		return nil, nil
+	}
+
+	groups, roles, err := as.getUserGroupsAndRoles(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	for _, group := range groups {
+		for _, permission := range AllPermissions {
+			if group.Permissions.Has(permission) {
+				return nil, nil
+			}
+		}
+	}
+
+	scope := &RoleResources{
+		ClientGroupIDs: roles[0].Resources.ClientGroupIDs,
+		ClientTags:     roles[0].Resources.ClientTags,
+	}
+	for _, role := range roles[1:] {
+		scope.merge(RoleResources{
+			ClientGroupIDs: role.Resources.ClientGroupIDs,
+			ClientTags:     role.Resources.ClientTags,
+		})
+	}
+	return scope, nil
+}
+
+// getUserGroupsAndRoles loads the user groups and the roles of the user to explain their permissions.
+func (as *APIService) getUserGroupsAndRoles(user *User) ([]Group, []*Role, error) {
+	groups := make([]Group, 0, len(user.Groups))
+	for _, groupName := range user.Groups {
+		group, err := as.Provider.GetGroup(groupName)
+		if err != nil {
+			return nil, nil, err
+		}
+		groups = append(groups, group)
+	}
+	roles, err := as.getUserRoles(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, roles, nil
+}
+
+func explainPermission(permission string, groups []Group, roles []*Role) *EffectivePermission {
+	result := &EffectivePermission{
+		UserGroups: []string{},
+		Roles:      []string{},
+	}
+	for _, group := range groups {
+		if group.Permissions.Has(permission) {
+			result.UserGroups = append(result.UserGroups, group.Name)
+		}
+	}
+
+	var resources *RoleResources
+	for _, role := range roles {
+		if !role.Permissions.Has(permission) {
+			continue
+		}
+		result.Roles = append(result.Roles, role.Name)
+		if resources == nil {
+			resources = &RoleResources{}
+			*resources = role.Resources
+			continue
+		}
+		resources.merge(role.Resources)
+	}
+
+	result.Granted = len(result.UserGroups) > 0 || len(result.Roles) > 0
+	// a user group grants the permission for all resources
+	if len(result.UserGroups) == 0 {
+		result.Resources = resources
+	}
+	return result
+}
+
+func (as *APIService) getUserRoles(user *User) ([]*Role, error) {
+	if as.RoleProvider == nil {
+		return nil, nil
+	}
+
+	roles, err := as.RoleProvider.GetAll(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Role
+	for _, role := range roles {
+		if role.IsAssignedTo(user.Groups) {
+			result = append(result, role)
+		}
+	}
+	return result, nil
+}
+
+func (as *APIService) ListRoles(ctx context.Context) ([]*Role, error) {
+	if as.RoleProvider == nil {
+		return []*Role{}, nil
+	}
+	roles, err := as.RoleProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []*Role{}
+	}
+	return roles, nil
+}
+
+func (as *APIService) GetRole(ctx context.Context, name string) (*Role, error) {
+	if as.RoleProvider == nil {
+		return nil, nil
+	}
+	return as.RoleProvider.Get(ctx, name)
+}
+
+func (as *APIService) SaveRole(ctx context.Context, role *Role) error {
+	if as.RoleProvider == nil || !as.SupportsGroupPermissions() {
+		return errors2.APIError{
+			Message:    "roles require user groups with permissions, use a user database with 'auth_group_details_table'",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	err := role.Validate()
+	if err != nil {
+		return err
+	}
+	if role.UserGroups == nil {
+		role.UserGroups = []string{}
+	}
+	err = as.ExistGroups(role.UserGroups)
+	if err != nil {
+		return err
+	}
+	return as.RoleProvider.Save(ctx, role)
+}
+
+func (as *APIService) DeleteRole(ctx context.Context, name string) error {
+	if as.RoleProvider == nil {
+		return errors2.APIError{
+			Message:    "roles require user groups with permissions, use a user database with 'auth_group_details_table'",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return as.RoleProvider.Delete(ctx, name)
+}
+
 func (as *APIService) ExistGroups(groups []string) error {
 	existingGroups, err := as.ListGroups()
 	if err != nil {
@@ -226,7 +527,15 @@ func (as *APIService) Change(usr *User, username string) error {
 	if err != nil {
 		return err
 	}
+	// TODO: use proper ctx when it will be propagated
+	ctx := context.Background()
 	if usr.Password != "" {
+		if username != "" {
+			err = as.checkPasswordReuse(ctx, username, usr.Password)
+			if err != nil {
+				return err
+			}
+		}
 		passHash, err := GenerateTokenHash(usr.Password)
 		if err != nil {
 			return err
@@ -235,9 +544,22 @@ func (as *APIService) Change(usr *User, username string) error {
 	}
 
 	if username != "" {
-		return as.updateUser(usr, username)
+		err = as.updateUser(usr, username)
+	} else {
+		err = as.addUser(usr)
 	}
-	return as.addUser(usr)
+	if err != nil {
+		return err
+	}
+
+	if usr.Password != "" {
+		historyUsername := username
+		if usr.Username != "" {
+			historyUsername = usr.Username
+		}
+		return as.addPasswordHistory(ctx, historyUsername, usr.Password, time.Now())
+	}
+	return nil
 }
 
 func (as *APIService) validate(dataToChange *User, usernameToFind string) error {
@@ -380,6 +702,10 @@ func (as *APIService) updateUser(dataToChange *User, usernameToFind string) erro
 	}
 	// TODO: if a username changes needs to update username in api tokens table also
 
+	if dataToChange.Username != "" && dataToChange.Username != usernameToFind && as.PasswordPolicyProvider != nil {
+		return as.PasswordPolicyProvider.RenameUser(context.Background(), usernameToFind, dataToChange.Username)
+	}
+
 	return nil
 }
 
@@ -397,7 +723,15 @@ func (as *APIService) Delete(usernameToDelete string) error {
 		}
 	}
 
-	return as.Provider.Delete(usernameToDelete)
+	err = as.Provider.Delete(usernameToDelete)
+	if err != nil {
+		return err
+	}
+
+	if as.PasswordPolicyProvider != nil {
+		return as.PasswordPolicyProvider.DeleteUser(context.Background(), usernameToDelete)
+	}
+	return nil
 }
 
 func GenerateTokenHash(newTokenClear string) (string, error) {
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strings"
@@ -9,6 +10,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/openrport/openrport/db/migration/roles"
+	"github.com/openrport/openrport/db/sqlite"
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/message"
 	"github.com/openrport/openrport/share/enums"
@@ -24,6 +27,8 @@ type ProviderMock struct {
 	ErrorToGiveOnDelete error
 	UsernameToUpdate    string
 	UsernameToDelete    string
+	// GroupPermissions enables the permissions of user groups
+	GroupPermissions bool
 }
 
 func (dpm *ProviderMock) GetAll() ([]*User, error) {
@@ -89,7 +94,7 @@ func (dpm *ProviderMock) Delete(usernameToDelete string) error {
 }
 
 func (dpm *ProviderMock) SupportsGroupPermissions() bool {
-	return false
+	return dpm.GroupPermissions
 }
 
 func (dpm ProviderMock) Type() enums.ProviderSource {
@@ -552,3 +557,121 @@ func TestCheckPermission(t *testing.T) {
 		})
 	}
 }
+
+func TestRolePermissions(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", roles.AssetNames(), roles.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	roleProvider := NewRoleSqliteProvider(db)
+	defer roleProvider.Close()
+
+	service := APIService{
+		Provider: &ProviderMock{
+			GroupsToGive: []Group{
+				NewGroup("group-commands", nil, nil, PermissionCommands),
+				NewGroup("ops", nil, nil),
+				NewGroup("dev", nil, nil),
+				NewGroup("other", nil, nil),
+			},
+			GroupPermissions: true,
+		},
+		RoleProvider: roleProvider,
+	}
+	err = service.SaveRole(ctx, &Role{
+		Name:       "unknown-group",
+		UserGroups: []string{"ops", "unknown"},
+	})
+	assert.EqualError(t, err, "user groups not found: unknown")
+	require.NoError(t, service.SaveRole(ctx, &Role{
+		Name:        "linux-scripts",
+		Permissions: NewPermissions(PermissionScripts, PermissionCommands),
+		Resources:   RoleResources{ClientTags: []string{"linux"}, ScriptIDs: []string{"script-1"}},
+		UserGroups:  []string{"ops"},
+	}))
+	require.NoError(t, service.SaveRole(ctx, &Role{
+		Name:        "windows-scripts",
+		Permissions: NewPermissions(PermissionScripts),
+		Resources:   RoleResources{ClientTags: []string{"windows"}},
+		UserGroups:  []string{"ops", "dev"},
+	}))
+	require.NoError(t, service.SaveRole(ctx, &Role{
+		Name:        "vault",
+		Permissions: NewPermissions(PermissionVault),
+		UserGroups:  []string{"other"},
+	}))
+
+	user := &User{Username: "user1", Groups: []string{"ops", "group-commands"}}
+
+	assert.NoError(t, service.CheckPermission(user, PermissionScripts))
+	assert.NoError(t, service.CheckPermission(user, PermissionCommands))
+	assert.Error(t, service.CheckPermission(user, PermissionVault))
+
+	explained, err := service.ExplainPermissions(user)
+	require.NoError(t, err)
+	assert.Equal(t, &EffectivePermission{
+		Granted:    true,
+		UserGroups: []string{},
+		Roles:      []string{"linux-scripts", "windows-scripts"},
+		Resources:  &RoleResources{ClientTags: []string{"linux", "windows"}},
+	}, explained[PermissionScripts])
+	assert.Equal(t, &EffectivePermission{
+		Granted:    true,
+		UserGroups: []string{"group-commands"},
+		Roles:      []string{"linux-scripts"},
+	}, explained[PermissionCommands])
+	assert.Equal(t, &EffectivePermission{
+		UserGroups: []string{},
+		Roles:      []string{},
+	}, explained[PermissionVault])
+
+	scope, err := service.GetPermissionScope(user, PermissionScripts)
+	require.NoError(t, err)
+	assert.Equal(t, &RoleResources{ClientTags: []string{"linux", "windows"}}, scope)
+	scope, err = service.GetPermissionScope(user, PermissionCommands)
+	require.NoError(t, err)
+	assert.Nil(t, scope)
+	_, err = service.GetPermissionScope(user, PermissionVault)
+	assert.EqualError(t, err, `user does not have "vault" permission`)
+
+	permissions, err := service.GetEffectiveUserPermissions(user)
+	require.NoError(t, err)
+	assert.True(t, permissions[PermissionScripts])
+	assert.False(t, permissions[PermissionVault])
+}
+
+func TestDeleteGroupUnassignsRoles(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", roles.AssetNames(), roles.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	roleProvider := NewRoleSqliteProvider(db)
+	defer roleProvider.Close()
+
+	service := APIService{
+		Provider: &ProviderMock{
+			GroupsToGive:     []Group{NewGroup("ops", nil, nil), NewGroup("dev", nil, nil)},
+			GroupPermissions: true,
+		},
+		RoleProvider: roleProvider,
+	}
+	require.NoError(t, service.SaveRole(ctx, &Role{Name: "both", UserGroups: []string{"ops", "dev"}}))
+	require.NoError(t, service.SaveRole(ctx, &Role{Name: "dev-only", UserGroups: []string{"dev"}}))
+
+	require.NoError(t, service.DeleteGroup("ops"))
+
+	both, err := service.GetRole(ctx, "both")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, []string(both.UserGroups))
+	devOnly, err := service.GetRole(ctx, "dev-only")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev"}, []string(devOnly.UserGroups))
+}
+
+func TestSaveRoleWithoutGroupPermissions(t *testing.T) {
+	service := APIService{
+		Provider:     &ProviderMock{},
+		RoleProvider: NewRoleSqliteProvider(nil),
+	}
+
+	err := service.SaveRole(context.Background(), &Role{Name: "role"})
+	assert.EqualError(t, err, "roles require user groups with permissions, use a user database with 'auth_group_details_table'")
+}
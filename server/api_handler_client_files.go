@@ -0,0 +1,406 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/comm"
+)
+
+const (
+	defaultTailLines = 100
+	maxTailLines     = 10000
+	// maxTailBytes limits how much of the end of a file is read to find the requested lines
+	maxTailBytes       = 1024 * 1024
+	tailFollowInterval = time.Second
+	tailChunkSize      = 32 * 1024
+)
+
+type ClientFileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	ModTime time.Time `json:"mod_time"`
+	IsDir   bool      `json:"is_dir"`
+}
+
+func newClientFileInfo(p string, fi os.FileInfo) ClientFileInfo {
+	return ClientFileInfo{
+		Name:    fi.Name(),
+		Path:    p,
+		Size:    fi.Size(),
+		Mode:    fi.Mode().String(),
+		ModTime: fi.ModTime(),
+		IsDir:   fi.IsDir(),
+	}
+}
+
+// handleListClientFiles handles GET /clients/{client_id}/files
+func (al *APIListener) handleListClientFiles(w http.ResponseWriter, req *http.Request) {
+	dir, err := requiredFilePath(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.withClientSFTP(w, req, func(client *clientdata.Client, sftpCl *sftp.Client) {
+		infos, err := sftpCl.ReadDir(dir)
+		if err != nil {
+			al.jsonError(w, clientFileError(dir, err))
+			return
+		}
+
+		al.auditLog.Entry(auditlog.ApplicationClientFiles, auditlog.ActionList).
+			WithHTTPRequest(req).
+			WithClient(client).
+			WithRequest(map[string]interface{}{"path": dir}).
+			WithResponse(map[string]interface{}{"entries": len(infos)}).
+			Save()
+
+		result := make([]ClientFileInfo, 0, len(infos))
+		for _, fi := range infos {
+			result = append(result, newClientFileInfo(path.Join(dir, fi.Name()), fi))
+		}
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
+	})
+}
+
+// handleStatClientFile handles GET /clients/{client_id}/files/stat
+func (al *APIListener) handleStatClientFile(w http.ResponseWriter, req *http.Request) {
+	p, err := requiredFilePath(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.withClientSFTP(w, req, func(client *clientdata.Client, sftpCl *sftp.Client) {
+		fi, err := sftpCl.Stat(p)
+		if err != nil {
+			al.jsonError(w, clientFileError(p, err))
+			return
+		}
+
+		al.auditLog.Entry(auditlog.ApplicationClientFiles, auditlog.ActionStat).
+			WithHTTPRequest(req).
+			WithClient(client).
+			WithRequest(map[string]interface{}{"path": p}).
+			Save()
+
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(newClientFileInfo(p, fi)))
+	})
+}
+
+// handleDownloadClientFile handles GET /clients/{client_id}/files/download
+func (al *APIListener) handleDownloadClientFile(w http.ResponseWriter, req *http.Request) {
+	p, err := requiredFilePath(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.withClientSFTP(w, req, func(client *clientdata.Client, sftpCl *sftp.Client) {
+		f, fi, err := openClientFile(sftpCl, p)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		defer f.Close()
+
+		al.auditLog.Entry(auditlog.ApplicationClientFiles, auditlog.ActionDownload).
+			WithHTTPRequest(req).
+			WithClient(client).
+			WithRequest(map[string]interface{}{"path": p}).
+			WithResponse(map[string]interface{}{"size": fi.Size()}).
+			Save()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fi.Name()))
+		http.ServeContent(w, req, fi.Name(), fi.ModTime(), f)
+	})
+}
+
+// handleTailClientFile handles GET /clients/{client_id}/files/tail
+func (al *APIListener) handleTailClientFile(w http.ResponseWriter, req *http.Request) {
+	p, lines, err := parseTailRequest(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.withClientSFTP(w, req, func(client *clientdata.Client, sftpCl *sftp.Client) {
+		f, fi, err := openClientFile(sftpCl, p)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		defer f.Close()
+
+		data, err := tailLines(f, fi.Size(), lines)
+		if err != nil {
+			al.jsonError(w, clientFileError(p, err))
+			return
+		}
+
+		al.auditLog.Entry(auditlog.ApplicationClientFiles, auditlog.ActionTail).
+			WithHTTPRequest(req).
+			WithClient(client).
+			WithRequest(map[string]interface{}{"path": p, "lines": lines}).
+			Save()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(data)
+	})
+}
+
+// handleTailClientFileWS handles GET /ws/clients/{client_id}/files/tail
+// It sends the last lines of the file and then the data appended to it, like tail -f.
+func (al *APIListener) handleTailClientFileWS(w http.ResponseWriter, req *http.Request) {
+	p, lines, err := parseTailRequest(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.withClientSFTP(w, req, func(client *clientdata.Client, sftpCl *sftp.Client) {
+		f, fi, err := openClientFile(sftpCl, p)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		defer f.Close()
+
+		data, err := tailLines(f, fi.Size(), lines)
+		if err != nil {
+			al.jsonError(w, clientFileError(p, err))
+			return
+		}
+
+		uiConn, err := apiUpgrader.Upgrade(w, req, nil)
+		if err != nil {
+			al.Errorf("Failed to establish WS connection: %v", err)
+			return
+		}
+		defer uiConn.Close()
+
+		al.auditLog.Entry(auditlog.ApplicationClientFiles, auditlog.ActionTail).
+			WithHTTPRequest(req).
+			WithClient(client).
+			WithRequest(map[string]interface{}{"path": p, "lines": lines, "follow": true}).
+			Save()
+
+		// the UI only closes the connection, reading detects it to stop following
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := uiConn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		err = uiConn.WriteMessage(websocket.BinaryMessage, data)
+		if err == nil {
+			err = followFile(f, fi.Size(), uiConn, tailFollowInterval, closed)
+		}
+		if err != nil {
+			al.Debugf("Stopped following %s on client %s: %v", p, client.GetID(), err)
+		}
+	})
+}
+
+// withClientSFTP opens the sftp subsystem of the active client of the request and passes it to the handler.
+func (al *APIListener) withClientSFTP(w http.ResponseWriter, req *http.Request, handle func(*clientdata.Client, *sftp.Client)) {
+	client, err := al.getClientFromContext(req.Context())
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusInternalServerError, "client not present in the request")
+		return
+	}
+
+	channel, reqs, err := client.GetConnection().OpenChannel(comm.ChannelTypeSFTP, nil)
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) && openErr.Reason == ssh.Prohibited {
+			al.jsonErrorResponseWithTitle(w, http.StatusForbidden, openErr.Message)
+			return
+		}
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to access files on client.", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	sftpCl, err := sftp.NewClientPipe(channel, channel)
+	if err != nil {
+		channel.Close()
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to access files on client.", err)
+		return
+	}
+	defer sftpCl.Close()
+
+	handle(client, sftpCl)
+}
+
+func openClientFile(sftpCl *sftp.Client, p string) (*sftp.File, os.FileInfo, error) {
+	f, err := sftpCl.Open(p)
+	if err != nil {
+		return nil, nil, clientFileError(p, err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, clientFileError(p, err)
+	}
+	if fi.IsDir() {
+		f.Close()
+		return nil, nil, errors2.APIError{
+			Err:        fmt.Errorf("%q is a directory", p),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	return f, fi, nil
+}
+
+func clientFileError(p string, err error) error {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return errors2.APIError{
+			Err:        fmt.Errorf("file %q not found", p),
+			HTTPStatus: http.StatusNotFound,
+		}
+	case errors.Is(err, os.ErrPermission):
+		return errors2.APIError{
+			Err:        fmt.Errorf("access to %q denied by the client", p),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return err
+}
+
+func requiredFilePath(req *http.Request) (string, error) {
+	p := req.URL.Query().Get("path")
+	if p == "" {
+		return "", errors2.APIError{
+			Err:        errors.New("missing \"path\" parameter"),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return p, nil
+}
+
+func parseTailRequest(req *http.Request) (string, int, error) {
+	p, err := requiredFilePath(req)
+	if err != nil {
+		return "", 0, err
+	}
+
+	lines := defaultTailLines
+	if value := req.URL.Query().Get("lines"); value != "" {
+		lines, err = strconv.Atoi(value)
+		if err != nil || lines < 0 || lines > maxTailLines {
+			return "", 0, errors2.APIError{
+				Err:        fmt.Errorf("invalid \"lines\": expected a number between 0 and %d, got %q", maxTailLines, value),
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+
+	return p, lines, nil
+}
+
+// tailLines returns the last lines of a file of the given size, reading at most maxTailBytes.
+func tailLines(r io.ReaderAt, size int64, lines int) ([]byte, error) {
+	if lines == 0 || size == 0 {
+		return []byte{}, nil
+	}
+
+	start := size - maxTailBytes
+	if start < 0 {
+		start = 0
+	}
+	buf := make([]byte, size-start)
+	n, err := r.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	// the newline terminating the last line doesn't start a new line
+	end := len(buf)
+	if end > 0 && buf[end-1] == '\n' {
+		end--
+	}
+	count := 0
+	for i := end - 1; i >= 0; i-- {
+		if buf[i] != '\n' {
+			continue
+		}
+		count++
+		if count == lines {
+			return buf[i+1:], nil
+		}
+	}
+	return buf, nil
+}
+
+type fileFollower interface {
+	io.ReaderAt
+	Stat() (os.FileInfo, error)
+}
+
+// followFile sends data appended to the file after the given offset until closed is closed or an error occurs.
+// If the file gets truncated, e.g. by log rotation, it's followed from the beginning.
+func followFile(f fileFollower, offset int64, uiConn shellConn, interval time.Duration, closed <-chan struct{}) error {
+	buf := make([]byte, tailChunkSize)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return nil
+		case <-ticker.C:
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if fi.Size() < offset {
+			offset = 0
+		}
+
+		for offset < fi.Size() {
+			n, err := f.ReadAt(buf, offset)
+			if n > 0 {
+				if writeErr := uiConn.WriteMessage(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+					return writeErr
+				}
+				offset += int64(n)
+			}
+			if errors.Is(err, io.EOF) || n == 0 {
+				break
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
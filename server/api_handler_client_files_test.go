@@ -0,0 +1,187 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+)
+
+func TestTailLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		lines    int
+		expected string
+	}{
+		{
+			name:     "less lines than requested",
+			content:  "line 1\nline 2\n",
+			lines:    10,
+			expected: "line 1\nline 2\n",
+		},
+		{
+			name:     "last lines",
+			content:  "line 1\nline 2\nline 3\n",
+			lines:    2,
+			expected: "line 2\nline 3\n",
+		},
+		{
+			name:     "last line not terminated",
+			content:  "line 1\nline 2\nline 3",
+			lines:    1,
+			expected: "line 3",
+		},
+		{
+			name:     "empty lines",
+			content:  "line 1\n\n\n",
+			lines:    2,
+			expected: "\n\n",
+		},
+		{
+			name:     "no lines",
+			content:  "line 1\n",
+			lines:    0,
+			expected: "",
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			lines:    10,
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tailLines(strings.NewReader(tc.content), int64(len(tc.content)), tc.lines)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(data))
+		})
+	}
+}
+
+func TestTailLinesLimited(t *testing.T) {
+	content := strings.Repeat("x", maxTailBytes) + "\nlast\n"
+
+	data, err := tailLines(strings.NewReader(content), int64(len(content)), 5)
+	require.NoError(t, err)
+	assert.Len(t, data, maxTailBytes)
+	assert.True(t, strings.HasSuffix(string(data), "x\nlast\n"))
+}
+
+type followUIConnMock struct {
+	replayUIConnMock
+
+	mu   sync.Mutex
+	data string
+}
+
+func (c *followUIConnMock) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data += string(data)
+	return nil
+}
+
+func (c *followUIConnMock) received() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data
+}
+
+func TestFollowFile(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(p, []byte("old\n"), 0600))
+	f, err := os.Open(p)
+	require.NoError(t, err)
+	defer f.Close()
+
+	uiConn := &followUIConnMock{}
+	closed := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- followFile(f, 4, uiConn, 10*time.Millisecond, closed)
+	}()
+
+	appendFile(t, p, "new 1\n")
+	assert.Eventually(t, func() bool { return uiConn.received() == "new 1\n" }, time.Second, 10*time.Millisecond)
+
+	// truncated by log rotation
+	require.NoError(t, os.WriteFile(p, []byte("rotated\n"), 0600))
+	assert.Eventually(t, func() bool { return uiConn.received() == "new 1\nrotated\n" }, time.Second, 10*time.Millisecond)
+
+	close(closed)
+	assert.NoError(t, <-done)
+}
+
+func appendFile(t *testing.T, p, data string) {
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(data)
+	require.NoError(t, err)
+}
+
+func TestParseTailRequest(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		expectedPath  string
+		expectedLines int
+		expectedErr   string
+	}{
+		{
+			name:          "defaults",
+			query:         "path=/var/log/syslog",
+			expectedPath:  "/var/log/syslog",
+			expectedLines: defaultTailLines,
+		},
+		{
+			name:          "lines",
+			query:         "path=/var/log/syslog&lines=10",
+			expectedPath:  "/var/log/syslog",
+			expectedLines: 10,
+		},
+		{
+			name:        "missing path",
+			query:       "lines=10",
+			expectedErr: `missing "path" parameter`,
+		},
+		{
+			name:        "invalid lines",
+			query:       "path=/var/log/syslog&lines=-1",
+			expectedErr: `invalid "lines": expected a number between 0 and 10000, got "-1"`,
+		},
+		{
+			name:        "too many lines",
+			query:       "path=/var/log/syslog&lines=10001",
+			expectedErr: `invalid "lines": expected a number between 0 and 10000, got "10001"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/files/tail?"+tc.query, nil)
+
+			p, lines, err := parseTailRequest(req)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				var apiErr errors2.APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, http.StatusBadRequest, apiErr.HTTPStatus)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPath, p)
+			assert.Equal(t, tc.expectedLines, lines)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package chserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clientupdates"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/models"
+)
+
+const (
+	defaultClientUpdateReconnectTimeoutSec = 300
+	minClientUpdateReconnectTimeoutSec     = 60
+	maxClientUpdateReconnectTimeoutSec     = 24 * 60 * 60
+)
+
+type ClientUpdateRolloutRequest struct {
+	Version             string                `json:"version"`
+	ClientIDs           []string              `json:"client_ids"`
+	GroupIDs            []string              `json:"group_ids"`
+	ClientTags          *models.JobClientTags `json:"tags"`
+	CanaryPercent       int                   `json:"canary_percent"`
+	ReconnectTimeoutSec int                   `json:"reconnect_timeout_sec"`
+}
+
+func (r *ClientUpdateRolloutRequest) GetClientIDs() (ids []string) {
+	return r.ClientIDs
+}
+
+func (r *ClientUpdateRolloutRequest) GetGroupIDs() (ids []string) {
+	return r.GroupIDs
+}
+
+func (r *ClientUpdateRolloutRequest) GetClientTags() (clientTags *models.JobClientTags) {
+	return r.ClientTags
+}
+
+// handleListClientUpdateArtefacts handles GET /client-updates/artefacts
+func (al *APIListener) handleListClientUpdateArtefacts(w http.ResponseWriter, req *http.Request) {
+	artefacts, err := al.clientUpdateArtefacts.List()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(artefacts))
+}
+
+// handlePostClientUpdateArtefact handles POST /client-updates/artefacts
+// The binary is either sent as multipart file or refers to a completed resumable upload.
+func (al *APIListener) handlePostClientUpdateArtefact(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = req.ParseMultipartForm(uploadBufSize)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid multipart form.", err)
+		return
+	}
+
+	version := req.FormValue("version")
+	goos := req.FormValue("os")
+	arch := req.FormValue("arch")
+	signature, err := base64.StdEncoding.DecodeString(req.FormValue("signature"))
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid signature, expected base64.", err)
+		return
+	}
+	err = clientupdates.ValidateArtefact(version, goos, arch, signature)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var binary io.ReadCloser
+	if uploadID := req.FormValue(routes.ParamUploadID); uploadID != "" {
+		session, err := al.getAccessibleUploadSession(req, uploadID)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !session.Completed() {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("upload %s is not completed", uploadID))
+			return
+		}
+		binary, err = os.Open(al.uploadBlobs.Path(session.SHA256))
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	} else {
+		binary, _, err = req.FormFile("upload")
+		if err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Missing client binary.", err)
+			return
+		}
+	}
+	defer binary.Close()
+
+	artefact, err := al.clientUpdateArtefacts.Put(version, goos, arch, signature, binary, curUser.Username)
+	if err != nil {
+		if errors.Is(err, clientupdates.ErrArtefactExists) {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
+			return
+		}
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientUpdateArtefact, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(artefact.Version + "/" + artefact.OS + "/" + artefact.Arch).
+		WithResponse(artefact).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(artefact))
+}
+
+// handleDeleteClientUpdateArtefact handles DELETE /client-updates/artefacts/{version}/{os}/{arch}
+func (al *APIListener) handleDeleteClientUpdateArtefact(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	version, goos, arch := vars["version"], vars["os"], vars["arch"]
+
+	if al.clientUpdateRollouts.IsRunning(version) {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("a rollout of version %s is running", version))
+		return
+	}
+
+	err := al.clientUpdateArtefacts.Delete(version, goos, arch)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client binary %s for %s/%s not found", version, goos, arch))
+			return
+		}
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientUpdateArtefact, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(version + "/" + goos + "/" + arch).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListClientUpdateRollouts handles GET /client-updates/rollouts
+func (al *APIListener) handleListClientUpdateRollouts(w http.ResponseWriter, req *http.Request) {
+	rollouts, err := al.clientUpdateRollouts.List()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rollouts))
+}
+
+// handleGetClientUpdateRollout handles GET /client-updates/rollouts/{rollout_id}
+func (al *APIListener) handleGetClientUpdateRollout(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamRolloutID]
+	rollout, err := al.clientUpdateRollouts.Get(id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if rollout == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("rollout with id %q not found", id))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rollout))
+}
+
+// handlePostClientUpdateRollout handles POST /client-updates/rollouts
+func (al *APIListener) handlePostClientUpdateRollout(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var reqBody ClientUpdateRolloutRequest
+	err = parseRequestBody(req.Body, &reqBody)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if reqBody.CanaryPercent < 0 || reqBody.CanaryPercent > 100 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "canary_percent must be between 0 and 100")
+		return
+	}
+	if reqBody.ReconnectTimeoutSec == 0 {
+		reqBody.ReconnectTimeoutSec = defaultClientUpdateReconnectTimeoutSec
+	}
+	if reqBody.ReconnectTimeoutSec < minClientUpdateReconnectTimeoutSec || reqBody.ReconnectTimeoutSec > maxClientUpdateReconnectTimeoutSec {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("reconnect_timeout_sec must be between %d and %d", minClientUpdateReconnectTimeoutSec, maxClientUpdateReconnectTimeoutSec))
+		return
+	}
+
+	orderedClients, _, err := al.getOrderedClientsWithValidation(req.Context(), &reqBody)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	clientIDs := make([]string, 0, len(orderedClients))
+	for _, c := range orderedClients {
+		clientIDs = append(clientIDs, c.GetID())
+	}
+
+	rollout, err := al.clientUpdateRollouts.Start(reqBody.Version, clientIDs, reqBody.CanaryPercent, reqBody.ReconnectTimeoutSec, curUser.Username)
+	if err != nil {
+		switch {
+		case errors.Is(err, clientupdates.ErrClientInRollout):
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
+		case errors.Is(err, clientupdates.ErrNoArtefacts):
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		default:
+			al.jsonError(w, err)
+		}
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientUpdateRollout, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(reqBody).
+		WithID(rollout.ID).
+		SaveForMultipleClients(orderedClients)
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(rollout))
+}
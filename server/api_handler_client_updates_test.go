@@ -0,0 +1,129 @@
+package chserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clientupdates"
+	"github.com/openrport/openrport/server/uploads"
+	"github.com/openrport/openrport/share/files"
+)
+
+func TestHandleClientUpdateArtefacts(t *testing.T) {
+	dir := t.TempDir()
+	artefacts, err := clientupdates.NewArtefactStore(dir)
+	require.NoError(t, err)
+	rollouts, err := clientupdates.NewRolloutManager(testLog, dir, artefacts, clients.NewClientRepository(nil, nil, testLog))
+	require.NoError(t, err)
+	blobs := uploads.NewBlobStore(files.NewFileSystem(), t.TempDir())
+	sessions, err := uploads.NewSessionManager(t.TempDir(), blobs)
+	require.NoError(t, err)
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024,
+					MaxFilePushSize: 1024,
+				},
+			},
+			uploadBlobs:           blobs,
+			uploadSessions:        sessions,
+			clientUpdateArtefacts: artefacts,
+			clientUpdateRollouts:  rollouts,
+		},
+		Logger: testLog,
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	signature := base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize))
+	postArtefact := func(username string, fields map[string]string, binary string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for k, v := range fields {
+			require.NoError(t, writer.WriteField(k, v))
+		}
+		if binary != "" {
+			part, err := writer.CreateFormFile("upload", "rport")
+			require.NoError(t, err)
+			_, err = part.Write([]byte(binary))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/client-updates/artefacts", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req = req.WithContext(api.WithUser(context.Background(), username))
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	do := func(method, url, username, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req = req.WithContext(api.WithUser(context.Background(), username))
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	fields := map[string]string{"version": "0.9.2", "os": "linux", "arch": "amd64", "signature": signature}
+
+	w := postArtefact("admin", map[string]string{"version": "0.9.2", "os": "linux", "arch": "amd64", "signature": "c2hvcnQ="}, "linux binary")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid signature")
+
+	w = postArtefact("admin", fields, "linux binary")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data clientupdates.Artefact `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "0.9.2", created.Data.Version)
+	assert.Equal(t, int64(12), created.Data.Size)
+	assert.Equal(t, "admin", created.Data.CreatedBy)
+
+	w = postArtefact("admin", fields, "linux binary")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = do(http.MethodGet, "/api/v1/client-updates/artefacts", "admin", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []clientupdates.Artefact `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 1)
+
+	w = do(http.MethodPost, "/api/v1/client-updates/rollouts", "admin", `{"version":"0.9.2","client_ids":["c1"],"canary_percent":120}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "canary_percent must be between 0 and 100")
+
+	w = do(http.MethodPost, "/api/v1/client-updates/rollouts", "admin", `{"version":"0.9.2","client_ids":["c1"],"reconnect_timeout_sec":10}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "reconnect_timeout_sec must be between 60 and 86400")
+
+	w = do(http.MethodGet, "/api/v1/client-updates/rollouts/f7d4e0f5-4c52-4d0e-a1f4-3f0e5b6c7d8e", "admin", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodDelete, "/api/v1/client-updates/artefacts/0.9.2/linux/amd64", "admin", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodDelete, "/api/v1/client-updates/artefacts/0.9.2/linux/amd64", "admin", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -230,6 +230,13 @@ func (al *APIListener) handleDeleteClientGroup(w http.ResponseWriter, req *http.
 		return
 	}
 
+	// stored tunnels are in the clients DB, they are deleted separately
+	err = al.storedTunnels.DeleteAllForGroup(req.Context(), id)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete stored tunnels of client group[id=%q].", id), err)
+		return
+	}
+
 	al.auditLog.Entry(auditlog.ApplicationClientGroup, auditlog.ActionDelete).
 		WithHTTPRequest(req).
 		WithID(id).
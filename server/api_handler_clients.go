@@ -1,11 +1,13 @@
 package chserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/ssh"
@@ -174,6 +176,19 @@ func (al *APIListener) handleGetClients(w http.ResponseWriter, req *http.Request
 		al.jsonError(w, err)
 		return
 	}
+	// GET /clients doesn't require a permission, the roles of the user limit it to all of their clients
+	clientScope, err := al.userService.GetClientScope(curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	allowedClients := make([]*clientdata.CalculatedClient, 0, len(filteredClients))
+	for _, c := range filteredClients {
+		if clientScope.AllowsClient(c.GetTags(), clientGroupIDs(c.Client, groups)) && al.allowsClient(req.Context(), c.Client, groups) {
+			allowedClients = append(allowedClients, c)
+		}
+	}
+	filteredClients = allowedClients
 
 	sortFunc(filteredClients, desc)
 
@@ -195,6 +210,13 @@ const (
 	autoCloseQueryParam          = "auto-close"
 	idleTimeoutMinutesQueryParam = "idle-timeout-minutes"
 	skipIdleTimeoutQueryParam    = "skip-idle-timeout"
+	maxBandwidthQueryParam       = "max_bandwidth"
+	tokenAuthQueryParam          = "token_auth"
+	healthCheckIntervalParam     = "health_check_interval"
+	healthCheckRestartParam      = "health_check_restart"
+	sshUserQueryParam            = "ssh_user"
+	sshVaultIDQueryParam         = "ssh_vault_id"
+	sshHostKeyQueryParam         = "ssh_host_key"
 
 	ErrCodeLocalPortInUse        = "ERR_CODE_LOCAL_PORT_IN_USE"
 	ErrCodeRemotePortNotOpen     = "ERR_CODE_REMOTE_PORT_NOT_OPEN"
@@ -231,12 +253,20 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 	localAddr := req.URL.Query().Get("local")
 	remoteAddr := req.URL.Query().Get("remote")
 
+	protocol := req.URL.Query().Get("protocol")
+
 	remoteStr := localAddr + ":" + remoteAddr
-	if localAddr == "" {
+	if protocol == models.ProtocolSOCKS {
+		// the destinations of socks tunnels are requested per connection
+		if remoteAddr != "" {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "remote not allowed with protocol socks")
+			return
+		}
+		remoteStr = localAddr
+	} else if localAddr == "" {
 		remoteStr = remoteAddr
 	}
 
-	protocol := req.URL.Query().Get("protocol")
 	if protocol != "" {
 		remoteStr += "/" + protocol
 	}
@@ -275,12 +305,38 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	err = al.setSSHOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	err = al.setAutoCloseIdleOptionsForRemote(req, remote)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
 
+	if maxBandwidthStr := req.URL.Query().Get(maxBandwidthQueryParam); maxBandwidthStr != "" {
+		remote.MaxBandwidth, err = strconv.ParseInt(maxBandwidthStr, 10, 64)
+		if err != nil || remote.MaxBandwidth < 0 {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s: %s, expected a non-negative number of bytes per second.", maxBandwidthQueryParam, maxBandwidthStr))
+			return
+		}
+	}
+
+	err = al.setTokenAuthOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.setHealthCheckOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	aclStr := req.URL.Query().Get("acl")
 	if _, err = clienttunnel.ParseTunnelACL(aclStr); err != nil {
 		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeInvalidACL, fmt.Sprintf("Invalid ACL: %s", err))
@@ -290,14 +346,17 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		remote.ACL = &aclStr
 	}
 
-	allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), al.Log())
-	if err != nil {
-		al.jsonError(w, err)
-		return
-	}
-	if !allowed {
-		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel destination is not allowed by client configuration.")
-		return
+	// the client checks the destinations of socks tunnels per connection
+	if remote.Protocol != models.ProtocolSOCKS {
+		allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), al.Log())
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !allowed {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel destination is not allowed by client configuration.")
+			return
+		}
 	}
 
 	if existing := al.clientService.FindTunnelByRemote(client, remote); existing != nil {
@@ -306,7 +365,7 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 	}
 
 	for _, t := range client.GetTunnels() {
-		if t.Remote.Remote() == remote.Remote() && t.Remote.IsProtocol(remote.Protocol) && t.EqualACL(remote.ACL) {
+		if remote.Protocol != models.ProtocolSOCKS && t.Remote.Remote() == remote.Remote() && t.Remote.IsProtocol(remote.Protocol) && t.EqualACL(remote.ACL) {
 			al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelToPortExist, fmt.Sprintf("Tunnel to port %s already exists.", remote.RemotePort))
 			return
 		}
@@ -321,7 +380,7 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 	}
 
 	if remote.IsLocalSpecified() {
-		err = al.checkLocalPort(remote.LocalPort, remote.Protocol)
+		err = al.checkLocalPort(remote.LocalPort, remote.ListenProtocol())
 		if err != nil {
 			al.jsonError(w, err)
 			return
@@ -423,6 +482,123 @@ func (al *APIListener) setAuthOptionsForRemote(req *http.Request, remote *models
 	return err
 }
 
+// setSSHOptionsForRemote presets the login of the browser terminal of a ssh tunnel proxy. Only the id of the vault
+// value holding the password or private key is kept, the current user must be allowed to read it.
+func (al *APIListener) setSSHOptionsForRemote(req *http.Request, remote *models.Remote) error {
+	sshUser := req.URL.Query().Get(sshUserQueryParam)
+	sshVaultIDStr := req.URL.Query().Get(sshVaultIDQueryParam)
+	sshHostKey := req.URL.Query().Get(sshHostKeyQueryParam)
+	if sshUser == "" && sshVaultIDStr == "" && sshHostKey == "" {
+		return nil
+	}
+	if !remote.HTTPProxy || remote.Scheme == nil || *remote.Scheme != "ssh" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s, %s and %s require scheme ssh and http_proxy to be activated", sshUserQueryParam, sshVaultIDQueryParam, sshHostKeyQueryParam), nil)
+	}
+
+	creds := &models.SSHCredentials{
+		Username: sshUser,
+	}
+	if sshHostKey != "" {
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(sshHostKey)); err != nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s, expected a public key in authorized_keys format.", sshHostKeyQueryParam), err)
+		}
+		creds.HostKey = sshHostKey
+	}
+	if sshVaultIDStr != "" {
+		sshVaultID, err := strconv.Atoi(sshVaultIDStr)
+		if err != nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s: %s.", sshVaultIDQueryParam, sshVaultIDStr), err)
+		}
+		curUser, err := al.getUserModelForAuth(req.Context())
+		if err != nil {
+			return err
+		}
+		_, found, err := al.vaultManager.GetOne(req.Context(), sshVaultID, curUser)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("vault value with id %d not found", sshVaultID), nil)
+		}
+		creds.VaultID = sshVaultID
+	}
+	remote.SSHCredentials = creds
+
+	return nil
+}
+
+// ReadSSHSecret returns the preset password or private key of the browser terminal of a ssh tunnel proxy. It's read
+// from the vault on every login with the permissions of the user the one-time token of the login was issued to.
+func (al *APIListener) ReadSSHSecret(ctx context.Context, vaultID int, username string) (string, error) {
+	user, err := al.userService.GetByUsername(username)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", fmt.Errorf("user %q not found", username)
+	}
+	value, found, err := al.vaultManager.GetOne(ctx, vaultID, user)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("vault value with id %d not found", vaultID)
+	}
+	return value.Value, nil
+}
+
+func (al *APIListener) setTokenAuthOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
+	tokenAuthStr := req.URL.Query().Get(tokenAuthQueryParam)
+	if tokenAuthStr == "" {
+		return nil
+	}
+	remote.TokenAuth, err = strconv.ParseBool(tokenAuthStr)
+	if err != nil {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s: %s.", tokenAuthQueryParam, tokenAuthStr), err)
+	}
+	if remote.TokenAuth && remote.Protocol != models.ProtocolTCP {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s not allowed with protocol %s", tokenAuthQueryParam, remote.Protocol), nil)
+	}
+	if remote.TokenAuth && remote.HTTPProxy {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s not allowed when http_proxy is true", tokenAuthQueryParam), nil)
+	}
+	return nil
+}
+
+// setHealthCheckOptionsForRemote enables the periodic probes of the tunnel target, udp and socks tunnels have no
+// target that can be probed by a TCP connect.
+func (al *APIListener) setHealthCheckOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
+	intervalStr := req.URL.Query().Get(healthCheckIntervalParam)
+	restartStr := req.URL.Query().Get(healthCheckRestartParam)
+	if intervalStr == "" && restartStr == "" {
+		return nil
+	}
+
+	if intervalStr != "" {
+		remote.HealthCheckInterval, err = time.ParseDuration(intervalStr)
+		if err != nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s: %s.", healthCheckIntervalParam, intervalStr), err)
+		}
+		if remote.HealthCheckInterval < clienttunnel.HealthCheckMinInterval {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s must be at least %s", healthCheckIntervalParam, clienttunnel.HealthCheckMinInterval), nil)
+		}
+	}
+	if restartStr != "" {
+		remote.HealthCheckRestart, err = strconv.ParseBool(restartStr)
+		if err != nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("Invalid %s: %s.", healthCheckRestartParam, restartStr), err)
+		}
+		if remote.HealthCheckRestart && remote.HealthCheckInterval == 0 {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s requires %s", healthCheckRestartParam, healthCheckIntervalParam), nil)
+		}
+	}
+
+	if remote.HealthCheckInterval > 0 && !remote.IsProtocol(models.ProtocolTCP) {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("%s not allowed with protocol %s", healthCheckIntervalParam, remote.Protocol), nil)
+	}
+	return nil
+}
+
 func (al *APIListener) setAutoCloseIdleOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
 	idleTimeoutMinutesStr := req.URL.Query().Get(idleTimeoutMinutesQueryParam)
 	skipIdleTimeout, err := strconv.ParseBool(req.URL.Query().Get(skipIdleTimeoutQueryParam))
@@ -565,6 +741,59 @@ func (al *APIListener) handleDeleteClientTunnel(w http.ResponseWriter, req *http
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handlePostClientTunnelToken handles POST /clients/{client_id}/tunnels/{tunnel_id}/tokens
+// It issues a one-time token to open a connection to a tunnel with token auth, only the tunnel owner and admins can
+// get tokens.
+func (al *APIListener) handlePostClientTunnelToken(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	tunnelID := vars["tunnel_id"]
+	tunnel := al.clientService.FindTunnel(client, tunnelID)
+	if tunnel == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "tunnel not found")
+		return
+	}
+	if tunnel.Tokens == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel has no token auth.")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !curUser.IsAdmin() && tunnel.Owner != curUser.Username {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "Only the owner of the tunnel and administrators can get tokens.")
+		return
+	}
+
+	token, err := tunnel.Tokens.Issue(curUser.Username)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to issue token.", err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTunnelToken, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnelID).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(token))
+}
+
 func (al *APIListener) handlePutClientTunnelACL(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	clientID := vars[routes.ParamClientID]
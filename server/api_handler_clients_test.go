@@ -2,6 +2,7 @@ package chserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -17,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/authorization"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/caddy"
 	"github.com/openrport/openrport/server/cgroups"
@@ -128,7 +130,12 @@ func TestHandleGetClient(t *testing.T) {
                 "auto_close": 0,
                 "created_at":"0001-01-01T00:00:00Z",
                 "id":"1",
-                "tunnel_url":""
+                "tunnel_url":"",
+                "max_bandwidth":0,
+                "token_auth":false,
+                "health_check_interval":0,
+                "health_check_restart":false,
+                "stats":null
             },
             {
                 "name": "",
@@ -149,9 +156,22 @@ func TestHandleGetClient(t *testing.T) {
                 "auto_close": 0,
                 "created_at":"0001-01-01T00:00:00Z",
                 "id":"2",
-                "tunnel_url":""
+                "tunnel_url":"",
+                "max_bandwidth":0,
+                "token_auth":false,
+                "health_check_interval":0,
+                "health_check_restart":false,
+                "stats":null
             }
         ],
+        "tunnel_stats":{
+            "bytes_in":0,
+            "bytes_out":0,
+            "connections":0,
+            "active_connections":0,
+            "udp_sessions":0,
+            "active_udp_sessions":0
+        },
         "connection_state":"connected",
         "cpu_family":"Virtual CPU",
         "cpu_model":"Virtual CPU",
@@ -203,6 +223,7 @@ func TestHandleGetClients(t *testing.T) {
 		Name         string
 		Offset       int
 		Limit        int
+		Restrictions *authorization.APITokenRestrictions
 		ExpectedJSON string
 	}{
 		{
@@ -250,6 +271,20 @@ func TestHandleGetClients(t *testing.T) {
       }
    ],
    "meta": {"count": 2}
+}`,
+		},
+		{
+			Name:         "token restricted to a client",
+			Restrictions: &authorization.APITokenRestrictions{ClientIDs: []string{"client-2"}},
+			ExpectedJSON: `{
+   "data":[
+      {
+         "id":"client-2",
+         "name":"Random Rport Client",
+         "hostname":"alpine-3-10-tk-01"
+      }
+   ],
+   "meta": {"count": 1}
 }`,
 		},
 		{
@@ -278,6 +313,9 @@ func TestHandleGetClients(t *testing.T) {
 			}
 			req := httptest.NewRequest("GET", "/api/v1/clients?"+v.Encode(), nil)
 			ctx := api.WithUser(context.Background(), curUser.Username)
+			if tc.Restrictions != nil {
+				ctx = api.WithTokenRestrictions(ctx, tc.Restrictions)
+			}
 			req = req.WithContext(ctx)
 			al.router.ServeHTTP(w, req)
 
@@ -441,7 +479,12 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"auth_user":"",
 				"auth_password":"",
 				"created_at": "0001-01-01T00:00:00Z",
-				"tunnel_url": ""
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
 			}
 		}`,
 		},
@@ -468,7 +511,12 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"auth_user":"",
 				"auth_password":"",
 				"created_at": "0001-01-01T00:00:00Z",
-				"tunnel_url": ""
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
 			}
 		}`,
 		},
@@ -495,7 +543,12 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"auth_user":"",
 				"auth_password":"",
 				"created_at": "0001-01-01T00:00:00Z",
-				"tunnel_url": ""
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
 			}
 		}`,
 		},
@@ -522,7 +575,12 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 				"auth_user":"admin",
 				"auth_password":"foo",
 				"created_at": "0001-01-01T00:00:00Z",
-				"tunnel_url": ""
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
 			}
 		}`,
 		},
@@ -531,6 +589,174 @@ func TestHandlePutTunnelWithName(t *testing.T) {
 			URL:           "/api/v1/clients/client-1/tunnels?scheme=http&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&auth_user=admin&http_proxy=1",
 			ExpectedError: "auth_user requires auth_password",
 		},
+		{
+			Name: "With Max Bandwidth",
+			URL:  "/api/v1/clients/client-1/tunnels?scheme=ssh&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&max_bandwidth=125000",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"lhost": "0.0.0.0",
+				"lport": "3390",
+				"rhost": "0.0.0.0",
+				"rport": "22",
+				"lport_random": false,
+				"scheme": "ssh",
+				"acl": "127.0.0.1",
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"max_bandwidth": 125000,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
+			}
+		}`,
+		},
+		{
+			Name:          "Invalid Max Bandwidth",
+			URL:           "/api/v1/clients/client-1/tunnels?scheme=ssh&acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&max_bandwidth=-1",
+			ExpectedError: "Invalid max_bandwidth: -1, expected a non-negative number of bytes per second.",
+		},
+		{
+			Name: "With Socks",
+			URL:  "/api/v1/clients/client-1/tunnels?acl=127.0.0.1&local=0.0.0.0%3A3390&protocol=socks",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "socks",
+				"lhost": "0.0.0.0",
+				"lport": "3390",
+				"rhost": "",
+				"rport": "",
+				"lport_random": false,
+				"scheme": null,
+				"acl": "127.0.0.1",
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
+			}
+		}`,
+		},
+		{
+			Name:          "Socks with http proxy",
+			URL:           "/api/v1/clients/client-1/tunnels?acl=127.0.0.1&local=0.0.0.0%3A3390&protocol=socks&http_proxy=1",
+			ExpectedError: "tunnel proxy not allowed with protcol socks",
+		},
+		{
+			Name:          "Socks with remote",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=22&protocol=socks",
+			ExpectedError: "remote not allowed with protocol socks",
+		},
+		{
+			Name: "With Token Auth",
+			URL:  "/api/v1/clients/client-1/tunnels?acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&token_auth=1",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"lhost": "0.0.0.0",
+				"lport": "3390",
+				"rhost": "0.0.0.0",
+				"rport": "22",
+				"lport_random": false,
+				"scheme": null,
+				"acl": "127.0.0.1",
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": true,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
+			}
+		}`,
+		},
+		{
+			Name:          "Token Auth with udp",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A53&protocol=udp&check_port=0&token_auth=1",
+			ExpectedError: "token_auth not allowed with protocol udp",
+		},
+		{
+			Name: "With Health Check",
+			URL:  "/api/v1/clients/client-1/tunnels?acl=127.0.0.1&local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&health_check_interval=30s&health_check_restart=1",
+			ExpectedJSON: `{
+			"data": {
+				"id": "10",
+				"name": "",
+				"owner": "test-user",
+				"protocol": "tcp",
+				"lhost": "0.0.0.0",
+				"lport": "3390",
+				"rhost": "0.0.0.0",
+				"rport": "22",
+				"lport_random": false,
+				"scheme": null,
+				"acl": "127.0.0.1",
+				"idle_timeout_minutes": 5,
+				"auto_close": 0,
+				"http_proxy": false,
+				"host_header": "",
+				"auth_user":"",
+				"auth_password":"",
+				"created_at": "0001-01-01T00:00:00Z",
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 30000000000,
+				"health_check_restart": true,
+				"stats": null
+			}
+		}`,
+		},
+		{
+			Name:          "Health check interval too short",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&health_check_interval=1s",
+			ExpectedError: "health_check_interval must be at least 10s",
+		},
+		{
+			Name:          "Health check with udp",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A53&protocol=udp&check_port=0&health_check_interval=1m",
+			ExpectedError: "health_check_interval not allowed with protocol udp",
+		},
+		{
+			Name:          "Health check restart without interval",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0&health_check_restart=true",
+			ExpectedError: "health_check_restart requires health_check_interval",
+		},
+		{
+			Name:          "SSH user without http proxy",
+			URL:           "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&scheme=ssh&check_port=0&ssh_user=root",
+			ExpectedError: "ssh_user, ssh_vault_id and ssh_host_key require scheme ssh and http_proxy to be activated",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -631,7 +857,12 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"auth_user":"",
 				"auth_password":"",
 				"created_at": "0001-01-01T00:00:00Z",
-				"tunnel_url": ""
+				"tunnel_url": "",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null
 			}
 		}`,
 		},
@@ -652,6 +883,11 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"lport_random": false,
 				"scheme": "http",
 				"tunnel_url": "https://12345678.tunnels.rport.test:443",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null,
 				"acl": "127.0.0.1",
 				"idle_timeout_minutes": 5,
 				"auto_close": 0,
@@ -680,6 +916,11 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"lport_random": false,
 				"scheme": "http",
 				"tunnel_url": "https://12345678.tunnels.rport.test:8443",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null,
 				"acl": "127.0.0.1",
 				"idle_timeout_minutes": 5,
 				"auto_close": 0,
@@ -708,6 +949,11 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 				"lport_random": false,
 				"scheme": null,
 				"tunnel_url": "https://12345678.tunnels.rport.test:443",
+				"max_bandwidth": 0,
+				"token_auth": false,
+				"health_check_interval": 0,
+				"health_check_restart": false,
+				"stats": null,
 				"acl": "127.0.0.1",
 				"idle_timeout_minutes": 5,
 				"auto_close": 0,
@@ -736,6 +982,11 @@ func TestHandlePutTunnelUsingCaddyProxies(t *testing.T) {
 					"lport_random": false,
 					"scheme": "http",
 					"tunnel_url": "",
+					"max_bandwidth": 0,
+					"token_auth": false,
+					"health_check_interval": 0,
+					"health_check_restart": false,
+					"stats": null,
 					"acl": "127.0.0.1",
 					"idle_timeout_minutes": 5,
 					"auto_close": 0,
@@ -908,6 +1159,80 @@ func TestHandlePutClientTunnelACL(t *testing.T) {
 	}
 }
 
+func TestHandlePostClientTunnelToken(t *testing.T) {
+	owner := &users.User{Username: "owner"}
+	other := &users.User{Username: "other"}
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	c1 := clients.New(t).ID("client-1").Build()
+	c1.Tunnels[0].Owner = owner.Username
+	c1.Tunnels[0].Tokens = &clienttunnel.TunnelTokens{}
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			config:        &chconfig.Config{},
+		},
+		userService: &MockUsersService{
+			UserService: users.NewAPIService(users.NewStaticProvider([]*users.User{owner, other, admin}), false, 0, -1),
+		},
+	}
+	al.initRouter()
+
+	testCases := []struct {
+		Name           string
+		URL            string
+		User           *users.User
+		ExpectedStatus int
+	}{
+		{
+			Name:           "owner",
+			URL:            "/api/v1/clients/client-1/tunnels/1/tokens",
+			User:           owner,
+			ExpectedStatus: http.StatusOK,
+		}, {
+			Name:           "admin",
+			URL:            "/api/v1/clients/client-1/tunnels/1/tokens",
+			User:           admin,
+			ExpectedStatus: http.StatusOK,
+		}, {
+			Name:           "other user",
+			URL:            "/api/v1/clients/client-1/tunnels/1/tokens",
+			User:           other,
+			ExpectedStatus: http.StatusForbidden,
+		}, {
+			Name:           "no token auth",
+			URL:            "/api/v1/clients/client-1/tunnels/2/tokens",
+			User:           admin,
+			ExpectedStatus: http.StatusBadRequest,
+		}, {
+			Name:           "unknown tunnel",
+			URL:            "/api/v1/clients/client-1/tunnels/unknown/tokens",
+			User:           admin,
+			ExpectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.URL, nil)
+			req = req.WithContext(api.WithUser(req.Context(), tc.User.Username))
+			al.router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.ExpectedStatus, w.Code, w.Body.String())
+			if tc.ExpectedStatus == http.StatusOK {
+				var resp struct {
+					Data *clienttunnel.TunnelToken `json:"data"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.NotEmpty(t, resp.Data.Token)
+				assert.Equal(t, tc.User.Username, resp.Data.Username)
+			}
+		})
+	}
+}
+
 type MockTunnelProtocol struct {
 	clienttunnel.TunnelProtocol
 	ACL *clienttunnel.TunnelACL
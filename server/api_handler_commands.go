@@ -2,6 +2,7 @@ package chserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/openrport/openrport/server/api"
 	"github.com/openrport/openrport/server/api/jobs"
 	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/server/validation"
 	"github.com/openrport/openrport/share/comm"
@@ -272,6 +274,59 @@ func (al *APIListener) handleGetCommand(w http.ResponseWriter, req *http.Request
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
 }
 
+// handleCancelCommand handles DELETE /clients/{client_id}/commands/{job_id}
+func (al *APIListener) handleCancelCommand(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	cid := vars[routes.ParamClientID]
+	jid := vars[routes.ParamJobID]
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	job, err := al.jobProvider.GetByJID(cid, jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a job[id=%q].", jid), err)
+		return
+	}
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Job[id=%q] not found.", jid))
+		return
+	}
+	if !curUser.IsAdmin() && job.CreatedBy != curUser.Username {
+		al.jsonErrorResponseWithError(w, http.StatusForbidden, "forbidden", fmt.Errorf("you are not allowed to cancel jobs created by another user"))
+		return
+	}
+	if job.Status != models.JobStatusRunning {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Job[id=%q] is not running.", jid))
+		return
+	}
+
+	err = al.cancelJob(job, curUser.Username)
+	if err != nil {
+		if _, ok := err.(*comm.ClientError); ok || errors.Is(err, ErrClientNotConnected) {
+			al.jsonErrorResponseWithError(w, http.StatusConflict, fmt.Sprintf("Failed to cancel Job[id=%q].", jid), err)
+			return
+		}
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to cancel Job[id=%q].", jid), err)
+		return
+	}
+
+	application := auditlog.ApplicationClientCommand
+	if job.IsScript {
+		application = auditlog.ApplicationClientScript
+	}
+	al.auditLog.Entry(application, auditlog.ActionCancel).
+		WithHTTPRequest(req).
+		WithClientID(cid).
+		WithID(jid).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
+}
+
 // TODO: refactor to reuse similar code for REST API and WebSocket to execute cmds if both will be supported
 // handlePostMultiClientCommand handles POST /commands
 func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *http.Request) {
@@ -311,7 +366,7 @@ func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *
 		al.jsonError(w, err)
 		return
 	}
-	err = al.clientService.CheckClientsAccess(reqBody.OrderedClients, curUser, clientGroups)
+	err = al.checkClientsAccess(req.Context(), reqBody.OrderedClients, curUser, clientGroups)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -379,18 +434,19 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		return nil
 	}
 	curJob := models.Job{
-		JID:         jid,
-		FinishedAt:  nil,
-		ClientID:    executeInput.ClientID,
-		ClientName:  client.GetName(),
-		Command:     executeInput.Command,
-		Interpreter: executeInput.Interpreter,
-		CreatedBy:   api.GetUser(ctx, al.Logger),
-		TimeoutSec:  executeInput.TimeoutSec,
-		Result:      nil,
-		Cwd:         executeInput.Cwd,
-		IsSudo:      executeInput.IsSudo,
-		IsScript:    executeInput.IsScript,
+		JID:          jid,
+		FinishedAt:   nil,
+		ClientID:     executeInput.ClientID,
+		ClientName:   client.GetName(),
+		Command:      executeInput.Command,
+		Interpreter:  executeInput.Interpreter,
+		CreatedBy:    api.GetUser(ctx, al.Logger),
+		TimeoutSec:   executeInput.TimeoutSec,
+		Result:       nil,
+		Cwd:          executeInput.Cwd,
+		IsSudo:       executeInput.IsSudo,
+		IsScript:     executeInput.IsScript,
+		StreamResult: al.persistsJobOutput(),
 	}
 	sshResp := &comm.RunCmdResponse{}
 	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, curJob, sshResp, al.Log())
@@ -501,6 +557,11 @@ func (al *APIListener) handleGetMultiClientCommands(w http.ResponseWriter, req *
 		return
 	}
 
+	if hasClientLimits(req.Context()) {
+		al.getAllowedMultiClientCommands(w, req, listOptions)
+		return
+	}
+
 	result, err := al.jobProvider.GetMultiJobSummaries(req.Context(), listOptions)
 	if err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get multi-client jobs.", err)
@@ -519,3 +580,233 @@ func (al *APIListener) handleGetMultiClientCommands(w http.ResponseWriter, req *
 	}
 	al.writeJSONResponse(w, http.StatusOK, payload)
 }
+
+// getAllowedMultiClientCommands lists the multi-client jobs of clients allowed by allowsClient. Jobs are filtered and
+// paginated after they are fetched, the clients of a job are not stored in the multi-client jobs table.
+func (al *APIListener) getAllowedMultiClientCommands(w http.ResponseWriter, req *http.Request, listOptions *query.ListOptions) {
+	ctx := req.Context()
+	pagination := listOptions.Pagination
+	listOptions.Pagination = nil
+
+	summaries, err := al.jobProvider.GetMultiJobSummaries(ctx, listOptions)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get multi-client jobs.", err)
+		return
+	}
+
+	jobClientIDs, err := al.jobProvider.GetMultiJobClientIDs(ctx)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get clients of multi-client jobs.", err)
+		return
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get client groups.", err)
+		return
+	}
+
+	allowed := make([]*models.MultiJobSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if al.allowsClientIDs(ctx, jobClientIDs[summary.JID], clientGroups) {
+			allowed = append(allowed, summary)
+		}
+	}
+
+	totalCount := len(allowed)
+	if pagination != nil {
+		start, end := pagination.GetStartEnd(totalCount)
+		allowed = allowed[start:end]
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: allowed,
+		Meta: api.NewMeta(totalCount),
+	})
+}
+
+// handleApproveMultiClientCommand handles POST /commands/{job_id}/approve
+func (al *APIListener) handleApproveMultiClientCommand(w http.ResponseWriter, req *http.Request) {
+	al.decideMultiClientCommand(w, req, true)
+}
+
+// handleRejectMultiClientCommand handles POST /commands/{job_id}/reject
+func (al *APIListener) handleRejectMultiClientCommand(w http.ResponseWriter, req *http.Request) {
+	al.decideMultiClientCommand(w, req, false)
+}
+
+// decideMultiClientCommand approves or rejects a multi-client command or script awaiting approval. It must be decided
+// by another user than the one who created it.
+func (al *APIListener) decideMultiClientCommand(w http.ResponseWriter, req *http.Request, approve bool) {
+	ctx := req.Context()
+	jid := mux.Vars(req)[routes.ParamJobID]
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.multiJobDecisionMu.Lock()
+	defer al.multiJobDecisionMu.Unlock()
+
+	job, err := al.jobProvider.GetMultiJob(ctx, jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
+		return
+	}
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Multi-client Job[id=%q] not found.", jid))
+		return
+	}
+	if job.Status != models.MultiJobStatusAwaitingApproval {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Multi-client Job[id=%q] is not awaiting approval.", jid))
+		return
+	}
+	if job.CreatedBy == curUser.Username {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "A multi-client job must be approved or rejected by another user than the one who created it.")
+		return
+	}
+
+	var orderedClients []*clientdata.Client
+	now := time.Now()
+	job.DecidedAt = &now
+	action := auditlog.ActionReject
+	if approve {
+		orderedClients, err = al.rebuildOrderedClients(ctx, &jobs.MultiJobRequest{
+			ClientIDs:  job.ClientIDs,
+			GroupIDs:   job.GroupIDs,
+			ClientTags: job.ClientTags,
+		})
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if len(orderedClients) == 0 {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, "No clients for execution.")
+			return
+		}
+		job.ApprovedBy = curUser.Username
+		job.Status = models.MultiJobStatusRunning
+		job.StartedAt = now
+		action = auditlog.ActionApprove
+	} else {
+		job.RejectedBy = curUser.Username
+		job.Status = models.MultiJobStatusRejected
+	}
+
+	err = al.jobProvider.SaveMultiJob(job)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	application := auditlog.ApplicationClientCommand
+	if job.IsScript {
+		application = auditlog.ApplicationClientScript
+	}
+	auditLogEntry := al.auditLog.Entry(application, action).
+		WithHTTPRequest(req).
+		WithID(job.JID)
+	if approve {
+		auditLogEntry.SaveForMultipleClients(orderedClients)
+	} else {
+		auditLogEntry.Save()
+	}
+
+	// respond before the job is executed, which changes its state concurrently
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
+
+	if approve {
+		al.Debugf("Multi-client Job[id=%q] approved by %s.", job.JID, curUser.Username)
+		go al.executeMultiClientJob(al.runningMultiJobs.Start(job.JID), job, orderedClients)
+	}
+}
+
+// handleCancelMultiClientCommand handles DELETE /commands/{job_id}
+// No further clients are started and the jobs still running on clients are cancelled.
+func (al *APIListener) handleCancelMultiClientCommand(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	jid := mux.Vars(req)[routes.ParamJobID]
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.multiJobDecisionMu.Lock()
+	defer al.multiJobDecisionMu.Unlock()
+
+	job, err := al.jobProvider.GetMultiJob(ctx, jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
+		return
+	}
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Multi-client Job[id=%q] not found.", jid))
+		return
+	}
+	if !curUser.IsAdmin() && job.CreatedBy != curUser.Username {
+		al.jsonErrorResponseWithError(w, http.StatusForbidden, "forbidden", fmt.Errorf("you are not allowed to cancel jobs created by another user"))
+		return
+	}
+	switch job.Status {
+	// jobs created before statuses were introduced have no status
+	case models.MultiJobStatusRunning, models.MultiJobStatusAwaitingApproval, "":
+	default:
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Multi-client Job[id=%q] is not running.", jid))
+		return
+	}
+
+	if done, ok := al.runningMultiJobs.Cancel(jid, curUser.Username); ok {
+		select {
+		case <-done:
+		case <-time.After(multiJobCancelTimeout):
+			al.jsonErrorResponseWithTitle(w, http.StatusInternalServerError, fmt.Sprintf("Timeout on cancelling Multi-client Job[id=%q].", jid))
+			return
+		}
+		job, err = al.jobProvider.GetMultiJob(ctx, jid)
+		if err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
+			return
+		}
+	} else {
+		// the job is not executed (anymore), e.g. it awaits approval or the server was restarted
+		job.Status = models.MultiJobStatusCancelled
+		job.CancelledBy = curUser.Username
+		err = al.jobProvider.SaveMultiJob(job)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	running, err := al.jobProvider.List(ctx, &query.ListOptions{
+		Filters: []query.FilterOption{
+			{Column: []string{"multi_job_id"}, Values: []string{jid}},
+			{Column: []string{"status"}, Values: []string{models.JobStatusRunning}},
+		},
+	})
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", jid), err)
+		return
+	}
+	for _, child := range running {
+		err := al.cancelJob(child, curUser.Username)
+		if err != nil {
+			al.Errorf("%s: failed to cancel: %v", child.LogPrefix(), err)
+		}
+	}
+
+	application := auditlog.ApplicationClientCommand
+	if job.IsScript {
+		application = auditlog.ApplicationClientScript
+	}
+	al.auditLog.Entry(application, auditlog.ActionCancel).
+		WithHTTPRequest(req).
+		WithID(jid).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
+}
@@ -2132,3 +2132,439 @@ func makeScheduleManager(t *testing.T, jp *jobs.SqliteProvider, jobRunner schedu
 
 	return scheduleManager
 }
+
+func newMultiClientCommandTestListener(t *testing.T, clientList []*clientdata.Client, userList ...*users.User) (*APIListener, *jobs.SqliteProvider) {
+	al := &APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository(clientList, &hour, testLog), testLog, nil),
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{
+					RunRemoteCmdTimeoutSec: 60,
+				},
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			jobsDoneChannel: jobResultChanMap{
+				m: make(map[string]chan *models.Job),
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider(userList), false, 0, -1),
+		Logger:      testLog,
+		testDone:    make(chan bool),
+	}
+	al.initRouter()
+
+	jobsDB, err := sqlite.New(
+		":memory:",
+		jobsmigration.AssetNames(),
+		jobsmigration.Asset,
+		DataSourceOptions,
+	)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, testLog)
+	t.Cleanup(func() { jp.Close() })
+	al.jobProvider = jp
+
+	return al, jp
+}
+
+func newConnectedTestClient(t *testing.T, id string) (*clientdata.Client, *test.ConnMock) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	sshRespBytes, err := json.Marshal(comm.RunCmdResponse{Pid: 1, StartedAt: time.Date(2020, 10, 10, 10, 10, 1, 0, time.UTC)})
+	require.NoError(t, err)
+	connMock.ReturnResponsePayload = sshRespBytes
+
+	return clients.New(t).ID(id).Connection(connMock).Logger(testLog).Build(), connMock
+}
+
+func postMultiClientCommand(al *APIListener, username, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/commands", strings.NewReader(body))
+	req = req.WithContext(api.WithUser(context.Background(), username))
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandlePostMultiClientCommandWithRollout(t *testing.T) {
+	c1, connMock1 := newConnectedTestClient(t, "client-1")
+	c2, _ := newConnectedTestClient(t, "client-2")
+	c3, _ := newConnectedTestClient(t, "client-3")
+	curUser := &users.User{Username: "test-user", Groups: []string{users.Administrators}}
+
+	testCases := []struct {
+		name           string
+		rollout        string
+		connReturnErr  error
+		wantStatusCode int
+		wantErr        string
+		wantStatus     string
+		wantWaves      []models.MultiJobWave
+		wantJobError   string
+	}{
+		{
+			name:           "waves of one client",
+			rollout:        `{"batch_size": 1}`,
+			wantStatusCode: http.StatusOK,
+			wantStatus:     models.MultiJobStatusFinished,
+			wantWaves: []models.MultiJobWave{
+				{ClientIDs: []string{"client-1"}, Succeeded: 1},
+				{ClientIDs: []string{"client-2"}, Succeeded: 1},
+				{ClientIDs: []string{"client-3"}, Succeeded: 1},
+			},
+		},
+		{
+			name:           "waves by percent",
+			rollout:        `{"batch_percent": 50}`,
+			wantStatusCode: http.StatusOK,
+			wantStatus:     models.MultiJobStatusFinished,
+			wantWaves: []models.MultiJobWave{
+				{ClientIDs: []string{"client-1", "client-2"}, Succeeded: 2},
+				{ClientIDs: []string{"client-3"}, Succeeded: 1},
+			},
+		},
+		{
+			name:           "success threshold not reached",
+			rollout:        `{"batch_size": 2, "success_threshold": 60}`,
+			connReturnErr:  errors.New("send fake error"),
+			wantStatusCode: http.StatusOK,
+			wantStatus:     models.MultiJobStatusAborted,
+			wantWaves: []models.MultiJobWave{
+				{ClientIDs: []string{"client-1", "client-2"}, Succeeded: 1, Failed: 1},
+			},
+			wantJobError: "1 of 2 jobs of wave 1 succeeded, below the success threshold of 60%",
+		},
+		{
+			name:           "success threshold reached",
+			rollout:        `{"batch_size": 2, "success_threshold": 50}`,
+			connReturnErr:  errors.New("send fake error"),
+			wantStatusCode: http.StatusOK,
+			wantStatus:     models.MultiJobStatusFinished,
+			wantWaves: []models.MultiJobWave{
+				{ClientIDs: []string{"client-1", "client-2"}, Succeeded: 1, Failed: 1},
+				{ClientIDs: []string{"client-3"}, Succeeded: 1},
+			},
+		},
+		{
+			name:           "invalid rollout",
+			rollout:        `{"batch_size": 1, "batch_percent": 10}`,
+			wantStatusCode: http.StatusBadRequest,
+			wantErr:        "batch_size and batch_percent cannot be used together",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1, c2, c3}, curUser)
+			connMock1.ReturnErr = tc.connReturnErr
+
+			w := postMultiClientCommand(al, curUser.Username, `{"command": "/bin/date", "client_ids": ["client-1", "client-2", "client-3"], "abort_on_error": false, "rollout": `+tc.rollout+`}`)
+
+			require.Equal(t, tc.wantStatusCode, w.Code, w.Body.String())
+			if tc.wantStatusCode != http.StatusOK {
+				assert.Contains(t, w.Body.String(), tc.wantErr)
+				return
+			}
+			<-al.testDone
+
+			var resp struct {
+				Data newJobResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			gotMultiJob, err := jp.GetMultiJob(context.Background(), resp.Data.JID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, gotMultiJob.Status)
+			assert.Equal(t, tc.wantJobError, gotMultiJob.Error)
+			require.Len(t, gotMultiJob.Waves, len(tc.wantWaves))
+			for i, wave := range gotMultiJob.Waves {
+				assert.Equal(t, tc.wantWaves[i].ClientIDs, wave.ClientIDs)
+				assert.Equal(t, tc.wantWaves[i].Succeeded, wave.Succeeded)
+				assert.Equal(t, tc.wantWaves[i].Failed, wave.Failed)
+				assert.NotNil(t, wave.FinishedAt)
+			}
+		})
+	}
+}
+
+func TestExecuteMultiJobWaveIgnoresLateResults(t *testing.T) {
+	c1, _ := newConnectedTestClient(t, "client-1")
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1})
+	// wait for the job results
+	al.insecureForTests = false
+	ctx := context.Background()
+
+	job := &models.MultiJob{
+		MultiJobSummary: models.MultiJobSummary{JID: "multi-job-1", CreatedBy: "test-user"},
+		Command:         "/bin/date",
+		TimeoutSec:      60,
+		Concurrent:      true,
+		Rollout:         &models.JobRollout{BatchSize: 1},
+	}
+	doneChannel := make(chan *models.Job, 2)
+	// the result of a job of a previous wave, which arrived after its wave timed out
+	doneChannel <- &models.Job{JID: "previous-wave-job", Status: models.JobStatusFailed}
+	go func() {
+		for {
+			started, err := jp.List(ctx, &query.ListOptions{})
+			if err == nil && len(started) > 0 {
+				doneChannel <- &models.Job{JID: started[0].JID, Status: models.JobStatusSuccessful}
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	wave := &models.MultiJobWave{}
+	err := al.executeMultiJobWave(ctx, job, wave, []*clientdata.Client{c1}, doneChannel)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, wave.Succeeded)
+	assert.Equal(t, 0, wave.Failed)
+}
+
+func TestHandleMultiClientCommandApproval(t *testing.T) {
+	c1, _ := newConnectedTestClient(t, "client-1")
+	c2, _ := newConnectedTestClient(t, "client-2")
+	requester := &users.User{Username: "requester", Groups: []string{users.Administrators}}
+	approver := &users.User{Username: "approver", Groups: []string{users.Administrators}}
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1, c2}, requester, approver)
+	ctx := context.Background()
+
+	decide := func(username, jid, decision string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/commands/"+jid+"/"+decision, nil)
+		req = req.WithContext(api.WithUser(ctx, username))
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	createJob := func() string {
+		w := postMultiClientCommand(al, requester.Username, `{"command": "/bin/date", "client_ids": ["client-1", "client-2"], "require_approval": true}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data newJobResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.JID
+	}
+
+	jid := createJob()
+	gotMultiJob, err := jp.GetMultiJob(ctx, jid)
+	require.NoError(t, err)
+	assert.Equal(t, models.MultiJobStatusAwaitingApproval, gotMultiJob.Status)
+	assert.Empty(t, gotMultiJob.Jobs)
+
+	w := decide(requester.Username, jid, "approve")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = decide(approver.Username, jid, "approve")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	<-al.testDone
+
+	gotMultiJob, err = jp.GetMultiJob(ctx, jid)
+	require.NoError(t, err)
+	assert.Equal(t, models.MultiJobStatusFinished, gotMultiJob.Status)
+	assert.Equal(t, approver.Username, gotMultiJob.ApprovedBy)
+	assert.NotNil(t, gotMultiJob.DecidedAt)
+	assert.Len(t, gotMultiJob.Jobs, 2)
+
+	w = decide(approver.Username, jid, "reject")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	jid = createJob()
+	w = decide(approver.Username, jid, "reject")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	gotMultiJob, err = jp.GetMultiJob(ctx, jid)
+	require.NoError(t, err)
+	assert.Equal(t, models.MultiJobStatusRejected, gotMultiJob.Status)
+	assert.Equal(t, approver.Username, gotMultiJob.RejectedBy)
+	assert.Empty(t, gotMultiJob.Jobs)
+
+	w = decide(approver.Username, "unknown", "approve")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func deleteCommand(al *APIListener, username, url string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, url, nil)
+	req = req.WithContext(api.WithUser(context.Background(), username))
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandleCancelCommand(t *testing.T) {
+	c1, connMock1 := newConnectedTestClient(t, "client-1")
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	other := &users.User{Username: "other"}
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1}, admin, other)
+
+	running := jb.New(t).ClientID("client-1").JID("job-1").Status(models.JobStatusRunning).Build()
+	require.NoError(t, jp.CreateJob(running))
+	disconnected := jb.New(t).ClientID("client-2").JID("job-2").Status(models.JobStatusRunning).Build()
+	require.NoError(t, jp.CreateJob(disconnected))
+
+	w := deleteCommand(al, admin.Username, "/api/v1/clients/client-1/commands/unknown")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = deleteCommand(al, other.Username, "/api/v1/clients/client-1/commands/job-1")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = deleteCommand(al, admin.Username, "/api/v1/clients/client-2/commands/job-2")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), ErrClientNotConnected.Error())
+
+	w = deleteCommand(al, admin.Username, "/api/v1/clients/client-1/commands/job-1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	name, _, payload := connMock1.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeCancelCmd, name)
+	assert.JSONEq(t, `{"jid": "job-1", "cancelled_by": "admin"}`, string(payload))
+
+	gotJob, err := jp.GetByJID("client-1", "job-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusCancelled, gotJob.Status)
+	assert.Equal(t, "cancelled by admin", gotJob.Error)
+	assert.NotNil(t, gotJob.FinishedAt)
+
+	w = deleteCommand(al, admin.Username, "/api/v1/clients/client-1/commands/job-1")
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHandleCancelMultiClientCommand(t *testing.T) {
+	c1, _ := newConnectedTestClient(t, "client-1")
+	c2, _ := newConnectedTestClient(t, "client-2")
+	requester := &users.User{Username: "requester", Groups: []string{users.Administrators}}
+	approver := &users.User{Username: "approver", Groups: []string{users.Administrators}}
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1, c2}, requester, approver)
+	ctx := context.Background()
+
+	postJob := func(body string) string {
+		w := postMultiClientCommand(al, requester.Username, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data newJobResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.JID
+	}
+
+	t.Run("awaiting approval", func(t *testing.T) {
+		jid := postJob(`{"command": "/bin/date", "client_ids": ["client-1", "client-2"], "require_approval": true}`)
+
+		w := deleteCommand(al, requester.Username, "/api/v1/commands/"+jid)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		gotMultiJob, err := jp.GetMultiJob(ctx, jid)
+		require.NoError(t, err)
+		assert.Equal(t, models.MultiJobStatusCancelled, gotMultiJob.Status)
+		assert.Equal(t, requester.Username, gotMultiJob.CancelledBy)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/commands/"+jid+"/approve", nil)
+		req = req.WithContext(api.WithUser(ctx, approver.Username))
+		w = httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		w = deleteCommand(al, requester.Username, "/api/v1/commands/"+jid)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("running", func(t *testing.T) {
+		jid := postJob(`{"command": "/bin/date", "client_ids": ["client-1", "client-2"], "rollout": {"batch_size": 1, "pause_sec": 3600}}`)
+		require.Eventually(t, func() bool {
+			gotMultiJob, err := jp.GetMultiJob(ctx, jid)
+			require.NoError(t, err)
+			return len(gotMultiJob.Jobs) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		go func() { <-al.testDone }()
+
+		w := deleteCommand(al, approver.Username, "/api/v1/commands/"+jid)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		gotMultiJob, err := jp.GetMultiJob(ctx, jid)
+		require.NoError(t, err)
+		assert.Equal(t, models.MultiJobStatusCancelled, gotMultiJob.Status)
+		assert.Equal(t, approver.Username, gotMultiJob.CancelledBy)
+		assert.Len(t, gotMultiJob.Waves, 1)
+		require.Len(t, gotMultiJob.Jobs, 1)
+		assert.Equal(t, models.JobStatusCancelled, gotMultiJob.Jobs[0].Status)
+	})
+}
+
+func TestHandleGetMultiClientCommandsClientLimits(t *testing.T) {
+	c1, _ := newConnectedTestClient(t, "client-1")
+	c2, _ := newConnectedTestClient(t, "client-2")
+	c1.Tags = []string{"linux"}
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{c1, c2}, admin)
+
+	startedAt := time.Date(2020, 10, 10, 10, 10, 10, 0, time.UTC)
+	require.NoError(t, jp.SaveMultiJob(jb.NewMulti(t).JID("job-1").StartedAt(startedAt).ClientIDs("client-1").Build()))
+	require.NoError(t, jp.SaveMultiJob(jb.NewMulti(t).JID("job-2").StartedAt(startedAt.Add(time.Minute)).ClientIDs("client-1", "client-2").Build()))
+	require.NoError(t, jp.SaveMultiJob(jb.NewMulti(t).JID("job-3").StartedAt(startedAt.Add(2*time.Minute)).ClientIDs("client-1").Build()))
+
+	testCases := []struct {
+		Name          string
+		Query         string
+		Restrictions  *authorization.APITokenRestrictions
+		Scope         *users.RoleResources
+		ExpectedJIDs  []string
+		ExpectedCount int
+	}{
+		{
+			Name:          "no restrictions",
+			ExpectedJIDs:  []string{"job-3", "job-2", "job-1"},
+			ExpectedCount: 3,
+		},
+		{
+			Name:          "restricted to a client",
+			Restrictions:  &authorization.APITokenRestrictions{ClientIDs: []string{"client-1"}},
+			ExpectedJIDs:  []string{"job-3", "job-1"},
+			ExpectedCount: 2,
+		},
+		{
+			Name:          "roles limited to a client tag",
+			Scope:         &users.RoleResources{ClientTags: []string{"linux"}},
+			ExpectedJIDs:  []string{"job-3", "job-1"},
+			ExpectedCount: 2,
+		},
+		{
+			Name:          "restricted with pagination",
+			Query:         "?page[limit]=1&page[offset]=1",
+			Restrictions:  &authorization.APITokenRestrictions{ClientIDs: []string{"client-1"}},
+			ExpectedJIDs:  []string{"job-1"},
+			ExpectedCount: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := api.WithUser(context.Background(), admin.Username)
+			if tc.Restrictions != nil {
+				ctx = api.WithTokenRestrictions(ctx, tc.Restrictions)
+			}
+			if tc.Scope != nil {
+				ctx = api.WithResourceScope(ctx, tc.Scope)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/commands"+tc.Query, nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			al.router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var resp struct {
+				Data []*models.MultiJobSummary `json:"data"`
+				Meta *api.Meta                 `json:"meta"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			var jids []string
+			for _, s := range resp.Data {
+				jids = append(jids, s.JID)
+			}
+			assert.Equal(t, tc.ExpectedJIDs, jids)
+			assert.Equal(t, tc.ExpectedCount, resp.Meta.Count)
+		})
+	}
+}
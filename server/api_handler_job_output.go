@@ -0,0 +1,189 @@
+package chserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/models"
+)
+
+const (
+	jobOutputPageSize  = 1000
+	jobOutputKeepAlive = 15 * time.Second
+)
+
+type jobOutputPayload struct {
+	Chunks []*models.JobOutputChunk `json:"chunks"`
+	// NextOffset is the offset to continue from to get further output
+	NextOffset int64 `json:"next_offset"`
+	// Finished is true if the job finished and all its output is returned
+	Finished bool `json:"finished"`
+}
+
+// handleGetCommandOutput handles GET /commands/{job_id}/output
+// The output persisted from the given offset on is returned as JSON. If requested with "Accept: text/event-stream",
+// the output is sent as server-sent events until the job finished.
+func (al *APIListener) handleGetCommandOutput(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	jid := mux.Vars(req)[routes.ParamJobID]
+
+	since, err := getJobOutputOffset(req)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid offset.", err)
+		return
+	}
+
+	job, err := al.jobProvider.GetByJID("", jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a job[id=%q].", jid), err)
+		return
+	}
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Job[id=%q] not found.", jid))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !curUser.IsAdmin() && job.CreatedBy != curUser.Username {
+		al.jsonErrorResponseWithError(w, http.StatusForbidden, "forbidden", fmt.Errorf("you are not allowed to access items created by another user"))
+		return
+	}
+
+	if strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		al.streamJobOutput(w, req, job, since)
+		return
+	}
+
+	chunks, err := al.jobProvider.ListOutput(ctx, jid, since, jobOutputPageSize)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get the output of Job[id=%q].", jid), err)
+		return
+	}
+
+	payload := jobOutputPayload{
+		Chunks:     chunks,
+		NextOffset: since,
+		Finished:   job.Status != models.JobStatusRunning && len(chunks) < jobOutputPageSize,
+	}
+	if len(chunks) > 0 {
+		payload.NextOffset = chunks[len(chunks)-1].End()
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(payload))
+}
+
+// streamJobOutput sends the output of a job as server-sent events. The id of each event is the offset to resume from.
+// A final "done" event contains the finished job.
+func (al *APIListener) streamJobOutput(w http.ResponseWriter, req *http.Request, job *models.Job, since int64) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		al.jsonErrorResponseWithTitle(w, http.StatusInternalServerError, "Streaming is not supported.")
+		return
+	}
+
+	updates, unsubscribe := al.jobOutputs.Subscribe(job.JID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(jobOutputKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		// the status is read before the output, so no output is missed when the job finished
+		finished := job.Status != models.JobStatusRunning
+		chunks, err := al.jobProvider.ListOutput(req.Context(), job.JID, since, jobOutputPageSize)
+		if err != nil {
+			al.Errorf("%s, failed to get output: %v", job.LogPrefix(), err)
+			return
+		}
+		for _, chunk := range chunks {
+			since = chunk.End()
+			err = writeServerSentEvent(w, strconv.FormatInt(since, 10), chunk.Stream, chunk)
+			if err != nil {
+				return
+			}
+		}
+
+		if finished && len(chunks) < jobOutputPageSize {
+			_ = writeServerSentEvent(w, "", "done", job)
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		if len(chunks) < jobOutputPageSize {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-updates:
+			case <-keepAlive.C:
+				// also catches up with jobs updated by other means than this server
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+				if err != nil {
+					return
+				}
+			}
+		}
+
+		current, err := al.jobProvider.GetByJID(job.ClientID, job.JID)
+		if err != nil {
+			al.Errorf("%s, failed to get job: %v", job.LogPrefix(), err)
+			return
+		}
+		if current != nil {
+			job = current
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, id, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		_, err = fmt.Fprintf(w, "id: %s\n", id)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// getJobOutputOffset returns the offset given by the "since" query param or by the "Last-Event-ID" header sent by
+// reconnecting event sources.
+func getJobOutputOffset(req *http.Request) (int64, error) {
+	value := req.URL.Query().Get("since")
+	if value == "" {
+		value = req.Header.Get("Last-Event-ID")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	since, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if since < 0 {
+		return 0, fmt.Errorf("offset must not be negative: %d", since)
+	}
+	return since, nil
+}
@@ -0,0 +1,114 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/test/jb"
+	"github.com/openrport/openrport/share/models"
+)
+
+func TestHandleGetCommandOutput(t *testing.T) {
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	other := &users.User{Username: "other"}
+	al, jp := newMultiClientCommandTestListener(t, []*clientdata.Client{}, admin, other)
+	ctx := context.Background()
+
+	job := jb.New(t).JID("job-1").Status(models.JobStatusRunning).Build()
+	job.CreatedBy = admin.Username
+	require.NoError(t, jp.CreateJob(job))
+	_, err := jp.AppendOutput(ctx, job.JID, models.ChannelStdout, []byte("line 1\n"), 1024)
+	require.NoError(t, err)
+	_, err = jp.AppendOutput(ctx, job.JID, models.ChannelStderr, []byte("error\n"), 1024)
+	require.NoError(t, err)
+
+	get := func(username, url string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(api.WithUser(ctx, username))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(admin.Username, "/api/v1/commands/unknown/output", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = get(other.Username, "/api/v1/commands/job-1/output", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = get(admin.Username, "/api/v1/commands/job-1/output?since=-1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = get(admin.Username, "/api/v1/commands/job-1/output?since=2", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data jobOutputPayload `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.Chunks, 2)
+	assert.Equal(t, "ne 1\n", resp.Data.Chunks[0].Data)
+	assert.Equal(t, models.ChannelStderr, resp.Data.Chunks[1].Stream)
+	assert.Equal(t, int64(13), resp.Data.NextOffset)
+	assert.False(t, resp.Data.Finished)
+
+	t.Run("live events", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			done <- get(admin.Username, "/api/v1/commands/job-1/output", http.Header{
+				"Accept":        {"text/event-stream"},
+				"Last-Event-Id": {"7"},
+			})
+		}()
+
+		// wait until subscribed
+		require.Eventually(t, func() bool {
+			al.jobOutputs.mu.Lock()
+			defer al.jobOutputs.mu.Unlock()
+			return len(al.jobOutputs.m[job.JID]) > 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		_, err := jp.AppendOutput(ctx, job.JID, models.ChannelStdout, []byte("line 2\n"), 1024)
+		require.NoError(t, err)
+		al.jobOutputs.Notify(job.JID)
+
+		finishedAt := time.Now()
+		job.Status = models.JobStatusSuccessful
+		job.FinishedAt = &finishedAt
+		require.NoError(t, jp.SaveJob(job))
+		al.jobOutputs.Notify(job.JID)
+
+		var w *httptest.ResponseRecorder
+		select {
+		case w = <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("event stream did not end")
+		}
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Contains(t, body, "id: 13\nevent: stderr\ndata: {\"jid\":\"job-1\",\"offset\":7,\"stream\":\"stderr\",\"data\":\"error\\n\"")
+		assert.Contains(t, body, "id: 20\nevent: stdout\ndata: {\"jid\":\"job-1\",\"offset\":13,\"stream\":\"stdout\",\"data\":\"line 2\\n\"")
+		assert.NotContains(t, body, "line 1")
+		assert.Contains(t, body, "event: done\ndata: {\"jid\":\"job-1\",\"status\":\"successful\"")
+	})
+
+	w = get(admin.Username, "/api/v1/commands/job-1/output?since=20", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Data.Chunks)
+	assert.Equal(t, int64(20), resp.Data.NextOffset)
+	assert.True(t, resp.Data.Finished)
+}
@@ -16,7 +16,7 @@ import (
 )
 
 func (al *APIListener) handleListScripts(w http.ResponseWriter, req *http.Request) {
-	items, count, err := al.scriptManager.List(req.Context(), req)
+	items, count, err := al.scriptManager.ListAllowed(req.Context(), req, api.GetResourceScope(req.Context()).AllowsScript)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -147,7 +147,7 @@ func (al *APIListener) handleDeleteScript(w http.ResponseWriter, req *http.Reque
 }
 
 func (al *APIListener) handleListCommands(w http.ResponseWriter, req *http.Request) {
-	items, count, err := al.commandManager.List(req.Context(), req)
+	items, count, err := al.commandManager.ListAllowed(req.Context(), req, api.GetResourceScope(req.Context()).AllowsCommand)
 	if err != nil {
 		al.jsonError(w, err)
 		return
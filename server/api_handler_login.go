@@ -17,9 +17,10 @@ import (
 )
 
 type twoFAResponse struct {
-	SendTo         string `json:"send_to"`
-	DeliveryMethod string `json:"delivery_method"`
-	TotPKeyStatus  string `json:"totp_key_status"`
+	SendTo            string `json:"send_to"`
+	DeliveryMethod    string `json:"delivery_method"`
+	TotPKeyStatus     string `json:"totp_key_status"`
+	WebAuthnKeyStatus string `json:"webauthn_key_status,omitempty"`
 }
 
 type loginResponse struct {
@@ -63,6 +64,11 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
+	if err := al.userService.CheckLockout(req.Context(), username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	authorized, user, err := al.validateCredentials(username, pwd, skipPasswordValidation)
 	if err != nil {
 		al.jsonError(w, err)
@@ -75,10 +81,19 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 
 	if !authorized {
 		al.bannedUsers.Add(username)
+		if err := al.addFailedLogin(req.Context(), username); err != nil {
+			al.jsonError(w, err)
+			return
+		}
 		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
+	if err := al.userService.ResetFailedLogins(req.Context(), username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	lifetime, err := parseTokenLifetime(req)
 	if err != nil {
 		al.jsonErrorResponse(w, http.StatusBadRequest, err)
@@ -96,11 +111,20 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 			return
 		}
 		user.PasswordExpired = users.PasswordExpired(false) // from here on
-	}
-
-	if user.PasswordExpired != nil && *user.PasswordExpired {
-		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, ErrThatPasswordHasExpired.Error())
-		return
+	} else {
+		passwordExpired := user.PasswordExpired != nil && *user.PasswordExpired
+		// the max age only applies to passwords the user has entered
+		if !skipPasswordValidation {
+			passwordExpired, err = al.userService.IsPasswordExpired(req.Context(), user)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+		}
+		if passwordExpired {
+			al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, ErrThatPasswordHasExpired.Error())
+			return
+		}
 	}
 
 	if al.config.API.IsTwoFAOn() {
@@ -136,7 +160,7 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
-	if al.config.API.TotPEnabled {
+	if al.config.API.TotPEnabled || al.config.API.WebAuthnEnabled {
 		al.twoFASrv.SetTotPLoginSession(username, al.config.API.TotPLoginSessionTimeout)
 
 		loginResp := loginResponse{
@@ -145,20 +169,51 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 			},
 		}
 
-		totP, err := GetUsersTotPCode(user)
-		if err != nil {
-			al.Logf(logger.LogLevelError, "failed to get TotP secret: %v", err)
-			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
-			return
+		var totP *TotP
+		if al.config.API.TotPEnabled {
+			totP, err = GetUsersTotPCode(user)
+			if err != nil {
+				al.Logf(logger.LogLevelError, "failed to get TotP secret: %v", err)
+				al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+				return
+			}
+		} else {
+			loginResp.TwoFA.DeliveryMethod = "webauthn"
+		}
+
+		hasKeys := false
+		if al.config.API.WebAuthnEnabled {
+			hasKeys, err = al.webAuthnSrv.HasCredentials(req.Context(), username)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
 		}
 
+		// a second factor can be set up with the login token only if the user doesn't have one yet
+		setupAllowed := totP == nil && !hasKeys
 		scopes := bearer.Scopes2FaCheckOnly
-		if totP == nil {
-			// we allow access to totp-secret creation only if no totp secret was created before
-			scopes = append(scopes, bearer.ScopesTotPCreateOnly...)
-			loginResp.TwoFA.TotPKeyStatus = TotPKeyPending.String()
-		} else {
-			loginResp.TwoFA.TotPKeyStatus = TotPKeyExists.String()
+		if al.config.API.TotPEnabled {
+			if totP == nil {
+				loginResp.TwoFA.TotPKeyStatus = TotPKeyPending.String()
+			} else {
+				loginResp.TwoFA.TotPKeyStatus = TotPKeyExists.String()
+			}
+			if setupAllowed {
+				scopes = append(scopes, bearer.ScopesTotPCreateOnly...)
+			}
+		}
+		if al.config.API.WebAuthnEnabled {
+			if hasKeys {
+				loginResp.TwoFA.WebAuthnKeyStatus = TotPKeyExists.String()
+			} else {
+				loginResp.TwoFA.WebAuthnKeyStatus = TotPKeyPending.String()
+			}
+			// the assertion is also needed right after a key is registered with this token
+			scopes = append(scopes, bearer.ScopesWebAuthnAssertion...)
+			if setupAllowed {
+				scopes = append(scopes, bearer.ScopesWebAuthnRegisterOnly...)
+			}
 		}
 
 		// TotP token
@@ -14,12 +14,18 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/db/migration/api_token"
+	"github.com/openrport/openrport/db/migration/password_policy"
+	webauthnMigration "github.com/openrport/openrport/db/migration/webauthn"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/server/api"
 	"github.com/openrport/openrport/server/api/authorization"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
+	"github.com/openrport/openrport/server/cgroups"
 	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/webauthn"
 	"github.com/openrport/openrport/share/ptr"
 	"github.com/openrport/openrport/share/random"
 	"github.com/openrport/openrport/share/security"
@@ -499,6 +505,276 @@ func TestWrapWithAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestWrapWithAuthMiddlewareTokenRestrictions(t *testing.T) {
+	user := &users.User{
+		Username: "user1",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+	}
+	tokenProvider := CommonAPITokenTestDb(t, "user1", "theprefi", "the name", authorization.APITokenReadWrite, "mynicefi-xedl-enth-long-livedpasswor")
+	restrictions := &authorization.APITokenRestrictions{
+		Permissions: []string{users.PermissionScripts},
+		Routes:      []string{"POST /api/v1/library/scripts/*", "/api/v1/me"},
+		SourceCIDRs: []string{"10.0.0.0/8"},
+	}
+	err := tokenProvider.Save(context.Background(), &authorization.APIToken{
+		Username:     "user1",
+		Prefix:       "restrict",
+		Name:         "restricted",
+		Scope:        authorization.APITokenReadWrite,
+		Token:        "mynicefi-xedl-enth-long-livedpasswor",
+		Restrictions: restrictions,
+	})
+	require.NoError(t, err)
+
+	al := APIListener{
+		Logger:      testLog,
+		apiSessions: newEmptyAPISessionCache(t),
+		bannedUsers: security.NewBanList(0),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+		tokenManager: authorization.NewManager(tokenProvider),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+
+	testCases := []struct {
+		Name                 string
+		Method               string
+		URL                  string
+		RemoteAddr           string
+		ForwardedFor         string
+		Password             string
+		ExpectedStatus       int
+		ExpectedRestrictions *authorization.APITokenRestrictions
+	}{
+		{
+			Name:                 "allowed route",
+			Method:               http.MethodPost,
+			URL:                  "/api/v1/library/scripts/123",
+			RemoteAddr:           "10.1.2.3:1234",
+			Password:             "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedRestrictions: restrictions,
+		},
+		{
+			Name:           "route not allowed",
+			Method:         http.MethodDelete,
+			URL:            "/api/v1/library/scripts/123",
+			RemoteAddr:     "10.1.2.3:1234",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "source ip not allowed",
+			Method:         http.MethodGet,
+			URL:            "/api/v1/me",
+			RemoteAddr:     "192.168.1.1:1234",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:                 "source ip behind a proxy",
+			Method:               http.MethodPost,
+			URL:                  "/api/v1/library/scripts/123",
+			RemoteAddr:           "127.0.0.1:1234",
+			ForwardedFor:         "10.1.2.3",
+			Password:             "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus:       http.StatusOK,
+			ExpectedRestrictions: restrictions,
+		},
+		{
+			Name:           "source ip behind a proxy not allowed",
+			Method:         http.MethodPost,
+			URL:            "/api/v1/library/scripts/123",
+			RemoteAddr:     "10.1.2.3:1234",
+			ForwardedFor:   "203.0.113.5",
+			Password:       "restrict_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "unrestricted token",
+			Method:         http.MethodDelete,
+			URL:            "/api/v1/library/scripts/123",
+			RemoteAddr:     "192.168.1.1:1234",
+			Password:       "theprefi_mynicefi-xedl-enth-long-livedpasswor",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "password is not restricted",
+			Method:         http.MethodDelete,
+			URL:            "/api/v1/library/scripts/123",
+			RemoteAddr:     "192.168.1.1:1234",
+			Password:       "pwd",
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			handler := al.wrapWithAuthMiddleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.ExpectedRestrictions, api.GetTokenRestrictions(r.Context()))
+			}))
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.Method, tc.URL, nil)
+			req.RemoteAddr = tc.RemoteAddr
+			if tc.ForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.ForwardedFor)
+			}
+			req.SetBasicAuth(user.Username, tc.Password)
+
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+		})
+	}
+}
+
+func TestPermissionsMiddlewareTokenRestrictions(t *testing.T) {
+	user := &users.User{
+		Username: "user1",
+	}
+	al := APIListener{
+		Logger:      testLog,
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+		Server: &Server{
+			config: &chconfig.Config{},
+		},
+	}
+	handler := al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, permissions := range [][]string{nil, {users.PermissionCommands}, {users.PermissionScripts}} {
+		ctx := api.WithUser(context.Background(), user.Username)
+		if permissions != nil {
+			ctx = api.WithTokenRestrictions(ctx, &authorization.APITokenRestrictions{Permissions: permissions})
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/commands", nil).WithContext(ctx)
+
+		handler.ServeHTTP(w, req)
+
+		if len(permissions) == 1 && permissions[0] == users.PermissionScripts {
+			assert.Equal(t, http.StatusForbidden, w.Code)
+			assert.Contains(t, w.Body.String(), `the provided token does not have \"commands\" permission`)
+		} else {
+			assert.Equal(t, http.StatusOK, w.Code, permissions)
+		}
+	}
+}
+
+func TestGetTokensWithRestrictions(t *testing.T) {
+	user := &users.User{
+		Username: "user1",
+	}
+	tokenProvider := CommonAPITokenTestDb(t, "user1", "theprefi", "the name", authorization.APITokenRead, "mynicefi-xedl-enth-long-livedpasswor")
+	err := tokenProvider.Delete(context.Background(), "user1", "expired1")
+	require.NoError(t, err)
+	err = tokenProvider.Save(context.Background(), &authorization.APIToken{
+		Username:  "user1",
+		Prefix:    "restrict",
+		Name:      "restricted",
+		CreatedAt: ptr.Time(time.Date(2001, 1, 1, 1, 0, 0, 0, time.UTC)),
+		Scope:     authorization.APITokenReadWrite,
+		Token:     "mynicefi-xedl-enth-long-livedpasswor",
+		Restrictions: &authorization.APITokenRestrictions{
+			Permissions: []string{users.PermissionScripts},
+			ClientIDs:   []string{"client-1"},
+		},
+	})
+	require.NoError(t, err)
+
+	al := APIListener{
+		Logger:           testLog,
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+		tokenManager: authorization.NewManager(tokenProvider),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+	al.initRouter()
+
+	t.Run("get", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me/tokens", nil)
+		req = req.WithContext(api.WithUser(req.Context(), user.Username))
+		w := httptest.NewRecorder()
+
+		al.router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		allPermissions := `"auditlog":true,"commands":true,"monitoring":true,"scheduler":true,"scripts":true,"tunnels":true,"uploads":true,"vault":true,"files":true`
+		scriptsOnly := `"auditlog":false,"commands":false,"monitoring":false,"scheduler":false,"scripts":true,"tunnels":false,"uploads":false,"vault":false,"files":false`
+		assert.JSONEq(t, `{"data":[
+			{"prefix":"restrict","name":"restricted","created_at":"2001-01-01T01:00:00Z","expires_at":null,"scope":"read+write",
+			 "restrictions":{"permissions":["scripts"],"client_ids":["client-1"]},"effective_permissions":{`+scriptsOnly+`}},
+			{"prefix":"theprefi","name":"the name","created_at":"2001-01-01T01:00:00Z","expires_at":"2051-01-01T02:00:00Z","scope":"read",
+			 "restrictions":null,"effective_permissions":{`+allPermissions+`}}
+		]}`, w.Body.String())
+	})
+
+	t.Run("restricted token cannot manage tokens", func(t *testing.T) {
+		for _, tc := range []struct {
+			Method string
+			URL    string
+			Body   string
+		}{
+			{Method: http.MethodPost, URL: "/api/v1/me/tokens", Body: `{"scope":"read+write","name":"wider"}`},
+			{Method: http.MethodPut, URL: "/api/v1/me/tokens/restrict", Body: `{"name":"restricted","expires_at":"2099-01-01T00:00:00Z"}`},
+			{Method: http.MethodDelete, URL: "/api/v1/me/tokens/theprefi"},
+		} {
+			req := httptest.NewRequest(tc.Method, tc.URL, strings.NewReader(tc.Body))
+			ctx := api.WithUser(req.Context(), user.Username)
+			ctx = api.WithTokenRestrictions(ctx, &authorization.APITokenRestrictions{Permissions: []string{users.PermissionScripts}})
+			w := httptest.NewRecorder()
+
+			al.router.ServeHTTP(w, req.WithContext(ctx))
+
+			assert.Equal(t, http.StatusForbidden, w.Code, tc.Method)
+			assert.Contains(t, w.Body.String(), "the provided token is restricted and not allowed to manage tokens")
+		}
+	})
+
+	t.Run("create with invalid restrictions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/me/tokens", strings.NewReader(
+			`{"scope":"read","name":"invalid","restrictions":{"source_cidrs":["10.0.0.1"]}}`))
+		req = req.WithContext(api.WithUser(req.Context(), user.Username))
+		w := httptest.NewRecorder()
+
+		al.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `invalid source CIDR \"10.0.0.1\"`)
+	})
+}
+
+func TestCheckTokenClientsAccess(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Build()
+	c2 := clients.New(t).ID("client-2").Build()
+	c3 := clients.New(t).ID("client-3").Build()
+	clientGroups := []*cgroups.ClientGroup{
+		makeClientGroup("group-1", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}}),
+	}
+	al := APIListener{}
+
+	ctx := api.WithTokenRestrictions(context.Background(), &authorization.APITokenRestrictions{
+		ClientIDs:      []string{"client-1"},
+		ClientGroupIDs: []string{"group-1"},
+	})
+	assert.NoError(t, al.checkTokenClientsAccess(ctx, []*clientdata.Client{c1, c2}, clientGroups))
+	assert.EqualError(t, al.checkTokenClientsAccess(ctx, []*clientdata.Client{c1, c3}, clientGroups),
+		"The provided token is not allowed to access client(s) with ID(s): client-3")
+	assert.NoError(t, al.checkTokenClientsAccess(context.Background(), []*clientdata.Client{c3}, clientGroups))
+}
+
 func TestAPISessionUpdates(t *testing.T) {
 	ctx := context.Background()
 
@@ -762,3 +1038,176 @@ func TestHandleGetLogin(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleGetLoginWebAuthn(t *testing.T) {
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	userService := users.NewAPIService(users.NewStaticProvider([]*users.User{
+		{Username: "no-keys", Password: password},
+		{Username: "with-keys", Password: password},
+	}), false, 0, -1)
+
+	db, err := sqlite.New(":memory:", webauthnMigration.AssetNames(), webauthnMigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	webAuthnProvider := webauthn.NewSqliteProvider(db)
+	defer webAuthnProvider.Close()
+	require.NoError(t, webAuthnProvider.Create(context.Background(), &webauthn.Credential{
+		ID:        "a2V5MQ",
+		Username:  "with-keys",
+		PublicKey: []byte{0xa0},
+		CreatedAt: time.Now(),
+	}))
+	webAuthnSrv, err := webauthn.NewService(webauthn.Config{
+		RPID:    "rport.example.com",
+		RPName:  "RPort",
+		Origins: []string{"https://rport.example.com"},
+		Timeout: time.Minute,
+	}, webAuthnProvider)
+	require.NoError(t, err)
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes:         1024 * 1024,
+					TotPLoginSessionTimeout: time.Minute,
+					WebAuthnEnabled:         true,
+				},
+			},
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: userService,
+		apiSessions: newEmptyAPISessionCache(t),
+		twoFASrv:    NewTwoFAService(0, time.Second, userService, nil),
+		webAuthnSrv: webAuthnSrv,
+	}
+	al.initRouter()
+
+	testCases := []struct {
+		Username                   string
+		ExpectedKeyStatus          string
+		ExpectedRegistrationStatus int
+		ExpectedAssertionStatus    int
+	}{
+		{
+			Username:                   "no-keys",
+			ExpectedKeyStatus:          "pending",
+			ExpectedRegistrationStatus: http.StatusOK,
+			ExpectedAssertionStatus:    http.StatusConflict,
+		},
+		{
+			Username:                   "with-keys",
+			ExpectedKeyStatus:          "exists",
+			ExpectedRegistrationStatus: http.StatusUnauthorized,
+			ExpectedAssertionStatus:    http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Username, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+			req.SetBasicAuth(tc.Username, "pwd")
+			al.router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var resp struct {
+				Data loginResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Data.Token)
+			assert.Equal(t, &twoFAResponse{
+				DeliveryMethod:    "webauthn",
+				WebAuthnKeyStatus: tc.ExpectedKeyStatus,
+			}, resp.Data.TwoFA)
+
+			status := func(method, url string) int {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(method, url, nil)
+				req.Header.Set("Authorization", "Bearer "+*resp.Data.Token)
+				al.router.ServeHTTP(w, req)
+				return w.Code
+			}
+			assert.Equal(t, tc.ExpectedRegistrationStatus, status(http.MethodPost, "/api/v1/me/webauthn/registration"))
+			assert.Equal(t, tc.ExpectedAssertionStatus, status(http.MethodPost, "/api/v1/me/webauthn/assertion"))
+			assert.Equal(t, http.StatusUnauthorized, status(http.MethodGet, "/api/v1/me/webauthn/credentials"))
+			assert.Equal(t, http.StatusUnauthorized, status(http.MethodGet, "/api/v1/me"))
+		})
+	}
+}
+
+func TestHandleGetLoginUserLockout(t *testing.T) {
+	ctx := context.Background()
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	userService := users.NewAPIService(users.NewStaticProvider([]*users.User{
+		{Username: "user1", Password: password},
+	}), false, 0, -1)
+	db, err := sqlite.New(":memory:", password_policy.AssetNames(), password_policy.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	userService.PasswordPolicyProvider = users.NewPasswordPolicySqliteProvider(db)
+	defer userService.PasswordPolicyProvider.Close()
+	userService.PasswordPolicy = users.PasswordPolicy{LockoutThreshold: 2}
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes:      1024 * 1024,
+					UserLockoutThreshold: 2,
+				},
+			},
+		},
+		bannedUsers:  security.NewBanList(0),
+		userService:  userService,
+		apiSessions:  newEmptyAPISessionCache(t),
+		tokenManager: authorization.NewManager(CommonAPITokenTestDb(t, "user1", "theprefi", "token1", authorization.APITokenReadWrite, password)),
+	}
+	al.initRouter()
+
+	basicAuth := func(pwd string) (bool, error) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		authorized, _, _, err := al.handleBasicAuth(req, "user1", pwd)
+		return authorized, err
+	}
+
+	login := func(pwd string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+		req.SetBasicAuth("user1", pwd)
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	assert.Equal(t, http.StatusOK, login("pwd").Code)
+
+	// wrong API tokens are not counted as failed logins
+	for i := 0; i < 3; i++ {
+		authorized, err := basicAuth("theprefi_wrong")
+		require.NoError(t, err)
+		assert.False(t, authorized)
+	}
+	authorized, err := basicAuth("pwd")
+	require.NoError(t, err)
+	assert.True(t, authorized)
+
+	// a successful login resets the failed attempts
+	assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+
+	w := login("pwd")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "account is locked")
+
+	// a locked user can't use the password with basic auth, but API tokens still work
+	_, err = basicAuth("pwd")
+	assert.ErrorIs(t, err, users.ErrUserLocked)
+	authorized, err = basicAuth("theprefi_pwd")
+	require.NoError(t, err)
+	assert.True(t, authorized)
+
+	require.NoError(t, userService.Unlock(ctx, "user1"))
+	assert.Equal(t, http.StatusOK, login("pwd").Code)
+}
@@ -191,7 +191,12 @@ func (al *APIListener) handleChangeMe(w http.ResponseWriter, req *http.Request)
 			return
 		}
 
-		if !verifyPassword(curUser.Password, r.OldPassword) {
+		passwordOk, err := al.verifyUserPassword(curUser, r.OldPassword)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !passwordOk {
 			al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "Incorrect old password.")
 			return
 		}
@@ -223,6 +228,26 @@ func (al *APIListener) handleGetIP(w http.ResponseWriter, req *http.Request) {
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(ipResp))
 }
 
+func (al *APIListener) handleGetMeEffectivePermissions(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	explained, err := al.userService.ExplainPermissions(user)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(explained))
+}
+
 func (al *APIListener) handleTokenGone(w http.ResponseWriter, req *http.Request) {
 	al.jsonErrorResponseWithTitle(w, http.StatusGone, "use new token management on /me/tokens")
 }
@@ -243,6 +268,9 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 		CreatedAt *time.Time                  `json:"created_at" db:"created_at"`
 		ExpiresAt *time.Time                  `json:"expires_at" db:"expires_at"`
 		Scope     authorization.APITokenScope `json:"scope" db:"scope"`
+		// Restrictions and EffectivePermissions are the effective scope, permissions are limited by the user's groups
+		Restrictions         *authorization.APITokenRestrictions `json:"restrictions"`
+		EffectivePermissions map[string]bool                     `json:"effective_permissions"`
 	}
 
 	apitokenset, err := al.tokenManager.GetAll(req.Context(), user.Username)
@@ -251,15 +279,27 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	userPermissions, err := al.userService.GetEffectiveUserPermissions(user)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	apiTokenToSend := make([]APITokenPayload, 0, len(apitokenset))
 	for _, at := range apitokenset {
+		effectivePermissions := make(map[string]bool, len(userPermissions))
+		for permission, granted := range userPermissions {
+			effectivePermissions[permission] = granted && at.Restrictions.HasPermission(permission)
+		}
 		apiTokenToSend = append(apiTokenToSend,
 			APITokenPayload{
-				Prefix:    at.Prefix,
-				Name:      at.Name,
-				CreatedAt: at.CreatedAt,
-				ExpiresAt: at.ExpiresAt,
-				Scope:     at.Scope,
+				Prefix:               at.Prefix,
+				Name:                 at.Name,
+				CreatedAt:            at.CreatedAt,
+				ExpiresAt:            at.ExpiresAt,
+				Scope:                at.Scope,
+				Restrictions:         at.Restrictions,
+				EffectivePermissions: effectivePermissions,
 			})
 	}
 
@@ -278,9 +318,10 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	var r struct {
-		Scope     authorization.APITokenScope `json:"scope"`
-		Name      string                      `json:"name"`
-		ExpiresAt *time.Time                  `json:"expires_at"`
+		Scope        authorization.APITokenScope         `json:"scope"`
+		Name         string                              `json:"name"`
+		ExpiresAt    *time.Time                          `json:"expires_at"`
+		Restrictions *authorization.APITokenRestrictions `json:"restrictions"`
 	}
 	err = parseRequestBody(req.Body, &r)
 	if err != nil {
@@ -303,6 +344,24 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if r.Restrictions != nil {
+		if err := r.Restrictions.Validate(); err != nil {
+			al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, "", "invalid restrictions.", err.Error())
+			return
+		}
+		userPermissions, err := al.userService.GetEffectiveUserPermissions(user)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		for _, permission := range r.Restrictions.Permissions {
+			if !userPermissions[permission] {
+				al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("current user does not have %q permission", permission))
+				return
+			}
+		}
+	}
+
 	createdAt := ptr.Time(time.Now().Truncate(time.Second).UTC())
 	if r.ExpiresAt == nil {
 		r.ExpiresAt = ptr.Time(createdAt.AddDate(1 /* year */, 0, 0)) // expiry date default is creation date + one year
@@ -322,13 +381,14 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 	}
 
 	newAPIToken := &authorization.APIToken{
-		Username:  user.Username,
-		Prefix:    newPrefix,
-		Name:      r.Name,
-		Scope:     r.Scope,
-		CreatedAt: createdAt,
-		ExpiresAt: r.ExpiresAt,
-		Token:     tokenHashStr,
+		Username:     user.Username,
+		Prefix:       newPrefix,
+		Name:         r.Name,
+		Scope:        r.Scope,
+		CreatedAt:    createdAt,
+		ExpiresAt:    r.ExpiresAt,
+		Token:        tokenHashStr,
+		Restrictions: r.Restrictions,
 	}
 	err = al.tokenManager.Create(req.Context(), newAPIToken)
 	if err != nil {
@@ -345,10 +405,11 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(
 		authorization.APIToken{
-			ExpiresAt: r.ExpiresAt,
-			Scope:     r.Scope,
-			Token:     fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
-			Prefix:    newPrefix,
+			ExpiresAt:    r.ExpiresAt,
+			Scope:        r.Scope,
+			Token:        fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
+			Prefix:       newPrefix,
+			Restrictions: r.Restrictions,
 		}))
 }
 
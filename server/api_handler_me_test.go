@@ -52,6 +52,7 @@ func TestHandleMeStaticAuth(t *testing.T) {
 			"two_fa_send_to": "",
 			"effective_user_permissions": {
 				"auditlog": true,
+				"files": true,
 				"commands": true,
 				"monitoring": true,
 				"scheduler": true,
@@ -138,6 +139,7 @@ func TestHandleMeDBAuth(t *testing.T) {
 			"two_fa_send_to": "",
 			"effective_user_permissions": {
 				"auditlog": false,
+				"files": false,
 				"commands": false,
 				"monitoring": true,
 				"scheduler": false,
@@ -164,6 +164,21 @@ func (al *APIListener) handleGetClientMountpoints(w http.ResponseWriter, req *ht
 	al.writeJSONResponse(w, http.StatusOK, payload)
 }
 
+// handleGetClientTunnelUsage handles GET /clients/{client_id}/tunnel-usage
+func (al *APIListener) handleGetClientTunnelUsage(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+
+	queryOptions := query.NewOptions(req, monitoring.ClientTunnelUsageSortDefault, monitoring.ClientTunnelUsageFilterDefault, monitoring.ClientTunnelUsageFieldsDefault)
+
+	payload, err := al.monitoringService.ListClientTunnelUsage(req.Context(), clientID, queryOptions)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, payload)
+}
+
 // handleMonitoringDisabled returns Not Found (404) when monitoring is disabled
 func (al *APIListener) handleMonitoringDisabled(w http.ResponseWriter, req *http.Request) {
 	al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "monitoring disabled. re-enable to view monitoring statistics.")
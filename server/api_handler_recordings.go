@@ -0,0 +1,240 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/recordings"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/query"
+)
+
+const (
+	defaultReplaySpeed         = 1
+	defaultReplayIdleTimeLimit = 2 * time.Second
+)
+
+// handleListRecordings handles GET /recordings
+func (al *APIListener) handleListRecordings(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	options := query.GetListOptions(req)
+	if !curUser.IsAdmin() {
+		// Deny none-admins looking for foreign recordings, like in the audit log
+		for _, v := range options.Filters {
+			for _, col := range v.Column {
+				if col == "username" {
+					al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "only members of group Administrators can filter by usernames")
+					return
+				}
+			}
+		}
+		options.Filters = append(options.Filters, query.FilterOption{
+			Column: []string{"username"},
+			Values: []string{curUser.Username},
+		})
+	}
+	err = query.ValidateListOptions(options, recordings.SupportedSorts, recordings.SupportedFilters, nil, &query.PaginationConfig{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	entries, err := al.recordings.List(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	totalCount := len(entries)
+	start, end := options.Pagination.GetStartEnd(totalCount)
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: entries[start:end],
+		Meta: api.NewMeta(totalCount),
+	})
+}
+
+// handleGetRecording handles GET /recordings/{recording_id}
+func (al *APIListener) handleGetRecording(w http.ResponseWriter, req *http.Request) {
+	rec := al.getAccessibleRecording(w, req)
+	if rec == nil {
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rec))
+}
+
+// handleDownloadRecording handles GET /recordings/{recording_id}/cast
+func (al *APIListener) handleDownloadRecording(w http.ResponseWriter, req *http.Request) {
+	rec := al.getAccessibleRecording(w, req)
+	if rec == nil {
+		return
+	}
+
+	f, err := al.recordings.Open(rec.ID)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to open recording.", err)
+		return
+	}
+	defer f.Close()
+
+	modTime := rec.StartedAt
+	if rec.FinishedAt != nil {
+		modTime = *rec.FinishedAt
+	}
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rec.ID+".cast"))
+	http.ServeContent(w, req, rec.ID+".cast", modTime, f)
+}
+
+// handleReplayRecordingWS handles GET /ws/recordings/{recording_id}/replay
+// It sends the output of the recording with its original timing using the messages of the shell websocket.
+func (al *APIListener) handleReplayRecordingWS(w http.ResponseWriter, req *http.Request) {
+	rec := al.getAccessibleRecording(w, req)
+	if rec == nil {
+		return
+	}
+
+	speed, err := parsePositiveFloat(req, "speed", defaultReplaySpeed)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idleTimeLimit, err := parsePositiveFloat(req, "idle_time_limit", defaultReplayIdleTimeLimit.Seconds())
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	f, err := al.recordings.Open(rec.ID)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to open recording.", err)
+		return
+	}
+	defer f.Close()
+
+	cast, err := recordings.NewCastReader(f)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to read recording.", err)
+		return
+	}
+
+	uiConn, err := apiUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		al.Errorf("Failed to establish WS connection: %v", err)
+		return
+	}
+	defer uiConn.Close()
+
+	// the UI only closes the connection, reading detects it to stop the replay
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := uiConn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = replayCast(cast, uiConn, speed, time.Duration(idleTimeLimit*float64(time.Second)), closed)
+	if err != nil {
+		al.Debugf("Replay of recording %s stopped: %v", rec.ID, err)
+		return
+	}
+	_ = uiConn.WriteJSON(shellMessage{Type: shellMessageExit})
+}
+
+func replayCast(cast *recordings.CastReader, uiConn shellConn, speed float64, idleTimeLimit time.Duration, closed <-chan struct{}) error {
+	err := uiConn.WriteJSON(shellMessage{Type: shellMessageResize, Cols: cast.Header.Width, Rows: cast.Header.Height})
+	if err != nil {
+		return err
+	}
+
+	var last time.Duration
+	for {
+		e, err := cast.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		wait := e.Elapsed() - last
+		last = e.Elapsed()
+		if wait > idleTimeLimit {
+			wait = idleTimeLimit
+		}
+		select {
+		case <-time.After(time.Duration(float64(wait) / speed)):
+		case <-closed:
+			return errors.New("connection closed")
+		}
+
+		switch e.Type {
+		case recordings.EventOutput:
+			err = uiConn.WriteMessage(websocket.BinaryMessage, []byte(e.Data))
+		case recordings.EventResize:
+			var cols, rows uint32
+			if _, scanErr := fmt.Sscanf(e.Data, "%dx%d", &cols, &rows); scanErr == nil {
+				err = uiConn.WriteJSON(shellMessage{Type: shellMessageResize, Cols: cols, Rows: rows})
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// getAccessibleRecording returns the recording of the request, if the current user is allowed to access it.
+// Otherwise an error response is written and nil returned. Non-admins can only access their own recordings.
+func (al *APIListener) getAccessibleRecording(w http.ResponseWriter, req *http.Request) *recordings.Recording {
+	id := mux.Vars(req)[routes.ParamRecordingID]
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
+
+	rec, err := al.recordings.Get(id)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
+	if rec == nil || (!curUser.IsAdmin() && rec.Username != curUser.Username) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Recording with id %q not found.", id))
+		return nil
+	}
+
+	return rec
+}
+
+func parsePositiveFloat(req *http.Request, param string, defaultValue float64) (float64, error) {
+	value := req.URL.Query().Get(param)
+	if value == "" {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid %q: expected a positive number, got %q", param, value)
+	}
+	return f, nil
+}
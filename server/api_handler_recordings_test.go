@@ -0,0 +1,75 @@
+package chserver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/recordings"
+)
+
+type replayUIConnMock struct {
+	messages []shellMessage
+}
+
+func (c *replayUIConnMock) ReadMessage() (int, []byte, error) {
+	return 0, nil, errors.New("not implemented")
+}
+
+func (c *replayUIConnMock) WriteMessage(messageType int, data []byte) error {
+	if messageType != websocket.BinaryMessage {
+		return errors.New("unexpected message type")
+	}
+	c.messages = append(c.messages, shellMessage{Type: "output", Data: string(data)})
+	return nil
+}
+
+func (c *replayUIConnMock) WriteJSON(v interface{}) error {
+	c.messages = append(c.messages, v.(shellMessage))
+	return nil
+}
+
+func (c *replayUIConnMock) Close() error {
+	return nil
+}
+
+func TestReplayCast(t *testing.T) {
+	cast, err := recordings.NewCastReader(strings.NewReader(`{"version":2,"width":80,"height":24}
+[0.001,"o","$ "]
+[0.002,"i","ls\r"]
+[0.003,"r","120x40"]
+[3600,"o","file.txt\r\n"]
+`))
+	require.NoError(t, err)
+
+	uiConn := &replayUIConnMock{}
+	start := time.Now()
+	// the pause of an hour is shortened to the idle time limit
+	err = replayCast(cast, uiConn, 2, 10*time.Millisecond, make(chan struct{}))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.Equal(t, []shellMessage{
+		{Type: shellMessageResize, Cols: 80, Rows: 24},
+		{Type: "output", Data: "$ "},
+		{Type: shellMessageResize, Cols: 120, Rows: 40},
+		{Type: "output", Data: "file.txt\r\n"},
+	}, uiConn.messages)
+}
+
+func TestReplayCastClosed(t *testing.T) {
+	cast, err := recordings.NewCastReader(strings.NewReader(`{"version":2,"width":80,"height":24}
+[1,"o","$ "]
+`))
+	require.NoError(t, err)
+
+	closed := make(chan struct{})
+	close(closed)
+	err = replayCast(cast, &replayUIConnMock{}, 1, time.Minute, closed)
+	assert.EqualError(t, err, "connection closed")
+}
@@ -0,0 +1,167 @@
+package chserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/share/comm"
+)
+
+const (
+	ErrCodeReverseTunnelNotAllowed = "ERR_CODE_REVERSE_TUNNEL_NOT_ALLOWED"
+
+	reverseTunnelDefaultLocalHost = "127.0.0.1"
+)
+
+// handleGetReverseTunnels handles GET /clients/{client_id}/reverse-tunnels
+func (al *APIListener) handleGetReverseTunnels(w http.ResponseWriter, req *http.Request) {
+	client, ok := al.getActiveClientForReverseTunnel(w, req)
+	if !ok {
+		return
+	}
+
+	tunnels := client.GetReverseTunnels()
+	if tunnels == nil {
+		tunnels = []*clienttunnel.ReverseTunnel{}
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tunnels))
+}
+
+// handlePutReverseTunnel handles PUT /clients/{client_id}/reverse-tunnels
+// The client listens on "local" and forwards all connections to the server-side "remote", which must be allowed by
+// 'reverse_tunnel_allowed'.
+func (al *APIListener) handlePutReverseTunnel(w http.ResponseWriter, req *http.Request) {
+	client, ok := al.getActiveClientForReverseTunnel(w, req)
+	if !ok {
+		return
+	}
+
+	local := req.URL.Query().Get("local")
+	if local == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Missing local.")
+		return
+	}
+	if _, _, err := net.SplitHostPort(local); err != nil {
+		// only a port is given
+		local = net.JoinHostPort(reverseTunnelDefaultLocalHost, local)
+	}
+	if _, _, err := net.SplitHostPort(local); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid local %q, expected '[<host>:]<port>'.", local))
+		return
+	}
+
+	remote := req.URL.Query().Get("remote")
+	if _, _, err := net.SplitHostPort(remote); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid remote %q, expected '<host>:<port>'.", remote))
+		return
+	}
+
+	allowed, err := al.config.Server.IsReverseTunnelAllowed(remote)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, fmt.Sprintf("Failed to check remote %q.", remote), err)
+		return
+	}
+	if !allowed {
+		al.jsonErrorResponseWithErrCode(w, http.StatusForbidden, ErrCodeReverseTunnelNotAllowed, fmt.Sprintf("Reverse tunnel to %q is not allowed by the server configuration.", remote))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	tunnel := &clienttunnel.ReverseTunnel{
+		ID:        client.NewTunnelID(),
+		Name:      req.URL.Query().Get("name"),
+		Local:     local,
+		Remote:    remote,
+		Owner:     curUser.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+	// added before starting, so no connection is rejected while the client already listens
+	client.AddReverseTunnel(tunnel)
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeStartReverseTunnel, comm.StartReverseTunnelRequest{
+		ID:    tunnel.ID,
+		Local: tunnel.Local,
+	}, nil, al.Log())
+	if err != nil {
+		client.RemoveReverseTunnel(tunnel.ID)
+		if _, ok := err.(*comm.ClientError); ok {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
+		} else {
+			al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to start reverse tunnel.", err)
+		}
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientReverseTunnel, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnel.ID).
+		WithRequest(tunnel).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tunnel))
+}
+
+// handleDeleteReverseTunnel handles DELETE /clients/{client_id}/reverse-tunnels/{tunnel_id}
+func (al *APIListener) handleDeleteReverseTunnel(w http.ResponseWriter, req *http.Request) {
+	client, ok := al.getActiveClientForReverseTunnel(w, req)
+	if !ok {
+		return
+	}
+
+	tunnelID := mux.Vars(req)["tunnel_id"]
+	tunnel := client.FindReverseTunnel(tunnelID)
+	if tunnel == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "reverse tunnel not found")
+		return
+	}
+
+	// removed first, so no further connections are accepted even if the client fails to stop listening
+	client.RemoveReverseTunnel(tunnel.ID)
+
+	err := comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeStopReverseTunnel, comm.StopReverseTunnelRequest{
+		ID: tunnel.ID,
+	}, nil, al.Log())
+	if err != nil {
+		client.Log().Errorf("Failed to stop reverse tunnel %s: %v", tunnel.ID, err)
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientReverseTunnel, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnel.ID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (al *APIListener) getActiveClientForReverseTunnel(w http.ResponseWriter, req *http.Request) (*clientdata.Client, bool) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return nil, false
+	}
+
+	return client, true
+}
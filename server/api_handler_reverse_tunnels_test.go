@@ -0,0 +1,136 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/test"
+)
+
+func TestHandleReverseTunnels(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	user := &users.User{
+		Username: "test-user",
+	}
+	c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).Logger(testLog).Build()
+	c1.SetConnection(connMock)
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: &SimpleMockClientService{
+				ActiveClients: []*clientdata.Client{c1},
+			},
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{
+					ReverseTunnelAllowed: []string{"127.0.0.1:5432"},
+				},
+			},
+		},
+		userService: &MockUsersService{
+			UserService: users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, nil)
+		req = req.WithContext(api.WithUser(req.Context(), user.Username))
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/api/v1/clients/client-1/reverse-tunnels?local=15432&remote=127.0.0.1:3306")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), ErrCodeReverseTunnelNotAllowed)
+
+	w = do(http.MethodPut, "/api/v1/clients/client-1/reverse-tunnels?local=15432&remote=5432")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPut, "/api/v1/clients/client-1/reverse-tunnels?local=15432&remote=127.0.0.1:5432&name=db")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data *clienttunnel.ReverseTunnel `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "db", resp.Data.Name)
+	assert.Equal(t, "127.0.0.1:15432", resp.Data.Local)
+	assert.Equal(t, "127.0.0.1:5432", resp.Data.Remote)
+	assert.Equal(t, user.Username, resp.Data.Owner)
+
+	name, _, payload := connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeStartReverseTunnel, name)
+	assert.JSONEq(t, `{"ID":"`+resp.Data.ID+`","Local":"127.0.0.1:15432"}`, string(payload))
+
+	w = do(http.MethodGet, "/api/v1/clients/client-1/reverse-tunnels")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"remote":"127.0.0.1:5432"`)
+
+	w = do(http.MethodDelete, "/api/v1/clients/client-1/reverse-tunnels/"+resp.Data.ID)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	name, _, _ = connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeStopReverseTunnel, name)
+	assert.Empty(t, c1.GetReverseTunnels())
+
+	w = do(http.MethodDelete, "/api/v1/clients/client-1/reverse-tunnels/"+resp.Data.ID)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+type newChannelMock struct {
+	ssh.NewChannel
+	extraData []byte
+
+	rejected ssh.RejectionReason
+}
+
+func (c *newChannelMock) ChannelType() string {
+	return comm.ChannelTypeReverseTunnel
+}
+
+func (c *newChannelMock) ExtraData() []byte {
+	return c.extraData
+}
+
+func (c *newChannelMock) Reject(reason ssh.RejectionReason, message string) error {
+	c.rejected = reason
+	return nil
+}
+
+func TestHandleReverseTunnelChannelRejected(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c1.AddReverseTunnel(&clienttunnel.ReverseTunnel{ID: "1", Remote: "127.0.0.1:5432"})
+
+	cl := &ClientListener{
+		server: &Server{
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{
+					ReverseTunnelAllowed: []string{"127.0.0.1:3306"},
+				},
+			},
+		},
+	}
+
+	unknown := &newChannelMock{extraData: []byte("2")}
+	cl.handleReverseTunnelChannel(testLog, c1, unknown)
+	assert.Equal(t, ssh.ConnectionFailed, unknown.rejected)
+
+	notAllowed := &newChannelMock{extraData: []byte("1")}
+	cl.handleReverseTunnelChannel(testLog, c1, notAllowed)
+	assert.Equal(t, ssh.Prohibited, notAllowed.rejected)
+}
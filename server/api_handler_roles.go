@@ -0,0 +1,106 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/auditlog"
+)
+
+func (al *APIListener) handleListRoles(w http.ResponseWriter, req *http.Request) {
+	roles, err := al.userService.ListRoles(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(roles))
+}
+
+func (al *APIListener) handleGetRole(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name := vars["role_name"]
+
+	role, err := al.userService.GetRole(req.Context(), name)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if role == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("role %q not found", name))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(role))
+}
+
+func (al *APIListener) handlePutRole(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name := vars["role_name"]
+
+	var input users.Role
+	err := parseRequestBody(req.Body, &input)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	input.Name = name
+
+	existing, err := al.userService.GetRole(req.Context(), name)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.userService.SaveRole(req.Context(), &input)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	action := auditlog.ActionUpdate
+	status := http.StatusOK
+	if existing == nil {
+		action = auditlog.ActionCreate
+		status = http.StatusCreated
+	}
+	al.auditLog.Entry(auditlog.ApplicationAuthUserRole, action).
+		WithHTTPRequest(req).
+		WithRequest(input).
+		WithID(name).
+		Save()
+
+	al.writeJSONResponse(w, status, api.NewSuccessPayload(input))
+}
+
+func (al *APIListener) handleDeleteRole(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name := vars["role_name"]
+
+	existing, err := al.userService.GetRole(req.Context(), name)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("role %q not found", name))
+		return
+	}
+
+	err = al.userService.DeleteRole(req.Context(), name)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserRole, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(name).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
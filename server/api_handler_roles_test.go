@@ -0,0 +1,307 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/roles"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+)
+
+// newRolesTestUserService returns a user service with group permissions, "test-user" is a member of "ops" which
+// only has the monitoring permission. "role-user" is a member of "roles-only" without any permissions.
+func newRolesTestUserService(t *testing.T, givenRoles ...*users.Role) *users.APIService {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	sqlExecs := []string{
+		`CREATE TABLE "users" ("username" TEXT PRIMARY KEY, "password" TEXT, "password_expired" BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0)`,
+		`INSERT INTO "users" VALUES("test-user","1", false)`,
+		`CREATE TABLE "groups" ("username" TEXT, "group" TEXT)`,
+		`INSERT INTO "users" VALUES("role-user","1", false)`,
+		`INSERT INTO "groups" VALUES("test-user","ops")`,
+		`INSERT INTO "groups" VALUES("role-user","roles-only")`,
+		`CREATE TABLE "group_details" ("name" TEXT, "permissions" TEXT)`,
+		`CREATE UNIQUE INDEX "main"."username_group_name" ON "group_details" ("name" ASC)`,
+		`INSERT INTO "group_details" VALUES('ops','{"monitoring": true}')`,
+	}
+	for _, sqlExec := range sqlExecs {
+		_, err = db.Exec(sqlExec)
+		require.NoError(t, err)
+	}
+	userProvider, err := users.NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	require.NoError(t, err)
+
+	rolesDB, err := sqlite.New(":memory:", roles.AssetNames(), roles.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	roleProvider := users.NewRoleSqliteProvider(rolesDB)
+	t.Cleanup(func() { roleProvider.Close() })
+
+	userService := users.NewAPIService(userProvider, false, 0, -1)
+	userService.RoleProvider = roleProvider
+	for _, role := range givenRoles {
+		require.NoError(t, userService.SaveRole(context.Background(), role))
+	}
+	return userService
+}
+
+func TestPermissionsMiddlewareResourceScope(t *testing.T) {
+	hour := time.Hour
+	linux := clients.New(t).ID("linux-1").Logger(testLog).Build()
+	linux.Tags = []string{"linux"}
+	windows := clients.New(t).ID("windows-1").Logger(testLog).Build()
+	windows.Tags = []string{"windows"}
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config:              &chconfig.Config{},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{linux, windows}, &hour, testLog), testLog, nil),
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: newRolesTestUserService(t, &users.Role{
+			Name:        "linux-scripts",
+			Permissions: users.NewPermissions(users.PermissionScripts),
+			Resources: users.RoleResources{
+				ClientTags: []string{"linux"},
+				ScriptIDs:  []string{"script-1"},
+			},
+			UserGroups: []string{"ops"},
+		}),
+	}
+
+	var scope *users.RoleResources
+	router := mux.NewRouter()
+	router.Use(al.permissionsMiddleware(users.PermissionScripts))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		scope = api.GetResourceScope(r.Context())
+	}
+	router.HandleFunc("/clients/{client_id}/scripts", handler).Methods(http.MethodPost)
+	router.HandleFunc("/library/scripts", handler).Methods(http.MethodPost)
+	router.HandleFunc("/library/scripts/{script_value_id}", handler).Methods(http.MethodGet)
+
+	testCases := []struct {
+		Method         string
+		URL            string
+		ExpectedStatus int
+	}{
+		{http.MethodPost, "/clients/linux-1/scripts", http.StatusOK},
+		{http.MethodPost, "/clients/windows-1/scripts", http.StatusForbidden},
+		{http.MethodGet, "/library/scripts/script-1", http.StatusOK},
+		{http.MethodGet, "/library/scripts/script-2", http.StatusForbidden},
+		{http.MethodPost, "/library/scripts", http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Method+" "+tc.URL, func(t *testing.T) {
+			scope = nil
+			req := httptest.NewRequest(tc.Method, tc.URL, nil)
+			req = req.WithContext(api.WithUser(req.Context(), "test-user"))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code, w.Body.String())
+			if tc.ExpectedStatus == http.StatusOK {
+				assert.Equal(t, []string{"linux"}, scope.ClientTags)
+			}
+		})
+	}
+}
+
+func TestCheckScopeClientsAccess(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Build()
+	c1.Tags = []string{"linux"}
+	c2 := clients.New(t).ID("client-2").Build()
+	c3 := clients.New(t).ID("client-3").Build()
+	clientGroups := []*cgroups.ClientGroup{
+		makeClientGroup("group-1", &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}}),
+	}
+	al := APIListener{}
+
+	ctx := api.WithResourceScope(context.Background(), &users.RoleResources{
+		ClientTags:     []string{"linux"},
+		ClientGroupIDs: []string{"group-1"},
+	})
+	assert.NoError(t, al.checkScopeClientsAccess(ctx, []*clientdata.Client{c1, c2}, clientGroups))
+	assert.EqualError(t, al.checkScopeClientsAccess(ctx, []*clientdata.Client{c1, c3}, clientGroups),
+		"The roles of the current user do not allow to access client(s) with ID(s): client-3")
+	assert.NoError(t, al.checkScopeClientsAccess(context.Background(), []*clientdata.Client{c3}, clientGroups))
+}
+
+func TestHandleRoles(t *testing.T) {
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+		userService: newRolesTestUserService(t),
+	}
+	al.initRouter()
+
+	serve := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req = req.WithContext(api.WithUser(req.Context(), "test-user"))
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(http.MethodPut, "/api/v1/roles/vault-readers", `{
+		"description": "Read database credentials",
+		"permissions": {"vault": true},
+		"resources": {"vault_keys": ["db-*"]},
+		"user_groups": ["ops"]
+	}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = serve(http.MethodPut, "/api/v1/roles/invalid", `{"resources": {"vault_keys": ["*-db"]}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(http.MethodPut, "/api/v1/roles/unknown-group", `{"user_groups": ["unknown"]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "user groups not found: unknown")
+
+	w = serve(http.MethodGet, "/api/v1/roles/vault-readers", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	allPermissions := `"auditlog":false,"commands":false,"monitoring":false,"scheduler":false,"scripts":false,"tunnels":false,"uploads":false,"files":false`
+	assert.JSONEq(t, `{"data":{
+		"name":"vault-readers",
+		"description":"Read database credentials",
+		"permissions":{`+allPermissions+`,"vault":true},
+		"resources":{"vault_keys":["db-*"]},
+		"user_groups":["ops"]
+	}}`, w.Body.String())
+
+	w = serve(http.MethodGet, "/api/v1/me/effective-permissions", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var explained struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &explained))
+	assert.JSONEq(t, `{
+		"granted":true,
+		"user_groups":[],
+		"roles":["vault-readers"],
+		"resources":{"vault_keys":["db-*"]}
+	}`, string(explained.Data["vault"]))
+	assert.JSONEq(t, `{
+		"granted":true,
+		"user_groups":["ops"],
+		"roles":[],
+		"resources":null
+	}`, string(explained.Data["monitoring"]))
+
+	w = serve(http.MethodDelete, "/api/v1/roles/vault-readers", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve(http.MethodDelete, "/api/v1/roles/vault-readers", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = serve(http.MethodGet, "/api/v1/roles", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+}
+
+func TestClientScopeOfRoles(t *testing.T) {
+	hour := time.Hour
+	linux := clients.New(t).ID("linux-1").AllowedUserGroups([]string{"ops", "roles-only"}).Logger(testLog).Build()
+	linux.Tags = []string{"linux"}
+	db := clients.New(t).ID("db-1").AllowedUserGroups([]string{"ops", "roles-only"}).Logger(testLog).Build()
+	db.Tags = []string{"db"}
+	windows := clients.New(t).ID("windows-1").AllowedUserGroups([]string{"ops", "roles-only"}).Logger(testLog).Build()
+	windows.Tags = []string{"windows"}
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{linux, db, windows}, &hour, testLog), testLog, nil),
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: newRolesTestUserService(t,
+			&users.Role{
+				Name:        "linux-scripts",
+				Permissions: users.NewPermissions(users.PermissionScripts),
+				Resources:   users.RoleResources{ClientTags: []string{"linux"}},
+				UserGroups:  []string{"roles-only"},
+			},
+			&users.Role{
+				Name:        "db-commands",
+				Permissions: users.NewPermissions(users.PermissionCommands),
+				Resources:   users.RoleResources{ClientTags: []string{"db"}},
+				UserGroups:  []string{"roles-only"},
+			},
+		),
+	}
+
+	t.Run("list", func(t *testing.T) {
+		for username, expectedIDs := range map[string][]string{
+			"role-user": {"db-1", "linux-1"},
+			// the monitoring permission of the user group is granted for all clients
+			"test-user": {"db-1", "linux-1", "windows-1"},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/clients?sort=id", nil)
+			req = req.WithContext(api.WithUser(req.Context(), username))
+			w := httptest.NewRecorder()
+
+			al.handleGetClients(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			var resp struct {
+				Data []struct {
+					ID string `json:"id"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			var gotIDs []string
+			for _, c := range resp.Data {
+				gotIDs = append(gotIDs, c.ID)
+			}
+			assert.Equal(t, expectedIDs, gotIDs, username)
+		}
+	})
+
+	t.Run("single client", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Handle("/clients/{client_id}", al.wrapClientAccessMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+		for clientID, expectedStatus := range map[string]int{
+			"linux-1":   http.StatusOK,
+			"db-1":      http.StatusOK,
+			"windows-1": http.StatusForbidden,
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/clients/"+clientID, nil)
+			req = req.WithContext(api.WithUser(req.Context(), "role-user"))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, expectedStatus, w.Code, clientID)
+		}
+	})
+}
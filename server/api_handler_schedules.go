@@ -49,7 +49,7 @@ func (al *APIListener) prepareHandleSchedules(req *http.Request) (schedule.Sched
 	if err != nil {
 		return scheduleInput, username, orderedClients, err
 	}
-	err = al.clientService.CheckClientsAccess(orderedClients, curUser, clientGroups)
+	err = al.checkClientsAccess(ctx, orderedClients, curUser, clientGroups)
 	if err != nil {
 		return scheduleInput, username, orderedClients, err
 	}
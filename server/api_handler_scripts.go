@@ -92,7 +92,7 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 	if err != nil {
 		al.jsonError(w, err)
 	}
-	err = al.clientService.CheckClientsAccess(inboundMsg.OrderedClients, curUser, clientGroups)
+	err = al.checkClientsAccess(ctx, inboundMsg.OrderedClients, curUser, clientGroups)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -0,0 +1,299 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/recordings"
+	"github.com/openrport/openrport/server/routes"
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const (
+	shellMessageInput  = "input"
+	shellMessageResize = "resize"
+	shellMessageExit   = "exit"
+
+	defaultShellCols = 80
+	defaultShellRows = 24
+
+	// shellTerm has to match the TERM the client sets for the shell
+	shellTerm = "xterm-256color"
+)
+
+// shellMessage is a control message of a shell websocket sent as JSON text message.
+// Terminal output is sent to the UI as binary messages.
+type shellMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	Cols     uint32 `json:"cols,omitempty"`
+	Rows     uint32 `json:"rows,omitempty"`
+	ExitCode *int   `json:"exit_code,omitempty"`
+}
+
+// handleShellWS handles GET /ws/clients/{client_id}/shell
+func (al *APIListener) handleShellWS(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Active client with id=%q not found.", clientID))
+		return
+	}
+
+	shellReq := comm.ShellRequest{
+		Shell: req.URL.Query().Get("shell"),
+	}
+	if shellReq.Cols, err = parseShellSize(req, "cols", defaultShellCols); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if shellReq.Rows, err = parseShellSize(req, "rows", defaultShellRows); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	payload, err := json.Marshal(shellReq)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var recorder *recordings.Recorder
+	if al.config.API.Recordings.RecordSessions {
+		env := map[string]string{"TERM": shellTerm}
+		if shellReq.Shell != "" {
+			env["SHELL"] = shellReq.Shell
+		}
+		recorder, err = al.recordings.Start(&recordings.Recording{
+			Type:       recordings.TypeShell,
+			ClientID:   clientID,
+			Username:   api.GetUser(req.Context(), al.Logger),
+			RemoteAddr: chshare.RemoteIP(req),
+		}, recordings.Header{
+			Width:  shellReq.Cols,
+			Height: shellReq.Rows,
+			Title:  fmt.Sprintf("shell on %s", client.GetHostname()),
+			Env:    env,
+		})
+		if err != nil {
+			// sessions must not run unrecorded when recording is enabled
+			al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to start recording.", err)
+			return
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				al.Errorf("Failed to finish recording %s: %v", recorder.ID(), err)
+			}
+		}()
+	}
+
+	// open the channel before the upgrade, so a rejection by the client is returned as regular error response
+	channel, reqs, err := client.GetConnection().OpenChannel(comm.ChannelTypeShell, payload)
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) && openErr.Reason == ssh.Prohibited {
+			al.jsonErrorResponseWithTitle(w, http.StatusForbidden, openErr.Message)
+			return
+		}
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to start shell on client.", err)
+		return
+	}
+
+	uiConn, err := apiUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		al.Errorf("Failed to establish WS connection: %v", err)
+		channel.Close()
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientShell, auditlog.ActionExecuteStart).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(shellReq).
+		WithRecording(recorder.ID()).
+		Save()
+
+	startedAt := time.Now()
+	session := newShellSession(al.Logger.Fork("shell %s", clientID), uiConn, channel, reqs)
+	session.recorder = recorder
+	exitCode := session.run()
+
+	al.auditLog.Entry(auditlog.ApplicationClientShell, auditlog.ActionExecuteDone).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRecording(recorder.ID()).
+		WithResponse(map[string]interface{}{
+			"exit_code": exitCode,
+			"duration":  time.Since(startedAt).Round(time.Second).String(),
+		}).
+		Save()
+}
+
+func parseShellSize(req *http.Request, param string, defaultValue uint32) (uint32, error) {
+	value := req.URL.Query().Get(param)
+	if value == "" {
+		return defaultValue, nil
+	}
+	size, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || size == 0 {
+		return 0, fmt.Errorf("invalid %q: expected a positive number, got %q", param, value)
+	}
+	return uint32(size), nil
+}
+
+type shellConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteJSON(v interface{}) error
+	Close() error
+}
+
+// shellSession connects a websocket of the UI with a shell channel of a client.
+type shellSession struct {
+	logger  *logger.Logger
+	uiConn  shellConn
+	channel ssh.Channel
+	reqs    <-chan *ssh.Request
+	// recorder is nil unless sessions are recorded
+	recorder *recordings.Recorder
+
+	// writeMu guards writes to uiConn
+	writeMu sync.Mutex
+}
+
+func newShellSession(l *logger.Logger, uiConn shellConn, channel ssh.Channel, reqs <-chan *ssh.Request) *shellSession {
+	return &shellSession{
+		logger:  l,
+		uiConn:  uiConn,
+		channel: channel,
+		reqs:    reqs,
+	}
+}
+
+// run forwards input and resize events to the client and output to the UI until the shell exits or the UI
+// disconnects. It returns the exit code of the shell, nil if unknown.
+func (s *shellSession) run() *int {
+	s.logger.Infof("shell session started")
+
+	exitCodeCh := make(chan *int, 1)
+	go func() {
+		exitCodeCh <- s.handleRequests()
+	}()
+	go s.forwardInput()
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.channel.Read(buf)
+		if n > 0 {
+			s.recorder.Output(buf[:n])
+			if writeErr := s.write(websocket.BinaryMessage, buf[:n]); writeErr != nil {
+				s.logger.Debugf("failed to write shell output: %v", writeErr)
+				break
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				s.logger.Debugf("failed to read shell output: %v", err)
+			}
+			break
+		}
+	}
+
+	// the requests channel is closed with the channel, after the exit status was received
+	s.channel.Close()
+	exitCode := <-exitCodeCh
+
+	s.writeMu.Lock()
+	_ = s.uiConn.WriteJSON(shellMessage{Type: shellMessageExit, ExitCode: exitCode})
+	s.writeMu.Unlock()
+	s.uiConn.Close()
+
+	s.logger.Infof("shell session finished")
+	return exitCode
+}
+
+func (s *shellSession) handleRequests() *int {
+	var exitCode *int
+	for r := range s.reqs {
+		if r.Type == comm.RequestTypeExitStatus {
+			status := &comm.ExitStatusRequest{}
+			if err := ssh.Unmarshal(r.Payload, status); err != nil {
+				s.logger.Errorf("invalid exit status: %v", err)
+			} else {
+				code := int(status.Status)
+				exitCode = &code
+			}
+		}
+		if r.WantReply {
+			_ = r.Reply(false, nil)
+		}
+	}
+	return exitCode
+}
+
+func (s *shellSession) forwardInput() {
+	// closing the channel ends the shell when the UI disconnects
+	defer s.channel.Close()
+
+	for {
+		_, data, err := s.uiConn.ReadMessage()
+		if err != nil {
+			s.logger.Debugf("shell websocket closed: %v", err)
+			return
+		}
+
+		msg := shellMessage{}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.logger.Debugf("invalid shell message: %v", err)
+			continue
+		}
+
+		switch msg.Type {
+		case shellMessageInput:
+			s.recorder.Input([]byte(msg.Data))
+			if _, err := s.channel.Write([]byte(msg.Data)); err != nil {
+				s.logger.Debugf("failed to write shell input: %v", err)
+				return
+			}
+		case shellMessageResize:
+			if msg.Cols == 0 || msg.Rows == 0 {
+				continue
+			}
+			_, err := s.channel.SendRequest(comm.RequestTypeWindowChange, false, ssh.Marshal(&comm.WindowChangeRequest{
+				Cols: msg.Cols,
+				Rows: msg.Rows,
+			}))
+			if err != nil {
+				s.logger.Debugf("failed to resize shell: %v", err)
+				return
+			}
+			s.recorder.Resize(msg.Cols, msg.Rows)
+		default:
+			s.logger.Debugf("unknown shell message type %q", msg.Type)
+		}
+	}
+}
+
+func (s *shellSession) write(messageType int, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.uiConn.WriteMessage(messageType, data)
+}
@@ -0,0 +1,118 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/comm"
+)
+
+type shellUIConnMock struct {
+	in chan []byte
+
+	mu     sync.Mutex
+	output []string
+	exit   *shellMessage
+}
+
+func (c *shellUIConnMock) ReadMessage() (int, []byte, error) {
+	data, ok := <-c.in
+	if !ok {
+		return 0, nil, errors.New("closed")
+	}
+	return websocket.TextMessage, data, nil
+}
+
+func (c *shellUIConnMock) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.output = append(c.output, string(data))
+	return nil
+}
+
+func (c *shellUIConnMock) WriteJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msg := v.(shellMessage)
+	c.exit = &msg
+	return nil
+}
+
+func (c *shellUIConnMock) Close() error {
+	return nil
+}
+
+type shellChannelMock struct {
+	ssh.Channel
+	io.Reader
+
+	mu       sync.Mutex
+	input    []string
+	requests []string
+}
+
+func (c *shellChannelMock) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
+
+func (c *shellChannelMock) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.input = append(c.input, string(p))
+	return len(p), nil
+}
+
+func (c *shellChannelMock) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	size := &comm.WindowChangeRequest{}
+	if err := ssh.Unmarshal(payload, size); err != nil {
+		return false, err
+	}
+	c.requests = append(c.requests, name)
+	return true, nil
+}
+
+func (c *shellChannelMock) Close() error {
+	return nil
+}
+
+func TestShellSession(t *testing.T) {
+	outputReader, outputWriter := io.Pipe()
+	channel := &shellChannelMock{Reader: outputReader}
+	uiConn := &shellUIConnMock{in: make(chan []byte, 2)}
+	reqs := make(chan *ssh.Request, 1)
+
+	input, err := json.Marshal(shellMessage{Type: shellMessageInput, Data: "exit 2\n"})
+	require.NoError(t, err)
+	resize, err := json.Marshal(shellMessage{Type: shellMessageResize, Cols: 120, Rows: 40})
+	require.NoError(t, err)
+	uiConn.in <- resize
+	uiConn.in <- input
+
+	go func() {
+		_, _ = outputWriter.Write([]byte("$ exit 2\r\n"))
+		reqs <- &ssh.Request{Type: comm.RequestTypeExitStatus, Payload: ssh.Marshal(&comm.ExitStatusRequest{Status: 2})}
+		close(reqs)
+		outputWriter.Close()
+	}()
+
+	exitCode := newShellSession(testLog, uiConn, channel, reqs).run()
+	close(uiConn.in)
+
+	require.NotNil(t, exitCode)
+	assert.Equal(t, 2, *exitCode)
+	assert.Equal(t, "$ exit 2\r\n", strings.Join(uiConn.output, ""))
+	require.NotNil(t, uiConn.exit)
+	assert.Equal(t, shellMessageExit, uiConn.exit.Type)
+	assert.Equal(t, 2, *uiConn.exit.ExitCode)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/ha"
 	chshare "github.com/openrport/openrport/share"
 )
 
@@ -45,7 +46,21 @@ func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request)
 		"used_ports":                al.config.Server.UsedPortsRaw,
 		"monitoring_enabled":        al.config.Monitoring.Enabled,
 		"password_min_length":       al.config.API.PasswordMinLength,
+		"high_availability":         al.config.HA.Enabled,
 	})
 
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
+
+// handleGetHAStatus reports whether this server is active or passive. It doesn't require authentication,
+// so load balancers can use it as health check, passive servers respond with 503.
+func (al *APIListener) handleGetHAStatus(w http.ResponseWriter, req *http.Request) {
+	status := al.elector.Status()
+
+	statusCode := http.StatusOK
+	if status.Role != ha.RoleActive {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	al.writeJSONResponse(w, statusCode, api.NewSuccessPayload(status))
+}
@@ -0,0 +1,65 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/ha"
+)
+
+func TestHAPassiveMode(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Elector        *ha.Elector
+		URL            string
+		ExpectedStatus int
+		ExpectedJSON   string
+	}{
+		{
+			Name:           "status, high availability disabled",
+			URL:            "/api/v1/ha/status",
+			ExpectedStatus: http.StatusOK,
+			ExpectedJSON:   `{"data":{"role":"active","node_id":"","active_node_id":"","lease_expires_at":null}}`,
+		}, {
+			Name:           "status, passive",
+			Elector:        ha.NewElector(nil, "node-1", 15*time.Second, testLog),
+			URL:            "/api/v1/ha/status",
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedJSON:   `{"data":{"role":"passive","node_id":"node-1","active_node_id":"","lease_expires_at":null}}`,
+		}, {
+			Name:           "other route, passive",
+			Elector:        ha.NewElector(nil, "node-1", 15*time.Second, testLog),
+			URL:            "/api/v1/clients",
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedJSON:   `{"errors":[{"code":"","title":"server \"node-1\" is passive, active server is \"\"","detail":""}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			al := APIListener{
+				insecureForTests: true,
+				Server: &Server{
+					clientService: clients.NewClientService(nil, nil, clients.NewClientRepository(nil, nil, testLog), testLog, nil),
+					config:        &chconfig.Config{},
+					elector:       tc.Elector,
+				},
+				Logger: testLog,
+			}
+			al.initRouter()
+
+			req := httptest.NewRequest(http.MethodGet, tc.URL, nil)
+			w := httptest.NewRecorder()
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			assert.JSONEq(t, tc.ExpectedJSON, w.Body.String())
+		})
+	}
+}
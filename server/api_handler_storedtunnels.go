@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/cgroups"
 	"github.com/openrport/openrport/server/clients/storedtunnels"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/share/query"
@@ -28,7 +29,11 @@ func (al *APIListener) handleGetStoredTunnels(w http.ResponseWriter, req *http.R
 	}
 
 	options := query.GetListOptions(req)
-	result, err := al.storedTunnels.List(ctx, options, client.GetID())
+	var allowedIDs []string
+	if scope := api.GetResourceScope(ctx); scope != nil {
+		allowedIDs = scope.StoredTunnelIDs
+	}
+	result, err := al.storedTunnels.ListAllowed(ctx, options, client.GetID(), allowedIDs)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -126,3 +131,96 @@ func (al *APIListener) handlePutStoredTunnel(w http.ResponseWriter, req *http.Re
 
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
 }
+
+// getClientGroupForStoredTunnels writes an error response if the group of the request doesn't exist.
+func (al *APIListener) getClientGroupForStoredTunnels(w http.ResponseWriter, req *http.Request) (*cgroups.ClientGroup, bool) {
+	groupID := mux.Vars(req)[routes.ParamGroupID]
+
+	group, err := al.clientGroupProvider.Get(req.Context(), groupID)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find client group[id=%q].", groupID), err)
+		return nil, false
+	}
+	if group == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client Group[id=%q] not found.", groupID))
+		return nil, false
+	}
+
+	return group, true
+}
+
+func (al *APIListener) handleGetGroupStoredTunnels(w http.ResponseWriter, req *http.Request) {
+	group, ok := al.getClientGroupForStoredTunnels(w, req)
+	if !ok {
+		return
+	}
+
+	options := query.GetListOptions(req)
+	result, err := al.storedTunnels.ListForGroup(req.Context(), options, group.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, result)
+}
+
+func (al *APIListener) handlePostGroupStoredTunnels(w http.ResponseWriter, req *http.Request) {
+	group, ok := al.getClientGroupForStoredTunnels(w, req)
+	if !ok {
+		return
+	}
+
+	storedTunnel := &storedtunnels.StoredTunnel{}
+	err := parseRequestBody(req.Body, storedTunnel)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	result, err := al.storedTunnels.CreateForGroup(req.Context(), group.ID, storedTunnel)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
+}
+
+func (al *APIListener) handleDeleteGroupStoredTunnel(w http.ResponseWriter, req *http.Request) {
+	group, ok := al.getClientGroupForStoredTunnels(w, req)
+	if !ok {
+		return
+	}
+
+	err := al.storedTunnels.DeleteForGroup(req.Context(), group.ID, mux.Vars(req)["tunnel_id"])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (al *APIListener) handlePutGroupStoredTunnel(w http.ResponseWriter, req *http.Request) {
+	group, ok := al.getClientGroupForStoredTunnels(w, req)
+	if !ok {
+		return
+	}
+
+	storedTunnel := &storedtunnels.StoredTunnel{}
+	err := parseRequestBody(req.Body, storedTunnel)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	storedTunnel.ID = mux.Vars(req)["tunnel_id"]
+
+	result, err := al.storedTunnels.UpdateForGroup(req.Context(), group.ID, storedTunnel)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
+}
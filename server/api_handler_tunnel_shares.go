@@ -0,0 +1,178 @@
+package chserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/routes"
+	chshare "github.com/openrport/openrport/share"
+)
+
+const tunnelShareDefaultExpiresIn = 24 * time.Hour
+
+type tunnelSharePayload struct {
+	// ExpiresIn is a duration like "2h", default is tunnelShareDefaultExpiresIn
+	ExpiresIn string `json:"expires_in"`
+	MaxUses   int    `json:"max_uses"`
+}
+
+type tunnelShareCreated struct {
+	clienttunnel.TunnelShare
+	URL string `json:"url"`
+}
+
+// handleGetClientTunnelShares handles GET /clients/{client_id}/tunnels/{tunnel_id}/shares
+func (al *APIListener) handleGetClientTunnelShares(w http.ResponseWriter, req *http.Request) {
+	_, tunnel, _, ok := al.getClientTunnelForShares(w, req)
+	if !ok {
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tunnel.InternalTunnelProxy.Shares.List()))
+}
+
+// handlePostClientTunnelShare handles POST /clients/{client_id}/tunnels/{tunnel_id}/shares
+// It creates an expiring link granting access to a tunnel served by the tunnel proxy without an rport account.
+func (al *APIListener) handlePostClientTunnelShare(w http.ResponseWriter, req *http.Request) {
+	client, tunnel, curUser, ok := al.getClientTunnelForShares(w, req)
+	if !ok {
+		return
+	}
+
+	var reqBody tunnelSharePayload
+	if req.ContentLength != 0 {
+		if err := parseRequestBody(req.Body, &reqBody); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	expiresIn := tunnelShareDefaultExpiresIn
+	if reqBody.ExpiresIn != "" {
+		var err error
+		expiresIn, err = time.ParseDuration(reqBody.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid expires_in: %q, expected a positive duration like '2h'.", reqBody.ExpiresIn))
+			return
+		}
+	}
+	if reqBody.MaxUses < 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid max_uses: %d, expected a non-negative number.", reqBody.MaxUses))
+		return
+	}
+
+	share, token, err := tunnel.InternalTunnelProxy.Shares.Create(curUser.Username, expiresIn, reqBody.MaxUses, al.auditTunnelShareUse(client, tunnel.ID))
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to create share link.", err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTunnelShare, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnel.ID).
+		WithRequest(reqBody).
+		WithResponse(share).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tunnelShareCreated{
+		TunnelShare: share,
+		URL:         al.tunnelShareURL(tunnel, token),
+	}))
+}
+
+// handleDeleteClientTunnelShare handles DELETE /clients/{client_id}/tunnels/{tunnel_id}/shares/{share_id}
+func (al *APIListener) handleDeleteClientTunnelShare(w http.ResponseWriter, req *http.Request) {
+	client, tunnel, _, ok := al.getClientTunnelForShares(w, req)
+	if !ok {
+		return
+	}
+
+	shareID := mux.Vars(req)["share_id"]
+	if err := tunnel.InternalTunnelProxy.Shares.Revoke(shareID); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTunnelShare, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnel.ID).
+		WithRequest(map[string]string{"share_id": shareID}).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getClientTunnelForShares returns the tunnel if it is served by the tunnel proxy and the current user is
+// the owner of the tunnel or an administrator.
+func (al *APIListener) getClientTunnelForShares(w http.ResponseWriter, req *http.Request) (*clientdata.Client, *clienttunnel.Tunnel, *users.User, bool) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, nil, nil, false
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return nil, nil, nil, false
+	}
+
+	tunnel := al.clientService.FindTunnel(client, vars["tunnel_id"])
+	if tunnel == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "tunnel not found")
+		return nil, nil, nil, false
+	}
+	if tunnel.InternalTunnelProxy == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Share links are only supported for tunnels with http_proxy.")
+		return nil, nil, nil, false
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, nil, nil, false
+	}
+	if !curUser.IsAdmin() && tunnel.Owner != curUser.Username {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "Only the owner of the tunnel and administrators can manage share links.")
+		return nil, nil, nil, false
+	}
+
+	return client, tunnel, curUser, true
+}
+
+// auditTunnelShareUse returns a func adding an audit log entry each time a share link is opened
+func (al *APIListener) auditTunnelShareUse(client *clientdata.Client, tunnelID string) clienttunnel.ShareUseFunc {
+	return func(share clienttunnel.TunnelShare, r *http.Request) {
+		al.auditLog.Entry(auditlog.ApplicationClientTunnelShare, auditlog.ActionUse).
+			WithRemoteIP(chshare.RemoteIP(r)).
+			WithClient(client).
+			WithID(tunnelID).
+			WithRequest(share).
+			Save()
+	}
+}
+
+func (al *APIListener) tunnelShareURL(tunnel *clienttunnel.Tunnel, token string) string {
+	base := tunnel.TunnelURL
+	if base == "" {
+		host := al.config.Server.InternalTunnelProxyConfig.Host
+		if host == "" {
+			host = tunnel.LocalHost
+		}
+		base = "https://" + net.JoinHostPort(host, tunnel.LocalPort)
+	}
+	return base + "/?" + url.Values{clienttunnel.ShareQueryParam: []string{token}}.Encode()
+}
@@ -0,0 +1,91 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+)
+
+func TestHandleClientTunnelShares(t *testing.T) {
+	owner := &users.User{Username: "owner"}
+	other := &users.User{Username: "other"}
+	c1 := clients.New(t).ID("client-1").Build()
+	c1.Tunnels[0].Owner = owner.Username
+	c1.Tunnels[0].InternalTunnelProxy = &clienttunnel.InternalTunnelProxy{Shares: &clienttunnel.TunnelShares{}}
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+				Server: chconfig.ServerConfig{
+					InternalTunnelProxyConfig: clienttunnel.InternalTunnelProxyConfig{
+						Host: "tunnels.example.com",
+					},
+				},
+			},
+		},
+		userService: &MockUsersService{
+			UserService: users.NewAPIService(users.NewStaticProvider([]*users.User{owner, other}), false, 0, -1),
+		},
+	}
+	al.initRouter()
+
+	do := func(user *users.User, method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req = req.WithContext(api.WithUser(req.Context(), user.Username))
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(other, http.MethodPost, "/api/v1/clients/client-1/tunnels/1/shares", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = do(owner, http.MethodPost, "/api/v1/clients/client-1/tunnels/2/shares", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "no http proxy")
+
+	w = do(owner, http.MethodPost, "/api/v1/clients/client-1/tunnels/1/shares", `{"expires_in": "-1h"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(owner, http.MethodPost, "/api/v1/clients/client-1/tunnels/1/shares", `{"expires_in": "2h", "max_uses": 3}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data struct {
+			ID        string `json:"id"`
+			CreatedBy string `json:"created_by"`
+			MaxUses   int    `json:"max_uses"`
+			URL       string `json:"url"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, owner.Username, resp.Data.CreatedBy)
+	assert.Equal(t, 3, resp.Data.MaxUses)
+	assert.True(t, strings.HasPrefix(resp.Data.URL, "https://tunnels.example.com:"+c1.Tunnels[0].LocalPort+"/?rport-share="+resp.Data.ID+"."), resp.Data.URL)
+
+	w = do(owner, http.MethodGet, "/api/v1/clients/client-1/tunnels/1/shares", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), resp.Data.ID)
+	assert.NotContains(t, w.Body.String(), "rport-share")
+
+	w = do(owner, http.MethodDelete, "/api/v1/clients/client-1/tunnels/1/shares/"+resp.Data.ID, "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, c1.Tunnels[0].InternalTunnelProxy.Shares.List())
+
+	w = do(owner, http.MethodDelete, "/api/v1/clients/client-1/tunnels/1/shares/"+resp.Data.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -14,15 +14,24 @@ type TunnelPayload struct {
 	ID        string    `json:"id"`
 	ClientID  string    `json:"client_id"`
 	CreatedAt time.Time `json:"created_at"`
+
+	Stats  clienttunnel.TunnelStatsPayload   `json:"stats"`
+	Health *clienttunnel.TunnelHealthPayload `json:"health,omitempty"`
 }
 
 func convertToTunnelPayload(t *clienttunnel.Tunnel, clientID string) TunnelPayload {
-	return TunnelPayload{
+	payload := TunnelPayload{
 		Remote:    t.Remote,
 		ID:        t.ID,
 		ClientID:  clientID,
 		CreatedAt: t.CreatedAt,
+		Stats:     t.Stats.Get(),
+	}
+	if t.Health != nil {
+		health := t.Health.Get()
+		payload.Health = &health
 	}
+	return payload
 }
 
 func (al *APIListener) handleGetTunnels(w http.ResponseWriter, req *http.Request) {
@@ -45,6 +54,9 @@ func (al *APIListener) handleGetTunnels(w http.ResponseWriter, req *http.Request
 		if !c.IsConnected() {
 			continue
 		}
+		if !al.allowsClient(req.Context(), c, clientGroups) {
+			continue
+		}
 
 		for _, t := range c.GetTunnels() {
 			tunnels = append(tunnels, convertToTunnelPayload(t, clientID))
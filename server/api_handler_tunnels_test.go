@@ -0,0 +1,69 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/authorization"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/clientdata"
+)
+
+func TestHandleGetTunnelsTokenRestrictions(t *testing.T) {
+	curUser := &users.User{
+		Username: "admin",
+		Groups:   []string{users.Administrators},
+	}
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog), testLog, nil),
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{curUser}), false, 0, -1),
+	}
+	al.initRouter()
+
+	for _, restrictions := range []*authorization.APITokenRestrictions{nil, {ClientIDs: []string{"client-2"}}} {
+		ctx := api.WithUser(context.Background(), curUser.Username)
+		if restrictions != nil {
+			ctx = api.WithTokenRestrictions(ctx, restrictions)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tunnels", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+
+		al.router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Data []TunnelPayload `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		clientIDs := map[string]bool{}
+		for _, tunnel := range resp.Data {
+			clientIDs[tunnel.ClientID] = true
+		}
+		if restrictions == nil {
+			assert.Equal(t, map[string]bool{"client-1": true, "client-2": true}, clientIDs)
+		} else {
+			assert.Equal(t, map[string]bool{"client-2": true}, clientIDs)
+		}
+	}
+}
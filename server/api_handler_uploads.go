@@ -0,0 +1,183 @@
+package chserver
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/server/uploads"
+)
+
+// The resumable upload API follows the core protocol of tus (https://tus.io/protocols/resumable-upload).
+const (
+	tusResumableHeader     = "Tus-Resumable"
+	tusMaxSizeHeader       = "Tus-Max-Size"
+	tusVersion             = "1.0.0"
+	uploadLengthHeader     = "Upload-Length"
+	uploadOffsetHeader     = "Upload-Offset"
+	uploadMetadataHeader   = "Upload-Metadata"
+	uploadChunkContentType = "application/offset+octet-stream"
+)
+
+// handleCreateUpload handles POST /uploads
+func (al *APIListener) handleCreateUpload(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	size, err := strconv.ParseInt(req.Header.Get(uploadLengthHeader), 10, 64)
+	if err != nil || size < 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid %s header, expected the size of the file in bytes", uploadLengthHeader))
+		return
+	}
+	if maxSize := al.config.API.Uploads.MaxSize; maxSize > 0 && size > maxSize {
+		w.Header().Set(tusMaxSizeHeader, strconv.FormatInt(maxSize, 10))
+		al.jsonErrorResponseWithTitle(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("upload of %d bytes exceeds the maximum of %d bytes", size, maxSize))
+		return
+	}
+
+	session, err := al.uploadSessions.Create(size, uploadFilename(req.Header.Get(uploadMetadataHeader)), curUser.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	w.Header().Set(tusResumableHeader, tusVersion)
+	w.Header().Set("Location", routes.AllRoutesPrefix+"/uploads/"+session.ID)
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(session))
+}
+
+// handleGetUpload handles GET /uploads/{upload_id}
+func (al *APIListener) handleGetUpload(w http.ResponseWriter, req *http.Request) {
+	session, err := al.getAccessibleUploadSession(req, mux.Vars(req)[routes.ParamUploadID])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(session))
+}
+
+// handleHeadUpload handles HEAD /uploads/{upload_id}
+func (al *APIListener) handleHeadUpload(w http.ResponseWriter, req *http.Request) {
+	session, err := al.getAccessibleUploadSession(req, mux.Vars(req)[routes.ParamUploadID])
+	if err != nil {
+		var apiErr errors2.APIError
+		if errors.As(err, &apiErr) {
+			w.WriteHeader(apiErr.HTTPStatus)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(tusResumableHeader, tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	w.Header().Set(uploadLengthHeader, strconv.FormatInt(session.Size, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleWriteUpload handles PATCH /uploads/{upload_id}
+// The body is appended to the upload at the given Upload-Offset.
+func (al *APIListener) handleWriteUpload(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Content-Type") != uploadChunkContentType {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Content-Type must be %s", uploadChunkContentType))
+		return
+	}
+
+	offset, err := strconv.ParseInt(req.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid %s header", uploadOffsetHeader))
+		return
+	}
+
+	session, err := al.getAccessibleUploadSession(req, mux.Vars(req)[routes.ParamUploadID])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	session, err = al.uploadSessions.Write(session, offset, req.Body)
+	if session != nil {
+		w.Header().Set(tusResumableHeader, tusVersion)
+		w.Header().Set(uploadOffsetHeader, strconv.FormatInt(session.Offset, 10))
+	}
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, uploads.ErrOffsetMismatch), errors.Is(err, uploads.ErrBusy), errors.Is(err, uploads.ErrCompleted):
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
+	case errors.Is(err, uploads.ErrSizeExceeded), errors.As(err, new(*http.MaxBytesError)):
+		al.jsonErrorResponseWithTitle(w, http.StatusRequestEntityTooLarge, err.Error())
+	default:
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to write upload.", err)
+	}
+}
+
+// handleDeleteUpload handles DELETE /uploads/{upload_id}
+func (al *APIListener) handleDeleteUpload(w http.ResponseWriter, req *http.Request) {
+	session, err := al.getAccessibleUploadSession(req, mux.Vars(req)[routes.ParamUploadID])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.uploadSessions.Delete(session.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getAccessibleUploadSession returns the upload session with the given id, if it's owned by the current user.
+// Administrators can access all upload sessions.
+func (al *APIListener) getAccessibleUploadSession(req *http.Request, id string) (*uploads.Session, error) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := al.uploadSessions.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || (!curUser.IsAdmin() && session.Owner != curUser.Username) {
+		return nil, errors2.APIError{
+			Err:        fmt.Errorf("upload with id %q not found", id),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+
+	return session, nil
+}
+
+// uploadFilename returns the filename of the tus Upload-Metadata header, a comma separated list of keys and
+// base64 encoded values.
+func uploadFilename(metadata string) string {
+	for _, pair := range strings.Split(metadata, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key != "filename" {
+			continue
+		}
+		filename, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return ""
+		}
+		return string(filename)
+	}
+	return ""
+}
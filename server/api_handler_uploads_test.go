@@ -0,0 +1,129 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/api/users"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/uploads"
+	"github.com/openrport/openrport/share/files"
+)
+
+func TestHandleResumableUpload(t *testing.T) {
+	blobs := uploads.NewBlobStore(files.NewFileSystem(), t.TempDir())
+	sessions, err := uploads.NewSessionManager(t.TempDir(), blobs)
+	require.NoError(t, err)
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024,
+					MaxFilePushSize: 1024,
+					Uploads:         uploads.Config{MaxSize: 8192},
+				},
+			},
+			uploadBlobs:    blobs,
+			uploadSessions: sessions,
+		},
+		Logger: testLog,
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Groups: []string{users.Administrators}},
+			{Username: "user1", Groups: []string{"group1"}},
+			{Username: "user2", Groups: []string{"group1"}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	do := func(method, url, username string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req = req.WithContext(api.WithUser(context.Background(), username))
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	chunk := func(offset string) map[string]string {
+		return map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": offset,
+		}
+	}
+
+	w := do(http.MethodPost, "/api/v1/uploads", "user1", map[string]string{
+		"Upload-Length":   "12",
+		"Upload-Metadata": "filename ZmlsZS50eHQ=",
+	}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "0", w.Header().Get("Upload-Offset"))
+	var created struct {
+		Data uploads.Session `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "file.txt", created.Data.Filename)
+	location := w.Header().Get("Location")
+	assert.Equal(t, "/api/v1/uploads/"+created.Data.ID, location)
+
+	w = do(http.MethodPatch, location, "user1", chunk("0"), "some ")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+
+	w = do(http.MethodHead, location, "user1", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+	assert.Equal(t, "12", w.Header().Get("Upload-Length"))
+
+	w = do(http.MethodPatch, location, "user1", chunk("0"), "some ")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Upload-Offset"))
+
+	w = do(http.MethodPatch, location, "user1", map[string]string{"Upload-Offset": "5"}, "content")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	// only the owner and administrators can access an upload
+	w = do(http.MethodPatch, location, "user2", chunk("5"), "content")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPatch, location, "user1", chunk("5"), "content")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, "12", w.Header().Get("Upload-Offset"))
+
+	w = do(http.MethodGet, location, "admin", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var completed struct {
+		Data uploads.Session `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &completed))
+	assert.True(t, completed.Data.Completed())
+	assert.Equal(t, "290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56", completed.Data.SHA256)
+	assert.FileExists(t, blobs.Path(completed.Data.SHA256))
+
+	w = do(http.MethodDelete, location, "user1", nil, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodGet, location, "user1", nil, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// the whole upload is limited by uploads_max_size
+	w = do(http.MethodPost, "/api/v1/uploads", "user1", map[string]string{"Upload-Length": "8193"}, "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "8192", w.Header().Get("Tus-Max-Size"))
+
+	// chunks are limited by max_filepush_size, the data received until the limit is kept
+	w = do(http.MethodPost, "/api/v1/uploads", "user1", map[string]string{"Upload-Length": "4096"}, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(http.MethodPatch, w.Header().Get("Location"), "user1", chunk("0"), strings.Repeat("x", 2048))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "1024", w.Header().Get("Upload-Offset"))
+}
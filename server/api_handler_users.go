@@ -24,6 +24,7 @@ var (
 type UserPayload struct {
 	Username                     string                       `json:"username"`
 	PasswordExpired              bool                         `json:"password_expired"`
+	Locked                       bool                         `json:"locked,omitempty"`
 	Groups                       []string                     `json:"groups"`
 	TwoFASendTo                  string                       `json:"two_fa_send_to"`
 	EffectiveUserPermissions     map[string]bool              `json:"effective_user_permissions"`
@@ -43,6 +44,12 @@ func (al *APIListener) handleGetUsers(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	lockouts, err := al.userService.GetLockouts(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	usersToSend := make([]UserPayload, 0, len(usrs))
 	for _, user := range usrs {
 		payload := UserPayload{
@@ -53,6 +60,9 @@ func (al *APIListener) handleGetUsers(w http.ResponseWriter, req *http.Request)
 		if user.PasswordExpired != nil {
 			payload.PasswordExpired = *user.PasswordExpired
 		}
+		if lockout := lockouts[user.Username]; lockout != nil {
+			payload.Locked = lockout.LockedAt != nil
+		}
 		usersToSend = append(usersToSend, payload)
 	}
 
@@ -153,6 +163,13 @@ func (al *APIListener) handleDeleteUser(w http.ResponseWriter, req *http.Request
 		return
 	}
 
+	if al.webAuthnSrv != nil {
+		if err := al.webAuthnSrv.DeleteAllCredentials(req.Context(), userID); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
 	al.auditLog.Entry("user", auditlog.ActionDelete).
 		WithHTTPRequest(req).
 		WithID(userID).
@@ -188,6 +205,29 @@ func (al *APIListener) handleDeleteUsersTotP(w http.ResponseWriter, req *http.Re
 	al.handleManageTotP(w, req, user, "delete")
 }
 
+// handleDeleteUserLockout unlocks a user who was locked after too many failed logins.
+func (al *APIListener) handleDeleteUserLockout(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	userID, userIDProvided := vars[routes.ParamUserID]
+	if !userIDProvided {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Empty user id provided")
+		return
+	}
+
+	if err := al.userService.Unlock(req.Context(), userID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserLockout, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(userID).
+		Save()
+
+	al.Debugf("User [%s] unlocked.", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (al *APIListener) handleGetUserAPISessions(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	userID, userIDExists := vars[routes.ParamUserID]
@@ -1,6 +1,7 @@
 package chserver
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -88,6 +89,16 @@ func (al *APIListener) handleListVaultValues(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	if scope := api.GetResourceScope(req.Context()); scope != nil {
+		allowed := make([]vault.ValueKey, 0, len(items))
+		for _, item := range items {
+			if scope.AllowsVaultKey(item.Key) {
+				allowed = append(allowed, item)
+			}
+		}
+		items = allowed
+	}
+
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(items))
 }
 
@@ -139,6 +150,12 @@ func (al *APIListener) handleReadVaultValue(w http.ResponseWriter, req *http.Req
 		return
 	}
 
+	err = checkVaultKeyScope(req.Context(), storedValue.Key)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(storedValue))
 }
 
@@ -165,6 +182,27 @@ func (al *APIListener) handleVaultStoreValue(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	err = checkVaultKeyScope(req.Context(), vaultKeyValue.Key)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if id != 0 && api.GetResourceScope(req.Context()) != nil {
+		// the key of an existing value must be selected as well, not only the new key
+		existing, found, err := al.vaultManager.GetOne(req.Context(), id, curUser)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if found {
+			err = checkVaultKeyScope(req.Context(), existing.Key)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+		}
+	}
+
 	storedValue, err := al.vaultManager.Store(req.Context(), int64(id), &vaultKeyValue, curUser)
 	if err != nil {
 		al.jsonError(w, err)
@@ -228,6 +266,12 @@ func (al *APIListener) handleVaultDeleteValue(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	err = checkVaultKeyScope(req.Context(), storedValue.Key)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	err = al.vaultManager.Delete(req.Context(), id, curUser)
 	if err != nil {
 		al.jsonError(w, err)
@@ -242,3 +286,14 @@ func (al *APIListener) handleVaultDeleteValue(w http.ResponseWriter, req *http.R
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// checkVaultKeyScope returns APIError with 403 if the roles granting the vault permission don't select the key.
+func checkVaultKeyScope(ctx context.Context, key string) error {
+	if api.GetResourceScope(ctx).AllowsVaultKey(key) {
+		return nil
+	}
+	return errors2.APIError{
+		Message:    fmt.Sprintf("The roles of the current user do not allow to access vault key %q", key),
+		HTTPStatus: http.StatusForbidden,
+	}
+}
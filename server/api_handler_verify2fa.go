@@ -3,6 +3,8 @@ package chserver
 import (
 	"net/http"
 
+	"github.com/go-webauthn/webauthn/protocol"
+
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/bearer"
 )
@@ -24,7 +26,7 @@ func (al *APIListener) handlePostVerify2FAToken() http.Handler {
 }
 
 func (al *APIListener) parseAndValidate2FATokenRequest(req *http.Request) (username string, err error) {
-	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
+	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled && !al.config.API.WebAuthnEnabled {
 		return "", errors2.APIError{
 			HTTPStatus: http.StatusConflict,
 			Message:    "2fa is disabled",
@@ -32,8 +34,9 @@ func (al *APIListener) parseAndValidate2FATokenRequest(req *http.Request) (usern
 	}
 
 	var reqBody struct {
-		Username string `json:"username"`
-		Token    string `json:"token"`
+		Username string                                `json:"username"`
+		Token    string                                `json:"token"`
+		WebAuthn *protocol.CredentialAssertionResponse `json:"webauthn"`
 	}
 	err = parseRequestBody(req.Body, &reqBody)
 	if err != nil {
@@ -54,14 +57,15 @@ func (al *APIListener) parseAndValidate2FATokenRequest(req *http.Request) (usern
 		}
 	}
 
-	if reqBody.Token == "" {
+	useWebAuthn := al.config.API.WebAuthnEnabled && reqBody.WebAuthn != nil
+	if reqBody.Token == "" && !useWebAuthn {
 		return reqBody.Username, errors2.APIError{
 			HTTPStatus: http.StatusUnauthorized,
 			Message:    "token is required",
 		}
 	}
 
-	if al.config.API.TotPEnabled {
+	if al.config.API.TotPEnabled || al.config.API.WebAuthnEnabled {
 		bearerToken, bearerAuthProvided := bearer.GetBearerToken(req)
 
 		if !bearerAuthProvided {
@@ -83,6 +87,16 @@ func (al *APIListener) parseAndValidate2FATokenRequest(req *http.Request) (usern
 			}
 		}
 
+		if useWebAuthn {
+			return token.AppClaims.Username, al.twoFASrv.ValidateWebAuthnAssertion(req.Context(), token.AppClaims.Username, reqBody.WebAuthn)
+		}
+		if !al.config.API.TotPEnabled {
+			return token.AppClaims.Username, errors2.APIError{
+				HTTPStatus: http.StatusUnauthorized,
+				Message:    "webauthn assertion is required",
+			}
+		}
+
 		user, err := al.userService.GetByUsername(token.AppClaims.Username)
 		if err != nil {
 			return "", err
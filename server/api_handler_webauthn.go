@@ -0,0 +1,177 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gorilla/mux"
+
+	"github.com/openrport/openrport/server/api"
+	"github.com/openrport/openrport/server/auditlog"
+	"github.com/openrport/openrport/server/routes"
+)
+
+type webAuthnCredentialRequest struct {
+	Name       string                               `json:"name"`
+	Credential *protocol.CredentialCreationResponse `json:"credential"`
+}
+
+// handlePostWebAuthnRegistration returns the options to create a new credential for the current user.
+func (al *APIListener) handlePostWebAuthnRegistration(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	options, err := al.webAuthnSrv.BeginRegistration(req.Context(), user.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(options))
+}
+
+// handlePostWebAuthnCredential verifies and stores the credential created with the options of the registration.
+func (al *APIListener) handlePostWebAuthnCredential(w http.ResponseWriter, req *http.Request) {
+	var r webAuthnCredentialRequest
+	err := parseRequestBody(req.Body, &r)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if r.Credential == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "credential is required")
+		return
+	}
+
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	credential, err := al.webAuthnSrv.FinishRegistration(req.Context(), user.Username, r.Name, r.Credential)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(credential.ID).
+		WithResponse(credential).
+		Save()
+
+	al.Debugf("WebAuthn credential %q is registered for user [%s].", credential.Name, user.Username)
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(credential))
+}
+
+func (al *APIListener) handleGetWebAuthnCredentials(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	credentials, err := al.webAuthnSrv.ListCredentials(req.Context(), user.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(credentials))
+}
+
+func (al *APIListener) handleDeleteWebAuthnCredential(w http.ResponseWriter, req *http.Request) {
+	credentialID := mux.Vars(req)[routes.ParamCredentialID]
+
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err := al.webAuthnSrv.DeleteCredential(req.Context(), user.Username, credentialID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(credentialID).
+		Save()
+
+	al.Debugf("WebAuthn credential %q is deleted for user [%s].", credentialID, user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePostWebAuthnAssertion returns the options to verify a credential of the current user during the login.
+func (al *APIListener) handlePostWebAuthnAssertion(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModel(req.Context())
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	options, err := al.webAuthnSrv.BeginAssertion(req.Context(), user.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(options))
+}
+
+func (al *APIListener) handleDeleteUsersWebAuthnCredentials(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	userID, userIDProvided := vars[routes.ParamUserID]
+	if !userIDProvided {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Empty user id provided")
+		return
+	}
+
+	user, err := al.userService.GetByUsername(userID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err := al.webAuthnSrv.DeleteAllCredentials(req.Context(), user.Username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserWebAuthn, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(userID).
+		Save()
+
+	al.Debugf("WebAuthn credentials are deleted for user [%s].", user.Username)
+	w.WriteHeader(http.StatusNoContent)
+}
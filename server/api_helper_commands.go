@@ -28,6 +28,10 @@ func (al *APIListener) handleCommandsExecutionWS(
 		uiConnTS.WriteError("Invalid interpreter", err)
 		return
 	}
+	if inboundMsg.Rollout != nil || inboundMsg.RequireApproval || (al.config.Server.MultiJobApprovalRequired && len(inboundMsg.OrderedClients) > 1) {
+		uiConnTS.WriteError("Rollouts and approvals of multi-client jobs are only supported by the REST API.", nil)
+		return
+	}
 
 	if inboundMsg.TimeoutSec <= 0 {
 		inboundMsg.TimeoutSec = al.config.Server.RunRemoteCmdTimeoutSec
@@ -50,7 +54,7 @@ func (al *APIListener) handleCommandsExecutionWS(
 	if err != nil {
 		uiConnTS.WriteError("Could not get client groups", err)
 	}
-	err = al.clientService.CheckClientsAccess(inboundMsg.OrderedClients, curUser, clientGroups)
+	err = al.checkClientsAccess(ctx, inboundMsg.OrderedClients, curUser, clientGroups)
 	if err != nil {
 		uiConnTS.WriteError(err.Error(), nil)
 		return
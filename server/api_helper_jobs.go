@@ -5,19 +5,34 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/jobs"
 	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/query"
 	"github.com/openrport/openrport/share/random"
+	"github.com/openrport/openrport/share/refs"
 	"github.com/openrport/openrport/share/ws"
 )
 
 var ErrClientNotConnected = errors.New("client is not connected")
 
+const (
+	// multiJobResultGracePeriod is added to the timeout of jobs of a wave to wait for their results
+	multiJobResultGracePeriod = time.Minute
+	// multiJobCancelTimeout is how long a cancel request waits for the execution of a multi-client job to stop
+	multiJobCancelTimeout = 30 * time.Second
+
+	multiJobIdentifiableType refs.IdentifiableType = "multi_job"
+)
+
 var generateNewJobID = func() (string, error) {
 	return random.UUID4()
 }
@@ -33,7 +48,11 @@ type JobProvider interface {
 	GetMultiJob(ctx context.Context, jid string) (*models.MultiJob, error)
 	GetMultiJobSummaries(ctx context.Context, options *query.ListOptions) ([]*models.MultiJobSummary, error)
 	CountMultiJobs(ctx context.Context, options *query.ListOptions) (int, error)
+	GetMultiJobClientIDs(ctx context.Context) (map[string][]string, error)
 	SaveMultiJob(multiJob *models.MultiJob) error
+	// AppendOutput persists a chunk of the streamed output of a job
+	AppendOutput(ctx context.Context, jid, stream string, data []byte, maxSize int64) (*models.JobOutputChunk, error)
+	ListOutput(ctx context.Context, jid string, since int64, limit int) ([]*models.JobOutputChunk, error)
 	CleanupJobsMultiJobs(context.Context, int) error
 	Close() error
 }
@@ -59,7 +78,7 @@ func (al *APIListener) createAndRunJob(
 		CreatedBy:    createdBy,
 		TimeoutSec:   timeoutSec,
 		MultiJobID:   multiJobID,
-		StreamResult: uiConnTS != nil,
+		StreamResult: uiConnTS != nil || al.persistsJobOutput(),
 	}
 	logPrefix := curJob.LogPrefix()
 
@@ -125,19 +144,20 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 	if multiJobRequest.TimeoutSec <= 0 {
 		multiJobRequest.TimeoutSec = al.config.Server.RunRemoteCmdTimeoutSec
 	}
+	if multiJobRequest.Rollout != nil {
+		if err := multiJobRequest.Rollout.Validate(); err != nil {
+			return nil, errors2.APIError{
+				Message:    "Invalid rollout.",
+				Err:        err,
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
 
 	if multiJobRequest.OrderedClients == nil {
-		// try to rebuild the ordered client list
-		if !hasClientTags(multiJobRequest) {
-			multiJobRequest.OrderedClients, _, err = al.getOrderedClients(ctx, multiJobRequest.ClientIDs, multiJobRequest.GroupIDs)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			multiJobRequest.OrderedClients, err = al.getOrderedClientsByTag(multiJobRequest.ClientTags)
-			if err != nil {
-				return nil, err
-			}
+		multiJobRequest.OrderedClients, err = al.rebuildOrderedClients(ctx, multiJobRequest)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -154,6 +174,10 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 		command = string(decodedScriptBytes)
 	}
 
+	// schedules are not approved on each execution
+	requireApproval := multiJobRequest.RequireApproval ||
+		(al.config.Server.MultiJobApprovalRequired && multiJobRequest.ScheduleID == nil && len(multiJobRequest.OrderedClients) > 1)
+
 	multiJob := &models.MultiJob{
 		MultiJobSummary: models.MultiJobSummary{
 			JID:        jid,
@@ -161,95 +185,358 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 			CreatedBy:  multiJobRequest.Username,
 			ScheduleID: multiJobRequest.ScheduleID,
 		},
-		ClientIDs:   multiJobRequest.ClientIDs,
-		GroupIDs:    multiJobRequest.GroupIDs,
-		ClientTags:  multiJobRequest.ClientTags,
-		Command:     command,
-		Interpreter: multiJobRequest.Interpreter,
-		Cwd:         multiJobRequest.Cwd,
-		IsScript:    multiJobRequest.IsScript,
-		IsSudo:      multiJobRequest.IsSudo,
-		TimeoutSec:  multiJobRequest.TimeoutSec,
-		Concurrent:  multiJobRequest.ExecuteConcurrently,
-		AbortOnErr:  abortOnErr,
+		ClientIDs:       multiJobRequest.ClientIDs,
+		GroupIDs:        multiJobRequest.GroupIDs,
+		ClientTags:      multiJobRequest.ClientTags,
+		Command:         command,
+		Interpreter:     multiJobRequest.Interpreter,
+		Cwd:             multiJobRequest.Cwd,
+		IsScript:        multiJobRequest.IsScript,
+		IsSudo:          multiJobRequest.IsSudo,
+		TimeoutSec:      multiJobRequest.TimeoutSec,
+		Concurrent:      multiJobRequest.ExecuteConcurrently,
+		AbortOnErr:      abortOnErr,
+		Rollout:         multiJobRequest.Rollout,
+		Status:          models.MultiJobStatusRunning,
+		RequireApproval: requireApproval,
+	}
+	if requireApproval {
+		multiJob.Status = models.MultiJobStatusAwaitingApproval
 	}
 	if err := al.jobProvider.SaveMultiJob(multiJob); err != nil {
 		return nil, err
 	}
 
-	go al.executeMultiClientJob(multiJob, multiJobRequest.OrderedClients)
+	if requireApproval {
+		al.notifyMultiJobApprovalRequest(ctx, multiJob, len(multiJobRequest.OrderedClients))
+		return multiJob, nil
+	}
+
+	go al.executeMultiClientJob(al.runningMultiJobs.Start(multiJob.JID), multiJob, multiJobRequest.OrderedClients)
 
 	return multiJob, nil
 }
 
+// rebuildOrderedClients returns the clients targeted by a multi-client job that was not created by an API request.
+func (al *APIListener) rebuildOrderedClients(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) ([]*clientdata.Client, error) {
+	if hasClientTags(multiJobRequest) {
+		return al.getOrderedClientsByTag(multiJobRequest.ClientTags)
+	}
+
+	orderedClients, _, err := al.getOrderedClients(ctx, multiJobRequest.ClientIDs, multiJobRequest.GroupIDs)
+	return orderedClients, err
+}
+
+// executeMultiClientJob executes the job on the clients in waves, if the job has a rollout, otherwise all clients are
+// one wave. Only waves of a rollout are recorded and wait for the results of their jobs. The execution stops, once ctx
+// is cancelled, which requires ctx to be registered in runningMultiJobs.
 func (al *APIListener) executeMultiClientJob(
+	ctx context.Context,
 	job *models.MultiJob,
 	orderedClients []*clientdata.Client,
 ) {
-	// for sequential execution - create a channel to get the job result
-	var curJobDoneChannel chan *models.Job
-	if !job.Concurrent {
-		curJobDoneChannel = make(chan *models.Job)
-		al.jobsDoneChannel.Set(job.JID, curJobDoneChannel)
-		defer func() {
-			close(curJobDoneChannel)
-			al.jobsDoneChannel.Del(job.JID)
-		}()
-	}
-	for _, client := range orderedClients {
-		curJID, err := generateNewJobID()
+	defer al.runningMultiJobs.Finish(job.JID)
+
+	// create a channel to get the job results, buffered to not block on results of jobs no longer waited for
+	curJobDoneChannel := make(chan *models.Job, len(orderedClients))
+	al.jobsDoneChannel.Set(job.JID, curJobDoneChannel)
+	defer al.jobsDoneChannel.Del(job.JID)
+
+	waveSize := job.Rollout.WaveSize(len(orderedClients))
+	for start := 0; start < len(orderedClients); start += waveSize {
+		end := start + waveSize
+		if end > len(orderedClients) {
+			end = len(orderedClients)
+		}
+		waveClients := orderedClients[start:end]
+
+		if start > 0 && job.Rollout.PauseSec > 0 {
+			select {
+			case <-time.After(time.Duration(job.Rollout.PauseSec) * time.Second):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wave := &models.MultiJobWave{
+			StartedAt: time.Now(),
+		}
+		for _, client := range waveClients {
+			wave.ClientIDs = append(wave.ClientIDs, client.GetID())
+		}
+		if job.Rollout != nil {
+			job.Waves = append(job.Waves, wave)
+			al.saveMultiJobState(job)
+		}
+
+		err := al.executeMultiJobWave(ctx, job, wave, waveClients, curJobDoneChannel)
+		now := time.Now()
+		wave.FinishedAt = &now
+		if ctx.Err() != nil {
+			break
+		}
 		if err != nil {
-			return
+			job.Status = models.MultiJobStatusAborted
+			job.Error = err.Error()
+			break
 		}
-		if job.Concurrent {
-			go al.createAndRunJob( //nolint:errcheck // error is logged, nothing to act on here
-				nil,
-				&job.JID,
-				curJID,
-				job.Command,
-				job.Interpreter,
-				job.CreatedBy,
-				job.Cwd,
-				job.TimeoutSec,
-				job.IsSudo,
-				job.IsScript,
-				client,
-			)
-		} else {
-			err := al.createAndRunJob(
-				nil,
-				&job.JID,
-				curJID,
-				job.Command,
-				job.Interpreter,
-				job.CreatedBy,
-				job.Cwd,
-				job.TimeoutSec,
-				job.IsSudo,
-				job.IsScript,
-				client,
-			)
+
+		rollout := job.Rollout
+		if rollout != nil && rollout.SuccessThreshold > 0 && end < len(orderedClients) {
+			total := wave.Succeeded + wave.Failed
+			if wave.Succeeded*100 < rollout.SuccessThreshold*total {
+				job.Status = models.MultiJobStatusAborted
+				job.Error = fmt.Sprintf("%d of %d jobs of wave %d succeeded, below the success threshold of %d%%", wave.Succeeded, total, len(job.Waves), rollout.SuccessThreshold)
+				break
+			}
+		}
+		if job.Rollout != nil {
+			al.saveMultiJobState(job)
+		}
+	}
+	switch {
+	case ctx.Err() != nil:
+		job.Status = models.MultiJobStatusCancelled
+		job.CancelledBy = al.runningMultiJobs.CancelledBy(job.JID)
+	case job.Status != models.MultiJobStatusAborted:
+		job.Status = models.MultiJobStatusFinished
+	}
+	al.saveMultiJobState(job)
+
+	if al.testDone != nil {
+		al.testDone <- true
+	}
+}
+
+// executeMultiJobWave executes the job on the clients of a wave and counts the succeeded and failed jobs. Results of
+// concurrently executed jobs are only waited for, if the job has a rollout. An error is returned, if the job is aborted.
+// No further clients are started and waited for, once ctx is cancelled. curJobDoneChannel is shared by all waves,
+// so results of jobs not started by this wave, which arrived after their wave timed out, are ignored.
+func (al *APIListener) executeMultiJobWave(
+	ctx context.Context,
+	job *models.MultiJob,
+	wave *models.MultiJobWave,
+	clients []*clientdata.Client,
+	curJobDoneChannel chan *models.Job,
+) error {
+	if job.Concurrent {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		// the jobs started successfully, their results are waited for
+		pending := make(map[string]bool)
+		for _, client := range clients {
+			if ctx.Err() != nil {
+				break
+			}
+			curJID, err := generateNewJobID()
 			if err != nil {
-				if job.AbortOnErr && !errors.Is(err, ErrClientNotConnected) {
-					break
+				return err
+			}
+			wg.Add(1)
+			go func(client *clientdata.Client, curJID string) {
+				defer wg.Done()
+				err := al.createAndRunJob(
+					nil,
+					&job.JID,
+					curJID,
+					job.Command,
+					job.Interpreter,
+					job.CreatedBy,
+					job.Cwd,
+					job.TimeoutSec,
+					job.IsSudo,
+					job.IsScript,
+					client,
+				)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					wave.Failed++
+				} else {
+					pending[curJID] = true
+				}
+			}(client, curJID)
+		}
+		wg.Wait()
+
+		// TODO: review use of this flag as a testing hack. works but not too nice.
+		if job.Rollout == nil || al.insecureForTests {
+			wave.Succeeded += len(pending)
+			return nil
+		}
+
+		timeout := time.After(time.Duration(job.TimeoutSec)*time.Second + multiJobResultGracePeriod)
+		for len(pending) > 0 {
+			select {
+			case jobResult := <-curJobDoneChannel:
+				if !pending[jobResult.JID] {
+					continue
 				}
-				continue
+				delete(pending, jobResult.JID)
+				countMultiJobResult(wave, jobResult)
+			case <-timeout:
+				wave.Failed += len(pending)
+				return nil
+			case <-ctx.Done():
+				return nil
 			}
+		}
+		return nil
+	}
 
-			// TODO: review use of this flag as a testing hack. works but not too nice.
-			// in tests skip next part to avoid waiting
-			if al.insecureForTests {
-				continue
+	for _, client := range clients {
+		if ctx.Err() != nil {
+			return nil
+		}
+		curJID, err := generateNewJobID()
+		if err != nil {
+			return err
+		}
+		err = al.createAndRunJob(
+			nil,
+			&job.JID,
+			curJID,
+			job.Command,
+			job.Interpreter,
+			job.CreatedBy,
+			job.Cwd,
+			job.TimeoutSec,
+			job.IsSudo,
+			job.IsScript,
+			client,
+		)
+		if err != nil {
+			wave.Failed++
+			if job.AbortOnErr && !errors.Is(err, ErrClientNotConnected) {
+				return fmt.Errorf("aborted after the job on client %s failed: %v", client.GetID(), err)
 			}
+			continue
+		}
 
-			// wait until command is finished
-			jobResult := <-curJobDoneChannel
-			if job.AbortOnErr && jobResult.Status == models.JobStatusFailed {
-				break
+		// TODO: review use of this flag as a testing hack. works but not too nice.
+		// in tests skip next part to avoid waiting
+		if al.insecureForTests {
+			wave.Succeeded++
+			continue
+		}
+
+		// wait until command is finished
+		var jobResult *models.Job
+		for jobResult == nil {
+			select {
+			case result := <-curJobDoneChannel:
+				if result.JID == curJID {
+					jobResult = result
+				}
+			case <-ctx.Done():
+				return nil
 			}
 		}
+		countMultiJobResult(wave, jobResult)
+		if job.AbortOnErr && jobResult.Status == models.JobStatusFailed {
+			return fmt.Errorf("aborted after the job on client %s failed", client.GetID())
+		}
 	}
-	if al.testDone != nil {
-		al.testDone <- true
+	return nil
+}
+
+func countMultiJobResult(wave *models.MultiJobWave, jobResult *models.Job) {
+	if jobResult.Status == models.JobStatusSuccessful {
+		wave.Succeeded++
+	} else {
+		wave.Failed++
+	}
+}
+
+func (al *APIListener) saveMultiJobState(job *models.MultiJob) {
+	if err := al.jobProvider.SaveMultiJob(job); err != nil {
+		al.Errorf("Multi-client Job[id=%q]: failed to save state: %v", job.JID, err)
+	}
+}
+
+// notifyMultiJobApprovalRequest sends an email to the approvers of a multi-client job. Errors are logged only, the job
+// can be approved without it.
+func (al *APIListener) notifyMultiJobApprovalRequest(ctx context.Context, job *models.MultiJob, clientCount int) {
+	if al.notificationsStorage == nil {
+		return
+	}
+
+	recipients := al.config.Server.MultiJobApprovalRecipients
+	if len(recipients) == 0 {
+		allUsers, err := al.userService.GetAll()
+		if err != nil {
+			al.Errorf("Multi-client Job[id=%q]: failed to get approvers: %v", job.JID, err)
+			return
+		}
+		for _, u := range allUsers {
+			if u.IsAdmin() && u.Username != job.CreatedBy && strings.Contains(u.TwoFASendTo, "@") {
+				recipients = append(recipients, u.TwoFASendTo)
+			}
+		}
+	}
+	if len(recipients) == 0 {
+		al.Infof("Multi-client Job[id=%q] awaits approval, no recipients to notify.", job.JID)
+		return
+	}
+
+	kind := "command"
+	if job.IsScript {
+		kind = "script"
 	}
+	content := fmt.Sprintf("%s requests to execute a %s on %d clients.\n\n%s\n\nApprove it with POST /api/v1/commands/%s/approve or reject it with POST /api/v1/commands/%s/reject.",
+		job.CreatedBy, kind, clientCount, job.Command, job.JID, job.JID)
+
+	dispatcher := notifications.NewDispatcher(al.notificationsStorage)
+	_, err := dispatcher.Dispatch(ctx, refs.NewIdentifiable(multiJobIdentifiableType, job.JID), notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  recipients,
+		Subject:     fmt.Sprintf("Multi-client %s %s awaits approval", kind, job.JID),
+		Content:     content,
+		ContentType: notifications.ContentTypeTextPlain,
+	})
+	if err != nil {
+		al.Errorf("Multi-client Job[id=%q]: failed to notify approvers: %v", job.JID, err)
+	}
+}
+
+// cancelJob asks the client to kill the process tree of a running job. The job is marked as cancelled, if the client
+// didn't report the result before responding.
+func (al *APIListener) cancelJob(job *models.Job, cancelledBy string) error {
+	client, err := al.clientService.GetActiveByID(job.ClientID)
+	if err != nil {
+		return err
+	}
+	if client == nil || client.Connection == nil {
+		return ErrClientNotConnected
+	}
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeCancelCmd, comm.CancelCmdRequest{
+		JID:         job.JID,
+		CancelledBy: cancelledBy,
+	}, nil, al.Log())
+	if err != nil {
+		return err
+	}
+
+	current, err := al.jobProvider.GetByJID(job.ClientID, job.JID)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Status != models.JobStatusRunning {
+		*job = *current
+		return nil
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusCancelled
+	job.FinishedAt = &now
+	job.Error = fmt.Sprintf("cancelled by %s", cancelledBy)
+	return al.jobProvider.SaveJob(job)
+}
+
+// persistsJobOutput returns true if the output streamed by jobs is persisted.
+func (al *APIListener) persistsJobOutput() bool {
+	return al.config.Server.JobsMaxOutputBytes > 0
 }
@@ -9,7 +9,6 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"path"
 	"strings"
 	"sync"
 	"time"
@@ -21,9 +20,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/jpillora/requestlog"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/api_token"
 	"github.com/openrport/openrport/db/migration/library"
-	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/db/migration/password_policy"
+	"github.com/openrport/openrport/db/migration/roles"
+	webauthnMigration "github.com/openrport/openrport/db/migration/webauthn"
 	rportplus "github.com/openrport/openrport/plus"
 	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/server/notifications/channels/rmailer"
@@ -42,6 +44,7 @@ import (
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/vault"
+	"github.com/openrport/openrport/server/webauthn"
 
 	extperm "github.com/openrport/openrport/plus/capabilities/extendedpermission"
 	chshare "github.com/openrport/openrport/share"
@@ -75,12 +78,15 @@ type APIListener struct {
 
 	testDone chan bool // is used only in tests to be able to wait until async task is done
 
-	userService    UserService
-	vaultManager   *vault.Manager
-	scriptManager  *script.Manager
-	tokenManager   *authorization.Manager
-	commandManager *command.Manager
-	storedTunnels  *storedtunnels.Manager
+	userService            UserService
+	vaultManager           *vault.Manager
+	scriptManager          *script.Manager
+	tokenManager           *authorization.Manager
+	commandManager         *command.Manager
+	storedTunnels          *storedtunnels.Manager
+	roleProvider           users.RoleProvider
+	passwordPolicyProvider users.PasswordPolicyProvider
+	webAuthnSrv            *webauthn.Service
 
 	notificationsStorage   notificationsSQLite.Repository
 	notificationsProcessor notifications.Processor
@@ -88,6 +94,8 @@ type APIListener struct {
 	notificationsCleaner   notificationsSQLite.Closeable
 
 	mu sync.RWMutex
+	// multiJobDecisionMu serializes approving and rejecting multi-client jobs, so a job is started only once
+	multiJobDecisionMu sync.Mutex
 }
 
 func (al *APIListener) Log() (l *logger.Logger) {
@@ -104,6 +112,7 @@ type UserService interface {
 	Delete(string) error
 	ExistGroups([]string) error
 	GetProviderType() enums.ProviderSource
+	PasswordVerifier() users.PasswordVerifier
 	ListGroups() ([]users.Group, error)
 	GetGroup(string) (users.Group, error)
 	UpdateGroup(string, users.Group) (users.Group, error)
@@ -112,6 +121,19 @@ type UserService interface {
 	SupportsGroupPermissions() bool
 	GetEffectiveUserPermissions(*users.User) (map[string]bool, error)
 	GetEffectiveUserExtendedPermissions(*users.User) ([]extperm.PermissionParams, []extperm.PermissionParams)
+	ExplainPermissions(*users.User) (map[string]*users.EffectivePermission, error)
+	GetPermissionScope(*users.User, string) (*users.RoleResources, error)
+	GetClientScope(*users.User) (*users.RoleResources, error)
+	ListRoles(context.Context) ([]*users.Role, error)
+	GetRole(context.Context, string) (*users.Role, error)
+	SaveRole(context.Context, *users.Role) error
+	DeleteRole(context.Context, string) error
+	IsPasswordExpired(context.Context, *users.User) (bool, error)
+	CheckLockout(context.Context, string) error
+	AddFailedLogin(context.Context, string) (bool, error)
+	ResetFailedLogins(context.Context, string) error
+	GetLockouts(context.Context) (map[string]*users.Lockout, error)
+	Unlock(context.Context, string) error
 }
 
 func NewAPIListener(
@@ -128,19 +150,28 @@ func NewAPIListener(
 
 	vaultLogger := logger.NewLogger("vault", config.Logging.LogOutput, config.Logging.LogLevel)
 
+	dbBackend := config.Backend()
+
 	vaultDBProviderFactory := vault.NewStatefulDbProviderFactory(
 		func() (vault.DbProvider, error) {
-			return vault.NewSqliteProvider(config, vaultLogger)
+			if dbBackend.IsSQLite() {
+				return vault.NewSqliteProvider(config, vaultLogger)
+			}
+			vaultDB, err := dbBackend.Open(vault.Store, vault.DataSourceOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed init vault DB instance: %w", err)
+			}
+			return vault.NewProvider(vaultDB, vaultLogger), nil
 		},
 		&vault.NotInitDbProvider{},
 	)
 
-	db, err := sqlite.New(
-		path.Join(config.Server.DataDir, "notifications.db"),
-		notificationsSQLite.AssetNames(),
-		notificationsSQLite.Asset,
-		config.Server.GetSQLiteDataSourceOptions(),
-	)
+	db, err := dbBackend.Open(backend.Store{
+		Name:           "notifications",
+		SQLiteFilename: "notifications.db",
+		AssetNames:     notificationsSQLite.AssetNames(),
+		Asset:          notificationsSQLite.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to bootstrap api: %v", err)
 	}
@@ -168,11 +199,13 @@ func NewAPIListener(
 	notificationProcessor := notifications.NewProcessor(notificationsLogger, store, notificationConsumers...)
 	notificationsCleaner := notificationsSQLite.StartCleaner(notificationLogger, store, config.Notifications.LogStorageDuration, config.Notifications.CleanupInterval)
 
-	// init vault DB if it already exists
-	fs := files.NewFileSystem()
-	exist, err := fs.Exist(config.GetVaultDBPath())
-	if err != nil {
-		return nil, fmt.Errorf("failed to check if vault DB %q exists: %v", config.GetVaultDBPath(), err)
+	// init vault DB if it already exists, a shared database always has the vault tables
+	exist := !dbBackend.IsSQLite()
+	if dbBackend.IsSQLite() {
+		exist, err = files.NewFileSystem().Exist(config.GetVaultDBPath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check if vault DB %q exists: %v", config.GetVaultDBPath(), err)
+		}
 	}
 	if exist {
 		err := vaultDBProviderFactory.Init()
@@ -181,26 +214,46 @@ func NewAPIListener(
 		}
 	}
 
-	libraryDb, err := sqlite.New(
-		path.Join(config.Server.DataDir, "library.db"),
-		library.AssetNames(),
-		library.Asset,
-		config.Server.GetSQLiteDataSourceOptions(),
-	)
+	libraryDb, err := dbBackend.Open(backend.Store{
+		Name:           "library",
+		SQLiteFilename: "library.db",
+		AssetNames:     library.AssetNames(),
+		Asset:          library.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed init library DB instance: %w", err)
 	}
 
-	apiTokenDb, err := sqlite.New(
-		path.Join(config.Server.DataDir, "api_token.db"),
-		api_token.AssetNames(),
-		api_token.Asset,
-		config.Server.GetSQLiteDataSourceOptions(),
-	)
+	apiTokenDb, err := dbBackend.Open(backend.Store{
+		Name:           "api_token",
+		SQLiteFilename: "api_token.db",
+		AssetNames:     api_token.AssetNames(),
+		Asset:          api_token.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed init api_token DB instance: %w", err)
 	}
 
+	rolesDb, err := dbBackend.Open(backend.Store{
+		Name:           "roles",
+		SQLiteFilename: "roles.db",
+		AssetNames:     roles.AssetNames(),
+		Asset:          roles.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed init roles DB instance: %w", err)
+	}
+
+	passwordPolicyDb, err := dbBackend.Open(backend.Store{
+		Name:           "password_policy",
+		SQLiteFilename: "password_policy.db",
+		AssetNames:     password_policy.AssetNames(),
+		Asset:          password_policy.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed init password_policy DB instance: %w", err)
+	}
+
 	scriptLogger := logger.NewLogger("scripts", config.Logging.LogOutput, config.Logging.LogLevel)
 	scriptProvider := script.NewSqliteProvider(libraryDb)
 	scriptManager := script.NewManager(scriptProvider, scriptLogger)
@@ -215,6 +268,10 @@ func NewAPIListener(
 	if err != nil {
 		return nil, fmt.Errorf("failed init api users service: %w", err)
 	}
+	roleProvider := users.NewRoleSqliteProvider(rolesDb)
+	userService.RoleProvider = roleProvider
+	passwordPolicyProvider := users.NewPasswordPolicySqliteProvider(passwordPolicyDb)
+	userService.PasswordPolicyProvider = passwordPolicyProvider
 
 	var HTTPServerOptions []chshare.ServerOption
 	if config.API.CertFile != "" && config.API.KeyFile != "" {
@@ -246,7 +303,9 @@ func NewAPIListener(
 		scriptManager:          scriptManager,
 		commandManager:         commandManager,
 		tokenManager:           tokenManager,
-		storedTunnels:          storedtunnels.New(server.clientDB),
+		roleProvider:           roleProvider,
+		passwordPolicyProvider: passwordPolicyProvider,
+		storedTunnels:          server.storedTunnels,
 		notificationsStorage:   store,
 		notificationsProcessor: notificationProcessor,
 		notificationsDB:        db,
@@ -293,16 +352,41 @@ func NewAPIListener(
 		a.Logger.Infof("2FA is enabled via using %s", config.API.TwoFATokenDelivery)
 	}
 
-	if config.API.TotPEnabled {
+	if config.API.TotPEnabled || config.API.WebAuthnEnabled {
 		a.twoFASrv = NewTwoFAService(
 			config.API.TwoFATokenTTLSeconds,
 			config.API.TwoFASendTimeout,
 			userService,
 			nil,
 		)
+	}
+	if config.API.TotPEnabled {
 		a.Logger.Infof("2FA is enabled via an Authenticator app")
 	}
 
+	if config.API.WebAuthnEnabled {
+		webAuthnDb, err := dbBackend.Open(backend.Store{
+			Name:           "webauthn",
+			SQLiteFilename: "webauthn.db",
+			AssetNames:     webauthnMigration.AssetNames(),
+			Asset:          webauthnMigration.Asset,
+		}, config.Server.GetSQLiteDataSourceOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed init webauthn DB instance: %w", err)
+		}
+		a.webAuthnSrv, err = webauthn.NewService(webauthn.Config{
+			RPID:    config.API.WebAuthnRPID,
+			RPName:  config.API.WebAuthnRPName,
+			Origins: config.API.WebAuthnOrigins,
+			Timeout: config.API.WebAuthnTimeout,
+		}, webauthn.NewSqliteProvider(webAuthnDb))
+		if err != nil {
+			return nil, fmt.Errorf("failed init webauthn: %w", err)
+		}
+		a.twoFASrv.WebAuthnSrv = a.webAuthnSrv
+		a.Logger.Infof("2FA is enabled via WebAuthn security keys for %s", config.API.WebAuthnRPID)
+	}
+
 	if config.API.MaxFailedLogin > 0 && config.API.BanTime > 0 {
 		a.bannedIPs = security.NewMaxBadAttemptsBanList(
 			config.API.MaxFailedLogin,
@@ -319,10 +403,11 @@ func NewAPIListener(
 		a.accessLogFile = accessLogFile
 	}
 
-	sessionDB, err := session.NewSqliteProvider(path.Join(config.Server.DataDir, "api_sessions.db"), config.Server.GetSQLiteDataSourceOptions())
+	sessionsDB, err := dbBackend.Open(session.Store, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("unable to create api session DB instance: %w", err)
 	}
+	sessionDB := session.NewProvider(sessionsDB)
 
 	a.apiSessions, err = session.NewCache(ctx, bearer.DefaultTokenLifetime, cleanupAPISessionsInterval, sessionDB, nil)
 	if err != nil {
@@ -376,6 +461,15 @@ func (al *APIListener) Close() error {
 	if al.apiSessions != nil {
 		g.Go(al.apiSessions.Close)
 	}
+	if al.roleProvider != nil {
+		g.Go(al.roleProvider.Close)
+	}
+	if al.webAuthnSrv != nil {
+		g.Go(al.webAuthnSrv.Close)
+	}
+	if al.passwordPolicyProvider != nil {
+		g.Go(al.passwordPolicyProvider.Close)
+	}
 
 	g.Go(al.notificationsStorage.Close)
 	g.Go(al.notificationsProcessor.Close)
@@ -394,98 +488,143 @@ var ErrPrefixNotFound = errors.New("there is no token with that prefix")
 var ErrInvalidScopeOfThatToken = errors.New("the scope of the provided token is not authorized for this operation")
 var ErrThatTokenHasExpired = errors.New("the provided token has expired")
 
-// lookupUser is used to get the user on every request in auth middleware
-func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, err error) {
+// isTokenScopeError returns true if a valid API token is not allowed to be used for the request.
+func isTokenScopeError(err error) bool {
+	return errors.Is(err, ErrInvalidScopeOfThatToken) ||
+		errors.Is(err, authorization.ErrTokenRouteNotAllowed) ||
+		errors.Is(err, authorization.ErrTokenSourceIPNotAllowed)
+}
+
+// lookupUser is used to get the user on every request in auth middleware, restrictions are set if the user
+// authorized with an API token.
+func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, restrictions *authorization.APITokenRestrictions, err error) {
 	if !isBearerOnly {
 		if basicUser, basicPwd, basicAuthProvided := r.BasicAuth(); basicAuthProvided {
-			return al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+			return al.handleBasicAuth(r, basicUser, basicPwd)
 		}
 	}
 
 	if bearerToken, bearerAuthProvided := bearer.GetBearerToken(r); bearerAuthProvided {
 		isAuthorized, token, err := al.checkBearerToken(r.Context(), bearerToken, r.URL.Path, r.Method)
 		if err != nil {
-			return isAuthorized, "", err
+			return isAuthorized, "", nil, err
 		}
 
-		return isAuthorized, token.AppClaims.Username, nil
+		return isAuthorized, token.AppClaims.Username, nil, nil
 	}
 
 	// case when no auth method is provided
 	if al.bannedUsers.IsBanned("") {
-		return false, "", ErrTooManyRequests
+		return false, "", nil, ErrTooManyRequests
 	}
 
-	return false, "", nil
+	return false, "", nil, nil
 }
 
-// handleBasicAuth checks username and password against either user's password or token
-func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, username, password string) (authorized bool, name string, err error) {
+// handleBasicAuth checks username and password against either user's password or token. Failed password checks are
+// counted to lock the user after too many attempts, API tokens aren't counted and still work for a locked user.
+func (al *APIListener) handleBasicAuth(r *http.Request, username, password string) (authorized bool, name string, restrictions *authorization.APITokenRestrictions, err error) {
 	if al.bannedUsers.IsBanned(username) {
-		return false, username, ErrTooManyRequests
+		return false, username, nil, ErrTooManyRequests
 	}
 
 	if username == "" {
-		return false, "", nil
+		return false, "", nil, nil
 	}
 
 	user, err := al.userService.GetByUsername(username)
 	if err != nil {
-		return false, username, fmt.Errorf("failed to get user: %v", err)
+		return false, username, nil, fmt.Errorf("failed to get user: %v", err)
 	}
 	if user == nil {
-		return false, username, nil
+		return false, username, nil, nil
 	}
 
-	if user.PasswordExpired != nil && *user.PasswordExpired {
-		return false, username, ErrThatPasswordHasExpired
+	passwordExpired, err := al.userService.IsPasswordExpired(r.Context(), user)
+	if err != nil {
+		return false, username, nil, err
 	}
-
-	// skip basic auth with password when 2fa is enabled
-	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
-		passwordOk := verifyPassword(user.Password, password)
-		if passwordOk {
-			return true, username, nil
-		}
+	if passwordExpired {
+		return false, username, nil, ErrThatPasswordHasExpired
 	}
 
 	// only check token if we have one saved == I can't know if I have the token for this operation until I check the prefix inside the db
 	//   only check token if the prefix gives notificationsSQLite a match with the db
 	// TODO: this type of tokens "User tokens", meant to be used by scripts - used in place of the password at each request - should be renamed "passwords" or "long lived passwords" or "encrypted long lived passwords"
-	prefix, password, err := authorization.Extract(password)
+	if prefix, token, err := authorization.Extract(password); err == nil {
+		userToken, err := al.tokenManager.Get(r.Context(), username, prefix)
+		if err != nil {
+			return false, username, nil, err
+		}
+		if userToken != nil {
+			return al.checkAPIToken(r, userToken, token)
+		}
+	}
+
+	// skip basic auth with password when 2fa is enabled
+	if al.config.API.IsTwoFAOn() || al.config.API.TotPEnabled {
+		return false, username, nil, nil
+	}
+
+	authorized, err = al.checkUserPassword(r.Context(), user, password)
+	return authorized, username, nil, err
+}
+
+// checkUserPassword checks the password of a user who isn't locked and counts a wrong password as failed login.
+func (al *APIListener) checkUserPassword(ctx context.Context, user *users.User, password string) (bool, error) {
+	if err := al.userService.CheckLockout(ctx, user.Username); err != nil {
+		return false, err
+	}
+
+	passwordOk, err := al.verifyUserPassword(user, password)
 	if err != nil {
-		return false, username, nil
+		return false, err
+	}
+	if passwordOk {
+		return true, al.userService.ResetFailedLogins(ctx, user.Username)
 	}
-	userToken, err := al.tokenManager.Get(ctx, username, prefix)
+	return false, al.addFailedLogin(ctx, user.Username)
+}
+
+// addFailedLogin counts a failed login of the user and logs if the user got locked.
+func (al *APIListener) addFailedLogin(ctx context.Context, username string) error {
+	locked, err := al.userService.AddFailedLogin(ctx, username)
 	if err != nil {
-		return false, username, err
+		return err
 	}
+	if locked {
+		al.Infof("User %s is locked after %d failed login attempts.", username, al.config.API.UserLockoutThreshold)
+	}
+	return nil
+}
 
-	if userToken != nil {
-		if userToken.ExpiresAt != nil {
-			if userToken.ExpiresAt.Before(time.Now()) {
-				return false, username, nil
-			}
+func (al *APIListener) checkAPIToken(r *http.Request, userToken *authorization.APIToken, token string) (authorized bool, name string, restrictions *authorization.APITokenRestrictions, err error) {
+	username := userToken.Username
+	if userToken.ExpiresAt != nil {
+		if userToken.ExpiresAt.Before(time.Now()) {
+			return false, username, nil, nil
 		}
-		tokenOk := verifyPassword(userToken.Token, password)
-		if tokenOk {
-			switch userToken.Scope {
-			case authorization.APITokenRead:
-				if httpverb == "GET" && !strings.Contains(urlpath, "/ws") {
-					return true, username, nil
-				}
-			case authorization.APITokenReadWrite:
-				return true, username, nil
-			case authorization.APITokenClientsAuth:
-				if strings.Contains(urlpath, "clients-auth") {
-					return true, username, nil
-				}
-			}
-			return false, username, ErrInvalidScopeOfThatToken
+	}
+	tokenOk := verifyPassword(userToken.Token, token)
+	if !tokenOk {
+		return false, username, nil, nil
+	}
+	if err := userToken.Restrictions.AllowsRequest(r.Method, r.URL.Path, chshare.RemoteIP(r)); err != nil {
+		return false, username, nil, err
+	}
+	switch userToken.Scope {
+	case authorization.APITokenRead:
+		if r.Method == "GET" && !strings.Contains(r.URL.Path, "/ws") {
+			return true, username, userToken.Restrictions, nil
+		}
+	case authorization.APITokenReadWrite:
+		return true, username, userToken.Restrictions, nil
+	case authorization.APITokenClientsAuth:
+		if strings.Contains(r.URL.Path, "clients-auth") {
+			return true, username, userToken.Restrictions, nil
 		}
 	}
-
-	return false, username, nil
+	return false, username, nil, ErrInvalidScopeOfThatToken
 }
 
 func (al *APIListener) checkBearerToken(ctx context.Context, bearerToken, uri, method string) (bool, *bearer.TokenContext, error) {
@@ -560,19 +699,33 @@ func (al *APIListener) validateCredentials(username, password string, skipPasswo
 		return true, user, nil
 	}
 
-	return verifyPassword(user.Password, password), user, nil
+	passwordOk, err := al.verifyUserPassword(user, password)
+	return passwordOk, user, err
 }
 
 func (al *APIListener) shouldCreateMissingUser(user *users.User, skipPasswordValidation bool) bool {
 	if user != nil || !skipPasswordValidation {
 		return false
 	}
+	// users of an LDAP directory cannot be created, the provider assigns the default user group itself
+	if al.userService.GetProviderType() == enums.ProviderSourceLDAP {
+		return false
+	}
 	if al.config.API.CreateMissingUsers || !rportplus.IsOAuthPermittedUserList(al.config.PlusConfig) {
 		return true
 	}
 	return false
 }
 
+// verifyUserPassword checks the password with the user provider if it verifies passwords itself, e.g. with an LDAP
+// bind, otherwise with the saved password of the user.
+func (al *APIListener) verifyUserPassword(user *users.User, password string) (bool, error) {
+	if verifier := al.userService.PasswordVerifier(); verifier != nil {
+		return verifier.VerifyPassword(user.Username, password)
+	}
+	return verifyPassword(user.Password, password), nil
+}
+
 func verifyPassword(saved, provided string) bool {
 	// bcrypt hashed password
 	if strings.HasPrefix(saved, htpasswdBcryptPrefix) {
@@ -594,6 +747,7 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var authorized bool
 		var username string
+		var restrictions *authorization.APITokenRestrictions
 		var err error
 
 		tokenStr := r.URL.Query().Get(WebSocketAccessTokenQueryParam)
@@ -601,7 +755,7 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 			basicUser, basicPwd, basicAuthProvided := r.BasicAuth()
 
 			if basicAuthProvided {
-				authorized, username, err = al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+				authorized, username, restrictions, err = al.handleBasicAuth(r, basicUser, basicPwd)
 			} else {
 				if !al.handleBannedIPs(r, false) {
 					return
@@ -622,6 +776,14 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 				al.jsonErrorResponse(w, http.StatusTooManyRequests, err)
 				return
 			}
+			if errors.Is(err, users.ErrUserLocked) {
+				al.jsonError(w, err)
+				return
+			}
+			if isTokenScopeError(err) {
+				al.jsonErrorResponse(w, http.StatusForbidden, err)
+				return
+			}
 			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -637,6 +799,9 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 		}
 
 		newCtx := api.WithUser(r.Context(), username)
+		if restrictions != nil {
+			newCtx = api.WithTokenRestrictions(newCtx, restrictions)
+		}
 		f.ServeHTTP(w, r.WithContext(newCtx))
 	}
 }
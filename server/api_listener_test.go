@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/server/api/users"
 )
@@ -132,3 +133,30 @@ func TestValidateCredentials(t *testing.T) {
 		assert.Equalf(t, gotRes, tc.wantRes, msg)
 	}
 }
+
+type passwordVerifierProviderMock struct {
+	*users.StaticProvider
+	passwords map[string]string
+}
+
+func (p *passwordVerifierProviderMock) VerifyPassword(username, password string) (bool, error) {
+	return password != "" && p.passwords[username] == password, nil
+}
+
+func TestValidateCredentialsWithPasswordVerifier(t *testing.T) {
+	al := &APIListener{}
+	al.userService = users.NewAPIService(&passwordVerifierProviderMock{
+		// users of providers verifying passwords themselves have no saved password
+		StaticProvider: users.NewStaticProvider([]*users.User{{Username: "jdoe"}}),
+		passwords:      map[string]string{"jdoe": "directory-secret"},
+	}, false, 0, -1)
+
+	ok, user, err := al.validateCredentials("jdoe", "directory-secret", false)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "jdoe", user.Username)
+
+	ok, _, err = al.validateCredentials("jdoe", "", false)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -14,6 +15,8 @@ import (
 	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/bearer"
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
 	"github.com/openrport/openrport/server/routes"
 	"github.com/openrport/openrport/share/enums"
@@ -33,6 +36,21 @@ func (al *APIListener) wrapStaticPassModeMiddleware(next http.HandlerFunc) http.
 	}
 }
 
+// wrapPassiveModeMiddleware rejects API requests while the server is passive, its in-memory state is stale.
+func (al *APIListener) wrapPassiveModeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == routes.AllRoutesPrefix+routes.HAStatusRoute || al.elector.IsActive() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		status := al.elector.Status()
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("server %q is passive, active server is %q", status.NodeID, status.ActiveNodeID),
+		})
+	})
+}
+
 func (al *APIListener) wrapAdminAccessMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if al.insecureForTests {
@@ -61,6 +79,21 @@ func (al *APIListener) wrapAdminAccessMiddleware(next http.Handler) http.Handler
 	})
 }
 
+// wrapUnrestrictedTokenMiddleware rejects requests authorized with a restricted API token, e.g. to manage tokens, as
+// they could create or extend tokens without the restrictions.
+func (al *APIListener) wrapUnrestrictedTokenMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.GetTokenRestrictions(r.Context()) != nil {
+			al.jsonError(w, errors2.APIError{
+				HTTPStatus: http.StatusForbidden,
+				Message:    "the provided token is restricted and not allowed to manage tokens",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
+
 func (al *APIListener) wrapTotPEnabledMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if !al.config.API.TotPEnabled {
@@ -72,16 +105,35 @@ func (al *APIListener) wrapTotPEnabledMiddleware(next http.HandlerFunc) http.Han
 	}
 }
 
+func (al *APIListener) wrapWebAuthnEnabledMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !al.config.API.WebAuthnEnabled {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "WebAuthn is disabled")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
 func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareFunc {
 	return func(f http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authorized, username, err := al.lookupUser(r, isBearerOnly)
+			authorized, username, restrictions, err := al.lookupUser(r, isBearerOnly)
 			if err != nil {
 				al.Logf(logger.LogLevelError, err.Error())
 				if errors.Is(err, ErrTooManyRequests) {
 					al.jsonErrorResponse(w, http.StatusTooManyRequests, err)
 					return
 				}
+				if errors.Is(err, users.ErrUserLocked) {
+					al.jsonError(w, err)
+					return
+				}
+				if isTokenScopeError(err) {
+					al.jsonErrorResponse(w, http.StatusForbidden, err)
+					return
+				}
 				al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 				return
 			}
@@ -97,6 +149,9 @@ func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareF
 			}
 
 			newCtx := api.WithUser(r.Context(), username)
+			if restrictions != nil {
+				newCtx = api.WithTokenRestrictions(newCtx, restrictions)
+			}
 
 			token, hasBearerToken := bearer.GetBearerToken(r)
 			if hasBearerToken {
@@ -135,6 +190,7 @@ func (al *APIListener) wrapClientAccessMiddleware(next http.Handler) http.Handle
 		clientGroups, err := al.clientGroupProvider.GetAll(r.Context())
 		if err != nil {
 			al.jsonError(w, err)
+			return
 		}
 		err = al.clientService.CheckClientAccess(clientID, curUser, clientGroups)
 		if err != nil {
@@ -142,10 +198,194 @@ func (al *APIListener) wrapClientAccessMiddleware(next http.Handler) http.Handle
 			return
 		}
 
+		clientScope, err := al.userService.GetClientScope(curUser)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+
+		if clientScope != nil || api.GetTokenRestrictions(r.Context()) != nil {
+			client, err := al.clientService.GetByID(clientID)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+			if client != nil && !clientScope.AllowsClient(client.GetTags(), clientGroupIDs(client, clientGroups)) {
+				al.jsonError(w, errors2.APIError{
+					Message:    fmt.Sprintf("The roles of the current user do not allow to access client with ID %s", clientID),
+					HTTPStatus: http.StatusForbidden,
+				})
+				return
+			}
+			err = al.checkTokenClientsAccess(r.Context(), []*clientdata.Client{client}, clientGroups)
+			if err != nil {
+				al.jsonError(w, err)
+				return
+			}
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
+// checkClientsAccess returns nil if the current user, the roles of the user and the API token used for the request
+// have access to all of the given clients. Otherwise, APIError with 403 is returned.
+func (al *APIListener) checkClientsAccess(ctx context.Context, clients []*clientdata.Client, user *users.User, clientGroups []*cgroups.ClientGroup) error {
+	err := al.clientService.CheckClientsAccess(clients, user, clientGroups)
+	if err != nil {
+		return err
+	}
+	err = al.checkScopeClientsAccess(ctx, clients, clientGroups)
+	if err != nil {
+		return err
+	}
+	return al.checkTokenClientsAccess(ctx, clients, clientGroups)
+}
+
+// checkScopeClientsAccess returns APIError with 403 if the roles granting the permission of the route are limited
+// to other clients.
+func (al *APIListener) checkScopeClientsAccess(ctx context.Context, clients []*clientdata.Client, clientGroups []*cgroups.ClientGroup) error {
+	scope := api.GetResourceScope(ctx)
+	if scope == nil {
+		return nil
+	}
+
+	var clientsWithNoAccess []string
+	for _, client := range clients {
+		if !scope.AllowsClient(client.GetTags(), clientGroupIDs(client, clientGroups)) {
+			clientsWithNoAccess = append(clientsWithNoAccess, client.GetID())
+		}
+	}
+
+	if len(clientsWithNoAccess) > 0 {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("The roles of the current user do not allow to access client(s) with ID(s): %v", strings.Join(clientsWithNoAccess, ", ")),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
+// checkResourceScope returns APIError with 403 if the resource of the request is not selected by the scope of the
+// permission. Multi-client jobs and vault values are checked by their handlers.
+func (al *APIListener) checkResourceScope(r *http.Request, scope *users.RoleResources, permission string) error {
+	vars := mux.Vars(r)
+
+	if clientID := vars[routes.ParamClientID]; clientID != "" {
+		client, err := al.clientService.GetByID(clientID)
+		if err != nil {
+			return err
+		}
+		if client != nil {
+			clientGroups, err := al.clientGroupProvider.GetAll(r.Context())
+			if err != nil {
+				return err
+			}
+			if !scope.AllowsClient(client.GetTags(), clientGroupIDs(client, clientGroups)) {
+				return errors2.APIError{
+					Message:    fmt.Sprintf("The roles of the current user do not allow to access client with ID %s", clientID),
+					HTTPStatus: http.StatusForbidden,
+				}
+			}
+		}
+	}
+
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			path = tpl
+		}
+	}
+
+	allowed := true
+	switch {
+	case permission == users.PermissionScripts && strings.HasSuffix(path, "/library/scripts") && r.Method == http.MethodPost:
+		allowed = scope.AllowsScript("")
+	case permission == users.PermissionScripts && vars[routes.ParamScriptValueID] != "":
+		allowed = scope.AllowsScript(vars[routes.ParamScriptValueID])
+	case permission == users.PermissionCommands && strings.HasSuffix(path, "/library/commands") && r.Method == http.MethodPost:
+		allowed = scope.AllowsCommand("")
+	case permission == users.PermissionCommands && vars[routes.ParamCommandValueID] != "":
+		allowed = scope.AllowsCommand(vars[routes.ParamCommandValueID])
+	case permission == users.PermissionTunnels && strings.HasSuffix(path, "/stored-tunnels") && r.Method == http.MethodPost:
+		allowed = scope.AllowsStoredTunnel("")
+	case permission == users.PermissionTunnels && strings.Contains(path, "/stored-tunnels/"):
+		allowed = scope.AllowsStoredTunnel(vars["tunnel_id"])
+	}
+	if !allowed {
+		return errors2.APIError{
+			Message:    "The roles of the current user do not allow to access this resource",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
+// clientGroupIDs returns the IDs of the given client groups the client belongs to.
+func clientGroupIDs(client *clientdata.Client, clientGroups []*cgroups.ClientGroup) []string {
+	var groupIDs []string
+	for _, group := range clientGroups {
+		if client.BelongsTo(group) {
+			groupIDs = append(groupIDs, group.ID)
+		}
+	}
+	return groupIDs
+}
+
+// allowsClient returns false if the API token used for the request or the roles granting the permission of the route
+// are limited to other clients. It's used to filter listings, single clients are checked by checkClientsAccess.
+func (al *APIListener) allowsClient(ctx context.Context, client *clientdata.Client, clientGroups []*cgroups.ClientGroup) bool {
+	groupIDs := clientGroupIDs(client, clientGroups)
+	return api.GetTokenRestrictions(ctx).AllowsClient(client.GetID(), groupIDs) &&
+		api.GetResourceScope(ctx).AllowsClient(client.GetTags(), groupIDs)
+}
+
+// hasClientLimits returns true if allowsClient may deny clients.
+func hasClientLimits(ctx context.Context) bool {
+	return api.GetTokenRestrictions(ctx) != nil || api.GetResourceScope(ctx) != nil
+}
+
+// allowsClientIDs returns true if allowsClient allows all of the clients with the given IDs. Clients that don't exist
+// anymore are allowed only if the API token is restricted to their ID and the roles are not limited to some clients.
+func (al *APIListener) allowsClientIDs(ctx context.Context, clientIDs []string, clientGroups []*cgroups.ClientGroup) bool {
+	for _, clientID := range clientIDs {
+		client, err := al.clientService.GetByID(clientID)
+		if err != nil || client == nil {
+			if !api.GetTokenRestrictions(ctx).AllowsClient(clientID, nil) || !api.GetResourceScope(ctx).AllowsClient(nil, nil) {
+				return false
+			}
+			continue
+		}
+		if !al.allowsClient(ctx, client, clientGroups) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkTokenClientsAccess returns APIError with 403 if the API token used for the request is restricted to other clients.
+func (al *APIListener) checkTokenClientsAccess(ctx context.Context, clients []*clientdata.Client, clientGroups []*cgroups.ClientGroup) error {
+	restrictions := api.GetTokenRestrictions(ctx)
+	if restrictions == nil {
+		return nil
+	}
+
+	var clientsWithNoAccess []string
+	for _, client := range clients {
+		if !restrictions.AllowsClient(client.GetID(), clientGroupIDs(client, clientGroups)) {
+			clientsWithNoAccess = append(clientsWithNoAccess, client.GetID())
+		}
+	}
+
+	if len(clientsWithNoAccess) > 0 {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("The provided token is not allowed to access client(s) with ID(s): %v", strings.Join(clientsWithNoAccess, ", ")),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
+
 func (al *APIListener) extendedPermissionDeleteTunnelRaw(tunnel *clienttunnel.Tunnel, currUser *users.User) error {
 	// TODO: this should be moved in the permission middleware
 	if rportplus.IsPlusEnabled(al.config.PlusConfig) && !currUser.IsAdmin() && tunnel.Owner != currUser.Username {
@@ -184,6 +424,14 @@ func (al *APIListener) permissionsMiddleware(permission string) mux.MiddlewareFu
 				return
 			}
 
+			if !api.GetTokenRestrictions(r.Context()).HasPermission(permission) {
+				al.jsonError(w, errors2.APIError{
+					Message:    fmt.Sprintf("the provided token does not have %q permission", permission),
+					HTTPStatus: http.StatusForbidden,
+				})
+				return
+			}
+
 			currUser, err := al.getUserModelForAuth(r.Context())
 			if err != nil {
 				al.jsonError(w, err)
@@ -192,11 +440,19 @@ func (al *APIListener) permissionsMiddleware(permission string) mux.MiddlewareFu
 
 			if al.userService.SupportsGroupPermissions() {
 				// Check group permissions only if supported otherwise let pass.
-				err = al.userService.CheckPermission(currUser, permission)
+				scope, err := al.userService.GetPermissionScope(currUser, permission)
 				if err != nil {
 					al.jsonError(w, err)
 					return
 				}
+				if scope != nil {
+					err = al.checkResourceScope(r, scope, permission)
+					if err != nil {
+						al.jsonError(w, err)
+						return
+					}
+					r = r.WithContext(api.WithResourceScope(r.Context(), scope))
+				}
 				if rportplus.IsPlusEnabled(al.config.PlusConfig) &&
 					(permission == users.PermissionTunnels ||
 						permission == users.PermissionCommands ||
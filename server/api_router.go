@@ -41,6 +41,7 @@ func (al *APIListener) initRouter() {
 	secureAPI.HandleFunc("/me", al.handleGetMe).Methods(http.MethodGet)
 	secureAPI.HandleFunc("/me", al.handleChangeMe).Methods(http.MethodPut)
 	secureAPI.HandleFunc("/me/ip", al.handleGetIP).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/me/effective-permissions", al.handleGetMeEffectivePermissions).Methods(http.MethodGet)
 
 	secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodGet)
 	secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodPost)
@@ -48,9 +49,9 @@ func (al *APIListener) initRouter() {
 	secureAPI.HandleFunc("/me/token/{_}", al.handleTokenGone).Methods(http.MethodDelete)
 
 	secureAPI.HandleFunc("/me/tokens", al.handleGetToken).Methods(http.MethodGet)
-	secureAPI.HandleFunc("/me/tokens", al.handlePostToken).Methods(http.MethodPost)
-	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handlePutToken).Methods(http.MethodPut)
-	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handleDeleteToken).Methods(http.MethodDelete)
+	secureAPI.HandleFunc("/me/tokens", al.wrapUnrestrictedTokenMiddleware(al.handlePostToken)).Methods(http.MethodPost)
+	secureAPI.HandleFunc("/me/tokens/{prefix}", al.wrapUnrestrictedTokenMiddleware(al.handlePutToken)).Methods(http.MethodPut)
+	secureAPI.HandleFunc("/me/tokens/{prefix}", al.wrapUnrestrictedTokenMiddleware(al.handleDeleteToken)).Methods(http.MethodDelete)
 
 	secureAPI.HandleFunc("/clients", al.handleGetClients).Methods(http.MethodGet)
 	clientDetails := secureAPI.PathPrefix("/clients/{client_id}").Subrouter()
@@ -70,16 +71,32 @@ func (al *APIListener) initRouter() {
 	clientCommands.HandleFunc("", al.handlePostCommand).Methods(http.MethodPost)
 	clientCommands.HandleFunc("", al.handleGetCommands).Methods(http.MethodGet)
 	clientCommands.HandleFunc("/{job_id}", al.handleGetCommand).Methods(http.MethodGet)
+	clientCommands.HandleFunc("/{job_id}", al.handleCancelCommand).Methods(http.MethodDelete)
 
 	clientTunnels := clientDetails.NewRoute().Subrouter()
 	clientTunnels.Use(al.permissionsMiddleware(users.PermissionTunnels))
 	clientTunnels.HandleFunc("/tunnels", al.handlePutClientTunnel).Methods(http.MethodPut)
 	clientTunnels.HandleFunc("/tunnels/{tunnel_id}", al.handleDeleteClientTunnel).Methods(http.MethodDelete)
 	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/acl", al.handlePutClientTunnelACL).Methods(http.MethodPut)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/tokens", al.handlePostClientTunnelToken).Methods(http.MethodPost)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/shares", al.handleGetClientTunnelShares).Methods(http.MethodGet)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/shares", al.handlePostClientTunnelShare).Methods(http.MethodPost)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/shares/{share_id}", al.handleDeleteClientTunnelShare).Methods(http.MethodDelete)
 	clientTunnels.HandleFunc("/stored-tunnels", al.handleGetStoredTunnels).Methods(http.MethodGet)
 	clientTunnels.HandleFunc("/stored-tunnels", al.handlePostStoredTunnels).Methods(http.MethodPost)
 	clientTunnels.HandleFunc("/stored-tunnels/{tunnel_id}", al.handleDeleteStoredTunnel).Methods(http.MethodDelete)
 	clientTunnels.HandleFunc("/stored-tunnels/{tunnel_id}", al.handlePutStoredTunnel).Methods(http.MethodPut)
+	clientTunnels.HandleFunc("/reverse-tunnels", al.handleGetReverseTunnels).Methods(http.MethodGet)
+	clientTunnels.HandleFunc("/reverse-tunnels", al.handlePutReverseTunnel).Methods(http.MethodPut)
+	clientTunnels.HandleFunc("/reverse-tunnels/{tunnel_id}", al.handleDeleteReverseTunnel).Methods(http.MethodDelete)
+
+	clientFiles := clientDetails.PathPrefix("/files").Subrouter()
+	clientFiles.Use(al.permissionsMiddleware(users.PermissionFiles))
+	clientFiles.Use(al.withActiveClient)
+	clientFiles.HandleFunc("", al.handleListClientFiles).Methods(http.MethodGet)
+	clientFiles.HandleFunc("/stat", al.handleStatClientFile).Methods(http.MethodGet)
+	clientFiles.HandleFunc("/download", al.handleDownloadClientFile).Methods(http.MethodGet)
+	clientFiles.HandleFunc("/tail", al.handleTailClientFile).Methods(http.MethodGet)
 
 	clientMonitoring := clientDetails.NewRoute().Subrouter()
 	clientMonitoring.Use(al.permissionsMiddleware(users.PermissionMonitoring))
@@ -90,19 +107,34 @@ func (al *APIListener) initRouter() {
 		clientMonitoring.HandleFunc("/metrics", al.handleGetClientMetrics).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/processes", al.handleGetClientProcesses).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/mountpoints", al.handleGetClientMountpoints).Methods(http.MethodGet)
+		clientMonitoring.HandleFunc("/tunnel-usage", al.handleGetClientTunnelUsage).Methods(http.MethodGet)
 	} else {
 		clientMonitoring.HandleFunc("/graph-metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/graph-metrics/{"+routes.ParamGraphName+"}", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/processes", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/mountpoints", al.handleMonitoringDisabled).Methods(http.MethodGet)
+		clientMonitoring.HandleFunc("/tunnel-usage", al.handleMonitoringDisabled).Methods(http.MethodGet)
 	}
 
 	secureAPI.HandleFunc("/client-tags", al.handleGetClientTags).Methods(http.MethodGet)
 
 	secureAPI.Handle("/tunnels", al.permissionsMiddleware(users.PermissionTunnels)(http.HandlerFunc(al.handleGetTunnels))).Methods(http.MethodGet)
 	secureAPI.Handle("/auditlog", al.permissionsMiddleware(users.PermissionsAuditLog)(http.HandlerFunc(al.handleListAuditLog))).Methods(http.MethodGet)
+
+	recordings := secureAPI.PathPrefix("/recordings").Subrouter()
+	recordings.Use(al.permissionsMiddleware(users.PermissionsAuditLog))
+	recordings.HandleFunc("", al.handleListRecordings).Methods(http.MethodGet)
+	recordings.HandleFunc("/{"+routes.ParamRecordingID+"}", al.handleGetRecording).Methods(http.MethodGet)
+	recordings.HandleFunc("/{"+routes.ParamRecordingID+"}/cast", al.handleDownloadRecording).Methods(http.MethodGet)
 	secureAPI.Handle("/files", al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleFileUploads))).Methods(http.MethodPost).Name(routes.FilesUploadRouteName)
+	uploads := secureAPI.PathPrefix("/uploads").Subrouter()
+	uploads.Use(al.permissionsMiddleware(users.PermissionUploads))
+	uploads.HandleFunc("", al.handleCreateUpload).Methods(http.MethodPost)
+	uploads.HandleFunc("/{"+routes.ParamUploadID+"}", al.handleGetUpload).Methods(http.MethodGet)
+	uploads.HandleFunc("/{"+routes.ParamUploadID+"}", al.handleHeadUpload).Methods(http.MethodHead)
+	uploads.HandleFunc("/{"+routes.ParamUploadID+"}", al.handleWriteUpload).Methods(http.MethodPatch).Name(routes.UploadChunkRouteName)
+	uploads.HandleFunc("/{"+routes.ParamUploadID+"}", al.handleDeleteUpload).Methods(http.MethodDelete)
 
 	secureAPI.HandleFunc("/client-groups", al.handleGetClientGroups).Methods(http.MethodGet)
 	secureAPI.HandleFunc("/client-groups/{group_id}", al.handleGetClientGroup).Methods(http.MethodGet)
@@ -112,6 +144,10 @@ func (al *APIListener) initRouter() {
 	adminOnly.HandleFunc("/client-groups", al.handlePostClientGroups).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/client-groups/{group_id}", al.handlePutClientGroup).Methods(http.MethodPut)
 	adminOnly.HandleFunc("/client-groups/{group_id}", al.handleDeleteClientGroup).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/client-groups/{group_id}/stored-tunnels", al.handleGetGroupStoredTunnels).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/client-groups/{group_id}/stored-tunnels", al.handlePostGroupStoredTunnels).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/client-groups/{group_id}/stored-tunnels/{tunnel_id}", al.handleDeleteGroupStoredTunnel).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/client-groups/{group_id}/stored-tunnels/{tunnel_id}", al.handlePutGroupStoredTunnel).Methods(http.MethodPut)
 	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleGetUsers)).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPut)
@@ -119,6 +155,8 @@ func (al *APIListener) initRouter() {
 	adminOnly.HandleFunc("/users/{user_id}/totp-secret", al.wrapStaticPassModeMiddleware(
 		al.wrapTotPEnabledMiddleware(al.handleDeleteUsersTotP),
 	)).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/users/{user_id}/lockout", al.wrapStaticPassModeMiddleware(al.handleDeleteUserLockout)).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/users/{user_id}/webauthn-credentials", al.wrapWebAuthnEnabledMiddleware(al.handleDeleteUsersWebAuthnCredentials)).Methods(http.MethodDelete)
 
 	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleGetUserAPISessions).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleDeleteAllUserAPISessions).Methods(http.MethodDelete)
@@ -129,20 +167,37 @@ func (al *APIListener) initRouter() {
 	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleUpdateUserGroup)).Methods(http.MethodPut)
 	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleDeleteUserGroup)).Methods(http.MethodDelete)
 
+	adminOnly.HandleFunc("/roles", al.handleListRoles).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/roles/{role_name}", al.handleGetRole).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/roles/{role_name}", al.handlePutRole).Methods(http.MethodPut)
+	adminOnly.HandleFunc("/roles/{role_name}", al.handleDeleteRole).Methods(http.MethodDelete)
+
 	adminOnly.HandleFunc("/clients-auth", al.handleGetClientsAuth).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleGetClientAuth).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/clients-auth", al.handlePostClientsAuth).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleDeleteClientAuth).Methods(http.MethodDelete)
 
+	adminOnly.HandleFunc("/commands/{job_id}/approve", al.handleApproveMultiClientCommand).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/commands/{job_id}/reject", al.handleRejectMultiClientCommand).Methods(http.MethodPost)
+
 	adminOnly.HandleFunc("/notification-logs", al.handleGetNotifications).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/notification-logs/{notification_id}", al.handleGetNotificationDetails).Methods(http.MethodGet)
 
+	adminOnly.HandleFunc("/client-updates/artefacts", al.handleListClientUpdateArtefacts).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/client-updates/artefacts", al.handlePostClientUpdateArtefact).Methods(http.MethodPost).Name(routes.ClientUpdateArtefactRoute)
+	adminOnly.HandleFunc("/client-updates/artefacts/{version}/{os}/{arch}", al.handleDeleteClientUpdateArtefact).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/client-updates/rollouts", al.handleListClientUpdateRollouts).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/client-updates/rollouts", al.handlePostClientUpdateRollout).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/client-updates/rollouts/{"+routes.ParamRolloutID+"}", al.handleGetClientUpdateRollout).Methods(http.MethodGet)
+
 	commands := secureAPI.NewRoute().Subrouter()
 	commands.Use(al.permissionsMiddleware(users.PermissionCommands))
 	commands.HandleFunc("/commands", al.handlePostMultiClientCommand).Methods(http.MethodPost)
 	commands.HandleFunc("/commands", al.handleGetMultiClientCommands).Methods(http.MethodGet)
 	commands.HandleFunc("/commands/{job_id}", al.handleGetMultiClientCommand).Methods(http.MethodGet)
+	commands.HandleFunc("/commands/{job_id}", al.handleCancelMultiClientCommand).Methods(http.MethodDelete)
 	commands.HandleFunc("/commands/{job_id}/jobs", al.handleGetMultiClientCommandJobs).Methods(http.MethodGet)
+	commands.HandleFunc("/commands/{job_id}/output", al.handleGetCommandOutput).Methods(http.MethodGet)
 	commands.HandleFunc("/library/commands", al.handleListCommands).Methods(http.MethodGet)
 	commands.HandleFunc("/library/commands", al.handleCommandCreate).Methods(http.MethodPost)
 	commands.HandleFunc("/library/commands/{"+routes.ParamCommandValueID+"}", al.handleCommandUpdate).Methods(http.MethodPut)
@@ -182,10 +237,17 @@ func (al *APIListener) initRouter() {
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handlePostTotP)).Methods(http.MethodPost)
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handleDeleteTotP)).Methods(http.MethodDelete)
 
+	secureAPI.HandleFunc(routes.WebAuthnRegistrationRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnRegistration)).Methods(http.MethodPost)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute, al.wrapWebAuthnEnabledMiddleware(al.handleGetWebAuthnCredentials)).Methods(http.MethodGet)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnCredential)).Methods(http.MethodPost)
+	secureAPI.HandleFunc(routes.WebAuthnCredentialsRoute+"/{credential_id}", al.wrapWebAuthnEnabledMiddleware(al.handleDeleteWebAuthnCredential)).Methods(http.MethodDelete)
+	secureAPI.HandleFunc(routes.WebAuthnAssertionRoute, al.wrapWebAuthnEnabledMiddleware(al.handlePostWebAuthnAssertion)).Methods(http.MethodPost)
+
 	// all routes defined below do not have authorization middleware, auth is done in each handler separately
 	api.HandleFunc("/login", al.handleGetLogin).Methods(http.MethodGet)
 	api.HandleFunc("/login", al.handlePostLogin).Methods(http.MethodPost)
 	api.HandleFunc("/logout", al.handleDeleteLogout).Methods(http.MethodDelete)
+	api.HandleFunc(routes.HAStatusRoute, al.handleGetHAStatus).Methods(http.MethodGet)
 	api.Handle(routes.Verify2FaRoute, al.wrapWithAuthMiddleware(true)(al.handlePostVerify2FAToken())).Methods(http.MethodPost)
 
 	// web sockets
@@ -193,6 +255,9 @@ func (al *APIListener) initRouter() {
 	api.HandleFunc("/ws/commands", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(al.handleCommandsWS)))).Methods(http.MethodGet)
 	api.HandleFunc("/ws/scripts", al.wsAuth(al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleScriptsWS)))).Methods(http.MethodGet)
 	api.HandleFunc("/ws/uploads", al.wsAuth(al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleUploadsWS)))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/recordings/{"+routes.ParamRecordingID+"}/replay", al.wsAuth(al.permissionsMiddleware(users.PermissionsAuditLog)(http.HandlerFunc(al.handleReplayRecordingWS)))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/clients/{"+routes.ParamClientID+"}/files/tail", al.wsAuth(al.permissionsMiddleware(users.PermissionFiles)(al.wrapClientAccessMiddleware(al.withActiveClient(http.HandlerFunc(al.handleTailClientFileWS)))))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/clients/{"+routes.ParamClientID+"}/shell", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(al.wrapClientAccessMiddleware(http.HandlerFunc(al.handleShellWS))))).Methods(http.MethodGet)
 
 	if al.config.API.EnableWsTestEndpoints {
 		api.HandleFunc("/test/commands/ui", al.wsCommands)
@@ -204,11 +269,14 @@ func (al *APIListener) initRouter() {
 		api.Use(security.RejectBannedIPs(al.bannedIPs))
 	}
 
+	api.Use(al.wrapPassiveModeMiddleware)
+
 	// add max bytes middleware
 	_ = api.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-		if route.GetName() == routes.FilesUploadRouteName {
+		switch route.GetName() {
+		case routes.FilesUploadRouteName, routes.UploadChunkRouteName, routes.ClientUpdateArtefactRoute:
 			route.HandlerFunc(middleware.MaxBytes(route.GetHandler(), al.config.API.MaxFilePushSize))
-		} else {
+		default:
 			route.HandlerFunc(middleware.MaxBytes(route.GetHandler(), al.config.API.MaxRequestBytes))
 		}
 		return nil
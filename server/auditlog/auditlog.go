@@ -11,6 +11,7 @@ import (
 	"github.com/openrport/openrport/server/api/users"
 	"github.com/openrport/openrport/server/auditlog/config"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/sqlite"
 
 	"github.com/openrport/openrport/server/api"
@@ -32,6 +33,7 @@ var (
 		"affected_id":      true,
 		"client_id":        true,
 		"client_hostname":  true,
+		"recording_id":     true,
 	}
 	supportedSorts = map[string]bool{
 		"timestamp":       true,
@@ -71,27 +73,40 @@ func (e *NotAllowedError) Error() string {
 	return e.Msg
 }
 
-func New(l *logger.Logger, cg ClientGetter, dataDir string, cfg config.Config, dataSourceOptions sqlite.DataSourceOptions) (*AuditLog, error) {
+func New(l *logger.Logger, cg ClientGetter, dbBackend backend.Config, cfg config.Config, dataSourceOptions sqlite.DataSourceOptions) (*AuditLog, error) {
 	a := &AuditLog{
 		logger:       l,
 		clientGetter: cg,
 		config:       cfg,
 	}
 
-	if cfg.Enable {
-		rotation, err := newRotationProvider(
-			l,
-			cfg.RotationPeriod(),
-			dataDir,
-			dataSourceOptions,
-		)
+	if !cfg.Enable {
+		return a, nil
+	}
+
+	if !dbBackend.IsSQLite() {
+		// rotation renames sqlite files, on a shared database all entries are kept in one table
+		db, err := dbBackend.Open(Store, dataSourceOptions)
 		if err != nil {
 			return nil, err
 		}
+		l.Infof("auditlog rotation is not supported with %s, entries are kept in the database", dbBackend.Driver)
+		a.provider = newProvider(db)
+		return a, nil
+	}
 
-		a.provider = rotation
+	rotation, err := newRotationProvider(
+		l,
+		cfg.RotationPeriod(),
+		dbBackend.DataDir,
+		dataSourceOptions,
+	)
+	if err != nil {
+		return nil, err
 	}
 
+	a.provider = rotation
+
 	return a, nil
 }
 
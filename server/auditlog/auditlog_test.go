@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/auditlog"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/server/clients/clientdata"
@@ -21,7 +22,7 @@ func TestNotEnabled(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 
 	mockProvider := &mockProvider{}
-	auditLog, err := New(nil, nil, "", config.Config{Enable: false}, DataSourceOptions)
+	auditLog, err := New(nil, nil, backend.Config{}, config.Config{Enable: false}, DataSourceOptions)
 	require.NoError(t, err)
 	auditLog.provider = mockProvider
 
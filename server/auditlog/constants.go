@@ -8,26 +8,45 @@ const (
 	ActionExecuteDone  = "execute.done"
 	ActionSuccess      = "success"
 	ActionFailed       = "failed"
+	ActionDownload     = "download"
+	ActionTail         = "tail"
+	ActionList         = "list"
+	ActionStat         = "stat"
+	ActionApprove      = "approve"
+	ActionReject       = "reject"
+	ActionCancel       = "cancel"
+	ActionUse          = "use"
 )
 
 const (
-	ApplicationAuthUser        = "auth.user"
-	ApplicationAuthUserMe      = "auth.user.me"
-	ApplicationAuthUserMeToken = "auth.user.me.token" //nolint:gosec
-	ApplicationAuthUserTotP    = "auth.user.totp"
-	ApplicationAuthUserGroup   = "auth.user.group"
-	ApplicationAuthAPISession  = "auth.api.session"
-	ApplicationAuthAPISessions = "auth.api.sessions"
-	ApplicationClient          = "client"
-	ApplicationClientACL       = "client.acl"
-	ApplicationClientAuth      = "client.auth"
-	ApplicationClientGroup     = "client.group"
-	ApplicationClientTunnel    = "client.tunnel"
-	ApplicationClientCommand   = "client.command"
-	ApplicationClientScript    = "client.script"
-	ApplicationLibraryCommand  = "library.command"
-	ApplicationLibraryScript   = "library.script"
-	ApplicationVault           = "vault"
-	ApplicationSchedule        = "schedule"
-	ApplicationUploads         = "uploads"
+	ApplicationAuthUser          = "auth.user"
+	ApplicationAuthUserMe        = "auth.user.me"
+	ApplicationAuthUserMeToken   = "auth.user.me.token" //nolint:gosec
+	ApplicationAuthUserTotP      = "auth.user.totp"
+	ApplicationAuthUserWebAuthn  = "auth.user.webauthn"
+	ApplicationAuthUserLockout   = "auth.user.lockout"
+	ApplicationAuthUserGroup     = "auth.user.group"
+	ApplicationAuthUserRole      = "auth.user.role"
+	ApplicationAuthAPISession    = "auth.api.session"
+	ApplicationAuthAPISessions   = "auth.api.sessions"
+	ApplicationClient            = "client"
+	ApplicationClientACL         = "client.acl"
+	ApplicationClientAuth        = "client.auth"
+	ApplicationClientGroup       = "client.group"
+	ApplicationClientTunnel      = "client.tunnel"
+	ApplicationClientTunnelToken = "client.tunnel.token"
+	ApplicationClientTunnelShare = "client.tunnel.share"
+	ApplicationClientCommand     = "client.command"
+	ApplicationClientScript      = "client.script"
+	ApplicationClientShell       = "client.shell"
+	ApplicationClientFiles       = "client.files"
+	ApplicationLibraryCommand    = "library.command"
+	ApplicationLibraryScript     = "library.script"
+	ApplicationVault             = "vault"
+	ApplicationSchedule          = "schedule"
+	ApplicationUploads           = "uploads"
+
+	ApplicationClientUpdateArtefact = "client.update.artefact"
+	ApplicationClientUpdateRollout  = "client.update.rollout"
+	ApplicationClientReverseTunnel  = "client.reverse_tunnel"
 )
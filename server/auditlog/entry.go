@@ -22,6 +22,7 @@ type Entry struct {
 	ClientHostName string    `db:"client_hostname" json:"client_hostname"`
 	Request        string    `db:"request" json:"request"`
 	Response       string    `db:"response" json:"response"`
+	RecordingID    string    `db:"recording_id" json:"recording_id"`
 
 	al *AuditLog
 }
@@ -46,6 +47,16 @@ func (e *Entry) WithHTTPRequest(req *http.Request) *Entry {
 	return e
 }
 
+// WithRemoteIP is used for requests not made by an authenticated user.
+func (e *Entry) WithRemoteIP(ip string) *Entry {
+	if e == nil {
+		return e
+	}
+
+	e.RemoteIP = ip
+	return e
+}
+
 func (e *Entry) WithRequest(request interface{}) *Entry {
 	if e == nil {
 		return e
@@ -76,6 +87,16 @@ func (e *Entry) WithResponse(response interface{}) *Entry {
 	return e
 }
 
+// WithRecording references the recording of the session the entry belongs to.
+func (e *Entry) WithRecording(id string) *Entry {
+	if e == nil {
+		return e
+	}
+
+	e.RecordingID = id
+	return e
+}
+
 func (e *Entry) WithClient(c *clientdata.Client) *Entry {
 	if e == nil {
 		return e
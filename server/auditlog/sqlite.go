@@ -7,11 +7,19 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/auditlog"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/share/query"
 )
 
+var Store = backend.Store{
+	Name:           "auditlog",
+	SQLiteFilename: sqliteFilename,
+	AssetNames:     auditlog.AssetNames(),
+	Asset:          auditlog.Asset,
+}
+
 type SQLiteProvider struct {
 	db        *sqlx.DB
 	converter *query.SQLConverter
@@ -27,10 +35,14 @@ func newSQLiteProvider(dataDir string, dataSourceOptions sqlite.DataSourceOption
 	if err != nil {
 		return nil, err
 	}
+	return newProvider(db), nil
+}
+
+func newProvider(db *sqlx.DB) *SQLiteProvider {
 	return &SQLiteProvider{
 		db:        db,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SQLiteProvider) Save(e *Entry) error {
@@ -45,7 +57,8 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 			client_id,
 			client_hostname,
 			request,
-			response
+			response,
+			recording_id
 		) VALUES (
 			:timestamp,
 			:username,
@@ -56,7 +69,8 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 			:client_id,
 			:client_hostname,
 			:request,
-			:response
+			:response,
+			:recording_id
 		)`,
 		e,
 	)
@@ -85,6 +99,7 @@ func (p *SQLiteProvider) Count(ctx context.Context, options *query.ListOptions)
 	q := "SELECT COUNT(*) FROM `auditlog`"
 	countOptions := *options
 	countOptions.Pagination = nil
+	countOptions.Sorts = nil
 	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, q)
 
 	err := p.db.GetContext(ctx, &result, q, params...)
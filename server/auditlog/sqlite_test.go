@@ -30,6 +30,7 @@ func TestSqliteSave(t *testing.T) {
 		ClientHostName: "127.0.0.1",
 		Request:        `{"k1": "v1"}`,
 		Response:       `{"k1": "v1"}`,
+		RecordingID:    "cb8a5c1c-7d8e-4b4f-9f57-4a0b0a3e3ac4",
 	}
 	err = dbProv.Save(e)
 	require.NoError(t, err)
@@ -46,6 +47,7 @@ func TestSqliteSave(t *testing.T) {
 			"client_hostname": e.ClientHostName,
 			"request":         e.Request,
 			"response":        e.Response,
+			"recording_id":    e.RecordingID,
 		},
 	}
 	q := "SELECT * FROM auditlog"
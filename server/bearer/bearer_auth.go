@@ -53,6 +53,24 @@ var ScopesTotPCreateOnly = []Scope{
 	},
 }
 
+var ScopesWebAuthnRegisterOnly = []Scope{
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnRegistrationRoute,
+		Method: http.MethodPost,
+	},
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnCredentialsRoute,
+		Method: http.MethodPost,
+	},
+}
+
+var ScopesWebAuthnAssertion = []Scope{
+	{
+		URI:    routes.AllRoutesPrefix + routes.WebAuthnAssertionRoute,
+		Method: http.MethodPost,
+	},
+}
+
 var Scopes2FaCheckOnly = []Scope{
 	{
 		URI:    routes.AllRoutesPrefix + routes.Verify2FaRoute,
@@ -43,6 +43,8 @@ var (
 	ErrUnableToCheckIfAPIKeyFileExists          = errors.New("unable to check if caddy api cert file exists")
 	ErrCaddyAPIKeyFileNotFound                  = errors.New("caddy api key file not found")
 	ErrCaddyUnknownTLSMin                       = errors.New("tls_min not a known tls protocol version")
+	ErrCaddyAcmeWithoutBuiltin                  = errors.New("enable_acme requires the builtin proxy")
+	ErrCaddyAcmeWithCertFile                    = errors.New("cert_file, key_file and enable_acme cannot be used together")
 )
 
 type Config struct {
@@ -56,6 +58,9 @@ type Config struct {
 	APICertFile string `mapstructure:"api_cert_file"`
 	APIKeyFile  string `mapstructure:"api_key_file"`
 	TLSMin      string `mapstructure:"tls_min"`
+	// Builtin serves the subdomain tunnels by rportd itself instead of a caddy process
+	Builtin    bool `mapstructure:"builtin"`
+	EnableAcme bool `mapstructure:"enable_acme"`
 
 	LogLevel         string `mapstructure:"-"` // taken from the rport server log level
 	DataDir          string `mapstructure:"-"` // taken from the rport server datadir
@@ -78,28 +83,18 @@ func existingCaddyLogLevel(loglevel string) (found bool) {
 
 func (c *Config) ParseAndValidate(serverDataDir string, serverLogLevel string, filesAPI files.FileAPI) error {
 	// first check if not configured at all
-	if c.ExecPath == "" && c.HostAddress == "" && c.BaseDomain == "" && c.CertFile == "" && c.KeyFile == "" {
+	if c.ExecPath == "" && c.HostAddress == "" && c.BaseDomain == "" && c.CertFile == "" && c.KeyFile == "" && !c.Builtin {
 		return nil
 	}
 
-	if c.ExecPath == "" {
-		return ErrCaddyExecPathMissing
-	}
-
-	exists, err := filesAPI.Exist(c.ExecPath)
-	if err != nil {
-		return ErrCaddyFailedCheckingExecPath
-	}
-	if !exists {
-		return ErrCaddyExecNotFound
+	if c.EnableAcme && !c.Builtin {
+		return ErrCaddyAcmeWithoutBuiltin
 	}
 
-	version, err := GetExecVersion(c)
-	if err != nil {
-		return ErrCaddyUnableToGetCaddyServerVersion
-	}
-	if version < 2 {
-		return ErrCaddyServerExecutableTooOld
+	if !c.Builtin {
+		if err := c.validateExec(filesAPI); err != nil {
+			return err
+		}
 	}
 
 	if c.HostAddress == "" {
@@ -108,37 +103,18 @@ func (c *Config) ParseAndValidate(serverDataDir string, serverLogLevel string, f
 	if c.BaseDomain == "" {
 		return ErrCaddyTunnelsBaseDomainMissing
 	}
-	if c.CertFile == "" {
-		return ErrCaddyTunnelsWildcardCertFileMissing
-	}
-	if c.KeyFile == "" {
-		return ErrCaddyTunnelsWildcardKeyFileMissing
-	}
 
-	_, _, err = net.SplitHostPort(c.HostAddress)
+	_, _, err := net.SplitHostPort(c.HostAddress)
 	if err != nil {
 		return ErrUnableToGetAddressAndPortFromHostAddress
 	}
 
-	exists, err = filesAPI.Exist(c.CertFile)
-	if err != nil {
-		return ErrUnableToCheckIfCertFileExists
-	}
-	if !exists {
-		return ErrCaddyCertFileNotFound
-	}
-
-	exists, err = filesAPI.Exist(c.KeyFile)
-	if err != nil {
-		return ErrUnableToCheckIfKeyFileExists
-	}
-	if !exists {
-		return ErrCaddyKeyFileNotFound
-	}
-
-	_, err = tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
-	if err != nil {
-		return fmt.Errorf("invalid 'cert_file', 'key_file': %v", err)
+	if c.EnableAcme {
+		if c.CertFile != "" || c.KeyFile != "" {
+			return ErrCaddyAcmeWithCertFile
+		}
+	} else if err := c.validateCertFiles(filesAPI); err != nil {
+		return err
 	}
 
 	if c.APIHostname != "" && c.APIPort == "" {
@@ -149,7 +125,7 @@ func (c *Config) ParseAndValidate(serverDataDir string, serverLogLevel string, f
 	}
 
 	if c.APICertFile != "" {
-		exists, err = filesAPI.Exist(c.APICertFile)
+		exists, err := filesAPI.Exist(c.APICertFile)
 		if err != nil {
 			return ErrUnableToCheckIfAPICertFileExists
 		}
@@ -165,7 +141,7 @@ func (c *Config) ParseAndValidate(serverDataDir string, serverLogLevel string, f
 	}
 
 	if c.APIKeyFile != "" {
-		exists, err = filesAPI.Exist(c.APIKeyFile)
+		exists, err := filesAPI.Exist(c.APIKeyFile)
 		if err != nil {
 			return ErrUnableToCheckIfAPIKeyFileExists
 		}
@@ -191,6 +167,62 @@ func (c *Config) ParseAndValidate(serverDataDir string, serverLogLevel string, f
 	return nil
 }
 
+func (c *Config) validateExec(filesAPI files.FileAPI) error {
+	if c.ExecPath == "" {
+		return ErrCaddyExecPathMissing
+	}
+
+	exists, err := filesAPI.Exist(c.ExecPath)
+	if err != nil {
+		return ErrCaddyFailedCheckingExecPath
+	}
+	if !exists {
+		return ErrCaddyExecNotFound
+	}
+
+	version, err := GetExecVersion(c)
+	if err != nil {
+		return ErrCaddyUnableToGetCaddyServerVersion
+	}
+	if version < 2 {
+		return ErrCaddyServerExecutableTooOld
+	}
+
+	return nil
+}
+
+func (c *Config) validateCertFiles(filesAPI files.FileAPI) error {
+	if c.CertFile == "" {
+		return ErrCaddyTunnelsWildcardCertFileMissing
+	}
+	if c.KeyFile == "" {
+		return ErrCaddyTunnelsWildcardKeyFileMissing
+	}
+
+	exists, err := filesAPI.Exist(c.CertFile)
+	if err != nil {
+		return ErrUnableToCheckIfCertFileExists
+	}
+	if !exists {
+		return ErrCaddyCertFileNotFound
+	}
+
+	exists, err = filesAPI.Exist(c.KeyFile)
+	if err != nil {
+		return ErrUnableToCheckIfKeyFileExists
+	}
+	if !exists {
+		return ErrCaddyKeyFileNotFound
+	}
+
+	_, err = tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return fmt.Errorf("invalid 'cert_file', 'key_file': %v", err)
+	}
+
+	return nil
+}
+
 func (c *Config) APIReverseProxyEnabled() (enabled bool) {
 	return c.APIHostname != "" && c.APIPort != ""
 }
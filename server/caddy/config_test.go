@@ -177,6 +177,76 @@ func TestShouldParseAndValidateCaddyIntegrationConfig(t *testing.T) {
 	}
 }
 
+func TestShouldParseAndValidateBuiltinConfig(t *testing.T) {
+	filesAPI := &mockFileSystem{}
+
+	cases := []struct {
+		Name          string
+		CaddyConfig   caddy.Config
+		ExpectedError error
+	}{
+		{
+			Name: "no caddy executable required",
+			CaddyConfig: caddy.Config{
+				Builtin:     true,
+				HostAddress: "0.0.0.0:443",
+				BaseDomain:  "tunnels.rport.example.com",
+				CertFile:    "../../testdata/certs/tunnels.rport.test.crt",
+				KeyFile:     "../../testdata/certs/tunnels.rport.test.key",
+			},
+		},
+		{
+			Name: "acme instead of cert files",
+			CaddyConfig: caddy.Config{
+				Builtin:     true,
+				EnableAcme:  true,
+				HostAddress: "0.0.0.0:443",
+				BaseDomain:  "tunnels.rport.example.com",
+			},
+		},
+		{
+			Name: "error if acme and cert file",
+			CaddyConfig: caddy.Config{
+				Builtin:     true,
+				EnableAcme:  true,
+				HostAddress: "0.0.0.0:443",
+				BaseDomain:  "tunnels.rport.example.com",
+				CertFile:    "../../testdata/certs/tunnels.rport.test.crt",
+			},
+			ExpectedError: caddy.ErrCaddyAcmeWithCertFile,
+		},
+		{
+			Name: "error if acme without builtin",
+			CaddyConfig: caddy.Config{
+				EnableAcme:  true,
+				HostAddress: "0.0.0.0:443",
+				BaseDomain:  "tunnels.rport.example.com",
+			},
+			ExpectedError: caddy.ErrCaddyAcmeWithoutBuiltin,
+		},
+		{
+			Name: "error if cert file missing",
+			CaddyConfig: caddy.Config{
+				Builtin:     true,
+				HostAddress: "0.0.0.0:443",
+				BaseDomain:  "tunnels.rport.example.com",
+			},
+			ExpectedError: caddy.ErrCaddyTunnelsWildcardCertFileMissing,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.CaddyConfig.ParseAndValidate("datadir", "info", filesAPI)
+			if tc.ExpectedError == nil {
+				assert.NoError(t, err)
+				assert.True(t, tc.CaddyConfig.Enabled)
+			} else {
+				assert.ErrorIs(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
 func TestShouldGenerateBaseConf(t *testing.T) {
 	cfg := &caddy.Config{
 		ExecPath:    "/usr/bin/caddy",
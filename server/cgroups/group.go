@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/share/types"
 )
 
@@ -182,11 +183,11 @@ func (p *ClientParams) Scan(value interface{}) error {
 	if p == nil {
 		return errors.New("'params' cannot be nil")
 	}
-	valueStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("expected to have string, got %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
 	}
-	err := json.Unmarshal([]byte(valueStr), p)
+	err = json.Unmarshal(data, p)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'params' field: %v", err)
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/share/query"
 )
 
@@ -36,7 +37,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context) ([]*ClientGroup, error) {
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		"SELECT * FROM client_groups ORDER BY id COLLATE NOCASE",
+		"SELECT * FROM client_groups ORDER BY LOWER(id)",
 	)
 	if err != nil {
 		return nil, err
@@ -85,7 +86,7 @@ func (p *SqliteProvider) Create(ctx context.Context, group *ClientGroup) error {
 func (p *SqliteProvider) Update(ctx context.Context, group *ClientGroup) error {
 	_, err := p.db.NamedExecContext(
 		ctx,
-		"INSERT OR REPLACE INTO client_groups (id, description, params, allowed_user_groups) VALUES (:id, :description, :params, :allowed_user_groups)",
+		backend.ReplaceInto(p.db.DriverName(), "client_groups", []string{"id"}, "id", "description", "params", "allowed_user_groups"),
 		group,
 	)
 	return err
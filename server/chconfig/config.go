@@ -22,6 +22,7 @@ import (
 
 	"github.com/xhit/go-str2duration/v2"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/sqlite"
 	rportplus "github.com/openrport/openrport/plus"
 	"github.com/openrport/openrport/server/caddy"
@@ -29,6 +30,7 @@ import (
 	"github.com/openrport/openrport/share/files"
 
 	mapset "github.com/deckarep/golang-set"
+	"github.com/go-ldap/ldap/v3"
 	"github.com/jpillora/requestlog"
 	"github.com/pkg/errors"
 
@@ -37,6 +39,8 @@ import (
 	"github.com/openrport/openrport/server/bearer"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
 	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/server/recordings"
+	"github.com/openrport/openrport/server/uploads"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/email"
 	"github.com/openrport/openrport/share/logger"
@@ -66,6 +70,8 @@ type APIConfig struct {
 	MaxTokenLifeTimeHours  int      `mapstructure:"max_token_lifetime"`
 	PasswordMinLength      int      `mapstructure:"password_min_length"`
 	PasswordZxcvbnMinscore int      `mapstructure:"password_zxcvbn_minscore"`
+	PasswordMaxAgeDays     int      `mapstructure:"password_max_age_days"`
+	PasswordHistory        int      `mapstructure:"password_history"`
 	TLSMin                 string   `mapstructure:"tls_min"`
 	EnableWsTestEndpoints  bool     `mapstructure:"enable_ws_test_endpoints"`
 	MaxRequestBytes        int64    `mapstructure:"max_request_bytes"`
@@ -79,10 +85,19 @@ type APIConfig struct {
 	TwoFASendToRegex         string                 `mapstructure:"two_fa_send_to_regex"`
 	TwoFASendToRegexCompiled *regexp.Regexp
 
-	AuditLog                auditlog.Config `mapstructure:",squash"`
-	TotPEnabled             bool            `mapstructure:"totp_enabled"`
-	TotPLoginSessionTimeout time.Duration   `mapstructure:"totp_login_session_ttl"`
-	TotPAccountName         string          `mapstructure:"totp_account_name"`
+	AuditLog                auditlog.Config   `mapstructure:",squash"`
+	Recordings              recordings.Config `mapstructure:",squash"`
+	Uploads                 uploads.Config    `mapstructure:",squash"`
+	TotPEnabled             bool              `mapstructure:"totp_enabled"`
+	TotPLoginSessionTimeout time.Duration     `mapstructure:"totp_login_session_ttl"`
+	TotPAccountName         string            `mapstructure:"totp_account_name"`
+	WebAuthnEnabled         bool              `mapstructure:"webauthn_enabled"`
+	WebAuthnRPID            string            `mapstructure:"webauthn_rp_id"`
+	WebAuthnRPName          string            `mapstructure:"webauthn_rp_name"`
+	WebAuthnOrigins         []string          `mapstructure:"webauthn_origins"`
+	WebAuthnTimeout         time.Duration     `mapstructure:"webauthn_timeout"`
+	UserLockoutThreshold    int               `mapstructure:"user_lockout_threshold"`
+	UserLockoutDuration     time.Duration     `mapstructure:"user_lockout_duration"`
 }
 
 func (c *APIConfig) IsTwoFAOn() bool {
@@ -113,6 +128,7 @@ const (
 	DefaultVaultDBName             = "vault.sqlite.db"
 	NotificationLogStorageDuration = "7d"
 	NotificationLogCleanupInterval = "1d"
+	MinHALeaseDuration             = 3 * time.Second
 
 	socketPrefix = "socket:"
 )
@@ -156,6 +172,10 @@ type ServerConfig struct {
 	BanTime                              int                                    `mapstructure:"ban_time"`
 	InternalTunnelProxyConfig            clienttunnel.InternalTunnelProxyConfig `mapstructure:",squash"`
 	JobsMaxResults                       int                                    `mapstructure:"jobs_max_results"`
+	JobsMaxOutputBytes                   int64                                  `mapstructure:"jobs_max_output_bytes"`
+	MultiJobApprovalRequired             bool                                   `mapstructure:"multi_job_approval_required"`
+	MultiJobApprovalRecipients           []string                               `mapstructure:"multi_job_approval_recipients"`
+	ReverseTunnelAllowed                 []string                               `mapstructure:"reverse_tunnel_allowed"`
 	AcmeHTTPPort                         int                                    `mapstructure:"acme_http_port"`
 
 	// DEPRECATED, only here for backwards compatibility
@@ -174,11 +194,21 @@ type DatabaseConfig struct {
 	User     string `mapstructure:"db_user"`
 	Password string `mapstructure:"db_password"`
 	Name     string `mapstructure:"db_name"`
+	// AllStores moves all server databases (clients, jobs, monitoring etc.) from sqlite files in the data dir to this database.
+	AllStores bool `mapstructure:"db_all_stores"`
 
 	Driver string
 	Dsn    string
 }
 
+type HighAvailabilityConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// NodeID identifies this server in the lease table, defaults to the hostname.
+	NodeID string `mapstructure:"node_id"`
+	// LeaseDuration is how long the active server holds the lease without renewing it.
+	LeaseDuration time.Duration `mapstructure:"lease_duration"`
+}
+
 type PushoverConfig struct {
 	APIToken string `mapstructure:"api_token"`
 	UserKey  string `mapstructure:"user_key"`
@@ -265,6 +295,83 @@ func (c *SMTPConfig) Validate() error {
 	return nil
 }
 
+type LDAPConfig struct {
+	URL                  string        `mapstructure:"url"`
+	StartTLS             bool          `mapstructure:"start_tls"`
+	CAFile               string        `mapstructure:"ca_file"`
+	InsecureSkipVerify   bool          `mapstructure:"insecure_skip_verify"`
+	Timeout              time.Duration `mapstructure:"timeout"`
+	BindDN               string        `mapstructure:"bind_dn"`
+	BindPassword         string        `mapstructure:"bind_password"`
+	BaseDN               string        `mapstructure:"base_dn"`
+	UserFilter           string        `mapstructure:"user_filter"`
+	UsernameAttribute    string        `mapstructure:"username_attribute"`
+	TwoFASendToAttribute string        `mapstructure:"two_fa_send_to_attribute"`
+	GroupBaseDN          string        `mapstructure:"group_base_dn"`
+	GroupFilter          string        `mapstructure:"group_filter"`
+	NestedGroups         bool          `mapstructure:"nested_groups"`
+	GroupMapping         []string      `mapstructure:"group_mapping"`
+	CacheTTL             time.Duration `mapstructure:"cache_ttl"`
+
+	// GroupMappingParsed maps lower-cased LDAP group names and DNs to rport user groups
+	GroupMappingParsed map[string][]string
+}
+
+func (c *LDAPConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+func (c *LDAPConfig) ParseAndValidate() error {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return fmt.Errorf("invalid ldap.url %q, expected e.g. 'ldaps://ldap.example.com:636'", c.URL)
+	}
+	if c.StartTLS && u.Scheme == "ldaps" {
+		return errors.New("ldap.start_tls cannot be used with an 'ldaps://' url")
+	}
+	if c.CAFile != "" {
+		if _, err := os.Stat(c.CAFile); err != nil {
+			return fmt.Errorf("invalid ldap.ca_file: %v", err)
+		}
+	}
+	if c.BaseDN == "" {
+		return errors.New("ldap.base_dn is required")
+	}
+	if c.BindDN != "" && c.BindPassword == "" {
+		return errors.New("ldap.bind_password is required when ldap.bind_dn is set")
+	}
+	if !strings.Contains(c.UserFilter, "{username}") {
+		return errors.New("ldap.user_filter must contain the {username} placeholder")
+	}
+	if _, err := ldap.CompileFilter(strings.ReplaceAll(c.UserFilter, "{username}", "x")); err != nil {
+		return fmt.Errorf("invalid ldap.user_filter: %v", err)
+	}
+	if c.GroupFilter != "" {
+		if _, err := ldap.CompileFilter(strings.NewReplacer("{username}", "x", "{dn}", "x").Replace(c.GroupFilter)); err != nil {
+			return fmt.Errorf("invalid ldap.group_filter: %v", err)
+		}
+	}
+	if c.GroupBaseDN == "" {
+		c.GroupBaseDN = c.BaseDN
+	}
+
+	c.GroupMappingParsed = make(map[string][]string)
+	for _, mapping := range c.GroupMapping {
+		// the rport user group follows the last colon, so LDAP group DNs may contain colons
+		pos := strings.LastIndex(mapping, ":")
+		if pos < 0 {
+			return fmt.Errorf("invalid ldap.group_mapping %q, expected '<ldap group>:<rport user group>'", mapping)
+		}
+		ldapGroup := strings.ToLower(strings.TrimSpace(mapping[:pos]))
+		userGroup := strings.TrimSpace(mapping[pos+1:])
+		if ldapGroup == "" || userGroup == "" {
+			return fmt.Errorf("invalid ldap.group_mapping %q, expected '<ldap group>:<rport user group>'", mapping)
+		}
+		c.GroupMappingParsed[ldapGroup] = append(c.GroupMappingParsed[ldapGroup], userGroup)
+	}
+	return nil
+}
+
 type MonitoringConfig struct {
 	DataStorageDuration string `mapstructure:"data_storage_duration"`
 	DataStorageDays     int64  `mapstructure:"data_storage_days"`
@@ -328,16 +435,18 @@ func (n *NotificationsConfig) parseAndValidateCleanup() error {
 }
 
 type Config struct {
-	Server        ServerConfig         `mapstructure:"server"`
-	Caddy         caddy.Config         `mapstructure:"caddy-integration"`
-	Logging       LogConfig            `mapstructure:"logging"`
-	API           APIConfig            `mapstructure:"api"`
-	Database      DatabaseConfig       `mapstructure:"database"`
-	Pushover      PushoverConfig       `mapstructure:"pushover"`
-	SMTP          SMTPConfig           `mapstructure:"smtp"`
-	Monitoring    MonitoringConfig     `mapstructure:"monitoring"`
-	Notifications NotificationsConfig  `mapstructure:"notifications"`
-	PlusConfig    rportplus.PlusConfig `mapstructure:",squash"`
+	Server        ServerConfig           `mapstructure:"server"`
+	Caddy         caddy.Config           `mapstructure:"caddy-integration"`
+	Logging       LogConfig              `mapstructure:"logging"`
+	API           APIConfig              `mapstructure:"api"`
+	Database      DatabaseConfig         `mapstructure:"database"`
+	HA            HighAvailabilityConfig `mapstructure:"high-availability"`
+	Pushover      PushoverConfig         `mapstructure:"pushover"`
+	SMTP          SMTPConfig             `mapstructure:"smtp"`
+	LDAP          LDAPConfig             `mapstructure:"ldap"`
+	Monitoring    MonitoringConfig       `mapstructure:"monitoring"`
+	Notifications NotificationsConfig    `mapstructure:"notifications"`
+	PlusConfig    rportplus.PlusConfig   `mapstructure:",squash"`
 }
 
 var (
@@ -352,6 +461,10 @@ func (c *Config) GetUploadDir() string {
 	return filepath.Join(c.Server.DataDir, files.DefaultUploadTempFolder)
 }
 
+func (c *Config) GetClientUpdatesDir() string {
+	return filepath.Join(c.Server.DataDir, "client-updates")
+}
+
 func (s *ServerConfig) GetSQLiteDataSourceOptions() sqlite.DataSourceOptions {
 	return sqlite.DataSourceOptions{WALEnabled: s.SqliteWAL}
 }
@@ -399,6 +512,12 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	for _, allowed := range c.Server.ReverseTunnelAllowed {
+		if _, _, err := chshare.ParseTunnelAllowed(allowed); err != nil {
+			return fmt.Errorf("invalid 'reverse_tunnel_allowed': %v", err)
+		}
+	}
+
 	if err := c.Server.InternalTunnelProxyConfig.ParseAndValidate(); err != nil {
 		return err
 	}
@@ -433,6 +552,10 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	if err := c.parseAndValidateHA(); err != nil {
+		return err
+	}
+
 	maxProcs := runtime.GOMAXPROCS(0)
 
 	mLog.Debugf("max_concurrent_ssh_handshakes = %d", c.Server.MaxConcurrentSSHConnectionHandshakes)
@@ -517,6 +640,11 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 			return err
 		}
 
+		err = c.parseAndValidateWebAuthn()
+		if err != nil {
+			return err
+		}
+
 		if c.API.TLSMin != "" && c.API.TLSMin != "1.2" && c.API.TLSMin != "1.3" {
 			return errors.New("TLS must be either 1.2 or 1.3")
 		}
@@ -525,6 +653,11 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 			return fmt.Errorf("max_token_lifetime outside allowable ranges. must be between 0 and %.0f", bearer.DefaultMaxTokenLifetime.Hours())
 		}
 
+		err = c.parseAndValidatePasswordPolicy()
+		if err != nil {
+			return err
+		}
+
 		c.API.CORS = parseAndValidateCORS(mLog, c.API.CORS)
 
 	} else {
@@ -538,6 +671,16 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	err = c.API.Recordings.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = c.API.Uploads.Validate()
+	if err != nil {
+		return err
+	}
+
 	err = c.validateAPIWhenCaddyIntegration()
 	if err != nil {
 		return err
@@ -695,6 +838,43 @@ func (c *Config) parseAndValidateTotPSecret() error {
 	return nil
 }
 
+// parseAndValidateWebAuthn derives the relying party id and the allowed origins from the base_url if not set.
+func (c *Config) parseAndValidateWebAuthn() error {
+	if !c.API.WebAuthnEnabled {
+		return nil
+	}
+	if c.API.TwoFATokenDelivery != "" {
+		return errors.New("conflicting 2FA configuration, two factor auth and webauthn_enabled options cannot be both enabled")
+	}
+
+	var baseURL *url.URL
+	if c.API.BaseURL != "" {
+		baseURL, _ = url.Parse(c.API.BaseURL)
+	}
+	if c.API.WebAuthnRPID == "" && baseURL != nil {
+		c.API.WebAuthnRPID = baseURL.Hostname()
+	}
+	if c.API.WebAuthnRPID == "" {
+		return errors.New("'webauthn_rp_id' or 'base_url' must be set when webauthn is enabled")
+	}
+	if len(c.API.WebAuthnOrigins) == 0 && baseURL != nil && baseURL.Host != "" {
+		c.API.WebAuthnOrigins = []string{baseURL.Scheme + "://" + baseURL.Host}
+	}
+	if len(c.API.WebAuthnOrigins) == 0 {
+		return errors.New("'webauthn_origins' or 'base_url' must be set when webauthn is enabled")
+	}
+	for _, origin := range c.API.WebAuthnOrigins {
+		if err := validateHTTPorHTTPSURL(origin); err != nil {
+			return fmt.Errorf("invalid webauthn_origins: %v", err)
+		}
+	}
+	if c.API.WebAuthnTimeout <= 0 {
+		return errors.New("'webauthn_timeout' must be greater than zero")
+	}
+
+	return nil
+}
+
 func (c *Config) parseAndValidate2FA() error {
 	if c.API.TwoFATokenDelivery == "" {
 		return nil
@@ -727,9 +907,40 @@ func (c *Config) parseAndValidate2FA() error {
 	return fmt.Errorf("unknown 2fa token delivery method: %s", c.API.TwoFATokenDelivery)
 }
 
+func (c *Config) parseAndValidatePasswordPolicy() error {
+	if c.API.PasswordMaxAgeDays < 0 {
+		return errors.New("'password_max_age_days' must not be negative")
+	}
+	if c.API.PasswordHistory < 0 {
+		return errors.New("'password_history' must not be negative")
+	}
+	if c.API.UserLockoutThreshold < 0 {
+		return errors.New("'user_lockout_threshold' must not be negative")
+	}
+	if c.API.UserLockoutDuration < 0 {
+		return errors.New("'user_lockout_duration' must not be negative")
+	}
+	if (c.API.Auth != "" || c.LDAP.Enabled()) && (c.API.PasswordMaxAgeDays > 0 || c.API.PasswordHistory > 0) {
+		return errors.New("'password_max_age_days' and 'password_history' require the passwords to be managed by rport, use 'auth_file' or 'auth_user_table'")
+	}
+	return nil
+}
+
 func (c *Config) parseAndValidateAPIAuth() error {
-	if c.API.AuthFile == "" && c.API.Auth == "" && c.API.AuthUserTable == "" {
-		return errors.New("authentication must be enabled: set either 'auth', 'auth_file' or 'auth_user_table'")
+	if c.API.AuthFile == "" && c.API.Auth == "" && c.API.AuthUserTable == "" && !c.LDAP.Enabled() {
+		return errors.New("authentication must be enabled: set either 'auth', 'auth_file', 'auth_user_table' or 'ldap.url'")
+	}
+
+	if c.LDAP.Enabled() {
+		if c.API.Auth != "" || c.API.AuthFile != "" || c.API.AuthUserTable != "" {
+			return errors.New("'ldap.url' is set: expected none of 'auth', 'auth_file' and 'auth_user_table'")
+		}
+		if c.API.TotPEnabled {
+			return errors.New("'totp_enabled' is not available with LDAP authentication, the secrets cannot be stored in the directory")
+		}
+		if err := c.LDAP.ParseAndValidate(); err != nil {
+			return err
+		}
 	}
 
 	if c.API.AuthFile != "" && c.API.Auth != "" {
@@ -786,6 +997,15 @@ func (c *Config) parseAndValidateAPIHTTPSOptions(mustBeConfigured bool, skipLoad
 	return nil
 }
 
+// IsReverseTunnelAllowed returns true if reverse tunnels can forward connections to a given server-side remote.
+// No reverse tunnels are allowed when 'reverse_tunnel_allowed' is empty.
+func (s *ServerConfig) IsReverseTunnelAllowed(remote string) (bool, error) {
+	if len(s.ReverseTunnelAllowed) == 0 {
+		return false, nil
+	}
+	return chshare.TunnelIsAllowed(s.ReverseTunnelAllowed, remote)
+}
+
 func (s *ServerConfig) parseAndValidatePorts() error {
 	usedPorts, err := ports.TryParsePortRanges(s.UsedPortsRaw)
 	if err != nil {
@@ -844,6 +1064,9 @@ func validateHTTPorHTTPSURL(testURL string) error {
 func (d *DatabaseConfig) ParseAndValidate() error {
 	switch d.Type {
 	case "":
+		if d.AllStores {
+			return errors.New("'db_type' must be set when 'db_all_stores' is enabled")
+		}
 		return nil
 	case "mysql":
 		d.Driver = "mysql"
@@ -865,19 +1088,92 @@ func (d *DatabaseConfig) ParseAndValidate() error {
 		}
 		d.Dsn += "/"
 		d.Dsn += d.Name
+	case "postgres":
+		d.Driver = "postgres"
+		var params []string
+		if d.Host != "" {
+			if strings.HasPrefix(d.Host, socketPrefix) {
+				// libpq expects the directory of the socket
+				params = append(params, "host="+quotePostgresParam(path.Dir(strings.TrimPrefix(d.Host, socketPrefix))))
+			} else if host, port, err := net.SplitHostPort(d.Host); err == nil {
+				params = append(params, "host="+quotePostgresParam(host), "port="+quotePostgresParam(port))
+			} else {
+				params = append(params, "host="+quotePostgresParam(d.Host))
+			}
+		}
+		if d.User != "" {
+			params = append(params, "user="+quotePostgresParam(d.User))
+		}
+		if d.Password != "" {
+			params = append(params, "password="+quotePostgresParam(d.Password))
+		}
+		if d.Name != "" {
+			params = append(params, "dbname="+quotePostgresParam(d.Name))
+		}
+		d.Dsn = strings.Join(params, " ")
 	case "sqlite":
 		d.Driver = "sqlite3"
 		d.Dsn = d.Name
+		if d.AllStores {
+			return errors.New("'db_all_stores' is only supported with 'db_type' 'mysql' or 'postgres'")
+		}
 	default:
-		return fmt.Errorf("invalid 'db_type', expected 'mysql' or 'sqlite', got %q", d.Type)
+		return fmt.Errorf("invalid 'db_type', expected 'mysql', 'postgres' or 'sqlite', got %q", d.Type)
+	}
+
+	return nil
+}
+
+// quotePostgresParam quotes a value of a postgres keyword/value connection string.
+func quotePostgresParam(v string) string {
+	if v != "" && !strings.ContainsAny(v, " '\\") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+func (c *Config) parseAndValidateHA() error {
+	if !c.HA.Enabled {
+		return nil
+	}
+
+	if !c.Database.AllStores {
+		return errors.New("high availability requires a shared database: set 'db_all_stores' in the [database] section")
+	}
+
+	if c.HA.NodeID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname for 'high-availability.node_id': %v", err)
+		}
+		c.HA.NodeID = hostname
+	}
+
+	if c.HA.LeaseDuration < MinHALeaseDuration {
+		return fmt.Errorf("'high-availability.lease_duration' must be at least %v, got %v", MinHALeaseDuration, c.HA.LeaseDuration)
 	}
 
 	return nil
 }
 
+// Backend returns the config of the SQL backend used by all server databases.
+func (c *Config) Backend() backend.Config {
+	cfg := backend.Config{
+		DataDir: c.Server.DataDir,
+	}
+	if c.Database.AllStores {
+		cfg.Driver = c.Database.Driver
+		cfg.DSN = c.Database.Dsn
+	}
+	return cfg
+}
+
 func (d *DatabaseConfig) DsnForLogs() string {
 	if d.Password != "" {
 		// hide the password
+		if d.Type == "postgres" {
+			return strings.Replace(d.Dsn, "password="+quotePostgresParam(d.Password), "password=***", 1)
+		}
 		return strings.Replace(d.Dsn, ":"+d.Password, ":***", 1)
 	}
 	return d.Dsn
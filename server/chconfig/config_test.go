@@ -95,6 +95,19 @@ func TestParseAndValidateServerConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Bad reverse tunnel allowed",
+			Config: Config{
+				Server: ServerConfig{
+					URL:                  []string{"http://localhost/"},
+					DataDir:              "./",
+					Auth:                 "abc:def",
+					UsedPortsRaw:         []string{"10-20"},
+					ReverseTunnelAllowed: []string{"10.0.0.0/33"},
+				},
+			},
+			ExpectedError: "invalid 'reverse_tunnel_allowed': invalid port: \"10.0.0.0/33\"",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -127,7 +140,7 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 			Database: DatabaseConfig{
 				Type: "mongodb",
 			},
-			ExpectedError: "invalid 'db_type', expected 'mysql' or 'sqlite', got \"mongodb\"",
+			ExpectedError: "invalid 'db_type', expected 'mysql', 'postgres' or 'sqlite', got \"mongodb\"",
 		}, {
 			Name: "sqlite",
 			Database: DatabaseConfig{
@@ -172,6 +185,69 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 			},
 			ExpectedDriver: "mysql",
 			ExpectedDSN:    "user:password@tcp(127.0.0.1:3306)/testdb",
+		}, {
+			Name: "postgres defaults",
+			Database: DatabaseConfig{
+				Type: "postgres",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    "",
+		}, {
+			Name: "postgres socket",
+			Database: DatabaseConfig{
+				Type: "postgres",
+				Host: "socket:/var/run/postgresql/.s.PGSQL.5432",
+				Name: "testdb",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    "host=/var/run/postgresql dbname=testdb",
+		}, {
+			Name: "postgres host with user and password",
+			Database: DatabaseConfig{
+				Type:     "postgres",
+				Host:     "127.0.0.1:5432",
+				Name:     "testdb",
+				User:     "user",
+				Password: "pass word's",
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    `host=127.0.0.1 port=5432 user=user password='pass word\'s' dbname=testdb`,
+		}, {
+			Name: "all stores on postgres",
+			Database: DatabaseConfig{
+				Type:      "postgres",
+				Host:      "db.example.com",
+				Name:      "testdb",
+				AllStores: true,
+			},
+			ExpectedDriver: "postgres",
+			ExpectedDSN:    "host=db.example.com dbname=testdb",
+		}, {
+			Name: "all stores on mysql",
+			Database: DatabaseConfig{
+				Type:      "mysql",
+				Host:      "127.0.0.1:3306",
+				Name:      "testdb",
+				AllStores: true,
+			},
+			ExpectedDriver: "mysql",
+			ExpectedDSN:    "tcp(127.0.0.1:3306)/testdb",
+		}, {
+			Name: "all stores on sqlite",
+			Database: DatabaseConfig{
+				Type:      "sqlite",
+				Name:      "/var/lib/rport/rport.db",
+				AllStores: true,
+			},
+			ExpectedDriver: "sqlite3",
+			ExpectedDSN:    "/var/lib/rport/rport.db",
+			ExpectedError:  "'db_all_stores' is only supported with 'db_type' 'mysql' or 'postgres'",
+		}, {
+			Name: "all stores without db",
+			Database: DatabaseConfig{
+				AllStores: true,
+			},
+			ExpectedError: "'db_type' must be set when 'db_all_stores' is enabled",
 		},
 	}
 
@@ -187,6 +263,76 @@ func TestDatabaseParseAndValidate(t *testing.T) {
 	}
 }
 
+func TestDatabaseDsnForLogs(t *testing.T) {
+	mysql := DatabaseConfig{Type: "mysql", Host: "127.0.0.1:3306", Name: "testdb", User: "user", Password: "secret"}
+	require.NoError(t, mysql.ParseAndValidate())
+	assert.Equal(t, "user:***@tcp(127.0.0.1:3306)/testdb", mysql.DsnForLogs())
+
+	postgres := DatabaseConfig{Type: "postgres", Host: "127.0.0.1:5432", Name: "testdb", User: "user", Password: "my secret"}
+	require.NoError(t, postgres.ParseAndValidate())
+	assert.Equal(t, "host=127.0.0.1 port=5432 user=user password=*** dbname=testdb", postgres.DsnForLogs())
+}
+
+func TestParseAndValidateHA(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Config         Config
+		ExpectedNodeID string
+		ExpectedError  string
+	}{
+		{
+			Name:   "disabled",
+			Config: Config{},
+		}, {
+			Name: "without shared database",
+			Config: Config{
+				HA: HighAvailabilityConfig{
+					Enabled:       true,
+					NodeID:        "node-1",
+					LeaseDuration: 15 * time.Second,
+				},
+			},
+			ExpectedNodeID: "node-1",
+			ExpectedError:  "high availability requires a shared database: set 'db_all_stores' in the [database] section",
+		}, {
+			Name: "lease duration too short",
+			Config: Config{
+				Database: DatabaseConfig{AllStores: true},
+				HA: HighAvailabilityConfig{
+					Enabled:       true,
+					NodeID:        "node-1",
+					LeaseDuration: time.Second,
+				},
+			},
+			ExpectedNodeID: "node-1",
+			ExpectedError:  "'high-availability.lease_duration' must be at least 3s, got 1s",
+		}, {
+			Name: "valid",
+			Config: Config{
+				Database: DatabaseConfig{AllStores: true},
+				HA: HighAvailabilityConfig{
+					Enabled:       true,
+					NodeID:        "node-1",
+					LeaseDuration: 15 * time.Second,
+				},
+			},
+			ExpectedNodeID: "node-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Config.parseAndValidateHA()
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.ExpectedNodeID, tc.Config.HA.NodeID)
+		})
+	}
+}
+
 func TestParseAndValidateClientAuth(t *testing.T) {
 	testCases := []struct {
 		Name                 string
@@ -285,6 +431,20 @@ func TestParseAndValidateClientAuth(t *testing.T) {
 	}
 }
 
+func TestParseAndValidateWebAuthnDefaults(t *testing.T) {
+	config := &Config{
+		API: APIConfig{
+			BaseURL:         "https://rport.example.com:3000/",
+			WebAuthnEnabled: true,
+			WebAuthnTimeout: time.Minute,
+		},
+	}
+
+	require.NoError(t, config.parseAndValidateWebAuthn())
+	assert.Equal(t, "rport.example.com", config.API.WebAuthnRPID)
+	assert.Equal(t, []string{"https://rport.example.com:3000"}, config.API.WebAuthnOrigins)
+}
+
 func TestParseAndValidateAPI(t *testing.T) {
 	testCases := []struct {
 		Name                 string
@@ -312,7 +472,7 @@ func TestParseAndValidateAPI(t *testing.T) {
 					Address: "0.0.0.0:3000",
 				},
 			},
-			ExpectedError: "API: authentication must be enabled: set either 'auth', 'auth_file' or 'auth_user_table'",
+			ExpectedError: "API: authentication must be enabled: set either 'auth', 'auth_file', 'auth_user_table' or 'ldap.url'",
 		}, {
 			Name: "api enabled, auth and auth_file",
 			Config: Config{
@@ -376,6 +536,72 @@ func TestParseAndValidateAPI(t *testing.T) {
 					Type: "sqlite",
 				},
 			},
+		}, {
+			Name: "api enabled, ldap and auth_file",
+			Config: Config{
+				API: APIConfig{
+					Address:  "0.0.0.0:3000",
+					AuthFile: "test.json",
+				},
+				LDAP: LDAPConfig{
+					URL: "ldap://ldap.example.com",
+				},
+			},
+			ExpectedError: "API: 'ldap.url' is set: expected none of 'auth', 'auth_file' and 'auth_user_table'",
+		}, {
+			Name: "api enabled, ldap with start_tls and ldaps",
+			Config: Config{
+				API: APIConfig{
+					Address: "0.0.0.0:3000",
+				},
+				LDAP: LDAPConfig{
+					URL:      "ldaps://ldap.example.com",
+					StartTLS: true,
+				},
+			},
+			ExpectedError: "API: ldap.start_tls cannot be used with an 'ldaps://' url",
+		}, {
+			Name: "api enabled, ldap without username placeholder",
+			Config: Config{
+				API: APIConfig{
+					Address: "0.0.0.0:3000",
+				},
+				LDAP: LDAPConfig{
+					URL:        "ldap://ldap.example.com",
+					BaseDN:     "dc=example,dc=com",
+					UserFilter: "(uid=jdoe)",
+				},
+			},
+			ExpectedError: "API: ldap.user_filter must contain the {username} placeholder",
+		}, {
+			Name: "api enabled, ldap with invalid group mapping",
+			Config: Config{
+				API: APIConfig{
+					Address: "0.0.0.0:3000",
+				},
+				LDAP: LDAPConfig{
+					URL:          "ldap://ldap.example.com",
+					BaseDN:       "dc=example,dc=com",
+					UserFilter:   "(uid={username})",
+					GroupMapping: []string{"rport-admins"},
+				},
+			},
+			ExpectedError: "API: invalid ldap.group_mapping \"rport-admins\", expected '<ldap group>:<rport user group>'",
+		}, {
+			Name: "api enabled, valid ldap",
+			Config: Config{
+				API: APIConfig{
+					Address: "0.0.0.0:3000",
+				},
+				LDAP: LDAPConfig{
+					URL:          "ldap://ldap.example.com",
+					StartTLS:     true,
+					BaseDN:       "dc=example,dc=com",
+					UserFilter:   "(&(objectClass=person)(uid={username}))",
+					GroupFilter:  "(member:1.2.840.113556.1.4.1941:={dn})",
+					GroupMapping: []string{"CN=Admins,OU=Groups,DC=example,DC=com:Administrators"},
+				},
+			},
 		}, {
 			Name: "api enabled, valid auth",
 			Config: Config{
@@ -600,6 +826,93 @@ func TestParseAndValidateAPI(t *testing.T) {
 			},
 			ExpectedError: "API: conflicting 2FA configuration, two factor auth and totp_enabled options cannot be both enabled",
 		},
+		{
+			Name: "api enabled, webauthn enabled ok",
+			Config: Config{
+				API: APIConfig{
+					Address:         "0.0.0.0:3000",
+					AuthFile:        "test.json",
+					BaseURL:         "https://rport.example.com:3000",
+					WebAuthnEnabled: true,
+					WebAuthnTimeout: time.Minute,
+				},
+			},
+		},
+		{
+			Name: "api enabled, webauthn enabled, no base url",
+			Config: Config{
+				API: APIConfig{
+					Address:         "0.0.0.0:3000",
+					AuthFile:        "test.json",
+					WebAuthnEnabled: true,
+					WebAuthnTimeout: time.Minute,
+				},
+			},
+			ExpectedError: "API: 'webauthn_rp_id' or 'base_url' must be set when webauthn is enabled",
+		},
+		{
+			Name: "api enabled, webauthn enabled, invalid origin",
+			Config: Config{
+				API: APIConfig{
+					Address:         "0.0.0.0:3000",
+					AuthFile:        "test.json",
+					WebAuthnEnabled: true,
+					WebAuthnRPID:    "example.com",
+					WebAuthnOrigins: []string{"rport.example.com"},
+					WebAuthnTimeout: time.Minute,
+				},
+			},
+			ExpectedError: `API: invalid webauthn_origins: invalid url rport.example.com: parse "rport.example.com": invalid URI for request`,
+		},
+		{
+			Name: "api enabled, webauthn enabled, 2fa enabled, conflict",
+			Config: Config{
+				API: APIConfig{
+					Address:            "0.0.0.0:3000",
+					AuthFile:           "test.json",
+					BaseURL:            "https://rport.example.com",
+					WebAuthnEnabled:    true,
+					TwoFATokenDelivery: "/bin/sh",
+					TwoFASendToType:    message.ValidationRegex,
+				},
+			},
+			ExpectedError: "API: conflicting 2FA configuration, two factor auth and webauthn_enabled options cannot be both enabled",
+		},
+		{
+			Name: "api enabled, password policy ok",
+			Config: Config{
+				API: APIConfig{
+					Address:              "0.0.0.0:3000",
+					AuthFile:             "test.json",
+					PasswordMaxAgeDays:   90,
+					PasswordHistory:      5,
+					UserLockoutThreshold: 5,
+					UserLockoutDuration:  time.Hour,
+				},
+			},
+		},
+		{
+			Name: "api enabled, negative user lockout threshold",
+			Config: Config{
+				API: APIConfig{
+					Address:              "0.0.0.0:3000",
+					AuthFile:             "test.json",
+					UserLockoutThreshold: -1,
+				},
+			},
+			ExpectedError: "API: 'user_lockout_threshold' must not be negative",
+		},
+		{
+			Name: "api enabled, password max age with static user",
+			Config: Config{
+				API: APIConfig{
+					Address:            "0.0.0.0:3000",
+					Auth:               "abc:def",
+					PasswordMaxAgeDays: 90,
+				},
+			},
+			ExpectedError: "API: 'password_max_age_days' and 'password_history' require the passwords to be managed by rport, use 'auth_file' or 'auth_user_table'",
+		},
 		{
 			Name: "api enabled, max token lifetime outside allowed range, negative",
 			Config: Config{
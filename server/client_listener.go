@@ -25,6 +25,7 @@ import (
 	rportplus "github.com/openrport/openrport/plus"
 	alertingcap "github.com/openrport/openrport/plus/capabilities/alerting"
 	"github.com/openrport/openrport/plus/capabilities/alerting/transformers"
+	"github.com/openrport/openrport/server/api/jobs"
 	"github.com/openrport/openrport/server/api/middleware"
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/chconfig"
@@ -238,6 +239,12 @@ func (cl *ClientListener) handleClient(w http.ResponseWriter, r *http.Request) {
 	upgrade := strings.ToLower(r.Header.Get("Upgrade"))
 	protocol := r.Header.Get("Sec-WebSocket-Protocol")
 	if upgrade == "websocket" && strings.HasPrefix(protocol, "rport-") {
+		if !cl.server.elector.IsActive() {
+			// the client moves on to its fallback servers
+			cl.log().Debugf("rejected client connection, server is passive")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
 		if protocol == chshare.ProtocolVersion {
 			cl.handleWebsocket(w, r)
 			return
@@ -396,7 +403,7 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 
 	// now run handler for other client requests and connections
 	go cl.handleSSHRequests(clientLog, clientID, reqs)
-	go cl.handleSSHChannels(clientLog.GetLogger(), chans)
+	go cl.handleSSHChannels(clientLog.GetLogger(), client, chans)
 
 	// wait until we're disconnected from the client
 	if err = sshConn.Wait(); err != nil {
@@ -631,6 +638,18 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 				clientLog.Errorf("Failed to save IPAddresses status: %s", err)
 				continue
 			}
+		case comm.RequestTypeUploadProgress:
+			progress := &models.UploadProgress{}
+			err := json.Unmarshal(r.Payload, progress)
+			if err != nil {
+				clientLog.Errorf("Failed to unmarshal upload progress: %s", err)
+				continue
+			}
+			cl.server.notifyUploadEventListeners(&UploadProgressOutput{
+				ClientID:       clientID,
+				Status:         "progress",
+				UploadProgress: progress,
+			})
 		default:
 			clientLog.Debugf("Unknown request: %s", r.Type)
 		}
@@ -687,13 +706,19 @@ func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to save job result: %s", err)
 	}
+	cl.server.jobOutputs.Notify(resp.JID)
 
 	return &resp, nil
 }
 
-func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-chan ssh.NewChannel) {
+func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, client *clientdata.Client, chans <-chan ssh.NewChannel) {
 	for ch := range chans {
 		ch := ch
+		if ch.ChannelType() == comm.ChannelTypeReverseTunnel {
+			go cl.handleReverseTunnelChannel(clientLog, client, ch)
+			continue
+		}
+
 		extraData := string(ch.ExtraData())
 		stream, reqs, err := ch.Accept()
 		if err != nil {
@@ -725,6 +750,40 @@ func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-ch
 	}
 }
 
+// handleReverseTunnelChannel connects a connection accepted by a reverse tunnel of a client to its server-side
+// destination. Clients open these channels on their own, so only destinations of known reverse tunnels are dialed and
+// the destination is checked again.
+func (cl *ClientListener) handleReverseTunnelChannel(clientLog *logger.Logger, client *clientdata.Client, ch ssh.NewChannel) {
+	tunnelID := string(ch.ExtraData())
+	tunnel := client.FindReverseTunnel(tunnelID)
+	if tunnel == nil {
+		clientLog.Debugf("Rejecting connection of unknown reverse tunnel %q", tunnelID)
+		if err := ch.Reject(ssh.ConnectionFailed, "reverse tunnel not found"); err != nil {
+			clientLog.Debugf("Failed to reject stream: %v", err)
+		}
+		return
+	}
+
+	allowed, err := cl.server.config.Server.IsReverseTunnelAllowed(tunnel.Remote)
+	if err != nil || !allowed {
+		clientLog.Errorf(`Rejecting connection to %q of reverse tunnel %s based on "reverse_tunnel_allowed" config: %v`, tunnel.Remote, tunnelID, err)
+		if err := ch.Reject(ssh.Prohibited, `not allowed with "reverse_tunnel_allowed" config`); err != nil {
+			clientLog.Debugf("Failed to reject stream: %v", err)
+		}
+		return
+	}
+
+	stream, reqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept stream: %s", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	connID := cl.connStats.New()
+	chshare.HandleTCPStream(clientLog.Fork("reverse tunnel#%s conn#%d", tunnelID, connID), &cl.connStats, stream, tunnel.Remote)
+}
+
 type outputChannelData struct {
 	JID        string            `json:"jid"`
 	ClientID   string            `json:"client_id"`
@@ -754,26 +813,32 @@ func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, client
 		ClientName: job.ClientName,
 	}
 
-	data := make([]byte, 4096)
-	for {
-		n, err := stream.Read(data)
-		if err != nil {
-			if err == io.EOF {
-				clientLog.Debugf("Output channel %s for %s stop: %v", typ, wsJID, err)
-				break
+	maxOutputBytes := cl.server.config.Server.JobsMaxOutputBytes
+	persist := maxOutputBytes > 0 && job.JID != ""
+
+	handleData := func(data []byte) {
+		if persist {
+			_, err := cl.server.jobProvider.AppendOutput(context.Background(), job.JID, typ, data, maxOutputBytes)
+			switch {
+			case errors.Is(err, jobs.ErrOutputLimitExceeded):
+				clientLog.Infof("%s, output exceeds %d bytes, no more output is persisted", job.LogPrefix(), maxOutputBytes)
+				persist = false
+			case err != nil:
+				clientLog.Errorf("%s, failed to persist output: %v", job.LogPrefix(), err)
+			default:
+				cl.server.jobOutputs.Notify(job.JID)
 			}
-			return err
 		}
 
 		if ws != nil {
 			switch typ {
 			case models.ChannelStdout:
 				ocd.Result = &models.JobResult{
-					StdOut: string(data[:n]),
+					StdOut: string(data),
 				}
 			case models.ChannelStderr:
 				ocd.Result = &models.JobResult{
-					StdErr: string(data[:n]),
+					StdErr: string(data),
 				}
 			}
 			err := ws.WriteNonFinalJSON(ocd)
@@ -785,6 +850,32 @@ func (cl *ClientListener) handleOutputChannel(typ string, jobData []byte, client
 			clientLog.Debugf("WS conn not found handling output channel. No active listeners connected")
 		}
 	}
+
+	buf := make([]byte, 4096)
+	// the first bytes of a multibyte character split by a read are kept in buf until the rest is read,
+	// so every chunk of output contains whole characters only
+	pending := 0
+	for {
+		n, err := stream.Read(buf[pending:])
+		n += pending
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		complete := n
+		if err == nil {
+			complete -= chshare.IncompleteUTF8Suffix(buf[:n])
+		}
+		if complete > 0 {
+			handleData(buf[:complete])
+		}
+
+		if err == io.EOF {
+			clientLog.Debugf("Output channel %s for %s stop: %v", typ, wsJID, err)
+			break
+		}
+		pending = copy(buf, buf[complete:n])
+	}
 	return nil
 }
 
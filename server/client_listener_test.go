@@ -1,6 +1,7 @@
 package chserver
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"os"
@@ -10,6 +11,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	jobsmigration "github.com/openrport/openrport/db/migration/jobs"
+	"github.com/openrport/openrport/db/sqlite"
+	"github.com/openrport/openrport/server/api/jobs"
+	"github.com/openrport/openrport/server/chconfig"
 	"github.com/openrport/openrport/share/logger"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/ptr"
@@ -18,7 +23,7 @@ import (
 
 func TestHandleOutputChannel(t *testing.T) {
 	log := logger.NewLogger("client-listener-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
-	cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache()}}
+	cl := &ClientListener{server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), config: &chconfig.Config{}}}
 	mockConn := &connMock{}
 	ws := ws.NewConcurrentWebSocket(mockConn, log)
 	cl.server.uiJobWebSockets.Set("test-jid", ws)
@@ -109,6 +114,50 @@ func TestHandleOutputChannel(t *testing.T) {
 	}
 }
 
+func TestHandleOutputChannelPersistsOutput(t *testing.T) {
+	log := logger.NewLogger("client-listener-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, log)
+	defer jp.Close()
+	cl := &ClientListener{server: &Server{
+		uiJobWebSockets: ws.NewWebSocketCache(),
+		jobProvider:     jp,
+		config: &chconfig.Config{
+			Server: chconfig.ServerConfig{
+				JobsMaxOutputBytes: 16,
+			},
+		},
+	}}
+	updates, unsubscribe := cl.server.jobOutputs.Subscribe("test-jid")
+	defer unsubscribe()
+
+	jobData, err := json.Marshal(models.Job{JID: "test-jid"})
+	require.NoError(t, err)
+	reader, writer := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- cl.handleOutputChannel(models.ChannelStdout, jobData, log, reader)
+	}()
+
+	_, err = writer.Write([]byte("first line\n"))
+	require.NoError(t, err)
+	<-updates
+	_, err = writer.Write([]byte("second line\n"))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("dropped"))
+	require.NoError(t, err)
+	writer.Close()
+	require.NoError(t, <-done)
+
+	chunks, err := jp.ListOutput(context.Background(), "test-jid", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "first line\n", chunks[0].Data)
+	assert.Equal(t, "secon", chunks[1].Data)
+	assert.Equal(t, models.ChannelStdout, chunks[1].Stream)
+}
+
 type connMock struct {
 	ws.Conn
 
@@ -0,0 +1,163 @@
+package clients
+
+import (
+	"context"
+	"time"
+
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/storedtunnels"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// AutostartTunnelsProvider returns the stored tunnels of a client and its groups that are started on connect.
+type AutostartTunnelsProvider interface {
+	ListAutostart(ctx context.Context, clientID string, groupIDs []string) ([]*storedtunnels.StoredTunnel, error)
+}
+
+func (s *ClientServiceProvider) SetAutostartTunnels(p AutostartTunnelsProvider, groups cgroups.ClientGroupProvider) {
+	// unguarded as set during initialization
+	s.autostartTunnels = p
+	s.clientGroups = groups
+}
+
+// startAutostartTunnels starts the stored autostart tunnels of a connecting client within their window.
+// Failures are logged only, they must not prevent the client from connecting.
+func (s *ClientServiceProvider) startAutostartTunnels(ctx context.Context, client *clientdata.Client, clog *logger.Logger) {
+	now := time.Now()
+	s.startStoredTunnels(ctx, client, clog, func(st *storedtunnels.StoredTunnel) bool {
+		return st.InAutostartWindow(now)
+	})
+}
+
+// StartScheduledAutostartTunnels starts the stored autostart tunnels of connected clients whose window opened
+// after since. Tunnels are not closed when their window ends.
+func (s *ClientServiceProvider) StartScheduledAutostartTunnels(ctx context.Context, since, now time.Time) {
+	if s.autostartTunnels == nil {
+		return
+	}
+
+	for _, client := range s.repo.GetAllActiveClients() {
+		if client.IsPaused() {
+			continue
+		}
+
+		clog := client.Log()
+		started := s.startStoredTunnels(ctx, client, clog, func(st *storedtunnels.StoredTunnel) bool {
+			return st.AutostartWindowOpened(since, now)
+		})
+		if started == 0 {
+			continue
+		}
+
+		if err := s.repo.Save(client); err != nil {
+			clog.Errorf("failed to save client after autostarting tunnels: %v", err)
+		}
+	}
+}
+
+// startStoredTunnels starts the stored autostart tunnels of the client accepted by inWindow and returns the number
+// of started tunnels.
+func (s *ClientServiceProvider) startStoredTunnels(
+	ctx context.Context,
+	client *clientdata.Client,
+	clog *logger.Logger,
+	inWindow func(st *storedtunnels.StoredTunnel) bool,
+) int {
+	if s.autostartTunnels == nil {
+		return 0
+	}
+
+	var groupIDs []string
+	if s.clientGroups != nil {
+		groups, err := s.clientGroups.GetAll(ctx)
+		if err != nil {
+			clog.Errorf("failed to get client groups for autostart tunnels: %v", err)
+			return 0
+		}
+		for _, g := range groups {
+			if client.BelongsTo(g) {
+				groupIDs = append(groupIDs, g.ID)
+			}
+		}
+	}
+
+	stored, err := s.autostartTunnels.ListAutostart(ctx, client.GetID(), groupIDs)
+	if err != nil {
+		clog.Errorf("failed to get autostart tunnels: %v", err)
+		return 0
+	}
+
+	started := 0
+	for _, st := range stored {
+		if !inWindow(st) {
+			clog.Debugf("stored tunnel %q is outside of its autostart window", st.ID)
+			continue
+		}
+
+		remote, err := st.ToRemote()
+		if err != nil {
+			clog.Errorf("invalid autostart tunnel %q: %v", st.ID, err)
+			continue
+		}
+
+		if hasTunnelToRemote(client, remote) {
+			clog.Debugf("stored tunnel %q is already running", st.ID)
+			continue
+		}
+
+		if remote.IsLocalSpecified() {
+			if err := s.portDistributor.Refresh(); err != nil {
+				clog.Errorf("failed to refresh ports: %v", err)
+				return started
+			}
+			if err := s.checkLocalPort(remote.ListenProtocol(), remote.LocalPort); err != nil {
+				clog.Infof("public port of stored tunnel %q is not available, using a random port: %v", st.ID, err)
+				remote.LocalHost = ""
+				remote.LocalPort = ""
+			}
+		}
+
+		if _, err := s.startClientTunnels(client, []*models.Remote{remote}, clog); err != nil {
+			clog.Errorf("failed to autostart stored tunnel %q: %v", st.ID, err)
+			continue
+		}
+		clog.Infof("autostarted stored tunnel %q: %s", st.ID, remote)
+		started++
+	}
+	return started
+}
+
+type AutostartTunnelsTask struct {
+	cs      ClientService
+	lastRun time.Time
+}
+
+// NewAutostartTunnelsTask returns a task to start the autostart tunnels of connected clients when their window opens.
+func NewAutostartTunnelsTask(cs ClientService) *AutostartTunnelsTask {
+	return &AutostartTunnelsTask{
+		cs: cs,
+	}
+}
+
+func (t *AutostartTunnelsTask) Run(ctx context.Context) error {
+	now := time.Now()
+	// tunnels within their window on the first run were started on connect
+	if !t.lastRun.IsZero() {
+		t.cs.StartScheduledAutostartTunnels(ctx, t.lastRun, now)
+	}
+	t.lastRun = now
+
+	return nil
+}
+
+// hasTunnelToRemote returns true if a tunnel to the same remote is running, e.g. re-established on reconnect.
+func hasTunnelToRemote(client *clientdata.Client, remote *models.Remote) bool {
+	for _, t := range client.GetTunnels() {
+		if t.Remote.Remote() == remote.Remote() && t.Protocol == remote.Protocol {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package clients
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/cgroups"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/clients/storedtunnels"
+	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/share/test"
+)
+
+type mockAutostartTunnels struct {
+	tunnels  []*storedtunnels.StoredTunnel
+	groupIDs []string
+}
+
+func (m *mockAutostartTunnels) ListAutostart(ctx context.Context, clientID string, groupIDs []string) ([]*storedtunnels.StoredTunnel, error) {
+	m.groupIDs = groupIDs
+	return m.tunnels, nil
+}
+
+type mockAutostartGroups struct {
+	cgroups.ClientGroupProvider
+	groups []*cgroups.ClientGroup
+}
+
+func (m mockAutostartGroups) GetAll(ctx context.Context) ([]*cgroups.ClientGroup, error) {
+	return m.groups, nil
+}
+
+func TestStartAutostartTunnels(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte("{ \"IsAllowed\": true }")
+
+	c1 := New(t).ID("client-1").Logger(testLog).Build()
+	c1.Connection = connMock
+	c1.Context = context.Background()
+
+	busy, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+	free, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	freePort := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	allowed := mapset.NewSetFromSlice([]interface{}{busyPort, freePort})
+	pd := ports.NewPortDistributorForTests(allowed, allowed.Clone(), allowed.Clone())
+	cs := NewClientService(&clienttunnel.InternalTunnelProxyConfig{}, pd, NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+
+	remotePort22, remotePort8080, remotePort9090 := 22, 8080, 9090
+	from, until := timeOfDay(time.Now().Add(time.Hour)), timeOfDay(time.Now().Add(2*time.Hour))
+	stored := &mockAutostartTunnels{tunnels: []*storedtunnels.StoredTunnel{
+		{ID: "already-running", Autostart: true, RemoteIP: strPtr("0.0.0.0"), RemotePort: &remotePort22},
+		{ID: "busy-port", Autostart: true, RemotePort: &remotePort8080, PublicPort: &busyPort},
+		{ID: "outside-window", Autostart: true, RemotePort: &remotePort9090, AutostartFrom: &from, AutostartUntil: &until},
+	}}
+	cs.SetAutostartTunnels(stored, mockAutostartGroups{groups: []*cgroups.ClientGroup{
+		{ID: "group-1", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}}},
+		{ID: "group-2", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}}},
+	}})
+
+	cs.startAutostartTunnels(context.Background(), c1, testLog)
+
+	assert.Equal(t, []string{"group-1"}, stored.groupIDs)
+	tunnels := c1.GetTunnels()
+	require.Len(t, tunnels, 3)
+	started := tunnels[2]
+	assert.Equal(t, "8080", started.RemotePort)
+	assert.Equal(t, strconv.Itoa(freePort), started.LocalPort, "busy public port is replaced by a random one")
+	assert.True(t, started.LocalPortRandom)
+
+	require.NoError(t, cs.TerminateTunnel(c1, started, true))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func timeOfDay(t time.Time) string {
+	return t.UTC().Format("15:04")
+}
+
+func TestStartScheduledAutostartTunnels(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte("{ \"IsAllowed\": true }")
+
+	c1 := New(t).ID("client-1").Logger(testLog).Build()
+	c1.Connection = connMock
+	c1.Context = context.Background()
+	c1.Tunnels = nil
+
+	free, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	freePort := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	allowed := mapset.NewSetFromSlice([]interface{}{freePort})
+	pd := ports.NewPortDistributorForTests(allowed, allowed.Clone(), allowed.Clone())
+	cs := NewClientService(&clienttunnel.InternalTunnelProxyConfig{}, pd, NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+
+	remotePort22, remotePort8080 := 22, 8080
+	stored := &mockAutostartTunnels{tunnels: []*storedtunnels.StoredTunnel{
+		{ID: "opened", Autostart: true, RemotePort: &remotePort22, AutostartFrom: strPtr("10:00"), AutostartUntil: strPtr("11:00")},
+		{ID: "not-opened", Autostart: true, RemotePort: &remotePort8080, AutostartFrom: strPtr("12:00"), AutostartUntil: strPtr("13:00")},
+	}}
+	cs.SetAutostartTunnels(stored, nil)
+
+	now := time.Date(2023, 1, 1, 10, 0, 30, 0, time.UTC)
+	cs.StartScheduledAutostartTunnels(context.Background(), now.Add(-time.Minute), now)
+
+	tunnels := c1.GetTunnels()
+	require.Len(t, tunnels, 1)
+	assert.Equal(t, "22", tunnels[0].RemotePort)
+
+	// the window opened before the previous run, the tunnel is not started again after it was closed
+	require.NoError(t, cs.TerminateTunnel(c1, tunnels[0], true))
+	cs.StartScheduledAutostartTunnels(context.Background(), now, now.Add(time.Minute))
+	assert.Len(t, c1.GetTunnels(), 0)
+}
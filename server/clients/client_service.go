@@ -71,6 +71,11 @@ type ClientService interface {
 	GetRepo() *ClientRepository
 
 	SetCaddyAPI(capi caddy.API)
+	SetTunnelRecorder(r TunnelRecorder)
+	SetTunnelUsageSaver(u TunnelUsageSaver)
+	SetSSHSecretReader(r clienttunnel.SSHSecretReader)
+	SetAutostartTunnels(p AutostartTunnelsProvider, groups cgroups.ClientGroupProvider)
+	StartScheduledAutostartTunnels(ctx context.Context, since, now time.Time)
 	StartClientTunnels(client *clientdata.Client, remotes []*models.Remote) ([]*clienttunnel.Tunnel, error)
 	StartTunnel(c *clientdata.Client, r *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error)
 	FindTunnel(c *clientdata.Client, id string) *clienttunnel.Tunnel
@@ -87,6 +92,11 @@ type ClientServiceProvider struct {
 	logger            *logger.Logger
 	acme              *acme.Acme
 	alertingService   alertingcap.Service
+	tunnelRecorder    TunnelRecorder
+	tunnelUsageSaver  TunnelUsageSaver
+	sshSecrets        clienttunnel.SSHSecretReader
+	autostartTunnels  AutostartTunnelsProvider
+	clientGroups      cgroups.ClientGroupProvider
 
 	licensecap licensecap.CapabilityEx
 
@@ -147,6 +157,7 @@ var OptionsSupportedFields = map[string]map[string]bool{
 		"version":                  true,
 		"address":                  true,
 		"tunnels":                  true,
+		"tunnel_stats":             true,
 		"disconnected_at":          true,
 		"last_heartbeat_at":        true,
 		"connection_state":         true,
@@ -412,6 +423,8 @@ func (s *ClientServiceProvider) StartClient(
 		if err != nil {
 			return nil, err
 		}
+
+		s.startAutostartTunnels(ctx, client, clog)
 	}
 
 	err = repo.Save(client)
@@ -515,9 +528,13 @@ func (s *ClientServiceProvider) startClientTunnels(client *clientdata.Client, re
 
 	tunnels := make([]*clienttunnel.Tunnel, 0, len(remotes))
 	for _, remote := range remotes {
+		if remote.Protocol == models.ProtocolSOCKS && remote.HTTPProxy {
+			return nil, apiErrors.NewAPIError(http.StatusBadRequest, "", "tunnel proxy not allowed with protocol socks", nil)
+		}
+
 		if !remote.IsLocalSpecified() {
 			clog.Debugf("no local specified")
-			port, err := s.portDistributor.GetRandomPort(remote.Protocol)
+			port, err := s.portDistributor.GetRandomPort(remote.ListenProtocol())
 			if err != nil {
 				return nil, err
 			}
@@ -527,11 +544,17 @@ func (s *ClientServiceProvider) startClientTunnels(client *clientdata.Client, re
 			clog.Debugf("using random port %s", remote.LocalPort)
 		} else {
 			clog.Debugf("checking local port %s", remote.LocalPort)
-			if err := s.checkLocalPort(remote.Protocol, remote.LocalPort); err != nil {
+			if err := s.checkLocalPort(remote.ListenProtocol(), remote.LocalPort); err != nil {
 				return nil, err
 			}
 		}
 
+		// a socks tunnel reaches every destination allowed by the client, without an acl it's only reachable from the
+		// server itself
+		if remote.Protocol == models.ProtocolSOCKS && remote.ACL == nil {
+			remote.LocalHost = models.LocalHost
+		}
+
 		clog.Debugf("initiating tunnel %+v", remote)
 
 		var acl *clienttunnel.TunnelACL
@@ -577,6 +600,9 @@ func (s *ClientServiceProvider) checkLocalPort(protocol, port string) error {
 
 func (s *ClientServiceProvider) Terminate(client *clientdata.Client) error {
 	s.log().Infof("terminating client: %s: %s", client.GetID(), client.GetName())
+	// the tunnels are closed with the connection of the client
+	s.saveTunnelUsage(client, client.GetTunnels()...)
+
 	keepDisconnectedClientsDuration := s.repo.GetKeepDisconnectedClients()
 	if keepDisconnectedClientsDuration != nil && *keepDisconnectedClientsDuration == 0 {
 		return s.repo.Delete(client)
@@ -787,6 +813,49 @@ func (s *ClientServiceProvider) SetCaddyAPI(capi caddy.API) {
 	s.caddyAPI = capi
 }
 
+// TunnelRecorder records the connections of TCP tunnels.
+type TunnelRecorder interface {
+	RecordTunnelConn(clientID, tunnelID string, remote models.Remote, src net.Addr) (clienttunnel.ConnRecording, error)
+}
+
+func (s *ClientServiceProvider) SetTunnelRecorder(r TunnelRecorder) {
+	// unguarded as set during initialization
+	s.tunnelRecorder = r
+}
+
+// TunnelUsageSaver stores the traffic of a tunnel, that isn't stored yet.
+type TunnelUsageSaver interface {
+	SaveTerminatedTunnelUsage(clientID string, t *clienttunnel.Tunnel)
+}
+
+func (s *ClientServiceProvider) SetTunnelUsageSaver(u TunnelUsageSaver) {
+	// unguarded as set during initialization
+	s.tunnelUsageSaver = u
+}
+
+func (s *ClientServiceProvider) SetSSHSecretReader(r clienttunnel.SSHSecretReader) {
+	// unguarded as set during initialization
+	s.sshSecrets = r
+}
+
+func (s *ClientServiceProvider) saveTunnelUsage(c *clientdata.Client, tunnels ...*clienttunnel.Tunnel) {
+	if s.tunnelUsageSaver == nil {
+		return
+	}
+	for _, t := range tunnels {
+		s.tunnelUsageSaver.SaveTerminatedTunnelUsage(c.GetID(), t)
+	}
+}
+
+func (s *ClientServiceProvider) recordConnFunc(clientID, tunnelID string) clienttunnel.RecordConnFunc {
+	if s.tunnelRecorder == nil {
+		return nil
+	}
+	return func(remote models.Remote, src net.Addr) (clienttunnel.ConnRecording, error) {
+		return s.tunnelRecorder.RecordTunnelConn(clientID, tunnelID, remote, src)
+	}
+}
+
 func (s *ClientServiceProvider) StartTunnel(
 	client *clientdata.Client,
 	remote *models.Remote,
@@ -842,6 +911,10 @@ func (s *ClientServiceProvider) StartTunnel(
 	existingTunnels = append(existingTunnels, tunnel)
 	client.SetTunnels(existingTunnels)
 
+	if tunnel.Health != nil {
+		go s.checkTunnelHealth(ctx, tunnel, client)
+	}
+
 	return tunnel, nil
 }
 
@@ -888,7 +961,7 @@ func (s *ClientServiceProvider) startCaddyDownstreamProxy(
 func (s *ClientServiceProvider) startRegularTunnel(ctx context.Context, client *clientdata.Client, remote *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error) {
 	tunnelID := client.NewTunnelID()
 
-	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl)
+	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl, s.recordConnFunc(client.GetID(), tunnelID))
 	if err != nil {
 		return nil, err
 	}
@@ -924,7 +997,7 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 
 	// reconfigure tunnel local host/addr to use 127.0.0.1 with a random port and make new acl
 	remote.LocalHost = "127.0.0.1"
-	port, err := s.portDistributor.GetRandomPort(remote.Protocol)
+	port, err := s.portDistributor.GetRandomPort(remote.ListenProtocol())
 	if err != nil {
 		return nil, err
 	}
@@ -935,7 +1008,7 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 	tunnelID := client.NewTunnelID()
 
 	// original tunnel will use the reconfigured original remote
-	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl)
+	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl, s.recordConnFunc(clientID, tunnelID))
 	if err != nil {
 		return nil, err
 	}
@@ -948,6 +1021,7 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 
 	// create new proxy tunnel listening at the original tunnel local host addr
 	tProxy := clienttunnel.NewInternalTunnelProxy(t, clientLogger, s.tunnelProxyConfig, proxyHost, proxyPort, proxyACL, s.acme)
+	tProxy.SSHSecrets = s.sshSecrets
 	clientLogger.Debugf("client %s starting tunnel proxy", clientID)
 	if err := tProxy.Start(ctx); err != nil {
 		clientLogger.Debugf("tunnel proxy could not be started, tunnel must be terminated: %v", err)
@@ -1013,6 +1087,7 @@ func (s *ClientServiceProvider) cleanupAfterAutoClose(c *clientdata.Client, t *c
 		}
 	}
 
+	s.saveTunnelUsage(c, t)
 	c.RemoveTunnelByID(t.ID)
 
 	err := s.repo.Save(c)
@@ -1045,6 +1120,7 @@ func (s *ClientServiceProvider) TerminateTunnel(c *clientdata.Client, t *clientt
 		}
 	}
 
+	s.saveTunnelUsage(c, t)
 	c.RemoveTunnelByID(t.ID)
 
 	err = s.repo.Save(c)
@@ -993,3 +993,88 @@ func TestShouldStartTunnelsWithSubdomains(t *testing.T) {
 		})
 	}
 }
+
+type tunnelUsageSaverMock struct {
+	saved []string
+}
+
+func (m *tunnelUsageSaverMock) SaveTerminatedTunnelUsage(clientID string, t *clienttunnel.Tunnel) {
+	m.saved = append(m.saved, clientID+"/"+t.ID)
+}
+
+func TestTunnelUsageSavedOnTermination(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte("{ \"IsAllowed\": true }")
+
+	c1 := New(t).ID("client-1").Logger(testLog).Build()
+	c1.Connection = connMock
+	c1.Context = context.Background()
+	c1.Tunnels = nil
+
+	pd := ports.NewPortDistributorForTests(
+		mapset.NewSetFromSlice([]interface{}{4000, 4001}),
+		mapset.NewSetFromSlice([]interface{}{4000, 4001}),
+		mapset.NewSetFromSlice([]interface{}{4000, 4001}),
+	)
+	clientService := NewClientService(&clienttunnel.InternalTunnelProxyConfig{}, pd, NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+	saver := &tunnelUsageSaverMock{}
+	clientService.SetTunnelUsageSaver(saver)
+
+	r1, err := models.NewRemote("4000:127.0.0.1:22")
+	require.NoError(t, err)
+	r2, err := models.NewRemote("4001:127.0.0.1:80")
+	require.NoError(t, err)
+	tunnels, err := clientService.StartClientTunnels(c1, []*models.Remote{r1, r2})
+	require.NoError(t, err)
+	require.Len(t, tunnels, 2)
+
+	require.NoError(t, clientService.TerminateTunnel(c1, tunnels[0], true))
+	assert.Equal(t, []string{"client-1/" + tunnels[0].ID}, saver.saved)
+
+	// the remaining tunnels are closed with the connection of the client
+	require.NoError(t, clientService.Terminate(c1))
+	assert.Equal(t, []string{"client-1/" + tunnels[0].ID, "client-1/" + tunnels[1].ID}, saver.saved)
+	assert.NoError(t, tunnels[1].Terminate(true))
+}
+
+func TestStartSOCKSTunnel(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+
+	c1 := New(t).ID("client-1").Logger(testLog).Build()
+	c1.Connection = connMock
+	c1.Context = context.Background()
+	c1.Tunnels = nil
+
+	pd := ports.NewPortDistributorForTests(
+		mapset.NewSetFromSlice([]interface{}{4010, 4011}),
+		mapset.NewSetFromSlice([]interface{}{4010, 4011}),
+		mapset.NewSetFromSlice([]interface{}{4010, 4011}),
+	)
+	clientService := NewClientService(&clienttunnel.InternalTunnelProxyConfig{}, pd, NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+
+	withoutACL, err := models.NewRemote("0.0.0.0:4010/socks")
+	require.NoError(t, err)
+	withACL, err := models.NewRemote("0.0.0.0:4011/socks")
+	require.NoError(t, err)
+	acl := "127.0.0.1"
+	withACL.ACL = &acl
+	tunnels, err := clientService.StartClientTunnels(c1, []*models.Remote{withoutACL, withACL})
+	require.NoError(t, err)
+	require.Len(t, tunnels, 2)
+	defer func() {
+		for _, tunnel := range tunnels {
+			assert.NoError(t, clientService.TerminateTunnel(c1, tunnel, true))
+		}
+	}()
+
+	assert.Equal(t, "127.0.0.1", tunnels[0].LocalHost, "socks tunnel without acl must listen on localhost")
+	assert.Equal(t, "0.0.0.0", tunnels[1].LocalHost)
+
+	withHTTPProxy, err := models.NewRemote("/socks")
+	require.NoError(t, err)
+	withHTTPProxy.HTTPProxy = true
+	_, err = clientService.StartClientTunnels(c1, []*models.Remote{withHTTPProxy})
+	assert.EqualError(t, err, "tunnel proxy not allowed with protocol socks")
+}
@@ -80,6 +80,9 @@ type Client struct {
 	IPAddresses         *models.IPAddresses   `json:"ext_ip_addresses"`
 	ClientConfiguration *clientconfig.Config  `json:"client_configuration"`
 
+	// ReverseTunnels are not persisted, the client stops listening when disconnected
+	ReverseTunnels []*clienttunnel.ReverseTunnel `json:"-"`
+
 	Connection   ssh.Conn        `json:"-"`
 	Context      context.Context `json:"-"`
 	Paused       bool            `json:"-"`
@@ -542,6 +545,39 @@ func (c *Client) RemoveTunnelByID(tunnelID string) {
 	c.SetTunnels(updatedTunnelList)
 }
 
+func (c *Client) GetReverseTunnels() []*clienttunnel.ReverseTunnel {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.ReverseTunnels
+}
+
+func (c *Client) FindReverseTunnel(tunnelID string) *clienttunnel.ReverseTunnel {
+	for _, tunnel := range c.GetReverseTunnels() {
+		if tunnel.ID == tunnelID {
+			return tunnel
+		}
+	}
+	return nil
+}
+
+func (c *Client) AddReverseTunnel(tunnel *clienttunnel.ReverseTunnel) {
+	c.flock.Lock()
+	defer c.flock.Unlock()
+	c.ReverseTunnels = append(c.ReverseTunnels, tunnel)
+}
+
+func (c *Client) RemoveReverseTunnel(tunnelID string) {
+	c.flock.Lock()
+	defer c.flock.Unlock()
+	updated := make([]*clienttunnel.ReverseTunnel, 0, len(c.ReverseTunnels))
+	for _, tunnel := range c.ReverseTunnels {
+		if tunnel.ID != tunnelID {
+			updated = append(updated, tunnel)
+		}
+	}
+	c.ReverseTunnels = updated
+}
+
 func (c *Client) Banner() string {
 	clientID := c.GetID()
 	clientName := c.GetName()
@@ -723,6 +759,7 @@ func NewClientFromConnRequest(ctx context.Context, existingClient *Client, clien
 	client.ClientConfiguration = req.ClientConfiguration
 	client.Address = clientHost
 	client.Tunnels = make([]*clienttunnel.Tunnel, 0)
+	client.ReverseTunnels = nil
 	client.DisconnectedAt = nil
 	client.ClientAuthID = clientAuthID
 	client.Connection = sshConn
@@ -0,0 +1,18 @@
+package clienttunnel
+
+import (
+	"time"
+)
+
+// ReverseTunnel exposes a server-side service to a client. The client listens on Local and forwards all connections
+// to the server, which connects them to Remote.
+type ReverseTunnel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Local is the address the client listens on
+	Local string `json:"local"`
+	// Remote is the destination on the server side
+	Remote    string    `json:"remote"`
+	Owner     string    `json:"owner"`
+	CreatedAt time.Time `json:"created_at"`
+}
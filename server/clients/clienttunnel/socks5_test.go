@@ -0,0 +1,171 @@
+package clienttunnel
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+type socksChannelMock struct {
+	ssh.Channel
+	conn net.Conn
+}
+
+func (c *socksChannelMock) Read(p []byte) (int, error)  { return c.conn.Read(p) }
+func (c *socksChannelMock) Write(p []byte) (int, error) { return c.conn.Write(p) }
+func (c *socksChannelMock) Close() error                { return c.conn.Close() }
+
+type socksConnMock struct {
+	ssh.Conn
+	allowed string
+
+	target string
+	client net.Conn
+}
+
+func (c *socksConnMock) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	c.target = string(data)
+	if c.target != c.allowed {
+		return nil, nil, &ssh.OpenChannelError{Reason: ssh.Prohibited}
+	}
+	server, client := net.Pipe()
+	c.client = client
+	return &socksChannelMock{conn: server}, nil, nil
+}
+
+func (c *socksConnMock) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func TestSOCKS5Handshake(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Request        []byte
+		ExpectedTarget string
+		ExpectedError  string
+		ExpectedReply  []byte
+	}{
+		{
+			Name:           "ipv4",
+			Request:        []byte{5, 1, 0, 5, 1, 0, 1, 10, 0, 0, 1, 0, 22},
+			ExpectedTarget: "10.0.0.1:22",
+			ExpectedReply:  []byte{5, 0},
+		},
+		{
+			Name:           "domain",
+			Request:        append(append([]byte{5, 2, 2, 0, 5, 1, 0, 3, 11}, "example.com"...), 1, 187),
+			ExpectedTarget: "example.com:443",
+			ExpectedReply:  []byte{5, 0},
+		},
+		{
+			Name:           "ipv6",
+			Request:        []byte{5, 1, 0, 5, 1, 0, 4, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 80},
+			ExpectedTarget: "[::1]:80",
+			ExpectedReply:  []byte{5, 0},
+		},
+		{
+			Name:          "no auth not offered",
+			Request:       []byte{5, 1, 2},
+			ExpectedError: "no supported authentication method offered",
+			ExpectedReply: []byte{5, 0xff},
+		},
+		{
+			Name:          "bind",
+			Request:       []byte{5, 1, 0, 5, 2, 0, 1, 10, 0, 0, 1, 0, 22},
+			ExpectedError: "unsupported command 2",
+			ExpectedReply: []byte{5, 0, 5, 7, 0, 1, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			Name:          "socks4",
+			Request:       []byte{4, 1, 0, 22, 10, 0, 0, 1, 0},
+			ExpectedError: "unsupported socks version 4",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := &readWriterMock{Reader: bytes.NewReader(tc.Request)}
+			target, err := socks5Handshake(conn)
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.ExpectedTarget, target)
+			}
+			assert.Equal(t, tc.ExpectedReply, conn.written)
+		})
+	}
+}
+
+func TestTunnelTCPWithSOCKS(t *testing.T) {
+	l := logger.NewLogger("socks-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	sshConn := &socksConnMock{allowed: "10.0.0.1:22"}
+	stats := &TunnelStats{}
+	tunnel := newTunnelTCP(l, sshConn, models.Remote{Protocol: models.ProtocolSOCKS}, nil, nil, newTunnelTraffic(stats, 0))
+
+	t.Run("not allowed", func(t *testing.T) {
+		src, peer := net.Pipe()
+		go tunnel.accept(context.Background(), src)
+
+		reply := socksRequest(t, peer, []byte{5, 1, 0, 1, 10, 0, 0, 2, 0, 22})
+		assert.Equal(t, "10.0.0.2:22", sshConn.target)
+		assert.Equal(t, []byte{5, socks5NotAllowed, 0, 1, 0, 0, 0, 0, 0, 0}, reply)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		src, peer := net.Pipe()
+		go tunnel.accept(context.Background(), src)
+
+		reply := socksRequest(t, peer, []byte{5, 1, 0, 1, 10, 0, 0, 1, 0, 22})
+		assert.Equal(t, []byte{5, socks5Succeeded, 0, 1, 0, 0, 0, 0, 0, 0}, reply)
+
+		go func() {
+			_, _ = peer.Write([]byte("ping"))
+		}()
+		data := make([]byte, 4)
+		_, err := io.ReadFull(sshConn.client, data)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(data))
+		peer.Close()
+	})
+}
+
+// socksRequest negotiates no authentication and sends a request step by step, as net.Pipe is not buffered.
+func socksRequest(t *testing.T, peer net.Conn, request []byte) []byte {
+	_, err := peer.Write([]byte{5, 1, 0})
+	require.NoError(t, err)
+	method := make([]byte, 2)
+	_, err = io.ReadFull(peer, method)
+	require.NoError(t, err)
+	require.Equal(t, []byte{5, 0}, method)
+
+	_, err = peer.Write(request)
+	require.NoError(t, err)
+	reply := make([]byte, 10)
+	_, err = io.ReadFull(peer, reply)
+	require.NoError(t, err)
+	return reply
+}
+
+type readWriterMock struct {
+	io.Reader
+	written []byte
+}
+
+func (c *readWriterMock) Write(p []byte) (int, error) {
+	c.written = append(c.written, p...)
+	return len(p), nil
+}
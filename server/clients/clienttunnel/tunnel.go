@@ -72,23 +72,47 @@ type Tunnel struct {
 	TunnelProtocol      `json:"-"`
 	InternalTunnelProxy *InternalTunnelProxy `json:"-"`
 	CreatedAt           time.Time            `json:"created_at"`
+	Stats               *TunnelStats         `json:"stats"`
+	// Tokens are set if Remote.TokenAuth is enabled or the tunnel proxy logs in with a preset ssh secret
+	Tokens *TunnelTokens `json:"-"`
+	// Health is set if Remote.HealthCheckInterval is set
+	Health *TunnelHealth `json:"-"`
 }
 
-func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL) (*Tunnel, error) {
+// NewTunnel returns a tunnel that is not started yet, TCP connections are recorded if recordConn is set.
+func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL, recordConn RecordConnFunc) (*Tunnel, error) {
 	logger = logger.Fork("tunnel#%s:%s", id, remote)
 	logger.Debugf("new tunnel with remote = %#v", remote)
 
+	// the traffic of all protocols is counted and limited together
+	stats := &TunnelStats{}
+	traffic := newTunnelTraffic(stats, remote.MaxBandwidth)
+
+	var tokens *TunnelTokens
+	if remote.TokenAuth || remote.SSHCredentials.HasSecret() {
+		tokens = &TunnelTokens{}
+	}
+
+	var health *TunnelHealth
+	if remote.HealthCheckInterval > 0 {
+		health = NewTunnelHealth()
+	}
+
 	var tunnelProtocol TunnelProtocol
 	switch remote.Protocol {
 	case models.ProtocolUDP:
-		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl)
-	case models.ProtocolTCP:
-		tunnelProtocol = newTunnelTCP(logger, ssh, remote, acl)
+		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl, traffic)
+	case models.ProtocolTCP, models.ProtocolSOCKS:
+		tcp := newTunnelTCP(logger, ssh, remote, acl, recordConn, traffic)
+		if remote.TokenAuth {
+			tcp.tokens = tokens
+		}
+		tunnelProtocol = tcp
 	case models.ProtocolTCPUDP:
 		tunnelProtocol = &MultiProtocolTunnel{
 			Protocols: []TunnelProtocol{
-				newTunnelTCP(logger, ssh, remote, acl),
-				newTunnelUDP(logger, ssh, remote, acl),
+				newTunnelTCP(logger, ssh, remote, acl, recordConn, traffic),
+				newTunnelUDP(logger, ssh, remote, acl, traffic),
 			},
 		}
 	default:
@@ -100,5 +124,8 @@ func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Rem
 		ID:             id,
 		TunnelProtocol: tunnelProtocol,
 		CreatedAt:      time.Now(),
+		Stats:          stats,
+		Tokens:         tokens,
+		Health:         health,
 	}, nil
 }
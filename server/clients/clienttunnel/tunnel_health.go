@@ -0,0 +1,181 @@
+package clienttunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/openrport/openrport/share"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+const (
+	HealthStatusUnknown   = "unknown"
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+
+	// HealthCheckMinInterval prevents probes from flooding the client
+	HealthCheckMinInterval = 10 * time.Second
+	// HealthCheckRestartFailures is the number of consecutive failed probes after which a tunnel is re-established
+	HealthCheckRestartFailures = 3
+
+	healthProbeTimeout = 5 * time.Second
+)
+
+// TunnelHealth is the state of the periodic probes of the tunnel target.
+type TunnelHealth struct {
+	mu        sync.RWMutex
+	status    string
+	checkedAt time.Time
+	since     time.Time
+	failures  int
+	errMsg    string
+	restarts  int
+}
+
+type TunnelHealthPayload struct {
+	Status              string     `json:"status"`
+	CheckedAt           *time.Time `json:"checked_at"`
+	Since               *time.Time `json:"since"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Error               string     `json:"error,omitempty"`
+	Restarts            int        `json:"restarts"`
+}
+
+func NewTunnelHealth() *TunnelHealth {
+	return &TunnelHealth{
+		status: HealthStatusUnknown,
+	}
+}
+
+// SetRestarts is called when the tunnel has been re-established by the health check.
+func (h *TunnelHealth) SetRestarts(restarts int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.restarts = restarts
+}
+
+// Record stores the result of a probe and returns true if the status changed.
+func (h *TunnelHealth) Record(probeErr error, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prevFailures := h.failures
+	status := HealthStatusHealthy
+	h.errMsg = ""
+	h.failures = 0
+	if probeErr != nil {
+		status = HealthStatusUnhealthy
+		h.errMsg = probeErr.Error()
+		h.failures = prevFailures + 1
+	}
+
+	h.checkedAt = now
+	if status == h.status {
+		return false
+	}
+	h.status = status
+	h.since = now
+	return true
+}
+
+// Get returns a copy of the current state.
+func (h *TunnelHealth) Get() TunnelHealthPayload {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	p := TunnelHealthPayload{
+		Status:              h.status,
+		ConsecutiveFailures: h.failures,
+		Error:               h.errMsg,
+		Restarts:            h.restarts,
+	}
+	if !h.checkedAt.IsZero() {
+		checkedAt := h.checkedAt
+		p.CheckedAt = &checkedAt
+	}
+	if !h.since.IsZero() {
+		since := h.since
+		p.Since = &since
+	}
+	return p
+}
+
+// ProbeTunnel checks the target of a tunnel from the client. Tunnels with a http scheme are checked with a GET
+// request over the tunnel, server errors count as failure. Other tunnels are checked by a TCP connect on the client.
+func ProbeTunnel(ctx context.Context, conn ssh.Conn, remote models.Remote, l *logger.Logger) error {
+	if remote.Scheme != nil && (*remote.Scheme == "http" || *remote.Scheme == "https") {
+		return probeHTTP(ctx, conn, remote, *remote.Scheme)
+	}
+
+	req := &comm.CheckPortRequest{
+		HostPort: remote.Remote(),
+		Timeout:  healthProbeTimeout,
+	}
+	resp := &comm.CheckPortResponse{}
+	if err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeCheckPort, req, resp, l); err != nil {
+		return err
+	}
+	if !resp.Open {
+		if resp.ErrMsg != "" {
+			return errors.New(resp.ErrMsg)
+		}
+		return fmt.Errorf("port %s is not open", remote.RemotePort)
+	}
+	return nil
+}
+
+func probeHTTP(ctx context.Context, conn ssh.Conn, remote models.Remote, scheme string) error {
+	client := &http.Client{
+		Timeout: healthProbeTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				// the client checks whether the target is allowed, same as for regular tunnel connections
+				ch, reqs, err := conn.OpenChannel("rport", []byte(remote.Remote()))
+				if err != nil {
+					return nil, err
+				}
+				go ssh.DiscardRequests(reqs)
+				return chshare.NewRWCConn(ch), nil
+			},
+			TLSClientConfig: &tls.Config{
+				// the probe sends no credentials and only checks that the target answers, targets reached by an ip
+				// address or with self-signed certificates would never be healthy otherwise
+				InsecureSkipVerify: true, //nolint:gosec
+			},
+			DisableKeepAlives: true,
+		},
+		// a redirect is a valid answer of the target
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scheme+"://"+remote.Remote()+"/", nil)
+	if err != nil {
+		return err
+	}
+	if remote.HostHeader != "" {
+		req.Host = remote.HostHeader
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package clienttunnel
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/test"
+)
+
+func TestTunnelHealthRecord(t *testing.T) {
+	health := NewTunnelHealth()
+	assert.Equal(t, TunnelHealthPayload{Status: HealthStatusUnknown}, health.Get())
+
+	t1 := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.True(t, health.Record(nil, t1))
+	assert.False(t, health.Record(nil, t1.Add(time.Minute)))
+
+	t3 := t1.Add(2 * time.Minute)
+	assert.True(t, health.Record(errors.New("connection refused"), t3))
+	assert.False(t, health.Record(errors.New("connection refused"), t3.Add(time.Minute)))
+
+	got := health.Get()
+	assert.Equal(t, HealthStatusUnhealthy, got.Status)
+	assert.Equal(t, 2, got.ConsecutiveFailures)
+	assert.Equal(t, "connection refused", got.Error)
+	assert.Equal(t, t3, *got.Since)
+	assert.Equal(t, t3.Add(time.Minute), *got.CheckedAt)
+
+	assert.True(t, health.Record(nil, t3.Add(2*time.Minute)))
+	got = health.Get()
+	assert.Equal(t, HealthStatusHealthy, got.Status)
+	assert.Equal(t, 0, got.ConsecutiveFailures)
+	assert.Empty(t, got.Error)
+}
+
+func TestProbeTunnel(t *testing.T) {
+	l := logger.NewLogger("health-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	remote := models.Remote{RemoteHost: "127.0.0.1", RemotePort: "22"}
+
+	testCases := []struct {
+		Name          string
+		Response      string
+		ExpectedError string
+	}{
+		{
+			Name:     "open",
+			Response: `{"Open": true}`,
+		},
+		{
+			Name:          "closed",
+			Response:      `{"Open": false, "ErrMsg": "connection refused"}`,
+			ExpectedError: "connection refused",
+		},
+		{
+			Name:          "closed without message",
+			Response:      `{"Open": false}`,
+			ExpectedError: "port 22 is not open",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			connMock := test.NewConnMock()
+			connMock.ReturnOk = true
+			connMock.ReturnResponsePayload = []byte(tc.Response)
+
+			err := ProbeTunnel(context.Background(), connMock, remote, l)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+			name, _, _ := connMock.InputSendRequest()
+			assert.Equal(t, comm.RequestTypeCheckPort, name)
+		})
+	}
+}
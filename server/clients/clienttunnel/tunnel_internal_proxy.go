@@ -37,6 +37,7 @@ type InternalTunnelProxyConfig struct {
 	KeyFile      string   `mapstructure:"tunnel_proxy_key_file"`
 	EnableAcme   bool     `mapstructure:"tunnel_enable_acme"`
 	NovncRoot    string   `mapstructure:"novnc_root"`
+	XtermjsRoot  string   `mapstructure:"xtermjs_root"`
 	TLSMin       string   `mapstructure:"tls_min"`
 	GuacdAddress string   `mapstructure:"guacd_address"`
 	CORS         []string `mapstructure:"tunnel_cors"`
@@ -104,13 +105,16 @@ func (c *InternalTunnelProxyConfig) validateHost() error {
 }
 
 type InternalTunnelProxy struct {
-	Tunnel               *Tunnel
-	Logger               *logger.Logger
-	Config               *InternalTunnelProxyConfig
-	Host                 string
-	Port                 string
-	TunnelHost           string
-	TunnelPort           string
+	Tunnel     *Tunnel
+	Logger     *logger.Logger
+	Config     *InternalTunnelProxyConfig
+	Host       string
+	Port       string
+	TunnelHost string
+	TunnelPort string
+	Shares     *TunnelShares
+	// SSHSecrets reads the preset secret of the browser terminal of ssh tunnels
+	SSHSecrets           SSHSecretReader
 	acl                  atomic.Pointer[TunnelACL]
 	proxyServer          *http.Server
 	tunnelProxyConnector TunnelProxyConnector
@@ -126,6 +130,7 @@ func NewInternalTunnelProxy(tunnel *Tunnel, logger *logger.Logger, config *Inter
 		TunnelHost: tunnel.Remote.LocalHost,
 		TunnelPort: tunnel.Remote.LocalPort,
 		acme:       acme,
+		Shares:     &TunnelShares{},
 	}
 	tp.SetACL(acl)
 	tp.Logger = logger.Fork("tunnel-proxy:%s", tp.Addr())
@@ -135,6 +140,7 @@ func NewInternalTunnelProxy(tunnel *Tunnel, logger *logger.Logger, config *Inter
 
 func (tp *InternalTunnelProxy) Start(ctx context.Context) error {
 	router := mux.NewRouter()
+	router.Use(tp.handleShare)
 	router.Use(tp.handleACL)
 
 	router.Handle("/css/tunnel-proxy.css", http.FileServer(http.FS(tunnelProxyCSS)))
@@ -207,11 +213,64 @@ func (tp *InternalTunnelProxy) TunnelAddr() string {
 	return net.JoinHostPort(tp.TunnelHost, tp.TunnelPort)
 }
 
+type sharedAccessCtxKey struct{}
+
+// handleShare middleware to grant access by share links. Opening a link counts a use and sets a cookie with the
+// token, so following requests are granted access until the share expires or is revoked.
+func (tp *InternalTunnelProxy) handleShare(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.URL.Query().Get(ShareQueryParam); token != "" {
+			share, err := tp.Shares.redeem(token, r)
+			if err != nil {
+				tp.Logger.Infof("Proxy Access by share link rejected: %v. Remote addr: %s", err, chshare.RemoteIP(r))
+				tp.sendHTML(w, http.StatusForbidden, err.Error())
+				return
+			}
+			tp.Logger.Infof("Proxy Access by share link %s of %s. Remote addr: %s", share.ID, share.CreatedBy, chshare.RemoteIP(r))
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     tp.shareCookieName(),
+				Value:    token,
+				Path:     "/",
+				Expires:  share.ExpiresAt,
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			query := r.URL.Query()
+			query.Del(ShareQueryParam)
+			redirectURL := *r.URL
+			redirectURL.RawQuery = query.Encode()
+			http.Redirect(w, r, redirectURL.RequestURI(), http.StatusFound)
+			return
+		}
+
+		if cookie, err := r.Cookie(tp.shareCookieName()); err == nil {
+			if err := tp.Shares.verify(cookie.Value); err == nil {
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sharedAccessCtxKey{}, true)))
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// shareCookieName includes the port, as browsers send cookies to all ports of a host
+func (tp *InternalTunnelProxy) shareCookieName() string {
+	return "rport_share_" + tp.Port
+}
+
+func isSharedAccess(r *http.Request) bool {
+	shared, _ := r.Context().Value(sharedAccessCtxKey{}).(bool)
+	return shared
+}
+
 // handleACL middleware to handle ACL
 func (tp *InternalTunnelProxy) handleACL(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		acl := tp.acl.Load()
-		if acl == nil {
+		if acl == nil || isSharedAccess(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
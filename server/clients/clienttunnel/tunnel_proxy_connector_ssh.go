@@ -0,0 +1,329 @@
+package clienttunnel
+
+import (
+	"bytes"
+	"context"
+	_ "embed" //to embed the web ssh template
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/server/api/middleware"
+	"github.com/openrport/openrport/share/models"
+)
+
+const (
+	sshDialTimeout = 10 * time.Second
+
+	// SSHTokenQueryParam is the query param of the browser terminal holding a one-time token of the tunnel. The
+	// preset password or private key of the tunnel is only used with a valid token.
+	SSHTokenQueryParam = "rport-token"
+)
+
+var errHostKeyMismatch = errors.New("host key of the ssh server does not match the pinned key")
+
+// SSHSecretReader reads the preset password or private key of ssh tunnels from the vault with the permissions of the
+// given user.
+type SSHSecretReader interface {
+	ReadSSHSecret(ctx context.Context, vaultID int, username string) (string, error)
+}
+
+//go:embed webssh/index.html
+var webSSHIndexHTML string
+
+// TunnelProxyConnectorSSH serves a browser terminal (xterm.js) and logs in to a ssh tunnel on the server side.
+// The first websocket message is the login, then binary messages carry the terminal data and text messages resize it.
+type TunnelProxyConnectorSSH struct {
+	tunnelProxy *InternalTunnelProxy
+
+	// hostKey is the key of the ssh server trusted on first use, unless a key is pinned by the credentials
+	hostKeyMu sync.Mutex
+	hostKey   ssh.PublicKey
+}
+
+type webSSHLogin struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Token    string `json:"token"`
+	Cols     int    `json:"cols"`
+	Rows     int    `json:"rows"`
+}
+
+type webSSHResize struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+func NewTunnelConnectorSSH(tp *InternalTunnelProxy) *TunnelProxyConnectorSSH {
+	return &TunnelProxyConnectorSSH{tunnelProxy: tp}
+}
+
+// InitRouter called when tunnel proxy is started
+func (tc *TunnelProxyConnectorSSH) InitRouter(router *mux.Router) *mux.Router {
+	router.Use(tc.tunnelProxy.noCache)
+
+	router.HandleFunc("/ssh", tc.serveSSH)
+	router.HandleFunc("/", tc.serveIndex)
+
+	//handle xterm.js from local filesystem
+	tc.tunnelProxy.Logger.Infof("serving xterm.js from: %s", tc.tunnelProxy.Config.XtermjsRoot)
+	router.PathPrefix("/xterm/").Handler(middleware.Handle404(
+		http.StripPrefix("/xterm/", http.FileServer(http.Dir(tc.tunnelProxy.Config.XtermjsRoot))),
+		http.NotFoundHandler(),
+	))
+
+	return router
+}
+
+func (tc *TunnelProxyConnectorSSH) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if tc.tunnelProxy.Config.XtermjsRoot == "" {
+		tc.tunnelProxy.sendHTML(w, http.StatusBadRequest, "No xtermjs_root configured.")
+		return
+	}
+
+	creds := tc.credentials()
+	templateData := map[string]interface{}{
+		"title":          tc.tunnelProxy.Tunnel.Name,
+		"presetUsername": creds.Username != "",
+		"presetSecret":   creds.HasSecret() && r.URL.Query().Get(SSHTokenQueryParam) != "",
+		"tokenParam":     SSHTokenQueryParam,
+	}
+
+	tc.tunnelProxy.serveTemplate(w, r, webSSHIndexHTML, templateData)
+}
+
+func (tc *TunnelProxyConnectorSSH) serveSSH(w http.ResponseWriter, r *http.Request) {
+	wsConn, err := Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		tc.tunnelProxy.Logger.Errorf("failed to upgrade websocket request: %v", err)
+		return
+	}
+	defer wsConn.Close()
+
+	var login webSSHLogin
+	if err := wsConn.ReadJSON(&login); err != nil {
+		tc.tunnelProxy.Logger.Debugf("failed to read ssh login: %v", err)
+		return
+	}
+
+	config, err := tc.clientConfig(r.Context(), login)
+	if err != nil {
+		_ = wsConn.WriteMessage(websocket.TextMessage, []byte(err.Error()))
+		return
+	}
+
+	tc.tunnelProxy.Logger.Infof("TunnelProxyConnectorSSH: login as %s to tunnel: %s", config.User, tc.tunnelProxy.TunnelAddr())
+	client, err := ssh.Dial("tcp", tc.tunnelProxy.TunnelAddr(), config)
+	if err != nil {
+		tc.tunnelProxy.Logger.Infof("ssh login failed: %v", err)
+		_ = wsConn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("SSH login failed: %v", err)))
+		return
+	}
+	defer client.Close()
+
+	session, stdin, err := startWebSSHSession(client, wsConn, login)
+	if err != nil {
+		tc.tunnelProxy.Logger.Errorf("failed to start ssh session: %v", err)
+		_ = wsConn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Failed to start ssh session: %v", err)))
+		return
+	}
+
+	go func() {
+		_ = session.Wait()
+		// unblocks reading the websocket
+		wsConn.Close()
+	}()
+
+	for {
+		msgType, data, err := wsConn.ReadMessage()
+		if err != nil {
+			break
+		}
+		switch msgType {
+		case websocket.BinaryMessage:
+			if _, err := stdin.Write(data); err != nil {
+				tc.tunnelProxy.Logger.Debugf("failed to write to ssh session: %v", err)
+				return
+			}
+		case websocket.TextMessage:
+			var resize webSSHResize
+			if err := json.Unmarshal(data, &resize); err == nil && resize.Cols > 0 && resize.Rows > 0 {
+				_ = session.WindowChange(resize.Rows, resize.Cols)
+			}
+		}
+	}
+	_ = session.Close()
+}
+
+func (tc *TunnelProxyConnectorSSH) credentials() models.SSHCredentials {
+	if tc.tunnelProxy.Tunnel.Remote.SSHCredentials == nil {
+		return models.SSHCredentials{}
+	}
+	return *tc.tunnelProxy.Tunnel.Remote.SSHCredentials
+}
+
+// clientConfig uses the preset credentials of the tunnel, missing values are taken from the login. The preset secret
+// requires a one-time token issued by the API, otherwise everyone reaching the tunnel proxy would get a shell. It's read
+// from the vault with the permissions of the user the token was issued to.
+func (tc *TunnelProxyConnectorSSH) clientConfig(ctx context.Context, login webSSHLogin) (*ssh.ClientConfig, error) {
+	creds := tc.credentials()
+	if creds.Username == "" {
+		creds.Username = login.Username
+	}
+	if creds.Username == "" {
+		return nil, errors.New("username is required")
+	}
+
+	password := login.Password
+	var privateKey string
+	if creds.HasSecret() {
+		if issued := tc.useToken(login.Token); issued != nil {
+			secret, err := tc.readSecret(ctx, creds.VaultID, issued.Username)
+			if err != nil {
+				return nil, err
+			}
+			// a vault value containing a private key in PEM format is used as private key, any other as password
+			if strings.Contains(secret, "PRIVATE KEY") {
+				privateKey = secret
+			} else {
+				password = secret
+			}
+		}
+	}
+
+	var auth []ssh.AuthMethod
+	if privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			tc.tunnelProxy.Logger.Errorf("invalid ssh private key of tunnel: %v", err)
+			return nil, errors.New("invalid private key")
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	} else {
+		auth = append(auth, ssh.Password(password), ssh.KeyboardInteractive(
+			func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range questions {
+					answers[i] = password
+				}
+				return answers, nil
+			},
+		))
+	}
+
+	return &ssh.ClientConfig{
+		User:            creds.Username,
+		Auth:            auth,
+		HostKeyCallback: tc.checkHostKey,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+func (tc *TunnelProxyConnectorSSH) useToken(token string) *TunnelToken {
+	if token == "" || tc.tunnelProxy.Tunnel.Tokens == nil {
+		return nil
+	}
+	issued, ok := tc.tunnelProxy.Tunnel.Tokens.use(token)
+	if !ok {
+		tc.tunnelProxy.Logger.Infof("invalid or expired token, preset ssh secret not used")
+		return nil
+	}
+	tc.tunnelProxy.Logger.Infof("preset ssh secret used with token of %s", issued.Username)
+	return issued
+}
+
+func (tc *TunnelProxyConnectorSSH) readSecret(ctx context.Context, vaultID int, username string) (string, error) {
+	if tc.tunnelProxy.SSHSecrets == nil {
+		return "", errors.New("preset ssh secret is not available")
+	}
+	secret, err := tc.tunnelProxy.SSHSecrets.ReadSSHSecret(ctx, vaultID, username)
+	if err != nil {
+		tc.tunnelProxy.Logger.Errorf("failed to read preset ssh secret from vault value %d: %v", vaultID, err)
+		return "", fmt.Errorf("failed to read preset ssh secret from vault: %v", err)
+	}
+	return secret, nil
+}
+
+// checkHostKey compares the key of the ssh server with the key pinned by the credentials or with the first key seen,
+// so the credentials are never sent to another server.
+func (tc *TunnelProxyConnectorSSH) checkHostKey(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if pinned := tc.credentials().HostKey; pinned != "" {
+		pinnedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(pinned))
+		if err != nil {
+			return fmt.Errorf("invalid pinned host key: %v", err)
+		}
+		return ssh.FixedHostKey(pinnedKey)(hostname, remote, key)
+	}
+
+	tc.hostKeyMu.Lock()
+	defer tc.hostKeyMu.Unlock()
+
+	if tc.hostKey == nil {
+		tc.tunnelProxy.Logger.Infof("trusting host key %s of ssh server", ssh.FingerprintSHA256(key))
+		tc.hostKey = key
+		return nil
+	}
+	if !bytes.Equal(tc.hostKey.Marshal(), key.Marshal()) {
+		tc.tunnelProxy.Logger.Errorf("host key %s of ssh server changed, trusted is %s", ssh.FingerprintSHA256(key), ssh.FingerprintSHA256(tc.hostKey))
+		return errHostKeyMismatch
+	}
+	return nil
+}
+
+// startWebSSHSession starts a shell with a pty, its output is sent as binary websocket messages.
+func startWebSSHSession(client *ssh.Client, wsConn *websocket.Conn, login webSSHLogin) (*ssh.Session, io.Writer, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols, rows := login.Cols, login.Rows
+	if cols <= 0 || rows <= 0 {
+		cols, rows = 80, 24
+	}
+	if err := session.RequestPty("xterm-256color", rows, cols, ssh.TerminalModes{ssh.ECHO: 1}); err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	out := &websocketWriter{conn: wsConn}
+	session.Stdout = out
+	session.Stderr = out
+
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	return session, stdin, nil
+}
+
+// websocketWriter sends each write as binary message, stdout and stderr are copied concurrently.
+type websocketWriter struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (w *websocketWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,272 @@
+package clienttunnel
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+// startEchoSSHServer accepts the password "secret" and echoes the shell input, the size of the pty is sent first.
+func startEchoSSHServer(t *testing.T) string {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "test" && string(pass) == "secret" {
+				return nil, nil
+			}
+			return nil, assert.AnError
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newCh := range chans {
+					ch, chReqs, err := newCh.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range chReqs {
+							if req.Type == "pty-req" {
+								// skip the term name to get cols and rows
+								termLen := binary.BigEndian.Uint32(req.Payload)
+								size := req.Payload[4+termLen:]
+								_, _ = fmt.Fprintf(ch, "pty %dx%d\n", binary.BigEndian.Uint32(size), binary.BigEndian.Uint32(size[4:]))
+							}
+							_ = req.Reply(req.Type == "pty-req" || req.Type == "shell", nil)
+						}
+					}()
+					go func() {
+						_, _ = io.Copy(ch, ch)
+					}()
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestTunnelProxyConnectorSSH(t *testing.T) {
+	sshAddr := startEchoSSHServer(t)
+	host, port, err := net.SplitHostPort(sshAddr)
+	require.NoError(t, err)
+
+	scheme := "ssh"
+	tp := &InternalTunnelProxy{
+		Tunnel: &Tunnel{
+			Remote: models.Remote{
+				Scheme:         &scheme,
+				SSHCredentials: &models.SSHCredentials{Username: "test"},
+			},
+		},
+		Logger:     logger.NewLogger("web-ssh-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		Config:     &InternalTunnelProxyConfig{XtermjsRoot: t.TempDir()},
+		TunnelHost: host,
+		TunnelPort: port,
+	}
+	server := httptest.NewServer(NewTunnelConnectorSSH(tp).InitRouter(mux.NewRouter()))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ssh"
+
+	t.Run("wrong password", func(t *testing.T) {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.WriteJSON(webSSHLogin{Password: "wrong"}))
+		msgType, msg, err := ws.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Contains(t, string(msg), "SSH login failed")
+	})
+
+	t.Run("login", func(t *testing.T) {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.WriteJSON(webSSHLogin{Username: "ignored", Password: "secret", Cols: 3, Rows: 2}))
+		msgType, msg, err := ws.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, msgType)
+		assert.Equal(t, "pty 3x2\n", string(msg), "preset username is used and the terminal size is passed on")
+
+		require.NoError(t, ws.WriteMessage(websocket.BinaryMessage, []byte("ls\n")))
+		_, msg, err = ws.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "ls\n", string(msg))
+	})
+}
+
+// vaultMock returns the secrets of the vault values by id, the users reading them are recorded.
+type vaultMock struct {
+	secrets map[int]string
+	err     error
+	readBy  []string
+}
+
+func (m *vaultMock) ReadSSHSecret(_ context.Context, vaultID int, username string) (string, error) {
+	m.readBy = append(m.readBy, username)
+	if m.err != nil {
+		return "", m.err
+	}
+	return m.secrets[vaultID], nil
+}
+
+func TestTunnelProxyConnectorSSHPresetSecret(t *testing.T) {
+	sshAddr := startEchoSSHServer(t)
+	host, port, err := net.SplitHostPort(sshAddr)
+	require.NoError(t, err)
+
+	scheme := "ssh"
+	vault := &vaultMock{secrets: map[int]string{12: "secret"}}
+	tp := &InternalTunnelProxy{
+		Tunnel: &Tunnel{
+			Remote: models.Remote{
+				Scheme:         &scheme,
+				SSHCredentials: &models.SSHCredentials{Username: "test", VaultID: 12},
+			},
+			Tokens: &TunnelTokens{},
+		},
+		SSHSecrets: vault,
+		Logger:     logger.NewLogger("web-ssh-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		Config:     &InternalTunnelProxyConfig{XtermjsRoot: t.TempDir()},
+		TunnelHost: host,
+		TunnelPort: port,
+	}
+	connector := NewTunnelConnectorSSH(tp)
+	server := httptest.NewServer(connector.InitRouter(mux.NewRouter()))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ssh"
+	login := func(t *testing.T, login webSSHLogin) (int, string) {
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.WriteJSON(login))
+		msgType, msg, err := ws.ReadMessage()
+		require.NoError(t, err)
+		return msgType, string(msg)
+	}
+
+	t.Run("without token", func(t *testing.T) {
+		msgType, msg := login(t, webSSHLogin{})
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Contains(t, msg, "SSH login failed", "preset secret must not be used without token")
+	})
+
+	t.Run("with token", func(t *testing.T) {
+		token, err := tp.Tunnel.Tokens.Issue("admin")
+		require.NoError(t, err)
+
+		msgType, _ := login(t, webSSHLogin{Token: token.Token})
+		assert.Equal(t, websocket.BinaryMessage, msgType)
+		assert.Equal(t, []string{"admin"}, vault.readBy, "secret is read with the permissions of the token user")
+
+		msgType, msg := login(t, webSSHLogin{Token: token.Token})
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Contains(t, msg, "SSH login failed", "token must be used once only")
+	})
+
+	t.Run("locked vault", func(t *testing.T) {
+		vault.err = errors.New("vault is locked")
+		defer func() { vault.err = nil }()
+
+		token, err := tp.Tunnel.Tokens.Issue("admin")
+		require.NoError(t, err)
+		msgType, msg := login(t, webSSHLogin{Token: token.Token})
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Equal(t, "failed to read preset ssh secret from vault: vault is locked", msg)
+	})
+
+	t.Run("changed host key", func(t *testing.T) {
+		_, other, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		signer, err := ssh.NewSignerFromKey(other)
+		require.NoError(t, err)
+		connector.hostKeyMu.Lock()
+		require.NotNil(t, connector.hostKey, "host key is trusted on first use")
+		connector.hostKey = signer.PublicKey()
+		connector.hostKeyMu.Unlock()
+
+		token, err := tp.Tunnel.Tokens.Issue("admin")
+		require.NoError(t, err)
+		msgType, msg := login(t, webSSHLogin{Token: token.Token})
+		assert.Equal(t, websocket.TextMessage, msgType)
+		assert.Contains(t, msg, errHostKeyMismatch.Error())
+	})
+
+	t.Run("cross origin", func(t *testing.T) {
+		_, resp, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Origin": []string{"https://attacker.example.com"}})
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestTunnelProxyConnectorSSHPinnedHostKey(t *testing.T) {
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(other)
+	require.NoError(t, err)
+	pinned := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+
+	tp := &InternalTunnelProxy{
+		Tunnel: &Tunnel{
+			Remote: models.Remote{
+				SSHCredentials: &models.SSHCredentials{HostKey: pinned},
+			},
+		},
+		Logger: logger.NewLogger("web-ssh-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+	}
+	connector := NewTunnelConnectorSSH(tp)
+
+	assert.NoError(t, connector.checkHostKey("", nil, signer.PublicKey()))
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err = ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	assert.Error(t, connector.checkHostKey("", nil, signer.PublicKey()))
+}
@@ -0,0 +1,36 @@
+package clienttunnel
+
+import (
+	"io"
+	"net"
+
+	"github.com/openrport/openrport/share/models"
+)
+
+// ConnRecording records the data of a single tunnel connection. The data is passed in chunks as read from the
+// connection, so a multibyte character might be split between two calls of the same method.
+type ConnRecording interface {
+	// Input records data sent to the client.
+	Input(p []byte)
+	// Output records data received from the client.
+	Output(p []byte)
+	Close() error
+}
+
+// RecordConnFunc starts a recording for a new connection to a tunnel, a nil func disables recording.
+type RecordConnFunc func(remote models.Remote, src net.Addr) (ConnRecording, error)
+
+// recordedConn passes all data read from conn to record, the recording joins characters split between reads
+// and must not keep p.
+type recordedConn struct {
+	io.ReadWriteCloser
+	record func(p []byte)
+}
+
+func (c *recordedConn) Read(p []byte) (int, error) {
+	n, err := c.ReadWriteCloser.Read(p)
+	if n > 0 {
+		c.record(p[:n])
+	}
+	return n, err
+}
@@ -0,0 +1,191 @@
+package clienttunnel
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/share/security"
+)
+
+const (
+	// ShareQueryParam is the query param of a share link holding the signed token
+	ShareQueryParam = "rport-share"
+
+	shareIDLength     = 12
+	shareSecretLength = 32
+)
+
+var (
+	errShareInvalid  = errors.New("invalid share link")
+	errShareExpired  = errors.New("share link expired")
+	errShareRevoked  = errors.New("share link revoked")
+	errShareUsedUp   = errors.New("share link used up")
+	errShareNotFound = errors.New("share link not found")
+)
+
+// ShareUseFunc is called each time a share link is used to access a tunnel.
+type ShareUseFunc func(share TunnelShare, r *http.Request)
+
+// TunnelShares are expiring links granting access to a tunnel served by the tunnel proxy without an rport account.
+// Links are signed with a secret of the tunnel, so they can't be forged or extended.
+type TunnelShares struct {
+	mu     sync.Mutex
+	secret []byte
+	shares map[string]*TunnelShare
+}
+
+type TunnelShare struct {
+	ID        string    `json:"id"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// MaxUses limits how often the link can be opened, 0 means no limit
+	MaxUses int `json:"max_uses"`
+	Uses    int `json:"uses"`
+
+	onUse ShareUseFunc
+}
+
+// Create returns a new share and the signed token of its link.
+func (s *TunnelShares) Create(createdBy string, ttl time.Duration, maxUses int, onUse ShareUseFunc) (TunnelShare, string, error) {
+	id, err := security.NewRandomToken(shareIDLength)
+	if err != nil {
+		return TunnelShare{}, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secret == nil {
+		secret, err := security.NewRandomToken(shareSecretLength)
+		if err != nil {
+			return TunnelShare{}, "", err
+		}
+		s.secret = []byte(secret)
+		s.shares = make(map[string]*TunnelShare)
+	}
+
+	now := time.Now()
+	// expired shares are removed on creating new ones
+	for k, v := range s.shares {
+		if v.ExpiresAt.Before(now) {
+			delete(s.shares, k)
+		}
+	}
+
+	share := &TunnelShare{
+		ID:        id,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		MaxUses:   maxUses,
+		onUse:     onUse,
+	}
+	s.shares[id] = share
+	return *share, s.sign(id, share.ExpiresAt), nil
+}
+
+// List returns all shares not expired yet, the oldest first.
+func (s *TunnelShares) List() []TunnelShare {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	result := make([]TunnelShare, 0, len(s.shares))
+	for _, v := range s.shares {
+		if v.ExpiresAt.After(now) {
+			result = append(result, *v)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// Revoke invalidates a share immediately, also for visitors who already opened the link.
+func (s *TunnelShares) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shares[id]; !ok {
+		return errShareNotFound
+	}
+	delete(s.shares, id)
+	return nil
+}
+
+// redeem counts a use of a share link and calls the ShareUseFunc of the share.
+func (s *TunnelShares) redeem(token string, r *http.Request) (TunnelShare, error) {
+	s.mu.Lock()
+	share, err := s.verifyLocked(token)
+	if err == nil && share.MaxUses > 0 && share.Uses >= share.MaxUses {
+		err = errShareUsedUp
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return TunnelShare{}, err
+	}
+	share.Uses++
+	result := *share
+	s.mu.Unlock()
+
+	if result.onUse != nil {
+		result.onUse(result, r)
+	}
+	return result, nil
+}
+
+// verify checks a token of a share link that was already redeemed.
+func (s *TunnelShares) verify(token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.verifyLocked(token)
+	return err
+}
+
+func (s *TunnelShares) verifyLocked(token string) (*TunnelShare, error) {
+	if s.secret == nil {
+		return nil, errShareInvalid
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errShareInvalid
+	}
+	expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, errShareInvalid
+	}
+	expiresAt := time.Unix(expiresUnix, 0)
+	if !hmac.Equal([]byte(token), []byte(s.sign(parts[0], expiresAt))) {
+		return nil, errShareInvalid
+	}
+	if expiresAt.Before(time.Now()) {
+		return nil, errShareExpired
+	}
+
+	share, ok := s.shares[parts[0]]
+	if !ok {
+		return nil, errShareRevoked
+	}
+	return share, nil
+}
+
+// sign returns the token "<id>.<expires unix>.<signature>"
+func (s *TunnelShares) sign(id string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%s.%d", id, expiresAt.Unix())
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
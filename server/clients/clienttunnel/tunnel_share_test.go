@@ -0,0 +1,95 @@
+package clienttunnel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+func TestTunnelShares(t *testing.T) {
+	shares := &TunnelShares{}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var used []TunnelShare
+	onUse := func(share TunnelShare, r *http.Request) {
+		used = append(used, share)
+	}
+
+	share, token, err := shares.Create("admin", time.Hour, 2, onUse)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", share.CreatedBy)
+	require.Len(t, shares.List(), 1)
+	assert.Equal(t, share.ID, shares.List()[0].ID)
+
+	_, err = shares.redeem(token, req)
+	require.NoError(t, err)
+	_, err = shares.redeem(token, req)
+	require.NoError(t, err)
+	_, err = shares.redeem(token, req)
+	assert.Equal(t, errShareUsedUp, err)
+	assert.NoError(t, shares.verify(token), "a used up link grants access to visitors who already opened it")
+	require.Len(t, used, 2)
+	assert.Equal(t, 2, used[1].Uses)
+
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + strconv.FormatInt(time.Now().Add(48*time.Hour).Unix(), 10) + "." + parts[2]
+	assert.Equal(t, errShareInvalid, shares.verify(forged))
+	assert.Equal(t, errShareInvalid, shares.verify("invalid"))
+
+	_, expiredToken, err := shares.Create("admin", -time.Second, 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, errShareExpired, shares.verify(expiredToken))
+	assert.Len(t, shares.List(), 1)
+
+	require.NoError(t, shares.Revoke(share.ID))
+	assert.Equal(t, errShareRevoked, shares.verify(token))
+	assert.Equal(t, errShareNotFound, shares.Revoke(share.ID))
+}
+
+func TestInternalTunnelProxyHandleShare(t *testing.T) {
+	tp := &InternalTunnelProxy{
+		Port:   "4443",
+		Logger: logger.NewLogger("share-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		Shares: &TunnelShares{},
+	}
+	acl, err := ParseTunnelACL("10.0.0.1")
+	require.NoError(t, err)
+	tp.SetACL(acl)
+	handler := tp.handleShare(tp.handleACL(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	_, token, err := tp.Shares.Create("admin", time.Hour, 1, nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/app?page=1", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "rejected by ACL without share")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/app?page=1&"+ShareQueryParam+"="+token, nil))
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/app?page=1", w.Header().Get("Location"))
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "rport_share_4443", cookies[0].Name)
+
+	req := httptest.NewRequest(http.MethodGet, "/app?page=1", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+ShareQueryParam+"="+token, nil))
+	assert.Equal(t, http.StatusForbidden, w.Code, "max uses reached")
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/openrport/openrport/share/models"
 )
 
+const socks5HandshakeTimeout = 10 * time.Second
+
 type tunnelTCP struct {
 	// Declare 64-bit integer before 32-bit for alignment when compiling Go on 32-bit ARM platforms
 	lastConnClose int64 // time stored as int64 so it can be used with atomic
@@ -25,17 +27,23 @@ type tunnelTCP struct {
 	sshConn ssh.Conn
 	acl     atomic.Pointer[TunnelACL] // parsed Remote.ACL field
 
+	recordConn RecordConnFunc
+	traffic    *tunnelTraffic
+	tokens     *TunnelTokens // nil if token auth is disabled
+
 	stopFn                    func()
 	connectionIDAutoIncrement int
 	connCount                 int32
 	wg                        sync.WaitGroup // TODO: verify whether wait group is needed here
 }
 
-func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelTCP {
+func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, recordConn RecordConnFunc, traffic *tunnelTraffic) *tunnelTCP {
 	t := &tunnelTCP{
-		Logger:  logger,
-		Remote:  remote,
-		sshConn: ssh,
+		Logger:     logger,
+		Remote:     remote,
+		sshConn:    ssh,
+		recordConn: recordConn,
+		traffic:    traffic,
 	}
 	t.SetACL(acl)
 	return t
@@ -136,11 +144,13 @@ func (t *tunnelTCP) LastActive() time.Time {
 	return time.Unix(atomic.LoadInt64(&t.lastConnClose), 0)
 }
 
-func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
+func (t *tunnelTCP) accept(ctx context.Context, src net.Conn) {
 	defer src.Close()
 	t.connectionIDAutoIncrement++
 	atomic.AddInt32(&t.connCount, 1)
 	defer atomic.AddInt32(&t.connCount, -1)
+	t.traffic.stats.connOpened()
+	defer t.traffic.stats.connClosed()
 
 	cid := t.connectionIDAutoIncrement
 	l := t.Fork("conn#%d", cid)
@@ -164,23 +174,89 @@ func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
 		l.Debugf("No remote connection")
 		return
 	}
-	// ssh request to open connection to this tunnel's remote
-	dst, reqs, err := t.sshConn.OpenChannel("rport", []byte(t.Remote.Remote()))
+	var tunnelSrc io.ReadWriteCloser = src
+	if t.tokens != nil {
+		authenticated, token, err := t.tokens.authenticate(src)
+		if err != nil {
+			l.Infof("Access rejected, token auth failed: %v", err)
+			return
+		}
+		l.Debugf("Authenticated by token of %s", token.Username)
+		tunnelSrc = authenticated
+	}
+
+	target := t.Remote.Remote()
+	if t.Protocol == models.ProtocolSOCKS {
+		var err error
+		target, err = t.socks5Handshake(src)
+		if err != nil {
+			l.Debugf("SOCKS5 handshake failed: %v", err)
+			return
+		}
+		l = l.Fork("%s", target)
+	}
+
+	// ssh request to open connection to this tunnel's remote, the client checks whether it's allowed
+	dst, reqs, err := t.sshConn.OpenChannel("rport", []byte(target))
 	if err != nil {
 		l.Errorf("Could not establish TCP tunnel: %v", err)
+		if t.Protocol == models.ProtocolSOCKS {
+			_ = socks5Reply(src, socks5ReplyForOpenChannelError(err))
+		}
 		return
 	}
+	if t.Protocol == models.ProtocolSOCKS {
+		if err := socks5Reply(src, socks5Succeeded); err != nil {
+			l.Debugf("Could not reply SOCKS5 request: %v", err)
+			dst.Close()
+			return
+		}
+	}
 
 	l.Debugf("SSH channel open")
 	l.Debugf("from %+v", t.sshConn.RemoteAddr())
 
 	go ssh.DiscardRequests(reqs)
+
+	var tunnelDst io.ReadWriteCloser = dst
+	if t.recordConn != nil {
+		rec, err := t.recordConn(t.Remote, src.RemoteAddr())
+		if err != nil {
+			// connections must not pass unrecorded when recording is enabled
+			l.Errorf("Could not start recording: %v", err)
+			dst.Close()
+			return
+		}
+		defer func() {
+			if err := rec.Close(); err != nil {
+				l.Errorf("Could not finish recording: %v", err)
+			}
+		}()
+		tunnelSrc = &recordedConn{ReadWriteCloser: tunnelSrc, record: rec.Input}
+		tunnelDst = &recordedConn{ReadWriteCloser: dst, record: rec.Output}
+	}
+
+	tunnelSrc = &meteredConn{ReadWriteCloser: tunnelSrc, ctx: ctx, limit: t.traffic.inLimit, count: t.traffic.received}
+	tunnelDst = &meteredConn{ReadWriteCloser: tunnelDst, ctx: ctx, limit: t.traffic.outLimit, count: t.traffic.sent}
+
 	//then pipe
-	s, r := chshare.Pipe(src, dst)
+	s, r := chshare.Pipe(tunnelSrc, tunnelDst)
 	l.Debugf("Close (sent %s received %s)", sizestr.ToString(s), sizestr.ToString(r))
 	close(done)
 }
 
+// socks5Handshake reads the destination requested by a SOCKS5 peer, slow peers are disconnected.
+func (t *tunnelTCP) socks5Handshake(src net.Conn) (string, error) {
+	if err := src.SetDeadline(time.Now().Add(socks5HandshakeTimeout)); err != nil {
+		return "", err
+	}
+	target, err := socks5Handshake(src)
+	if err != nil {
+		return "", err
+	}
+	return target, src.SetDeadline(time.Time{})
+}
+
 func (t *tunnelTCP) SetACL(acl *TunnelACL) {
 	t.acl.Store(acl)
 }
@@ -0,0 +1,118 @@
+package clienttunnel
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/share/security"
+)
+
+const (
+	// TunnelTokenTTL is the time a token can be used to open a connection
+	TunnelTokenTTL = time.Minute
+
+	tunnelTokenLength      = 32
+	tunnelTokenReadTimeout = 10 * time.Second
+	// tunnelTokenMaxLine limits the bytes read while looking for the token line
+	tunnelTokenMaxLine = 256
+)
+
+var errInvalidTunnelToken = errors.New("invalid or expired token")
+
+// TunnelTokens are one-time tokens for tunnels with token auth. Each connection to such a tunnel has to send an unused
+// token followed by a newline before any other data.
+type TunnelTokens struct {
+	mu     sync.Mutex
+	tokens map[string]*TunnelToken
+}
+
+type TunnelToken struct {
+	Token     string    `json:"token"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Issue returns a new token for a given user valid for TunnelTokenTTL.
+func (t *TunnelTokens) Issue(username string) (*TunnelToken, error) {
+	token, err := security.NewRandomToken(tunnelTokenLength)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.tokens == nil {
+		t.tokens = make(map[string]*TunnelToken)
+	}
+	// expired tokens are removed on issuing new ones
+	for k, v := range t.tokens {
+		if v.ExpiresAt.Before(now) {
+			delete(t.tokens, k)
+		}
+	}
+
+	issued := &TunnelToken{
+		Token:     token,
+		Username:  username,
+		ExpiresAt: now.Add(TunnelTokenTTL),
+	}
+	t.tokens[token] = issued
+	return issued, nil
+}
+
+// use invalidates a given token and returns it if it was valid.
+func (t *TunnelTokens) use(token string) (*TunnelToken, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issued, ok := t.tokens[token]
+	if !ok {
+		return nil, false
+	}
+	delete(t.tokens, token)
+	if issued.ExpiresAt.Before(time.Now()) {
+		return nil, false
+	}
+	return issued, true
+}
+
+// authenticate reads the token line from a given connection. It returns a connection passing on all data sent
+// after the token line.
+func (t *TunnelTokens) authenticate(conn net.Conn) (io.ReadWriteCloser, *TunnelToken, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(tunnelTokenReadTimeout)); err != nil {
+		return nil, nil, err
+	}
+
+	r := bufio.NewReaderSize(conn, tunnelTokenMaxLine)
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issued, ok := t.use(strings.TrimSpace(string(line)))
+	if !ok {
+		return nil, nil, errInvalidTunnelToken
+	}
+
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, nil, err
+	}
+	return &tokenAuthenticatedConn{ReadWriteCloser: conn, r: r}, issued, nil
+}
+
+// tokenAuthenticatedConn reads the data buffered while reading the token line first.
+type tokenAuthenticatedConn struct {
+	io.ReadWriteCloser
+	r *bufio.Reader
+}
+
+func (c *tokenAuthenticatedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
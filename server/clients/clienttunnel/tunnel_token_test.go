@@ -0,0 +1,70 @@
+package clienttunnel
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTunnelTokens(t *testing.T) {
+	tokens := &TunnelTokens{}
+
+	issued, err := tokens.Issue("test-user")
+	require.NoError(t, err)
+	assert.Equal(t, "test-user", issued.Username)
+	assert.NotEmpty(t, issued.Token)
+
+	_, ok := tokens.use("unknown")
+	assert.False(t, ok)
+
+	used, ok := tokens.use(issued.Token)
+	require.True(t, ok)
+	assert.Equal(t, issued, used)
+
+	_, ok = tokens.use(issued.Token)
+	assert.False(t, ok, "tokens can be used only once")
+
+	expired, err := tokens.Issue("test-user")
+	require.NoError(t, err)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	_, ok = tokens.use(expired.Token)
+	assert.False(t, ok, "expired tokens are rejected")
+}
+
+func TestTunnelTokensAuthenticate(t *testing.T) {
+	tokens := &TunnelTokens{}
+	issued, err := tokens.Issue("test-user")
+	require.NoError(t, err)
+
+	t.Run("valid token", func(t *testing.T) {
+		src, peer := net.Pipe()
+		defer peer.Close()
+		go func() {
+			_, _ = peer.Write([]byte(issued.Token + "\nping"))
+		}()
+
+		conn, token, err := tokens.authenticate(src)
+		require.NoError(t, err)
+		assert.Equal(t, "test-user", token.Username)
+
+		data := make([]byte, 4)
+		_, err = io.ReadFull(conn, data)
+		require.NoError(t, err)
+		assert.Equal(t, "ping", string(data), "data sent after the token is passed on")
+	})
+
+	t.Run("used token", func(t *testing.T) {
+		src, peer := net.Pipe()
+		defer peer.Close()
+		go func() {
+			_, _ = peer.Write([]byte(issued.Token + "\n"))
+		}()
+
+		_, _, err := tokens.authenticate(src)
+		assert.Equal(t, errInvalidTunnelToken, err)
+	})
+}
@@ -0,0 +1,229 @@
+package clienttunnel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TunnelStatsPayload is a snapshot of the traffic of a tunnel.
+type TunnelStatsPayload struct {
+	// BytesIn is the number of bytes received from the peers connected to the tunnel and sent to the client
+	BytesIn int64 `json:"bytes_in"`
+	// BytesOut is the number of bytes received from the client and sent to the peers connected to the tunnel
+	BytesOut int64 `json:"bytes_out"`
+	// Connections is the number of TCP connections accepted by the tunnel
+	Connections int64 `json:"connections"`
+	// ActiveConnections is the number of TCP connections currently open
+	ActiveConnections int64 `json:"active_connections"`
+	// UDPSessions is the number of UDP peers that have sent datagrams to the tunnel
+	UDPSessions int64 `json:"udp_sessions"`
+	// ActiveUDPSessions is the number of UDP sessions that have not expired yet
+	ActiveUDPSessions int64 `json:"active_udp_sessions"`
+}
+
+// Add adds the counters of other to s.
+func (s *TunnelStatsPayload) Add(other TunnelStatsPayload) {
+	s.BytesIn += other.BytesIn
+	s.BytesOut += other.BytesOut
+	s.Connections += other.Connections
+	s.ActiveConnections += other.ActiveConnections
+	s.UDPSessions += other.UDPSessions
+	s.ActiveUDPSessions += other.ActiveUDPSessions
+}
+
+// TunnelStats counts the traffic of a tunnel, it is shared by all protocols of the tunnel.
+type TunnelStats struct {
+	bytesIn           int64
+	bytesOut          int64
+	connections       int64
+	activeConnections int64
+	udpSessions       int64
+	activeUDPSessions int64
+
+	mtx      sync.Mutex
+	reported TunnelStatsPayload
+}
+
+func (s *TunnelStats) addIn(n int) {
+	atomic.AddInt64(&s.bytesIn, int64(n))
+}
+
+func (s *TunnelStats) addOut(n int) {
+	atomic.AddInt64(&s.bytesOut, int64(n))
+}
+
+func (s *TunnelStats) connOpened() {
+	atomic.AddInt64(&s.connections, 1)
+	atomic.AddInt64(&s.activeConnections, 1)
+}
+
+func (s *TunnelStats) connClosed() {
+	atomic.AddInt64(&s.activeConnections, -1)
+}
+
+func (s *TunnelStats) udpSessionOpened() {
+	atomic.AddInt64(&s.udpSessions, 1)
+	atomic.AddInt64(&s.activeUDPSessions, 1)
+}
+
+func (s *TunnelStats) udpSessionsClosed(n int) {
+	atomic.AddInt64(&s.activeUDPSessions, -int64(n))
+}
+
+// Get returns the current counters.
+func (s *TunnelStats) Get() TunnelStatsPayload {
+	if s == nil {
+		return TunnelStatsPayload{}
+	}
+	return TunnelStatsPayload{
+		BytesIn:           atomic.LoadInt64(&s.bytesIn),
+		BytesOut:          atomic.LoadInt64(&s.bytesOut),
+		Connections:       atomic.LoadInt64(&s.connections),
+		ActiveConnections: atomic.LoadInt64(&s.activeConnections),
+		UDPSessions:       atomic.LoadInt64(&s.udpSessions),
+		ActiveUDPSessions: atomic.LoadInt64(&s.activeUDPSessions),
+	}
+}
+
+// Usage returns the traffic since the previous call of Usage. ActiveConnections and ActiveUDPSessions are the current
+// values.
+func (s *TunnelStats) Usage() TunnelStatsPayload {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	current := s.Get()
+	usage := TunnelStatsPayload{
+		BytesIn:           current.BytesIn - s.reported.BytesIn,
+		BytesOut:          current.BytesOut - s.reported.BytesOut,
+		Connections:       current.Connections - s.reported.Connections,
+		ActiveConnections: current.ActiveConnections,
+		UDPSessions:       current.UDPSessions - s.reported.UDPSessions,
+		ActiveUDPSessions: current.ActiveUDPSessions,
+	}
+	s.reported = current
+	return usage
+}
+
+func (s *TunnelStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Get())
+}
+
+func (s *TunnelStats) UnmarshalJSON(data []byte) error {
+	// counters are not restored, a tunnel always starts without traffic
+	return nil
+}
+
+// bandwidthLimiter is a token bucket allowing rate bytes per second with bursts of up to one second.
+type bandwidthLimiter struct {
+	rate float64
+
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter returns a limiter for the given bytes per second or nil if rate is not positive.
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// burst returns the max number of bytes that should be transferred at once.
+func (l *bandwidthLimiter) burst() int {
+	if l.rate < 1 {
+		return 1
+	}
+	return int(l.rate)
+}
+
+// wait takes n tokens from the bucket and blocks until the bucket is no longer in debt.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mtx.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mtx.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tunnelTraffic counts and limits the traffic of a tunnel in both directions.
+type tunnelTraffic struct {
+	stats    *TunnelStats
+	inLimit  *bandwidthLimiter
+	outLimit *bandwidthLimiter
+}
+
+func newTunnelTraffic(stats *TunnelStats, maxBandwidth int64) *tunnelTraffic {
+	return &tunnelTraffic{
+		stats:    stats,
+		inLimit:  newBandwidthLimiter(maxBandwidth),
+		outLimit: newBandwidthLimiter(maxBandwidth),
+	}
+}
+
+// received accounts n bytes received from a peer, it blocks while the bandwidth limit is exceeded.
+func (t *tunnelTraffic) received(ctx context.Context, n int) error {
+	t.stats.addIn(n)
+	return t.inLimit.wait(ctx, n)
+}
+
+// sent accounts n bytes sent to a peer, it blocks while the bandwidth limit is exceeded.
+func (t *tunnelTraffic) sent(ctx context.Context, n int) error {
+	t.stats.addOut(n)
+	return t.outLimit.wait(ctx, n)
+}
+
+// meteredConn passes all data read from conn to count.
+type meteredConn struct {
+	io.ReadWriteCloser
+	ctx   context.Context
+	limit *bandwidthLimiter
+	count func(ctx context.Context, n int) error
+}
+
+func (c *meteredConn) Read(p []byte) (int, error) {
+	// read at most one burst, so data is not delayed for longer than needed
+	if c.limit != nil && len(p) > c.limit.burst() {
+		p = p[:c.limit.burst()]
+	}
+	n, err := c.ReadWriteCloser.Read(p)
+	if n > 0 {
+		if waitErr := c.count(c.ctx, n); waitErr != nil && err == nil {
+			err = waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,68 @@
+package clienttunnel
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nopReadWriteCloser struct {
+	io.Reader
+}
+
+func (nopReadWriteCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (nopReadWriteCloser) Close() error {
+	return nil
+}
+
+func TestTunnelStatsUsage(t *testing.T) {
+	stats := &TunnelStats{}
+	stats.addIn(10)
+	stats.addOut(20)
+	stats.connOpened()
+
+	assert.Equal(t, TunnelStatsPayload{BytesIn: 10, BytesOut: 20, Connections: 1, ActiveConnections: 1}, stats.Usage())
+
+	stats.addIn(5)
+	stats.connClosed()
+
+	assert.Equal(t, TunnelStatsPayload{BytesIn: 5}, stats.Usage())
+	assert.Equal(t, TunnelStatsPayload{BytesIn: 15, BytesOut: 20, Connections: 1}, stats.Get())
+}
+
+func TestMeteredConnWithBandwidthLimit(t *testing.T) {
+	stats := &TunnelStats{}
+	traffic := newTunnelTraffic(stats, 1000)
+	conn := &meteredConn{
+		ReadWriteCloser: nopReadWriteCloser{Reader: strings.NewReader(strings.Repeat("x", 1500))},
+		ctx:             context.Background(),
+		limit:           traffic.inLimit,
+		count:           traffic.received,
+	}
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, conn)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1500), n)
+	assert.Equal(t, int64(1500), stats.Get().BytesIn)
+	// the first 1000 bytes are a burst, the remaining 500 bytes take half a second
+	assert.InDelta(t, 500*time.Millisecond, time.Since(start), float64(200*time.Millisecond))
+}
+
+func TestBandwidthLimiterCanceled(t *testing.T) {
+	l := newBandwidthLimiter(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.NoError(t, l.wait(ctx, 10))
+	assert.ErrorIs(t, l.wait(ctx, 10), context.Canceled)
+}
@@ -23,21 +23,25 @@ type tunnelUDP struct {
 	sshConn     ssh.Conn
 	acl         atomic.Pointer[TunnelACL] // parsed Remote.ACL field
 	idleTimeout time.Duration
+	traffic     *tunnelTraffic
 
-	conn    *net.UDPConn
-	channel *comm.UDPChannel
-	done    chan struct{}
-	cancel  func()
+	conn     *net.UDPConn
+	channel  *comm.UDPChannel
+	sessions *comm.UDPSessions
+	done     chan struct{}
+	cancel   func()
 
 	mtx        sync.Mutex
 	lastActive time.Time
 }
 
-func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelUDP {
+func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, traffic *tunnelTraffic) *tunnelUDP {
 	t := &tunnelUDP{
+		traffic:     traffic,
 		Logger:      logger,
 		Remote:      remote,
 		sshConn:     ssh,
+		sessions:    comm.NewUDPSessions(comm.UDPSessionIdleTimeout, comm.UDPMaxSessions),
 		done:        make(chan struct{}),
 		lastActive:  time.Now(),
 		idleTimeout: time.Duration(remote.IdleTimeoutMinutes) * time.Minute,
@@ -92,9 +96,13 @@ func (t *tunnelUDP) start(ctx context.Context, sshChan io.ReadWriter) error {
 func (t *tunnelUDP) runInbound(ctx context.Context) error {
 	defer t.conn.Close()
 	defer close(t.done)
+	defer func() {
+		t.traffic.stats.udpSessionsClosed(t.sessions.CloseAll())
+	}()
 
 	const maxMTU = 9012
 	buff := make([]byte, maxMTU)
+	lastExpire := time.Now()
 	for {
 		select {
 		case <-ctx.Done():
@@ -102,6 +110,11 @@ func (t *tunnelUDP) runInbound(ctx context.Context) error {
 		default:
 		}
 
+		if now := time.Now(); now.Sub(lastExpire) >= udpReadTimeout {
+			t.expireSessions(now)
+			lastExpire = now
+		}
+
 		err := t.conn.SetReadDeadline(time.Now().Add(udpReadTimeout))
 		if err != nil {
 			return err
@@ -128,6 +141,22 @@ func (t *tunnelUDP) runInbound(ctx context.Context) error {
 			}
 		}
 
+		_, created, err := t.sessions.Open(sourceAddr, nil)
+		if err != nil {
+			t.Debugf("Datagram from %s dropped: %v", sourceAddr, err)
+			continue
+		}
+		if created {
+			t.traffic.stats.udpSessionOpened()
+			t.Debugf("New UDP session of %s, %d active", sourceAddr, t.sessions.Active())
+		}
+
+		// packets exceeding the bandwidth limit queue up in the socket buffer until they are dropped
+		err = t.traffic.received(ctx, n)
+		if err != nil {
+			return nil
+		}
+
 		err = t.channel.Encode(sourceAddr, buff[:n])
 		if err != nil {
 			return err
@@ -151,8 +180,19 @@ func (t *tunnelUDP) runOutbound(ctx context.Context) error {
 			return err
 		}
 
+		// same as a NAT, replies are only relayed to peers with an active session
+		if !t.sessions.Touch(addr) {
+			t.Debugf("Datagram to %s dropped, no active UDP session", addr)
+			continue
+		}
+
 		t.setLastActive()
 
+		err = t.traffic.sent(ctx, len(data))
+		if err != nil {
+			return nil
+		}
+
 		_, err = t.conn.WriteToUDP(data, addr)
 		if err != nil {
 			return err
@@ -160,6 +200,17 @@ func (t *tunnelUDP) runOutbound(ctx context.Context) error {
 	}
 }
 
+func (t *tunnelUDP) expireSessions(now time.Time) {
+	expired := t.sessions.Expire(now)
+	if len(expired) == 0 {
+		return
+	}
+	t.traffic.stats.udpSessionsClosed(len(expired))
+	for _, session := range expired {
+		t.Debugf("UDP session of %s expired", session.Addr)
+	}
+}
+
 func (t *tunnelUDP) Terminate(force bool) error {
 	t.cancel()
 	<-t.done
@@ -20,7 +20,8 @@ func TestTunnelUDP(t *testing.T) {
 	udpReadTimeout = time.Millisecond
 	remote := models.Remote{}
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
-	tunnel := newTunnelUDP(logger, nil, remote, nil)
+	stats := &TunnelStats{}
+	tunnel := newTunnelUDP(logger, nil, remote, nil, newTunnelTraffic(stats, 0))
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	err := tunnel.start(context.Background(), serverChannel)
@@ -50,6 +51,7 @@ func TestTunnelUDP(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.WithinDuration(t, time.Now(), tunnel.LastActive(), 10*time.Millisecond)
+	assert.Equal(t, TunnelStatsPayload{BytesIn: 3, BytesOut: 3, UDPSessions: 1}, stats.Get())
 }
 
 func TestTunnelUDPWithACL(t *testing.T) {
@@ -58,7 +60,7 @@ func TestTunnelUDPWithACL(t *testing.T) {
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
 	acl, err := ParseTunnelACL("127.0.0.2")
 	require.NoError(t, err)
-	tunnel := newTunnelUDP(logger, nil, remote, acl)
+	tunnel := newTunnelUDP(logger, nil, remote, acl, newTunnelTraffic(&TunnelStats{}, 0))
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	local1, err := net.ResolveUDPAddr("udp", "127.0.0.1:0")
@@ -108,3 +110,65 @@ func TestTunnelUDPWithACL(t *testing.T) {
 	assert.Equal(t, []byte("def"), data)
 	assert.Equal(t, conn.LocalAddr(), addr)
 }
+
+func TestTunnelUDPSessions(t *testing.T) {
+	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	stats := &TunnelStats{}
+	tunnel := newTunnelUDP(logger, nil, models.Remote{}, nil, newTunnelTraffic(stats, 0))
+	tunnel.sessions = comm.NewUDPSessions(200*time.Millisecond, 2)
+	serverChannel, clientChannel := test.NewMockChannel()
+	channel := comm.NewUDPChannel(clientChannel)
+	err := tunnel.start(context.Background(), serverChannel)
+	require.NoError(t, err)
+
+	var peers []*net.UDPConn
+	for i := 0; i < 3; i++ {
+		conn, err := net.DialUDP("udp", nil, tunnel.conn.LocalAddr().(*net.UDPAddr))
+		require.NoError(t, err)
+		defer conn.Close()
+		peers = append(peers, conn)
+	}
+
+	for i, peer := range peers[:2] {
+		_, err = peer.Write([]byte{byte(i)})
+		require.NoError(t, err)
+		addr, data, err := channel.Decode()
+		require.NoError(t, err)
+		assert.Equal(t, peer.LocalAddr(), addr)
+		assert.Equal(t, []byte{byte(i)}, data)
+	}
+	assert.Equal(t, int64(2), stats.Get().ActiveUDPSessions)
+
+	// the session limit is reached, the datagram of the third peer is dropped
+	_, err = peers[2].Write([]byte("dropped"))
+	require.NoError(t, err)
+
+	// replies are relayed to the peer of the session, replies without session are dropped
+	err = channel.Encode(peers[2].LocalAddr().(*net.UDPAddr), []byte("no session"))
+	require.NoError(t, err)
+	for i := 1; i >= 0; i-- {
+		err = channel.Encode(peers[i].LocalAddr().(*net.UDPAddr), []byte{byte(i + 10)})
+		require.NoError(t, err)
+	}
+	buffer := make([]byte, 128)
+	for i, peer := range peers[:2] {
+		n, err := peer.Read(buffer)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{byte(i + 10)}, buffer[:n])
+	}
+
+	// idle sessions expire
+	assert.Eventually(t, func() bool {
+		return stats.Get().ActiveUDPSessions == 0
+	}, 3*time.Second, 10*time.Millisecond)
+	_, err = peers[2].Write([]byte("new session"))
+	require.NoError(t, err)
+	addr, data, err := channel.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, peers[2].LocalAddr(), addr)
+	assert.Equal(t, []byte("new session"), data)
+
+	require.NoError(t, tunnel.Terminate(false))
+	assert.Equal(t, int64(3), stats.Get().UDPSessions)
+	assert.Equal(t, int64(0), stats.Get().ActiveUDPSessions)
+}
@@ -3,6 +3,8 @@ package clienttunnel
 import (
 	"net"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/gorilla/websocket"
 
@@ -14,10 +16,25 @@ const websocketBufferSize = 1024
 var Upgrader = websocket.Upgrader{
 	ReadBufferSize:  websocketBufferSize,
 	WriteBufferSize: websocketBufferSize,
-	CheckOrigin:     func(r *http.Request) bool { return true },
+	CheckOrigin:     isSameOrigin,
 	Subprotocols:    []string{"binary"},
 }
 
+// isSameOrigin allows websocket requests of pages served by the tunnel proxy only. Browsers don't apply the same
+// origin policy to websockets, any other site could use the access a visitor has to the tunnel proxy otherwise.
+func isSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// not sent by a browser
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
 // WebsocketTCPProxy holds state information about the connection being proxied.
 type WebsocketTCPProxy struct {
 	wsConn  *websocket.Conn
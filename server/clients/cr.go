@@ -73,6 +73,41 @@ func InitClientRepository(
 	return NewClientRepositoryWithDB(initialClients, keepDisconnectedClients, provider, logger), nil
 }
 
+// Reload replaces the in-memory state by the clients from the store, e.g. when a passive server becomes active
+// and takes over the clients of the previously active server. Clients still marked as connected are marked as
+// disconnected, they have to reconnect to this server.
+func (r *ClientRepository) Reload(ctx context.Context) error {
+	store := r.getStore()
+	if store == nil {
+		return nil
+	}
+
+	loadedClients, err := LoadInitialClients(ctx, store, r.log())
+	if err != nil {
+		return err
+	}
+
+	clients := make(map[string]*clientdata.Client, len(loadedClients))
+	for _, cl := range loadedClients {
+		clients[cl.GetID()] = cl
+	}
+
+	r.mu.Lock()
+	r.clientState = clients
+	r.mu.Unlock()
+
+	return nil
+}
+
+// CloseAllActive closes the connections of all connected clients, so they reconnect to another server.
+func (r *ClientRepository) CloseAllActive() {
+	for _, cl := range r.GetAllActiveClients() {
+		if err := cl.Close(); err != nil {
+			r.log().Errorf("failed to close connection of client %s: %v", cl.GetID(), err)
+		}
+	}
+}
+
 func (r *ClientRepository) SetPostSaveHandlerFn(handlerFn func(cl *clientdata.Client)) {
 	r.postSaveHandlerFn = handlerFn
 }
@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -77,6 +78,27 @@ func TestCRWithExpiration(t *testing.T) {
 	assert.ElementsMatch([]*clientdata.Client{c1, c2}, gotClients)
 }
 
+func TestCRReload(t *testing.T) {
+	ctx := context.Background()
+	exp := time.Hour
+	cl1 := New(t).ID("client-1").Logger(testLog).Build()
+	cl2 := New(t).ID("client-2").DisconnectedDuration(5 * time.Minute).Logger(testLog).Build()
+	p := NewFakeClientProvider(t, &exp, cl1)
+	defer p.Close()
+
+	repo := NewClientRepositoryWithDB(nil, &exp, p, testLog)
+	assert.Equal(t, 0, repo.Count())
+
+	// saved by another server
+	require.NoError(t, p.Save(ctx, cl2))
+
+	require.NoError(t, repo.Reload(ctx))
+
+	assert.Equal(t, 2, repo.Count())
+	// clients connected to the other server have to reconnect
+	assert.Equal(t, 0, repo.CountActive())
+}
+
 func TestCRWithNoExpiration(t *testing.T) {
 	clientdata.Now = nowMockF
 
@@ -11,40 +11,41 @@ import (
 )
 
 type ClientPayload struct {
-	ID                     *string                 `json:"id,omitempty"`
-	Name                   *string                 `json:"name,omitempty"`
-	Address                *string                 `json:"address,omitempty"`
-	Hostname               *string                 `json:"hostname,omitempty"`
-	OS                     *string                 `json:"os,omitempty"`
-	OSFullName             *string                 `json:"os_full_name,omitempty"`
-	OSVersion              *string                 `json:"os_version,omitempty"`
-	OSArch                 *string                 `json:"os_arch,omitempty"`
-	OSFamily               *string                 `json:"os_family,omitempty"`
-	OSKernel               *string                 `json:"os_kernel,omitempty"`
-	OSVirtualizationSystem *string                 `json:"os_virtualization_system,omitempty"`
-	OSVirtualizationRole   *string                 `json:"os_virtualization_role,omitempty"`
-	NumCPUs                *int                    `json:"num_cpus,omitempty"`
-	CPUFamily              *string                 `json:"cpu_family,omitempty"`
-	CPUModel               *string                 `json:"cpu_model,omitempty"`
-	CPUModelName           *string                 `json:"cpu_model_name,omitempty"`
-	CPUVendor              *string                 `json:"cpu_vendor,omitempty"`
-	MemoryTotal            *uint64                 `json:"mem_total,omitempty"`
-	Timezone               *string                 `json:"timezone,omitempty"`
-	ClientAuthID           *string                 `json:"client_auth_id,omitempty"`
-	Version                *string                 `json:"version,omitempty"`
-	DisconnectedAt         **time.Time             `json:"disconnected_at,omitempty"`
-	LastHeartbeatAt        **time.Time             `json:"last_heartbeat_at,omitempty"`
-	ConnectionState        *string                 `json:"connection_state,omitempty"`
-	IPv4                   *[]string               `json:"ipv4,omitempty"`
-	IPv6                   *[]string               `json:"ipv6,omitempty"`
-	Tags                   *[]string               `json:"tags,omitempty"`
-	AllowedUserGroups      *[]string               `json:"allowed_user_groups,omitempty"`
-	Tunnels                *[]*clienttunnel.Tunnel `json:"tunnels,omitempty"`
-	UpdatesStatus          **models.UpdatesStatus  `json:"updates_status,omitempty"`
-	IPAddresses            **models.IPAddresses    `json:"ext_ip_addresses,omitempty"`
-	ClientConfiguration    **clientconfig.Config   `json:"client_configuration,omitempty"`
-	Groups                 *[]string               `json:"groups,omitempty"`
-	Labels                 *map[string]string      `json:"labels,omitempty"`
+	ID                     *string                          `json:"id,omitempty"`
+	Name                   *string                          `json:"name,omitempty"`
+	Address                *string                          `json:"address,omitempty"`
+	Hostname               *string                          `json:"hostname,omitempty"`
+	OS                     *string                          `json:"os,omitempty"`
+	OSFullName             *string                          `json:"os_full_name,omitempty"`
+	OSVersion              *string                          `json:"os_version,omitempty"`
+	OSArch                 *string                          `json:"os_arch,omitempty"`
+	OSFamily               *string                          `json:"os_family,omitempty"`
+	OSKernel               *string                          `json:"os_kernel,omitempty"`
+	OSVirtualizationSystem *string                          `json:"os_virtualization_system,omitempty"`
+	OSVirtualizationRole   *string                          `json:"os_virtualization_role,omitempty"`
+	NumCPUs                *int                             `json:"num_cpus,omitempty"`
+	CPUFamily              *string                          `json:"cpu_family,omitempty"`
+	CPUModel               *string                          `json:"cpu_model,omitempty"`
+	CPUModelName           *string                          `json:"cpu_model_name,omitempty"`
+	CPUVendor              *string                          `json:"cpu_vendor,omitempty"`
+	MemoryTotal            *uint64                          `json:"mem_total,omitempty"`
+	Timezone               *string                          `json:"timezone,omitempty"`
+	ClientAuthID           *string                          `json:"client_auth_id,omitempty"`
+	Version                *string                          `json:"version,omitempty"`
+	DisconnectedAt         **time.Time                      `json:"disconnected_at,omitempty"`
+	LastHeartbeatAt        **time.Time                      `json:"last_heartbeat_at,omitempty"`
+	ConnectionState        *string                          `json:"connection_state,omitempty"`
+	IPv4                   *[]string                        `json:"ipv4,omitempty"`
+	IPv6                   *[]string                        `json:"ipv6,omitempty"`
+	Tags                   *[]string                        `json:"tags,omitempty"`
+	AllowedUserGroups      *[]string                        `json:"allowed_user_groups,omitempty"`
+	Tunnels                *[]*clienttunnel.Tunnel          `json:"tunnels,omitempty"`
+	TunnelStats            *clienttunnel.TunnelStatsPayload `json:"tunnel_stats,omitempty"`
+	UpdatesStatus          **models.UpdatesStatus           `json:"updates_status,omitempty"`
+	IPAddresses            **models.IPAddresses             `json:"ext_ip_addresses,omitempty"`
+	ClientConfiguration    **clientconfig.Config            `json:"client_configuration,omitempty"`
+	Groups                 *[]string                        `json:"groups,omitempty"`
+	Labels                 *map[string]string               `json:"labels,omitempty"`
 }
 
 func ConvertToClientsPayload(clientsList []*clientdata.CalculatedClient, fields []query.FieldsOption) []ClientPayload {
@@ -97,6 +98,13 @@ func ConvertToClientPayload(client *clientdata.CalculatedClient, fields []query.
 			p.Address = &client.Address
 		case "tunnels":
 			p.Tunnels = &client.Tunnels
+		case "tunnel_stats":
+			// the traffic of the currently open tunnels
+			stats := clienttunnel.TunnelStatsPayload{}
+			for _, t := range client.Tunnels {
+				stats.Add(t.Stats.Get())
+			}
+			p.TunnelStats = &stats
 		case "disconnected_at":
 			disconnectedAt := client.DisconnectedAt
 			p.DisconnectedAt = &disconnectedAt
@@ -11,6 +11,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/server/clients/clientdata"
 	"github.com/openrport/openrport/server/clients/clienttunnel"
@@ -41,7 +42,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clien
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		"SELECT * FROM clients WHERE disconnected_at IS NULL OR DATETIME(disconnected_at) >= DATETIME(?) OR ?",
+		"SELECT * FROM clients WHERE disconnected_at IS NULL OR "+p.dateTime("disconnected_at")+" >= "+p.dateTime("?")+" OR ?",
 		p.keepDisconnectedClientsStart(),
 		p.keepDisconnectedClients == nil,
 	)
@@ -51,6 +52,10 @@ func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clien
 	return convertClientList(res, l), nil
 }
 
+func (p *SqliteProvider) dateTime(expr string) string {
+	return backend.DateTime(p.db.DriverName(), expr)
+}
+
 // test only
 func (p *SqliteProvider) get(ctx context.Context, id string, l *logger.Logger) (*clientdata.Client, error) {
 	res := &clientSqlite{}
@@ -72,7 +77,7 @@ func (p *SqliteProvider) Save(ctx context.Context, client *clientdata.Client) er
 
 		_, err = p.db.NamedExecContext(
 			ctx,
-			"INSERT OR REPLACE INTO clients (id, client_auth_id, disconnected_at, details) VALUES (:id, :client_auth_id, :disconnected_at, :details)",
+			backend.ReplaceInto(p.db.DriverName(), "clients", []string{"id"}, "id", "client_auth_id", "disconnected_at", "details"),
 			clientForSQL,
 		)
 
@@ -87,7 +92,7 @@ func (p *SqliteProvider) DeleteObsolete(ctx context.Context, l *logger.Logger) e
 
 		_, err = p.db.ExecContext(
 			ctx,
-			"DELETE FROM clients WHERE disconnected_at IS NOT NULL AND DATETIME(disconnected_at) < DATETIME(?) AND ?",
+			"DELETE FROM clients WHERE disconnected_at IS NOT NULL AND "+p.dateTime("disconnected_at")+" < "+p.dateTime("?")+" AND ?",
 			p.keepDisconnectedClientsStart(),
 			p.keepDisconnectedClients != nil,
 		)
@@ -155,6 +160,7 @@ func convertToSqlite(c *clientdata.Client) (res *clientSqlite) {
 			Tags:                   c.Tags,
 			Labels:                 c.Labels,
 			Tunnels:                c.Tunnels,
+			TunnelSSHCredentials:   tunnelSSHCredentials(c.Tunnels),
 			AllowedUserGroups:      c.AllowedUserGroups,
 			UpdatesStatus:          c.UpdatesStatus,
 			IPAddresses:            c.IPAddresses,
@@ -201,21 +207,24 @@ type clientDetails struct {
 	Tags                   []string               `json:"tags"`
 	Labels                 map[string]string      `json:"labels"`
 	Tunnels                []*clienttunnel.Tunnel `json:"tunnels"`
-	AllowedUserGroups      []string               `json:"allowed_user_groups"`
-	UpdatesStatus          *models.UpdatesStatus  `json:"updates_status"`
-	IPAddresses            *models.IPAddresses    `json:"ext_ip_addresses"`
-	ClientConfig           *chshare.Config        `json:"client_configuration"`
+	// TunnelSSHCredentials by tunnel id, they are not part of the tunnels, as tunnels are returned by the API. They
+	// hold the vault id of the secret only, never the secret itself.
+	TunnelSSHCredentials map[string]*models.SSHCredentials `json:"tunnel_ssh_credentials,omitempty"`
+	AllowedUserGroups    []string                          `json:"allowed_user_groups"`
+	UpdatesStatus        *models.UpdatesStatus             `json:"updates_status"`
+	IPAddresses          *models.IPAddresses               `json:"ext_ip_addresses"`
+	ClientConfig         *chshare.Config                   `json:"client_configuration"`
 }
 
 func (d *clientDetails) Scan(value interface{}) error {
 	if d == nil {
 		return errors.New("'details' cannot be nil")
 	}
-	valueStr, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("expected to have string, got %T", value)
+	data, err := backend.ScanText(value)
+	if err != nil {
+		return err
 	}
-	err := json.Unmarshal([]byte(valueStr), d)
+	err = json.Unmarshal(data, d)
 	if err != nil {
 		return fmt.Errorf("failed to decode 'details' field: %v", err)
 	}
@@ -268,12 +277,29 @@ func (s *clientSqlite) convert(l *logger.Logger) (res *clientdata.Client) {
 		ClientConfiguration:    d.ClientConfig,
 		Logger:                 l,
 	}
+	for _, t := range res.Tunnels {
+		t.Remote.SSHCredentials = d.TunnelSSHCredentials[t.ID]
+	}
 	if s.DisconnectedAt.Valid {
 		res.SetDisconnectedAt(&s.DisconnectedAt.Time)
 	}
 	return res
 }
 
+func tunnelSSHCredentials(tunnels []*clienttunnel.Tunnel) map[string]*models.SSHCredentials {
+	var res map[string]*models.SSHCredentials
+	for _, t := range tunnels {
+		if t.Remote.SSHCredentials == nil {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]*models.SSHCredentials)
+		}
+		res[t.ID] = t.Remote.SSHCredentials
+	}
+	return res
+}
+
 func convertClientList(list []*clientSqlite, l *logger.Logger) []*clientdata.Client {
 	res := make([]*clientdata.Client, 0, len(list))
 	for _, cur := range list {
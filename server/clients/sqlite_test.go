@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/models"
 )
 
 func TestClientsSqliteProvider(t *testing.T) {
@@ -70,3 +71,23 @@ func TestClientsSqliteProvider(t *testing.T) {
 	require.NoError(t, err)
 	assert.ElementsMatch(t, []*clientdata.Client{c1, c2, c3, c4}, gotAll)
 }
+
+func TestClientsSqliteProviderKeepsSSHCredentials(t *testing.T) {
+	ctx := context.Background()
+	p := NewFakeClientProvider(t, &hour)
+	defer p.Close()
+
+	c1 := New(t).Logger(testLog).Build()
+	require.NotEmpty(t, c1.Tunnels)
+	creds := &models.SSHCredentials{Username: "root", VaultID: 12, HostKey: "ssh-ed25519 AAAA"}
+	c1.Tunnels[0].Remote.SSHCredentials = creds
+	require.NoError(t, p.Save(ctx, c1))
+
+	got, err := p.get(ctx, c1.GetID(), testLog)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, creds, got.Tunnels[0].Remote.SSHCredentials)
+	for _, tunnel := range got.Tunnels[1:] {
+		assert.Nil(t, tunnel.Remote.SSHCredentials)
+	}
+}
@@ -1,14 +1,23 @@
 package storedtunnels
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/types"
 )
 
+// autostartTimeLayout is the format of the autostart window, it's evaluated in UTC.
+const autostartTimeLayout = "15:04"
+
 type StoredTunnel struct {
 	ID             string            `json:"id" db:"id"`
 	ClientID       string            `json:"-" db:"client_id"`
+	ClientGroupID  string            `json:"-" db:"client_group_id"`
 	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
 	Name           string            `json:"name" db:"name"`
 	Scheme         *string           `json:"scheme" db:"scheme"`
@@ -17,4 +26,121 @@ type StoredTunnel struct {
 	PublicPort     *int              `json:"public_port" db:"public_port"`
 	ACL            *string           `json:"acl" db:"acl"`
 	FurtherOptions *types.JSONString `json:"further_options" db:"further_options"`
+	Autostart      bool              `json:"autostart" db:"autostart"`
+	AutostartFrom  *string           `json:"autostart_from" db:"autostart_from"`
+	AutostartUntil *string           `json:"autostart_until" db:"autostart_until"`
+}
+
+func (t *StoredTunnel) Validate() error {
+	if t.Autostart && t.RemotePort == nil {
+		return errors.New("remote_port is required for autostart")
+	}
+	if (t.AutostartFrom == nil) != (t.AutostartUntil == nil) {
+		return errors.New("autostart_from and autostart_until must be given together")
+	}
+	if t.AutostartFrom == nil {
+		return nil
+	}
+	if _, err := time.Parse(autostartTimeLayout, *t.AutostartFrom); err != nil {
+		return errors.New("invalid autostart_from, expected HH:MM")
+	}
+	if _, err := time.Parse(autostartTimeLayout, *t.AutostartUntil); err != nil {
+		return errors.New("invalid autostart_until, expected HH:MM")
+	}
+	return nil
+}
+
+// InAutostartWindow returns true if the tunnel should be started at the given time. A window with from after until
+// spans midnight, e.g. 22:00 - 06:00.
+func (t *StoredTunnel) InAutostartWindow(now time.Time) bool {
+	if !t.Autostart {
+		return false
+	}
+	if t.AutostartFrom == nil || t.AutostartUntil == nil {
+		return true
+	}
+
+	from, err := time.Parse(autostartTimeLayout, *t.AutostartFrom)
+	if err != nil {
+		return false
+	}
+	until, err := time.Parse(autostartTimeLayout, *t.AutostartUntil)
+	if err != nil {
+		return false
+	}
+
+	now = now.UTC()
+	minute := now.Hour()*60 + now.Minute()
+	fromMinute := from.Hour()*60 + from.Minute()
+	untilMinute := until.Hour()*60 + until.Minute()
+	if fromMinute <= untilMinute {
+		return minute >= fromMinute && minute < untilMinute
+	}
+	return minute >= fromMinute || minute < untilMinute
+}
+
+// AutostartWindowOpened returns true if the autostart window of the tunnel started after since and not after now.
+func (t *StoredTunnel) AutostartWindowOpened(since, now time.Time) bool {
+	if !t.Autostart || t.AutostartFrom == nil || t.AutostartUntil == nil {
+		return false
+	}
+
+	from, err := time.Parse(autostartTimeLayout, *t.AutostartFrom)
+	if err != nil {
+		return false
+	}
+
+	// the latest start of the window not after now
+	now = now.UTC()
+	opened := time.Date(now.Year(), now.Month(), now.Day(), from.Hour(), from.Minute(), 0, 0, time.UTC)
+	if opened.After(now) {
+		opened = opened.AddDate(0, 0, -1)
+	}
+	return opened.After(since)
+}
+
+// autostartOptions are the further options applied when a tunnel is autostarted, others are kept for the UI only.
+type autostartOptions struct {
+	Protocol           string `json:"protocol"`
+	HTTPProxy          bool   `json:"http_proxy"`
+	HostHeader         string `json:"host_header"`
+	IdleTimeoutMinutes int    `json:"idle_timeout_minutes"`
+}
+
+// ToRemote returns the tunnel to start, it listens on a random port if no public port is given.
+func (t *StoredTunnel) ToRemote() (*models.Remote, error) {
+	if t.RemotePort == nil {
+		return nil, errors.New("remote_port is missing")
+	}
+
+	var options autostartOptions
+	if t.FurtherOptions != nil && *t.FurtherOptions != "" {
+		if err := json.Unmarshal([]byte(*t.FurtherOptions), &options); err != nil {
+			return nil, fmt.Errorf("invalid further_options: %v", err)
+		}
+	}
+
+	r := &models.Remote{
+		Name:               t.Name,
+		Protocol:           models.ProtocolTCP,
+		RemoteHost:         models.LocalHost,
+		RemotePort:         strconv.Itoa(*t.RemotePort),
+		Scheme:             t.Scheme,
+		ACL:                t.ACL,
+		HTTPProxy:          options.HTTPProxy,
+		HostHeader:         options.HostHeader,
+		IdleTimeoutMinutes: options.IdleTimeoutMinutes,
+	}
+	if options.Protocol != "" {
+		r.Protocol = options.Protocol
+	}
+	if t.RemoteIP != nil && *t.RemoteIP != "" {
+		r.RemoteHost = *t.RemoteIP
+	}
+	if t.PublicPort != nil {
+		r.LocalHost = models.ZeroHost
+		r.LocalPort = strconv.Itoa(*t.PublicPort)
+	}
+
+	return r, nil
 }
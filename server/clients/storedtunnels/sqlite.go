@@ -2,29 +2,36 @@ package storedtunnels
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/openrport/openrport/share/query"
 )
 
+// SQLiteProvider stores the tunnels of one owner type, either clients in stored_tunnels
+// or client groups in group_stored_tunnels.
 type SQLiteProvider struct {
-	db        *sqlx.DB
-	converter *query.SQLConverter
+	db          *sqlx.DB
+	converter   *query.SQLConverter
+	table       string
+	ownerColumn string
 }
 
-func newSQLiteProvider(db *sqlx.DB) *SQLiteProvider {
+func newSQLiteProvider(db *sqlx.DB, table, ownerColumn string) *SQLiteProvider {
 	return &SQLiteProvider{
-		db:        db,
-		converter: query.NewSQLConverter(db.DriverName()),
+		db:          db,
+		converter:   query.NewSQLConverter(db.DriverName()),
+		table:       table,
+		ownerColumn: ownerColumn,
 	}
 }
 
 func (p *SQLiteProvider) Insert(ctx context.Context, t *StoredTunnel) error {
 	_, err := p.db.NamedExecContext(ctx,
-		`INSERT INTO stored_tunnels (
+		fmt.Sprintf(`INSERT INTO %[1]s (
 			id,
-			client_id,
+			%[2]s,
 			created_at,
 			name,
 			scheme,
@@ -32,10 +39,13 @@ func (p *SQLiteProvider) Insert(ctx context.Context, t *StoredTunnel) error {
 			remote_port,
 			public_port,
 			acl,
-			further_options
+			further_options,
+			autostart,
+			autostart_from,
+			autostart_until
 		) VALUES (
 			:id,
-			:client_id,
+			:%[2]s,
 			:created_at,
 			:name,
 			:scheme,
@@ -43,8 +53,11 @@ func (p *SQLiteProvider) Insert(ctx context.Context, t *StoredTunnel) error {
 			:remote_port,
 			:public_port,
 			:acl,
-			:further_options
-		)`,
+			:further_options,
+			:autostart,
+			:autostart_from,
+			:autostart_until
+		)`, p.table, p.ownerColumn),
 		t,
 	)
 
@@ -53,26 +66,29 @@ func (p *SQLiteProvider) Insert(ctx context.Context, t *StoredTunnel) error {
 
 func (p *SQLiteProvider) Update(ctx context.Context, t *StoredTunnel) error {
 	_, err := p.db.NamedExecContext(ctx,
-		`UPDATE stored_tunnels SET
+		fmt.Sprintf(`UPDATE %[1]s SET
 			name = :name,
 			scheme = :scheme,
 			remote_ip = :remote_ip,
 			remote_port = :remote_port,
 			public_port = :public_port,
 			acl = :acl,
-			further_options = :further_options
-		WHERE client_id = :client_id AND id = :id`,
+			further_options = :further_options,
+			autostart = :autostart,
+			autostart_from = :autostart_from,
+			autostart_until = :autostart_until
+		WHERE %[2]s = :%[2]s AND id = :id`, p.table, p.ownerColumn),
 		t,
 	)
 
 	return err
 }
 
-func (p *SQLiteProvider) List(ctx context.Context, clientID string, options *query.ListOptions) ([]*StoredTunnel, error) {
+func (p *SQLiteProvider) List(ctx context.Context, ownerID string, options *query.ListOptions) ([]*StoredTunnel, error) {
 	values := []*StoredTunnel{}
 
-	q := "SELECT * FROM stored_tunnels WHERE client_id = ?"
-	params := []interface{}{clientID}
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", p.table, p.ownerColumn)
+	params := []interface{}{ownerID}
 
 	q, params = p.converter.AppendOptionsToQuery(options, q, params)
 
@@ -84,14 +100,38 @@ func (p *SQLiteProvider) List(ctx context.Context, clientID string, options *que
 	return values, nil
 }
 
-func (p *SQLiteProvider) Count(ctx context.Context, clientID string, options *query.ListOptions) (int, error) {
+func (p *SQLiteProvider) ListAutostart(ctx context.Context, ownerIDs []string) ([]*StoredTunnel, error) {
+	values := []*StoredTunnel{}
+	if len(ownerIDs) == 0 {
+		return values, nil
+	}
+
+	q, params, err := sqlx.In(
+		fmt.Sprintf("SELECT * FROM %s WHERE autostart = ? AND %s IN (?) ORDER BY created_at", p.table, p.ownerColumn),
+		true,
+		ownerIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.db.SelectContext(ctx, &values, p.db.Rebind(q), params...)
+	if err != nil {
+		return values, err
+	}
+
+	return values, nil
+}
+
+func (p *SQLiteProvider) Count(ctx context.Context, ownerID string, options *query.ListOptions) (int, error) {
 	var result int
 
-	q := "SELECT COUNT(*) FROM stored_tunnels WHERE client_id = ?"
-	params := []interface{}{clientID}
+	q := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", p.table, p.ownerColumn)
+	params := []interface{}{ownerID}
 
 	countOptions := *options
 	countOptions.Pagination = nil
+	countOptions.Sorts = nil
 	q, params = p.converter.AppendOptionsToQuery(&countOptions, q, params)
 
 	err := p.db.GetContext(ctx, &result, q, params...)
@@ -102,7 +142,12 @@ func (p *SQLiteProvider) Count(ctx context.Context, clientID string, options *qu
 	return result, nil
 }
 
-func (p *SQLiteProvider) Delete(ctx context.Context, clientID, id string) error {
-	_, err := p.db.ExecContext(ctx, "DELETE FROM stored_tunnels WHERE client_id = ? AND id = ?", clientID, id)
+func (p *SQLiteProvider) Delete(ctx context.Context, ownerID, id string) error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND id = ?", p.table, p.ownerColumn), ownerID, id)
+	return err
+}
+
+func (p *SQLiteProvider) DeleteAll(ctx context.Context, ownerID string) error {
+	_, err := p.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s = ?", p.table, p.ownerColumn), ownerID)
 	return err
 }
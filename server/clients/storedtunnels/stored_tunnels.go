@@ -2,11 +2,13 @@ package storedtunnels
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/openrport/openrport/server/api"
+	errors2 "github.com/openrport/openrport/server/api/errors"
 	"github.com/openrport/openrport/share/query"
 	"github.com/openrport/openrport/share/random"
 )
@@ -29,24 +31,41 @@ var (
 
 type Provider interface {
 	Delete(context.Context, string, string) error
+	DeleteAll(context.Context, string) error
 	Insert(context.Context, *StoredTunnel) error
 	Update(context.Context, *StoredTunnel) error
 	List(context.Context, string, *query.ListOptions) ([]*StoredTunnel, error)
 	Count(context.Context, string, *query.ListOptions) (int, error)
+	ListAutostart(context.Context, []string) ([]*StoredTunnel, error)
 }
 
+// Manager handles the stored tunnels of clients and of client groups, the latter apply to every client of the group.
 type Manager struct {
-	provider Provider
+	provider      Provider
+	groupProvider Provider
 }
 
 func New(db *sqlx.DB) *Manager {
 	return &Manager{
-		provider: newSQLiteProvider(db),
+		provider:      newSQLiteProvider(db, "stored_tunnels", "client_id"),
+		groupProvider: newSQLiteProvider(db, "group_stored_tunnels", "client_group_id"),
 	}
 }
 
 func (m *Manager) List(ctx context.Context, options *query.ListOptions, clientID string) (*api.SuccessPayload, error) {
+	return m.list(ctx, m.provider, options, clientID, nil)
+}
+
+// ListAllowed is like List but returns only the stored tunnels with the given IDs, no IDs allow all stored tunnels.
+func (m *Manager) ListAllowed(ctx context.Context, options *query.ListOptions, clientID string, allowedIDs []string) (*api.SuccessPayload, error) {
+	return m.list(ctx, m.provider, options, clientID, allowedIDs)
+}
+
+func (m *Manager) ListForGroup(ctx context.Context, options *query.ListOptions, groupID string) (*api.SuccessPayload, error) {
+	return m.list(ctx, m.groupProvider, options, groupID, nil)
+}
 
+func (m *Manager) list(ctx context.Context, provider Provider, options *query.ListOptions, ownerID string, allowedIDs []string) (*api.SuccessPayload, error) {
 	err := query.ValidateListOptions(options, supportedSorts, supportedFilters, nil, &query.PaginationConfig{
 		DefaultLimit: 10,
 		MaxLimit:     100,
@@ -54,13 +73,20 @@ func (m *Manager) List(ctx context.Context, options *query.ListOptions, clientID
 	if err != nil {
 		return nil, err
 	}
+	if len(allowedIDs) > 0 {
+		// added after the validation, id is not a filter supported in requests
+		options.Filters = append(options.Filters, query.FilterOption{
+			Column: []string{"id"},
+			Values: allowedIDs,
+		})
+	}
 
-	entries, err := m.provider.List(ctx, clientID, options)
+	entries, err := provider.List(ctx, ownerID, options)
 	if err != nil {
 		return nil, err
 	}
 
-	count, err := m.provider.Count(ctx, clientID, options)
+	count, err := provider.Count(ctx, ownerID, options)
 	if err != nil {
 		return nil, err
 	}
@@ -72,15 +98,28 @@ func (m *Manager) List(ctx context.Context, options *query.ListOptions, clientID
 }
 
 func (m *Manager) Create(ctx context.Context, clientID string, t *StoredTunnel) (*StoredTunnel, error) {
+	t.ClientID = clientID
+	return m.create(ctx, m.provider, t)
+}
+
+func (m *Manager) CreateForGroup(ctx context.Context, groupID string, t *StoredTunnel) (*StoredTunnel, error) {
+	t.ClientGroupID = groupID
+	return m.create(ctx, m.groupProvider, t)
+}
+
+func (m *Manager) create(ctx context.Context, provider Provider, t *StoredTunnel) (*StoredTunnel, error) {
+	if err := t.Validate(); err != nil {
+		return nil, errors2.NewAPIError(http.StatusBadRequest, "", err.Error(), err)
+	}
+
 	id, err := random.UUID4()
 	if err != nil {
 		return nil, err
 	}
 	t.ID = id
 	t.CreatedAt = time.Now()
-	t.ClientID = clientID
 
-	err = m.provider.Insert(ctx, t)
+	err = provider.Insert(ctx, t)
 	if err != nil {
 		return nil, err
 	}
@@ -90,8 +129,20 @@ func (m *Manager) Create(ctx context.Context, clientID string, t *StoredTunnel)
 
 func (m *Manager) Update(ctx context.Context, clientID string, t *StoredTunnel) (*StoredTunnel, error) {
 	t.ClientID = clientID
+	return m.update(ctx, m.provider, t)
+}
+
+func (m *Manager) UpdateForGroup(ctx context.Context, groupID string, t *StoredTunnel) (*StoredTunnel, error) {
+	t.ClientGroupID = groupID
+	return m.update(ctx, m.groupProvider, t)
+}
 
-	err := m.provider.Update(ctx, t)
+func (m *Manager) update(ctx context.Context, provider Provider, t *StoredTunnel) (*StoredTunnel, error) {
+	if err := t.Validate(); err != nil {
+		return nil, errors2.NewAPIError(http.StatusBadRequest, "", err.Error(), err)
+	}
+
+	err := provider.Update(ctx, t)
 	if err != nil {
 		return nil, err
 	}
@@ -102,3 +153,28 @@ func (m *Manager) Update(ctx context.Context, clientID string, t *StoredTunnel)
 func (m *Manager) Delete(ctx context.Context, clientID, id string) error {
 	return m.provider.Delete(ctx, clientID, id)
 }
+
+func (m *Manager) DeleteForGroup(ctx context.Context, groupID, id string) error {
+	return m.groupProvider.Delete(ctx, groupID, id)
+}
+
+// DeleteAllForGroup deletes the stored tunnels of a deleted client group.
+func (m *Manager) DeleteAllForGroup(ctx context.Context, groupID string) error {
+	return m.groupProvider.DeleteAll(ctx, groupID)
+}
+
+// ListAutostart returns the autostart tunnels of the client and of the given groups of the client,
+// regardless of their autostart window.
+func (m *Manager) ListAutostart(ctx context.Context, clientID string, groupIDs []string) ([]*StoredTunnel, error) {
+	tunnels, err := m.provider.ListAutostart(ctx, []string{clientID})
+	if err != nil {
+		return nil, err
+	}
+
+	groupTunnels, err := m.groupProvider.ListAutostart(ctx, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(tunnels, groupTunnels...), nil
+}
@@ -3,6 +3,7 @@ package storedtunnels
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -11,6 +12,7 @@ import (
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/share/query"
 	"github.com/openrport/openrport/share/random"
+	"github.com/openrport/openrport/share/types"
 )
 
 var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
@@ -54,3 +56,138 @@ func TestStoredTunnels(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, results.Meta.Count)
 }
+
+func TestStoredTunnelsListAllowed(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", clients.AssetNames(), clients.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	manager := New(db)
+
+	tunnel1, err := manager.Create(ctx, "client-1", &StoredTunnel{Name: "tunnel-1"})
+	require.NoError(t, err)
+	_, err = manager.Create(ctx, "client-1", &StoredTunnel{Name: "tunnel-2"})
+	require.NoError(t, err)
+
+	results, err := manager.ListAllowed(ctx, &query.ListOptions{}, "client-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, results.Meta.Count)
+
+	results, err = manager.ListAllowed(ctx, &query.ListOptions{}, "client-1", []string{tunnel1.ID, "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Meta.Count)
+	require.Len(t, results.Data, 1)
+	assert.Equal(t, tunnel1.ID, results.Data.([]*StoredTunnel)[0].ID)
+}
+
+func TestStoredTunnelsAutostart(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", clients.AssetNames(), clients.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	manager := New(db)
+	remotePort := 22
+	from, until := "22:00", "06:00"
+
+	_, err = manager.Create(ctx, "client-1", &StoredTunnel{Name: "manual", RemotePort: &remotePort})
+	require.NoError(t, err)
+	clientTunnel, err := manager.Create(ctx, "client-1", &StoredTunnel{Name: "client", RemotePort: &remotePort, Autostart: true})
+	require.NoError(t, err)
+	groupTunnel, err := manager.CreateForGroup(ctx, "group-1", &StoredTunnel{
+		Name:           "group",
+		RemotePort:     &remotePort,
+		Autostart:      true,
+		AutostartFrom:  &from,
+		AutostartUntil: &until,
+	})
+	require.NoError(t, err)
+
+	_, err = manager.CreateForGroup(ctx, "group-1", &StoredTunnel{Autostart: true})
+	assert.EqualError(t, err, "remote_port is required for autostart")
+	_, err = manager.CreateForGroup(ctx, "group-1", &StoredTunnel{AutostartFrom: &from})
+	assert.EqualError(t, err, "autostart_from and autostart_until must be given together")
+
+	results, err := manager.ListForGroup(ctx, &query.ListOptions{}, "group-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Meta.Count)
+
+	autostart, err := manager.ListAutostart(ctx, "client-1", []string{"group-1", "group-2"})
+	require.NoError(t, err)
+	require.Len(t, autostart, 2)
+	assert.Equal(t, clientTunnel.ID, autostart[0].ID)
+	assert.Equal(t, groupTunnel.ID, autostart[1].ID)
+	assert.Equal(t, "group-1", autostart[1].ClientGroupID)
+
+	autostart, err = manager.ListAutostart(ctx, "client-2", nil)
+	require.NoError(t, err)
+	assert.Empty(t, autostart)
+
+	require.NoError(t, manager.DeleteForGroup(ctx, "group-1", groupTunnel.ID))
+	autostart, err = manager.ListAutostart(ctx, "client-1", []string{"group-1"})
+	require.NoError(t, err)
+	assert.Len(t, autostart, 1)
+
+	_, err = manager.CreateForGroup(ctx, "group-1", &StoredTunnel{RemotePort: &remotePort})
+	require.NoError(t, err)
+	_, err = manager.CreateForGroup(ctx, "group-2", &StoredTunnel{RemotePort: &remotePort})
+	require.NoError(t, err)
+	require.NoError(t, manager.DeleteAllForGroup(ctx, "group-1"))
+	results, err = manager.ListForGroup(ctx, &query.ListOptions{}, "group-1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, results.Meta.Count)
+	results, err = manager.ListForGroup(ctx, &query.ListOptions{}, "group-2")
+	require.NoError(t, err)
+	assert.Equal(t, 1, results.Meta.Count)
+}
+
+func TestStoredTunnelInAutostartWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2023, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	window := func(from, until string) *StoredTunnel {
+		return &StoredTunnel{Autostart: true, AutostartFrom: &from, AutostartUntil: &until}
+	}
+
+	assert.False(t, (&StoredTunnel{}).InAutostartWindow(at(12, 0)))
+	assert.True(t, (&StoredTunnel{Autostart: true}).InAutostartWindow(at(12, 0)))
+	assert.True(t, window("08:00", "18:00").InAutostartWindow(at(8, 0)))
+	assert.False(t, window("08:00", "18:00").InAutostartWindow(at(18, 0)))
+	assert.True(t, window("22:00", "06:00").InAutostartWindow(at(23, 30)))
+	assert.True(t, window("22:00", "06:00").InAutostartWindow(at(5, 59)))
+	assert.False(t, window("22:00", "06:00").InAutostartWindow(at(12, 0)))
+}
+
+func TestStoredTunnelAutostartWindowOpened(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2023, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+	window := func(from, until string) *StoredTunnel {
+		return &StoredTunnel{Autostart: true, AutostartFrom: &from, AutostartUntil: &until}
+	}
+
+	assert.False(t, (&StoredTunnel{Autostart: true}).AutostartWindowOpened(at(7, 59), at(8, 0)), "no window")
+	assert.False(t, (&StoredTunnel{AutostartFrom: strPtr("08:00"), AutostartUntil: strPtr("18:00")}).AutostartWindowOpened(at(7, 59), at(8, 0)), "no autostart")
+	assert.True(t, window("08:00", "18:00").AutostartWindowOpened(at(7, 59), at(8, 0)))
+	assert.False(t, window("08:00", "18:00").AutostartWindowOpened(at(8, 0), at(8, 1)), "opened before the previous run")
+	assert.False(t, window("08:00", "18:00").AutostartWindowOpened(at(7, 58), at(7, 59)))
+	assert.True(t, window("00:00", "06:00").AutostartWindowOpened(at(23, 59).Add(-24*time.Hour), at(0, 0)))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestStoredTunnelToRemote(t *testing.T) {
+	remotePort, publicPort := 80, 4000
+	options := types.JSONString(`{"protocol": "udp", "http_proxy": true, "label": "ui only"}`)
+
+	r, err := (&StoredTunnel{Name: "web", RemotePort: &remotePort, PublicPort: &publicPort, FurtherOptions: &options}).ToRemote()
+	require.NoError(t, err)
+	assert.Equal(t, "web", r.Name)
+	assert.Equal(t, "udp", r.Protocol)
+	assert.True(t, r.HTTPProxy)
+	assert.Equal(t, "127.0.0.1:80", r.Remote())
+	assert.Equal(t, "0.0.0.0:4000", r.Local())
+
+	r, err = (&StoredTunnel{RemotePort: &remotePort}).ToRemote()
+	require.NoError(t, err)
+	assert.False(t, r.IsLocalSpecified())
+}
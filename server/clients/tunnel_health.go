@@ -0,0 +1,83 @@
+package clients
+
+import (
+	"context"
+	"time"
+
+	"github.com/openrport/openrport/plus/capabilities/alerting/transformers"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/share/models"
+)
+
+// checkTunnelHealth probes the target of the tunnel periodically until the tunnel is terminated or re-established.
+func (s *ClientServiceProvider) checkTunnelHealth(ctx context.Context, t *clienttunnel.Tunnel, c *clientdata.Client) {
+	ticker := time.NewTicker(t.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !s.probeTunnelHealth(ctx, t, c) {
+				return
+			}
+		}
+	}
+}
+
+// probeTunnelHealth records the result of a single probe and returns false if the tunnel is gone. Status changes are
+// sent to the alerting service, a tunnel with restart enabled is re-established after consecutive failed probes.
+func (s *ClientServiceProvider) probeTunnelHealth(ctx context.Context, t *clienttunnel.Tunnel, c *clientdata.Client) bool {
+	if s.FindTunnel(c, t.ID) != t {
+		return false
+	}
+
+	err := clienttunnel.ProbeTunnel(ctx, c.GetConnection(), t.Remote, c.Log())
+	if err != nil {
+		c.Log().Debugf("health check of tunnel %s failed: %v", t.ID, err)
+	}
+	changed := t.Health.Record(err, time.Now())
+
+	health := t.Health.Get()
+	restart := t.HealthCheckRestart && health.ConsecutiveFailures >= clienttunnel.HealthCheckRestartFailures
+	if changed || restart {
+		s.sendTunnelHealthToAlerting(c, t, restart)
+	}
+	if !restart {
+		return true
+	}
+
+	s.restartTunnel(c, t, health.Restarts+1)
+	return false
+}
+
+// restartTunnel re-establishes the tunnel with the same remote, a new tunnel id is assigned.
+func (s *ClientServiceProvider) restartTunnel(c *clientdata.Client, t *clienttunnel.Tunnel, restarts int) {
+	clog := c.Log()
+	clog.Infof("Re-establishing tunnel %s after %d failed health checks", t.ID, clienttunnel.HealthCheckRestartFailures)
+
+	remote := t.Remote
+	if err := s.TerminateTunnel(c, t, true); err != nil {
+		clog.Errorf("failed to terminate unhealthy tunnel %s: %v", t.ID, err)
+		return
+	}
+
+	tunnels, err := s.StartClientTunnels(c, []*models.Remote{&remote})
+	if err != nil {
+		clog.Errorf("failed to re-establish tunnel %s: %v", t.ID, err)
+		return
+	}
+	tunnels[0].Health.SetRestarts(restarts)
+	clog.Infof("Re-established tunnel %s as tunnel %s", t.ID, tunnels[0].ID)
+}
+
+func (s *ClientServiceProvider) sendTunnelHealthToAlerting(c *clientdata.Client, t *clienttunnel.Tunnel, restarted bool) {
+	if s.alertingService == nil {
+		return
+	}
+	err := s.alertingService.PutTunnelHealth(transformers.TransformRportTunnelToTunnelHealth(c, t, restarted))
+	if err != nil {
+		s.log().Debugf("Failed to send tunnel health to the alerting service: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertingcap "github.com/openrport/openrport/plus/capabilities/alerting"
+	"github.com/openrport/openrport/plus/capabilities/alerting/entities/tunnelhealth"
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/test"
+)
+
+type mockTunnelHealthAlerting struct {
+	alertingcap.Service
+	updates []*tunnelhealth.TunnelHealth
+}
+
+func (m *mockTunnelHealthAlerting) PutTunnelHealth(th *tunnelhealth.TunnelHealth) error {
+	m.updates = append(m.updates, th)
+	return nil
+}
+
+func TestProbeTunnelHealthRestartsUnhealthyTunnel(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte(`{"Open": false, "ErrMsg": "connection refused"}`)
+
+	c1 := New(t).ID("client-1").Logger(testLog).Build()
+	c1.Connection = connMock
+	c1.Context = context.Background()
+	c1.SetTunnels(nil)
+
+	free, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	freePort := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	allowed := mapset.NewSetFromSlice([]interface{}{freePort})
+	pd := ports.NewPortDistributorForTests(allowed, allowed.Clone(), allowed.Clone())
+	cs := NewClientService(&clienttunnel.InternalTunnelProxyConfig{}, pd, NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+	alerting := &mockTunnelHealthAlerting{}
+	cs.alertingService = alerting
+
+	tunnels, err := cs.StartClientTunnels(c1, []*models.Remote{{
+		Protocol:            models.ProtocolTCP,
+		RemoteHost:          "127.0.0.1",
+		RemotePort:          "8080",
+		HealthCheckInterval: time.Hour,
+		HealthCheckRestart:  true,
+	}})
+	require.NoError(t, err)
+	unhealthy := tunnels[0]
+
+	for i := 1; i < clienttunnel.HealthCheckRestartFailures; i++ {
+		assert.True(t, cs.probeTunnelHealth(context.Background(), unhealthy, c1))
+	}
+	require.Len(t, alerting.updates, 1, "only the status change is sent")
+	assert.Equal(t, clienttunnel.HealthStatusUnhealthy, alerting.updates[0].Status)
+	assert.Equal(t, "connection refused", alerting.updates[0].Error)
+	assert.False(t, alerting.updates[0].Restarted)
+
+	assert.False(t, cs.probeTunnelHealth(context.Background(), unhealthy, c1))
+
+	require.Len(t, alerting.updates, 2)
+	assert.True(t, alerting.updates[1].Restarted)
+	assert.Equal(t, unhealthy.ID, alerting.updates[1].TunnelID)
+
+	assert.Nil(t, cs.FindTunnel(c1, unhealthy.ID))
+	restarted := c1.GetTunnels()[len(c1.GetTunnels())-1]
+	assert.NotEqual(t, unhealthy.ID, restarted.ID)
+	assert.Equal(t, unhealthy.LocalPort, restarted.LocalPort)
+	assert.Equal(t, 1, restarted.Health.Get().Restarts)
+	assert.Equal(t, clienttunnel.HealthStatusUnknown, restarted.Health.Get().Status)
+
+	assert.False(t, cs.probeTunnelHealth(context.Background(), unhealthy, c1), "terminated tunnel is not probed")
+	require.NoError(t, cs.TerminateTunnel(c1, restarted, true))
+}
@@ -0,0 +1,258 @@
+// Package clientupdates stores rport client binaries per version, OS and architecture and rolls out new versions
+// to clients.
+package clientupdates
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	artefactsDir     = "artefacts"
+	artefactMetaFile = "artefact.json"
+)
+
+var (
+	ErrArtefactExists = errors.New("client binary already exists, delete it first to replace it")
+
+	validVersion  = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._+-]*$`)
+	validPlatform = regexp.MustCompile(`^[0-9a-z]+$`)
+)
+
+// Artefact is a client binary of a version for an OS and architecture, named as GOOS and GOARCH.
+type Artefact struct {
+	Version  string `json:"version"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+	// Signature is the ed25519 signature of the binary, verified by clients with their configured public key
+	Signature []byte    `json:"signature"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArtefactStore stores each client binary in <dir>/artefacts/<version>/<os>_<arch> together with its metadata.
+type ArtefactStore struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+func NewArtefactStore(dir string) (*ArtefactStore, error) {
+	dir = filepath.Join(dir, artefactsDir)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client binaries dir: %w", err)
+	}
+
+	return &ArtefactStore{
+		dir: dir,
+	}, nil
+}
+
+// ValidateArtefact checks the version, OS, architecture and signature of a new client binary.
+func ValidateArtefact(version, goos, arch string, signature []byte) error {
+	if !validVersion.MatchString(version) {
+		return fmt.Errorf("invalid version %q", version)
+	}
+	if !validPlatform.MatchString(goos) {
+		return fmt.Errorf("invalid os %q, expected a GOOS value like linux or windows", goos)
+	}
+	if !validPlatform.MatchString(arch) {
+		return fmt.Errorf("invalid arch %q, expected a GOARCH value like amd64 or arm64", arch)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature, expected a base64 encoded ed25519 signature of %d bytes", ed25519.SignatureSize)
+	}
+	return nil
+}
+
+// Put stores the client binary read from r.
+func (s *ArtefactStore) Put(version, goos, arch string, signature []byte, r io.Reader, createdBy string) (*Artefact, error) {
+	err := ValidateArtefact(version, goos, arch, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir := s.platformDir(version, goos, arch)
+	if _, err := os.Stat(dir); err == nil {
+		return nil, ErrArtefactExists
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Artefact{
+		Version:   version,
+		OS:        goos,
+		Arch:      arch,
+		Filename:  "rport",
+		Signature: signature,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if goos == "windows" {
+		a.Filename = "rport.exe"
+	}
+
+	a.Size, a.SHA256, err = writeFile(filepath.Join(dir, a.Filename), r)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(dir, artefactMetaFile), b, 0600)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to save client binary: %w", err)
+	}
+
+	return a, nil
+}
+
+// Get returns the client binary of the given version, OS and architecture, nil if it doesn't exist.
+func (s *ArtefactStore) Get(version, goos, arch string) (*Artefact, error) {
+	if !validVersion.MatchString(version) || !validPlatform.MatchString(goos) || !validPlatform.MatchString(arch) {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.get(s.platformDir(version, goos, arch))
+}
+
+// List returns all client binaries ordered by version, OS and architecture.
+func (s *ArtefactStore) List() ([]*Artefact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dirs, err := filepath.Glob(filepath.Join(s.dir, "*", "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Artefact, 0, len(dirs))
+	for _, dir := range dirs {
+		a, err := s.get(dir)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			result = append(result, a)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Version != result[j].Version {
+			return result[i].Version < result[j].Version
+		}
+		if result[i].OS != result[j].OS {
+			return result[i].OS < result[j].OS
+		}
+		return result[i].Arch < result[j].Arch
+	})
+
+	return result, nil
+}
+
+// HasVersion returns true if at least one client binary of the given version exists.
+func (s *ArtefactStore) HasVersion(version string) (bool, error) {
+	if !validVersion.MatchString(version) {
+		return false, nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dirs, err := filepath.Glob(filepath.Join(s.dir, version, "*"))
+	return len(dirs) > 0, err
+}
+
+// Delete deletes the client binary of the given version, OS and architecture.
+func (s *ArtefactStore) Delete(version, goos, arch string) error {
+	a, err := s.Get(version, goos, arch)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return os.ErrNotExist
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = os.RemoveAll(s.platformDir(version, goos, arch))
+	if err != nil {
+		return err
+	}
+
+	// remove the version dir, if it's empty now
+	_ = os.Remove(filepath.Join(s.dir, version))
+
+	return nil
+}
+
+// Path returns the path of the binary of the given artefact.
+func (s *ArtefactStore) Path(a *Artefact) string {
+	return filepath.Join(s.platformDir(a.Version, a.OS, a.Arch), a.Filename)
+}
+
+func (s *ArtefactStore) get(dir string) (*Artefact, error) {
+	b, err := os.ReadFile(filepath.Join(dir, artefactMetaFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	a := &Artefact{}
+	err = json.Unmarshal(b, a)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client binary metadata in %s: %w", dir, err)
+	}
+
+	return a, nil
+}
+
+func (s *ArtefactStore) platformDir(version, goos, arch string) string {
+	return filepath.Join(s.dir, version, goos+"_"+arch)
+}
+
+func writeFile(path string, r io.Reader) (int64, string, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to save client binary: %w", err)
+	}
+
+	return size, hex.EncodeToString(hash.Sum(nil)), f.Close()
+}
@@ -0,0 +1,249 @@
+package clientupdates
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+)
+
+var (
+	testLog       = logger.NewLogger("client-updates", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	testSignature = make([]byte, ed25519.SignatureSize)
+)
+
+type fakeClients struct {
+	mu      sync.Mutex
+	clients map[string]*clientdata.Client
+}
+
+func newFakeClients(clients ...*clientdata.Client) *fakeClients {
+	f := &fakeClients{
+		clients: make(map[string]*clientdata.Client),
+	}
+	for _, c := range clients {
+		f.clients[c.ID] = c
+	}
+	return f
+}
+
+func (f *fakeClients) GetByID(id string) (*clientdata.Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.clients[id], nil
+}
+
+// reconnect replaces the client by a client running the given version
+func (f *fakeClients) reconnect(id, version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.clients[id]
+	f.clients[id] = newClient(c.ID, version, c.OSKernel, c.OSArch)
+}
+
+func newClient(id, version, goos, arch string) *clientdata.Client {
+	return &clientdata.Client{
+		ID:       id,
+		Version:  version,
+		OSKernel: goos,
+		OSArch:   arch,
+	}
+}
+
+func TestArtefactStore(t *testing.T) {
+	s, err := NewArtefactStore(t.TempDir())
+	require.NoError(t, err)
+
+	a, err := s.Put("0.9.2", "linux", "amd64", testSignature, strings.NewReader("linux binary"), "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "rport", a.Filename)
+	assert.Equal(t, int64(12), a.Size)
+	assert.Len(t, a.SHA256, 64)
+	b, err := os.ReadFile(s.Path(a))
+	require.NoError(t, err)
+	assert.Equal(t, "linux binary", string(b))
+
+	_, err = s.Put("0.9.2", "linux", "amd64", testSignature, strings.NewReader("other binary"), "admin")
+	assert.ErrorIs(t, err, ErrArtefactExists)
+
+	w, err := s.Put("0.9.2", "windows", "amd64", testSignature, strings.NewReader("windows binary"), "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "rport.exe", w.Filename)
+
+	_, err = s.Put("../0.9.2", "linux", "amd64", testSignature, strings.NewReader(""), "admin")
+	assert.EqualError(t, err, `invalid version "../0.9.2"`)
+	_, err = s.Put("0.9.2", "linux", "amd64", []byte("short"), strings.NewReader(""), "admin")
+	assert.EqualError(t, err, "invalid signature, expected a base64 encoded ed25519 signature of 64 bytes")
+
+	all, err := s.List()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "linux", all[0].OS)
+	assert.Equal(t, "windows", all[1].OS)
+
+	got, err := s.Get("0.9.2", "linux", "arm64")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	require.NoError(t, s.Delete("0.9.2", "linux", "amd64"))
+	assert.ErrorIs(t, s.Delete("0.9.2", "linux", "amd64"), os.ErrNotExist)
+	ok, err := s.HasVersion("0.9.2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	require.NoError(t, s.Delete("0.9.2", "windows", "amd64"))
+	ok, err = s.HasVersion("0.9.2")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func newTestRolloutManager(t *testing.T, clients *fakeClients) (*RolloutManager, *ArtefactStore) {
+	dir := t.TempDir()
+	artefacts, err := NewArtefactStore(dir)
+	require.NoError(t, err)
+	_, err = artefacts.Put("0.9.2", "linux", "amd64", testSignature, strings.NewReader("linux binary"), "admin")
+	require.NoError(t, err)
+
+	m, err := NewRolloutManager(testLog, dir, artefacts, clients)
+	require.NoError(t, err)
+	m.pollInterval = 10 * time.Millisecond
+
+	return m, artefacts
+}
+
+func waitForRollout(t *testing.T, m *RolloutManager, id string) *Rollout {
+	var r *Rollout
+	require.Eventually(t, func() bool {
+		var err error
+		r, err = m.Get(id)
+		require.NoError(t, err)
+		return r.Status != RolloutStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+	return r
+}
+
+func TestRolloutSucceeds(t *testing.T) {
+	clients := newFakeClients(
+		newClient("c1", "0.9.1", "linux", "amd64"),
+		newClient("c2", "0.9.2", "linux", "amd64"),
+		newClient("c3", "0.9.1", "linux", "amd64"),
+	)
+	m, artefacts := newTestRolloutManager(t, clients)
+
+	var mu sync.Mutex
+	var sent []string
+	m.send = func(client *clientdata.Client, req *comm.ClientUpdateRequest) error {
+		mu.Lock()
+		sent = append(sent, client.ID)
+		mu.Unlock()
+
+		a, err := artefacts.Get("0.9.2", "linux", "amd64")
+		require.NoError(t, err)
+		assert.Equal(t, artefacts.Path(a), req.SourceFilePath)
+		assert.Equal(t, a.SHA256, req.SHA256)
+		assert.Equal(t, 5*time.Minute, req.RollbackTimeout)
+
+		clients.reconnect(client.ID, req.Version)
+		return nil
+	}
+
+	r, err := m.Start("0.9.2", []string{"c1", "c2", "c3"}, 34, 300, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, RolloutStatusRunning, r.Status)
+	assert.True(t, r.Clients[0].Canary)
+	assert.True(t, r.Clients[1].Canary)
+	assert.False(t, r.Clients[2].Canary)
+
+	r = waitForRollout(t, m, r.ID)
+	assert.Equal(t, RolloutStatusSucceeded, r.Status)
+	for _, rc := range r.Clients {
+		assert.Equal(t, ClientStatusUpdated, rc.Status, rc.ClientID)
+	}
+	assert.Equal(t, "0.9.1", r.Clients[0].PreviousVersion)
+	// c2 already runs the version
+	assert.ElementsMatch(t, []string{"c1", "c3"}, sent)
+	assert.False(t, m.IsRunning("0.9.2"))
+}
+
+func TestRolloutCanaryFails(t *testing.T) {
+	clients := newFakeClients(
+		newClient("c1", "0.9.1", "linux", "amd64"),
+		newClient("c2", "0.9.1", "linux", "amd64"),
+		newClient("c3", "0.9.1", "linux", "amd64"),
+		newClient("c4", "0.9.1", "linux", "amd64"),
+	)
+	m, _ := newTestRolloutManager(t, clients)
+	m.send = func(client *clientdata.Client, req *comm.ClientUpdateRequest) error {
+		return errors.New("client error: client update is disabled on this client")
+	}
+
+	r, err := m.Start("0.9.2", []string{"c1", "c2", "c3", "c4"}, 25, 300, "admin")
+	require.NoError(t, err)
+
+	_, err = m.Start("0.9.2", []string{"c4"}, 0, 300, "admin")
+	assert.ErrorIs(t, err, ErrClientInRollout)
+
+	r = waitForRollout(t, m, r.ID)
+	assert.Equal(t, RolloutStatusFailed, r.Status)
+	assert.Equal(t, ClientStatusFailed, r.Clients[0].Status)
+	assert.Equal(t, "client error: client update is disabled on this client", r.Clients[0].Error)
+	for _, rc := range r.Clients[1:] {
+		assert.Equal(t, ClientStatusSkipped, rc.Status)
+	}
+}
+
+func TestRolloutClientNotReconnected(t *testing.T) {
+	clients := newFakeClients(
+		newClient("c1", "0.9.1", "linux", "amd64"),
+		newClient("c2", "0.9.1", "linux", "arm64"),
+	)
+	m, _ := newTestRolloutManager(t, clients)
+	m.send = func(client *clientdata.Client, req *comm.ClientUpdateRequest) error {
+		return nil
+	}
+
+	r, err := m.Start("0.9.2", []string{"c1", "c2"}, 0, 1, "admin")
+	require.NoError(t, err)
+
+	r = waitForRollout(t, m, r.ID)
+	assert.Equal(t, RolloutStatusFailed, r.Status)
+	assert.Equal(t, ClientStatusFailed, r.Clients[0].Status)
+	assert.Equal(t, "client did not reconnect with version 0.9.2 within 1s, it rolls back to version 0.9.1", r.Clients[0].Error)
+	assert.Equal(t, ClientStatusFailed, r.Clients[1].Status)
+	assert.Equal(t, "no client binary of version 0.9.2 for linux/arm64", r.Clients[1].Error)
+
+	_, err = m.Start("0.9.3", []string{"c1"}, 0, 1, "admin")
+	assert.ErrorIs(t, err, ErrNoArtefacts)
+}
+
+func TestRolloutInterruptedByRestart(t *testing.T) {
+	clients := newFakeClients(newClient("c1", "0.9.1", "linux", "amd64"))
+	m, artefacts := newTestRolloutManager(t, clients)
+	r := &Rollout{
+		ID:      "f7d4e0f5-4c52-4d0e-a1f4-3f0e5b6c7d8e",
+		Version: "0.9.2",
+		Status:  RolloutStatusRunning,
+		Clients: []*RolloutClient{{ClientID: "c1", Status: ClientStatusUpdating}},
+	}
+	require.NoError(t, m.save(r))
+
+	m, err := NewRolloutManager(testLog, strings.TrimSuffix(m.dir, rolloutsDir), artefacts, clients)
+	require.NoError(t, err)
+
+	r, err = m.Get(r.ID)
+	require.NoError(t, err)
+	assert.Equal(t, RolloutStatusFailed, r.Status)
+	assert.NotNil(t, r.FinishedAt)
+	assert.Equal(t, ClientStatusFailed, r.Clients[0].Status)
+	assert.Equal(t, "rollout was interrupted by a server restart", r.Clients[0].Error)
+}
@@ -0,0 +1,462 @@
+package clientupdates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/share/comm"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/random"
+)
+
+const (
+	rolloutsDir = "rollouts"
+
+	RolloutStatusRunning   = "running"
+	RolloutStatusSucceeded = "succeeded"
+	RolloutStatusFailed    = "failed"
+
+	ClientStatusPending  = "pending"
+	ClientStatusUpdating = "updating"
+	ClientStatusUpdated  = "updated"
+	ClientStatusFailed   = "failed"
+	ClientStatusSkipped  = "skipped"
+
+	// maxParallelUpdates limits the clients downloading a new version at the same time
+	maxParallelUpdates = 20
+	pollInterval       = 5 * time.Second
+)
+
+var (
+	ErrClientInRollout = errors.New("client is already part of a running rollout")
+	ErrNoArtefacts     = errors.New("no client binaries found")
+
+	validID = regexp.MustCompile(`^[0-9a-f-]+$`)
+)
+
+// Rollout updates clients to a version. The canary clients are updated first, the remaining clients only if all
+// canary clients reconnected with the new version within the reconnect timeout.
+type Rollout struct {
+	ID                  string           `json:"id"`
+	Version             string           `json:"version"`
+	CanaryPercent       int              `json:"canary_percent"`
+	ReconnectTimeoutSec int              `json:"reconnect_timeout_sec"`
+	Status              string           `json:"status"`
+	CreatedBy           string           `json:"created_by"`
+	CreatedAt           time.Time        `json:"created_at"`
+	FinishedAt          *time.Time       `json:"finished_at"`
+	Clients             []*RolloutClient `json:"clients"`
+}
+
+type RolloutClient struct {
+	ClientID        string     `json:"client_id"`
+	Canary          bool       `json:"canary"`
+	Status          string     `json:"status"`
+	PreviousVersion string     `json:"previous_version"`
+	Error           string     `json:"error,omitempty"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+
+func (r *Rollout) reconnectTimeout() time.Duration {
+	return time.Duration(r.ReconnectTimeoutSec) * time.Second
+}
+
+type ClientProvider interface {
+	GetByID(id string) (*clientdata.Client, error)
+}
+
+// RolloutManager runs rollouts and stores them in <dir>/rollouts/<id>.json.
+type RolloutManager struct {
+	logger    *logger.Logger
+	dir       string
+	artefacts *ArtefactStore
+	clients   ClientProvider
+
+	// send and pollInterval are replaced in tests
+	send         func(client *clientdata.Client, req *comm.ClientUpdateRequest) error
+	pollInterval time.Duration
+
+	mu      sync.Mutex
+	running map[string]*Rollout
+}
+
+func NewRolloutManager(l *logger.Logger, dir string, artefacts *ArtefactStore, clients ClientProvider) (*RolloutManager, error) {
+	dir = filepath.Join(dir, rolloutsDir)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rollouts dir: %w", err)
+	}
+
+	m := &RolloutManager{
+		logger:       l,
+		dir:          dir,
+		artefacts:    artefacts,
+		clients:      clients,
+		pollInterval: pollInterval,
+		running:      make(map[string]*Rollout),
+	}
+	m.send = func(client *clientdata.Client, req *comm.ClientUpdateRequest) error {
+		return comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeClientUpdate, req, nil, m.logger)
+	}
+
+	err = m.failInterrupted()
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Start creates a rollout of the version to the given clients and runs it in the background.
+func (m *RolloutManager) Start(version string, clientIDs []string, canaryPercent, reconnectTimeoutSec int, createdBy string) (*Rollout, error) {
+	ok, err := m.artefacts.HasVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w of version %q", ErrNoArtefacts, version)
+	}
+
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Rollout{
+		ID:                  id,
+		Version:             version,
+		CanaryPercent:       canaryPercent,
+		ReconnectTimeoutSec: reconnectTimeoutSec,
+		Status:              RolloutStatusRunning,
+		CreatedBy:           createdBy,
+		CreatedAt:           time.Now(),
+	}
+	canaries := canaryCount(len(clientIDs), canaryPercent)
+	for i, clientID := range clientIDs {
+		r.Clients = append(r.Clients, &RolloutClient{
+			ClientID: clientID,
+			Canary:   i < canaries,
+			Status:   ClientStatusPending,
+		})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, running := range m.running {
+		for _, rc := range running.Clients {
+			for _, clientID := range clientIDs {
+				if rc.ClientID == clientID {
+					return nil, fmt.Errorf("%w: %s", ErrClientInRollout, clientID)
+				}
+			}
+		}
+	}
+
+	err = m.save(r)
+	if err != nil {
+		return nil, err
+	}
+	m.running[r.ID] = r
+
+	go m.run(r)
+
+	return m.copy(r)
+}
+
+// Get returns the rollout with the given id, nil if it doesn't exist.
+func (m *RolloutManager) Get(id string) (*Rollout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.load(id)
+}
+
+// List returns all rollouts, the latest first.
+func (m *RolloutManager) List() ([]*Rollout, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Rollout, 0, len(entries))
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), ".json")
+		if id == e.Name() {
+			continue
+		}
+		r, err := m.load(id)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// IsRunning returns true if a rollout of the given version is running.
+func (m *RolloutManager) IsRunning(version string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.running {
+		if r.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *RolloutManager) run(r *Rollout) {
+	m.logger.Infof("Rollout %s of version %s to %d clients started", r.ID, r.Version, len(r.Clients))
+
+	var canaries, others []*RolloutClient
+	for _, rc := range r.Clients {
+		if rc.Canary {
+			canaries = append(canaries, rc)
+		} else {
+			others = append(others, rc)
+		}
+	}
+
+	status := RolloutStatusSucceeded
+	if !m.runWave(r, canaries) {
+		for _, rc := range others {
+			m.setClientStatus(r, rc, ClientStatusSkipped, "canary clients failed to update")
+		}
+		status = RolloutStatusFailed
+	} else if !m.runWave(r, others) {
+		status = RolloutStatusFailed
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	r.Status = status
+	r.FinishedAt = &now
+	delete(m.running, r.ID)
+	if err := m.save(r); err != nil {
+		m.logger.Errorf("Failed to save rollout %s: %v", r.ID, err)
+	}
+
+	m.logger.Infof("Rollout %s of version %s %s", r.ID, r.Version, status)
+}
+
+// runWave updates the given clients and returns true if all of them were updated.
+func (m *RolloutManager) runWave(r *Rollout, clients []*RolloutClient) bool {
+	sem := make(chan struct{}, maxParallelUpdates)
+	wg := sync.WaitGroup{}
+	for _, rc := range clients {
+		wg.Add(1)
+		go func(rc *RolloutClient) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			m.updateClient(r, rc)
+		}(rc)
+	}
+	wg.Wait()
+
+	for _, rc := range clients {
+		if rc.Status != ClientStatusUpdated {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *RolloutManager) updateClient(r *Rollout, rc *RolloutClient) {
+	client, err := m.clients.GetByID(rc.ClientID)
+	if err != nil {
+		m.setClientStatus(r, rc, ClientStatusFailed, err.Error())
+		return
+	}
+	if client == nil {
+		m.setClientStatus(r, rc, ClientStatusFailed, "client not found")
+		return
+	}
+	if !client.IsConnected() {
+		m.setClientStatus(r, rc, ClientStatusFailed, "client is not connected")
+		return
+	}
+
+	m.mu.Lock()
+	rc.PreviousVersion = client.GetVersion()
+	m.mu.Unlock()
+	if client.GetVersion() == r.Version {
+		m.setClientStatus(r, rc, ClientStatusUpdated, "")
+		return
+	}
+
+	a, err := m.artefacts.Get(r.Version, client.GetOSKernel(), client.GetOSArch())
+	if err != nil {
+		m.setClientStatus(r, rc, ClientStatusFailed, err.Error())
+		return
+	}
+	if a == nil {
+		m.setClientStatus(r, rc, ClientStatusFailed, fmt.Sprintf("no client binary of version %s for %s/%s", r.Version, client.GetOSKernel(), client.GetOSArch()))
+		return
+	}
+
+	m.setClientStatus(r, rc, ClientStatusUpdating, "")
+	err = m.send(client, &comm.ClientUpdateRequest{
+		RolloutID:       r.ID,
+		Version:         a.Version,
+		SourceFilePath:  m.artefacts.Path(a),
+		Size:            a.Size,
+		SHA256:          a.SHA256,
+		Signature:       a.Signature,
+		RollbackTimeout: r.reconnectTimeout(),
+	})
+	if err != nil {
+		m.setClientStatus(r, rc, ClientStatusFailed, err.Error())
+		return
+	}
+
+	deadline := time.Now().Add(r.reconnectTimeout())
+	for time.Now().Before(deadline) {
+		time.Sleep(m.pollInterval)
+
+		client, err = m.clients.GetByID(rc.ClientID)
+		if err != nil {
+			m.logger.Errorf("Failed to get client %s: %v", rc.ClientID, err)
+			continue
+		}
+		if client != nil && client.IsConnected() && client.GetVersion() == r.Version {
+			m.setClientStatus(r, rc, ClientStatusUpdated, "")
+			return
+		}
+	}
+
+	m.setClientStatus(r, rc, ClientStatusFailed, fmt.Sprintf("client did not reconnect with version %s within %s, it rolls back to version %s", r.Version, r.reconnectTimeout(), rc.PreviousVersion))
+}
+
+func (m *RolloutManager) setClientStatus(r *Rollout, rc *RolloutClient, status, msg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	rc.Status = status
+	rc.Error = msg
+	rc.UpdatedAt = &now
+
+	if status == ClientStatusFailed {
+		m.logger.Errorf("Rollout %s: failed to update client %s: %s", r.ID, rc.ClientID, msg)
+	}
+
+	if err := m.save(r); err != nil {
+		m.logger.Errorf("Failed to save rollout %s: %v", r.ID, err)
+	}
+}
+
+// failInterrupted marks rollouts as failed, which were running when the server stopped.
+func (m *RolloutManager) failInterrupted() error {
+	rollouts, err := m.List()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rollouts {
+		if r.Status != RolloutStatusRunning {
+			continue
+		}
+		now := time.Now()
+		r.Status = RolloutStatusFailed
+		r.FinishedAt = &now
+		for _, rc := range r.Clients {
+			if rc.Status == ClientStatusPending || rc.Status == ClientStatusUpdating {
+				rc.Status = ClientStatusFailed
+				rc.Error = "rollout was interrupted by a server restart"
+				rc.UpdatedAt = &now
+			}
+		}
+		err = m.save(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copy returns a copy of the rollout, so it can be used while the rollout is running, m.mu must be locked.
+func (m *RolloutManager) copy(r *Rollout) (*Rollout, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Rollout{}
+	return result, json.Unmarshal(b, result)
+}
+
+func (m *RolloutManager) load(id string) (*Rollout, error) {
+	if !validID.MatchString(id) {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(m.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	r := &Rollout{}
+	err = json.Unmarshal(b, r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rollout %s: %w", id, err)
+	}
+
+	return r, nil
+}
+
+// save stores the rollout, m.mu must be locked.
+func (m *RolloutManager) save(r *Rollout) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp := m.path(r.ID) + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save rollout: %w", err)
+	}
+
+	return os.Rename(tmp, m.path(r.ID))
+}
+
+func (m *RolloutManager) path(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+// canaryCount returns the number of canary clients, at least one if canaryPercent is greater than 0.
+func canaryCount(clients, canaryPercent int) int {
+	if canaryPercent <= 0 {
+		return 0
+	}
+	return (clients*canaryPercent + 99) / 100
+}
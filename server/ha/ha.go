@@ -0,0 +1,223 @@
+// Package ha elects a single active server among several rportd sharing the same database.
+// The active server holds a lease row that it renews periodically, passive servers take it over once it expires.
+package ha
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/server/scheduler"
+	"github.com/openrport/openrport/share/logger"
+)
+
+const leaseName = "rportd"
+
+type Role string
+
+const (
+	RoleActive  Role = "active"
+	RolePassive Role = "passive"
+)
+
+type Lease struct {
+	NodeID    string    `db:"node_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type LeaseProvider interface {
+	Acquire(ctx context.Context, name, nodeID string, now, expiresAt time.Time) (*Lease, error)
+	Release(ctx context.Context, name, nodeID string) error
+}
+
+type Status struct {
+	Role           Role       `json:"role"`
+	NodeID         string     `json:"node_id"`
+	ActiveNodeID   string     `json:"active_node_id"`
+	LeaseExpiresAt *time.Time `json:"lease_expires_at"`
+}
+
+// Elector decides whether this server is active. A nil Elector is always active, so callers don't need to
+// distinguish whether high availability is enabled.
+type Elector struct {
+	*logger.Logger
+	provider      LeaseProvider
+	nodeID        string
+	leaseDuration time.Duration
+	now           func() time.Time
+
+	mu           sync.RWMutex
+	active       bool
+	lease        *Lease
+	heldUntil    time.Time
+	onActivate   []func(ctx context.Context)
+	onDeactivate []func()
+}
+
+func NewElector(provider LeaseProvider, nodeID string, leaseDuration time.Duration, logger *logger.Logger) *Elector {
+	return &Elector{
+		Logger:        logger,
+		provider:      provider,
+		nodeID:        nodeID,
+		leaseDuration: leaseDuration,
+		now:           time.Now,
+	}
+}
+
+// OnActivate registers fn to be called when this server becomes active, before it's reported as active.
+func (e *Elector) OnActivate(fn func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onActivate = append(e.onActivate, fn)
+}
+
+// OnDeactivate registers fn to be called when this server loses the lease, after it's reported as passive.
+func (e *Elector) OnDeactivate(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onDeactivate = append(e.onDeactivate, fn)
+}
+
+func (e *Elector) IsActive() bool {
+	if e == nil {
+		return true
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.active
+}
+
+func (e *Elector) Status() Status {
+	if e == nil {
+		return Status{Role: RoleActive}
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	status := Status{
+		Role:   RolePassive,
+		NodeID: e.nodeID,
+	}
+	if e.active {
+		status.Role = RoleActive
+	}
+	if e.lease != nil {
+		status.ActiveNodeID = e.lease.NodeID
+		status.LeaseExpiresAt = &e.lease.ExpiresAt
+	}
+	return status
+}
+
+// Run tries to acquire or renew the lease every third of the lease duration until ctx is canceled.
+// On cancellation the lease is released so a passive server can take over immediately.
+func (e *Elector) Run(ctx context.Context) {
+	e.Infof("high availability enabled, node %q, lease duration %v", e.nodeID, e.leaseDuration)
+
+	e.elect(ctx)
+	tick := time.NewTicker(e.leaseDuration / 3)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			e.elect(ctx)
+		case <-ctx.Done():
+			e.release()
+			return
+		}
+	}
+}
+
+func (e *Elector) elect(ctx context.Context) {
+	now := e.now()
+	expiresAt := now.Add(e.leaseDuration)
+
+	lease, err := e.provider.Acquire(ctx, leaseName, e.nodeID, now, expiresAt)
+	if err != nil {
+		e.Errorf("failed to acquire lease: %v", err)
+		// without access to the lease another node may take over once it expires
+		if e.IsActive() && !now.Before(e.getHeldUntil()) {
+			e.deactivate()
+		}
+		return
+	}
+
+	e.mu.Lock()
+	e.lease = lease
+	if lease.NodeID == e.nodeID {
+		e.heldUntil = expiresAt
+	}
+	e.mu.Unlock()
+
+	switch {
+	case lease.NodeID == e.nodeID && !e.IsActive():
+		e.activate(ctx)
+	case lease.NodeID != e.nodeID && e.IsActive():
+		e.deactivate()
+	}
+}
+
+func (e *Elector) activate(ctx context.Context) {
+	e.Infof("acquired lease, becoming active")
+
+	e.mu.RLock()
+	callbacks := e.onActivate
+	e.mu.RUnlock()
+	for _, fn := range callbacks {
+		fn(ctx)
+	}
+
+	e.mu.Lock()
+	e.active = true
+	e.mu.Unlock()
+}
+
+func (e *Elector) deactivate() {
+	e.Infof("lost lease, becoming passive")
+
+	e.mu.Lock()
+	e.active = false
+	callbacks := e.onDeactivate
+	e.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn()
+	}
+}
+
+func (e *Elector) release() {
+	if !e.IsActive() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.leaseDuration)
+	defer cancel()
+	if err := e.provider.Release(ctx, leaseName, e.nodeID); err != nil {
+		e.Errorf("%v", err)
+		return
+	}
+	e.Infof("released lease")
+}
+
+func (e *Elector) getHeldUntil() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.heldUntil
+}
+
+// OnlyActive wraps task so it's skipped while this server is passive.
+func (e *Elector) OnlyActive(task scheduler.Task) scheduler.Task {
+	return &onlyActiveTask{elector: e, task: task}
+}
+
+type onlyActiveTask struct {
+	elector *Elector
+	task    scheduler.Task
+}
+
+func (t *onlyActiveTask) Run(ctx context.Context) error {
+	if !t.elector.IsActive() {
+		return nil
+	}
+	return t.task.Run(ctx)
+}
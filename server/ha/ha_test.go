@@ -0,0 +1,143 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+var testLog = logger.NewLogger("ha", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type leaseProviderMock struct {
+	nodeID string
+	err    error
+}
+
+func (p *leaseProviderMock) Acquire(ctx context.Context, name, nodeID string, now, expiresAt time.Time) (*Lease, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.nodeID == "" {
+		p.nodeID = nodeID
+	}
+	return &Lease{NodeID: p.nodeID, ExpiresAt: expiresAt}, nil
+}
+
+func (p *leaseProviderMock) Release(ctx context.Context, name, nodeID string) error {
+	if p.nodeID == nodeID {
+		p.nodeID = ""
+	}
+	return nil
+}
+
+type taskMock struct {
+	runs int
+}
+
+func (t *taskMock) Run(ctx context.Context) error {
+	t.runs++
+	return nil
+}
+
+func TestNilElectorIsActive(t *testing.T) {
+	var e *Elector
+
+	assert.True(t, e.IsActive())
+	assert.Equal(t, Status{Role: RoleActive}, e.Status())
+
+	task := &taskMock{}
+	assert.NoError(t, e.OnlyActive(task).Run(context.Background()))
+	assert.Equal(t, 1, task.runs)
+}
+
+func TestElect(t *testing.T) {
+	ctx := context.Background()
+	p := &leaseProviderMock{nodeID: "node-2"}
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := NewElector(p, "node-1", 15*time.Second, testLog)
+	e.now = func() time.Time { return now }
+
+	activated, deactivated := 0, 0
+	e.OnActivate(func(context.Context) {
+		// callbacks run before the server is reported as active
+		assert.False(t, e.IsActive())
+		activated++
+	})
+	e.OnDeactivate(func() {
+		deactivated++
+	})
+	task := &taskMock{}
+	onlyActiveTask := e.OnlyActive(task)
+
+	// lease held by another node
+	e.elect(ctx)
+	assert.False(t, e.IsActive())
+	assert.Equal(t, RolePassive, e.Status().Role)
+	assert.Equal(t, "node-2", e.Status().ActiveNodeID)
+	assert.NoError(t, onlyActiveTask.Run(ctx))
+	assert.Equal(t, 0, task.runs)
+
+	// lease taken over
+	p.nodeID = ""
+	e.elect(ctx)
+	assert.True(t, e.IsActive())
+	assert.Equal(t, Status{
+		Role:           RoleActive,
+		NodeID:         "node-1",
+		ActiveNodeID:   "node-1",
+		LeaseExpiresAt: func() *time.Time { t := now.Add(15 * time.Second); return &t }(),
+	}, e.Status())
+	assert.NoError(t, onlyActiveTask.Run(ctx))
+	assert.Equal(t, 1, task.runs)
+
+	// renewed
+	e.elect(ctx)
+	assert.True(t, e.IsActive())
+	assert.Equal(t, 1, activated)
+
+	// renewal failing before the lease expires
+	p.err = errors.New("db gone")
+	now = now.Add(5 * time.Second)
+	e.elect(ctx)
+	assert.True(t, e.IsActive())
+
+	// renewal failing after the lease expired
+	now = now.Add(20 * time.Second)
+	e.elect(ctx)
+	assert.False(t, e.IsActive())
+	assert.Equal(t, 1, deactivated)
+
+	// lease taken by another node
+	p.err = nil
+	p.nodeID = ""
+	e.elect(ctx)
+	assert.True(t, e.IsActive())
+	p.nodeID = "node-2"
+	e.elect(ctx)
+	assert.False(t, e.IsActive())
+	assert.Equal(t, 2, activated)
+	assert.Equal(t, 2, deactivated)
+}
+
+func TestRunReleasesLease(t *testing.T) {
+	p := &leaseProviderMock{}
+	e := NewElector(p, "node-1", 3*time.Second, testLog)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		e.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, e.IsActive, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	assert.Equal(t, "", p.nodeID)
+}
@@ -0,0 +1,67 @@
+package ha
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/openrport/openrport/db/backend"
+)
+
+// Store has no sqlite migrations, high availability requires all stores on a shared database.
+var Store = backend.Store{
+	Name: "ha",
+}
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+// NewProvider returns a provider using an already migrated DB.
+func NewProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{db: db}
+}
+
+// Acquire takes the lease with the given name for nodeID until expiresAt if it's free, expired or already held by
+// nodeID. It returns the lease as stored after the attempt, so a different node ID means another node holds it.
+func (p *SqliteProvider) Acquire(ctx context.Context, name, nodeID string, now, expiresAt time.Time) (*Lease, error) {
+	q := "INSERT OR IGNORE INTO ha_leases (name, node_id, expires_at) VALUES (?, ?, ?)"
+	switch p.db.DriverName() {
+	case backend.DriverMySQL:
+		q = "INSERT IGNORE INTO ha_leases (name, node_id, expires_at) VALUES (?, ?, ?)"
+	case backend.DriverPostgres:
+		q = "INSERT INTO ha_leases (name, node_id, expires_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING"
+	}
+	_, err := p.db.ExecContext(ctx, q, name, nodeID, expiresAt.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lease %q: %w", name, err)
+	}
+
+	_, err = p.db.ExecContext(ctx,
+		"UPDATE ha_leases SET node_id = ?, expires_at = ? WHERE name = ? AND (node_id = ? OR "+
+			backend.DateTime(p.db.DriverName(), "expires_at")+" < "+backend.DateTime(p.db.DriverName(), "?")+")",
+		nodeID, expiresAt.UTC(), name, nodeID, now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update lease %q: %w", name, err)
+	}
+
+	lease := &Lease{}
+	err = p.db.GetContext(ctx, lease, "SELECT node_id, expires_at FROM ha_leases WHERE name = ?", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease %q: %w", name, err)
+	}
+
+	return lease, nil
+}
+
+// Release gives up the lease if it's held by nodeID, so another node can take over without waiting for it to expire.
+func (p *SqliteProvider) Release(ctx context.Context, name, nodeID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM ha_leases WHERE name = ? AND node_id = ?", name, nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to release lease %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package ha
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newInmemoryDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE ha_leases (
+		name TEXT NOT NULL PRIMARY KEY,
+		node_id TEXT NOT NULL,
+		expires_at DATETIME NOT NULL
+	)`)
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	ctx := context.Background()
+	p := NewProvider(newInmemoryDB(t))
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// free lease
+	lease, err := p.Acquire(ctx, "rportd", "node-1", now, now.Add(15*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", lease.NodeID)
+	assert.True(t, now.Add(15*time.Second).Equal(lease.ExpiresAt))
+
+	// held by another node
+	lease, err = p.Acquire(ctx, "rportd", "node-2", now.Add(5*time.Second), now.Add(20*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", lease.NodeID)
+
+	// renewed by holder
+	lease, err = p.Acquire(ctx, "rportd", "node-1", now.Add(5*time.Second), now.Add(20*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", lease.NodeID)
+	assert.True(t, now.Add(20*time.Second).Equal(lease.ExpiresAt))
+
+	// expired
+	lease, err = p.Acquire(ctx, "rportd", "node-2", now.Add(21*time.Second), now.Add(36*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-2", lease.NodeID)
+
+	// release by other node is ignored
+	require.NoError(t, p.Release(ctx, "rportd", "node-1"))
+	lease, err = p.Acquire(ctx, "rportd", "node-1", now.Add(22*time.Second), now.Add(37*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-2", lease.NodeID)
+
+	// released
+	require.NoError(t, p.Release(ctx, "rportd", "node-2"))
+	lease, err = p.Acquire(ctx, "rportd", "node-1", now.Add(23*time.Second), now.Add(38*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", lease.NodeID)
+}
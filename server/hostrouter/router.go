@@ -0,0 +1,248 @@
+// Package hostrouter is the builtin alternative to the caddy integration. It routes https requests for subdomain
+// tunnels by their host to the internal tunnel proxies, all on one shared listener of rportd.
+package hostrouter
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/server/acme"
+	"github.com/openrport/openrport/server/caddy"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/security"
+)
+
+const readHeaderTimeout = 10 * time.Second
+
+// Router implements caddy.API, so subdomain tunnels are started and removed in the same way as with caddy.
+type Router struct {
+	cfg    *caddy.Config
+	logger *logger.Logger
+	acme   *acme.Acme
+
+	// apiProxy serves the API on api_hostname, nil if not configured
+	apiProxy http.Handler
+
+	mu     sync.RWMutex
+	routes map[string]*route // by route id, that is the subdomain
+	hosts  map[string]*route
+
+	ctx      context.Context
+	server   *http.Server
+	listener net.Listener
+	errCh    chan error
+}
+
+type route struct {
+	id    string
+	host  string
+	proxy http.Handler
+}
+
+var _ caddy.API = &Router{}
+
+// New returns a router for the given caddy config, targetAPIPort is the port of the API on localhost.
+// acme is only used if enabled in the config.
+func New(cfg *caddy.Config, targetAPIPort string, acme *acme.Acme, l *logger.Logger) *Router {
+	r := &Router{
+		cfg:    cfg,
+		logger: l,
+		acme:   acme,
+		routes: make(map[string]*route),
+		hosts:  make(map[string]*route),
+		errCh:  make(chan error, 1),
+	}
+
+	if cfg.APIReverseProxyEnabled() {
+		r.apiProxy = httputil.NewSingleHostReverseProxy(&url.URL{
+			Scheme: "http",
+			Host:   net.JoinHostPort("127.0.0.1", targetAPIPort),
+		})
+		if cfg.EnableAcme {
+			acme.AddHost(cfg.APIHostname)
+		}
+	}
+
+	return r
+}
+
+func (r *Router) AddRoute(ctx context.Context, nrr *caddy.NewRouteRequest) (*http.Response, error) {
+	host := strings.ToLower(nrr.DownstreamProxySubdomain + "." + nrr.DownstreamProxyBaseDomain)
+	rt := &route{
+		id:    nrr.RouteID,
+		host:  host,
+		proxy: newTunnelProxy(net.JoinHostPort(nrr.TargetTunnelHost, nrr.TargetTunnelPort)),
+	}
+
+	r.mu.Lock()
+	if existing := r.routes[rt.id]; existing != nil {
+		delete(r.hosts, existing.host)
+	}
+	r.routes[rt.id] = rt
+	r.hosts[rt.host] = rt
+	r.mu.Unlock()
+
+	if r.cfg.EnableAcme {
+		r.acme.AddHost(host)
+	}
+
+	r.logger.Debugf("added route %s: %s -> %s:%s", rt.id, host, nrr.TargetTunnelHost, nrr.TargetTunnelPort)
+	return newResponse(http.StatusOK), nil
+}
+
+func (r *Router) DeleteRoute(ctx context.Context, routeID string) (*http.Response, error) {
+	r.mu.Lock()
+	rt := r.routes[routeID]
+	if rt != nil {
+		delete(r.routes, routeID)
+		delete(r.hosts, rt.host)
+	}
+	r.mu.Unlock()
+
+	if rt == nil {
+		return newResponse(http.StatusNotFound), nil
+	}
+
+	if r.cfg.EnableAcme {
+		r.acme.RemoveHost(rt.host)
+	}
+
+	r.logger.Debugf("deleted route %s: %s", rt.id, rt.host)
+	return newResponse(http.StatusOK), nil
+}
+
+// ServeHTTP routes by the host header, the tls server name was already checked for the certificate.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	host := req.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	if r.apiProxy != nil && host == strings.ToLower(r.cfg.APIHostname) {
+		r.apiProxy.ServeHTTP(w, req)
+		return
+	}
+
+	r.mu.RLock()
+	rt := r.hosts[host]
+	r.mu.RUnlock()
+
+	if rt == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	rt.proxy.ServeHTTP(w, req)
+}
+
+func (r *Router) Start(ctx context.Context) error {
+	tlsConfig, err := r.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", r.cfg.HostAddress)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", r.cfg.HostAddress, err)
+	}
+
+	r.ctx = ctx
+	r.listener = l
+	r.server = &http.Server{
+		Handler:           r,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	r.logger.Infof("listening for subdomain tunnels of %s on %s", r.cfg.BaseDomain, r.cfg.HostAddress)
+	go func() {
+		err := r.server.ServeTLS(l, "", "")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.logger.Errorf("subdomain tunnels router stopping, reason: %s", err)
+			r.errCh <- err
+		}
+		close(r.errCh)
+	}()
+
+	return nil
+}
+
+func (r *Router) Wait() error {
+	select {
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	case err := <-r.errCh:
+		return err
+	}
+}
+
+func (r *Router) Close() error {
+	if r.server == nil {
+		return nil
+	}
+	return r.server.Close()
+}
+
+// tlsConfig selects the certificate by the server name, either by acme or the configured files.
+func (r *Router) tlsConfig() (*tls.Config, error) {
+	cfg := security.TLSConfig(r.cfg.TLSMin)
+	if r.cfg.EnableAcme {
+		return r.acme.ApplyTLSConfig(cfg), nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Certificates = []tls.Certificate{cert}
+
+	if r.cfg.APIReverseProxyEnabled() && r.cfg.APICertFile != "" {
+		apiCert, err := tls.LoadX509KeyPair(r.cfg.APICertFile, r.cfg.APIKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if strings.EqualFold(hello.ServerName, r.cfg.APIHostname) {
+				return &apiCert, nil
+			}
+			return &cert, nil
+		}
+	}
+
+	return cfg, nil
+}
+
+// newTunnelProxy forwards to the internal tunnel proxy, it keeps the host and sets the X-Forwarded headers
+// for the ACL of the tunnel, like the caddy route does.
+func newTunnelProxy(target string) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "https", Host: target})
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	// the tunnel proxy listens on an ip address and may use a self-signed certificate
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+	proxy.Transport = transport
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Header.Set("X-Forwarded-Host", req.Host)
+	}
+	return proxy
+}
+
+// newResponse mimics the response of the caddy admin API.
+func newResponse(status int) *http.Response {
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Body:       http.NoBody,
+	}
+}
@@ -0,0 +1,106 @@
+package hostrouter
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/caddy"
+	"github.com/openrport/openrport/share/logger"
+)
+
+var testLog = logger.NewLogger("hostrouter-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func TestRouterRoutes(t *testing.T) {
+	tunnelProxy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "tunnel "+r.Host+" "+r.Header.Get("X-Forwarded-Host")+" "+r.Header.Get("X-Forwarded-For"))
+	}))
+	defer tunnelProxy.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "api "+r.URL.Path)
+	}))
+	defer api.Close()
+	tunnelHost, tunnelPort, err := net.SplitHostPort(tunnelProxy.Listener.Addr().String())
+	require.NoError(t, err)
+	_, apiPort, err := net.SplitHostPort(api.Listener.Addr().String())
+	require.NoError(t, err)
+
+	router := New(&caddy.Config{
+		BaseDomain:  "tunnels.rport.test",
+		APIHostname: "api.rport.test",
+		APIPort:     "443",
+	}, apiPort, nil, testLog)
+
+	get := func(host string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/app", nil)
+		req.Host = host
+		req.RemoteAddr = "192.0.2.10:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	res, err := router.AddRoute(context.Background(), &caddy.NewRouteRequest{
+		RouteID:                   "abc",
+		TargetTunnelHost:          tunnelHost,
+		TargetTunnelPort:          tunnelPort,
+		DownstreamProxySubdomain:  "abc",
+		DownstreamProxyBaseDomain: "tunnels.rport.test",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	code, body := get("ABC.tunnels.rport.test:8443")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "tunnel ABC.tunnels.rport.test:8443 ABC.tunnels.rport.test:8443 192.0.2.10", body)
+
+	code, body = get("api.rport.test")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "api /app", body)
+
+	code, _ = get("other.tunnels.rport.test")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	res, err = router.DeleteRoute(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	code, _ = get("abc.tunnels.rport.test")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	res, err = router.DeleteRoute(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRouterStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	router := New(&caddy.Config{
+		HostAddress: "127.0.0.1:0",
+		BaseDomain:  "tunnels.rport.test",
+		CertFile:    "../../testdata/certs/tunnels.rport.test.crt",
+		KeyFile:     "../../testdata/certs/tunnels.rport.test.key",
+	}, "", nil, testLog)
+	require.NoError(t, router.Start(ctx))
+	defer router.Close()
+
+	conn, err := tls.Dial("tcp", router.listener.Addr().String(), &tls.Config{
+		ServerName:         "abc.tunnels.rport.test",
+		InsecureSkipVerify: true, // #nosec G402
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "*.tunnels.rport.test", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+
+	cancel()
+	assert.ErrorIs(t, router.Wait(), context.Canceled)
+}
@@ -29,5 +29,11 @@ func (t *CleanupTask) Run(ctx context.Context) error {
 		return fmt.Errorf("failed to cleanup measurements: %v", err)
 	}
 	t.log.Debugf("monitoring.CleanupTask: %d measurement records deleted", deletedRecords)
+
+	deletedRecords, err = t.service.DeleteTunnelUsageOlderThan(ctx, t.duration)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup tunnel usage: %v", err)
+	}
+	t.log.Debugf("monitoring.CleanupTask: %d tunnel usage records deleted", deletedRecords)
 	return nil
 }
@@ -16,6 +16,7 @@ type DBProviderMock struct {
 	MetricsListPayload           []*ClientMetricsPayload
 	ProcessesListPayload         []*ClientProcessesPayload
 	MountpointsListPayload       []*ClientMountpointsPayload
+	TunnelUsageListPayload       []*ClientTunnelUsagePayload
 }
 
 func (p *DBProviderMock) CountByClientID(ctx context.Context, clientID string, fo *query.ListOptions) (int, error) {
@@ -50,6 +51,22 @@ func (p *DBProviderMock) DeleteMeasurementsBefore(ctx context.Context, compare t
 	return 0, nil
 }
 
+func (p *DBProviderMock) CreateTunnelUsage(ctx context.Context, usage *models.TunnelUsage) error {
+	return nil
+}
+
+func (p *DBProviderMock) DeleteTunnelUsageBefore(ctx context.Context, compare time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (p *DBProviderMock) ListTunnelUsageByClientID(ctx context.Context, clientID string, o *query.ListOptions) ([]*ClientTunnelUsagePayload, error) {
+	return p.TunnelUsageListPayload, nil
+}
+
+func (p *DBProviderMock) CountTunnelUsageByClientID(ctx context.Context, clientID string, o *query.ListOptions) (int, error) {
+	return len(p.TunnelUsageListPayload), nil
+}
+
 func (p *DBProviderMock) Close() error {
 	return nil
 }
@@ -126,6 +126,15 @@ type ClientMountpointsPayload struct {
 	Mountpoints types.JSONString `json:"mountpoints" db:"mountpoints"`
 }
 
+type ClientTunnelUsagePayload struct {
+	ClientID    string    `json:"-" db:"client_id"`
+	TunnelID    string    `json:"tunnel_id" db:"tunnel_id"`
+	Timestamp   time.Time `json:"timestamp" db:"timestamp"`
+	BytesIn     int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out" db:"bytes_out"`
+	Connections int64     `json:"connections" db:"connections"`
+}
+
 type GraphMetricsLinksPayload struct {
 	CPUUsagePercent    *string `json:"cpu_usage_percent,omitempty"`
 	MemUsagePercent    *string `json:"mem_usage_percent,omitempty"`
@@ -157,6 +166,19 @@ var ClientMountpointsSortFields = map[string]bool{
 	"timestamp": true,
 }
 
+var ClientTunnelUsageSortFields = map[string]bool{
+	"timestamp": true,
+	"tunnel_id": true,
+}
+
+var ClientTunnelUsageFilterFields = map[string]bool{
+	"timestamp[gt]":    true,
+	"timestamp[lt]":    true,
+	"timestamp[since]": true,
+	"timestamp[until]": true,
+	"tunnel_id":        true,
+}
+
 var ClientMetricsFilterFields = map[string]bool{
 	"timestamp[gt]":    true,
 	"timestamp[lt]":    true,
@@ -202,6 +224,16 @@ var ClientMetricsFields = map[string]map[string]bool{
 	},
 }
 
+var ClientTunnelUsageFields = map[string]map[string]bool{
+	"tunnel_usage": {
+		"timestamp":   true,
+		"tunnel_id":   true,
+		"bytes_in":    true,
+		"bytes_out":   true,
+		"connections": true,
+	},
+}
+
 var ClientProcessesFields = map[string]map[string]bool{
 	"processes": {
 		"timestamp": true,
@@ -224,6 +256,10 @@ var ClientMetricsSortDefault = map[string][]string{"sort": {"-timestamp"}}
 var ClientMetricsFilterDefault = map[string][]string{}
 var ClientMetricsFieldsDefault = map[string][]string{"fields[metrics]": {"timestamp", "cpu_usage_percent", "memory_usage_percent", "io_usage_percent"}}
 
+var ClientTunnelUsageSortDefault = map[string][]string{"sort": {"-timestamp"}}
+var ClientTunnelUsageFilterDefault = map[string][]string{}
+var ClientTunnelUsageFieldsDefault = map[string][]string{"fields[tunnel_usage]": {"timestamp", "tunnel_id", "bytes_in", "bytes_out", "connections"}}
+
 var ClientProcessesSortDefault = map[string][]string{"sort": {"-timestamp"}}
 var ClientProcessesFilterDefault = map[string][]string{}
 var ClientProcessesFieldsDefault = map[string][]string{"fields[processes]": {"timestamp", "processes"}}
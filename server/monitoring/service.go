@@ -24,6 +24,9 @@ type Service interface {
 	ListClientGraphMetrics(context.Context, string, *query.ListOptions, *query.RequestInfo, bool, bool) (*api.SuccessPayload, error)
 	ListClientMountpoints(context.Context, string, *query.ListOptions) (*api.SuccessPayload, error)
 	ListClientProcesses(context.Context, string, *query.ListOptions) (*api.SuccessPayload, error)
+	SaveTunnelUsage(ctx context.Context, usage *models.TunnelUsage) error
+	DeleteTunnelUsageOlderThan(ctx context.Context, period time.Duration) (int64, error)
+	ListClientTunnelUsage(context.Context, string, *query.ListOptions) (*api.SuccessPayload, error)
 }
 
 const layoutAPI = time.RFC3339
@@ -34,6 +37,8 @@ const defaultLimitMountpoints = 1
 const maxLimitMountpoints = 100
 const defaultLimitProcesses = 1
 const maxLimitProcesses = 10
+const defaultLimitTunnelUsage = 100
+const maxLimitTunnelUsage = 1000
 const minDownsamplingHours = 2
 const minDownsamplingDuration = time.Duration(minDownsamplingHours) * time.Hour
 const maxDownsamplingHours = 48
@@ -68,6 +73,15 @@ func (s *monitoringService) DeleteMeasurementsOlderThan(ctx context.Context, per
 	return s.DBProvider.DeleteMeasurementsBefore(ctx, compare)
 }
 
+func (s *monitoringService) SaveTunnelUsage(ctx context.Context, usage *models.TunnelUsage) error {
+	return s.DBProvider.CreateTunnelUsage(ctx, usage)
+}
+
+func (s *monitoringService) DeleteTunnelUsageOlderThan(ctx context.Context, period time.Duration) (int64, error) {
+	compare := time.Now().Add(-period)
+	return s.DBProvider.DeleteTunnelUsageBefore(ctx, compare)
+}
+
 func (s *monitoringService) ListClientGraphMetrics(ctx context.Context, clientID string, lo *query.ListOptions, ri *query.RequestInfo, netLan bool, netWan bool) (*api.SuccessPayload, error) {
 	span, err := s.validateAndParseGraphOptions(lo)
 	if err != nil {
@@ -333,6 +347,33 @@ func (s *monitoringService) ListClientProcesses(ctx context.Context, clientID st
 	}, nil
 }
 
+func (s *monitoringService) ListClientTunnelUsage(ctx context.Context, clientID string, options *query.ListOptions) (*api.SuccessPayload, error) {
+	err := query.ValidateListOptions(options, ClientTunnelUsageSortFields, ClientTunnelUsageFilterFields, ClientTunnelUsageFields, &query.PaginationConfig{
+		DefaultLimit: defaultLimitTunnelUsage,
+		MaxLimit:     maxLimitTunnelUsage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := parseAndConvertFilterValues(options.Filters); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.DBProvider.ListTunnelUsageByClientID(ctx, clientID, options)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.DBProvider.CountTunnelUsageByClientID(ctx, clientID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.SuccessPayload{
+		Data: entries,
+		Meta: api.NewMeta(count),
+	}, nil
+}
+
 func parseAndConvertFilterValues(filters []query.FilterOption) error {
 	for _, fo := range filters {
 		if (fo.Operator == query.FilterOperatorTypeGT) || (fo.Operator == query.FilterOperatorTypeLT) {
@@ -10,6 +10,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/monitoring"
 	"github.com/openrport/openrport/db/sqlite"
 	"github.com/openrport/openrport/share/logger"
@@ -26,6 +27,10 @@ type DBProvider interface {
 	ListMountpointsByClientID(context.Context, string, *query.ListOptions) ([]*ClientMountpointsPayload, error)
 	ListProcessesByClientID(context.Context, string, *query.ListOptions) ([]*ClientProcessesPayload, error)
 	CountByClientID(context.Context, string, *query.ListOptions) (int, error)
+	CreateTunnelUsage(ctx context.Context, usage *models.TunnelUsage) error
+	DeleteTunnelUsageBefore(ctx context.Context, compare time.Time) (int64, error)
+	ListTunnelUsageByClientID(context.Context, string, *query.ListOptions) ([]*ClientTunnelUsagePayload, error)
+	CountTunnelUsageByClientID(context.Context, string, *query.ListOptions) (int, error)
 	Close() error
 }
 
@@ -33,6 +38,13 @@ type DBProvider interface {
 // clean them in chunks and this is the chunk size
 const MaxDeletedEntries = 5000
 
+var Store = backend.Store{
+	Name:           "monitoring",
+	SQLiteFilename: "monitoring.db",
+	AssetNames:     monitoring.AssetNames(),
+	Asset:          monitoring.Asset,
+}
+
 type SqliteProvider struct {
 	db        *sqlx.DB
 	logger    *logger.Logger
@@ -47,11 +59,16 @@ func NewSqliteProvider(dbPath string, dataSourceOptions sqlite.DataSourceOptions
 
 	logger.Infof("initialized database at %s", dbPath)
 
+	return NewProvider(db, logger), nil
+}
+
+// NewProvider returns a provider using an already migrated DB.
+func NewProvider(db *sqlx.DB, logger *logger.Logger) DBProvider {
 	return &SqliteProvider{
 		db:        db,
 		logger:    logger,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SqliteProvider) ListMountpointsByClientID(ctx context.Context, clientID string, o *query.ListOptions) ([]*ClientMountpointsPayload, error) {
@@ -93,6 +110,7 @@ func (p *SqliteProvider) CountByClientID(ctx context.Context, clientID string, o
 	q := "SELECT COUNT(*) FROM `measurements` WHERE `client_id` = ? "
 	countOptions := *options
 	countOptions.Pagination = nil
+	countOptions.Sorts = nil
 
 	params := []interface{}{}
 	params = append(params, clientID)
@@ -111,7 +129,7 @@ func (p *SqliteProvider) ListGraphMetricsByClientID(ctx context.Context, clientI
 	params = append(params, clientID)
 
 	q := `SELECT
-		timestamp,
+		` + p.timestampColumn() + `,
 		round(avg(cpu_usage_percent),2) as cpu_usage_percent_avg,
 		min(cpu_usage_percent) as cpu_usage_percent_min,
 		max(cpu_usage_percent) as cpu_usage_percent_max,
@@ -128,7 +146,7 @@ func (p *SqliteProvider) ListGraphMetricsByClientID(ctx context.Context, clientI
 	/*This is the part of "downsampling graph data" (group together graph points, so that you don't get too much points in one request).
 	The value of "29" comes from Thorsten. He did some research and found out that "29" would be the best fit.
 	*/
-	q = q + ` GROUP BY round((strftime('%s',timestamp)/(?)),0)`
+	q = q + p.downsamplingGroupBy()
 	divisor := (math.Round(hours*100) / 100) * 29
 	params = append(params, divisor)
 
@@ -148,7 +166,7 @@ func (p *SqliteProvider) ListGraphByClientID(ctx context.Context, clientID strin
 		return nil, fmt.Errorf("unknown graph: %s", graph)
 	}
 
-	q := `SELECT timestamp, `
+	q := `SELECT ` + p.timestampColumn() + `, `
 	q = q + ` 
 		round(avg(` + field + `),2) as ` + alias + `_avg,
 		min(` + field + `) as ` + alias + `_min,
@@ -167,7 +185,7 @@ func (p *SqliteProvider) ListGraphByClientID(ctx context.Context, clientID strin
 
 	q, params = p.converter.AddWhere(lo.Filters, q, params)
 
-	q = q + ` GROUP BY round((strftime('%s',timestamp)/(?)),0)`
+	q = q + p.downsamplingGroupBy()
 	divisor := (math.Round(hours*100) / 100) * 29
 	params = append(params, divisor)
 
@@ -204,13 +222,91 @@ func (p *SqliteProvider) CreateMeasurement(ctx context.Context, measurement *mod
 // DeleteMeasurementsBefore deletes entries in chunks of MaxDeletedEntries
 // to clean all you can run in loop as long as there are more than 0 rows affected
 func (p *SqliteProvider) DeleteMeasurementsBefore(ctx context.Context, compare time.Time) (int64, error) {
-	result, err := p.db.ExecContext(ctx, "DELETE FROM measurements WHERE  timestamp IN (SELECT distinct timestamp FROM measurements WHERE timestamp < ? ORDER BY timestamp LIMIT ?)", compare, MaxDeletedEntries)
+	q := "DELETE FROM measurements WHERE  timestamp IN (SELECT distinct timestamp FROM measurements WHERE timestamp < ? ORDER BY timestamp LIMIT ?)"
+	if p.db.DriverName() == backend.DriverMySQL {
+		// mysql doesn't support LIMIT in IN subqueries
+		q = "DELETE FROM measurements WHERE timestamp < ? ORDER BY timestamp LIMIT ?"
+	}
+	result, err := p.db.ExecContext(ctx, q, compare, MaxDeletedEntries)
 	if err != nil {
 		return 0, err
 	}
 	return result.RowsAffected()
 }
 
+func (p *SqliteProvider) CreateTunnelUsage(ctx context.Context, usage *models.TunnelUsage) error {
+	_, err := sqlite.WithRetryWhenBusy(func() (result sql.Result, err error) {
+		return p.db.NamedExecContext(ctx, `INSERT INTO tunnel_usage (client_id, tunnel_id, timestamp, bytes_in, bytes_out, connections)
+			VALUES (:client_id, :tunnel_id, :timestamp, :bytes_in, :bytes_out, :connections)`, usage)
+	}, "createtunnelusage", p.logger)
+
+	return err
+}
+
+// DeleteTunnelUsageBefore deletes entries in chunks of MaxDeletedEntries like DeleteMeasurementsBefore
+func (p *SqliteProvider) DeleteTunnelUsageBefore(ctx context.Context, compare time.Time) (int64, error) {
+	q := "DELETE FROM tunnel_usage WHERE timestamp IN (SELECT distinct timestamp FROM tunnel_usage WHERE timestamp < ? ORDER BY timestamp LIMIT ?)"
+	if p.db.DriverName() == backend.DriverMySQL {
+		// mysql doesn't support LIMIT in IN subqueries
+		q = "DELETE FROM tunnel_usage WHERE timestamp < ? ORDER BY timestamp LIMIT ?"
+	}
+	result, err := p.db.ExecContext(ctx, q, compare, MaxDeletedEntries)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (p *SqliteProvider) ListTunnelUsageByClientID(ctx context.Context, clientID string, o *query.ListOptions) ([]*ClientTunnelUsagePayload, error) {
+	q := "SELECT * FROM `tunnel_usage` WHERE `client_id` = ? "
+	params := []interface{}{}
+	params = append(params, clientID)
+	q, params = p.converter.AppendOptionsToQuery(o, q, params)
+
+	val := []*ClientTunnelUsagePayload{}
+	err := p.db.SelectContext(ctx, &val, q, params...)
+	return val, err
+}
+
+func (p *SqliteProvider) CountTunnelUsageByClientID(ctx context.Context, clientID string, options *query.ListOptions) (int, error) {
+	var result int
+
+	q := "SELECT COUNT(*) FROM `tunnel_usage` WHERE `client_id` = ? "
+	countOptions := *options
+	countOptions.Pagination = nil
+	countOptions.Sorts = nil
+
+	params := []interface{}{}
+	params = append(params, clientID)
+	q, params = p.converter.AppendOptionsToQuery(&countOptions, q, params)
+
+	err := p.db.GetContext(ctx, &result, q, params...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// timestampColumn returns the timestamp of a group of downsampled measurements.
+func (p *SqliteProvider) timestampColumn() string {
+	if p.db.DriverName() == backend.DriverMySQL || p.db.DriverName() == backend.DriverPostgres {
+		// mysql and postgres don't allow not aggregated columns in grouped queries
+		return "MIN(timestamp) AS timestamp"
+	}
+	return "timestamp"
+}
+
+func (p *SqliteProvider) downsamplingGroupBy() string {
+	switch p.db.DriverName() {
+	case backend.DriverMySQL:
+		return ` GROUP BY round((UNIX_TIMESTAMP(timestamp)/(?)),0)`
+	case backend.DriverPostgres:
+		return ` GROUP BY round(CAST(EXTRACT(EPOCH FROM timestamp) AS NUMERIC)/(?),0)`
+	}
+	return ` GROUP BY round((strftime('%s',timestamp)/(?)),0)`
+}
+
 func (p *SqliteProvider) Close() error {
 	return p.db.Close()
 }
@@ -0,0 +1,81 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+)
+
+type ActiveClientsProvider interface {
+	GetAllActiveClients() []*clientdata.Client
+}
+
+type TunnelUsageTask struct {
+	log     *logger.Logger
+	service Service
+	clients ActiveClientsProvider
+}
+
+// NewTunnelUsageTask returns a task to store the traffic of all tunnels since the previous run
+func NewTunnelUsageTask(log *logger.Logger, service Service, clients ActiveClientsProvider) *TunnelUsageTask {
+	return &TunnelUsageTask{
+		log:     log,
+		service: service,
+		clients: clients,
+	}
+}
+
+func (t *TunnelUsageTask) Run(ctx context.Context) error {
+	now := time.Now().UTC()
+	saved := 0
+	for _, c := range t.clients.GetAllActiveClients() {
+		for _, tunnel := range c.GetTunnels() {
+			ok, err := t.saveUsage(ctx, c.GetID(), tunnel, now)
+			if err != nil {
+				return err
+			}
+			if ok {
+				saved++
+			}
+		}
+	}
+	t.log.Debugf("monitoring.TunnelUsageTask: usage of %d tunnels saved", saved)
+	return nil
+}
+
+// SaveTerminatedTunnelUsage stores the traffic of a tunnel since the previous run, it's called when the tunnel is
+// terminated, because the tunnel is gone on the next run.
+func (t *TunnelUsageTask) SaveTerminatedTunnelUsage(clientID string, tunnel *clienttunnel.Tunnel) {
+	if _, err := t.saveUsage(context.Background(), clientID, tunnel, time.Now().UTC()); err != nil {
+		t.log.Errorf("monitoring.TunnelUsageTask: %v", err)
+	}
+}
+
+func (t *TunnelUsageTask) saveUsage(ctx context.Context, clientID string, tunnel *clienttunnel.Tunnel, now time.Time) (bool, error) {
+	if tunnel.Stats == nil {
+		return false, nil
+	}
+	usage := tunnel.Stats.Usage()
+	// idle tunnels are not stored
+	if usage.BytesIn == 0 && usage.BytesOut == 0 && usage.Connections == 0 {
+		return false, nil
+	}
+
+	err := t.service.SaveTunnelUsage(ctx, &models.TunnelUsage{
+		ClientID:    clientID,
+		TunnelID:    tunnel.ID,
+		Timestamp:   now,
+		BytesIn:     usage.BytesIn,
+		BytesOut:    usage.BytesOut,
+		Connections: usage.Connections,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to save usage of tunnel %s of client %s: %v", tunnel.ID, clientID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/query"
+)
+
+type activeClientsMock []*clientdata.Client
+
+func (m activeClientsMock) GetAllActiveClients() []*clientdata.Client {
+	return m
+}
+
+func TestSqliteProvider_TunnelUsage(t *testing.T) {
+	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
+	require.NoError(t, err)
+	defer dbProvider.Close()
+
+	ctx := context.Background()
+
+	for _, u := range []*models.TunnelUsage{
+		{ClientID: "test_client_1", TunnelID: "1", Timestamp: measurement1, BytesIn: 100, BytesOut: 200, Connections: 1},
+		{ClientID: "test_client_1", TunnelID: "2", Timestamp: measurement1, BytesIn: 10, BytesOut: 20},
+		{ClientID: "test_client_1", TunnelID: "1", Timestamp: measurement2, BytesIn: 300, BytesOut: 400, Connections: 2},
+		{ClientID: "test_client_2", TunnelID: "1", Timestamp: measurement2, BytesIn: 1, BytesOut: 1},
+	} {
+		require.NoError(t, dbProvider.CreateTunnelUsage(ctx, u))
+	}
+
+	options := &query.ListOptions{
+		Sorts:   query.ParseSortOptions(ClientTunnelUsageSortDefault),
+		Filters: []query.FilterOption{{Column: []string{"tunnel_id"}, Values: []string{"1"}}},
+		Fields:  query.ParseFieldsOptions(ClientTunnelUsageFieldsDefault),
+	}
+	usage, err := dbProvider.ListTunnelUsageByClientID(ctx, "test_client_1", options)
+	require.NoError(t, err)
+	assert.Equal(t, []*ClientTunnelUsagePayload{
+		{TunnelID: "1", Timestamp: measurement2, BytesIn: 300, BytesOut: 400, Connections: 2},
+		{TunnelID: "1", Timestamp: measurement1, BytesIn: 100, BytesOut: 200, Connections: 1},
+	}, usage)
+
+	count, err := dbProvider.CountTunnelUsageByClientID(ctx, "test_client_1", options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	deleted, err := dbProvider.DeleteTunnelUsageBefore(ctx, measurement2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestTunnelUsageTask(t *testing.T) {
+	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
+	require.NoError(t, err)
+	defer dbProvider.Close()
+
+	ctx := context.Background()
+
+	c := &clientdata.Client{
+		ID: "test_client_1",
+		Tunnels: []*clienttunnel.Tunnel{
+			{ID: "1", Stats: &clienttunnel.TunnelStats{}},
+			{ID: "2"},
+		},
+	}
+	task := NewTunnelUsageTask(testLog, NewService(dbProvider, testLog), activeClientsMock{c})
+
+	// idle tunnels and tunnels without stats are skipped
+	require.NoError(t, task.Run(ctx))
+	count, err := dbProvider.CountTunnelUsageByClientID(ctx, "test_client_1", &query.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
@@ -45,7 +45,8 @@ func (r repository) Count(ctx context.Context, options *query.ListOptions) (int,
 
 	countOptions := *options
 	countOptions.Pagination = nil
-	q := "SELECT COUNT(*) FROM notifications_log ORDER by notification_id"
+	countOptions.Sorts = nil
+	q := "SELECT COUNT(*) FROM notifications_log"
 	params := []interface{}{}
 	q, params = r.converter.AppendOptionsToQuery(&countOptions, q, params)
 
@@ -140,7 +141,9 @@ func (r repository) save(ctx context.Context, details notifications.Notification
 }
 
 func (r repository) Details(ctx context.Context, nid string) (notifications.NotificationDetails, bool, error) {
-	q := "SELECT * FROM `notifications_log` WHERE `notification_id` = ? order by oid asc"
+	// the columns are listed, because the mysql and postgres tables have the oid as an explicit column
+	q := "SELECT `notification_id`, `timestamp`, `contentType`, `reference_id`, `transport`, `recipients`, `state`, `subject`, `body`, `out`, `err`" +
+		" FROM `notifications_log` WHERE `notification_id` = ? order by oid asc"
 
 	empty := notifications.NotificationDetails{}
 	entities := []SQLNotification{}
@@ -192,7 +195,7 @@ func (r repository) List(ctx context.Context, options *query.ListOptions) ([]not
 	var res []notifications.NotificationSummary
 
 	q := `
-SELECT notification_id, state, transport, timestamp, out, err
+SELECT notification_id, state, transport, timestamp, ` + "`out`" + `, err
 FROM notifications_log ORDER by timestamp desc`
 	params := []interface{}{}
 	q, params = r.converter.AppendOptionsToQuery(options, q, params)
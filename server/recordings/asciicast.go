@@ -0,0 +1,220 @@
+package recordings
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	chshare "github.com/openrport/openrport/share"
+)
+
+// asciicast v2 format, see https://docs.asciinema.org/manual/asciicast/v2/
+const (
+	castVersion = 2
+
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     uint32            `json:"width"`
+	Height    uint32            `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a line of an asciicast v2 file after the header, it's encoded as [time, type, data].
+type Event struct {
+	// Time is the number of seconds since the start of the recording.
+	Time float64
+	Type string
+	Data string
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Type, e.Data})
+}
+
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+	if len(raw) != 3 {
+		return fmt.Errorf("invalid event: expected 3 elements, got %d", len(raw))
+	}
+	if err := json.Unmarshal(raw[0], &e.Time); err != nil {
+		return fmt.Errorf("invalid event time: %w", err)
+	}
+	if err := json.Unmarshal(raw[1], &e.Type); err != nil {
+		return fmt.Errorf("invalid event type: %w", err)
+	}
+	if err := json.Unmarshal(raw[2], &e.Data); err != nil {
+		return fmt.Errorf("invalid event data: %w", err)
+	}
+	return nil
+}
+
+// Elapsed returns the time of the event as duration.
+func (e Event) Elapsed() time.Duration {
+	return time.Duration(e.Time * float64(time.Second))
+}
+
+// castWriter writes events with the time elapsed since it was created.
+type castWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	// pending holds an incomplete UTF-8 sequence at the end of the previous data per event type,
+	// it's completed by the next data of the same type.
+	pending map[string][]byte
+}
+
+func newCastWriter(w io.Writer, h Header) (*castWriter, error) {
+	h.Version = castVersion
+	start := time.Now()
+	if h.Timestamp == 0 {
+		h.Timestamp = start.Unix()
+	}
+
+	err := writeLine(w, h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+
+	return &castWriter{
+		w:       w,
+		start:   start,
+		pending: make(map[string][]byte),
+	}, nil
+}
+
+// writeEvent writes data read from a stream, a multibyte character split between two reads is written
+// with the second event.
+func (cw *castWriter) writeEvent(eventType string, data []byte) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	if pending := cw.pending[eventType]; len(pending) > 0 {
+		data = append(pending, data...)
+		delete(cw.pending, eventType)
+	}
+	if n := chshare.IncompleteUTF8Suffix(data); n > 0 {
+		cw.pending[eventType] = append([]byte(nil), data[len(data)-n:]...)
+		data = data[:len(data)-n]
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	return cw.write(eventType, data)
+}
+
+// flush writes the incomplete sequences held back by writeEvent.
+func (cw *castWriter) flush() error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	for eventType, pending := range cw.pending {
+		delete(cw.pending, eventType)
+		if err := cw.write(eventType, pending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cw *castWriter) write(eventType string, data []byte) error {
+	// asciicast uses seconds with microsecond precision
+	elapsed := float64(time.Since(cw.start).Microseconds()) / 1e6
+	return writeLine(cw.w, Event{Time: elapsed, Type: eventType, Data: escapeInvalidUTF8(data)})
+}
+
+// escapeInvalidUTF8 returns data as string with bytes that aren't valid UTF-8 written as \xNN, json encoding
+// would replace them with U+FFFD.
+func escapeInvalidUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	var b strings.Builder
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			fmt.Fprintf(&b, "\\x%02x", data[0])
+		} else {
+			b.Write(data[:size])
+		}
+		data = data[size:]
+	}
+	return b.String()
+}
+
+func writeLine(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// CastReader reads an asciicast v2 file event by event.
+type CastReader struct {
+	r      *bufio.Reader
+	Header Header
+}
+
+func NewCastReader(r io.Reader) (*CastReader, error) {
+	cr := &CastReader{
+		r: bufio.NewReader(r),
+	}
+
+	line, err := cr.r.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	err = json.Unmarshal(line, &cr.Header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	if cr.Header.Version != castVersion {
+		return nil, fmt.Errorf("unsupported asciicast version %d", cr.Header.Version)
+	}
+
+	return cr, nil
+}
+
+// Next returns the next event, io.EOF is returned after the last event.
+func (cr *CastReader) Next() (*Event, error) {
+	for {
+		line, err := cr.r.ReadBytes('\n')
+		if len(line) == 0 || (len(line) == 1 && line[0] == '\n') {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		e := &Event{}
+		// a recording might end with an incomplete line when the server stopped while writing
+		if jsonErr := json.Unmarshal(line, e); jsonErr != nil {
+			if err != nil {
+				return nil, io.EOF
+			}
+			return nil, jsonErr
+		}
+		return e, nil
+	}
+}
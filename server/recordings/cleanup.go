@@ -0,0 +1,33 @@
+package recordings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+type CleanupTask struct {
+	log      *logger.Logger
+	manager  *Manager
+	duration time.Duration
+}
+
+// NewCleanupTask returns a task to delete recordings after the configured storage duration
+func NewCleanupTask(log *logger.Logger, manager *Manager, duration time.Duration) *CleanupTask {
+	return &CleanupTask{
+		log:      log,
+		manager:  manager,
+		duration: duration,
+	}
+}
+
+func (t *CleanupTask) Run(ctx context.Context) error {
+	deleted, err := t.manager.DeleteOlderThan(ctx, time.Now().Add(-t.duration))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup recordings: %v", err)
+	}
+	t.log.Debugf("recordings.CleanupTask: %d recordings deleted", deleted)
+	return nil
+}
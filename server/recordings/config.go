@@ -0,0 +1,26 @@
+package recordings
+
+import (
+	"fmt"
+	"time"
+)
+
+const MinStorageDuration = time.Hour
+
+type Config struct {
+	RecordSessions  bool          `mapstructure:"record_sessions"`
+	RecordTunnels   bool          `mapstructure:"record_tunnels"`
+	StorageDuration time.Duration `mapstructure:"recordings_storage_duration"`
+}
+
+func (c *Config) Enabled() bool {
+	return c.RecordSessions || c.RecordTunnels
+}
+
+func (c *Config) Validate() error {
+	// 0 keeps recordings forever
+	if c.StorageDuration != 0 && c.StorageDuration < MinStorageDuration {
+		return fmt.Errorf("invalid api.recordings_storage_duration: %v, must be at least %v", c.StorageDuration, MinStorageDuration)
+	}
+	return nil
+}
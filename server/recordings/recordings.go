@@ -0,0 +1,369 @@
+// Package recordings stores interactive shell sessions and tunnel connections in asciicast v2 format,
+// so it can be followed up what operators did on clients.
+package recordings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/server/clients/clienttunnel"
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/query"
+	"github.com/openrport/openrport/share/random"
+)
+
+const (
+	TypeShell  = "shell"
+	TypeTunnel = "tunnel"
+
+	castExt = ".cast"
+	metaExt = ".json"
+
+	tunnelWidth  = 80
+	tunnelHeight = 24
+)
+
+var (
+	SupportedFilters = map[string]bool{
+		"type":            true,
+		"client_id":       true,
+		"tunnel_id":       true,
+		"username":        true,
+		"started_at[gt]":  true,
+		"started_at[lt]":  true,
+		"started_at[eq]":  true,
+		"finished_at[gt]": true,
+		"finished_at[lt]": true,
+	}
+	SupportedSorts = map[string]bool{
+		"started_at": true,
+	}
+
+	validID = regexp.MustCompile(`^[0-9a-f-]+$`)
+)
+
+// Recording describes a recorded session, it's stored next to the asciicast file.
+type Recording struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	ClientID   string     `json:"client_id"`
+	TunnelID   string     `json:"tunnel_id"`
+	Username   string     `json:"username"`
+	RemoteAddr string     `json:"remote_addr"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Size       int64      `json:"size"`
+}
+
+// Manager stores recordings as files in a directory, the asciicast file <id>.cast and its metadata in <id>.json.
+type Manager struct {
+	logger *logger.Logger
+	dir    string
+}
+
+func NewManager(l *logger.Logger, dir string) (*Manager, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recordings dir: %w", err)
+	}
+
+	return &Manager{
+		logger: l,
+		dir:    dir,
+	}, nil
+}
+
+// Start creates a new recording, rec is completed with its ID and start time.
+func (m *Manager) Start(rec *Recording, h Header) (*Recorder, error) {
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+	rec.ID = id
+	rec.StartedAt = time.Now()
+	rec.FinishedAt = nil
+	h.Timestamp = rec.StartedAt.Unix()
+
+	err = m.saveMeta(rec)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(m.castPath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	cw, err := newCastWriter(f, h)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Recorder{
+		manager: m,
+		rec:     rec,
+		file:    f,
+		cast:    cw,
+	}, nil
+}
+
+// RecordTunnelConn starts a recording of a connection to a tunnel of the given client.
+func (m *Manager) RecordTunnelConn(clientID, tunnelID string, remote models.Remote, src net.Addr) (clienttunnel.ConnRecording, error) {
+	rec := &Recording{
+		Type:     TypeTunnel,
+		ClientID: clientID,
+		TunnelID: tunnelID,
+		Username: remote.Owner,
+	}
+	if src != nil {
+		rec.RemoteAddr = src.String()
+	}
+
+	r, err := m.Start(rec, Header{
+		Width:  tunnelWidth,
+		Height: tunnelHeight,
+		Title:  fmt.Sprintf("tunnel %s %s", tunnelID, remote.String()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Get returns the recording with the given ID, nil if it doesn't exist.
+func (m *Manager) Get(id string) (*Recording, error) {
+	if !validID.MatchString(id) {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(m.metaPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rec := &Recording{}
+	err = json.Unmarshal(b, rec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", id, err)
+	}
+
+	return rec, nil
+}
+
+// Open returns the asciicast file of the recording with the given ID.
+func (m *Manager) Open(id string) (*os.File, error) {
+	if !validID.MatchString(id) {
+		return nil, os.ErrNotExist
+	}
+	return os.Open(m.castPath(id))
+}
+
+// List returns recordings matching the filters of the given options sorted by start time, newest first unless
+// sorted ascending. Pagination is not applied.
+func (m *Manager) List(ctx context.Context, options *query.ListOptions) ([]*Recording, error) {
+	all, err := m.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Recording, 0, len(all))
+	for _, rec := range all {
+		matches, err := query.MatchesFilters(rec, options.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			result = append(result, rec)
+		}
+	}
+
+	asc := len(options.Sorts) > 0 && options.Sorts[0].IsASC
+	sort.SliceStable(result, func(i, j int) bool {
+		if asc {
+			return result[i].StartedAt.Before(result[j].StartedAt)
+		}
+		return result[i].StartedAt.After(result[j].StartedAt)
+	})
+
+	return result, nil
+}
+
+// DeleteOlderThan deletes all recordings finished before the given time. Recordings that never finished, because the
+// server stopped while recording, are deleted if they started before.
+func (m *Manager) DeleteOlderThan(ctx context.Context, t time.Time) (int, error) {
+	all, err := m.all(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, rec := range all {
+		end := rec.StartedAt
+		if rec.FinishedAt != nil {
+			end = *rec.FinishedAt
+		}
+		if !end.Before(t) {
+			continue
+		}
+
+		err := m.delete(rec.ID)
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+func (m *Manager) all(ctx context.Context) ([]*Recording, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Recording, 0, len(entries))
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if e.IsDir() || !strings.HasSuffix(e.Name(), metaExt) {
+			continue
+		}
+
+		rec, err := m.Get(strings.TrimSuffix(e.Name(), metaExt))
+		if err != nil {
+			m.logger.Errorf("Skipping recording: %v", err)
+			continue
+		}
+		if rec != nil {
+			result = append(result, rec)
+		}
+	}
+
+	return result, nil
+}
+
+func (m *Manager) delete(id string) error {
+	for _, p := range []string{m.castPath(id), m.metaPath(id)} {
+		err := os.Remove(p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete recording %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) saveMeta(rec *Recording) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file first, so listing never sees incomplete metadata
+	tmp := m.metaPath(rec.ID) + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save recording %s: %w", rec.ID, err)
+	}
+	return os.Rename(tmp, m.metaPath(rec.ID))
+}
+
+func (m *Manager) castPath(id string) string {
+	return filepath.Join(m.dir, id+castExt)
+}
+
+func (m *Manager) metaPath(id string) string {
+	return filepath.Join(m.dir, id+metaExt)
+}
+
+// Recorder writes the events of a running recording. A nil recorder records nothing, so callers don't need to
+// check whether recording is enabled.
+type Recorder struct {
+	manager *Manager
+	rec     *Recording
+	file    *os.File
+	cast    *castWriter
+
+	// only the first write error is logged, following writes most likely fail the same way
+	failOnce  sync.Once
+	closeOnce sync.Once
+}
+
+// ID returns the ID of the recording, empty for a nil recorder.
+func (r *Recorder) ID() string {
+	if r == nil {
+		return ""
+	}
+	return r.rec.ID
+}
+
+// Output records data sent to the user.
+func (r *Recorder) Output(p []byte) {
+	r.write(EventOutput, p)
+}
+
+// Input records data sent by the user.
+func (r *Recorder) Input(p []byte) {
+	r.write(EventInput, p)
+}
+
+// Resize records a change of the terminal size.
+func (r *Recorder) Resize(cols, rows uint32) {
+	r.write(EventResize, []byte(fmt.Sprintf("%dx%d", cols, rows)))
+}
+
+func (r *Recorder) write(eventType string, data []byte) {
+	if r == nil || len(data) == 0 {
+		return
+	}
+
+	r.logWriteError(r.cast.writeEvent(eventType, data))
+}
+
+func (r *Recorder) logWriteError(err error) {
+	if err != nil {
+		r.failOnce.Do(func() {
+			r.manager.logger.Errorf("Failed to write recording %s: %v", r.rec.ID, err)
+		})
+	}
+}
+
+// Close finishes the recording.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	var err error
+	r.closeOnce.Do(func() {
+		r.logWriteError(r.cast.flush())
+		err = r.file.Close()
+		if err != nil {
+			return
+		}
+
+		now := time.Now()
+		r.rec.FinishedAt = &now
+		if info, statErr := os.Stat(r.manager.castPath(r.rec.ID)); statErr == nil {
+			r.rec.Size = info.Size()
+		}
+		err = r.manager.saveMeta(r.rec)
+	})
+	return err
+}
@@ -0,0 +1,284 @@
+package recordings
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/logger"
+	"github.com/openrport/openrport/share/models"
+	"github.com/openrport/openrport/share/query"
+)
+
+var testLog = logger.NewLogger("recordings", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func TestRecordAndRead(t *testing.T) {
+	m, err := NewManager(testLog, t.TempDir())
+	require.NoError(t, err)
+
+	r, err := m.Start(&Recording{
+		Type:     TypeShell,
+		ClientID: "client-1",
+		Username: "admin",
+	}, Header{Width: 80, Height: 24, Env: map[string]string{"TERM": "xterm-256color"}})
+	require.NoError(t, err)
+
+	r.Output([]byte("$ "))
+	r.Input([]byte("ls\r"))
+	r.Resize(120, 40)
+	r.Output([]byte("file.txt\r\n"))
+	require.NoError(t, r.Close())
+	// closing twice is a no-op
+	require.NoError(t, r.Close())
+
+	rec, err := m.Get(r.ID())
+	require.NoError(t, err)
+	require.NotNil(t, rec)
+	assert.Equal(t, TypeShell, rec.Type)
+	assert.Equal(t, "client-1", rec.ClientID)
+	assert.Equal(t, "admin", rec.Username)
+	require.NotNil(t, rec.FinishedAt)
+	assert.False(t, rec.FinishedAt.Before(rec.StartedAt))
+	assert.Greater(t, rec.Size, int64(0))
+
+	f, err := m.Open(r.ID())
+	require.NoError(t, err)
+	defer f.Close()
+
+	cast, err := NewCastReader(f)
+	require.NoError(t, err)
+	assert.Equal(t, Header{
+		Version:   2,
+		Width:     80,
+		Height:    24,
+		Timestamp: rec.StartedAt.Unix(),
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	}, cast.Header)
+
+	var events []Event
+	var last time.Duration
+	for {
+		e, err := cast.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, e.Elapsed(), last)
+		last = e.Elapsed()
+		events = append(events, Event{Type: e.Type, Data: e.Data})
+	}
+	assert.Equal(t, []Event{
+		{Type: EventOutput, Data: "$ "},
+		{Type: EventInput, Data: "ls\r"},
+		{Type: EventResize, Data: "120x40"},
+		{Type: EventOutput, Data: "file.txt\r\n"},
+	}, events)
+}
+
+func TestRecordSplitAndInvalidUTF8(t *testing.T) {
+	m, err := NewManager(testLog, t.TempDir())
+	require.NoError(t, err)
+
+	r, err := m.Start(&Recording{Type: TypeShell, ClientID: "client-1"}, Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+
+	euro := []byte("€")
+	r.Output(append([]byte("a"), euro[:1]...))
+	r.Input([]byte("x"))
+	r.Output(append(euro[1:], 'b'))
+	r.Output([]byte{'c', 0xff, 'd'})
+	// the incomplete character at the end is written on close
+	r.Output([]byte{'e', euro[0], euro[1]})
+	require.NoError(t, r.Close())
+
+	f, err := m.Open(r.ID())
+	require.NoError(t, err)
+	defer f.Close()
+	cast, err := NewCastReader(f)
+	require.NoError(t, err)
+
+	var events []Event
+	for {
+		e, err := cast.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		events = append(events, Event{Type: e.Type, Data: e.Data})
+	}
+	assert.Equal(t, []Event{
+		{Type: EventOutput, Data: "a"},
+		{Type: EventInput, Data: "x"},
+		{Type: EventOutput, Data: "€b"},
+		{Type: EventOutput, Data: `c\xffd`},
+		{Type: EventOutput, Data: "e"},
+		{Type: EventOutput, Data: `\xe2\x82`},
+	}, events)
+}
+
+func TestCastReaderTruncated(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "truncated.cast")
+	err := os.WriteFile(p, []byte(`{"version":2,"width":80,"height":24}
+[0.1,"o","hello"]
+[0.2,"o","wor`), 0600)
+	require.NoError(t, err)
+
+	f, err := os.Open(p)
+	require.NoError(t, err)
+	defer f.Close()
+
+	cast, err := NewCastReader(f)
+	require.NoError(t, err)
+
+	e, err := cast.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &Event{Time: 0.1, Type: EventOutput, Data: "hello"}, e)
+
+	_, err = cast.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCastReaderInvalidVersion(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "*.cast")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(`{"version":1,"width":80,"height":24}` + "\n")
+	require.NoError(t, err)
+	_, err = f.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	_, err = NewCastReader(f)
+	assert.EqualError(t, err, "unsupported asciicast version 1")
+}
+
+func TestRecordTunnelConn(t *testing.T) {
+	m, err := NewManager(testLog, t.TempDir())
+	require.NoError(t, err)
+
+	remote := models.Remote{
+		LocalHost:  "0.0.0.0",
+		LocalPort:  "3390",
+		RemoteHost: "127.0.0.1",
+		RemotePort: "3389",
+		Owner:      "user1",
+	}
+	r, err := m.RecordTunnelConn("client-1", "1", remote, &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 50000})
+	require.NoError(t, err)
+	r.Input([]byte("request"))
+	r.Output([]byte("response"))
+	require.NoError(t, r.Close())
+
+	list, err := m.List(context.Background(), &query.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, TypeTunnel, list[0].Type)
+	assert.Equal(t, "client-1", list[0].ClientID)
+	assert.Equal(t, "1", list[0].TunnelID)
+	assert.Equal(t, "user1", list[0].Username)
+	assert.Equal(t, "192.0.2.1:50000", list[0].RemoteAddr)
+}
+
+func TestListAndDelete(t *testing.T) {
+	m, err := NewManager(testLog, t.TempDir())
+	require.NoError(t, err)
+
+	start := func(rec *Recording) *Recording {
+		r, err := m.Start(rec, Header{Width: 80, Height: 24})
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		return rec
+	}
+	rec1 := start(&Recording{Type: TypeShell, ClientID: "client-1", Username: "admin"})
+	rec2 := start(&Recording{Type: TypeShell, ClientID: "client-2", Username: "user1"})
+	rec3 := start(&Recording{Type: TypeTunnel, ClientID: "client-1", TunnelID: "1", Username: "user1"})
+
+	testCases := []struct {
+		name     string
+		options  *query.ListOptions
+		expected []string
+	}{
+		{
+			name:     "all, newest first",
+			options:  &query.ListOptions{},
+			expected: []string{rec3.ID, rec2.ID, rec1.ID},
+		},
+		{
+			name: "oldest first",
+			options: &query.ListOptions{
+				Sorts: []query.SortOption{{Column: "started_at", IsASC: true}},
+			},
+			expected: []string{rec1.ID, rec2.ID, rec3.ID},
+		},
+		{
+			name: "filter by type",
+			options: &query.ListOptions{
+				Filters: []query.FilterOption{{Column: []string{"type"}, Values: []string{TypeShell}}},
+			},
+			expected: []string{rec2.ID, rec1.ID},
+		},
+		{
+			name: "filter by username and client",
+			options: &query.ListOptions{
+				Filters: []query.FilterOption{
+					{Column: []string{"username"}, Values: []string{"user1"}},
+					{Column: []string{"client_id"}, Values: []string{"client-1"}},
+				},
+			},
+			expected: []string{rec3.ID},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := m.List(context.Background(), tc.options)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, rec := range list {
+				ids = append(ids, rec.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+
+	deleted, err := m.DeleteOlderThan(context.Background(), rec2.FinishedAt.Add(time.Nanosecond))
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	list, err := m.List(context.Background(), &query.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, rec3.ID, list[0].ID)
+
+	_, err = m.Open(rec1.ID)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestGetInvalidID(t *testing.T) {
+	m, err := NewManager(testLog, t.TempDir())
+	require.NoError(t, err)
+
+	rec, err := m.Get("../secret")
+	require.NoError(t, err)
+	assert.Nil(t, rec)
+
+	_, err = m.Open("../secret")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+
+	r.Output([]byte("out"))
+	r.Input([]byte("in"))
+	r.Resize(80, 24)
+	assert.Equal(t, "", r.ID())
+	assert.NoError(t, r.Close())
+}
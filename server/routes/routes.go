@@ -16,6 +16,10 @@ const (
 	ParamProblemID        = "problem_id"
 	ParamNotificationID   = "notification_id"
 	ParamSampleDataChoice = "sample_data_choice"
+	ParamRecordingID      = "recording_id"
+	ParamUploadID         = "upload_id"
+	ParamRolloutID        = "rollout_id"
+	ParamCredentialID     = "credential_id"
 
 	AllRoutesPrefix             = "/api/v1"
 	AuthRoutesPrefix            = "/auth"
@@ -29,6 +33,12 @@ const (
 	ASRunTestRulesRoute         = "/test"
 	ASSampleDataRoute           = "/sample-data"
 	TotPRoutes                  = "/me/totp-secret"
+	WebAuthnRegistrationRoute   = "/me/webauthn/registration"
+	WebAuthnCredentialsRoute    = "/me/webauthn/credentials"
+	WebAuthnAssertionRoute      = "/me/webauthn/assertion"
 	Verify2FaRoute              = "/verify-2fa"
 	FilesUploadRouteName        = "files"
+	UploadChunkRouteName        = "upload_chunk"
+	ClientUpdateArtefactRoute   = "client_update_artefact"
+	HAStatusRoute               = "/ha/status"
 )
@@ -70,6 +70,11 @@ func NewManager(db DbProvider, logger *logger.Logger) *Manager {
 }
 
 func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, error) {
+	return m.ListAllowed(ctx, re, nil)
+}
+
+// ListAllowed is like List but returns only the entries for which allowed returns true, nil allows all entries.
+func (m *Manager) ListAllowed(ctx context.Context, re *http.Request, allowed func(id string) bool) ([]Script, int, error) {
 	listOptions := query.GetListOptions(re)
 
 	err := query.ValidateListOptions(listOptions, supportedSortAndFilters, supportedSortAndFilters, supportedFields, &query.PaginationConfig{
@@ -93,6 +98,9 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, er
 
 	filtered := make([]Script, 0, len(entries))
 	for _, entry := range entries {
+		if allowed != nil && !allowed(entry.ID) {
+			continue
+		}
 		matches, err := query.MatchesFilters(entry, manualFilters)
 		if err != nil {
 			return nil, 0, err
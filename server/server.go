@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"path"
 	"runtime"
 	"sync"
@@ -20,10 +21,10 @@ import (
 
 	"github.com/patrickmn/go-cache"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/client_groups"
 	clientsmigration "github.com/openrport/openrport/db/migration/clients"
 	jobsmigration "github.com/openrport/openrport/db/migration/jobs"
-	"github.com/openrport/openrport/db/sqlite"
 	rportplus "github.com/openrport/openrport/plus"
 	alertingcap "github.com/openrport/openrport/plus/capabilities/alerting"
 	"github.com/openrport/openrport/server/acme"
@@ -35,11 +36,17 @@ import (
 	"github.com/openrport/openrport/server/cgroups"
 	"github.com/openrport/openrport/server/chconfig"
 	"github.com/openrport/openrport/server/clients"
+	"github.com/openrport/openrport/server/clients/storedtunnels"
 	"github.com/openrport/openrport/server/clientsauth"
+	"github.com/openrport/openrport/server/clientupdates"
+	"github.com/openrport/openrport/server/ha"
+	"github.com/openrport/openrport/server/hostrouter"
 	"github.com/openrport/openrport/server/monitoring"
 	"github.com/openrport/openrport/server/notifications"
 	"github.com/openrport/openrport/server/ports"
+	"github.com/openrport/openrport/server/recordings"
 	"github.com/openrport/openrport/server/scheduler"
+	"github.com/openrport/openrport/server/uploads"
 	chshare "github.com/openrport/openrport/share"
 	"github.com/openrport/openrport/share/capabilities"
 	"github.com/openrport/openrport/share/files"
@@ -50,8 +57,12 @@ import (
 
 const (
 	cleanupMeasurementsInterval = time.Minute * 2
+	tunnelUsageInterval         = time.Minute
+	autostartTunnelsInterval    = time.Minute
 	cleanupAPISessionsInterval  = time.Hour
 	cleanupJobsInterval         = time.Hour
+	cleanupRecordingsInterval   = time.Hour
+	cleanupUploadsInterval      = time.Hour
 	LogNumGoRoutinesInterval    = time.Minute * 2
 
 	DefaultMaxClientDBConnections = 50
@@ -61,31 +72,51 @@ const (
 	maxAlertingWorkers = 2
 )
 
+// subdomainTunnelsServer serves the subdomain tunnels, either by a caddy process or by the builtin host router
+type subdomainTunnelsServer interface {
+	caddy.API
+	Start(ctx context.Context) error
+	Wait() error
+	Close() error
+}
+
 // Server represents a rport service
 type Server struct {
 	*logger.Logger
-	clientListener      *ClientListener
-	apiListener         *APIListener
-	config              *chconfig.Config
-	clientService       clients.ClientService
-	clientDB            *sqlx.DB
-	clientAuthProvider  clientsauth.Provider
-	jobProvider         JobProvider
-	clientGroupProvider cgroups.ClientGroupProvider
-	monitoringService   monitoring.Service
-	authDB              *sqlx.DB
-	uiJobWebSockets     ws.WebSocketCache // used to push job result to UI
-	uploadWebSockets    sync.Map
-	jobsDoneChannel     jobResultChanMap // used for sequential command execution to know when command is finished
-	auditLog            *auditlog.AuditLog
-	capabilities        *models.Capabilities
-	scheduleManager     *schedule.Manager
-	filesAPI            files.FileAPI
-	plusManager         rportplus.Manager
-	caddyServer         *caddy.Server
-	acme                *acme.Acme
-	alertingService     alertingcap.Service
-	monitoringQueue     monitoring.MeasurementSaver
+	clientListener        *ClientListener
+	apiListener           *APIListener
+	config                *chconfig.Config
+	clientService         clients.ClientService
+	clientDB              *sqlx.DB
+	storedTunnels         *storedtunnels.Manager
+	clientAuthProvider    clientsauth.Provider
+	jobProvider           JobProvider
+	clientGroupProvider   cgroups.ClientGroupProvider
+	monitoringService     monitoring.Service
+	tunnelUsageTask       *monitoring.TunnelUsageTask
+	authDB                *sqlx.DB
+	uiJobWebSockets       ws.WebSocketCache // used to push job result to UI
+	uploadWebSockets      sync.Map
+	jobsDoneChannel       jobResultChanMap // used for sequential command execution to know when command is finished
+	runningMultiJobs      runningMultiJobMap
+	jobOutputs            jobOutputSubscriptions
+	auditLog              *auditlog.AuditLog
+	recordings            *recordings.Manager
+	uploadBlobs           *uploads.BlobStore
+	uploadSessions        *uploads.SessionManager
+	clientUpdateArtefacts *clientupdates.ArtefactStore
+	clientUpdateRollouts  *clientupdates.RolloutManager
+	capabilities          *models.Capabilities
+	scheduleManager       *schedule.Manager
+	filesAPI              files.FileAPI
+	plusManager           rportplus.Manager
+	caddyServer           subdomainTunnelsServer
+	acme                  *acme.Acme
+	alertingService       alertingcap.Service
+	monitoringQueue       monitoring.MeasurementSaver
+	elector               *ha.Elector // nil unless high availability is enabled, a nil elector is always active
+	alertingMu            sync.Mutex
+	alertingCancel        context.CancelFunc // stops the alerting run, with high availability it runs only while active
 }
 
 type ServerOpts struct {
@@ -159,24 +190,26 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		s.Errorf("Failed to store fingerprint %q in file %q: %v", fingerprint, fingerprintFile, err)
 	}
 
-	jobsDB, err := sqlite.New(
-		path.Join(config.Server.DataDir, "jobs.db"),
-		jobsmigration.AssetNames(),
-		jobsmigration.Asset,
-		config.Server.GetSQLiteDataSourceOptions(),
-	)
+	dbBackend := config.Backend()
+
+	jobsDB, err := dbBackend.Open(backend.Store{
+		Name:           "jobs",
+		SQLiteFilename: "jobs.db",
+		AssetNames:     jobsmigration.AssetNames(),
+		Asset:          jobsmigration.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create jobs DB instance: %v", err)
 	}
 
 	s.jobProvider = jobs.NewSqliteProvider(jobsDB, s.Logger)
 
-	groupsDB, err := sqlite.New(
-		path.Join(config.Server.DataDir, "client_groups.db"),
-		client_groups.AssetNames(),
-		client_groups.Asset,
-		config.Server.GetSQLiteDataSourceOptions(),
-	)
+	groupsDB, err := dbBackend.Open(backend.Store{
+		Name:           "client_groups",
+		SQLiteFilename: "client_groups.db",
+		AssetNames:     client_groups.AssetNames(),
+		Asset:          client_groups.Asset,
+	}, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client_groups DB instance: %v", err)
 	}
@@ -186,14 +219,11 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		return nil, err
 	}
 
-	monitoringProvider, err := monitoring.NewSqliteProvider(
-		path.Join(config.Server.DataDir, "monitoring.db"),
-		config.Server.GetSQLiteDataSourceOptions(),
-		s.Logger,
-	)
+	monitoringDB, err := dbBackend.Open(monitoring.Store, config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create monitoring DB instance: %v", err)
 	}
+	monitoringProvider := monitoring.NewProvider(monitoringDB, s.Logger)
 
 	// even if monitoring disabled, always create the monitoring service to support queries of past data etc
 	s.monitoringService = monitoring.NewService(monitoringProvider, s.Logger.Fork("monitoring"))
@@ -207,12 +237,12 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	// concurrent thread access.
 	sourceOptions.MaxOpenConnections = DefaultMaxClientDBConnections
 
-	s.clientDB, err = sqlite.New(
-		path.Join(config.Server.DataDir, "clients.db"),
-		clientsmigration.AssetNames(),
-		clientsmigration.Asset,
-		sourceOptions,
-	)
+	s.clientDB, err = dbBackend.Open(backend.Store{
+		Name:           "clients",
+		SQLiteFilename: "clients.db",
+		AssetNames:     clientsmigration.AssetNames(),
+		Asset:          clientsmigration.Asset,
+	}, sourceOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create clients DB instance: %v", err)
 	}
@@ -236,6 +266,24 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		return nil, err
 	}
 
+	// recordings are always available to view past sessions, even if recording is disabled now
+	s.recordings, err = recordings.NewManager(s.Logger.Fork("recordings"), path.Join(config.Server.DataDir, "recordings"))
+	if err != nil {
+		return nil, err
+	}
+	if config.API.Recordings.RecordTunnels {
+		s.clientService.SetTunnelRecorder(s.recordings)
+	}
+
+	if config.Monitoring.Enabled {
+		// tunnels are served by the server the client is connected to, so every server stores the usage of its own
+		s.tunnelUsageTask = monitoring.NewTunnelUsageTask(s.Logger, s.monitoringService, s.clientService.GetRepo())
+		s.clientService.SetTunnelUsageSaver(s.tunnelUsageTask)
+	}
+
+	s.storedTunnels = storedtunnels.New(s.clientDB)
+	s.clientService.SetAutostartTunnels(s.storedTunnels, s.clientGroupProvider)
+
 	if rportplus.IsPlusEnabled(config.PlusConfig) {
 		licCapEx := s.plusManager.GetLicenseCapabilityEx()
 		s.clientService.SetPlusLicenseInfoCap(licCapEx)
@@ -245,7 +293,7 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	s.auditLog, err = auditlog.New(
 		logger.NewLogger("auditlog", config.Logging.LogOutput, config.Logging.LogLevel),
 		s.clientService,
-		s.config.Server.DataDir,
+		dbBackend,
 		s.config.API.AuditLog,
 		s.config.Server.GetSQLiteDataSourceOptions(),
 	)
@@ -254,7 +302,7 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	}
 
 	if config.Database.Driver != "" {
-		s.authDB, err = sqlx.Connect(config.Database.Driver, config.Database.Dsn)
+		s.authDB, err = backend.Connect(config.Database.Driver, config.Database.Dsn)
 		if err != nil {
 			return nil, err
 		}
@@ -273,10 +321,26 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 
 	s.filesAPI = filesAPI
 
+	s.uploadBlobs = uploads.NewBlobStore(filesAPI, config.GetUploadDir())
+	s.uploadSessions, err = uploads.NewSessionManager(config.GetUploadDir(), s.uploadBlobs)
+	if err != nil {
+		return nil, err
+	}
+
+	s.clientUpdateArtefacts, err = clientupdates.NewArtefactStore(config.GetClientUpdatesDir())
+	if err != nil {
+		return nil, err
+	}
+	s.clientUpdateRollouts, err = clientupdates.NewRolloutManager(s.Logger.Fork("client-updates"), config.GetClientUpdatesDir(), s.clientUpdateArtefacts, s.clientService)
+	if err != nil {
+		return nil, err
+	}
+
 	s.apiListener, err = NewAPIListener(s, fingerprint)
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.SetSSHSecretReader(s.apiListener)
 
 	s.capabilities = capabilities.NewServerCapabilities(&config.Monitoring)
 
@@ -285,28 +349,93 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		return nil, err
 	}
 
+	if config.HA.Enabled {
+		haDB, err := dbBackend.Open(ha.Store, config.Server.GetSQLiteDataSourceOptions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create high availability DB instance: %v", err)
+		}
+		s.elector = ha.NewElector(ha.NewProvider(haDB), config.HA.NodeID, config.HA.LeaseDuration, s.Logger.Fork("ha"))
+		s.scheduleManager.SetActiveChecker(s.elector)
+		s.elector.OnActivate(s.activate)
+		s.elector.OnDeactivate(s.deactivate)
+	}
+
 	if s.config.CaddyEnabled() {
 		cfg := s.config
 		caddyLog := logger.NewLogger("caddy", cfg.Logging.LogOutput, cfg.Logging.LogLevel)
 
-		baseConfig, err := cfg.WriteCaddyBaseConfig(&cfg.Caddy)
-		if err != nil {
-			return nil, err
-		}
+		if cfg.Caddy.Builtin {
+			_, targetAPIPort, err := net.SplitHostPort(cfg.API.Address)
+			if err != nil {
+				return nil, err
+			}
+			s.caddyServer = hostrouter.New(&cfg.Caddy, targetAPIPort, s.acme, caddyLog.Fork("builtin"))
+		} else {
+			baseConfig, err := cfg.WriteCaddyBaseConfig(&cfg.Caddy)
+			if err != nil {
+				return nil, err
+			}
 
-		caddy.HostDomainSocket = baseConfig.GlobalSettings.AdminSocket
+			caddy.HostDomainSocket = baseConfig.GlobalSettings.AdminSocket
 
-		s.caddyServer = caddy.NewCaddyServer(&cfg.Caddy, caddyLog)
+			s.caddyServer = caddy.NewCaddyServer(&cfg.Caddy, caddyLog)
+		}
 		s.clientService.SetCaddyAPI(s.caddyServer)
 	}
 
-	if s.alertingService != nil {
-		dispatcher := notifications.NewDispatcher(s.apiListener.notificationsStorage)
-		s.alertingService.Run(ctx, config.Notifications.NotificationScriptDir, dispatcher, maxAlertingWorkers)
+	// with high availability alerting is started once the server becomes active
+	if s.elector == nil {
+		s.runAlertingService(ctx)
 	}
 	return s, nil
 }
 
+func (s *Server) runAlertingService(ctx context.Context) {
+	if s.alertingService == nil {
+		return
+	}
+	s.alertingMu.Lock()
+	defer s.alertingMu.Unlock()
+	if s.alertingCancel != nil {
+		return
+	}
+
+	ctx, s.alertingCancel = context.WithCancel(ctx)
+	dispatcher := notifications.NewDispatcher(s.apiListener.notificationsStorage)
+	s.alertingService.Run(ctx, s.config.Notifications.NotificationScriptDir, dispatcher, maxAlertingWorkers)
+}
+
+// stopAlertingService cancels the context of the alerting run, so it can be started again by runAlertingService.
+func (s *Server) stopAlertingService() {
+	s.alertingMu.Lock()
+	defer s.alertingMu.Unlock()
+	if s.alertingCancel != nil {
+		s.alertingCancel()
+		s.alertingCancel = nil
+	}
+}
+
+// activate takes over the state written by the previously active server before this server is reported active.
+func (s *Server) activate(ctx context.Context) {
+	if err := s.clientService.GetRepo().Reload(ctx); err != nil {
+		s.Errorf("failed to reload clients: %v", err)
+	}
+	if err := s.scheduleManager.Reload(ctx); err != nil {
+		s.Errorf("failed to reload schedules: %v", err)
+	}
+	if err := s.apiListener.apiSessions.Reload(ctx); err != nil {
+		s.Errorf("failed to reload api sessions: %v", err)
+	}
+	s.runAlertingService(ctx)
+}
+
+// deactivate disconnects all clients, so they reconnect to the active server via their fallback servers,
+// and stops alerting, the active server evaluates the rules.
+func (s *Server) deactivate() {
+	s.clientService.GetRepo().CloseAllActive()
+	s.stopAlertingService()
+}
+
 func (s *Server) HandlePlusLicenseInfoAvailable() {
 	s.Logger.Debugf("received license info from rport-plus")
 
@@ -382,7 +511,7 @@ func (s *Server) Run(ctx context.Context) error {
 	// TODO(m-terel): add graceful shutdown of background task
 	if s.config.Server.PurgeDisconnectedClients {
 		s.Infof("Period to keep disconnected clients is set to %v", s.config.Server.KeepDisconnectedClients)
-		go scheduler.Run(ctx, s.Logger, clients.NewCleanupTask(s.Logger, s.clientListener.server.clientService.GetRepo()), s.config.Server.PurgeDisconnectedClientsInterval)
+		go scheduler.Run(ctx, s.Logger, s.elector.OnlyActive(clients.NewCleanupTask(s.Logger, s.clientListener.server.clientService.GetRepo())), s.config.Server.PurgeDisconnectedClientsInterval)
 		s.Infof("Task to purge disconnected clients will run with interval %v", s.config.Server.PurgeDisconnectedClientsInterval)
 	} else {
 		s.Debugf("Task to purge disconnected clients disabled")
@@ -398,6 +527,11 @@ func (s *Server) Run(ctx context.Context) error {
 	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", clientsStatusCheckTask)), clientsStatusCheckTask, s.config.Server.CheckClientsConnectionInterval)
 	s.Infof("Task to check the clients connection status will run with interval %v", s.config.Server.CheckClientsConnectionInterval)
 
+	// tunnels are bound to the server the client is connected to, so every server starts its own
+	autostartTunnelsTask := clients.NewAutostartTunnelsTask(s.clientService)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", autostartTunnelsTask)), autostartTunnelsTask, autostartTunnelsInterval)
+	s.Infof("Task to start autostart tunnels within their window will run with interval %v", autostartTunnelsInterval)
+
 	if s.config.Monitoring.Enabled {
 		var cleaningPeriod time.Duration
 		if s.config.Monitoring.DataStorageDays > 0 {
@@ -409,20 +543,41 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 
 		monitoringCleanupTask := monitoring.NewCleanupTask(s.Logger, s.monitoringService, cleaningPeriod)
-		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", monitoringCleanupTask)), monitoringCleanupTask, cleanupMeasurementsInterval)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", monitoringCleanupTask)), s.elector.OnlyActive(monitoringCleanupTask), cleanupMeasurementsInterval)
 		s.Infof("Task to cleanup measurements will run with interval %v", cleanupMeasurementsInterval)
+
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", s.tunnelUsageTask)), s.tunnelUsageTask, tunnelUsageInterval)
+		s.Infof("Task to store the tunnel usage will run with interval %v", tunnelUsageInterval)
 	} else {
 		s.Infof("Measurement disabled")
 	}
 
 	sessionsCleanupTask := session.NewCleanupTask(s.apiListener.apiSessions)
-	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", sessionsCleanupTask)), sessionsCleanupTask, cleanupAPISessionsInterval)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", sessionsCleanupTask)), s.elector.OnlyActive(sessionsCleanupTask), cleanupAPISessionsInterval)
 	s.Infof("Task to cleanup expired api sessions will run with interval %v", cleanupAPISessionsInterval)
 
 	jobsCleanupTask := jobs.NewCleanupTask(s.jobProvider, s.config.Server.JobsMaxResults)
-	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", jobsCleanupTask)), jobsCleanupTask, cleanupJobsInterval)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", jobsCleanupTask)), s.elector.OnlyActive(jobsCleanupTask), cleanupJobsInterval)
 	s.Infof("Task to cleanup jobs will run with interval %v", cleanupJobsInterval)
 
+	// recordings are stored in the local data dir, so every server cleans up its own, active or not
+	if s.config.API.Recordings.StorageDuration > 0 {
+		recordingsCleanupTask := recordings.NewCleanupTask(s.Logger, s.recordings, s.config.API.Recordings.StorageDuration)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", recordingsCleanupTask)), recordingsCleanupTask, cleanupRecordingsInterval)
+		s.Infof("Task to cleanup recordings older than %v will run with interval %v", s.config.API.Recordings.StorageDuration, cleanupRecordingsInterval)
+	}
+
+	// uploads are stored in the local data dir as well
+	if s.config.API.Uploads.Retention > 0 {
+		uploadsCleanupTask := uploads.NewCleanupTask(s.Logger, s.uploadSessions, s.config.API.Uploads.Retention)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", uploadsCleanupTask)), uploadsCleanupTask, cleanupUploadsInterval)
+		s.Infof("Task to cleanup uploads unused for %v will run with interval %v", s.config.API.Uploads.Retention, cleanupUploadsInterval)
+	}
+
+	if s.elector != nil {
+		go s.elector.Run(ctx)
+	}
+
 	// Only on debug mode, log the number of running go routines
 	if s.config.Logging.LogLevel == logger.LogLevelDebug {
 		go func() {
@@ -525,6 +680,118 @@ func (s *Server) Close() error {
 	return err
 }
 
+// runningMultiJobMap holds the multi-client jobs executed by this server, so they can be cancelled.
+type runningMultiJobMap struct {
+	m  map[string]*runningMultiJob
+	mu sync.Mutex
+}
+
+type runningMultiJob struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+	cancelledBy string
+}
+
+// Start registers a multi-client job, its context is cancelled when the job is cancelled.
+func (m *runningMultiJobMap) Start(jobID string) context.Context {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.m == nil {
+		m.m = make(map[string]*runningMultiJob)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.m[jobID] = &runningMultiJob{
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	return ctx
+}
+
+// Finish unregisters a multi-client job.
+func (m *runningMultiJobMap) Finish(jobID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.m[jobID]; ok {
+		r.cancel()
+		close(r.done)
+		delete(m.m, jobID)
+	}
+}
+
+// CancelledBy returns the user who cancelled the multi-client job.
+func (m *runningMultiJobMap) CancelledBy(jobID string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.m[jobID]; ok {
+		return r.cancelledBy
+	}
+	return ""
+}
+
+// Cancel cancels a running multi-client job. The returned channel is closed when the job stopped. Returns false if
+// the job is not executed by this server.
+func (m *runningMultiJobMap) Cancel(jobID, cancelledBy string) (<-chan struct{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.m[jobID]
+	if !ok {
+		return nil, false
+	}
+	r.cancelledBy = cancelledBy
+	r.cancel()
+	return r.done, true
+}
+
+// jobOutputSubscriptions notifies the subscribers of a job about its new output and its result.
+type jobOutputSubscriptions struct {
+	m  map[string]map[chan struct{}]struct{}
+	mu sync.Mutex
+}
+
+// Subscribe returns a channel receiving a value when the job has changed and a func to unsubscribe.
+func (s *jobOutputSubscriptions) Subscribe(jobID string) (<-chan struct{}, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.m == nil {
+		s.m = make(map[string]map[chan struct{}]struct{})
+	}
+	if s.m[jobID] == nil {
+		s.m[jobID] = make(map[chan struct{}]struct{})
+	}
+	ch := make(chan struct{}, 1)
+	s.m[jobID][ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.m[jobID], ch)
+		if len(s.m[jobID]) == 0 {
+			delete(s.m, jobID)
+		}
+	}
+}
+
+// Notify notifies the subscribers of the job without blocking, pending notifications are merged.
+func (s *jobOutputSubscriptions) Notify(jobID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.m[jobID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // jobResultChanMap is thread safe map with [jobID, chan *models.Job] pairs.
 type jobResultChanMap struct {
 	m  map[string]chan *models.Job
@@ -0,0 +1,42 @@
+package chserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/plus/capabilities/alerting/alertingmock"
+	"github.com/openrport/openrport/server/chconfig"
+	"github.com/openrport/openrport/server/notifications"
+)
+
+type alertingRunRecorder struct {
+	*alertingmock.MockServiceProvider
+	runs []context.Context
+}
+
+func (r *alertingRunRecorder) Run(ctx context.Context, _ string, _ notifications.Dispatcher, _ int) {
+	r.runs = append(r.runs, ctx)
+}
+
+func TestAlertingServiceRestartsOnActivation(t *testing.T) {
+	recorder := &alertingRunRecorder{MockServiceProvider: alertingmock.NewMockServiceProvider()}
+	s := &Server{
+		alertingService: recorder,
+		apiListener:     &APIListener{},
+		config:          &chconfig.Config{},
+	}
+
+	s.runAlertingService(context.Background())
+	s.runAlertingService(context.Background())
+	require.Len(t, recorder.runs, 1, "alerting must not run twice")
+
+	s.stopAlertingService()
+	assert.Error(t, recorder.runs[0].Err(), "deactivation must cancel the alerting run")
+
+	s.runAlertingService(context.Background())
+	require.Len(t, recorder.runs, 2, "activation must restart alerting")
+	assert.NoError(t, recorder.runs[1].Err())
+}
@@ -17,10 +17,13 @@ import (
 	"github.com/openrport/openrport/server/auditlog"
 	"github.com/openrport/openrport/server/clients"
 	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/routes"
+	"github.com/openrport/openrport/server/uploads"
 	"github.com/openrport/openrport/share/comm"
 	"github.com/openrport/openrport/share/files"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/random"
+	"github.com/openrport/openrport/share/ws"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -37,6 +40,10 @@ type UploadRequest struct {
 	ClientTags           *models.JobClientTags
 	clientsInGroupsCount int
 	Clients              []*clientdata.Client
+	// UploadID refers to a completed resumable upload to send instead of the file of the request
+	UploadID string
+	session  *uploads.Session
+	hash     string
 	*models.UploadedFile
 }
 
@@ -64,7 +71,9 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer uploadRequest.File.Close()
+	if uploadRequest.File != nil {
+		defer uploadRequest.File.Close()
+	}
 
 	wasCreated, err := al.filesAPI.CreateDirIfNotExists(al.config.GetUploadDir(), files.DefaultMode)
 	if err != nil {
@@ -75,7 +84,20 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		al.Infof("created directory %s", al.config.GetUploadDir())
 	}
 
-	uploadRequest.SourceFilePath = al.genFilePath(uploadRequest.ID)
+	if uploadRequest.UploadID != "" {
+		uploadRequest.session, err = al.getAccessibleUploadSession(req, uploadRequest.UploadID)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if !uploadRequest.session.Completed() {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("upload %s is not completed", uploadRequest.UploadID))
+			return
+		}
+		uploadRequest.SourceFilePath = al.uploadBlobs.Path(uploadRequest.session.SHA256)
+	} else {
+		uploadRequest.SourceFilePath = al.genFilePath(uploadRequest.ID)
+	}
 
 	err = uploadRequest.Validate()
 	if err != nil {
@@ -97,40 +119,25 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		al.jsonErrorResponseWithDetail(w, http.StatusForbidden, "ACCESS_CONTROL_VIOLATION", "upload forbidden", err.Error())
 		return
 	}
-
-	copiedBytes, err := al.filesAPI.CreateFile(uploadRequest.SourceFilePath, uploadRequest.File)
-	if err != nil {
-		al.jsonError(w, err)
+	if err := al.checkScopeClientsAccess(req.Context(), uploadRequest.Clients, clientGroups); err != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusForbidden, "ACCESS_CONTROL_VIOLATION", "upload forbidden", err.Error())
 		return
 	}
-
-	file, err := al.filesAPI.Open(uploadRequest.SourceFilePath)
-	if err != nil {
-		al.jsonError(w, err)
+	if err := al.checkTokenClientsAccess(req.Context(), uploadRequest.Clients, clientGroups); err != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusForbidden, "ACCESS_CONTROL_VIOLATION", "upload forbidden", err.Error())
 		return
 	}
 
-	md5Checksum, err := files.Md5HashFromReader(file)
+	err = al.storeUploadedFile(uploadRequest)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
 
-	uploadRequest.Md5Checksum = md5Checksum
-
-	al.Debugf(
-		"stored file %s on server, size %d, Content-Type %s, temp location: %s, md5 checksum: %x",
-		uploadRequest.FileHeader.Filename,
-		uploadRequest.FileHeader.Size,
-		uploadRequest.FileHeader.Header.Get("Content-Type"),
-		uploadRequest.SourceFilePath,
-		md5Checksum,
-	)
-
 	uploadRep := &models.UploadResponseShort{
 		ID:        uploadRequest.ID,
 		Filepath:  uploadRequest.DestinationPath,
-		SizeBytes: copiedBytes,
+		SizeBytes: uploadRequest.Size,
 	}
 	al.auditLog.Entry(auditlog.ApplicationUploads, auditlog.ActionCreate).
 		WithHTTPRequest(req).
@@ -139,6 +146,8 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		WithID(uploadRequest.UploadedFile.ID).
 		SaveForMultipleClients(uploadRequest.Clients)
 
+	// the blob must not be deleted by the cleanup while clients copy it
+	al.uploadBlobs.Acquire(uploadRequest.hash)
 	go al.sendFileToClients(uploadRequest)
 
 	response := api.NewSuccessPayload(uploadRep)
@@ -146,6 +155,54 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// storeUploadedFile stores the file of the request in the blob store, unless it was uploaded with a resumable
+// upload before, which is already stored.
+func (al *APIListener) storeUploadedFile(uploadRequest *UploadRequest) error {
+	if uploadRequest.session != nil {
+		uploadRequest.hash = uploadRequest.session.SHA256
+		uploadRequest.Size = uploadRequest.session.Size
+		uploadRequest.Md5Checksum = uploadRequest.session.Md5Checksum()
+		return nil
+	}
+
+	tempFilePath := uploadRequest.SourceFilePath
+	copiedBytes, err := al.filesAPI.CreateFile(tempFilePath, uploadRequest.File)
+	if err != nil {
+		return err
+	}
+
+	file, err := al.filesAPI.Open(tempFilePath)
+	if err != nil {
+		return err
+	}
+	md5Checksum, sha256Checksum, err := uploads.Checksums(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	blobPath, err := al.uploadBlobs.Put(tempFilePath, sha256Checksum)
+	if err != nil {
+		return err
+	}
+
+	uploadRequest.SourceFilePath = blobPath
+	uploadRequest.Size = copiedBytes
+	uploadRequest.Md5Checksum = md5Checksum
+	uploadRequest.hash = sha256Checksum
+
+	al.Debugf(
+		"stored file %s on server, size %d, Content-Type %s, location: %s, md5 checksum: %x",
+		uploadRequest.FileHeader.Filename,
+		uploadRequest.FileHeader.Size,
+		uploadRequest.FileHeader.Header.Get("Content-Type"),
+		uploadRequest.SourceFilePath,
+		md5Checksum,
+	)
+
+	return nil
+}
+
 func (al *APIListener) handleUploadsWS(w http.ResponseWriter, req *http.Request) {
 	uiConn, err := apiUpgrader.Upgrade(w, req, nil)
 	if err != nil {
@@ -159,7 +216,8 @@ func (al *APIListener) handleUploadsWS(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	al.Server.uploadWebSockets.Store(connID, uiConn)
+	// results and progress of clients are sent concurrently
+	al.Server.uploadWebSockets.Store(connID, ws.NewConcurrentWebSocket(uiConn, al.Logger))
 
 	defer al.Server.uploadWebSockets.Delete(connID)
 	defer uiConn.Close()
@@ -192,6 +250,12 @@ type UploadOutput struct {
 	*models.UploadResponse
 }
 
+type UploadProgressOutput struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	*models.UploadProgress
+}
+
 func (al *APIListener) sendFileToClients(uploadRequest *UploadRequest) {
 	wg := &sync.WaitGroup{}
 	wg.Add(len(uploadRequest.Clients))
@@ -209,10 +273,8 @@ func (al *APIListener) sendFileToClients(uploadRequest *UploadRequest) {
 
 	al.consumeUploadResults(resChan, uploadRequest)
 
-	err := al.filesAPI.Remove(uploadRequest.SourceFilePath)
-	if err != nil {
-		al.Errorf("failed to delete temp file path %s: %v", uploadRequest.SourceFilePath, err)
-	}
+	// the file is kept to be reused by further uploads of the same file until it's deleted by the cleanup
+	al.uploadBlobs.Release(uploadRequest.hash)
 }
 
 func (al *APIListener) consumeUploadResults(resChan chan *uploadResult, uploadRequest *UploadRequest) {
@@ -288,12 +350,12 @@ func (al *APIListener) sendFileToClient(wg *sync.WaitGroup, file *models.Uploade
 	}
 }
 
-func (al *APIListener) notifyUploadEventListeners(msg interface{}) {
-	al.uploadWebSockets.Range(func(key, value interface{}) bool {
-		if wsConn, ok := value.(*websocket.Conn); ok {
+func (s *Server) notifyUploadEventListeners(msg interface{}) {
+	s.uploadWebSockets.Range(func(key, value interface{}) bool {
+		if wsConn, ok := value.(*ws.ConcurrentWebSocket); ok {
 			err := wsConn.WriteJSON(msg)
 			if err != nil {
-				al.Errorf("failed to send notification to websocket client %s: %v", key, err)
+				s.Errorf("failed to send notification to websocket client %s: %v", key, err)
 			}
 		}
 		return true
@@ -342,7 +404,11 @@ func (al *APIListener) uploadRequestFromRequest(req *http.Request) (ur *UploadRe
 		}
 	}
 
-	ur.File, ur.FileHeader, err = req.FormFile("upload")
+	if len(req.MultipartForm.Value[routes.ParamUploadID]) > 0 {
+		ur.UploadID = req.MultipartForm.Value[routes.ParamUploadID][0]
+	} else {
+		ur.File, ur.FileHeader, err = req.FormFile("upload")
+	}
 	if err != nil {
 		return nil, &errors2.APIError{
 			Err:        err,
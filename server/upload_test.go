@@ -22,6 +22,7 @@ import (
 	"github.com/openrport/openrport/server/chconfig"
 	"github.com/openrport/openrport/server/clients"
 	"github.com/openrport/openrport/server/clients/clientdata"
+	"github.com/openrport/openrport/server/uploads"
 	"github.com/openrport/openrport/share/files"
 	"github.com/openrport/openrport/share/models"
 	"github.com/openrport/openrport/share/test"
@@ -35,6 +36,8 @@ func MockUserService(user string, group string) *users.APIService {
 	return users.NewAPIService(users.NewStaticProvider([]*users.User{curUser}), false, 0, -1)
 }
 
+const someContentBlobPath = "/data/filepush/blobs/290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56"
+
 func FsCallback(fs *test.FileAPIMock, t *testing.T) {
 	fs.On("CreateDirIfNotExists", "/data/"+files.DefaultUploadTempFolder, files.DefaultMode).Return(true, nil)
 
@@ -52,7 +55,10 @@ func FsCallback(fs *test.FileAPIMock, t *testing.T) {
 	fileMock.On("Close").Return(nil)
 
 	fs.On("Open", "/data/filepush/id-123_rport_filepush").Return(fileMock, nil)
-	fs.On("Remove", "/data/filepush/id-123_rport_filepush").Return(nil)
+
+	fs.On("CreateDirIfNotExists", "/data/filepush/blobs", files.DefaultMode).Return(true, nil)
+	fs.On("Exist", someContentBlobPath).Return(false, nil)
+	fs.On("Rename", "/data/filepush/id-123_rport_filepush", someContentBlobPath).Return(nil)
 }
 
 func TestHandleFileUploads(t *testing.T) {
@@ -115,7 +121,7 @@ func TestHandleFileUploads(t *testing.T) {
 			},
 			wantClientInputFile: &models.UploadedFile{
 				ID:                   "id-123",
-				SourceFilePath:       "/data/filepush/id-123_rport_filepush",
+				SourceFilePath:       someContentBlobPath,
 				DestinationPath:      "/destination/myfile.txt",
 				DestinationFileMode:  0744,
 				DestinationFileOwner: "admin",
@@ -123,6 +129,7 @@ func TestHandleFileUploads(t *testing.T) {
 				ForceWrite:           true,
 				Sync:                 true,
 				Md5Checksum:          test.Md5Hash("some content"),
+				Size:                 10,
 			},
 		},
 		{
@@ -171,7 +178,7 @@ func TestHandleFileUploads(t *testing.T) {
 			},
 			wantClientInputFile: &models.UploadedFile{
 				ID:                   "id-123",
-				SourceFilePath:       "/data/filepush/id-123_rport_filepush",
+				SourceFilePath:       someContentBlobPath,
 				DestinationPath:      "/destination/myfile.txt",
 				DestinationFileMode:  0744,
 				DestinationFileOwner: "admin",
@@ -179,6 +186,7 @@ func TestHandleFileUploads(t *testing.T) {
 				ForceWrite:           true,
 				Sync:                 true,
 				Md5Checksum:          test.Md5Hash("some content"),
+				Size:                 10,
 			},
 		},
 		{
@@ -358,7 +366,8 @@ func TestHandleFileUploads(t *testing.T) {
 							MaxFilePushSize: int64(10 << 20),
 						},
 					},
-					filesAPI: fileAPIMock,
+					filesAPI:    fileAPIMock,
+					uploadBlobs: uploads.NewBlobStore(fileAPIMock, "/data/filepush"),
 				},
 				Logger:      testLog,
 				userService: MockUserService(tc.user, tc.group),
@@ -0,0 +1,150 @@
+// Package uploads stores files uploaded to the server to push them to clients. Uploaded files are stored once
+// by their content hash, they can be uploaded in one request or in chunks with a resumable upload session.
+package uploads
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/share/files"
+)
+
+const blobsDir = "blobs"
+
+var validHash = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// BlobStore is a content-addressed storage of uploaded files, each file is stored as <dir>/blobs/<sha256>.
+// Blobs in use by a running file push are reference counted, so they are not deleted by the cleanup.
+type BlobStore struct {
+	filesAPI files.FileAPI
+	dir      string
+
+	mu       sync.Mutex
+	refs     map[string]int
+	lastUsed map[string]time.Time
+}
+
+func NewBlobStore(filesAPI files.FileAPI, uploadDir string) *BlobStore {
+	return &BlobStore{
+		filesAPI: filesAPI,
+		dir:      filepath.Join(uploadDir, blobsDir),
+		refs:     make(map[string]int),
+		lastUsed: make(map[string]time.Time),
+	}
+}
+
+// Path returns the path of the blob with the given sha256 hash.
+func (s *BlobStore) Path(hash string) string {
+	return filepath.Join(s.dir, hash)
+}
+
+// Put moves the file at src into the store as the blob with the given sha256 hash and returns its path.
+// If the blob is already stored, src is removed.
+func (s *BlobStore) Put(src, hash string) (string, error) {
+	if !validHash.MatchString(hash) {
+		return "", fmt.Errorf("invalid blob hash %q", hash)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.filesAPI.CreateDirIfNotExists(s.dir, files.DefaultMode); err != nil {
+		return "", err
+	}
+
+	blobPath := s.Path(hash)
+	exists, err := s.filesAPI.Exist(blobPath)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		err = s.filesAPI.Remove(src)
+	} else {
+		err = s.filesAPI.Rename(src, blobPath)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.lastUsed[hash] = time.Now()
+	return blobPath, nil
+}
+
+// Acquire marks the blob as in use until it's released.
+func (s *BlobStore) Acquire(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refs[hash]++
+	s.lastUsed[hash] = time.Now()
+}
+
+func (s *BlobStore) Release(hash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refs[hash]--
+	if s.refs[hash] <= 0 {
+		delete(s.refs, hash)
+	}
+	s.lastUsed[hash] = time.Now()
+}
+
+// DeleteUnused deletes blobs which are not in use, not in keep and were last used before the given time.
+// Blobs not used since the server start are considered last used at their modification time.
+func (s *BlobStore) DeleteUnused(before time.Time, keep map[string]bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exists, err := s.filesAPI.Exist(s.dir)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	infos, err := s.filesAPI.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, info := range infos {
+		hash := info.Name()
+		if !validHash.MatchString(hash) || s.refs[hash] > 0 || keep[hash] {
+			continue
+		}
+		lastUsed, ok := s.lastUsed[hash]
+		if !ok {
+			lastUsed = info.ModTime()
+		}
+		if !lastUsed.Before(before) {
+			continue
+		}
+
+		if err := s.filesAPI.Remove(s.Path(hash)); err != nil {
+			return deleted, err
+		}
+		delete(s.lastUsed, hash)
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// Checksums returns the md5 and the hex encoded sha256 checksum of the data read from r.
+func Checksums(r io.Reader) (md5Sum []byte, sha256Sum string, err error) {
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(md5Hash, sha256Hash), r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to calculate checksums: %w", err)
+	}
+
+	return md5Hash.Sum(nil), hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
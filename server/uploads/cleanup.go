@@ -0,0 +1,33 @@
+package uploads
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openrport/openrport/share/logger"
+)
+
+type CleanupTask struct {
+	log       *logger.Logger
+	sessions  *SessionManager
+	retention time.Duration
+}
+
+// NewCleanupTask returns a task to delete expired upload sessions and uploaded files not used anymore
+func NewCleanupTask(log *logger.Logger, sessions *SessionManager, retention time.Duration) *CleanupTask {
+	return &CleanupTask{
+		log:       log,
+		sessions:  sessions,
+		retention: retention,
+	}
+}
+
+func (t *CleanupTask) Run(ctx context.Context) error {
+	sessions, blobs, err := t.sessions.Cleanup(t.retention)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup uploads: %v", err)
+	}
+	t.log.Debugf("uploads.CleanupTask: %d upload sessions and %d uploaded files deleted", sessions, blobs)
+	return nil
+}
@@ -0,0 +1,25 @@
+package uploads
+
+import (
+	"fmt"
+	"time"
+)
+
+const MinRetention = time.Hour
+
+type Config struct {
+	Retention time.Duration `mapstructure:"uploads_retention"`
+	// MaxSize limits the size of a resumable upload in bytes, 0 disables the limit
+	MaxSize int64 `mapstructure:"uploads_max_size"`
+}
+
+func (c *Config) Validate() error {
+	// 0 keeps uploads forever
+	if c.Retention != 0 && c.Retention < MinRetention {
+		return fmt.Errorf("invalid api.uploads_retention: %v, must be at least %v", c.Retention, MinRetention)
+	}
+	if c.MaxSize < 0 {
+		return fmt.Errorf("invalid api.uploads_max_size: %d, must not be negative", c.MaxSize)
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package uploads
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openrport/openrport/share/random"
+)
+
+const (
+	sessionsDir = "sessions"
+	partExt     = ".part"
+	metaExt     = ".json"
+)
+
+var (
+	ErrOffsetMismatch = errors.New("upload offset doesn't match the current offset of the upload")
+	ErrSizeExceeded   = errors.New("uploaded data exceeds the upload length")
+	ErrCompleted      = errors.New("upload is already completed")
+	ErrBusy           = errors.New("upload is in progress by another request")
+
+	validID = regexp.MustCompile(`^[0-9a-f-]+$`)
+)
+
+// Session is a resumable upload, the data is appended in chunks until the declared size is reached.
+// Then the file is moved to the blob store.
+type Session struct {
+	ID          string     `json:"id"`
+	Size        int64      `json:"size"`
+	Offset      int64      `json:"offset"`
+	Filename    string     `json:"filename"`
+	Owner       string     `json:"owner"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	SHA256      string     `json:"sha256,omitempty"`
+	MD5         string     `json:"md5,omitempty"`
+}
+
+func (s *Session) Completed() bool {
+	return s.CompletedAt != nil
+}
+
+// Md5Checksum returns the md5 checksum of a completed upload.
+func (s *Session) Md5Checksum() []byte {
+	sum, _ := hex.DecodeString(s.MD5)
+	return sum
+}
+
+// SessionManager stores resumable uploads in <dir>/sessions, the data received so far in <id>.part and the
+// session in <id>.json. The offset of a session is the size of its part file, so data received before a
+// connection was interrupted is kept.
+type SessionManager struct {
+	dir   string
+	blobs *BlobStore
+
+	mu     sync.Mutex
+	active map[string]bool
+}
+
+func NewSessionManager(uploadDir string, blobs *BlobStore) (*SessionManager, error) {
+	dir := filepath.Join(uploadDir, sessionsDir)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload sessions dir: %w", err)
+	}
+
+	return &SessionManager{
+		dir:    dir,
+		blobs:  blobs,
+		active: make(map[string]bool),
+	}, nil
+}
+
+// Create starts a new upload session of the given size.
+func (m *SessionManager) Create(size int64, filename, owner string) (*Session, error) {
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s := &Session{
+		ID:        id,
+		Size:      size,
+		Filename:  filename,
+		Owner:     owner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	f, err := os.OpenFile(m.partPath(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload: %w", err)
+	}
+	f.Close()
+
+	err = m.save(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if size == 0 {
+		return s, m.complete(s)
+	}
+
+	return s, nil
+}
+
+// Get returns the session with the given ID, nil if it doesn't exist.
+func (m *SessionManager) Get(id string) (*Session, error) {
+	if !validID.MatchString(id) {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(m.metaPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	s := &Session{}
+	err = json.Unmarshal(b, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload session %s: %w", id, err)
+	}
+
+	if s.Completed() {
+		s.Offset = s.Size
+		return s, nil
+	}
+
+	fi, err := os.Stat(m.partPath(id))
+	if err != nil {
+		return nil, err
+	}
+	s.Offset = fi.Size()
+
+	return s, nil
+}
+
+// Write appends the data read from r to the session, offset must be the current offset of the session.
+// The data read until an error occurred is kept, so the upload can be resumed from the new offset.
+// When the declared size is reached, the upload is completed.
+func (m *SessionManager) Write(s *Session, offset int64, r io.Reader) (*Session, error) {
+	if !m.lock(s.ID) {
+		return s, ErrBusy
+	}
+	defer m.unlock(s.ID)
+
+	// get the current state again while it's locked
+	s, err := m.Get(s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, os.ErrNotExist
+	}
+	if s.Completed() {
+		return s, ErrCompleted
+	}
+	if offset != s.Offset {
+		return s, ErrOffsetMismatch
+	}
+
+	f, err := os.OpenFile(m.partPath(s.ID), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return s, err
+	}
+	written, copyErr := io.Copy(f, io.LimitReader(r, s.Size-s.Offset))
+	closeErr := f.Close()
+	s.Offset += written
+	s.UpdatedAt = time.Now()
+
+	if copyErr != nil {
+		return s, copyErr
+	}
+	if closeErr != nil {
+		return s, closeErr
+	}
+
+	if s.Offset < s.Size {
+		return s, m.save(s)
+	}
+
+	if n, _ := r.Read(make([]byte, 1)); n > 0 {
+		return s, ErrSizeExceeded
+	}
+
+	return s, m.complete(s)
+}
+
+// complete moves the uploaded file to the blob store.
+func (m *SessionManager) complete(s *Session) error {
+	f, err := os.Open(m.partPath(s.ID))
+	if err != nil {
+		return err
+	}
+	md5Sum, sha256Sum, err := Checksums(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	_, err = m.blobs.Put(m.partPath(s.ID), sha256Sum)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	s.MD5 = hex.EncodeToString(md5Sum)
+	s.SHA256 = sha256Sum
+	s.CompletedAt = &now
+	s.UpdatedAt = now
+	s.Offset = s.Size
+
+	return m.save(s)
+}
+
+// Delete deletes the session and the data uploaded so far. The blob of a completed upload is kept until
+// it's deleted by the cleanup.
+func (m *SessionManager) Delete(id string) error {
+	if !validID.MatchString(id) {
+		return os.ErrNotExist
+	}
+
+	err := os.Remove(m.partPath(id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return os.Remove(m.metaPath(id))
+}
+
+// Cleanup deletes sessions and unused blobs not updated within the given retention.
+// Blobs of the remaining completed sessions are kept.
+func (m *SessionManager) Cleanup(retention time.Duration) (sessions int, blobs int, err error) {
+	before := time.Now().Add(-retention)
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	keep := make(map[string]bool)
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), metaExt)
+		if id == e.Name() {
+			continue
+		}
+
+		s, err := m.Get(id)
+		if err != nil || s == nil {
+			continue
+		}
+		if s.UpdatedAt.Before(before) && !m.isActive(id) {
+			if err := m.Delete(id); err != nil {
+				return sessions, 0, err
+			}
+			sessions++
+			continue
+		}
+		if s.Completed() {
+			keep[s.SHA256] = true
+		}
+	}
+
+	blobs, err = m.blobs.DeleteUnused(before, keep)
+	return sessions, blobs, err
+}
+
+func (m *SessionManager) save(s *Session) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := m.metaPath(s.ID) + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to save upload session: %w", err)
+	}
+
+	return os.Rename(tmp, m.metaPath(s.ID))
+}
+
+func (m *SessionManager) lock(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.active[id] {
+		return false
+	}
+	m.active[id] = true
+	return true
+}
+
+func (m *SessionManager) unlock(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.active, id)
+}
+
+func (m *SessionManager) isActive(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.active[id]
+}
+
+func (m *SessionManager) partPath(id string) string {
+	return filepath.Join(m.dir, id+partExt)
+}
+
+func (m *SessionManager) metaPath(id string) string {
+	return filepath.Join(m.dir, id+metaExt)
+}
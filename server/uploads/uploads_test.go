@@ -0,0 +1,155 @@
+package uploads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/share/files"
+)
+
+const (
+	someContent       = "some content"
+	someContentSHA256 = "290f493c44f5d63d06b374d0a5abd292fae38b92cab2fae5efefe1b0e9347f56"
+	someContentMD5    = "9893532233caff98cd083a116b013c0b"
+)
+
+func newTestSessionManager(t *testing.T) (*SessionManager, *BlobStore, string) {
+	dir := t.TempDir()
+	blobs := NewBlobStore(files.NewFileSystem(), dir)
+	m, err := NewSessionManager(dir, blobs)
+	require.NoError(t, err)
+	return m, blobs, dir
+}
+
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestSessionWriteAndResume(t *testing.T) {
+	m, blobs, _ := newTestSessionManager(t)
+
+	s, err := m.Create(int64(len(someContent)), "file.txt", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), s.Offset)
+
+	// the data received before the connection was interrupted is kept
+	s, err = m.Write(s, 0, &failingReader{data: "some "})
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, int64(5), s.Offset)
+
+	s, err = m.Get(s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), s.Offset)
+	assert.False(t, s.Completed())
+
+	_, err = m.Write(s, 0, strings.NewReader(someContent))
+	assert.ErrorIs(t, err, ErrOffsetMismatch)
+
+	s, err = m.Write(s, 5, strings.NewReader("content"))
+	require.NoError(t, err)
+	assert.True(t, s.Completed())
+	assert.Equal(t, someContentSHA256, s.SHA256)
+	assert.Equal(t, someContentMD5, s.MD5)
+
+	data, err := os.ReadFile(blobs.Path(someContentSHA256))
+	require.NoError(t, err)
+	assert.Equal(t, someContent, string(data))
+
+	_, err = m.Write(s, s.Offset, strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrCompleted)
+}
+
+func TestSessionWriteSizeExceeded(t *testing.T) {
+	m, _, _ := newTestSessionManager(t)
+
+	s, err := m.Create(4, "", "admin")
+	require.NoError(t, err)
+
+	s, err = m.Write(s, 0, strings.NewReader(someContent))
+	assert.ErrorIs(t, err, ErrSizeExceeded)
+	assert.Equal(t, int64(4), s.Offset)
+}
+
+func TestBlobStoreDeduplicates(t *testing.T) {
+	dir := t.TempDir()
+	blobs := NewBlobStore(files.NewFileSystem(), dir)
+
+	for _, name := range []string{"first", "second"} {
+		src := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(src, []byte(someContent), 0600))
+
+		p, err := blobs.Put(src, someContentSHA256)
+		require.NoError(t, err)
+		assert.Equal(t, blobs.Path(someContentSHA256), p)
+		assert.NoFileExists(t, src)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, blobsDir))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = blobs.Put(filepath.Join(dir, "third"), "../../etc/passwd")
+	assert.EqualError(t, err, `invalid blob hash "../../etc/passwd"`)
+}
+
+func TestCleanup(t *testing.T) {
+	m, blobs, dir := newTestSessionManager(t)
+
+	completed, err := m.Create(int64(len(someContent)), "", "admin")
+	require.NoError(t, err)
+	_, err = m.Write(completed, 0, strings.NewReader(someContent))
+	require.NoError(t, err)
+
+	expired, err := m.Create(10, "", "admin")
+	require.NoError(t, err)
+	expired.UpdatedAt = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, m.save(expired))
+
+	inUse := filepath.Join(dir, "in-use")
+	require.NoError(t, os.WriteFile(inUse, []byte("in use"), 0600))
+	inUseHash := strings.Repeat("a", 64)
+	_, err = blobs.Put(inUse, inUseHash)
+	require.NoError(t, err)
+	blobs.Acquire(inUseHash)
+
+	unused := filepath.Join(dir, "unused")
+	require.NoError(t, os.WriteFile(unused, []byte("unused"), 0600))
+	unusedHash := strings.Repeat("b", 64)
+	_, err = blobs.Put(unused, unusedHash)
+	require.NoError(t, err)
+	blobs.lastUsed[unusedHash] = time.Now().Add(-2 * time.Hour)
+	// the blob of a completed session is kept as long as the session
+	blobs.lastUsed[someContentSHA256] = time.Now().Add(-2 * time.Hour)
+
+	sessions, deletedBlobs, err := m.Cleanup(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sessions)
+	assert.Equal(t, 1, deletedBlobs)
+
+	s, err := m.Get(expired.ID)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+	s, err = m.Get(completed.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, s)
+
+	assert.FileExists(t, blobs.Path(someContentSHA256))
+	assert.FileExists(t, blobs.Path(inUseHash))
+	assert.NoFileExists(t, blobs.Path(unusedHash))
+}
@@ -53,7 +53,7 @@ func ResolveIdleTunnelTimeoutValue(idleTimeoutMinutesStr string, skipIdleTimeout
 }
 
 func SchemeSupportsHTTPProxy(schemeStr string) bool {
-	return schemeStr == "http" || schemeStr == "https" || schemeStr == "vnc" || schemeStr == "rdp"
+	return schemeStr == "http" || schemeStr == "https" || schemeStr == "vnc" || schemeStr == "rdp" || schemeStr == "ssh"
 }
 
 const (
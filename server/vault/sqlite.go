@@ -15,6 +15,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"github.com/openrport/openrport/db/backend"
 	"github.com/openrport/openrport/db/migration/vaults"
 
 	"github.com/openrport/openrport/db/sqlite"
@@ -26,6 +27,13 @@ var ErrDatabaseNotInitialised = errors2.APIError{
 }
 var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
 
+var Store = backend.Store{
+	Name:           "vaults",
+	SQLiteFilename: "vault.sqlite.db",
+	AssetNames:     vaults.AssetNames(),
+	Asset:          vaults.Asset,
+}
+
 type SqliteProvider struct {
 	db        *sqlx.DB
 	logger    *logger.Logger
@@ -42,11 +50,16 @@ func NewSqliteProvider(c Config, logger *logger.Logger) (*SqliteProvider, error)
 
 	logger.Infof("initialized database at %s", dbPath)
 
+	return NewProvider(db, logger), nil
+}
+
+// NewProvider returns a provider using an already migrated DB.
+func NewProvider(db *sqlx.DB, logger *logger.Logger) *SqliteProvider {
 	return &SqliteProvider{
 		logger:    logger,
 		db:        db,
 		converter: query.NewSQLConverter(db.DriverName()),
-	}, nil
+	}
 }
 
 func (p *SqliteProvider) Close() error {
@@ -166,20 +179,29 @@ func (p *SqliteProvider) FindByKeyAndClientID(ctx context.Context, key, clientID
 
 func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64, val *InputValue, nowDate time.Time) (int64, error) {
 	if idToUpdate == 0 {
-		res, err := p.db.ExecContext(
-			ctx,
-			"INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		q := "INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+		params := []interface{}{
 			val.ClientID,
 			val.RequiredGroup,
-			nowDate.Format(time.RFC3339),
+			p.dbTime(nowDate),
 			user,
-			nowDate.Format(time.RFC3339),
+			p.dbTime(nowDate),
 			user,
 			val.Key,
 			val.Value,
 			val.Type,
-		)
+		}
+
+		if p.db.DriverName() == backend.DriverPostgres {
+			// postgres doesn't support LastInsertId
+			err := p.db.GetContext(ctx, &idToUpdate, q+" RETURNING id", params...)
+			if err != nil {
+				return 0, err
+			}
+			return idToUpdate, nil
+		}
 
+		res, err := p.db.ExecContext(ctx, q, params...)
 		if err != nil {
 			return 0, err
 		}
@@ -192,7 +214,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 		params := []interface{}{
 			val.ClientID,
 			val.RequiredGroup,
-			nowDate.Format(time.RFC3339),
+			p.dbTime(nowDate),
 			user,
 			val.Key,
 			val.Value,
@@ -227,6 +249,15 @@ func (p *SqliteProvider) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// dbTime returns the value stored for the given time. sqlite keeps the RFC3339 text for compatibility with existing
+// values, the DATETIME columns of other databases don't accept the zone suffix.
+func (p *SqliteProvider) dbTime(t time.Time) interface{} {
+	if p.db.DriverName() == backend.DriverSQLite {
+		return t.Format(time.RFC3339)
+	}
+	return t
+}
+
 func (p *SqliteProvider) handleRollback(tx *sqlx.Tx) {
 	err := tx.Rollback()
 	if err != nil {
@@ -0,0 +1,28 @@
+package webauthn
+
+import (
+	"context"
+	"time"
+)
+
+// Credential is a public key credential registered by a user as a second factor.
+type Credential struct {
+	// ID is the base64url encoded credential id
+	ID         string     `json:"id" db:"id"`
+	Username   string     `json:"-" db:"username"`
+	Name       string     `json:"name" db:"name"`
+	PublicKey  []byte     `json:"-" db:"public_key"`
+	SignCount  uint32     `json:"sign_count" db:"sign_count"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+type CredentialProvider interface {
+	GetAll(ctx context.Context, username string) ([]*Credential, error)
+	Get(ctx context.Context, username, id string) (*Credential, error)
+	Create(ctx context.Context, credential *Credential) error
+	UpdateUsage(ctx context.Context, credential *Credential) error
+	Delete(ctx context.Context, username, id string) error
+	DeleteAll(ctx context.Context, username string) error
+	Close() error
+}
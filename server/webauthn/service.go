@@ -0,0 +1,351 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	gowebauthn "github.com/go-webauthn/webauthn/webauthn"
+
+	errors2 "github.com/openrport/openrport/server/api/errors"
+)
+
+const (
+	ceremonyRegistration = "registration"
+	ceremonyAssertion    = "assertion"
+)
+
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	Timeout time.Duration
+}
+
+// Service registers WebAuthn credentials and verifies assertions of them with go-webauthn. Sessions are kept in
+// memory until the ceremony is finished or the timeout is exceeded, there is only a single pending ceremony of each
+// kind per user.
+type Service struct {
+	webAuthn *gowebauthn.WebAuthn
+	provider CredentialProvider
+	timeout  time.Duration
+	now      func() time.Time
+
+	sessions map[string]*session
+	mu       sync.Mutex
+}
+
+type session struct {
+	data   *gowebauthn.SessionData
+	expiry time.Time
+}
+
+func NewService(config Config, provider CredentialProvider) (*Service, error) {
+	timeout := gowebauthn.TimeoutConfig{
+		Timeout:    config.Timeout,
+		TimeoutUVD: config.Timeout,
+	}
+	webAuthn, err := gowebauthn.New(&gowebauthn.Config{
+		RPID:                  config.RPID,
+		RPDisplayName:         config.RPName,
+		RPOrigins:             config.Origins,
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementDiscouraged,
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeouts: gowebauthn.TimeoutsConfig{
+			Login:        timeout,
+			Registration: timeout,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		webAuthn: webAuthn,
+		provider: provider,
+		timeout:  config.Timeout,
+		now:      time.Now,
+		sessions: make(map[string]*session),
+	}, nil
+}
+
+// UserHandle returns the opaque user id of the given user, it doesn't reveal the username.
+func UserHandle(username string) []byte {
+	h := sha256.Sum256([]byte(username))
+	return h[:]
+}
+
+// user is a user with its stored credentials as required by go-webauthn.
+type user struct {
+	name        string
+	credentials []*Credential
+}
+
+func (u *user) WebAuthnID() []byte {
+	return UserHandle(u.name)
+}
+
+func (u *user) WebAuthnName() string {
+	return u.name
+}
+
+func (u *user) WebAuthnDisplayName() string {
+	return u.name
+}
+
+func (u *user) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *user) WebAuthnCredentials() []gowebauthn.Credential {
+	var res []gowebauthn.Credential
+	for _, c := range u.credentials {
+		id, err := base64.RawURLEncoding.DecodeString(c.ID)
+		if err != nil {
+			continue
+		}
+		res = append(res, gowebauthn.Credential{
+			ID:            id,
+			PublicKey:     c.PublicKey,
+			Authenticator: gowebauthn.Authenticator{SignCount: c.SignCount},
+		})
+	}
+	return res
+}
+
+func (u *user) descriptors() []protocol.CredentialDescriptor {
+	res := []protocol.CredentialDescriptor{}
+	for _, c := range u.WebAuthnCredentials() {
+		res = append(res, c.Descriptor())
+	}
+	return res
+}
+
+func (u *user) credential(id string) *Credential {
+	for _, c := range u.credentials {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Service) getUser(ctx context.Context, username string) (*user, error) {
+	credentials, err := s.provider.GetAll(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return &user{name: username, credentials: credentials}, nil
+}
+
+func (s *Service) BeginRegistration(ctx context.Context, username string) (*protocol.CredentialCreation, error) {
+	u, err := s.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	options, data, err := s.webAuthn.BeginRegistration(u, gowebauthn.WithExclusions(u.descriptors()))
+	if err != nil {
+		return nil, err
+	}
+	s.putSession(ceremonyRegistration, username, data)
+	return options, nil
+}
+
+// FinishRegistration verifies the response of the authenticator and stores the new credential.
+func (s *Service) FinishRegistration(ctx context.Context, username, name string, resp *protocol.CredentialCreationResponse) (*Credential, error) {
+	data := s.takeSession(ceremonyRegistration, username)
+	if data == nil {
+		return nil, errors2.APIError{
+			Message:    "registration not found or expired, please start a new registration",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	parsed, err := resp.Parse()
+	if err != nil {
+		return nil, invalidRegistration(err)
+	}
+	u, err := s.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	created, err := s.webAuthn.CreateCredential(u, *data, parsed)
+	if err != nil {
+		return nil, invalidRegistration(err)
+	}
+
+	id := protocol.URLEncodedBase64(created.ID).String()
+	existing, err := s.provider.Get(ctx, username, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors2.APIError{
+			Message:    "credential is already registered",
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	if name == "" {
+		name = "Security key"
+	}
+	credential := &Credential{
+		ID:        id,
+		Username:  username,
+		Name:      name,
+		PublicKey: created.PublicKey,
+		SignCount: created.Authenticator.SignCount,
+		CreatedAt: s.now(),
+	}
+	if err := s.provider.Create(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// BeginAssertion starts the verification of a credential of the user, it fails if the user has no credentials.
+func (s *Service) BeginAssertion(ctx context.Context, username string) (*protocol.CredentialAssertion, error) {
+	u, err := s.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.credentials) == 0 {
+		return nil, errors2.APIError{
+			Message:    "no webauthn credentials registered for this user",
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	options, data, err := s.webAuthn.BeginLogin(u)
+	if err != nil {
+		return nil, err
+	}
+	s.putSession(ceremonyAssertion, username, data)
+	return options, nil
+}
+
+// FinishAssertion verifies the signature of a credential of the user and updates its usage.
+func (s *Service) FinishAssertion(ctx context.Context, username string, resp *protocol.CredentialAssertionResponse) (*Credential, error) {
+	data := s.takeSession(ceremonyAssertion, username)
+	if data == nil {
+		return nil, errors2.APIError{
+			Message:    "webauthn assertion not found or expired",
+			HTTPStatus: http.StatusUnauthorized,
+		}
+	}
+
+	parsed, err := resp.Parse()
+	if err != nil {
+		return nil, invalidAssertion(err)
+	}
+	u, err := s.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	validated, err := s.webAuthn.ValidateLogin(u, *data, parsed)
+	if err != nil {
+		return nil, invalidAssertion(err)
+	}
+	// authenticators without a counter always report zero, otherwise the counter must increase with every assertion
+	if validated.Authenticator.CloneWarning {
+		return nil, invalidAssertion(errors.New("signature counter didn't increase, the authenticator might be cloned"))
+	}
+
+	credential := u.credential(protocol.URLEncodedBase64(validated.ID).String())
+	if credential == nil {
+		return nil, invalidAssertion(errors.New("unknown credential"))
+	}
+	now := s.now()
+	credential.SignCount = validated.Authenticator.SignCount
+	credential.LastUsedAt = &now
+	if err := s.provider.UpdateUsage(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *Service) ListCredentials(ctx context.Context, username string) ([]*Credential, error) {
+	return s.provider.GetAll(ctx, username)
+}
+
+func (s *Service) HasCredentials(ctx context.Context, username string) (bool, error) {
+	credentials, err := s.provider.GetAll(ctx, username)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
+
+func (s *Service) DeleteCredential(ctx context.Context, username, id string) error {
+	existing, err := s.provider.Get(ctx, username, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors2.APIError{
+			Message:    "credential not found",
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return s.provider.Delete(ctx, username, id)
+}
+
+func (s *Service) DeleteAllCredentials(ctx context.Context, username string) error {
+	return s.provider.DeleteAll(ctx, username)
+}
+
+func (s *Service) Close() error {
+	return s.provider.Close()
+}
+
+func (s *Service) putSession(ceremony, username string, data *gowebauthn.SessionData) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, c := range s.sessions {
+		if now.After(c.expiry) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[ceremony+":"+username] = &session{
+		data:   data,
+		expiry: now.Add(s.timeout),
+	}
+}
+
+// takeSession returns the pending session of the user and removes it, so its challenge can only be answered once.
+func (s *Service) takeSession(ceremony, username string) *gowebauthn.SessionData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := ceremony + ":" + username
+	c := s.sessions[key]
+	delete(s.sessions, key)
+	if c == nil || s.now().After(c.expiry) {
+		return nil
+	}
+	return c.data
+}
+
+func invalidRegistration(err error) error {
+	return errors2.APIError{
+		Message:    "invalid webauthn registration: " + err.Error(),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func invalidAssertion(err error) error {
+	return errors2.APIError{
+		Message:    "invalid webauthn assertion: " + err.Error(),
+		HTTPStatus: http.StatusUnauthorized,
+	}
+}
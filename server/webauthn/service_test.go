@@ -0,0 +1,411 @@
+package webauthn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openrport/openrport/db/migration/webauthn"
+	"github.com/openrport/openrport/db/sqlite"
+	errors2 "github.com/openrport/openrport/server/api/errors"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+const (
+	testRPID   = "rport.example.com"
+	testOrigin = "https://rport.example.com"
+)
+
+// testAuthenticator is a software authenticator with a single ES256 credential.
+type testAuthenticator struct {
+	t         *testing.T
+	id        []byte
+	key       *ecdsa.PrivateKey
+	signCount uint32
+	format    string
+	rpID      string
+	origin    string
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	id := make([]byte, 16)
+	_, err = rand.Read(id)
+	require.NoError(t, err)
+	return &testAuthenticator{
+		t:      t,
+		id:     id,
+		key:    key,
+		format: "none",
+		rpID:   testRPID,
+		origin: testOrigin,
+	}
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	return a.encode(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+func (a *testAuthenticator) encode(v interface{}) []byte {
+	data, err := webauthncbor.Marshal(v)
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *testAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(protocol.FlagUserPresent)
+	if attested {
+		flags |= byte(protocol.FlagAttestedCredentialData)
+	}
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // aaguid
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func (a *testAuthenticator) clientData(ceremony protocol.CeremonyType, challenge []byte) []byte {
+	data, err := json.Marshal(protocol.CollectedClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		Origin:    a.origin,
+	})
+	require.NoError(a.t, err)
+	return data
+}
+
+func (a *testAuthenticator) sign(authData, clientDataJSON []byte) []byte {
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(a.t, err)
+	return sig
+}
+
+func (a *testAuthenticator) credential() protocol.PublicKeyCredential {
+	return protocol.PublicKeyCredential{
+		Credential: protocol.Credential{
+			ID:   base64.RawURLEncoding.EncodeToString(a.id),
+			Type: string(protocol.PublicKeyCredentialType),
+		},
+		RawID: a.id,
+	}
+}
+
+func (a *testAuthenticator) create(options *protocol.CredentialCreation) *protocol.CredentialCreationResponse {
+	resp := &protocol.CredentialCreationResponse{PublicKeyCredential: a.credential()}
+	resp.AttestationResponse.ClientDataJSON = a.clientData(protocol.CreateCeremony, options.Response.Challenge)
+	authData := a.authData(true)
+
+	stmt := map[string]interface{}{}
+	if a.format == "packed" {
+		stmt["alg"] = int64(webauthncose.AlgES256)
+		stmt["sig"] = a.sign(authData, resp.AttestationResponse.ClientDataJSON)
+	}
+	resp.AttestationResponse.AttestationObject = a.encode(map[string]interface{}{
+		"fmt":      a.format,
+		"attStmt":  stmt,
+		"authData": authData,
+	})
+	return resp
+}
+
+func (a *testAuthenticator) get(options *protocol.CredentialAssertion) *protocol.CredentialAssertionResponse {
+	a.signCount++
+	resp := &protocol.CredentialAssertionResponse{PublicKeyCredential: a.credential()}
+	resp.AssertionResponse.ClientDataJSON = a.clientData(protocol.AssertCeremony, options.Response.Challenge)
+	resp.AssertionResponse.AuthenticatorData = a.authData(false)
+	resp.AssertionResponse.Signature = a.sign(resp.AssertionResponse.AuthenticatorData, resp.AssertionResponse.ClientDataJSON)
+	resp.AssertionResponse.UserHandle = UserHandle("user1")
+	return resp
+}
+
+func newTestService(t *testing.T) *Service {
+	db, err := sqlite.New(":memory:", webauthn.AssetNames(), webauthn.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	s, err := NewService(Config{
+		RPID:    testRPID,
+		RPName:  "RPort",
+		Origins: []string{testOrigin},
+		Timeout: time.Minute,
+	}, NewSqliteProvider(db))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+func register(t *testing.T, s *Service, a *testAuthenticator) *Credential {
+	ctx := context.Background()
+	options, err := s.BeginRegistration(ctx, "user1")
+	require.NoError(t, err)
+	credential, err := s.FinishRegistration(ctx, "user1", "my key", a.create(options))
+	require.NoError(t, err)
+	return credential
+}
+
+func assertAPIError(t *testing.T, err error, status int, message string) {
+	var apiErr errors2.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, status, apiErr.HTTPStatus)
+	assert.Equal(t, message, apiErr.Message)
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	for _, format := range []string{"none", "packed"} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			ctx := context.Background()
+			s := newTestService(t)
+			a := newTestAuthenticator(t)
+			a.format = format
+
+			options, err := s.BeginRegistration(ctx, "user1")
+			require.NoError(t, err)
+			assert.Equal(t, testRPID, options.Response.RelyingParty.ID)
+			assert.Equal(t, protocol.URLEncodedBase64(UserHandle("user1")), options.Response.User.ID)
+			assert.Equal(t, 60000, options.Response.Timeout)
+			assert.Equal(t, protocol.PreferNoAttestation, options.Response.Attestation)
+			assert.Empty(t, options.Response.CredentialExcludeList)
+
+			credential, err := s.FinishRegistration(ctx, "user1", "", a.create(options))
+			require.NoError(t, err)
+			assert.Equal(t, base64.RawURLEncoding.EncodeToString(a.id), credential.ID)
+			assert.Equal(t, "Security key", credential.Name)
+
+			hasCredentials, err := s.HasCredentials(ctx, "user1")
+			require.NoError(t, err)
+			assert.True(t, hasCredentials)
+
+			requestOptions, err := s.BeginAssertion(ctx, "user1")
+			require.NoError(t, err)
+			require.Len(t, requestOptions.Response.AllowedCredentials, 1)
+			assert.Equal(t, protocol.URLEncodedBase64(a.id), requestOptions.Response.AllowedCredentials[0].CredentialID)
+
+			used, err := s.FinishAssertion(ctx, "user1", a.get(requestOptions))
+			require.NoError(t, err)
+			assert.Equal(t, uint32(1), used.SignCount)
+			assert.NotNil(t, used.LastUsedAt)
+
+			stored, err := s.ListCredentials(ctx, "user1")
+			require.NoError(t, err)
+			require.Len(t, stored, 1)
+			assert.Equal(t, uint32(1), stored[0].SignCount)
+			assert.NotNil(t, stored[0].LastUsedAt)
+		})
+	}
+}
+
+func TestRegistrationErrors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		Name            string
+		Modify          func(*testAuthenticator, *protocol.CredentialCreation)
+		ExpectedStatus  int
+		ExpectedMessage string
+	}{
+		{
+			Name:            "wrong origin",
+			Modify:          func(a *testAuthenticator, _ *protocol.CredentialCreation) { a.origin = "https://evil.example.com" },
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedMessage: "invalid webauthn registration: Error validating origin",
+		},
+		{
+			Name:            "wrong relying party",
+			Modify:          func(a *testAuthenticator, _ *protocol.CredentialCreation) { a.rpID = "evil.example.com" },
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedMessage: "invalid webauthn registration: Error validating the authenticator response",
+		},
+		{
+			Name:            "wrong challenge",
+			Modify:          func(_ *testAuthenticator, o *protocol.CredentialCreation) { o.Response.Challenge = []byte("other") },
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedMessage: "invalid webauthn registration: Error validating challenge",
+		},
+		{
+			Name:            "unsupported attestation",
+			Modify:          func(a *testAuthenticator, _ *protocol.CredentialCreation) { a.format = "unknown" },
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedMessage: "invalid webauthn registration: Invalid attestation format",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			s := newTestService(t)
+			a := newTestAuthenticator(t)
+			options, err := s.BeginRegistration(ctx, "user1")
+			require.NoError(t, err)
+			tc.Modify(a, options)
+
+			_, err = s.FinishRegistration(ctx, "user1", "my key", a.create(options))
+
+			assertAPIError(t, err, tc.ExpectedStatus, tc.ExpectedMessage)
+		})
+	}
+
+	t.Run("without registration", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		options, err := s.BeginRegistration(ctx, "user2")
+		require.NoError(t, err)
+
+		_, err = s.FinishRegistration(ctx, "user1", "my key", a.create(options))
+
+		assertAPIError(t, err, http.StatusBadRequest, "registration not found or expired, please start a new registration")
+	})
+
+	t.Run("already registered", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+
+		options, err := s.BeginRegistration(ctx, "user1")
+		require.NoError(t, err)
+		require.Len(t, options.Response.CredentialExcludeList, 1)
+		assert.Equal(t, protocol.URLEncodedBase64(a.id), options.Response.CredentialExcludeList[0].CredentialID)
+		_, err = s.FinishRegistration(ctx, "user1", "my key", a.create(options))
+
+		assertAPIError(t, err, http.StatusConflict, "credential is already registered")
+	})
+}
+
+func TestAssertionErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no credentials", func(t *testing.T) {
+		s := newTestService(t)
+
+		_, err := s.BeginAssertion(ctx, "user1")
+
+		assertAPIError(t, err, http.StatusConflict, "no webauthn credentials registered for this user")
+	})
+
+	t.Run("replayed", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+		options, err := s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+		resp := a.get(options)
+		_, err = s.FinishAssertion(ctx, "user1", resp)
+		require.NoError(t, err)
+
+		_, err = s.FinishAssertion(ctx, "user1", resp)
+
+		assertAPIError(t, err, http.StatusUnauthorized, "webauthn assertion not found or expired")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+		options, err := s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+		s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+		_, err = s.FinishAssertion(ctx, "user1", a.get(options))
+
+		assertAPIError(t, err, http.StatusUnauthorized, "webauthn assertion not found or expired")
+	})
+
+	t.Run("credential of other user", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+		other := newTestAuthenticator(t)
+		options, err := s.BeginRegistration(ctx, "user2")
+		require.NoError(t, err)
+		_, err = s.FinishRegistration(ctx, "user2", "", other.create(options))
+		require.NoError(t, err)
+		requestOptions, err := s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+
+		_, err = s.FinishAssertion(ctx, "user1", other.get(requestOptions))
+
+		assertAPIError(t, err, http.StatusUnauthorized, "invalid webauthn assertion: User does not own the credential returned")
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+		options, err := s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+		resp := a.get(options)
+		resp.AssertionResponse.AuthenticatorData[36]++
+
+		_, err = s.FinishAssertion(ctx, "user1", resp)
+
+		assertAPIError(t, err, http.StatusUnauthorized, "invalid webauthn assertion: Error validating the assertion signature: <nil>")
+	})
+
+	t.Run("sign count not increased", func(t *testing.T) {
+		s := newTestService(t)
+		a := newTestAuthenticator(t)
+		register(t, s, a)
+		options, err := s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+		_, err = s.FinishAssertion(ctx, "user1", a.get(options))
+		require.NoError(t, err)
+
+		options, err = s.BeginAssertion(ctx, "user1")
+		require.NoError(t, err)
+		a.signCount--
+		_, err = s.FinishAssertion(ctx, "user1", a.get(options))
+
+		assertAPIError(t, err, http.StatusUnauthorized, "invalid webauthn assertion: signature counter didn't increase, the authenticator might be cloned")
+	})
+}
+
+func TestDeleteCredentials(t *testing.T) {
+	ctx := context.Background()
+	s := newTestService(t)
+	first := register(t, s, newTestAuthenticator(t))
+	register(t, s, newTestAuthenticator(t))
+
+	err := s.DeleteCredential(ctx, "user1", "unknown")
+	assertAPIError(t, err, http.StatusNotFound, "credential not found")
+
+	require.NoError(t, s.DeleteCredential(ctx, "user1", first.ID))
+	credentials, err := s.ListCredentials(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, credentials, 1)
+
+	require.NoError(t, s.DeleteAllCredentials(ctx, "user1"))
+	hasCredentials, err := s.HasCredentials(ctx, "user1")
+	require.NoError(t, err)
+	assert.False(t, hasCredentials)
+}
@@ -0,0 +1,83 @@
+package webauthn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+func (p *SqliteProvider) GetAll(ctx context.Context, username string) ([]*Credential, error) {
+	res := []*Credential{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM webauthn_credentials WHERE username = ? ORDER BY created_at", username)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get webauthn credentials from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *SqliteProvider) Get(ctx context.Context, username, id string) (*Credential, error) {
+	res := &Credential{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM webauthn_credentials WHERE username = ? AND id = ?", username, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get webauthn credential from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *SqliteProvider) Create(ctx context.Context, credential *Credential) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT INTO webauthn_credentials (id, username, name, public_key, sign_count, created_at) VALUES (:id, :username, :name, :public_key, :sign_count, :created_at)",
+		credential,
+	)
+	return err
+}
+
+func (p *SqliteProvider) UpdateUsage(ctx context.Context, credential *Credential) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"UPDATE webauthn_credentials SET sign_count = :sign_count, last_used_at = :last_used_at WHERE username = :username AND id = :id",
+		credential,
+	)
+	return err
+}
+
+func (p *SqliteProvider) Delete(ctx context.Context, username, id string) error {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM webauthn_credentials WHERE username = ? AND id = ?", username, id)
+	if err != nil {
+		return err
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affectedRows == 0 {
+		return fmt.Errorf("cannot find webauthn credential by id %s", id)
+	}
+	return nil
+}
+
+func (p *SqliteProvider) DeleteAll(ctx context.Context, username string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM webauthn_credentials WHERE username = ?", username)
+	return err
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
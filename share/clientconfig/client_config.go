@@ -1,6 +1,7 @@
 package clientconfig
 
 import (
+	"crypto/ed25519"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -20,6 +21,8 @@ type Config struct {
 	Tunnels                  TunnelsConfig       `json:"-"`
 	InterpreterAliasesConfig map[string]any      `json:"-" mapstructure:"interpreter-aliases"`
 	FileReceptionConfig      FileReceptionConfig `json:"file_reception" mapstructure:"file-reception"`
+	FileAccessConfig         FileAccessConfig    `json:"file_access" mapstructure:"file-access"`
+	ClientUpdateConfig       ClientUpdateConfig  `json:"client_update" mapstructure:"client-update"`
 
 	InterpreterAliases          map[string]string                   `json:"interpreter_aliases"`
 	InterpreterAliasesEncodings map[string]InterpreterAliasEncoding `json:"interpreter_aliases_encodings"`
@@ -80,6 +83,7 @@ type LogConfig struct {
 type CommandsConfig struct {
 	Enabled       bool      `json:"enabled" mapstructure:"enabled"`
 	SendBackLimit int       `json:"send_back_limit" mapstructure:"send_back_limit"`
+	StreamLimit   int       `json:"stream_limit" mapstructure:"stream_limit"`
 	Allow         []string  `json:"allow" mapstructure:"allow"`
 	Deny          []string  `json:"deny" mapstructure:"deny"`
 	Order         [2]string `json:"order" mapstructure:"order"`
@@ -114,6 +118,22 @@ type FileReceptionConfig struct {
 	Enabled   bool     `json:"enabled" mapstructure:"enabled"`
 }
 
+// FileAccessConfig restricts which files the server can list and read on the client.
+type FileAccessConfig struct {
+	Enabled   bool     `json:"enabled" mapstructure:"enabled"`
+	Allow     []string `json:"allow" mapstructure:"allow"`
+	Protected []string `json:"protected" mapstructure:"protected"`
+}
+
+// ClientUpdateConfig allows the server to replace the client binary by a newer version signed with the private
+// key matching PublicKey.
+type ClientUpdateConfig struct {
+	Enabled   bool   `json:"enabled" mapstructure:"enabled"`
+	PublicKey string `json:"public_key" mapstructure:"public_key"`
+
+	ParsedPublicKey ed25519.PublicKey `json:"-"`
+}
+
 type InterpreterAliasEncoding struct {
 	InputEncoding  string `json:"input_encoding"`
 	OutputEncoding string `json:"output_encoding"`
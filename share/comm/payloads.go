@@ -10,9 +10,13 @@ const (
 	// RequestTypeCheckPort request types sent by server to clients
 	RequestTypeCheckPort            = "check_port"
 	RequestTypeRunCmd               = "run_cmd"
+	RequestTypeCancelCmd            = "cancel_cmd"
 	RequestTypeRefreshUpdatesStatus = "refresh_updates_status"
 	RequestTypePutCapabilities      = "put_capabilities"
 	RequestTypeCheckTunnelAllowed   = "check_tunnel_allowed"
+	RequestTypeClientUpdate         = "client_update"
+	RequestTypeStartReverseTunnel   = "start_reverse_tunnel"
+	RequestTypeStopReverseTunnel    = "stop_reverse_tunnel"
 
 	RequestTypeUpdateClientAttributes = "update_client_metadata"
 
@@ -22,9 +26,23 @@ const (
 	RequestTypeSaveMeasurement = "save_measurement"
 	RequestTypeUpload          = "upload"
 	RequestTypeIPAddresses     = "ip_addresses"
+	RequestTypeUploadProgress  = "upload_progress"
 
 	// RequestTypePing request types understood on both sides, client and server
 	RequestTypePing = "ping"
+
+	// ChannelTypeShell is the type of channels opened by server to run an interactive shell on a client
+	ChannelTypeShell = "shell"
+	// RequestTypeWindowChange and RequestTypeExitStatus are sent on shell channels, named and encoded as in RFC 4254
+	RequestTypeWindowChange = "window-change"
+	RequestTypeExitStatus   = "exit-status"
+
+	// ChannelTypeSFTP is the type of channels opened by server to browse and download files from a client
+	ChannelTypeSFTP = "sftp"
+
+	// ChannelTypeReverseTunnel is the type of channels opened by clients for each connection accepted by a reverse tunnel,
+	// the extra data is the id of the reverse tunnel
+	ChannelTypeReverseTunnel = "reverse_tunnel"
 )
 
 type CheckPortRequest struct {
@@ -50,6 +68,12 @@ type RunCmdResponse struct {
 	StartedAt time.Time
 }
 
+// CancelCmdRequest asks a client to kill the process tree of a running job.
+type CancelCmdRequest struct {
+	JID         string `json:"jid"`
+	CancelledBy string `json:"cancelled_by"`
+}
+
 type CheckTunnelAllowedRequest struct {
 	Remote string
 }
@@ -57,3 +81,48 @@ type CheckTunnelAllowedRequest struct {
 type CheckTunnelAllowedResponse struct {
 	IsAllowed bool
 }
+
+// StartReverseTunnelRequest asks a client to listen on Local and forward all connections to the server.
+type StartReverseTunnelRequest struct {
+	ID    string
+	Local string
+}
+
+// StopReverseTunnelRequest asks a client to stop listening for a reverse tunnel.
+type StopReverseTunnelRequest struct {
+	ID string
+}
+
+// ClientUpdateRequest asks a client to replace its binary by the given version, downloaded from the server.
+type ClientUpdateRequest struct {
+	RolloutID      string
+	Version        string
+	SourceFilePath string
+	Size           int64
+	SHA256         string
+	// Signature is the ed25519 signature of the binary
+	Signature []byte
+	// RollbackTimeout is the time the new version has to reconnect to the server, otherwise the client rolls back.
+	RollbackTimeout time.Duration
+}
+
+// ShellRequest is sent as extra data when opening a shell channel.
+type ShellRequest struct {
+	// Shell is the executable to run, the client default is used if empty.
+	Shell string
+	Cols  uint32
+	Rows  uint32
+}
+
+// WindowChangeRequest is the payload of window-change requests, it has to be encoded with ssh.Marshal.
+type WindowChangeRequest struct {
+	Cols     uint32
+	Rows     uint32
+	WidthPx  uint32
+	HeightPx uint32
+}
+
+// ExitStatusRequest is the payload of exit-status requests, it has to be encoded with ssh.Marshal.
+type ExitStatusRequest struct {
+	Status uint32
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/gob"
 	"io"
 	"net"
+	"sync"
 )
 
 type UDPChannel struct {
-	enc *gob.Encoder
-	dec *gob.Decoder
+	// datagrams of concurrent sessions are encoded one after another
+	encMtx sync.Mutex
+	enc    *gob.Encoder
+	dec    *gob.Decoder
 }
 
 type UDPMessage struct {
@@ -24,6 +27,9 @@ func NewUDPChannel(rw io.ReadWriter) *UDPChannel {
 }
 
 func (c *UDPChannel) Encode(addr *net.UDPAddr, data []byte) error {
+	c.encMtx.Lock()
+	defer c.encMtx.Unlock()
+
 	return c.enc.Encode(UDPMessage{
 		Addr: addr,
 		Data: data,
@@ -0,0 +1,165 @@
+package comm
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// UDPSessionIdleTimeout is the time after which a session without datagrams in either direction expires
+	UDPSessionIdleTimeout = 30 * time.Second
+	// UDPMaxSessions limits the concurrent peers of a UDP tunnel, each session holds a socket on the client
+	UDPMaxSessions = 1024
+)
+
+var ErrUDPSessionLimit = errors.New("max number of UDP sessions reached")
+
+// UDPSession maps a peer of a UDP tunnel identified by its source address.
+type UDPSession struct {
+	Addr *net.UDPAddr
+	// Conn is the socket of the session on the client, each peer gets its own source port towards the target
+	Conn net.Conn
+
+	lastActive time.Time
+}
+
+// UDPSessions is the session table of a UDP tunnel, so that datagrams of many concurrent peers are relayed to the right
+// peer. Sessions expire after being idle for the idle timeout.
+type UDPSessions struct {
+	idleTimeout time.Duration
+	maxSessions int
+
+	mtx      sync.Mutex
+	sessions map[string]*UDPSession
+	total    int64
+}
+
+// NewUDPSessions returns an empty session table, maxSessions 0 means unlimited.
+func NewUDPSessions(idleTimeout time.Duration, maxSessions int) *UDPSessions {
+	return &UDPSessions{
+		idleTimeout: idleTimeout,
+		maxSessions: maxSessions,
+		sessions:    make(map[string]*UDPSession),
+	}
+}
+
+// Open returns the session of addr and marks it active. If there is none, a new session is created and created is
+// true. dial creates the socket of a new session, it's called with the table locked and may be nil.
+func (s *UDPSessions) Open(addr *net.UDPAddr, dial func() (net.Conn, error)) (session *UDPSession, created bool, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	key := addr.String()
+	if session, ok := s.sessions[key]; ok {
+		session.lastActive = time.Now()
+		return session, false, nil
+	}
+
+	if s.maxSessions > 0 && len(s.sessions) >= s.maxSessions {
+		return nil, false, ErrUDPSessionLimit
+	}
+
+	session = &UDPSession{
+		Addr:       addr,
+		lastActive: time.Now(),
+	}
+	if dial != nil {
+		session.Conn, err = dial()
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	s.sessions[key] = session
+	s.total++
+
+	return session, true, nil
+}
+
+// Touch marks the session of addr active. It returns false if there is no session, e.g. it has expired.
+func (s *UDPSessions) Touch(addr *net.UDPAddr) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	session, ok := s.sessions[addr.String()]
+	if !ok {
+		return false
+	}
+	session.lastActive = time.Now()
+	return true
+}
+
+// ExpireIdle removes and closes the given session if it has been idle for the idle timeout.
+func (s *UDPSessions) ExpireIdle(session *UDPSession, now time.Time) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if now.Sub(session.lastActive) < s.idleTimeout {
+		return false
+	}
+	s.remove(session)
+	return true
+}
+
+// Expire removes and closes all sessions that have been idle for the idle timeout, the expired sessions are returned.
+func (s *UDPSessions) Expire(now time.Time) []*UDPSession {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var expired []*UDPSession
+	for _, session := range s.sessions {
+		if now.Sub(session.lastActive) >= s.idleTimeout {
+			expired = append(expired, session)
+			s.remove(session)
+		}
+	}
+	return expired
+}
+
+// Remove removes and closes the session, a newer session of the same peer is kept.
+func (s *UDPSessions) Remove(session *UDPSession) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.remove(session)
+}
+
+// CloseAll removes and closes all sessions and returns their number.
+func (s *UDPSessions) CloseAll() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	n := len(s.sessions)
+	for _, session := range s.sessions {
+		s.remove(session)
+	}
+	return n
+}
+
+// Active returns the number of open sessions.
+func (s *UDPSessions) Active() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return len(s.sessions)
+}
+
+// Total returns the number of sessions created since the table was created.
+func (s *UDPSessions) Total() int64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.total
+}
+
+func (s *UDPSessions) remove(session *UDPSession) {
+	key := session.Addr.String()
+	if s.sessions[key] != session {
+		return
+	}
+	delete(s.sessions, key)
+	if session.Conn != nil {
+		session.Conn.Close()
+	}
+}
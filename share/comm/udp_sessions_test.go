@@ -0,0 +1,62 @@
+package comm
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUDPSessions(t *testing.T) {
+	sessions := NewUDPSessions(time.Minute, 2)
+	addr1, err := net.ResolveUDPAddr("udp", "127.0.0.1:12345")
+	require.NoError(t, err)
+	addr2, err := net.ResolveUDPAddr("udp", "127.0.0.1:23456")
+	require.NoError(t, err)
+	addr3, err := net.ResolveUDPAddr("udp", "127.0.0.1:34567")
+	require.NoError(t, err)
+
+	var dialed []net.Conn
+	dial := func() (net.Conn, error) {
+		conn, _ := net.Pipe()
+		dialed = append(dialed, conn)
+		return conn, nil
+	}
+
+	session1, created, err := sessions.Open(addr1, dial)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, addr1, session1.Addr)
+
+	again, created, err := sessions.Open(addr1, dial)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Same(t, session1, again)
+	assert.Len(t, dialed, 1)
+
+	_, _, err = sessions.Open(addr2, dial)
+	require.NoError(t, err)
+	_, _, err = sessions.Open(addr3, dial)
+	assert.Equal(t, ErrUDPSessionLimit, err)
+
+	assert.True(t, sessions.Touch(addr2))
+	assert.False(t, sessions.Touch(addr3))
+	assert.Equal(t, 2, sessions.Active())
+
+	assert.False(t, sessions.ExpireIdle(session1, time.Now()))
+	expired := sessions.Expire(time.Now().Add(time.Minute))
+	assert.Len(t, expired, 2)
+	assert.Equal(t, 0, sessions.Active())
+	assert.Equal(t, int64(2), sessions.Total())
+	_, err = dialed[0].Write([]byte("closed"))
+	assert.Error(t, err, "the socket of an expired session is closed")
+
+	newSession, _, err := sessions.Open(addr1, nil)
+	require.NoError(t, err)
+	sessions.Remove(session1)
+	assert.Equal(t, 1, sessions.Active(), "a newer session of the same peer is kept")
+	assert.True(t, sessions.ExpireIdle(newSession, time.Now().Add(time.Minute)))
+	assert.Equal(t, 0, sessions.CloseAll())
+}
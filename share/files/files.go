@@ -27,12 +27,14 @@ type FileAPI interface {
 	Open(file string) (io.ReadWriteCloser, error)
 	Exist(path string) (bool, error)
 	CreateFile(path string, sourceReader io.Reader) (writtenBytes int64, err error)
+	AppendFile(path string, sourceReader io.Reader) (writtenBytes int64, err error)
 	ChangeOwner(path, owner, group string) error
 	ChangeMode(path string, targetMode os.FileMode) error
 	CreateDirIfNotExists(path string, mode os.FileMode) (wasCreated bool, err error)
 	Remove(name string) error
 	Rename(oldPath, newPath string) error
 	GetFileMode(file string) (os.FileMode, error)
+	GetFileSize(file string) (int64, error)
 	GetFileOwnerAndGroup(file string) (uid, gid uint32, err error)
 }
 
@@ -125,6 +127,15 @@ func (f *FileSystem) GetFileMode(file string) (os.FileMode, error) {
 	return fileInfo.Mode(), nil
 }
 
+func (f *FileSystem) GetFileSize(file string) (int64, error) {
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		return 0, err
+	}
+
+	return fileInfo.Size(), nil
+}
+
 func (f *FileSystem) GetFileOwnerAndGroup(file string) (uid, gid uint32, err error) {
 	return GetFileUIDAndGID(file)
 }
@@ -199,6 +210,18 @@ func (f *FileSystem) CreateFile(path string, sourceReader io.Reader) (writtenByt
 	return copiedBytes, nil
 }
 
+// AppendFile appends the data read from sourceReader to the file, which is created if it doesn't exist.
+// The number of bytes written is returned on errors as well.
+func (f *FileSystem) AppendFile(path string, sourceReader io.Reader) (writtenBytes int64, err error) {
+	targetFile, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, DefaultMode)
+	if err != nil {
+		return 0, err
+	}
+	defer targetFile.Close()
+
+	return io.Copy(targetFile, sourceReader)
+}
+
 func (f *FileSystem) Remove(name string) error {
 	return os.Remove(name)
 }
@@ -34,6 +34,8 @@ type UploadedFile struct {
 	ForceWrite           bool
 	Sync                 bool
 	Md5Checksum          []byte
+	// Size is sent by servers supporting to resume interrupted copies of the source file
+	Size int64 `json:",omitempty"`
 }
 
 func (uf UploadedFile) Validate() error {
@@ -139,3 +141,11 @@ type UploadResponseShort struct {
 	Filepath  string `json:"filepath"`
 	SizeBytes int64  `json:"size"`
 }
+
+// UploadProgress is sent by clients while copying an uploaded file
+type UploadProgress struct {
+	ID          string `json:"uuid"`
+	Filepath    string `json:"filepath"`
+	SizeBytes   int64  `json:"size"`
+	CopiedBytes int64  `json:"bytes_copied"`
+}
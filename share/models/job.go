@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,9 +12,17 @@ const (
 	JobStatusRunning    = "running"
 	JobStatusFailed     = "failed"
 	JobStatusUnknown    = "unknown"
+	JobStatusCancelled  = "cancelled"
 
 	ChannelStdout = "stdout"
 	ChannelStderr = "stderr"
+
+	MultiJobStatusAwaitingApproval = "awaiting_approval"
+	MultiJobStatusRejected         = "rejected"
+	MultiJobStatusRunning          = "running"
+	MultiJobStatusAborted          = "aborted"
+	MultiJobStatusFinished         = "finished"
+	MultiJobStatusCancelled        = "cancelled"
 )
 
 type Job struct {
@@ -44,6 +53,21 @@ type JobResult struct {
 	Summary string `json:"summary"`
 }
 
+// JobOutputChunk is a part of the stdout or stderr streamed by a job. The offset counts the bytes of both streams
+// received before the chunk.
+type JobOutputChunk struct {
+	JID       string    `json:"jid"`
+	Offset    int64     `json:"offset"`
+	Stream    string    `json:"stream"`
+	Data      string    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// End returns the offset following the chunk.
+func (c *JobOutputChunk) End() int64 {
+	return c.Offset + int64(len(c.Data))
+}
+
 type JobClientTags struct {
 	Tags     []string `json:"tags"`
 	Operator string   `json:"operator"`
@@ -64,6 +88,36 @@ type MultiJob struct {
 	Jobs        []*Job         `json:"jobs"`
 	IsSudo      bool           `json:"is_sudo"`
 	IsScript    bool           `json:"is_script"`
+	Rollout     *JobRollout    `json:"rollout"`
+	// Status is empty for multi-client jobs created before statuses were introduced
+	Status          string          `json:"status"`
+	Error           string          `json:"error"`
+	RequireApproval bool            `json:"require_approval"`
+	ApprovedBy      string          `json:"approved_by"`
+	RejectedBy      string          `json:"rejected_by"`
+	CancelledBy     string          `json:"cancelled_by"`
+	DecidedAt       *time.Time      `json:"decided_at"`
+	Waves           []*MultiJobWave `json:"waves"`
+}
+
+// JobRollout executes a multi-client job in waves of clients instead of all clients at once.
+type JobRollout struct {
+	// BatchSize is the number of clients per wave
+	BatchSize int `json:"batch_size"`
+	// BatchPercent is the percentage of clients per wave, used if BatchSize is not set
+	BatchPercent int `json:"batch_percent"`
+	// PauseSec is the time to wait between waves
+	PauseSec int `json:"pause_sec"`
+	// SuccessThreshold is the minimum percentage of successful jobs in a wave to continue with the next wave
+	SuccessThreshold int `json:"success_threshold"`
+}
+
+type MultiJobWave struct {
+	ClientIDs  []string   `json:"client_ids"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
 }
 
 type MultiJobSummary struct {
@@ -88,6 +142,42 @@ func (j Job) LogPrefix() string {
 	return r
 }
 
+func (r *JobRollout) Validate() error {
+	if r.BatchSize < 0 {
+		return errors.New("batch_size must not be negative")
+	}
+	if r.BatchPercent < 0 || r.BatchPercent > 100 {
+		return errors.New("batch_percent must be between 0 and 100")
+	}
+	if r.BatchSize > 0 && r.BatchPercent > 0 {
+		return errors.New("batch_size and batch_percent cannot be used together")
+	}
+	if r.PauseSec < 0 {
+		return errors.New("pause_sec must not be negative")
+	}
+	if r.SuccessThreshold < 0 || r.SuccessThreshold > 100 {
+		return errors.New("success_threshold must be between 0 and 100")
+	}
+	return nil
+}
+
+// WaveSize returns the number of clients per wave for a job on total clients.
+func (r *JobRollout) WaveSize(total int) int {
+	size := total
+	switch {
+	case r == nil:
+	case r.BatchSize > 0:
+		size = r.BatchSize
+	case r.BatchPercent > 0:
+		// round up to have at least one client per wave
+		size = (total*r.BatchPercent + 99) / 100
+	}
+	if size <= 0 || size > total {
+		size = total
+	}
+	return size
+}
+
 // TODO: add some unit tests. not high priority but good to get done.
 func (jct *JobClientTags) String() string {
 	var str string
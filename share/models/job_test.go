@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobRolloutWaveSize(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rollout *JobRollout
+		total   int
+		want    int
+	}{
+		{name: "no rollout", rollout: nil, total: 5, want: 5},
+		{name: "no batch", rollout: &JobRollout{PauseSec: 10}, total: 5, want: 5},
+		{name: "batch size", rollout: &JobRollout{BatchSize: 2}, total: 5, want: 2},
+		{name: "batch size exceeds total", rollout: &JobRollout{BatchSize: 10}, total: 5, want: 5},
+		{name: "batch percent rounded up", rollout: &JobRollout{BatchPercent: 10}, total: 5, want: 1},
+		{name: "batch percent", rollout: &JobRollout{BatchPercent: 50}, total: 10, want: 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.rollout.WaveSize(tc.total))
+		})
+	}
+}
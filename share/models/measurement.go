@@ -20,3 +20,13 @@ type Measurement struct {
 	NetLan             *NetBytes `json:"net_lan" db:"net_lan"`
 	NetWan             *NetBytes `json:"net_wan" db:"net_wan"`
 }
+
+// TunnelUsage is the traffic of a tunnel since the previous sample.
+type TunnelUsage struct {
+	ClientID    string    `json:"client_id" db:"client_id"`
+	TunnelID    string    `json:"tunnel_id" db:"tunnel_id"`
+	Timestamp   time.Time `json:"timestamp" db:"timestamp"`
+	BytesIn     int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut    int64     `json:"bytes_out" db:"bytes_out"`
+	Connections int64     `json:"connections" db:"connections"`
+}
@@ -23,6 +23,9 @@ import (
 //     local  192.168.0.1:3000
 //     remote google.com:80
 //   .../udp ->  udp protocol
+//   3000/socks ->
+//     local  0.0.0.0:3000
+//     remote chosen per connection by the SOCKS5 client
 
 const (
 	ZeroHost       = "0.0.0.0"
@@ -30,9 +33,11 @@ const (
 	ProtocolTCP    = "tcp"
 	ProtocolUDP    = "udp"
 	ProtocolTCPUDP = "tcp+udp"
+	// ProtocolSOCKS is a TCP tunnel speaking SOCKS5, the destination of each connection is requested by the peer
+	ProtocolSOCKS = "socks"
 )
 
-var protocolRe = regexp.MustCompile(`(.*)\/(tcp|udp|tcp\+udp)$`)
+var protocolRe = regexp.MustCompile(`(.*)\/(tcp|udp|tcp\+udp|socks)$`)
 
 // TODO(m-terel): Remote should be only used for parsing command args and URL query params. Current Remote is kind of a Tunnel model. Refactor to use separate models for representation and business logic.
 type Remote struct {
@@ -53,6 +58,30 @@ type Remote struct {
 	AuthUser           string        `json:"auth_user"`
 	AuthPassword       string        `json:"auth_password"`
 	TunnelURL          string        `json:"tunnel_url"`
+	MaxBandwidth       int64         `json:"max_bandwidth"` // bytes per second in each direction, 0 means unlimited
+	TokenAuth          bool          `json:"token_auth"`    // connections have to start with a one-time token line
+	// HealthCheckInterval enables periodic probes of the tunnel target, 0 means disabled
+	HealthCheckInterval time.Duration `json:"health_check_interval"`
+	// HealthCheckRestart re-establishes the tunnel after consecutive failed probes
+	HealthCheckRestart bool `json:"health_check_restart"`
+	// SSHCredentials are used by the tunnel proxy to log in to ssh tunnels, they are never returned by the API but
+	// stored with the client, see clients.clientDetails
+	SSHCredentials *SSHCredentials `json:"-"`
+}
+
+// SSHCredentials are preset for the login of the tunnel proxy of an ssh tunnel, empty values are asked for on login.
+type SSHCredentials struct {
+	Username string `json:"username"`
+	// VaultID refers to the vault value holding the password or private key, the secret itself is never stored with
+	// the tunnel but read from the vault on login
+	VaultID int `json:"vault_id"`
+	// HostKey pins the host key of the ssh server in authorized_keys format, if empty the first key seen is trusted
+	HostKey string `json:"host_key"`
+}
+
+// HasSecret returns true if a password or private key is preset.
+func (c *SSHCredentials) HasSecret() bool {
+	return c != nil && c.VaultID != 0
 }
 
 func NewRemote(s string) (*Remote, error) {
@@ -63,6 +92,10 @@ func NewRemote(s string) (*Remote, error) {
 		protocol = matches[2]
 	}
 
+	if protocol == ProtocolSOCKS {
+		return newSOCKSRemote(s)
+	}
+
 	parts := strings.Split(s, ":")
 	if len(parts) <= 0 || len(parts) >= 5 {
 		return nil, errors.New("Invalid remote")
@@ -102,6 +135,29 @@ func NewRemote(s string) (*Remote, error) {
 	return r, nil
 }
 
+// newSOCKSRemote parses the optional local address of a SOCKS5 tunnel, it has no fixed remote.
+func newSOCKSRemote(s string) (*Remote, error) {
+	r := &Remote{
+		Protocol: ProtocolSOCKS,
+	}
+	if s == "" {
+		return r, nil
+	}
+
+	parts := strings.Split(s, ":")
+	switch {
+	case len(parts) == 1 && isPort(parts[0]):
+		r.LocalHost = ZeroHost
+		r.LocalPort = parts[0]
+	case len(parts) == 2 && isPort(parts[1]) && isHost(parts[0]):
+		r.LocalHost = parts[0]
+		r.LocalPort = parts[1]
+	default:
+		return nil, errors.New("Invalid local address of socks tunnel")
+	}
+	return r, nil
+}
+
 var isPortRegExp = regexp.MustCompile(`^\d+$`)
 
 func isPort(s string) bool {
@@ -116,6 +172,9 @@ func isHost(s string) bool {
 // implement Stringer
 func (r Remote) String() string {
 	s := r.LocalHost + ":" + r.LocalPort + ":" + r.Remote()
+	if r.Protocol == ProtocolSOCKS {
+		s = r.LocalHost + ":" + r.LocalPort
+	}
 
 	if r.Protocol != ProtocolTCP {
 		s += "/" + r.Protocol
@@ -152,6 +211,14 @@ func (r *Remote) IsProtocol(other string) bool {
 	return false
 }
 
+// ListenProtocol returns the protocol of the listener on the server side.
+func (r *Remote) ListenProtocol() string {
+	if r.Protocol == ProtocolSOCKS {
+		return ProtocolTCP
+	}
+	return r.Protocol
+}
+
 func (r *Remote) EqualACL(acl *string) bool {
 	if r.ACL != nil && acl != nil {
 		return *r.ACL == *acl
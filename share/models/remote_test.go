@@ -101,6 +101,22 @@ func TestDecodeRemote(t *testing.T) {
 			WantRemoteHost: "google.com",
 			WantRemotePort: "80",
 		},
+		{
+			Input:        "/socks",
+			WantProtocol: ProtocolSOCKS,
+		},
+		{
+			Input:         "3000/socks",
+			WantProtocol:  ProtocolSOCKS,
+			WantLocalHost: ZeroHost,
+			WantLocalPort: "3000",
+		},
+		{
+			Input:         "127.0.0.1:3000/socks",
+			WantProtocol:  ProtocolSOCKS,
+			WantLocalHost: LocalHost,
+			WantLocalPort: "3000",
+		},
 	}
 
 	for _, tc := range testCases {
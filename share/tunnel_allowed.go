@@ -1,4 +1,4 @@
-package chclient
+package chshare
 
 import (
 	"net"
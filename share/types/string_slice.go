@@ -10,11 +10,17 @@ import (
 type StringSlice []string
 
 func (s *StringSlice) Scan(value interface{}) error {
-	valueStr, ok := value.(string)
-	if !ok {
+	var data []byte
+	switch v := value.(type) {
+	case string:
+		data = []byte(v)
+	case []uint8:
+		// MySQL returns text columns as bytes
+		data = v
+	default:
 		return fmt.Errorf("expected to have string, got %T", value)
 	}
-	err := json.Unmarshal([]byte(valueStr), s)
+	err := json.Unmarshal(data, s)
 	if err != nil {
 		return fmt.Errorf("failed to decode string slice: %v", err)
 	}
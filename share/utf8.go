@@ -0,0 +1,17 @@
+package chshare
+
+import "unicode/utf8"
+
+// IncompleteUTF8Suffix returns the length of a UTF-8 character at the end of data that is missing bytes,
+// e.g. because data was read from a stream and the character continues with the next read.
+func IncompleteUTF8Suffix(data []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if utf8.FullRune(data[len(data)-i:]) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package chshare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncompleteUTF8Suffix(t *testing.T) {
+	euro := []byte("€")
+	testCases := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "empty", data: nil, want: 0},
+		{name: "ascii", data: []byte("abc"), want: 0},
+		{name: "complete", data: append([]byte("a"), euro...), want: 0},
+		{name: "first byte", data: append([]byte("a"), euro[:1]...), want: 1},
+		{name: "two bytes", data: append([]byte("a"), euro[:2]...), want: 2},
+		{name: "invalid byte", data: []byte{'a', 0xff}, want: 0},
+		{name: "continuation bytes only", data: euro[1:], want: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IncompleteUTF8Suffix(tc.data))
+		})
+	}
+}